@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// AnonymizeTemplate rewrites a template so it can be shared in a bug report without leaking
+// configuration: every word of variable names, string literals, template names and text is
+// replaced by a hash, while actions, function names, numbers, punctuation and whitespace stay
+// A word hashes the same everywhere, so .db_host and getv "/db_host" still refer to one variable;
+// the salt makes the hashes of short names impractical to reverse by guessing
+// Comments are dropped and trim markers are applied
+func (p *Parser) AnonymizeTemplate(fileName, fileContent, salt string) (string, error) {
+	tmpl, err := p.parseTemplate(context.Background(), fileName, fileContent)
+	if err != nil {
+		return "", err
+	}
+
+	a := &anonymizer{salt: salt, words: make(map[string]string)}
+	var b strings.Builder
+	if tmpl.Tree != nil {
+		a.node(tmpl.Tree.Root, 0)
+		b.WriteString(tmpl.Tree.Root.String())
+	}
+
+	// Associated templates come from define blocks, written out again after the main template
+	var defined []string
+	for _, t := range tmpl.Templates() {
+		if t.Name() != tmpl.Name() && t.Tree != nil {
+			defined = append(defined, t.Name())
+		}
+	}
+	sort.Strings(defined)
+	for _, name := range defined {
+		root := tmpl.Lookup(name).Tree.Root
+		a.node(root, 0)
+		b.WriteString("{{define " + strconv.Quote(a.text(name)) + "}}" + root.String() + "{{end}}")
+	}
+	return b.String(), nil
+}
+
+// anonymizer replaces the words of a parse tree in place, memoizing the hash of each word
+type anonymizer struct {
+	salt  string
+	words map[string]string
+}
+
+// word returns the hash of a word: a letter followed by hex digits, so it stays a valid identifier
+func (a *anonymizer) word(w string) string {
+	if hashed, ok := a.words[w]; ok {
+		return hashed
+	}
+	sum := sha256.Sum256([]byte(a.salt + "\x00" + w))
+	hashed := "x" + hex.EncodeToString(sum[:])[:7]
+	a.words[w] = hashed
+	return hashed
+}
+
+// text hashes every run of letters, digits and underscores in s, keeping everything else
+func (a *anonymizer) text(s string) string {
+	var b strings.Builder
+	start := -1
+	for i, r := range s {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			b.WriteString(a.word(s[start:i]))
+			start = -1
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		b.WriteString(a.word(s[start:]))
+	}
+	return b.String()
+}
+
+// idents hashes each segment of a field or variable path
+func (a *anonymizer) idents(idents []string) {
+	for i, ident := range idents {
+		idents[i] = a.text(ident)
+	}
+}
+
+// node anonymizes the words under node
+func (a *anonymizer) node(node parse.Node, depth int) {
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		switch node := node.(type) {
+		case *parse.TextNode:
+			node.Text = []byte(a.text(string(node.Text)))
+		case *parse.StringNode:
+			node.Text = a.text(node.Text)
+			node.Quoted = strconv.Quote(node.Text)
+		case *parse.FieldNode:
+			a.idents(node.Ident)
+		case *parse.VariableNode:
+			// The first segment is the variable itself, $ or $name
+			a.idents(node.Ident[1:])
+			if node.Ident[0] != "$" {
+				node.Ident[0] = "$" + a.text(strings.TrimPrefix(node.Ident[0], "$"))
+			}
+		case *parse.ChainNode:
+			a.idents(node.Field)
+		case *parse.TemplateNode:
+			node.Name = a.text(node.Name)
+		}
+		return true
+	})
+}
+
+// isWordRune reports whether r belongs to a word that anonymization hashes
+func isWordRune(r rune) bool {
+	return r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 0x7f
+}
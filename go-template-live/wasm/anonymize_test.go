@@ -0,0 +1,55 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestAnonymizeTemplate tests that anonymization hides names and text but keeps the structure
+func TestAnonymizeTemplate(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{/* owned by payments */}}server acme-db.internal:{{.Database.Port}}
+{{- range $i, $host := .Replicas}}
+replica {{$i}} = {{printf "%s:%d" $host 5432}}
+{{- end}}
+{{if eq .Mode "secret-mode"}}{{template "footer" .Database}}{{end}}
+{{define "footer"}}# {{.Port}} managed by acme{{end}}`
+
+	anonymized, err := parser.AnonymizeTemplate("test.tmpl", template, "salt")
+	if err != nil {
+		t.Fatalf("AnonymizeTemplate() error = %v", err)
+	}
+	for _, secret := range []string{"acme", "payments", "Database", "Replicas", "host", "secret", "footer", "replica", "Mode"} {
+		if strings.Contains(anonymized, secret) {
+			t.Errorf("AnonymizeTemplate() = %q, contains %q", anonymized, secret)
+		}
+	}
+	for _, kept := range []string{"range", "printf", "eq", "5432", "{{define", "{{template"} {
+		if !strings.Contains(anonymized, kept) {
+			t.Errorf("AnonymizeTemplate() = %q, does not contain %q", anonymized, kept)
+		}
+	}
+
+	original, err := parser.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+	rewritten, err := parser.ExtractVariablesAggregated("test.tmpl", anonymized)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() of anonymized template error = %v", err)
+	}
+	if len(rewritten) != len(original) {
+		t.Errorf("anonymized variables = %d, want %d", len(rewritten), len(original))
+	}
+
+	again, err := parser.AnonymizeTemplate("test.tmpl", template, "salt")
+	if err != nil || again != anonymized {
+		t.Errorf("AnonymizeTemplate() is not deterministic for the same salt")
+	}
+	if salted, _ := parser.AnonymizeTemplate("test.tmpl", template, "other"); salted == anonymized {
+		t.Errorf("AnonymizeTemplate() ignores the salt")
+	}
+}
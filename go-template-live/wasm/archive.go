@@ -0,0 +1,72 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// archiveTime is the modification time of every archive entry, so the same files always give
+// the same archive
+var archiveTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ArchiveFile is one rendered file of an archive
+type ArchiveFile struct {
+	// Path is the destination of the file; absolute destinations such as /etc/nginx/nginx.conf
+	// are stored relative to the archive root
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// BuildArchive packages rendered files as a zip archive, for downloading a project or matrix
+// render at once. Entries keep the given order; paths are cleaned, and a path that is empty,
+// leaves the archive root (..) or is given twice fails the archive
+func BuildArchive(files []ArchiveFile) ([]byte, error) {
+	var b bytes.Buffer
+	archive := zip.NewWriter(&b)
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		name, err := archivePath(file.Path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("archive path %s is given twice", name)
+		}
+		seen[name] = true
+
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveTime})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(file.Content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// archivePath returns the archive entry name of a destination path, with Windows separators
+// and drive letters normalized
+func archivePath(p string) (string, error) {
+	name := strings.ReplaceAll(p, `\`, "/")
+	if len(name) >= 2 && name[1] == ':' {
+		name = name[2:]
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "", fmt.Errorf("invalid archive path %q", p)
+	}
+	for _, segment := range strings.Split(strings.ReplaceAll(p, `\`, "/"), "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("archive path %q leaves the archive root", p)
+		}
+	}
+	return name, nil
+}
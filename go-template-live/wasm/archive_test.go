@@ -0,0 +1,77 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildArchive(t *testing.T) {
+	data, err := BuildArchive([]ArchiveFile{
+		{Path: "/etc/nginx/nginx.conf", Content: "worker_processes 4;\n"},
+		{Path: `C:\app\config.yaml`, Content: "port: 80\n"},
+		{Path: "./out//readme.txt", Content: ""},
+	})
+	if err != nil {
+		t.Fatalf("BuildArchive() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	contents := map[string]string{}
+	var names []string
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		names = append(names, file.Name)
+		contents[file.Name] = string(content)
+	}
+	if expected := []string{"etc/nginx/nginx.conf", "app/config.yaml", "out/readme.txt"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("BuildArchive() entries = %v, want %v", names, expected)
+	}
+	if contents["app/config.yaml"] != "port: 80\n" {
+		t.Errorf("BuildArchive() app/config.yaml = %q", contents["app/config.yaml"])
+	}
+
+	again, _ := BuildArchive([]ArchiveFile{
+		{Path: "/etc/nginx/nginx.conf", Content: "worker_processes 4;\n"},
+		{Path: `C:\app\config.yaml`, Content: "port: 80\n"},
+		{Path: "./out//readme.txt", Content: ""},
+	})
+	if !bytes.Equal(data, again) {
+		t.Error("BuildArchive() is not reproducible")
+	}
+}
+
+func TestBuildArchive_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []ArchiveFile
+		expected string
+	}{
+		{name: "empty path", files: []ArchiveFile{{Path: "/"}}, expected: "invalid archive path"},
+		{name: "parent", files: []ArchiveFile{{Path: "../secret"}}, expected: "leaves the archive root"},
+		{name: "duplicate", files: []ArchiveFile{{Path: "/a"}, {Path: "a"}}, expected: "archive path a is given twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildArchive(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("BuildArchive() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"text/template/parse"
+)
+
+// SensitiveReference is one use of a sensitive variable
+type SensitiveReference struct {
+	Name string `json:"name"`
+	// Function is the innermost function the value is passed or piped to, empty for a bare use
+	// such as {{.Password}}
+	Function string   `json:"function,omitempty"`
+	Position Position `json:"position"`
+}
+
+// AuditSecrets lists every use of the variables read with secret, in document order
+// A variable is sensitive everywhere once any occurrence is, so a secret also read with getv
+// or as a field shows up at each of those places
+// The playground's review panel calls it through auditSecrets; no `audit` command is built
+func (p *Parser) AuditSecrets(fileName, fileContent string) ([]SensitiveReference, error) {
+	variables, err := p.ExtractVariablesWithPositions(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	references := []SensitiveReference{}
+	sensitive := make(map[string]bool)
+	for _, v := range variables {
+		if v.Sensitive {
+			sensitive[v.Name] = true
+		}
+	}
+	if len(sensitive) == 0 {
+		return references, nil
+	}
+
+	tmpl, err := p.parseTemplate(context.Background(), fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	functions := make(map[int]string)
+	p.collectFunctions(tmpl.Tree.Root, functions, 0)
+
+	for _, v := range variables {
+		if !sensitive[v.Name] || v.Position == nil {
+			continue
+		}
+		references = append(references, SensitiveReference{
+			Name:     v.Name,
+			Function: functions[v.Position.Offset],
+			Position: *v.Position,
+		})
+	}
+	return references, nil
+}
+
+// collectFunctions records, per occurrence offset, the innermost function the occurrence is
+// passed to as an argument or piped into
+func (p *Parser) collectFunctions(node parse.Node, functions map[int]string, depth int) {
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		pipe, ok := node.(*parse.PipeNode)
+		if !ok {
+			return true
+		}
+		for i, cmd := range pipe.Cmds {
+			ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+			if !ok {
+				continue
+			}
+			// Outer calls are recorded first so nested calls in the arguments override them
+			p.recordFunction(cmd, ident.Ident, functions, depth+1)
+			if i > 0 {
+				if _, called := pipe.Cmds[i-1].Args[0].(*parse.IdentifierNode); !called {
+					p.recordFunction(pipe.Cmds[i-1], ident.Ident, functions, depth+1)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// recordFunction records function for every occurrence under node
+func (p *Parser) recordFunction(node parse.Node, function string, functions map[int]string, depth int) {
+	buf := getScratchVariables()
+	defer putScratchVariables(buf)
+
+	occurrences, err := p.appendFieldsWithDefaults((*buf)[:0], node, depth)
+	if err != nil {
+		return
+	}
+	*buf = occurrences
+	for _, v := range occurrences {
+		if v.Position != nil {
+			functions[v.Position.Offset] = function
+		}
+	}
+}
@@ -0,0 +1,146 @@
+//go:build !js
+// +build !js
+
+// This file contains concurrent rendering of many template resources for directory mode
+// Tag: !js (WASM runs single-threaded, so a worker pool brings no benefit in the browser)
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TemplateResource is one template of a batch render
+type TemplateResource struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Dest    string `json:"dest,omitempty"`
+}
+
+// ResourceResult is the render outcome of one template resource
+type ResourceResult struct {
+	Name        string   `json:"name"`
+	Dest        string   `json:"dest,omitempty"`
+	Output      string   `json:"output"`
+	MissingKeys []string `json:"missingKeys,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	// Status and Hash are set by RenderTracker.Track in daemon mode
+	Status string `json:"status,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// BatchError aggregates the failures of a batch render
+type BatchError struct {
+	Total    int
+	Failures map[string]string
+}
+
+func (e *BatchError) Error() string {
+	names := make([]string, 0, len(e.Failures))
+	for name := range e.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, 0, len(names))
+	for _, name := range names {
+		messages = append(messages, name+": "+e.Failures[name])
+	}
+	return fmt.Sprintf("%d of %d templates failed: %s", len(e.Failures), e.Total, strings.Join(messages, "; "))
+}
+
+// RenderBatch renders independent resources concurrently with at most workers goroutines
+// (workers <= 0 uses one per CPU). Results keep the input order; every resource is rendered
+// even when others fail, and failures are reported together as a *BatchError
+// Each resource renders as it would alone, in a render environment of its own: deterministic
+// renders reseed the random source and value resolution starts over, with its own lookup
+// budget, for every resource
+func (r *Renderer) RenderBatch(resources []TemplateResource, variables map[string]interface{}, opts RenderOptions, workers int) ([]ResourceResult, error) {
+	if _, err := r.prepare(opts); err != nil {
+		return nil, err
+	}
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(resources) {
+		workers = len(resources)
+	}
+
+	results := make([]ResourceResult, len(resources))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = r.renderResource(resources[i], variables, opts)
+			}
+		}()
+	}
+	for i := range resources {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	batchErr := &BatchError{Total: len(resources), Failures: make(map[string]string)}
+	for _, result := range results {
+		if result.Error != "" {
+			batchErr.Failures[result.Name] = result.Error
+		}
+	}
+	if len(batchErr.Failures) > 0 {
+		return results, batchErr
+	}
+	return results, nil
+}
+
+// renderResource renders one resource in a fresh render environment, recording errors in the
+// result
+func (r *Renderer) renderResource(resource TemplateResource, variables map[string]interface{}, opts RenderOptions) ResourceResult {
+	result := ResourceResult{Name: resource.Name, Dest: resource.Dest}
+	env, err := r.prepare(opts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	rendered, err := r.execute(resource.Content, variables, env, opts)
+	if rendered != nil {
+		result.Output = rendered.Output
+		result.MissingKeys = rendered.MissingKeys
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LoadProjectResources reads the templates selected by a project config, with their destinations
+func LoadProjectResources(root string, config *ProjectConfig) ([]TemplateResource, error) {
+	paths, err := config.ResolveTemplates(root)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]TemplateResource, 0, len(paths))
+	for _, p := range paths {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s: %v", p, err)
+		}
+		resources = append(resources, TemplateResource{
+			Name:    p,
+			Content: string(content),
+			Dest:    config.Destination(p),
+		})
+	}
+	return resources, nil
+}
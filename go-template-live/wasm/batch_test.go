@@ -0,0 +1,145 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestRenderer_RenderBatch tests ordered results and aggregated errors across a worker pool
+func TestRenderer_RenderBatch(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+
+	var resources []TemplateResource
+	for i := 0; i < 200; i++ {
+		resources = append(resources, TemplateResource{
+			Name:    fmt.Sprintf("t%03d.tmpl", i),
+			Content: fmt.Sprintf("%d:{{.Name}}", i),
+		})
+	}
+	resources[17].Content = "{{.Name.Missing}}"
+	resources[123].Content = "{{.Name"
+
+	results, err := renderer.RenderBatch(resources, map[string]interface{}{"Name": "web"}, RenderOptions{}, 8)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("RenderBatch() error = %v, want *BatchError", err)
+	}
+	if len(batchErr.Failures) != 2 || batchErr.Total != 200 {
+		t.Errorf("RenderBatch() failures = %v of %d", batchErr.Failures, batchErr.Total)
+	}
+	if len(results) != len(resources) {
+		t.Fatalf("RenderBatch() returned %d results, want %d", len(results), len(resources))
+	}
+	for i, result := range results {
+		if i == 17 || i == 123 {
+			if result.Error == "" {
+				t.Errorf("result %d expected error", i)
+			}
+			continue
+		}
+		if want := fmt.Sprintf("%d:web", i); result.Output != want || result.Name != resources[i].Name {
+			t.Errorf("result %d = %+v, want output %q", i, result, want)
+		}
+	}
+}
+
+// TestRenderer_RenderBatchDeterministic tests that a seeded batch renders each resource as it
+// renders alone, whatever the other resources of the batch
+func TestRenderer_RenderBatchDeterministic(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{Name: "roll", EnvHandler: func(env *renderEnv) interface{} {
+		return func() int { return env.randomSource().Intn(1000000) }
+	}})
+	renderer := NewRenderer(registry, nil)
+	opts := RenderOptions{Deterministic: true, Seed: 42}
+
+	var resources []TemplateResource
+	for i := 0; i < 50; i++ {
+		resources = append(resources, TemplateResource{
+			Name:    fmt.Sprintf("t%02d.tmpl", i),
+			Content: strings.Repeat("{{roll}} ", i%5+1),
+		})
+	}
+
+	first, err := renderer.RenderBatch(resources, nil, opts, 0)
+	if err != nil {
+		t.Fatalf("RenderBatch() error = %v", err)
+	}
+	second, err := renderer.RenderBatch(resources[25:], nil, opts, 3)
+	if err != nil {
+		t.Fatalf("RenderBatch() error = %v", err)
+	}
+	for i, resource := range resources {
+		alone, err := renderer.Render(resource.Content, nil, opts)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if first[i].Output != alone.Output {
+			t.Errorf("%s = %q in the batch, %q alone", resource.Name, first[i].Output, alone.Output)
+		}
+		if i >= 25 && second[i-25].Output != alone.Output {
+			t.Errorf("%s = %q in a smaller batch, %q alone", resource.Name, second[i-25].Output, alone.Output)
+		}
+	}
+}
+
+// TestRenderer_RenderBatchConcurrent tests batches rendered by concurrent workers, and two
+// batches with different timezones and seeds rendered at the same time; run with -race
+func TestRenderer_RenderBatchConcurrent(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{Name: "clock", EnvHandler: func(env *renderEnv) interface{} { return env.currentTime }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "roll", EnvHandler: func(env *renderEnv) interface{} {
+		return func() int { return env.randomSource().Intn(1000000) }
+	}})
+	renderer := NewRenderer(registry, nil)
+
+	var resources []TemplateResource
+	for i := 0; i < 200; i++ {
+		resources = append(resources, TemplateResource{
+			Name:    fmt.Sprintf("t%03d.tmpl", i),
+			Content: fmt.Sprintf(`%d {{.Name}} {{clock.Format "15:04 MST"}} {{roll}}`, i),
+		})
+	}
+	batches := []RenderOptions{
+		{Deterministic: true, Seed: 1, Timezone: "UTC"},
+		{Deterministic: true, Seed: 2, Timezone: "America/New_York"},
+	}
+	alone := make([]string, len(batches))
+	for b, opts := range batches {
+		result, err := renderer.Render(`{{clock.Format "15:04 MST"}} {{roll}}`, nil, opts)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		alone[b] = result.Output
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]ResourceResult, len(batches))
+	errs := make([]error, len(batches))
+	for b, opts := range batches {
+		wg.Add(1)
+		go func(b int, opts RenderOptions) {
+			defer wg.Done()
+			results[b], errs[b] = renderer.RenderBatch(resources, map[string]interface{}{"Name": "web"}, opts, 8)
+		}(b, opts)
+	}
+	wg.Wait()
+
+	for b := range batches {
+		if errs[b] != nil {
+			t.Fatalf("RenderBatch() error = %v", errs[b])
+		}
+		for i, result := range results[b] {
+			if want := fmt.Sprintf("%d web %s", i, alone[b]); result.Output != want || result.Name != resources[i].Name {
+				t.Errorf("batch %d result %d = %+v, want output %q", b, i, result, want)
+			}
+		}
+	}
+}
@@ -1,15 +1,21 @@
 #!/bin/bash
 
-# Build script for creating three separate WASM files using build tags
+# Build script for creating six separate WASM files using build tags
 # 1. official.wasm - Only official Go template functions
 # 2. custom.wasm - Official functions + custom functions (getv, exists, get, json)
 # 3. confd.wasm - Official functions + Confd-style functions
+# 4. sprig.wasm - Official functions + sprig functions
+# 5. gomplate.wasm - Official functions + gomplate namespaces
+# 6. helm.wasm - Official functions + sprig + Helm chart functions
 #
 # Architecture:
 # - Core functionality is shared between all builds
 # - Custom functions are conditionally compiled using build tags
 # - functions_custom.go: included when building with "custom" tag
 # - functions_confd.go: included when building with "confd" tag
+# - functions_sprig.go: included when building with "sprig" tag
+# - functions_gomplate.go: included when building with "gomplate" tag
+# - functions_helm.go: included (with functions_sprig.go) when building with "helm" tag
 # - functions_official.go: included when building with "official" tag
 #
 # Build-time Optimization Techniques:
@@ -69,6 +75,45 @@ fi
 
 echo ""
 
+# Build WASM with sprig functions
+echo "Building sprig.wasm (with sprig functions)..."
+GOOS=js GOARCH=wasm go build -tags sprig -ldflags="-s -w" -trimpath -o sprig.wasm .
+
+if [ $? -eq 0 ]; then
+    echo "✓ sprig.wasm built successfully"
+else
+    echo "✗ Failed to build sprig.wasm"
+    exit 1
+fi
+
+echo ""
+
+# Build WASM with gomplate functions
+echo "Building gomplate.wasm (with gomplate namespaces)..."
+GOOS=js GOARCH=wasm go build -tags gomplate -ldflags="-s -w" -trimpath -o gomplate.wasm .
+
+if [ $? -eq 0 ]; then
+    echo "✓ gomplate.wasm built successfully"
+else
+    echo "✗ Failed to build gomplate.wasm"
+    exit 1
+fi
+
+echo ""
+
+# Build WASM with Helm chart functions
+echo "Building helm.wasm (with sprig and Helm chart functions)..."
+GOOS=js GOARCH=wasm go build -tags helm -ldflags="-s -w" -trimpath -o helm.wasm .
+
+if [ $? -eq 0 ]; then
+    echo "✓ helm.wasm built successfully"
+else
+    echo "✗ Failed to build helm.wasm"
+    exit 1
+fi
+
+echo ""
+
 # Copy confd.wasm to main.wasm as the default WASM for frontend
 echo "Copying confd.wasm to main.wasm (default WASM for frontend)..."
 cp confd.wasm main.wasm
@@ -91,10 +136,13 @@ echo "Files created:"
 echo "  - official.wasm (official Go template functions only)"
 echo "  - custom.wasm (with custom functions: getv, exists, get, json, jsonArray)"
 echo "  - confd.wasm (with Confd-style functions: base, split, json, jsonArray, dir, map, join, datetime, toUpper, toLower, replace, contains, base64Encode, base64Decode, trimSuffix, parseBool, reverse, add, sub, div, mod, mul, seq, atoi)"
+echo "  - sprig.wasm (with sprig functions as used by Helm: strings, lists, dicts, math, regex, json, encoding, dates)"
+echo "  - gomplate.wasm (with gomplate namespaces: strings, conv, coll, data, math, regexp, path, filepath, time, crypto, base64, env, test, random, uuid)"
+echo "  - helm.wasm (sprig functions plus include, tpl, toYaml, fromYaml and lookup, with default .Release/.Chart/.Capabilities)"
 echo "  - main.wasm (copy of confd.wasm for frontend)"
 
 # Show file sizes
 echo ""
 echo "File sizes:"
-ls -lh official.wasm custom.wasm confd.wasm main.wasm 2>/dev/null || ls -lh *.wasm
+ls -lh official.wasm custom.wasm confd.wasm sprig.wasm gomplate.wasm helm.wasm main.wasm 2>/dev/null || ls -lh *.wasm
 
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// BundleFormat identifies template bundles
+const BundleFormat = "go-template-live-bundle"
+
+// bundleVersion is the format version of bundles written by ExportBundle
+const bundleVersion = 1
+
+// Bundle is a complete, reproducible playground session in one JSON document: the template
+// files, the entry point rendered, the values and render options, and the function profile they
+// need. Bundles exported in the browser render the same with a native build of that profile
+type Bundle struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// Profile is the function profile the templates are written for
+	Profile   string            `json:"profile"`
+	Entry     string            `json:"entry"`
+	Templates map[string]string `json:"templates"`
+	// Variables are the entry point's variables with their defaults and type hints, as
+	// aggregated extraction returns them; they are recomputed on export
+	Variables []VariableInfo         `json:"variables"`
+	Values    map[string]interface{} `json:"values"`
+	Options   RenderOptions          `json:"options"`
+}
+
+// ExportBundle bundles the project files with the entry point, values and options to render
+func (p *Project) ExportBundle(entry string, values map[string]interface{}, opts RenderOptions) (*Bundle, error) {
+	variables, err := p.ExtractVariables(entry)
+	if err != nil {
+		return nil, err
+	}
+	for i := range variables {
+		variables[i].Position = nil
+		variables[i].Occurrences = nil
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	templates := make(map[string]string, len(p.templates))
+	for name, content := range p.templates {
+		templates[name] = content
+	}
+	return &Bundle{
+		Format:    BundleFormat,
+		Version:   bundleVersion,
+		Profile:   p.parser.registry.Profile(),
+		Entry:     entry,
+		Templates: templates,
+		Variables: variables,
+		Values:    values,
+		Options:   opts,
+	}, nil
+}
+
+// ParseBundle reads a bundle, checking that it is one this version understands and that its
+// entry point is among its templates
+func ParseBundle(data []byte) (*Bundle, error) {
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %v", err)
+	}
+	switch {
+	case bundle.Format != BundleFormat:
+		return nil, fmt.Errorf("invalid bundle: format is %q, expected %q", bundle.Format, BundleFormat)
+	case bundle.Version < 1 || bundle.Version > bundleVersion:
+		return nil, fmt.Errorf("unsupported bundle version %d, this build reads versions up to %d", bundle.Version, bundleVersion)
+	case bundle.Entry == "":
+		return nil, fmt.Errorf("invalid bundle: no entry point")
+	}
+	if _, ok := bundle.Templates[bundle.Entry]; !ok {
+		return nil, fmt.Errorf("invalid bundle: entry point %s is not among its templates", bundle.Entry)
+	}
+	if bundle.Values == nil {
+		bundle.Values = map[string]interface{}{}
+	}
+	return &bundle, nil
+}
+
+// ImportBundle loads the templates of a bundle into a new project of parser and renderer
+// It fails when the bundle needs another function profile than the one of parser, as the
+// templates would not render the same
+func ImportBundle(parser *Parser, renderer *Renderer, bundle *Bundle) (*Project, error) {
+	if profile := parser.registry.Profile(); bundle.Profile != "" && bundle.Profile != profile {
+		return nil, fmt.Errorf("bundle needs the %s function profile, this build has %s", bundle.Profile, profile)
+	}
+	project := NewProject(parser, renderer)
+	for name, content := range bundle.Templates {
+		project.AddTemplate(name, content)
+	}
+	return project, nil
+}
+
+// Render renders the bundle's entry point with its values and options in project, a project
+// returned by ImportBundle
+func (b *Bundle) Render(project *Project) (*RenderResult, error) {
+	return project.Render(b.Entry, b.Values, b.Options)
+}
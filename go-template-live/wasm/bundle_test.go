@@ -0,0 +1,80 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestBundle_RoundTrip tests that an exported bundle imports and renders the same output
+func TestBundle_RoundTrip(t *testing.T) {
+	registry := NewFunctionRegistry()
+	parser, renderer := NewParser(registry), NewRenderer(registry, nil)
+	project := NewProject(parser, renderer)
+	project.AddTemplate("layout.tmpl", `{{define "layout.tmpl"}}<h1>{{.Title}}</h1>{{block "body" .}}{{end}}{{end}}`)
+	project.AddTemplate("home.tmpl", `{{template "layout.tmpl" .}}{{define "body"}}{{.Body}}{{end}}`)
+	values := map[string]interface{}{"Title": "Home", "Body": "Welcome"}
+
+	expected, err := project.Render("home.tmpl", values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	bundle, err := project.ExportBundle("home.tmpl", values, RenderOptions{MissingKey: MissingKeyError})
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	if bundle.Profile != ProfileOfficial || len(bundle.Variables) == 0 || bundle.Variables[0].Position != nil {
+		t.Errorf("ExportBundle() = %+v, want the official profile and variables without positions", bundle)
+	}
+
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	imported, err := ParseBundle(data)
+	if err != nil {
+		t.Fatalf("ParseBundle() error = %v", err)
+	}
+	importedProject, err := ImportBundle(parser, renderer, imported)
+	if err != nil {
+		t.Fatalf("ImportBundle() error = %v", err)
+	}
+	result, err := imported.Render(importedProject)
+	if err != nil {
+		t.Fatalf("Bundle.Render() error = %v", err)
+	}
+	if result.Output != expected.Output || imported.Options.MissingKey != MissingKeyError {
+		t.Errorf("Bundle.Render() = %q with options %+v, want %q", result.Output, imported.Options, expected.Output)
+	}
+
+	imported.Profile = ProfileHelm
+	if _, err := ImportBundle(parser, renderer, imported); err == nil || !strings.Contains(err.Error(), "needs the helm function profile") {
+		t.Errorf("ImportBundle() of another profile error = %v", err)
+	}
+}
+
+func TestParseBundle_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "not json", data: "{", expected: "invalid bundle"},
+		{name: "format", data: `{"format": "other", "version": 1}`, expected: `format is "other"`},
+		{name: "newer", data: `{"format": "go-template-live-bundle", "version": 9}`, expected: "unsupported bundle version 9"},
+		{name: "no entry", data: `{"format": "go-template-live-bundle", "version": 1}`, expected: "no entry point"},
+		{name: "missing entry", data: `{"format": "go-template-live-bundle", "version": 1, "entry": "a.tmpl", "templates": {}}`, expected: "entry point a.tmpl is not among its templates"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBundle([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ParseBundle() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
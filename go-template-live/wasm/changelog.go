@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// TemplateRevision is a template together with the values it is rendered with
+type TemplateRevision struct {
+	Content string                 `json:"content"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+// DefaultChange is a variable whose default value differs between two revisions
+// An empty Old or New means the variable had no default on that side
+type DefaultChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// Changelog summarizes how a template edit changes its inputs and output
+type Changelog struct {
+	AddedVariables   []string        `json:"addedVariables"`
+	RemovedVariables []string        `json:"removedVariables"`
+	DefaultChanges   []DefaultChange `json:"defaultChanges"`
+	// ChangedValues lists the top-level value keys added, removed or modified
+	ChangedValues []string  `json:"changedValues"`
+	TemplateStats DiffStats `json:"templateStats"`
+	OutputStats   DiffStats `json:"outputStats"`
+	// OldError and NewError record render failures, which are reported rather than returned
+	OldError string `json:"oldError,omitempty"`
+	NewError string `json:"newError,omitempty"`
+	// Markdown is the summary as a list suitable for a pull request description
+	Markdown string `json:"markdown"`
+}
+
+// GenerateChangelog compares two revisions of a template
+// Variables and defaults come from extraction, so both templates must parse; the outputs are
+// rendered with each revision's values and diffed line by line
+// It is exposed to JavaScript as generateChangelog; the module has no native command to expose
+// it on the command line
+func GenerateChangelog(parser *Parser, renderer *Renderer, oldRevision, newRevision TemplateRevision) (*Changelog, error) {
+	oldVariables, err := parser.ExtractVariablesAggregated("old", oldRevision.Content)
+	if err != nil {
+		return nil, fmt.Errorf("old template: %w", err)
+	}
+	newVariables, err := parser.ExtractVariablesAggregated("new", newRevision.Content)
+	if err != nil {
+		return nil, fmt.Errorf("new template: %w", err)
+	}
+
+	changelog := &Changelog{
+		AddedVariables:   []string{},
+		RemovedVariables: []string{},
+		DefaultChanges:   []DefaultChange{},
+		ChangedValues:    append([]string{}, ChangedKeys(oldRevision.Values, newRevision.Values)...),
+		TemplateStats:    ComputeDiffStats(DiffLines(oldRevision.Content, newRevision.Content)),
+	}
+
+	oldDefaults := make(map[string]string, len(oldVariables))
+	for _, v := range oldVariables {
+		oldDefaults[v.Name] = v.DefaultValue
+	}
+	newDefaults := make(map[string]string, len(newVariables))
+	for _, v := range newVariables {
+		newDefaults[v.Name] = v.DefaultValue
+		oldDefault, existed := oldDefaults[v.Name]
+		if !existed {
+			changelog.AddedVariables = append(changelog.AddedVariables, v.Name)
+		} else if oldDefault != v.DefaultValue {
+			changelog.DefaultChanges = append(changelog.DefaultChanges, DefaultChange{Name: v.Name, Old: oldDefault, New: v.DefaultValue})
+		}
+	}
+	for _, v := range oldVariables {
+		if _, ok := newDefaults[v.Name]; !ok {
+			changelog.RemovedVariables = append(changelog.RemovedVariables, v.Name)
+		}
+	}
+	sort.Strings(changelog.AddedVariables)
+	sort.Strings(changelog.RemovedVariables)
+	sort.Slice(changelog.DefaultChanges, func(i, j int) bool { return changelog.DefaultChanges[i].Name < changelog.DefaultChanges[j].Name })
+
+	oldOutput, oldErr := renderer.Render(oldRevision.Content, oldRevision.Values, RenderOptions{})
+	newOutput, newErr := renderer.Render(newRevision.Content, newRevision.Values, RenderOptions{})
+	if oldErr != nil {
+		changelog.OldError = oldErr.Error()
+	}
+	if newErr != nil {
+		changelog.NewError = newErr.Error()
+	}
+	changelog.OutputStats = ComputeDiffStats(DiffLines(renderedOutput(oldOutput), renderedOutput(newOutput)))
+
+	changelog.Markdown = changelog.markdown()
+	return changelog, nil
+}
+
+// renderedOutput returns the output of a render, which is empty when parsing failed
+func renderedOutput(result *RenderResult) string {
+	if result == nil {
+		return ""
+	}
+	return result.Output
+}
+
+// markdown formats the changelog as a Markdown list
+func (c *Changelog) markdown() string {
+	var b strings.Builder
+	if len(c.AddedVariables) > 0 {
+		fmt.Fprintf(&b, "- Variables added: %s\n", codeList(c.AddedVariables))
+	}
+	if len(c.RemovedVariables) > 0 {
+		fmt.Fprintf(&b, "- Variables removed: %s\n", codeList(c.RemovedVariables))
+	}
+	for _, change := range c.DefaultChanges {
+		fmt.Fprintf(&b, "- Default of `%s` changed: %s → %s\n", change.Name, defaultText(change.Old), defaultText(change.New))
+	}
+	if len(c.ChangedValues) > 0 {
+		fmt.Fprintf(&b, "- Values changed: %s\n", codeList(c.ChangedValues))
+	}
+	fmt.Fprintf(&b, "- Template: +%d / -%d lines\n", c.TemplateStats.Added, c.TemplateStats.Removed)
+	fmt.Fprintf(&b, "- Output: +%d / -%d lines (%d unchanged)\n", c.OutputStats.Added, c.OutputStats.Removed, c.OutputStats.Unchanged)
+	if c.OldError != "" {
+		fmt.Fprintf(&b, "- Old revision failed to render: %s\n", c.OldError)
+	}
+	if c.NewError != "" {
+		fmt.Fprintf(&b, "- New revision fails to render: %s\n", c.NewError)
+	}
+	return b.String()
+}
+
+// codeList formats names as a comma-separated list of code spans
+func codeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// defaultText formats a default value for the changelog
+func defaultText(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return fmt.Sprintf("`%s`", value)
+}
+
+// ChangedKeys returns the sorted top-level keys added, removed or modified between two value sets
+func ChangedKeys(oldValues, newValues map[string]interface{}) []string {
+	var changed []string
+	for key, newValue := range newValues {
+		if oldValue, ok := oldValues[key]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,75 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateChangelog(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+
+	oldRevision := TemplateRevision{
+		Content: "{{/* @var Port default=80 */}}\nlisten {{.Port}}\nhost {{.Host}}\n",
+		Values:  map[string]interface{}{"Port": "80", "Host": "a"},
+	}
+	newRevision := TemplateRevision{
+		Content: "{{/* @var Port default=8080 */}}\nlisten {{.Port}}\nregion {{.Region}}\n",
+		Values:  map[string]interface{}{"Port": "8080", "Region": "eu"},
+	}
+
+	changelog, err := GenerateChangelog(parser, renderer, oldRevision, newRevision)
+	if err != nil {
+		t.Fatalf("GenerateChangelog() error = %v", err)
+	}
+
+	if expected := []string{"Region"}; !reflect.DeepEqual(changelog.AddedVariables, expected) {
+		t.Errorf("AddedVariables = %v, want %v", changelog.AddedVariables, expected)
+	}
+	if expected := []string{"Host"}; !reflect.DeepEqual(changelog.RemovedVariables, expected) {
+		t.Errorf("RemovedVariables = %v, want %v", changelog.RemovedVariables, expected)
+	}
+	if expected := []DefaultChange{{Name: "Port", Old: "80", New: "8080"}}; !reflect.DeepEqual(changelog.DefaultChanges, expected) {
+		t.Errorf("DefaultChanges = %v, want %v", changelog.DefaultChanges, expected)
+	}
+	if expected := []string{"Host", "Port", "Region"}; !reflect.DeepEqual(changelog.ChangedValues, expected) {
+		t.Errorf("ChangedValues = %v, want %v", changelog.ChangedValues, expected)
+	}
+	if expected := (DiffStats{Added: 2, Removed: 2, Unchanged: 1}); changelog.OutputStats != expected {
+		t.Errorf("OutputStats = %+v, want %+v", changelog.OutputStats, expected)
+	}
+
+	expectedMarkdown := "- Variables added: `Region`\n" +
+		"- Variables removed: `Host`\n" +
+		"- Default of `Port` changed: `80` → `8080`\n" +
+		"- Values changed: `Host`, `Port`, `Region`\n" +
+		"- Template: +2 / -2 lines\n" +
+		"- Output: +2 / -2 lines (1 unchanged)\n"
+	if changelog.Markdown != expectedMarkdown {
+		t.Errorf("Markdown =\n%s\nwant\n%s", changelog.Markdown, expectedMarkdown)
+	}
+}
+
+func TestGenerateChangelog_Errors(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+
+	if _, err := GenerateChangelog(parser, renderer, TemplateRevision{Content: "{{.A}}"}, TemplateRevision{Content: "{{.A"}); err == nil {
+		t.Error("GenerateChangelog() with an invalid new template should fail")
+	}
+
+	// Render failures are reported in the changelog
+	changelog, err := GenerateChangelog(parser, renderer,
+		TemplateRevision{Content: "{{.A}}"},
+		TemplateRevision{Content: "{{index .A 1}}", Values: map[string]interface{}{"A": []interface{}{}}})
+	if err != nil {
+		t.Fatalf("GenerateChangelog() error = %v", err)
+	}
+	if changelog.NewError == "" || !strings.Contains(changelog.Markdown, "New revision fails to render") {
+		t.Errorf("GenerateChangelog() did not report the render failure: %+v", changelog)
+	}
+}
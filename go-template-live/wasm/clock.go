@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// deterministicEpoch is the frozen time used by deterministic renders without an explicit time
+var deterministicEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Clock supplies the current time to time-dependent template functions (e.g. datetime)
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock reads the host clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// fixedClock always returns the same instant
+type fixedClock struct {
+	t time.Time
+}
+
+func (c fixedClock) Now() time.Time { return c.t }
+
+// SetRenderClock pins the clock used by template functions to the given Unix time in milliseconds
+func (e *Environment) SetRenderClock(unixMillis int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = fixedClock{t: time.UnixMilli(unixMillis).UTC()}
+}
+
+// ResetRenderClock restores the host clock for template functions
+func (e *Environment) ResetRenderClock() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clock = systemClock{}
+}
+
+// currentTime returns the time template functions should treat as "now",
+// in the render timezone when one is set
+func (env *renderEnv) currentTime() time.Time {
+	now := env.clock.Now()
+	if env.location != nil {
+		return now.In(env.location)
+	}
+	return now
+}
+
+// randomSource returns the random source template functions should draw from
+func (env *renderEnv) randomSource() *rand.Rand {
+	return env.random
+}
+
+// loadTimezone loads the IANA timezone of time-dependent functions
+func loadTimezone(name string) (*time.Location, error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %v", name, err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"text/template/parse"
+)
+
+// CompareSide selects the engine configuration for one side of a comparison
+type CompareSide struct {
+	// Profile is the function profile to render with; empty selects the active profile
+	Profile string        `json:"profile,omitempty"`
+	Options RenderOptions `json:"options"`
+}
+
+// CompareSideResult is the render outcome of one side of a comparison
+type CompareSideResult struct {
+	Profile              string   `json:"profile"`
+	Output               string   `json:"output"`
+	Error                string   `json:"error,omitempty"`
+	UnavailableFunctions []string `json:"unavailableFunctions"`
+}
+
+// CompareResult is the outcome of rendering one template under two engine configurations
+type CompareResult struct {
+	Left      CompareSideResult `json:"left"`
+	Right     CompareSideResult `json:"right"`
+	Identical bool              `json:"identical"`
+	Diff      []DiffLine        `json:"diff"`
+	Stats     DiffStats         `json:"stats"`
+}
+
+// Comparer renders templates under the function profiles compiled into this build
+type Comparer struct {
+	activeProfile string
+	engines       map[string]*Renderer
+}
+
+// NewComparer creates a comparer for the active renderer plus the always-available official profile
+func NewComparer(active *Renderer) *Comparer {
+	official := NewRenderer(NewFunctionRegistry(), nil)
+	official.SetEnvironment(active.env)
+	engines := map[string]*Renderer{
+		ProfileOfficial: official,
+	}
+	engines[active.registry.Profile()] = active
+	return &Comparer{
+		activeProfile: active.registry.Profile(),
+		engines:       engines,
+	}
+}
+
+// Profiles returns the profile names that can be compared in this build
+func (c *Comparer) Profiles() []string {
+	names := make([]string, 0, len(c.engines))
+	for name := range c.engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compare renders the template on both sides and diffs the outputs
+func (c *Comparer) Compare(templateContent string, variables map[string]interface{}, left, right CompareSide) (*CompareResult, error) {
+	used, err := collectFunctionNames(templateContent)
+	if err != nil {
+		return nil, err
+	}
+
+	leftResult, err := c.renderSide(templateContent, variables, left, used)
+	if err != nil {
+		return nil, err
+	}
+	rightResult, err := c.renderSide(templateContent, variables, right, used)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := DiffLines(leftResult.Output, rightResult.Output)
+	return &CompareResult{
+		Left:      *leftResult,
+		Right:     *rightResult,
+		Identical: leftResult.Error == "" && rightResult.Error == "" && leftResult.Output == rightResult.Output,
+		Diff:      diff,
+		Stats:     ComputeDiffStats(diff),
+	}, nil
+}
+
+// renderSide renders one side, recording render errors in the result rather than failing
+func (c *Comparer) renderSide(templateContent string, variables map[string]interface{}, side CompareSide, used []string) (*CompareSideResult, error) {
+	profile := side.Profile
+	if profile == "" {
+		profile = c.activeProfile
+	}
+	renderer, ok := c.engines[profile]
+	if !ok {
+		return nil, fmt.Errorf("profile %s is not available in this build, expected one of %v", profile, c.Profiles())
+	}
+
+	result := &CompareSideResult{
+		Profile:              profile,
+		UnavailableFunctions: []string{},
+	}
+	for _, name := range used {
+		if !builtinFunctions[name] && !renderer.registry.HasFunction(name) {
+			result.UnavailableFunctions = append(result.UnavailableFunctions, name)
+		}
+	}
+
+	rendered, err := renderer.Render(templateContent, variables, side.Options)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Output = rendered.Output
+	return result, nil
+}
+
+// collectFunctionNames returns the sorted, unique function identifiers called by a template
+// Parsing skips function checks so templates using functions of any profile can be inspected
+func collectFunctionNames(templateContent string) ([]string, error) {
+	tree := parse.New("template")
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(templateContent, "", "", treeSet); err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range treeSet {
+		collectIdentifiers(t.Root, seen)
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// collectIdentifiers records every function identifier under node
+func collectIdentifiers(node parse.Node, seen map[string]bool) {
+	walkNodes(node, 0, func(node parse.Node, depth int) bool {
+		if ident, ok := node.(*parse.IdentifierNode); ok {
+			seen[ident.Ident] = true
+		}
+		return true
+	})
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of completion candidates
+const (
+	CompletionKeyword  = "keyword"
+	CompletionVariable = "variable"
+	CompletionField    = "field"
+	CompletionKey      = "key"
+	CompletionFunction = "function"
+)
+
+// Completion is one candidate offered at the cursor
+type Completion struct {
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	// Detail is the signature of a function, e.g. getv(string, ...string) string
+	Detail string `json:"detail,omitempty"`
+	// Documentation is the description of a function
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// CompletionResult holds the candidates for the word being typed at the cursor
+// The editor replaces the source from From up to the cursor with the chosen label
+type CompletionResult struct {
+	From   int          `json:"from"`
+	Prefix string       `json:"prefix"`
+	Items  []Completion `json:"items"`
+}
+
+// controlKeywords start an action and are offered at its beginning
+var controlKeywords = []string{"if", "range", "with", "define", "block", "template"}
+
+// builtinSignatures are the signatures of the functions predefined by text/template
+var builtinSignatures = map[string]string{
+	"and": "and(any, ...any) any", "or": "or(any, ...any) any", "not": "not(any) bool",
+	"call": "call(func, ...any) any", "index": "index(any, ...any) any", "slice": "slice(any, ...int) any",
+	"len": "len(any) int", "print": "print(...any) string", "printf": "printf(string, ...any) string",
+	"println": "println(...any) string", "html": "html(...any) string", "js": "js(...any) string",
+	"urlquery": "urlquery(...any) string", "eq": "eq(any, ...any) bool", "ne": "ne(any, any) bool",
+	"lt": "lt(any, any) bool", "le": "le(any, any) bool", "gt": "gt(any, any) bool", "ge": "ge(any, any) bool",
+}
+
+var (
+	fieldReference    = regexp.MustCompile(`(?:^|[\s(|{])(\.[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)`)
+	variableReference = regexp.MustCompile(`\$[A-Za-z_][A-Za-z0-9_]*`)
+	keyLiteral        = regexp.MustCompile(`["` + "`" + `](/[^"` + "`" + `\s]*)["` + "`" + `]`)
+)
+
+// Completions returns the candidates for the cursor at a byte offset of a template: keywords
+// valid there, functions of the registry with their signatures, and the $variables, fields and
+// keys the template already uses. The template does not need to parse, as it is usually being
+// typed; outside actions there are no candidates
+func (p *Parser) Completions(templateContent string, offset int) *CompletionResult {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(templateContent) {
+		offset = len(templateContent)
+	}
+	result := &CompletionResult{From: offset, Items: []Completion{}}
+
+	actionStart, ok := openActionAt(templateContent, offset)
+	if !ok {
+		return result
+	}
+	bodyStart := actionStart + 2
+	if strings.HasPrefix(templateContent[bodyStart:], "- ") {
+		bodyStart++
+	}
+	body := templateContent[bodyStart:offset]
+
+	// Inside a string literal only keys are completed
+	if quote := openStringAt(body); quote >= 0 {
+		result.From = bodyStart + quote + 1
+		result.Prefix = templateContent[result.From:offset]
+		for _, key := range seenMatches(templateContent, keyLiteral, offset) {
+			if strings.HasPrefix(key, result.Prefix) {
+				result.Items = append(result.Items, Completion{Label: key, Kind: CompletionKey})
+			}
+		}
+		return result
+	}
+
+	wordStart := len(body)
+	for wordStart > 0 && isCompletionWordByte(body[wordStart-1]) {
+		wordStart--
+	}
+	result.From = bodyStart + wordStart
+	result.Prefix = body[wordStart:]
+	before := strings.TrimSpace(body[:wordStart])
+
+	switch {
+	case strings.HasPrefix(result.Prefix, "$"):
+		for _, name := range seenMatches(templateContent, variableReference, offset) {
+			result.add(name, CompletionVariable, "", "")
+		}
+		result.add("$", CompletionVariable, "", "")
+	case strings.HasPrefix(result.Prefix, "."):
+		for _, field := range seenMatches(templateContent, fieldReference, offset) {
+			result.add(field, CompletionField, "", "")
+		}
+	default:
+		if before == "" {
+			for _, keyword := range validKeywords(templateContent, actionStart) {
+				result.add(keyword, CompletionKeyword, "", "")
+			}
+		} else if before == "else" {
+			result.add("if", CompletionKeyword, "", "")
+			result.add("with", CompletionKeyword, "", "")
+		}
+		for name, signature := range builtinSignatures {
+			if !p.registry.HasFunction(name) {
+				result.add(name, CompletionFunction, signature, "")
+			}
+		}
+		for _, name := range p.registry.GetFunctionNames() {
+			def, _ := p.registry.GetFunction(name)
+			result.add(name, CompletionFunction, functionSignature(name, def.Handler), def.Description)
+		}
+	}
+
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		a, b := result.Items[i], result.Items[j]
+		if a.Kind != b.Kind {
+			return completionKindRank(a.Kind) < completionKindRank(b.Kind)
+		}
+		return a.Label < b.Label
+	})
+	return result
+}
+
+// add appends a candidate matching the prefix being typed, ignoring case
+func (r *CompletionResult) add(label, kind, detail, documentation string) {
+	if !strings.HasPrefix(strings.ToLower(label), strings.ToLower(r.Prefix)) {
+		return
+	}
+	for _, item := range r.Items {
+		if item.Label == label && item.Kind == kind {
+			return
+		}
+	}
+	r.Items = append(r.Items, Completion{Label: label, Kind: kind, Detail: detail, Documentation: documentation})
+}
+
+// openActionAt returns the start of the action the cursor is in, if it has not been closed
+// before the cursor
+func openActionAt(content string, offset int) (int, bool) {
+	start := strings.LastIndex(content[:offset], "{{")
+	if start < 0 {
+		return 0, false
+	}
+	body := content[start+2 : offset]
+	if strings.HasPrefix(strings.TrimLeft(body, "- \t\r\n"), "/*") {
+		return 0, false
+	}
+	if end := scanActionEnd(content[:offset], start+2); end >= 0 {
+		return 0, false
+	}
+	return start, true
+}
+
+// openStringAt returns the offset of the quote of a string literal left open at the end of an
+// action body, or -1
+func openStringAt(body string) int {
+	for i := 0; i < len(body); i++ {
+		quote := body[i]
+		if quote != '"' && quote != '`' && quote != '\'' {
+			continue
+		}
+		j := i + 1
+		for j < len(body) && body[j] != quote {
+			if body[j] == '\\' && quote != '`' {
+				j++
+			}
+			j++
+		}
+		if j >= len(body) {
+			return i
+		}
+		i = j
+	}
+	return -1
+}
+
+// isCompletionWordByte reports whether c is part of a function, field or $variable name
+func isCompletionWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '$'
+}
+
+// seenMatches returns the first group (or whole match) of pattern in the actions of a template,
+// sorted and without the word being typed at offset
+func seenMatches(content string, pattern *regexp.Regexp, offset int) []string {
+	seen := make(map[string]bool)
+	for _, a := range scanActions(content) {
+		if strings.HasPrefix(strings.TrimSpace(actionBody(content, a)), "/*") {
+			continue
+		}
+		for _, m := range pattern.FindAllStringSubmatchIndex(content[a.start:a.end], -1) {
+			start, end := m[0], m[1]
+			if len(m) > 2 {
+				start, end = m[2], m[3]
+			}
+			if a.start+start <= offset && offset <= a.start+end {
+				continue
+			}
+			seen[content[a.start+start:a.start+end]] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validKeywords returns the keywords that may start the action at actionStart: the control
+// keywords, else and end inside a block, and break and continue inside a range
+func validKeywords(content string, actionStart int) []string {
+	var blocks []string
+	for _, a := range scanActions(content[:actionStart]) {
+		switch keyword := actionKeyword(content, a); {
+		case blockKeywords[keyword]:
+			blocks = append(blocks, keyword)
+		case keyword == "end" && len(blocks) > 0:
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+
+	keywords := append([]string(nil), controlKeywords...)
+	if len(blocks) > 0 {
+		keywords = append(keywords, "end")
+		switch blocks[len(blocks)-1] {
+		case "if", "range", "with":
+			keywords = append(keywords, "else")
+		}
+	}
+	for _, block := range blocks {
+		if block == "range" {
+			keywords = append(keywords, "break", "continue")
+			break
+		}
+	}
+	return keywords
+}
+
+// functionSignature describes the parameters and results of a function handler, such as
+// getv(string, ...string) string
+func functionSignature(name string, handler interface{}) string {
+	t := reflect.TypeOf(handler)
+	if t == nil || t.Kind() != reflect.Func {
+		return ""
+	}
+	params := make([]string, t.NumIn())
+	for i := range params {
+		in := t.In(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			params[i] = "..." + signatureType(in.Elem())
+			continue
+		}
+		params[i] = signatureType(in)
+	}
+	signature := name + "(" + strings.Join(params, ", ") + ")"
+	results := make([]string, 0, t.NumOut())
+	for i := 0; i < t.NumOut(); i++ {
+		if out := t.Out(i); out != reflect.TypeOf((*error)(nil)).Elem() {
+			results = append(results, signatureType(out))
+		}
+	}
+	switch len(results) {
+	case 0:
+		return signature
+	case 1:
+		return signature + " " + results[0]
+	}
+	return signature + " (" + strings.Join(results, ", ") + ")"
+}
+
+// signatureType writes a parameter type, with any for empty interfaces
+func signatureType(t reflect.Type) string {
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		return "any"
+	}
+	return strings.ReplaceAll(t.String(), "interface {}", "any")
+}
+
+// completionKindRank orders candidates: keywords, variables, fields, keys, then functions
+func completionKindRank(kind string) int {
+	switch kind {
+	case CompletionKeyword:
+		return 0
+	case CompletionVariable:
+		return 1
+	case CompletionField:
+		return 2
+	case CompletionKey:
+		return 3
+	}
+	return 4
+}
@@ -0,0 +1,99 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// completionLabels returns the labels of the candidates of a kind
+func completionLabels(result *CompletionResult, kind string) []string {
+	var labels []string
+	for _, item := range result.Items {
+		if item.Kind == kind {
+			labels = append(labels, item.Label)
+		}
+	}
+	return labels
+}
+
+func TestCompletions(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:        "getv",
+		Description: "Returns the value of a key",
+		Handler:     func(key string, defaultValue ...string) (string, error) { return "", nil },
+	})
+	registry.RegisterFunction(&FunctionDefinition{Name: "getenv", Handler: func(string) string { return "" }})
+	parser := NewParser(registry)
+
+	template := `{{$port := getv "/app/port"}}{{range .Servers}}{{.Host}}:{{$port}} {{.Config.Name}}
+{{/* .Hidden */}}{{getv "/app/host"}}`
+	cursor := func(typed string) (string, int) {
+		content := template + typed
+		return content, len(content)
+	}
+
+	content, offset := cursor("{{")
+	result := parser.Completions(content, offset)
+	if got := completionLabels(result, CompletionKeyword); !reflect.DeepEqual(got, []string{"block", "break", "continue", "define", "else", "end", "if", "range", "template", "with"}) {
+		t.Errorf("keywords in range = %v", got)
+	}
+
+	content, offset = cursor("{{ge")
+	result = parser.Completions(content, offset)
+	if result.Prefix != "ge" || result.From != offset-2 {
+		t.Errorf("prefix = %q from %d, want ge from %d", result.Prefix, result.From, offset-2)
+	}
+	wantFunctions := []Completion{
+		{Label: "ge", Kind: CompletionFunction, Detail: "ge(any, any) bool"},
+		{Label: "getenv", Kind: CompletionFunction, Detail: "getenv(string) string"},
+		{Label: "getv", Kind: CompletionFunction, Detail: "getv(string, ...string) string", Documentation: "Returns the value of a key"},
+	}
+	if !reflect.DeepEqual(result.Items, wantFunctions) {
+		t.Errorf("items = %+v, want %+v", result.Items, wantFunctions)
+	}
+
+	content, offset = cursor("{{if .C")
+	result = parser.Completions(content, offset)
+	if got := completionLabels(result, CompletionField); !reflect.DeepEqual(got, []string{".Config.Name"}) {
+		t.Errorf("fields = %v, want .Config.Name (comments skipped)", got)
+	}
+	if len(completionLabels(result, CompletionKeyword)) != 0 {
+		t.Error("keywords offered after if")
+	}
+
+	content, offset = cursor("{{ $")
+	result = parser.Completions(content, offset)
+	if got := completionLabels(result, CompletionVariable); !reflect.DeepEqual(got, []string{"$", "$port"}) {
+		t.Errorf("variables = %v, want $ and $port", got)
+	}
+
+	content, offset = cursor(`{{getv "/app/`)
+	result = parser.Completions(content, offset)
+	if got := completionLabels(result, CompletionKey); !reflect.DeepEqual(got, []string{"/app/host", "/app/port"}) || result.Prefix != "/app/" {
+		t.Errorf("keys = %v with prefix %q, want /app/host and /app/port", got, result.Prefix)
+	}
+}
+
+func TestCompletions_OutsideActions(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	for _, content := range []string{"plain text", "{{.A}} after", "{{/* comm"} {
+		if result := parser.Completions(content, len(content)); len(result.Items) != 0 {
+			t.Errorf("Completions(%q) = %+v, want none", content, result.Items)
+		}
+	}
+
+	// Keywords outside any block
+	content := "{{if .A}}{{end}}{{e"
+	result := parser.Completions(content, len(content))
+	if got := completionLabels(result, CompletionKeyword); len(got) != 0 {
+		t.Errorf("keywords = %v, want none starting with e outside blocks", got)
+	}
+	if !strings.HasPrefix(result.Items[0].Label, "e") {
+		t.Errorf("items = %+v, want functions starting with e", result.Items)
+	}
+}
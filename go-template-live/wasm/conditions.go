@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"text/template/parse"
+)
+
+// applyConditionalDependencies sets VariableInfo.DependsOn for occurrences inside if/with branches
+// The guards of a branch are the variables of its condition, e.g. A in {{if .A}} or
+// {{if exists "A"}}; nested branches accumulate the guards of every enclosing condition
+func (p *Parser) applyConditionalDependencies(root *parse.ListNode, variables []VariableInfo) {
+	guards := make(map[int][]string)
+	p.collectGuards(root, guards, 0)
+	for i := range variables {
+		if variables[i].Position == nil {
+			continue
+		}
+		for _, guard := range guards[variables[i].Position.Offset] {
+			if guard != variables[i].Name && !containsString(variables[i].DependsOn, guard) {
+				variables[i].DependsOn = append(variables[i].DependsOn, guard)
+			}
+		}
+		sort.Strings(variables[i].DependsOn)
+	}
+}
+
+// collectGuards records, per occurrence offset, the condition variables guarding it
+func (p *Parser) collectGuards(node parse.Node, guards map[int][]string, depth int) {
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		switch node := node.(type) {
+		case *parse.IfNode:
+			p.recordGuards(node.Pipe, node.List, node.ElseList, guards, depth+1)
+		case *parse.WithNode:
+			p.recordGuards(node.Pipe, node.List, node.ElseList, guards, depth+1)
+		case *parse.ListNode, *parse.RangeNode:
+		default:
+			// Guards only nest through the bodies of control structures
+			return false
+		}
+		return true
+	})
+}
+
+// recordGuards adds the variables of pipe as guards of every occurrence in the branches
+// Condition and branch variables are only inspected here, so they use pooled scratch buffers
+func (p *Parser) recordGuards(pipe *parse.PipeNode, list, elseList *parse.ListNode, guards map[int][]string, depth int) {
+	conditionsBuf, guardedBuf := getScratchVariables(), getScratchVariables()
+	defer putScratchVariables(conditionsBuf)
+	defer putScratchVariables(guardedBuf)
+
+	conditions, err := p.appendFieldsWithDefaults((*conditionsBuf)[:0], pipe, depth)
+	if err != nil || len(conditions) == 0 {
+		return
+	}
+	*conditionsBuf = conditions
+	for _, branch := range []*parse.ListNode{list, elseList} {
+		if branch == nil {
+			continue
+		}
+		guarded, err := p.appendFieldsWithDefaults((*guardedBuf)[:0], branch, depth)
+		if err != nil {
+			continue
+		}
+		*guardedBuf = guarded
+		for _, v := range guarded {
+			if v.Position == nil {
+				continue
+			}
+			for _, condition := range conditions {
+				guards[v.Position.Offset] = append(guards[v.Position.Offset], condition.Name)
+			}
+		}
+	}
+}
+
+// scratchVariables pools the temporary slices used while collecting guards
+var scratchVariables = sync.Pool{
+	New: func() interface{} { return new([]VariableInfo) },
+}
+
+func getScratchVariables() *[]VariableInfo { return scratchVariables.Get().(*[]VariableInfo) }
+
+// putScratchVariables clears the buffer so pooled entries do not keep names and positions alive
+func putScratchVariables(buf *[]VariableInfo) {
+	clear((*buf)[:cap(*buf)])
+	*buf = (*buf)[:0]
+	scratchVariables.Put(buf)
+}
+
+// intersectDependsOn keeps the guards shared by two occurrences of the same variable,
+// since a variable only depends on a guard when every one of its uses is inside that guard
+func intersectDependsOn(a, b []string) []string {
+	var result []string
+	for _, name := range a {
+		if containsString(b, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
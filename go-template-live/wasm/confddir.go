@@ -0,0 +1,44 @@
+//go:build !js
+// +build !js
+
+// This file contains linting of confd directories on disk for native builds
+// Tag: !js (the browser playground passes the files to lintConfdResources instead)
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LintConfdDir lints the template resources of a confd directory, the one holding conf.d and
+// templates (confd's -confdir, /etc/confd by default); see LintConfdFiles
+func (p *Parser) LintConfdDir(confdir string) ([]ConfdResourceReport, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(confdir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(confdir, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !isConfdResourceFile(rel) && !strings.HasPrefix(rel, "templates/") && !strings.Contains(rel, "/templates/") {
+			return nil
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		files[rel] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading confd directory: %v", err)
+	}
+	return p.LintConfdFiles(files), nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ConfdResource is a confd template resource, the [template] table of a conf.d/*.toml file
+type ConfdResource struct {
+	// File is the path of the TOML file the resource was read from
+	File string `json:"file"`
+	// Src is the template file name, relative to the templates directory next to conf.d
+	Src  string `json:"src"`
+	Dest string `json:"dest"`
+	// Keys are the key prefixes confd fetches from the backend for the template
+	Keys      []string `json:"keys"`
+	Prefix    string   `json:"prefix,omitempty"`
+	Owner     string   `json:"owner,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	Mode      string   `json:"mode,omitempty"`
+	CheckCmd  string   `json:"checkCmd,omitempty"`
+	ReloadCmd string   `json:"reloadCmd,omitempty"`
+}
+
+// ConfdResourceReport is the lint result of one template resource
+type ConfdResourceReport struct {
+	Resource *ConfdResource `json:"resource,omitempty"`
+	// Template is the path of the src template, empty when the resource could not be read
+	Template string `json:"template,omitempty"`
+	// UndeclaredKeys are keys the template reads that no declared key covers, so confd never
+	// fetches them; wildcard reads are listed by their pattern
+	UndeclaredKeys []string `json:"undeclaredKeys,omitempty"`
+	// UnusedKeys are declared keys the template reads nothing under
+	UnusedKeys []string `json:"unusedKeys,omitempty"`
+	// Errors are problems that stop confd from processing the resource
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ParseConfdResource reads the [template] table of a confd resource file
+// Unknown settings are ignored, as confd does; src, dest and keys must be present
+func ParseConfdResource(file, content string) (*ConfdResource, error) {
+	var document map[string]interface{}
+	if _, err := toml.Decode(content, &document); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, strings.TrimPrefix(err.Error(), "toml: "))
+	}
+	table, ok := document["template"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: missing [template] table", file)
+	}
+
+	resource := &ConfdResource{File: file}
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"src", &resource.Src},
+		{"dest", &resource.Dest},
+		{"prefix", &resource.Prefix},
+		{"owner", &resource.Owner},
+		{"group", &resource.Group},
+		{"mode", &resource.Mode},
+		{"check_cmd", &resource.CheckCmd},
+		{"reload_cmd", &resource.ReloadCmd},
+	}
+	for _, field := range fields {
+		value, ok := table[field.name]
+		if !ok {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s must be a string", file, field.name)
+		}
+		*field.value = s
+	}
+
+	if keys, ok := table["keys"]; ok {
+		list, ok := keys.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: keys must be an array of strings", file)
+		}
+		for _, key := range list {
+			s, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: keys must be an array of strings", file)
+			}
+			resource.Keys = append(resource.Keys, s)
+		}
+	}
+
+	switch {
+	case resource.Src == "":
+		return nil, fmt.Errorf("%s: empty src template", file)
+	case resource.Dest == "":
+		return nil, fmt.Errorf("%s: empty dest", file)
+	case len(resource.Keys) == 0:
+		return nil, fmt.Errorf("%s: no keys declared", file)
+	}
+	return resource, nil
+}
+
+// LintConfdResource cross-checks the keys a resource declares against the keys its template reads
+// A key is covered by a declared key that is the same key or one of its parent directories.
+// Both are relative to the resource prefix, as confd strips it before rendering
+func (p *Parser) LintConfdResource(resource *ConfdResource, templateContent string) (*ConfdResourceReport, error) {
+	variables, err := p.ExtractVariablesAggregated(resource.Src, templateContent)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make([][]string, len(resource.Keys))
+	for i, key := range resource.Keys {
+		declared[i] = splitKey(key)
+	}
+	used := make([]bool, len(declared))
+
+	report := &ConfdResourceReport{Resource: resource}
+	for _, variable := range variables {
+		if !isKeyPath(variable.Name) {
+			continue
+		}
+		read := splitKey(variable.Name)
+		if variable.Wildcard {
+			read = staticKeyPrefix(read)
+		}
+
+		covered := false
+		for i, key := range declared {
+			switch {
+			case hasPrefix(read, key):
+				covered = true
+				used[i] = true
+			case variable.Wildcard && hasPrefix(key, read):
+				// A pattern such as /upstreams/* also reads the declared keys below its directory
+				used[i] = true
+			}
+		}
+		if !covered {
+			report.UndeclaredKeys = append(report.UndeclaredKeys, variable.Name)
+		}
+	}
+	for i, key := range resource.Keys {
+		if !used[i] {
+			report.UnusedKeys = append(report.UnusedKeys, key)
+		}
+	}
+	return report, nil
+}
+
+// staticKeyPrefix returns the segments of a key pattern before the first one holding a glob
+// metacharacter
+func staticKeyPrefix(segments []string) []string {
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			return segments[:i]
+		}
+	}
+	return segments
+}
+
+// isConfdResourceFile reports whether a slash path is a template resource of a confd directory,
+// a .toml file below a conf.d directory
+func isConfdResourceFile(name string) bool {
+	return path.Ext(name) == ".toml" && (strings.HasPrefix(name, "conf.d/") || strings.Contains(name, "/conf.d/"))
+}
+
+// confdTemplatePath returns the path of a resource's src template: confd reads it from the
+// templates directory next to the conf.d directory holding the resource
+func confdTemplatePath(resourceFile, src string) string {
+	confdir := ""
+	if i := strings.LastIndex(resourceFile, "conf.d/"); i > 0 {
+		confdir = resourceFile[:i]
+	}
+	return path.Join(confdir, "templates", src)
+}
+
+// LintConfdFiles lints every template resource of a confd directory, given as slash paths
+// relative to it (conf.d/nginx.toml, templates/nginx.conf.tmpl) mapped to contents
+// Reports are sorted by resource file; a resource that cannot be read or whose template is
+// missing or does not parse gets a report with errors instead of failing the whole run
+func (p *Parser) LintConfdFiles(files map[string]string) []ConfdResourceReport {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if isConfdResourceFile(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	reports := make([]ConfdResourceReport, 0, len(names))
+	for _, name := range names {
+		resource, err := ParseConfdResource(name, files[name])
+		if err != nil {
+			reports = append(reports, ConfdResourceReport{Errors: []string{err.Error()}})
+			continue
+		}
+
+		templatePath := confdTemplatePath(name, resource.Src)
+		content, ok := files[templatePath]
+		if !ok {
+			reports = append(reports, ConfdResourceReport{
+				Resource: resource,
+				Template: templatePath,
+				Errors:   []string{fmt.Sprintf("%s: src template %s not found", name, templatePath)},
+			})
+			continue
+		}
+
+		report, err := p.LintConfdResource(resource, content)
+		if err != nil {
+			reports = append(reports, ConfdResourceReport{
+				Resource: resource,
+				Template: templatePath,
+				Errors:   []string{fmt.Sprintf("%s: %v", templatePath, err)},
+			})
+			continue
+		}
+		report.Template = templatePath
+		reports = append(reports, *report)
+	}
+	return reports
+}
@@ -0,0 +1,165 @@
+//go:build !js && confd
+// +build !js,confd
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const nginxResource = `[template]
+prefix = "/production"
+src = "nginx.conf.tmpl"
+dest = "/etc/nginx/nginx.conf"
+owner = "nginx"
+mode = "0644"
+keys = [
+  "/nginx",
+  "/upstreams",
+  "/unused",
+]
+check_cmd = "/usr/sbin/nginx -t -c {{.src}}"
+reload_cmd = "/usr/sbin/service nginx reload"
+`
+
+const nginxTemplate = `worker_processes {{getv "/nginx/workers" "4"}};
+{{range gets "/upstreams/*"}}server {{.Value}};
+{{end}}listen {{getv "/app/port"}};
+`
+
+func TestParseConfdResource(t *testing.T) {
+	resource, err := ParseConfdResource("conf.d/nginx.toml", nginxResource)
+	if err != nil {
+		t.Fatalf("ParseConfdResource() error = %v", err)
+	}
+
+	expected := &ConfdResource{
+		File:      "conf.d/nginx.toml",
+		Src:       "nginx.conf.tmpl",
+		Dest:      "/etc/nginx/nginx.conf",
+		Keys:      []string{"/nginx", "/upstreams", "/unused"},
+		Prefix:    "/production",
+		Owner:     "nginx",
+		Mode:      "0644",
+		CheckCmd:  "/usr/sbin/nginx -t -c {{.src}}",
+		ReloadCmd: "/usr/sbin/service nginx reload",
+	}
+	if !reflect.DeepEqual(resource, expected) {
+		t.Errorf("ParseConfdResource() = %+v, want %+v", resource, expected)
+	}
+}
+
+func TestParseConfdResource_DottedKeys(t *testing.T) {
+	content := `template.src = "a.tmpl"
+template.dest = "/a"
+template.keys = ["/a"]
+template.updated = 2024-05-01T10:00:00Z
+`
+	resource, err := ParseConfdResource("a.toml", content)
+	if err != nil {
+		t.Fatalf("ParseConfdResource() error = %v", err)
+	}
+
+	expected := &ConfdResource{File: "a.toml", Src: "a.tmpl", Dest: "/a", Keys: []string{"/a"}}
+	if !reflect.DeepEqual(resource, expected) {
+		t.Errorf("ParseConfdResource() = %+v, want %+v", resource, expected)
+	}
+}
+
+func TestParseConfdResource_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "no table", content: "src = \"a\"\n", expected: "missing [template] table"},
+		{name: "no src", content: "[template]\ndest = \"/a\"\nkeys = [\"/a\"]\n", expected: "empty src template"},
+		{name: "no keys", content: "[template]\nsrc = \"a\"\ndest = \"/a\"\n", expected: "no keys declared"},
+		{name: "key type", content: "[template]\nsrc = \"a\"\ndest = \"/a\"\nkeys = [1]\n", expected: "keys must be an array of strings"},
+		{name: "syntax", content: "[template]\nsrc = \"a\" b\n", expected: "x.toml: line 2"},
+		{name: "src type", content: "[template]\nsrc = 1\ndest = \"/a\"\nkeys = [\"/a\"]\n", expected: "src must be a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfdResource("x.toml", tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ParseConfdResource() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLintConfdFiles(t *testing.T) {
+	parser := createConfdParser()
+
+	reports := parser.LintConfdFiles(map[string]string{
+		"conf.d/nginx.toml":         nginxResource,
+		"templates/nginx.conf.tmpl": nginxTemplate,
+		"conf.d/missing.toml":       "[template]\nsrc = \"missing.tmpl\"\ndest = \"/a\"\nkeys = [\"/a\"]\n",
+		"conf.d/broken.toml":        "[template\n",
+		"README.md":                 "not a resource",
+	})
+
+	if len(reports) != 3 {
+		t.Fatalf("LintConfdFiles() returned %d reports, want 3: %+v", len(reports), reports)
+	}
+	if len(reports[0].Errors) != 1 || !strings.HasPrefix(reports[0].Errors[0], "conf.d/broken.toml:") {
+		t.Errorf("broken resource errors = %v", reports[0].Errors)
+	}
+	if len(reports[1].Errors) != 1 || !strings.Contains(reports[1].Errors[0], "src template templates/missing.tmpl not found") {
+		t.Errorf("missing template errors = %v", reports[1].Errors)
+	}
+
+	nginx := reports[2]
+	if nginx.Template != "templates/nginx.conf.tmpl" || len(nginx.Errors) != 0 {
+		t.Errorf("nginx report = %+v", nginx)
+	}
+	if !reflect.DeepEqual(nginx.UndeclaredKeys, []string{"/app/port"}) {
+		t.Errorf("UndeclaredKeys = %v, want [/app/port]", nginx.UndeclaredKeys)
+	}
+	if !reflect.DeepEqual(nginx.UnusedKeys, []string{"/unused"}) {
+		t.Errorf("UnusedKeys = %v, want [/unused]", nginx.UnusedKeys)
+	}
+}
+
+func TestLintConfdResource_Coverage(t *testing.T) {
+	parser := createConfdParser()
+	resource := &ConfdResource{Src: "app.tmpl", Keys: []string{"/", "/services/web/hosts"}}
+
+	report, err := parser.LintConfdResource(resource, `{{range lsdir "/services"}}{{.}}{{end}}{{getv "/a"}}`)
+	if err != nil {
+		t.Fatalf("LintConfdResource() error = %v", err)
+	}
+	if len(report.UndeclaredKeys) != 0 || len(report.UnusedKeys) != 0 {
+		t.Errorf("LintConfdResource() = %+v, want every key covered and used", report)
+	}
+}
+
+func TestLintConfdDir(t *testing.T) {
+	confdir := t.TempDir()
+	for name, content := range map[string]string{
+		"conf.d/nginx.toml":         nginxResource,
+		"templates/nginx.conf.tmpl": nginxTemplate,
+	} {
+		file := filepath.Join(confdir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports, err := createConfdParser().LintConfdDir(confdir)
+	if err != nil {
+		t.Fatalf("LintConfdDir() error = %v", err)
+	}
+	if len(reports) != 1 || !reflect.DeepEqual(reports[0].UndeclaredKeys, []string{"/app/port"}) {
+		t.Errorf("LintConfdDir() = %+v", reports)
+	}
+}
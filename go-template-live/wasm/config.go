@@ -0,0 +1,194 @@
+//go:build !js
+// +build !js
+
+// This file contains the project configuration of template repositories for native builds
+// Tag: !js (the browser playground has no project directory)
+// No `templatelive render` command is built from this module; a tool embedding it loads the
+// configuration with LoadProjectConfig and renders ResolveTemplates to their Destination
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFileName is the project-level configuration file read by LoadProjectConfig
+const ProjectConfigFileName = "templatelive.yaml"
+
+// knownProfiles are the function profiles a project may select
+var knownProfiles = map[string]bool{
+	ProfileOfficial: true,
+	ProfileCustom:   true,
+	ProfileConfd:    true,
+	ProfileSprig:    true,
+	ProfileGomplate: true,
+	ProfileHelm:     true,
+}
+
+// knownValidators are the validators a project may enable
+var knownValidators = map[string]bool{
+	"lint": true, "json": true, "yaml": true, "toml": true, "ini": true, "xml": true,
+}
+
+// OutputTarget maps a template to an explicit destination path
+type OutputTarget struct {
+	Template string `json:"template"`
+	Dest     string `json:"dest"`
+}
+
+// ProjectConfig declares how templates in a repository are rendered
+type ProjectConfig struct {
+	// Profile is the function profile templates are written against
+	Profile string `json:"profile"`
+	// Templates are glob patterns (relative to the project root, ** allowed) selecting template files
+	Templates []string `json:"templates"`
+	// Values are values files merged in order, later files overriding earlier ones
+	Values []string `json:"values"`
+	// Validators are checks run on each template or rendered output
+	Validators []string `json:"validators"`
+	// Render holds the render options applied to every template
+	Render RenderOptions `json:"render"`
+	// OutputDir receives rendered files that have no explicit output target
+	OutputDir string `json:"outputDir"`
+	// Outputs map individual templates to destination paths
+	Outputs []OutputTarget `json:"outputs"`
+}
+
+// LoadProjectConfig reads templatelive.yaml from the project root directory
+func LoadProjectConfig(root string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(filepath.Join(root, ProjectConfigFileName))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", ProjectConfigFileName, err)
+	}
+	return ParseProjectConfig(data)
+}
+
+// ParseProjectConfig parses and validates a YAML project configuration
+func ParseProjectConfig(data []byte) (*ProjectConfig, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", ProjectConfigFileName, err)
+	}
+
+	// Decode through JSON so the config shares the json tags of RenderOptions
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", ProjectConfigFileName, err)
+	}
+	config := &ProjectConfig{}
+	if err := json.Unmarshal(jsonData, config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", ProjectConfigFileName, err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", ProjectConfigFileName, err)
+	}
+	return config, nil
+}
+
+// validate checks profile, globs, validators and render options
+func (c *ProjectConfig) validate() error {
+	if c.Profile == "" {
+		c.Profile = ProfileOfficial
+	}
+	if !knownProfiles[c.Profile] {
+		return fmt.Errorf("unknown profile %q", c.Profile)
+	}
+	if len(c.Templates) == 0 {
+		return fmt.Errorf("at least one template glob is required")
+	}
+	for _, pattern := range c.Templates {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid template glob %q: %v", pattern, err)
+		}
+	}
+	for _, validator := range c.Validators {
+		if !knownValidators[validator] {
+			return fmt.Errorf("unknown validator %q", validator)
+		}
+	}
+	for _, output := range c.Outputs {
+		if output.Template == "" || output.Dest == "" {
+			return fmt.Errorf("outputs entries need both template and dest")
+		}
+	}
+	return validateMissingKey(c.Render.MissingKey)
+}
+
+// ResolveTemplates returns the slash-separated paths under root matching the template globs
+func (c *ProjectConfig) ResolveTemplates(root string) ([]string, error) {
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range c.Templates {
+			if matchGlob(pattern, rel) {
+				matches = append(matches, rel)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error resolving templates: %v", err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Destination returns where the rendered output of a template should be written
+// Explicit outputs win; otherwise the template path is mirrored into OutputDir without its .tmpl suffix
+func (c *ProjectConfig) Destination(templatePath string) string {
+	for _, output := range c.Outputs {
+		if output.Template == templatePath {
+			return output.Dest
+		}
+	}
+	if c.OutputDir == "" {
+		return ""
+	}
+	return path.Join(c.OutputDir, strings.TrimSuffix(templatePath, ".tmpl"))
+}
+
+// matchGlob matches slash-separated paths against a pattern where ** spans directories
+func matchGlob(pattern, name string) bool {
+	patternParts := strings.Split(pattern, "/")
+	nameParts := strings.Split(name, "/")
+	return matchGlobParts(patternParts, nameParts)
+}
+
+func matchGlobParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,93 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestProjectConfig_LoadAndResolve tests loading templatelive.yaml and resolving templates and destinations
+func TestProjectConfig_LoadAndResolve(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		ProjectConfigFileName: `
+profile: confd
+templates:
+  - "templates/**/*.tmpl"
+values:
+  - values/base.json
+validators: [lint, yaml]
+render:
+  missingKey: error
+outputDir: out
+outputs:
+  - template: templates/nginx/site.conf.tmpl
+    dest: /etc/nginx/conf.d/site.conf
+`,
+		"templates/app.yaml.tmpl":             "",
+		"templates/nginx/site.conf.tmpl":      "",
+		"templates/nginx/README.md":           "",
+		"other/ignored.tmpl":                  "",
+		"templates/deep/a/b/service.ini.tmpl": "",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config, err := LoadProjectConfig(root)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if config.Profile != ProfileConfd || config.Render.MissingKey != MissingKeyError {
+		t.Errorf("LoadProjectConfig() = %+v", config)
+	}
+
+	templates, err := config.ResolveTemplates(root)
+	if err != nil {
+		t.Fatalf("ResolveTemplates() error = %v", err)
+	}
+	expected := []string{"templates/app.yaml.tmpl", "templates/deep/a/b/service.ini.tmpl", "templates/nginx/site.conf.tmpl"}
+	if !reflect.DeepEqual(templates, expected) {
+		t.Errorf("ResolveTemplates() = %v, want %v", templates, expected)
+	}
+
+	if got := config.Destination("templates/nginx/site.conf.tmpl"); got != "/etc/nginx/conf.d/site.conf" {
+		t.Errorf("Destination() = %q", got)
+	}
+	if got := config.Destination("templates/app.yaml.tmpl"); got != "out/templates/app.yaml" {
+		t.Errorf("Destination() = %q", got)
+	}
+}
+
+// TestParseProjectConfig_Invalid tests validation errors
+func TestParseProjectConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{name: "unknown profile", config: "profile: jinja\ntemplates: ['*.tmpl']"},
+		{name: "no templates", config: "profile: confd"},
+		{name: "unknown validator", config: "templates: ['*.tmpl']\nvalidators: [spellcheck]"},
+		{name: "bad missingkey", config: "templates: ['*.tmpl']\nrender:\n  missingKey: strict"},
+		{name: "incomplete output", config: "templates: ['*.tmpl']\noutputs:\n  - template: a.tmpl"},
+		{name: "malformed yaml", config: "templates: [a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProjectConfig([]byte(tt.config)); err == nil {
+				t.Error("ParseProjectConfig() expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// DynamicLookup is a function call reading a key held in a $variable whose value is only known
+// when rendering, such as {{$key := .ConfigKey}}{{getv $key}}
+type DynamicLookup struct {
+	Function string `json:"function"`
+	Variable string `json:"variable"`
+	// Source is the pipeline last assigned to the variable, empty when it is not known, e.g.
+	// for the element of a range
+	Source   string    `json:"source,omitempty"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// probePrefix marks the string arguments standing in for unresolved variables while an extractor
+// runs; a NUL byte cannot appear in a key written in a template
+const probePrefix = "\x00"
+
+// bind records the variables declared or assigned by pipe; iterates is set for range pipelines,
+// whose variables hold an index and element rather than the pipeline's value
+func (p *Parser) bind(pipe *parse.PipeNode, iterates bool) {
+	if p.bindings == nil || pipe == nil {
+		return
+	}
+	for _, variable := range pipe.Decl {
+		if iterates || len(pipe.Decl) > 1 {
+			p.bindings[variable.Ident[0]] = nil
+			continue
+		}
+		p.bindings[variable.Ident[0]] = pipe
+	}
+}
+
+// scope starts the variable scope of a control structure; restore ends it, forgetting the
+// variables declared inside and treating outer ones assigned inside as unknown
+func (p *Parser) scope() (restore func()) {
+	if p.bindings == nil {
+		return func() {}
+	}
+	outer := make(map[string]*parse.PipeNode, len(p.bindings))
+	for name, pipe := range p.bindings {
+		outer[name] = pipe
+	}
+	return func() {
+		for name, pipe := range outer {
+			if p.bindings[name] != pipe {
+				outer[name] = nil
+			}
+		}
+		p.bindings = outer
+	}
+}
+
+// templateScope gives an invoked template its own variables, as text/template does; dynamic
+// lookups are not recorded inside included files, their positions are not in this template
+func (p *Parser) templateScope(tree *parse.Tree) (restore func()) {
+	prevBindings, prevDynamic := p.bindings, p.dynamic
+	if p.bindings != nil {
+		p.bindings = make(map[string]*parse.PipeNode)
+	}
+	if tree.ParseName != p.templates.Name() {
+		p.dynamic = nil
+	}
+	return func() { p.bindings, p.dynamic = prevBindings, prevDynamic }
+}
+
+// boundArg returns the single argument of the pipeline bound to a variable, such as the string
+// of {{$key := "/myapp/host"}} or the field of {{$db := .Database}}
+func (p *Parser) boundArg(name string) parse.Node {
+	pipe := p.bindings[name]
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+	return pipe.Cmds[0].Args[0]
+}
+
+// variableField returns the field chain a variable with fields stands for: $.Host is the
+// field Host and $db.Host is Database.Host after {{$db := .Database}}
+// A variable without fields reports nothing, its value was extracted where it was assigned
+func (p *Parser) variableField(node *parse.VariableNode) ([]string, bool) {
+	if len(node.Ident) < 2 {
+		return nil, false
+	}
+	if node.Ident[0] == "$" {
+		return node.Ident[1:], true
+	}
+	field, ok := p.boundArg(node.Ident[0]).(*parse.FieldNode)
+	if !ok {
+		return nil, false
+	}
+	return append(append([]string{}, field.Ident...), node.Ident[1:]...), true
+}
+
+// variablePosition returns the position of a variable reference such as $db.Host; like a field
+// chain, a variable with fields is positioned at its last field
+func variablePosition(node *parse.VariableNode) *Position {
+	text := node.String()
+	if len(node.Ident) == 1 {
+		return nodePosition(node.Position(), len(text))
+	}
+	last := "." + node.Ident[len(node.Ident)-1]
+	start := int(node.Position()) - (len(text) - len(last))
+	if start < 0 {
+		start = int(node.Position())
+	}
+	return nodePosition(parse.Pos(start), len(text))
+}
+
+// resolveArgs replaces the variables among the arguments of a function call by the string they
+// were assigned, so extractors see {{$key := "/host"}}{{getv $key}} as {{getv "/host"}}
+// Variables with a value only known when rendering are replaced by probes, returned by text,
+// so the variables the extractor derives from them can be reported as dynamic lookups
+func (p *Parser) resolveArgs(args []parse.Node) ([]parse.Node, map[string]*parse.VariableNode) {
+	if p.bindings == nil {
+		return args, nil
+	}
+	var resolved []parse.Node
+	var probes map[string]*parse.VariableNode
+	for i, arg := range args {
+		variable, ok := arg.(*parse.VariableNode)
+		if !ok || len(variable.Ident) != 1 || variable.Ident[0] == "$" {
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]parse.Node{}, args...)
+		}
+		if literal, ok := p.boundArg(variable.Ident[0]).(*parse.StringNode); ok {
+			resolved[i] = literal
+			continue
+		}
+		text := probePrefix + variable.Ident[0]
+		if probes == nil {
+			probes = make(map[string]*parse.VariableNode)
+		}
+		probes[text] = variable
+		resolved[i] = &parse.StringNode{NodeType: parse.NodeString, Pos: variable.Pos, Quoted: strconv.Quote(text), Text: text}
+	}
+	if resolved == nil {
+		return args, nil
+	}
+	return resolved, probes
+}
+
+// dropProbes removes the variables an extractor derived from probes, recording them as
+// dynamic lookups of function
+func (p *Parser) dropProbes(variables []VariableInfo, function string, probes map[string]*parse.VariableNode) []VariableInfo {
+	kept := variables[:0]
+	for _, v := range variables {
+		if !strings.Contains(v.Name, probePrefix) {
+			kept = append(kept, v)
+			continue
+		}
+		p.recordDynamicLookup(function, v.Name, probes)
+	}
+	return kept
+}
+
+// dropProbeNames is dropProbes for name-only extraction
+func (p *Parser) dropProbeNames(names []string, function string, probes map[string]*parse.VariableNode) []string {
+	kept := names[:0]
+	for _, name := range names {
+		if !strings.Contains(name, probePrefix) {
+			kept = append(kept, name)
+			continue
+		}
+		p.recordDynamicLookup(function, name, probes)
+	}
+	return kept
+}
+
+func (p *Parser) recordDynamicLookup(function, name string, probes map[string]*parse.VariableNode) {
+	if p.dynamic == nil {
+		return
+	}
+	for text, variable := range probes {
+		if !strings.Contains(name, text) {
+			continue
+		}
+		lookup := DynamicLookup{Function: function, Variable: variable.String(), Position: variablePosition(variable)}
+		if pipe := p.bindings[variable.Ident[0]]; pipe != nil {
+			cmds := make([]string, len(pipe.Cmds))
+			for i, cmd := range pipe.Cmds {
+				cmds[i] = cmd.String()
+			}
+			lookup.Source = strings.Join(cmds, " | ")
+		}
+		*p.dynamic = append(*p.dynamic, lookup)
+		return
+	}
+}
+
+// DynamicLookups returns the calls reading a key held in a $variable that extraction cannot
+// resolve to a string, in document order
+func (p *Parser) DynamicLookups(fileName, fileContent string) ([]DynamicLookup, error) {
+	tmpl, err := p.parseTemplate(context.Background(), fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := loadIncludes(tmpl, p.includes, p.env.includeSource()); err != nil {
+		return nil, err
+	}
+
+	lookups := []DynamicLookup{}
+	walker := p.newWalker(tmpl, nil)
+	walker.dynamic = &lookups
+	if _, err := walker.appendFieldsWithDefaults(nil, tmpl.Tree.Root, 0); err != nil {
+		return nil, err
+	}
+	err = walker.walkUninvoked(func(root *parse.ListNode) error {
+		_, err := walker.appendFieldsWithDefaults(nil, root, 0)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(lookups, func(i, j int) bool { return lookups[i].Position.Offset < lookups[j].Position.Offset })
+	index := newLineIndex(fileContent)
+	for _, lookup := range lookups {
+		index.resolve(fileContent, lookup.Position)
+	}
+	return lookups, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import "strings"
+
+// applyDefaults returns variables with every missing variable that has an extracted default
+// filled in, along with the names filled; the caller's map is not modified
+// Defaults are typed by the variable's type hint when they convert, as in the form schema
+func (r *Renderer) applyDefaults(templateContent string, variables map[string]interface{}) (map[string]interface{}, []string, error) {
+	extracted, err := r.parser().ExtractVariablesWithPositions("template", templateContent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filled := variables
+	var applied []string
+	for _, v := range AggregateVariables(extracted) {
+		if v.DefaultValue == "" {
+			continue
+		}
+		if _, ok := lookupValue(filled, v.Name); ok {
+			continue
+		}
+		value, ok := v.typedDefault()
+		if !ok {
+			value = v.DefaultValue
+		}
+		if len(applied) == 0 {
+			filled = copyValues(variables)
+		}
+		if setValue(filled, v.Name, value) {
+			applied = append(applied, v.Name)
+		}
+	}
+	return filled, applied, nil
+}
+
+// DefaultValues returns the defaults found during extraction as values, typed as applyDefaults
+// fills them, for use as the lowest layer of MergeVariables
+func (r *Renderer) DefaultValues(templateContent string) (map[string]interface{}, error) {
+	defaults, _, err := r.applyDefaults(templateContent, map[string]interface{}{})
+	return defaults, err
+}
+
+// setValue stores value under a variable name, the counterpart of lookupValue
+// Key-value paths (leading "/") and undotted names are flat keys; dotted field paths are nested,
+// copying the objects along the path so maps shared with the caller are not modified
+// It reports false when the path runs through a value that is not an object
+func setValue(values map[string]interface{}, name string, value interface{}) bool {
+	if strings.HasPrefix(name, "/") || !strings.Contains(name, ".") {
+		values[name] = value
+		return true
+	}
+
+	segments := strings.Split(name, ".")
+	current := values
+	for _, segment := range segments[:len(segments)-1] {
+		next, exists := current[segment]
+		if !exists {
+			created := make(map[string]interface{})
+			current[segment] = created
+			current = created
+			continue
+		}
+		object, ok := next.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		object = copyValues(object)
+		current[segment] = object
+		current = object
+	}
+	current[segments[len(segments)-1]] = value
+	return true
+}
+
+// withBuiltinValues returns values on top of builtins: nested objects present in both are
+// merged the same way, and any other value of values replaces the builtin one
+func withBuiltinValues(builtins, values map[string]interface{}) map[string]interface{} {
+	if len(builtins) == 0 {
+		return values
+	}
+	merged := copyValues(builtins)
+	for key, value := range values {
+		object, isObject := value.(map[string]interface{})
+		builtin, builtinIsObject := merged[key].(map[string]interface{})
+		if isObject && builtinIsObject {
+			merged[key] = withBuiltinValues(builtin, object)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// copyValues returns a shallow copy of a values map
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values)+1)
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,62 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRender_ApplyDefaults(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	template := `{{/* @var Server.Port type=number default=8080 */}}
+{{/* @var Region default=eu */}}
+{{/* @var Debug type=bool default=maybe */}}
+{{.Server.Host}}:{{.Server.Port}} {{.Region}} {{.Debug}}`
+
+	values := map[string]interface{}{
+		"Server": map[string]interface{}{"Host": "localhost"},
+		"Region": "us",
+	}
+	result, err := renderer.Render(template, values, RenderOptions{ApplyDefaults: true})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "\n\n\nlocalhost:8080 us maybe"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+	if expected := []string{"Server.Port", "Debug"}; !reflect.DeepEqual(result.AppliedDefaults, expected) {
+		t.Errorf("Render() applied defaults = %v, want %v", result.AppliedDefaults, expected)
+	}
+	if len(result.MissingKeys) != 0 {
+		t.Errorf("Render() missing keys = %v, want none", result.MissingKeys)
+	}
+
+	// The caller's values are left untouched
+	expected := map[string]interface{}{
+		"Server": map[string]interface{}{"Host": "localhost"},
+		"Region": "us",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Render() modified values to %v", values)
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]interface{}
+		expected map[string]interface{}
+		ok       bool
+	}{
+		{"/app/port", map[string]interface{}{}, map[string]interface{}{"/app/port": "v"}, true},
+		{"a.b", map[string]interface{}{}, map[string]interface{}{"a": map[string]interface{}{"b": "v"}}, true},
+		{"a.b", map[string]interface{}{"a": "text"}, map[string]interface{}{"a": "text"}, false},
+	}
+	for _, tt := range tests {
+		if ok := setValue(tt.values, tt.name, "v"); ok != tt.ok || !reflect.DeepEqual(tt.values, tt.expected) {
+			t.Errorf("setValue(%q) = %v, %v, want %v, %v", tt.name, ok, tt.values, tt.ok, tt.expected)
+		}
+	}
+}
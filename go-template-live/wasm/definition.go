@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// Kinds of definitions found by FindDefinition
+const (
+	DefinitionTemplate = "template"
+	DefinitionVariable = "variable"
+)
+
+// Definition is where the template or $variable under the cursor is defined
+type Definition struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// File is the file holding the definition: the template itself, or the included file
+	// defining a named template
+	File string `json:"file"`
+	// Position is the name in {{define "name"}} or {{block "name"}}, the start of an included
+	// file, or the $variable of its := declaration; nil when no definition is found, as for $
+	Position *Position `json:"position,omitempty"`
+}
+
+// definitionVariable is a $variable declaration in scope
+type definitionVariable struct {
+	name string
+	pos  parse.Pos
+}
+
+// FindDefinition returns the definition of what the cursor at a byte offset is on: the
+// template invoked by a {{template "name"}} action, or the := declaration of a $variable in
+// scope. Named templates not defined in the file are looked up in the project and registered
+// includes. It returns nil when the cursor is on neither
+func (p *Parser) FindDefinition(fileName, fileContent string, offset int) (*Definition, error) {
+	tree := parse.New(fileName)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(fileContent, "", "", treeSet); err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
+	}
+
+	if name, ok := invokedTemplateAt(fileContent, offset); ok {
+		return p.templateDefinition(fileName, fileContent, name), nil
+	}
+
+	for _, t := range treeSet {
+		if t.Root == nil {
+			continue
+		}
+		finder := &variableFinder{content: fileContent, offset: offset}
+		finder.walk(t.Root, nil)
+		if finder.name == "" {
+			continue
+		}
+		definition := &Definition{Kind: DefinitionVariable, Name: finder.name, File: fileName}
+		if finder.found {
+			definition.Position = &Position{Offset: int(finder.declaration), Length: len(finder.name)}
+			newLineIndex(fileContent).resolve(fileContent, definition.Position)
+		}
+		return definition, nil
+	}
+	return nil, nil
+}
+
+// invokedTemplateAt returns the name of the template invoked by the {{template}} or {{block}}
+// action the cursor is in
+func invokedTemplateAt(content string, offset int) (string, bool) {
+	for _, a := range scanActions(content) {
+		if offset < a.start || offset >= a.end {
+			continue
+		}
+		fields := strings.Fields(actionBody(content, a))
+		if len(fields) < 2 || (fields[0] != "template" && fields[0] != "block") {
+			return "", false
+		}
+		literal := fields[1]
+		if end := stringLiteralEnd(literal, 0); end < len(literal) {
+			literal = literal[:end]
+		}
+		name, err := strconv.Unquote(literal)
+		return name, err == nil
+	}
+	return "", false
+}
+
+// templateDefinition looks up a named template: among the {{define}} and {{block}} actions of
+// the file, then as an included file, then among the definitions of included files
+func (p *Parser) templateDefinition(fileName, fileContent, name string) *Definition {
+	definition := &Definition{Kind: DefinitionTemplate, Name: name}
+	if position, ok := definePosition(fileContent, name); ok {
+		definition.File, definition.Position = fileName, position
+		return definition
+	}
+	source := p.env.includeSource()
+	if _, ok, err := includeContent(name, p.includes, source); ok && err == nil {
+		definition.File, definition.Position = name, &Position{Line: 1, Column: 1}
+		return definition
+	}
+	if includeName, content, ok := definingInclude(name, p.includes, source); ok {
+		if position, ok := definePosition(content, name); ok {
+			definition.File, definition.Position = includeName, position
+		}
+	}
+	return definition
+}
+
+// definePosition returns the position of the name literal of the {{define}} or {{block}}
+// action defining a template
+func definePosition(content, name string) (*Position, bool) {
+	for _, a := range scanActions(content) {
+		keyword := actionKeyword(content, a)
+		if keyword != "define" && keyword != "block" {
+			continue
+		}
+		body := actionBody(content, a)
+		bodyStart := strings.Index(content[a.start:a.end], body) + a.start
+		start := strings.Index(body, keyword) + len(keyword)
+		for start < len(body) && isTemplateSpace(body[start]) {
+			start++
+		}
+		if start >= len(body) {
+			continue
+		}
+		end := stringLiteralEnd(body, start)
+		if defined, err := strconv.Unquote(body[start:end]); err != nil || defined != name {
+			continue
+		}
+		position := &Position{Offset: bodyStart + start, Length: end - start}
+		newLineIndex(content).resolve(content, position)
+		return position, true
+	}
+	return nil, false
+}
+
+// variableFinder resolves the $variable at an offset to its declaration
+type variableFinder struct {
+	content string
+	offset  int
+	// name is set once the cursor is found on a variable; found and declaration tell whether
+	// and where it is declared
+	name        string
+	found       bool
+	declaration parse.Pos
+}
+
+// walk visits node with the variables declared before it in scope, returning the scope after
+// it: a variable is visible from its declaration to the {{end}} of the enclosing block
+func (f *variableFinder) walk(node parse.Node, scope []definitionVariable) []definitionVariable {
+	if f.name != "" {
+		return scope
+	}
+	switch node := node.(type) {
+	case *parse.IfNode:
+		f.walkBranch(&node.BranchNode, scope)
+	case *parse.RangeNode:
+		f.walkBranch(&node.BranchNode, scope)
+	case *parse.WithNode:
+		f.walkBranch(&node.BranchNode, scope)
+	case *parse.PipeNode:
+		if node == nil {
+			return scope
+		}
+		for _, cmd := range node.Cmds {
+			for _, arg := range cmd.Args {
+				f.walk(arg, scope)
+			}
+		}
+		for _, decl := range node.Decl {
+			if node.IsAssign {
+				f.walk(decl, scope)
+				continue
+			}
+			scope = append(scope, definitionVariable{name: decl.Ident[0], pos: parse.Pos(variableStart(f.content, decl))})
+			f.walk(decl, scope)
+		}
+	case *parse.VariableNode:
+		f.visit(node, scope)
+	default:
+		for _, child := range childNodes(node) {
+			scope = f.walk(child, scope)
+		}
+	}
+	return scope
+}
+
+// walkBranch visits an if, range or with; variables declared in its pipeline or body are only
+// visible up to its {{end}}
+func (f *variableFinder) walkBranch(branch *parse.BranchNode, scope []definitionVariable) {
+	scope = append([]definitionVariable(nil), scope...)
+	scope = f.walk(branch.Pipe, scope)
+	f.walk(branch.List, scope)
+	f.walk(branch.ElseList, scope)
+}
+
+// visit resolves a variable when the cursor is on it
+func (f *variableFinder) visit(node *parse.VariableNode, scope []definitionVariable) {
+	name := node.Ident[0]
+	start := variableStart(f.content, node)
+	if f.offset < start || f.offset > start+len(name) {
+		return
+	}
+	f.name = name
+	for i := len(scope) - 1; i >= 0; i-- {
+		if scope[i].name == name {
+			f.found, f.declaration = true, scope[i].pos
+			return
+		}
+	}
+}
+
+// variableStart returns the offset of the $name of a variable node; the node of $x.Field is
+// positioned at .Field
+func variableStart(content string, node *parse.VariableNode) int {
+	name := node.Ident[0]
+	pos := int(node.Pos)
+	if pos+len(name) <= len(content) && content[pos:pos+len(name)] == name {
+		return pos
+	}
+	if i := strings.LastIndex(content[:pos], name); i >= 0 {
+		return i
+	}
+	return pos
+}
@@ -0,0 +1,95 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindDefinition_Variables(t *testing.T) {
+	template := `{{$name := .Name}}{{range $i, $item := .Items}}{{$name := $item.Name}}{{$name}}{{end}}
+{{$name}} {{$}}
+{{define "inner"}}{{$name := 1}}{{$name}}{{end}}`
+	parser := NewParser(NewFunctionRegistry())
+
+	tests := []struct {
+		name   string
+		cursor int
+		want   int // offset of the declaration, -1 when not found
+	}{
+		{"shadowing declaration in range", strings.Index(template, "{{$name}}") + 3, strings.Index(template, "$name := $item")},
+		{"range variable", strings.Index(template, "$item.Name") + 2, strings.Index(template, "$item :=")},
+		{"outer declaration after end", strings.LastIndex(template, "\n{{$name}}") + 4, 2},
+		{"declaration itself", 3, 2},
+		{"declaration in a define", strings.LastIndex(template, "{{$name}}") + 3, strings.LastIndex(template, "$name := 1")},
+		{"root", strings.Index(template, "{{$}}") + 2, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition, err := parser.FindDefinition("app.tmpl", template, tt.cursor)
+			if err != nil {
+				t.Fatalf("FindDefinition() error = %v", err)
+			}
+			if definition == nil || definition.Kind != DefinitionVariable {
+				t.Fatalf("definition = %+v, want a variable", definition)
+			}
+			if tt.want < 0 {
+				if definition.Position != nil {
+					t.Errorf("Position = %+v, want none", definition.Position)
+				}
+				return
+			}
+			if definition.Position == nil || definition.Position.Offset != tt.want {
+				t.Errorf("Position = %+v, want offset %d", definition.Position, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDefinition_Templates(t *testing.T) {
+	template := "{{template \"local\" .}}\n{{template \"app.labels\" .}}{{template \"partials/header.tmpl\"}}{{template \"missing\"}}\n{{define \"local\"}}x{{end}}"
+	parser := NewParser(NewFunctionRegistry())
+	parser.env.SetTemplateIncludes(map[string]string{
+		"_helpers.tpl":         "{{/* helpers */}}\n{{- define \"app.labels\" -}}\napp: x\n{{- end }}",
+		"partials/header.tmpl": "# header",
+	})
+
+	tests := []struct {
+		cursor     string
+		file       string
+		line, col  int
+		noPosition bool
+	}{
+		{cursor: `"local" .}}`, file: "app.tmpl", line: 3, col: 10},
+		{cursor: `"app.labels"`, file: "_helpers.tpl", line: 2, col: 12},
+		{cursor: `"partials/header.tmpl"`, file: "partials/header.tmpl", line: 1, col: 1},
+		{cursor: `"missing"`, noPosition: true},
+	}
+	for _, tt := range tests {
+		definition, err := parser.FindDefinition("app.tmpl", template, strings.Index(template, tt.cursor)+1)
+		if err != nil {
+			t.Fatalf("FindDefinition() error = %v", err)
+		}
+		if definition == nil || definition.Kind != DefinitionTemplate {
+			t.Fatalf("definition at %s = %+v, want a template", tt.cursor, definition)
+		}
+		if tt.noPosition {
+			if definition.Position != nil {
+				t.Errorf("definition at %s = %+v, want no position", tt.cursor, definition.Position)
+			}
+			continue
+		}
+		if definition.File != tt.file || definition.Position == nil || definition.Position.Line != tt.line || definition.Position.Column != tt.col {
+			t.Errorf("definition at %s = %+v %+v, want %s:%d:%d", tt.cursor, definition, definition.Position, tt.file, tt.line, tt.col)
+		}
+	}
+
+	if definition, err := parser.FindDefinition("app.tmpl", template, 0); err != nil || definition == nil {
+		t.Errorf("FindDefinition() at the template keyword = %+v, %v, want the local define", definition, err)
+	}
+	if definition, err := parser.FindDefinition("app.tmpl", "plain {{.X}}", 2); err != nil || definition != nil {
+		t.Errorf("FindDefinition() on text = %+v, %v, want nil", definition, err)
+	}
+}
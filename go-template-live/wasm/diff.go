@@ -0,0 +1,135 @@
+package main
+
+import (
+	"strings"
+)
+
+// Diff operations
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells bounds the LCS table size; larger inputs fall back to a whole-block replace
+const maxDiffCells = 4_000_000
+
+// DiffLine is a single line of a line-based diff
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffStats summarizes a diff
+type DiffStats struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// DiffLines computes a line-based diff turning a into b
+func DiffLines(a, b string) []DiffLine {
+	return diffLineSlices(splitLines(a), splitLines(b))
+}
+
+// diffLineSlices diffs two sequences of lines
+func diffLineSlices(oldLines, newLines []string) []DiffLine {
+	// Strip common prefix and suffix to keep the LCS table small
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	var result []DiffLine
+	for _, line := range oldLines[:prefix] {
+		result = append(result, DiffLine{Op: DiffEqual, Text: line})
+	}
+	result = append(result, diffMiddle(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		result = append(result, DiffLine{Op: DiffEqual, Text: line})
+	}
+	return result
+}
+
+// diffMiddle diffs the differing middle section with a longest-common-subsequence table
+func diffMiddle(oldLines, newLines []string) []DiffLine {
+	var result []DiffLine
+	if len(oldLines)*len(newLines) > maxDiffCells {
+		for _, line := range oldLines {
+			result = append(result, DiffLine{Op: DiffDelete, Text: line})
+		}
+		for _, line := range newLines {
+			result = append(result, DiffLine{Op: DiffInsert, Text: line})
+		}
+		return result
+	}
+
+	// lcs[i][j] is the LCS length of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			result = append(result, DiffLine{Op: DiffEqual, Text: oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, DiffLine{Op: DiffDelete, Text: oldLines[i]})
+			i++
+		default:
+			result = append(result, DiffLine{Op: DiffInsert, Text: newLines[j]})
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		result = append(result, DiffLine{Op: DiffDelete, Text: oldLines[i]})
+	}
+	for ; j < len(newLines); j++ {
+		result = append(result, DiffLine{Op: DiffInsert, Text: newLines[j]})
+	}
+	return result
+}
+
+// ComputeDiffStats counts added, removed and unchanged lines
+func ComputeDiffStats(diff []DiffLine) DiffStats {
+	var stats DiffStats
+	for _, line := range diff {
+		switch line.Op {
+		case DiffInsert:
+			stats.Added++
+		case DiffDelete:
+			stats.Removed++
+		default:
+			stats.Unchanged++
+		}
+	}
+	return stats
+}
+
+// splitLines splits text into lines without a trailing empty element
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
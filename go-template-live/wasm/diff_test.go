@@ -0,0 +1,64 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDiffLines tests the line-based diff used by comparisons and change summaries
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected []DiffLine
+	}{
+		{
+			name:     "identical",
+			a:        "a\nb\n",
+			b:        "a\nb\n",
+			expected: []DiffLine{{Op: DiffEqual, Text: "a"}, {Op: DiffEqual, Text: "b"}},
+		},
+		{
+			name: "changed middle line",
+			a:    "a\nb\nc",
+			b:    "a\nx\nc",
+			expected: []DiffLine{
+				{Op: DiffEqual, Text: "a"},
+				{Op: DiffDelete, Text: "b"},
+				{Op: DiffInsert, Text: "x"},
+				{Op: DiffEqual, Text: "c"},
+			},
+		},
+		{
+			name: "insertions and deletions",
+			a:    "a\nb\nc\nd",
+			b:    "b\nc\ne\nd",
+			expected: []DiffLine{
+				{Op: DiffDelete, Text: "a"},
+				{Op: DiffEqual, Text: "b"},
+				{Op: DiffEqual, Text: "c"},
+				{Op: DiffInsert, Text: "e"},
+				{Op: DiffEqual, Text: "d"},
+			},
+		},
+		{
+			name:     "empty to content",
+			a:        "",
+			b:        "a",
+			expected: []DiffLine{{Op: DiffInsert, Text: "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffLines(tt.a, tt.b)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("DiffLines() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
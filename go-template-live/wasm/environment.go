@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Environment is what template functions may read beyond the values: the clock, the
+// filesystem, the DNS resolver, the value resolver and the registered template includes
+// A Parser and a Renderer each hold one; the WASM page shares a single environment between
+// them and configures it through the Set methods. Renders never modify it: each takes a
+// renderEnv snapshot, so renders can run concurrently and settings apply from the next render
+type Environment struct {
+	mu       sync.Mutex
+	clock    Clock
+	fs       FileSystem
+	resolver Resolver
+	includes map[string]string
+	values   valueResolution
+}
+
+// NewEnvironment creates an environment with the host clock and the default filesystem and
+// resolver of the build, no value resolver and no includes
+func NewEnvironment() *Environment {
+	return &Environment{
+		clock:    systemClock{},
+		fs:       defaultFileSystem(),
+		resolver: defaultResolver(),
+	}
+}
+
+// renderEnv is the environment of one render, Evaluate call or batch resource: a snapshot of
+// an Environment plus the timezone, path style, frozen clock and random source chosen by the
+// render options. Functions reading it are bound to it when the render's function map is
+// built (see FunctionDefinition.EnvHandler), so it is only used by the goroutine rendering
+type renderEnv struct {
+	clock     Clock
+	location  *time.Location
+	random    *rand.Rand
+	pathStyle string
+	fs        FileSystem
+	resolver  Resolver
+	includes  map[string]string
+	values    *valueLookups
+}
+
+// newRenderEnv snapshots the environment for a render with the timezone, path style and
+// determinism of opts
+func (e *Environment) newRenderEnv(opts RenderOptions) (*renderEnv, error) {
+	e.mu.Lock()
+	env := &renderEnv{
+		clock:     e.clock,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		pathStyle: PathStylePOSIX,
+		fs:        e.fs,
+		resolver:  e.resolver,
+		includes:  e.includes,
+		values:    e.values.lookups(&e.mu),
+	}
+	e.mu.Unlock()
+
+	if opts.Timezone != "" {
+		loc, err := loadTimezone(opts.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		env.location = loc
+	}
+	if opts.PathStyle != "" {
+		if err := validatePathStyle(opts.PathStyle); err != nil {
+			return nil, err
+		}
+		env.pathStyle = opts.PathStyle
+	}
+	if opts.Deterministic {
+		env.clock = fixedClock{t: deterministicTime(opts)}
+		env.random = rand.New(rand.NewSource(opts.Seed))
+	}
+	return env, nil
+}
+
+// includeSource returns where {{template}} includes are looked up outside a render
+func (e *Environment) includeSource() includeSource {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return includeSource{files: e.includes, fs: e.fs}
+}
+
+// includeSource returns where {{template}} includes are looked up during the render
+func (env *renderEnv) includeSource() includeSource {
+	return includeSource{files: env.includes, fs: env.fs}
+}
+
+// defaultRenderEnv returns an environment of the host clock, the default filesystem and
+// resolver of the build and a random source of its own, to which the parsing handlers of
+// environment-bound functions are bound
+func defaultRenderEnv() *renderEnv {
+	env, _ := NewEnvironment().newRenderEnv(RenderOptions{})
+	return env
+}
+
+// keyStore creates a key store over values that asks the value resolver of the render for
+// missing keys
+func (env *renderEnv) keyStore(values map[string]interface{}) *KeyStore {
+	return &KeyStore{values: values, lookups: env.values}
+}
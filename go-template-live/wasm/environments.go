@@ -0,0 +1,38 @@
+package main
+
+import "sort"
+
+// EnvironmentResult is the render outcome of a template for one environment
+type EnvironmentResult struct {
+	Output      string   `json:"output"`
+	MissingKeys []string `json:"missingKeys,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// RenderEnvironments renders a template once per environment, with the environment's overlay
+// merged over the base values as MergeValues does (nested objects merge, null removes a key)
+// Every environment is rendered even when others fail; failures are recorded in their results
+func (r *Renderer) RenderEnvironments(templateContent string, base map[string]interface{}, overlays map[string]map[string]interface{}, opts RenderOptions) map[string]*EnvironmentResult {
+	names := make([]string, 0, len(overlays))
+	for name := range overlays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make(map[string]*EnvironmentResult, len(names))
+	for _, name := range names {
+		result := &EnvironmentResult{}
+		rendered, err := r.Render(templateContent, MergeValues(base, overlays[name]), opts)
+		if rendered != nil {
+			result.Output = rendered.Output
+			result.MissingKeys = rendered.MissingKeys
+			result.Warnings = rendered.Warnings
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results[name] = result
+	}
+	return results
+}
@@ -0,0 +1,43 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRenderEnvironments tests that each environment renders its overlay merged over the base
+// values, and that a failing environment does not stop the others
+func TestRenderEnvironments(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	base := map[string]interface{}{
+		"name":  "web",
+		"image": map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+	}
+	overlays := map[string]map[string]interface{}{
+		"dev":  {},
+		"prod": {"image": map[string]interface{}{"tag": "1.27"}},
+		"bad":  {"name": nil},
+	}
+
+	results := renderer.RenderEnvironments("{{.name}}={{.image.repository}}:{{.image.tag}}", base, overlays,
+		RenderOptions{MissingKey: MissingKeyError})
+
+	if len(results) != 3 {
+		t.Fatalf("RenderEnvironments() returned %d results, want 3", len(results))
+	}
+	if output := results["dev"].Output; output != "web=nginx:1.25" {
+		t.Errorf("dev output = %q, want %q", output, "web=nginx:1.25")
+	}
+	if output := results["prod"].Output; output != "web=nginx:1.27" {
+		t.Errorf("prod output = %q, want %q", output, "web=nginx:1.27")
+	}
+	if !strings.Contains(results["bad"].Error, "map has no entry for key") {
+		t.Errorf("bad error = %q, want a missing key error", results["bad"].Error)
+	}
+	if tag := base["image"].(map[string]interface{})["tag"]; tag != "1.25" {
+		t.Errorf("RenderEnvironments() modified the base values, image.tag = %v", tag)
+	}
+}
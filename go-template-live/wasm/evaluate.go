@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// evalCaptureFunc receives the value of an evaluated pipeline; the trailing underscores keep
+// it clear of registered function names
+const evalCaptureFunc = "evalCapture__"
+
+// EvalResult is the value of a single pipeline evaluated by Renderer.Evaluate
+type EvalResult struct {
+	// Value is the value encoded as JSON; values JSON cannot represent, such as functions, are
+	// given as the text an action would print
+	Value json.RawMessage `json:"value"`
+	// Type is the JSON type of Value: "string", "number", "bool", "array", "object" or "null"
+	Type string `json:"type"`
+	// GoType is the Go type of the value, e.g. int64 or []interface {}, empty for nil
+	GoType string `json:"goType,omitempty"`
+	// Output is the value as an action printing it would render, e.g. map[host:db]
+	Output string `json:"output"`
+}
+
+// Evaluate evaluates a single pipeline, such as add (atoi .port) 1, against variables and
+// returns its value, for evaluating a selection in the editor
+// The pipeline runs in a throwaway template with the render functions and environment of opts;
+// it must be one pipeline without delimiters or variable declarations
+func (r *Renderer) Evaluate(pipeline string, variables map[string]interface{}, opts RenderOptions) (*EvalResult, error) {
+	env, err := r.prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+	variables = WithKeyPrefix(variables, opts.KeyPrefix)
+
+	var captured interface{}
+	funcs := template.FuncMap{}
+	for name, fn := range r.funcMap(variables, env) {
+		funcs[name] = fn
+	}
+	funcs[evalCaptureFunc] = func(value interface{}) string {
+		captured = value
+		return ""
+	}
+	if err := checkSinglePipeline(pipeline, funcs); err != nil {
+		return nil, err
+	}
+
+	tmpl := template.New("expression").Funcs(funcs)
+	if opts.MissingKey != "" {
+		tmpl = tmpl.Option("missingkey=" + opts.MissingKey)
+	}
+	if tmpl, err = tmpl.Parse("{{" + evalCaptureFunc + " (" + pipeline + ")}}"); err != nil {
+		return nil, fmt.Errorf("error parsing expression: %v", err)
+	}
+	if err := tmpl.Execute(io.Discard, variables); err != nil {
+		return nil, fmt.Errorf("error evaluating expression: %v", err)
+	}
+	return newEvalResult(captured), nil
+}
+
+// checkSinglePipeline rejects text that is not exactly one pipeline, such as "x}}{{.y" or a
+// $variable declaration, before it is wrapped in a template
+func checkSinglePipeline(pipeline string, funcs template.FuncMap) error {
+	if strings.TrimSpace(pipeline) == "" {
+		return fmt.Errorf("empty expression")
+	}
+	tmpl, err := template.New("expression").Funcs(funcs).Parse("{{" + pipeline + "}}")
+	if err != nil {
+		return fmt.Errorf("error parsing expression: %v", err)
+	}
+	nodes := tmpl.Tree.Root.Nodes
+	if len(nodes) != 1 || nodes[0].Type() != parse.NodeAction {
+		return fmt.Errorf("expected a single pipeline, got %q", pipeline)
+	}
+	if len(nodes[0].(*parse.ActionNode).Pipe.Decl) > 0 {
+		return fmt.Errorf("expected a pipeline without variable declarations, got %q", pipeline)
+	}
+	return nil
+}
+
+// newEvalResult describes a value; its JSON type is taken from its encoding, so values with
+// their own JSON form, such as times, report the type of that form
+func newEvalResult(value interface{}) *EvalResult {
+	result := &EvalResult{Output: "<no value>"}
+	if value != nil {
+		result.GoType = fmt.Sprintf("%T", value)
+		result.Output = fmt.Sprint(value)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(result.Output)
+	}
+	result.Value = encoded
+
+	switch encoded[0] {
+	case '"':
+		result.Type = "string"
+	case '[':
+		result.Type = "array"
+	case '{':
+		result.Type = "object"
+	case 't', 'f':
+		result.Type = "bool"
+	case 'n':
+		result.Type = "null"
+	default:
+		result.Type = "number"
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestRenderer_Evaluate tests evaluating single pipelines and describing their values
+func TestRenderer_Evaluate(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), func(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+		return map[string]interface{}{
+			"atoi": strconv.Atoi,
+			"add":  func(a, b int) int { return a + b },
+		}
+	})
+	values := map[string]interface{}{
+		"port":  "8080",
+		"hosts": []interface{}{"a", "b"},
+		"db":    map[string]interface{}{"host": "db"},
+	}
+
+	tests := []struct {
+		pipeline, value, typ, goType, output string
+	}{
+		{"add (atoi .port) 1", "8081", "number", "int", "8081"},
+		{`.port | printf "%s/tcp"`, `"8080/tcp"`, "string", "string", "8080/tcp"},
+		{".hosts", `["a","b"]`, "array", "[]interface {}", "[a b]"},
+		{".db", `{"host":"db"}`, "object", "map[string]interface {}", "map[host:db]"},
+		{"eq .port \"8080\"", "true", "bool", "bool", "true"},
+		{".missing", "null", "null", "", "<no value>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pipeline, func(t *testing.T) {
+			result, err := renderer.Evaluate(tt.pipeline, values, RenderOptions{})
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if string(result.Value) != tt.value || result.Type != tt.typ || result.GoType != tt.goType || result.Output != tt.output {
+				t.Errorf("Evaluate() = %s %s %s %q, want %s %s %s %q", result.Value, result.Type, result.GoType, result.Output, tt.value, tt.typ, tt.goType, tt.output)
+			}
+		})
+	}
+
+	for _, pipeline := range []string{"", ".port}}{{.db", "$x := .port", "add 1", ".missing"} {
+		opts := RenderOptions{}
+		if pipeline == ".missing" {
+			opts.MissingKey = MissingKeyError
+		}
+		if _, err := renderer.Evaluate(pipeline, values, opts); err == nil {
+			t.Errorf("Evaluate(%q) succeeded", pipeline)
+		}
+	}
+}
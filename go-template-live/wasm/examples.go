@@ -0,0 +1,104 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Example templates are embedded so the playground works offline
+// Layout: examples/<profile>/<name>.tmpl with optional <name>.values.json sample values
+//
+//go:embed examples
+var examplesFS embed.FS
+
+const (
+	examplesRoot         = "examples"
+	exampleTemplateExt   = ".tmpl"
+	exampleValuesSuffix  = ".values.json"
+	exampleCommentPrefix = "{{/*"
+)
+
+// ExampleInfo describes an embedded example template
+type ExampleInfo struct {
+	Name        string `json:"name"`
+	Profile     string `json:"profile"`
+	Description string `json:"description,omitempty"`
+}
+
+// Example is an embedded example template with its sample values
+type Example struct {
+	ExampleInfo
+	Template string                 `json:"template"`
+	Values   map[string]interface{} `json:"values,omitempty"`
+}
+
+// ListExamples returns the examples available for a function profile, sorted by name
+func ListExamples(profile string) ([]ExampleInfo, error) {
+	entries, err := fs.ReadDir(examplesFS, path.Join(examplesRoot, profile))
+	if err != nil {
+		return nil, fmt.Errorf("no examples for profile %s", profile)
+	}
+
+	examples := make([]ExampleInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), exampleTemplateExt) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), exampleTemplateExt)
+		content, err := fs.ReadFile(examplesFS, path.Join(examplesRoot, profile, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading example %s: %v", name, err)
+		}
+		examples = append(examples, ExampleInfo{
+			Name:        name,
+			Profile:     profile,
+			Description: exampleDescription(string(content)),
+		})
+	}
+
+	sort.Slice(examples, func(i, j int) bool { return examples[i].Name < examples[j].Name })
+	return examples, nil
+}
+
+// GetExample returns a single example template and its sample values
+func GetExample(profile, name string) (*Example, error) {
+	dir := path.Join(examplesRoot, profile)
+	content, err := fs.ReadFile(examplesFS, path.Join(dir, name+exampleTemplateExt))
+	if err != nil {
+		return nil, fmt.Errorf("example %s not found for profile %s", name, profile)
+	}
+
+	example := &Example{
+		ExampleInfo: ExampleInfo{
+			Name:        name,
+			Profile:     profile,
+			Description: exampleDescription(string(content)),
+		},
+		Template: string(content),
+	}
+
+	if values, err := fs.ReadFile(examplesFS, path.Join(dir, name+exampleValuesSuffix)); err == nil {
+		if example.Values, err = ParseValues(string(values)); err != nil {
+			return nil, fmt.Errorf("error parsing values for example %s: %v", name, err)
+		}
+	}
+
+	return example, nil
+}
+
+// exampleDescription returns the text of a leading {{/* ... */}} comment, if any
+func exampleDescription(content string) string {
+	firstLine := strings.SplitN(content, "\n", 2)[0]
+	if !strings.HasPrefix(firstLine, exampleCommentPrefix) {
+		return ""
+	}
+	end := strings.Index(firstLine, "*/")
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimSpace(firstLine[len(exampleCommentPrefix):end])
+}
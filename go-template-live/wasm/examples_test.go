@@ -0,0 +1,62 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// testProfileExamples tests that every embedded example of a profile extracts and renders with
+// its sample values, and that the basic lessons are offered in the profile
+func testProfileExamples(t *testing.T, profile string, parser *Parser, renderer *Renderer) {
+	t.Helper()
+	examples, err := ListExamples(profile)
+	if err != nil {
+		t.Fatalf("ListExamples() error = %v", err)
+	}
+	if len(examples) == 0 {
+		t.Fatal("ListExamples() returned no examples")
+	}
+
+	for _, info := range examples {
+		t.Run(info.Name, func(t *testing.T) {
+			example, err := GetExample(profile, info.Name)
+			if err != nil {
+				t.Fatalf("GetExample() error = %v", err)
+			}
+			if _, err := parser.ExtractVariablesWithDefaults(info.Name, example.Template); err != nil {
+				t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+			}
+			result, err := renderer.Render(example.Template, example.Values, RenderOptions{MissingKey: MissingKeyError})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if strings.Contains(result.Output, "<no value>") {
+				t.Errorf("Render() output has missing values:\n%s", result.Output)
+			}
+		})
+	}
+
+	engine, err := NewTutorialEngine(parser, renderer)
+	if err != nil {
+		t.Fatalf("NewTutorialEngine() error = %v", err)
+	}
+	if _, err := engine.GetLesson("hello-field"); err != nil {
+		t.Errorf("GetLesson() error = %v", err)
+	}
+}
+
+// TestGetExample_Values tests that example values decode like the values entered in the
+// playground, with integers as ints
+func TestGetExample_Values(t *testing.T) {
+	example, err := GetExample(ProfileHelm, "deployment")
+	if err != nil {
+		t.Fatalf("GetExample() error = %v", err)
+	}
+	values, _ := example.Values["Values"].(map[string]interface{})
+	if replicas, ok := values["replicaCount"].(int); !ok || replicas != 3 {
+		t.Errorf("GetExample() replicaCount = %#v, want 3", values["replicaCount"])
+	}
+}
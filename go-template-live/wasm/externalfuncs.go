@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ExternalFunc implements a template function outside Go, such as in the page's JavaScript in
+// WASM builds; it receives the arguments of the call
+type ExternalFunc func(args []interface{}) (interface{}, error)
+
+// functionNamePattern matches the identifiers text/template accepts as function names
+var functionNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RegisterExternalFunction registers fn as a template function taking arity arguments (-1 for any
+// number), available to parsing, extraction and rendering like the profile's functions
+// Variables are extracted from its arguments as for any function without an extractor. It cannot
+// replace a text/template builtin or a function of the profile, but can replace an external one
+func (r *FunctionRegistry) RegisterExternalFunction(name string, arity int, fn ExternalFunc) error {
+	if !functionNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid function name %q", name)
+	}
+	if builtinFunctions[name] {
+		return fmt.Errorf("cannot replace the builtin function %s", name)
+	}
+	if def, exists := r.GetFunction(name); exists && !def.External {
+		return fmt.Errorf("cannot replace the %s function %s", r.Profile(), name)
+	}
+	if arity < -1 {
+		return fmt.Errorf("invalid arity %d for %s", arity, name)
+	}
+
+	r.RegisterFunction(&FunctionDefinition{
+		Name:        name,
+		Description: "External function",
+		Handler:     externalHandler(name, arity, fn),
+		External:    true,
+	})
+	return nil
+}
+
+// externalHandler adapts an external function to text/template, which calls it with the
+// arguments of the action; a wrong number of arguments fails the action
+func externalHandler(name string, arity int, fn ExternalFunc) func(args ...interface{}) (interface{}, error) {
+	return func(args ...interface{}) (interface{}, error) {
+		if arity >= 0 && len(args) != arity {
+			return nil, fmt.Errorf("%s: expected %d arguments, got %d", name, arity, len(args))
+		}
+		return fn(args)
+	}
+}
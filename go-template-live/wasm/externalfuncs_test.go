@@ -0,0 +1,56 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRegisterExternalFunction tests that external functions parse, extract and render like
+// the profile's functions
+func TestRegisterExternalFunction(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{Name: "getv", Handler: func(string) string { return "" }})
+
+	upper := func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(args[0].(string)), nil
+	}
+	if err := registry.RegisterExternalFunction("shout", 1, upper); err != nil {
+		t.Fatalf("RegisterExternalFunction() error = %v", err)
+	}
+
+	template := `{{shout .Name}} {{.Count | shout}}`
+	names, err := NewParser(registry).ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"Name", "Count"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+
+	renderer := NewRenderer(registry, nil)
+	result, err := renderer.Render(template, map[string]interface{}{"Name": "app", "Count": "three"}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Output != "APP THREE" {
+		t.Errorf("Render() = %q, want %q", result.Output, "APP THREE")
+	}
+
+	_, err = renderer.Render(`{{shout "a" "b"}}`, nil, RenderOptions{})
+	if err == nil || !strings.Contains(err.Error(), "shout: expected 1 arguments, got 2") {
+		t.Errorf("Render() with two arguments error = %v, want an arity error", err)
+	}
+
+	if err := registry.RegisterExternalFunction("shout", -1, upper); err != nil {
+		t.Errorf("RegisterExternalFunction() replacing an external function error = %v", err)
+	}
+	for _, name := range []string{"len", "getv", "not-a-name", ""} {
+		if err := registry.RegisterExternalFunction(name, 1, upper); err == nil {
+			t.Errorf("RegisterExternalFunction(%q) succeeded, want an error", name)
+		}
+	}
+}
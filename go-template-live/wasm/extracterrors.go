@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// ExtractError is a failure to extract the variables of one template node
+type ExtractError struct {
+	Message string `json:"message"`
+	// Position is the node that failed; it is nil for errors that concern the whole template
+	Position *Position `json:"position,omitempty"`
+}
+
+// ExtractionErrors lists the failures collected under ErrorPolicyCollectAll
+type ExtractionErrors struct {
+	Errors []ExtractError
+}
+
+func (e *ExtractionErrors) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Message
+		if err.Position != nil && err.Position.Line > 0 {
+			messages[i] = fmt.Sprintf("line %d: %s", err.Position.Line, err.Message)
+		}
+	}
+	return fmt.Sprintf("%d extraction error(s): %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// collectError records err for node and reports whether the walk may continue past it
+func (p *Parser) collectError(node parse.Node, err error) bool {
+	if p.collected == nil {
+		return false
+	}
+	*p.collected = append(*p.collected, ExtractError{Message: err.Error(), Position: nodePosition(node.Position(), 0)})
+	return true
+}
+
+// maxSyntaxRepairs bounds the attempts made by repairSyntax
+const maxSyntaxRepairs = 64
+
+// repairSyntax makes a template that fails to parse parseable, so the variables of the rest can
+// still be extracted while it is being typed: blocks left open at the end are closed with
+// {{end}}, and any other failing line is blanked out. Blanking keeps every byte offset, so
+// positions found in the repaired content point into the original
+// It returns the repaired content and the syntax error of the original, or ok false when the
+// content parses as is or cannot be repaired
+func (p *Parser) repairSyntax(fileName, fileContent string) (repaired string, syntaxError ExtractError, ok bool) {
+	funcs := p.registry.GetMinimalFuncMap()
+	text := []byte(fileContent)
+	closers := ""
+	for attempt := 0; attempt < maxSyntaxRepairs; attempt++ {
+		source := string(text) + closers
+		_, err := template.New(fileName).Funcs(funcs).Parse(source)
+		if err == nil {
+			return source, syntaxError, attempt > 0
+		}
+		line, message := syntaxErrorLine(fileName, err)
+		if attempt == 0 {
+			syntaxError = ExtractError{Message: message, Position: linePosition(fileContent, line)}
+		}
+		if strings.HasSuffix(message, "unexpected EOF") {
+			closers += "{{end}}"
+			continue
+		}
+		if !blankLine(text, line) {
+			break
+		}
+	}
+	return "", syntaxError, false
+}
+
+// syntaxErrorLine splits a text/template parse error such as "template: app.tmpl:3: unexpected
+// EOF" into its 1-based line and message; the line is 0 when the error has none
+func syntaxErrorLine(fileName string, err error) (int, string) {
+	message := err.Error()
+	rest := strings.TrimPrefix(message, "template: "+fileName+":")
+	if rest == message {
+		return 0, message
+	}
+	lineText, detail, found := strings.Cut(rest, ":")
+	line, convErr := strconv.Atoi(lineText)
+	if !found || convErr != nil {
+		return 0, message
+	}
+	return line, strings.TrimSpace(detail)
+}
+
+// linePosition returns the position of a whole 1-based line of text, nil when out of range
+func linePosition(text string, line int) *Position {
+	lineStarts := newLineIndex(text)
+	if line < 1 || line > len(lineStarts) {
+		return nil
+	}
+	start := lineStarts[line-1]
+	end := len(text)
+	if line < len(lineStarts) {
+		end = lineStarts[line] - 1
+	}
+	return &Position{Offset: start, Length: end - start}
+}
+
+// blankLine replaces the 1-based line of text with spaces, or the nearest line above it that is
+// not blank yet, and reports whether any line was changed
+func blankLine(text []byte, line int) bool {
+	lines := bytes.SplitAfter(text, []byte("\n"))
+	if line > len(lines) {
+		line = len(lines)
+	}
+	for ; line >= 1; line-- {
+		current := lines[line-1]
+		if len(bytes.TrimSpace(current)) == 0 {
+			continue
+		}
+		for i, b := range current {
+			if b != '\n' {
+				current[i] = ' '
+			}
+		}
+		return true
+	}
+	return false
+}
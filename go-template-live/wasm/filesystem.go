@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FileSystem answers the file access of template functions such as fileExists
+// Native builds use the real filesystem; WASM builds, which have none, use a VirtualFS the
+// page populates
+type FileSystem interface {
+	// Exists reports whether a file or directory exists at name
+	Exists(name string) bool
+	// ReadFile returns the contents of the file at name
+	ReadFile(name string) ([]byte, error)
+}
+
+// SetRenderFS sets the filesystem seen by template functions
+func (e *Environment) SetRenderFS(fsys FileSystem) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fs = fsys
+}
+
+// ResetRenderFS restores the default filesystem of the build
+func (e *Environment) ResetRenderFS() {
+	e.SetRenderFS(defaultFileSystem())
+}
+
+// VirtualFS is an in-memory filesystem of file paths and contents
+// Directories exist implicitly as the parents of files
+type VirtualFS struct {
+	files map[string]string
+}
+
+// NewVirtualFS creates a filesystem holding files, keyed by slash-separated path
+func NewVirtualFS(files map[string]string) *VirtualFS {
+	cleaned := make(map[string]string, len(files))
+	for name, contents := range files {
+		cleaned[cleanVirtualPath(name)] = contents
+	}
+	return &VirtualFS{files: cleaned}
+}
+
+// Exists reports whether name is a file or the parent directory of one
+func (v *VirtualFS) Exists(name string) bool {
+	name = cleanVirtualPath(name)
+	if _, ok := v.files[name]; ok {
+		return true
+	}
+	prefix := strings.TrimSuffix(name, "/") + "/"
+	for file := range v.files {
+		if strings.HasPrefix(file, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadFile returns the contents of the file at name
+func (v *VirtualFS) ReadFile(name string) ([]byte, error) {
+	contents, ok := v.files[cleanVirtualPath(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return []byte(contents), nil
+}
+
+// cleanVirtualPath normalizes a path so /etc/app.conf, etc/app.conf and /etc//app.conf match
+func cleanVirtualPath(name string) string {
+	return path.Clean("/" + name)
+}
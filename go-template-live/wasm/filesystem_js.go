@@ -0,0 +1,9 @@
+//go:build js
+// +build js
+
+package main
+
+// defaultFileSystem is empty until the page provides files
+func defaultFileSystem() FileSystem {
+	return NewVirtualFS(nil)
+}
@@ -0,0 +1,24 @@
+//go:build !js
+// +build !js
+
+package main
+
+import "os"
+
+// osFileSystem reads the host filesystem
+type osFileSystem struct{}
+
+func defaultFileSystem() FileSystem {
+	return osFileSystem{}
+}
+
+// Exists treats any error other than "not exist", such as a permission error, as existing,
+// like confd's fileExists
+func (osFileSystem) Exists(name string) bool {
+	_, err := os.Stat(name)
+	return !os.IsNotExist(err)
+}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
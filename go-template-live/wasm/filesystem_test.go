@@ -0,0 +1,57 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVirtualFS(t *testing.T) {
+	vfs := NewVirtualFS(map[string]string{
+		"/etc/app/tls.crt": "cert",
+		"etc/app/conf.d/a": "a",
+	})
+
+	for _, name := range []string{"/etc/app/tls.crt", "etc/app/tls.crt", "/etc//app/./tls.crt", "/etc/app", "/etc/app/", "/etc/app/conf.d/a", "/"} {
+		if !vfs.Exists(name) {
+			t.Errorf("Exists(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"/etc/app/tls.key", "/etc/ap", "/var"} {
+		if vfs.Exists(name) {
+			t.Errorf("Exists(%q) = true, want false", name)
+		}
+	}
+
+	contents, err := vfs.ReadFile("etc/app/tls.crt")
+	if err != nil || string(contents) != "cert" {
+		t.Errorf("ReadFile() = %q, %v, want cert", contents, err)
+	}
+	if _, err := vfs.ReadFile("/etc/app"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile() of a directory error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestOSFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(name, []byte("port = 80"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := defaultFileSystem()
+	if !fsys.Exists(name) || !fsys.Exists(dir) {
+		t.Errorf("Exists() = false for an existing file or directory")
+	}
+	if fsys.Exists(filepath.Join(dir, "missing.conf")) {
+		t.Errorf("Exists() = true for a missing file")
+	}
+	if contents, err := fsys.ReadFile(name); err != nil || string(contents) != "port = 80" {
+		t.Errorf("ReadFile() = %q, %v", contents, err)
+	}
+}
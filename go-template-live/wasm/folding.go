@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// Kinds of folding ranges, as in the Language Server Protocol
+const (
+	FoldingRegion  = "region"
+	FoldingComment = "comment"
+)
+
+// FoldingRange is a part of a template the editor can collapse
+type FoldingRange struct {
+	// StartLine and EndLine are 1-based; StartLine stays visible when the range is folded
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+	// StartOffset and EndOffset are the byte offsets of the range, from the opening action to
+	// the end of its {{end}}, or of a comment section
+	StartOffset int    `json:"startOffset"`
+	EndOffset   int    `json:"endOffset"`
+	Kind        string `json:"kind"`
+	// Keyword is if, range, with, define or block for regions
+	Keyword string `json:"keyword,omitempty"`
+}
+
+// FoldingRanges returns the foldable ranges of a template spanning more than one line: its
+// if, range, with, define and block blocks up to their {{end}}, and comments, consecutive
+// comment-only lines being one range. Ranges are sorted by start, enclosing ranges first
+func FoldingRanges(fileName, fileContent string) ([]FoldingRange, error) {
+	tree := parse.New(fileName)
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(fileContent, "", "", treeSet); err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
+	}
+
+	lines := newLineIndex(fileContent)
+	lineOf := func(offset int) int {
+		return sort.Search(len(lines), func(j int) bool { return lines[j] > offset })
+	}
+	ranges := []FoldingRange{}
+	add := func(start, end int, kind, keyword string) {
+		startLine, endLine := lineOf(start), lineOf(end-1)
+		if endLine > startLine {
+			ranges = append(ranges, FoldingRange{StartLine: startLine, EndLine: endLine, StartOffset: start, EndOffset: end, Kind: kind, Keyword: keyword})
+		}
+	}
+
+	// Blocks: each opening action paired with its {{end}}
+	type openBlock struct {
+		start   int
+		keyword string
+	}
+	actions := scanActions(fileContent)
+	var open []openBlock
+	for _, a := range actions {
+		switch keyword := actionKeyword(fileContent, a); {
+		case blockKeywords[keyword]:
+			open = append(open, openBlock{start: a.start, keyword: keyword})
+		case keyword == "end" && len(open) > 0:
+			block := open[len(open)-1]
+			open = open[:len(open)-1]
+			add(block.start, a.end, FoldingRegion, block.keyword)
+		}
+	}
+
+	// Comments, merged across lines holding nothing else
+	var comments []*parse.CommentNode
+	for _, t := range treeSet {
+		collectComments(t.Root, &comments, 0)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Pos < comments[j].Pos })
+	sectionStart, sectionEnd := -1, -1
+	for _, c := range comments {
+		start, end := commentAction(actions, int(c.Pos))
+		if sectionStart >= 0 && strings.TrimSpace(fileContent[sectionEnd:start]) == "" && lineOf(start) <= lineOf(sectionEnd-1)+1 {
+			sectionEnd = end
+			continue
+		}
+		if sectionStart >= 0 {
+			add(sectionStart, sectionEnd, FoldingComment, "")
+		}
+		sectionStart, sectionEnd = start, end
+	}
+	if sectionStart >= 0 {
+		add(sectionStart, sectionEnd, FoldingComment, "")
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].StartOffset != ranges[j].StartOffset {
+			return ranges[i].StartOffset < ranges[j].StartOffset
+		}
+		return ranges[i].EndOffset > ranges[j].EndOffset
+	})
+	return ranges, nil
+}
+
+// commentAction returns the extent of the action holding the comment at pos, delimiters and
+// trim markers included
+func commentAction(actions []templateAction, pos int) (int, int) {
+	i := sort.Search(len(actions), func(j int) bool { return actions[j].end > pos })
+	if i < len(actions) && actions[i].start <= pos {
+		return actions[i].start, actions[i].end
+	}
+	return pos, pos
+}
@@ -0,0 +1,54 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFoldingRanges(t *testing.T) {
+	template := `{{/* Header comment,
+spanning lines */}}
+{{/* one */}}
+{{- /* two */}}
+{{define "item"}}
+  {{- if .Enabled}}
+  {{range .Items}}{{.}}{{end}}
+  {{range .Ports}}
+    - {{.}}
+  {{end}}
+  {{- else}}
+  none
+  {{- end}}
+{{end}}
+{{/* single line */}}
+{{with .X}}{{.}}{{end}}
+`
+	ranges, err := FoldingRanges("app.tmpl", template)
+	if err != nil {
+		t.Fatalf("FoldingRanges() error = %v", err)
+	}
+	var got []string
+	for _, r := range ranges {
+		got = append(got, fmt.Sprintf("%s %s %d-%d", r.Kind, r.Keyword, r.StartLine, r.EndLine))
+	}
+	want := []string{
+		"comment  1-4",
+		"region define 5-14",
+		"region if 6-13",
+		"region range 8-10",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ranges = %v, want %v", got, want)
+	}
+	if ranges[1].StartOffset != len("{{/* Header comment,\nspanning lines */}}\n{{/* one */}}\n{{- /* two */}}\n") || template[ranges[1].EndOffset-len("{{end}}"):ranges[1].EndOffset] != "{{end}}" {
+		t.Errorf("define range offsets = %d-%d", ranges[1].StartOffset, ranges[1].EndOffset)
+	}
+
+	if _, err := FoldingRanges("app.tmpl", "{{if .A}}"); err == nil {
+		t.Error("FoldingRanges() accepted an unclosed block")
+	}
+}
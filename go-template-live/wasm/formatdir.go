@@ -0,0 +1,59 @@
+//go:build !js
+// +build !js
+
+// This file contains formatting of template directories on disk for native builds
+// Tag: !js (the browser playground formats the open template with formatTemplate instead)
+// The module builds no native command; FormatTemplateDir is the library side of a `fmt`
+// subcommand for the embedding tool to expose
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FormatTemplateDir formats every template below dir (files ending in .tmpl, .tpl, .gotmpl or
+// .template) and returns the paths, relative to dir, of those whose formatting differs, sorted
+// With write, the formatted templates are written back, like gofmt -l -w
+func (p *Parser) FormatTemplateDir(dir string, opts FormatOptions, write bool) ([]string, error) {
+	changed := []string{}
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !templateExtensions[filepath.Ext(name)] {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		formatted, err := p.FormatTemplate(rel, string(content), opts)
+		if err != nil {
+			return fmt.Errorf("%s: %v", rel, err)
+		}
+		if formatted == string(content) {
+			return nil
+		}
+		changed = append(changed, rel)
+		if write {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(name, []byte(formatted), info.Mode().Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error formatting template directory: %v", err)
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// FormSchema is a JSON Schema plus UI schema, the input format of react-jsonschema-form (RJSF)
+// and, for the schema part, JSON Forms
+type FormSchema struct {
+	Schema   map[string]interface{} `json:"schema"`
+	UISchema map[string]interface{} `json:"uiSchema"`
+}
+
+// formWidgets maps type hints to RJSF widgets; unlisted types use the default text input
+var formWidgets = map[string]string{
+	TypeBool:       "checkbox",
+	TypeNumber:     "updown",
+	TypeJSONString: "textarea",
+}
+
+// GenerateFormSchema builds a form for the values a template needs
+// Fields appear in order of first use, nested by dotted name; labels and help text come from
+// @var pragma descriptions, defaults are typed by the type hint, secrets use a password input,
+// and variables without a
+// default that are always used (no guarding condition) or passed to required are required
+func GenerateFormSchema(variables []VariableInfo) *FormSchema {
+	schema, uiSchema := formObject(buildVariableTree(variables))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	return &FormSchema{Schema: schema, UISchema: uiSchema}
+}
+
+// formObject returns the schema and UI schema of an object node
+func formObject(node *variableTree) (map[string]interface{}, map[string]interface{}) {
+	properties := make(map[string]interface{}, len(node.children))
+	uiSchema := map[string]interface{}{"ui:order": append([]string{}, node.order...)}
+	required := []string{}
+
+	for _, name := range node.order {
+		child := node.children[name]
+		if len(child.children) > 0 {
+			properties[name], uiSchema[name] = formObject(child)
+			continue
+		}
+
+		v := child.variable
+		properties[name] = formField(name, v)
+		if widget, ok := formWidgets[v.Type]; ok {
+			uiSchema[name] = map[string]interface{}{"ui:widget": widget}
+		}
+		if v.Sensitive {
+			uiSchema[name] = map[string]interface{}{"ui:widget": "password"}
+		}
+		if v.isRequired() {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, uiSchema
+}
+
+// formField returns the JSON Schema of a single value
+func formField(name string, v *VariableInfo) map[string]interface{} {
+	field := map[string]interface{}{"title": name}
+	if v.Description != "" {
+		field["title"] = v.Description
+		field["description"] = v.Name
+	}
+	if v.RequiredMessage != "" {
+		field["description"] = v.RequiredMessage
+	}
+
+	switch v.Type {
+	case TypeNumber:
+		field["type"] = "number"
+	case TypeBool:
+		field["type"] = "boolean"
+	case TypeArray:
+		field["type"] = "array"
+		field["items"] = map[string]interface{}{"type": "string"}
+	default:
+		field["type"] = "string"
+	}
+
+	if v.DefaultValue != "" {
+		if value, ok := v.typedDefault(); ok {
+			field["default"] = value
+		}
+	}
+	return field
+}
+
+// typedDefault converts the default of v using its type hint, or the literal type of the default
+// (e.g. 8080 in {{getv "port" 8080}}) when there is no hint
+func (v *VariableInfo) typedDefault() (interface{}, bool) {
+	typeHint := v.Type
+	if typeHint == "" {
+		typeHint = v.DefaultType
+	}
+	return typedDefault(typeHint, v.DefaultValue)
+}
+
+// typedDefault converts a default value to the field type; defaults that do not convert are dropped
+// rather than producing a schema that fails its own validation
+func typedDefault(typeHint, value string) (interface{}, bool) {
+	switch typeHint {
+	case TypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		return n, err == nil
+	case TypeBool:
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	case TypeArray:
+		var items []interface{}
+		err := json.Unmarshal([]byte(value), &items)
+		return items, err == nil
+	}
+	return value, true
+}
@@ -0,0 +1,39 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestGenerateFormSchema tests field order, labels, typed defaults, widgets and required fields
+func TestGenerateFormSchema(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{/* @var Server.Port type=number default=8080 description="Listen port" */}}
+{{/* @var Debug default=yes type=bool */}}
+{{.Server.Port}} {{.Server.Host}}
+{{if .Debug}}{{.Level}}{{end}}
+{{range .Upstreams}}{{end}}`
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	got, err := json.Marshal(GenerateFormSchema(variables))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	expected := `{"schema":{"$schema":"http://json-schema.org/draft-07/schema#","properties":{` +
+		`"Debug":{"title":"Debug","type":"boolean"},` +
+		`"Level":{"title":"Level","type":"string"},` +
+		`"Server":{"properties":{"Host":{"title":"Host","type":"string"},"Port":{"default":8080,"description":"Server.Port","title":"Listen port","type":"number"}},"required":["Host"],"type":"object"},` +
+		`"Upstreams":{"items":{"type":"string"},"title":"Upstreams","type":"array"}},` +
+		`"required":["Upstreams"],"type":"object"},` +
+		`"uiSchema":{"Debug":{"ui:widget":"checkbox"},"Server":{"Port":{"ui:widget":"updown"},"ui:order":["Port","Host"]},"ui:order":["Server","Debug","Level","Upstreams"]}}`
+	if string(got) != expected {
+		t.Errorf("GenerateFormSchema() =\n%s\nwant\n%s", got, expected)
+	}
+}
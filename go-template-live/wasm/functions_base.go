@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strconv"
 	"text/template/parse"
 )
 
@@ -46,31 +47,96 @@ func extractArgVariableWithDefaults(args []parse.Node, cycle int, argIndex int,
 				// String literals are variable names to look up
 				stringNode := item.(*parse.StringNode)
 				varInfo := VariableInfo{
-					Name: stringNode.Text,
+					Name:     stringNode.Text,
+					Position: nodePosition(stringNode.Position(), len(stringNode.Quoted)),
 				}
 				// Check for default value
-				if defaultArgIndex > 0 && len(args) > defaultArgIndex && args[defaultArgIndex].Type() == parse.NodeString {
-					defaultValueNode := args[defaultArgIndex].(*parse.StringNode)
-					varInfo.DefaultValue = defaultValueNode.Text
+				if defaultArgIndex > 0 && len(args) > defaultArgIndex {
+					varInfo.DefaultValue, varInfo.DefaultType = literalDefault(args[defaultArgIndex])
 				}
 				result = append(result, varInfo)
 			}
 			// else: string literals are just data, skip them
 		} else {
-			// For complex expressions, extract variable names without defaults
+			// For complex expressions, extract variables without defaults (positions are kept)
 			parser := NewParser(globalRegistry)
-			sonResult, err := parser.getFieldFromNode(item, cycle)
+			sonResult, err := parser.getFieldFromNodeWithDefaults(item, cycle)
 			if err != nil {
 				return nil, err
 			}
-			for _, name := range sonResult {
-				result = append(result, VariableInfo{Name: name})
-			}
+			result = append(result, withoutDefaults(sonResult)...)
 		}
 	}
 	return result, nil
 }
 
+// extractDefaultedVariables extracts the arguments of a sprig-style default: the value is the
+// second argument, or the piped value, and the default is the first and may be a field too,
+// as in {{default .Fallback .Name}}
+func extractDefaultedVariables(args []parse.Node, cycle int) ([]string, error) {
+	fallback, err := extractArgVariable(args, cycle, 1, false)
+	if err != nil {
+		return nil, err
+	}
+	value, err := extractArgVariable(args, cycle, 2, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(value, fallback...), nil
+}
+
+// extractDefaultedVariablesInfo records a literal default as the default value of the
+// variable it stands in for: {{default "web" .Name}} and {{.Name | default "web"}}
+func extractDefaultedVariablesInfo(args []parse.Node, cycle int) ([]VariableInfo, error) {
+	variables, err := extractArgVariableWithDefaults(args, cycle, 2, -1, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 2 {
+		switch args[2].(type) {
+		case *parse.FieldNode, *parse.VariableNode, *parse.ChainNode:
+			value, valueType := literalDefault(args[1])
+			for i := range variables {
+				variables[i].DefaultValue, variables[i].DefaultType = value, valueType
+			}
+		}
+	}
+	fallback, err := extractArgVariableWithDefaults(args, cycle, 1, -1, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(variables, fallback...), nil
+}
+
+// literalDefault returns the text and literal type of a default value argument
+// Strings keep their unquoted text; numbers keep their source text (e.g. 8080, 0x1F, 1.5) and
+// booleans are "true" or "false". Other nodes, such as field references, are not defaults
+func literalDefault(node parse.Node) (string, string) {
+	switch node := node.(type) {
+	case *parse.StringNode:
+		return node.Text, ""
+	case *parse.NumberNode:
+		return node.Text, TypeNumber
+	case *parse.BoolNode:
+		return strconv.FormatBool(node.True), TypeBool
+	}
+	return "", ""
+}
+
+// nodePosition records the byte offset and length of a node; line and column are resolved later
+func nodePosition(pos parse.Pos, length int) *Position {
+	return &Position{Offset: int(pos), Length: length}
+}
+
+// withoutDefaults strips default values, keeping names, positions and accessed fields
+func withoutDefaults(variables []VariableInfo) []VariableInfo {
+	result := make([]VariableInfo, 0, len(variables))
+	for _, v := range variables {
+		result = append(result, VariableInfo{Name: v.Name, Position: v.Position, Fields: v.Fields})
+	}
+	return result
+}
+
 // Legacy wrappers for backward compatibility
 
 // extractStringArgVariable treats string literals as variable names (for json, getv, etc.)
@@ -10,6 +10,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net"
 	"path"
 	"strconv"
 	"strings"
@@ -22,6 +23,11 @@ import (
 // This is called by both WASM (via init in functions_confd.go) and tests
 func registerConfdFunctions() {
 	registry := GetGlobalRegistry()
+	registry.SetProfile(ProfileConfd)
+	registerTypedGetvFunctions(registry)
+	registerRequiredFunction(registry)
+	registerSecretFunction(registry)
+	registerLocaleFunctions(registry)
 
 	// Custom functions (getv, exists, get)
 	// getv - Get variable value with optional default
@@ -31,6 +37,7 @@ func registerConfdFunctions() {
 		Handler:               getvMinimalHandler,
 		Extractor:             extractGetvVariables,
 		ExtractorWithDefaults: extractGetvVariablesWithDefaults,
+		ArgNames:              []string{"key", "default"},
 	})
 
 	// exists - Check if variable exists (no default value support)
@@ -40,6 +47,7 @@ func registerConfdFunctions() {
 		Handler:               existsMinimalHandler,
 		Extractor:             extractKeyArgVariable,
 		ExtractorWithDefaults: extractKeyArgVariableInfo,
+		ArgNames:              []string{"key"},
 	})
 
 	// get - Get variable value (errors if not found, no default value support)
@@ -49,6 +57,47 @@ func registerConfdFunctions() {
 		Handler:               getMinimalHandler,
 		Extractor:             extractKeyArgVariable,
 		ExtractorWithDefaults: extractKeyArgVariableInfo,
+		ArgNames:              []string{"key"},
+	})
+
+	// gets - Get all key-value pairs matching a pattern
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "gets",
+		Description:           "Get all key-value pairs whose key matches a pattern such as /prefix/* (Confd-style)",
+		Handler:               getsMinimalHandler,
+		Extractor:             extractKeyArgVariable,
+		ExtractorWithDefaults: extractPatternArgVariableInfo,
+		ArgNames:              []string{"pattern"},
+	})
+
+	// getvs - Get the values of all keys matching a pattern
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "getvs",
+		Description:           "Get the values of all keys matching a pattern such as /prefix/* (Confd-style)",
+		Handler:               getvsMinimalHandler,
+		Extractor:             extractKeyArgVariable,
+		ExtractorWithDefaults: extractPatternArgVariableInfo,
+		ArgNames:              []string{"pattern"},
+	})
+
+	// ls - List the keys and directories under a directory
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "ls",
+		Description:           "List the names of the keys and directories directly under a directory (Confd-style)",
+		Handler:               lsMinimalHandler,
+		Extractor:             extractKeyArgVariable,
+		ExtractorWithDefaults: extractDirArgVariableInfo("/*"),
+		ArgNames:              []string{"dir"},
+	})
+
+	// lsdir - List the directories under a directory
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "lsdir",
+		Description:           "List the names of the directories directly under a directory (Confd-style)",
+		Handler:               lsMinimalHandler,
+		Extractor:             extractKeyArgVariable,
+		ExtractorWithDefaults: extractDirArgVariableInfo("/*/*"),
+		ArgNames:              []string{"dir"},
 	})
 
 	// base - Base function (path.Base) - extracts variables from first argument
@@ -76,6 +125,8 @@ func registerConfdFunctions() {
 		Handler:               jsonConfdMinimalHandler,
 		Extractor:             extractSingleStringArgVariable,
 		ExtractorWithDefaults: extractSingleStringArgVariableInfo,
+		ArgTypeHint:           TypeJSONString,
+		ArgNames:              []string{"key"},
 	})
 
 	// jsonArray - Parse JSON array
@@ -85,6 +136,8 @@ func registerConfdFunctions() {
 		Handler:               jsonArrayConfdMinimalHandler,
 		Extractor:             extractSingleStringArgVariable,
 		ExtractorWithDefaults: extractSingleStringArgVariableInfo,
+		ArgTypeHint:           TypeJSONString,
+		ArgNames:              []string{"key"},
 	})
 
 	// dir - Directory function (path.Dir) - extracts variables from first argument
@@ -96,6 +149,30 @@ func registerConfdFunctions() {
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
 	})
 
+	// filepathBase, filepathDir, filepathJoin - base, dir and join in the render path style
+	// (RenderOptions.PathStyle), so Windows paths use backslashes and drive letters
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "filepathBase",
+		Description:           "Returns the last element of a path in the render path style",
+		Handler:               baseMinimalHandler,
+		Extractor:             extractFirstArgVariable,
+		ExtractorWithDefaults: extractFirstArgVariableInfo,
+	})
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "filepathDir",
+		Description:           "Returns all but the last element of a path in the render path style",
+		Handler:               dirMinimalHandler,
+		Extractor:             extractFirstArgVariable,
+		ExtractorWithDefaults: extractFirstArgVariableInfo,
+	})
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "filepathJoin",
+		Description:           "Joins path elements with the separator of the render path style",
+		Handler:               filepathJoinMinimalHandler,
+		Extractor:             extractAllArgVariables,
+		ExtractorWithDefaults: extractAllArgVariablesInfo,
+	})
+
 	// map - Create map from key-value pairs - pure utility, no variable extraction
 	registry.RegisterFunction(&FunctionDefinition{
 		Name:                  "map",
@@ -193,6 +270,7 @@ func registerConfdFunctions() {
 		Handler:               parseBoolMinimalHandler,
 		Extractor:             extractFirstArgVariable,
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
+		ArgTypeHint:           TypeBool,
 	})
 
 	// reverse - Reverse array - pure utility, no variable extraction
@@ -211,6 +289,7 @@ func registerConfdFunctions() {
 		Handler:               addMinimalHandler,
 		Extractor:             extractFirstArgVariable,
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
+		ArgTypeHint:           TypeNumber,
 	})
 
 	// sub - Subtract numbers - extracts variables from first argument
@@ -220,6 +299,7 @@ func registerConfdFunctions() {
 		Handler:               subMinimalHandler,
 		Extractor:             extractFirstArgVariable,
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
+		ArgTypeHint:           TypeNumber,
 	})
 
 	// div - Divide numbers - extracts variables from first argument
@@ -229,6 +309,7 @@ func registerConfdFunctions() {
 		Handler:               divMinimalHandler,
 		Extractor:             extractFirstArgVariable,
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
+		ArgTypeHint:           TypeNumber,
 	})
 
 	// mod - Modulo operation - extracts variables from first argument
@@ -238,6 +319,7 @@ func registerConfdFunctions() {
 		Handler:               modMinimalHandler,
 		Extractor:             extractFirstArgVariable,
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
+		ArgTypeHint:           TypeNumber,
 	})
 
 	// mul - Multiply numbers - extracts variables from first argument
@@ -247,6 +329,7 @@ func registerConfdFunctions() {
 		Handler:               mulMinimalHandler,
 		Extractor:             extractFirstArgVariable,
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
+		ArgTypeHint:           TypeNumber,
 	})
 
 	// seq - Generate sequence - extracts variables from first argument
@@ -256,6 +339,34 @@ func registerConfdFunctions() {
 		Handler:               seqMinimalHandler,
 		Extractor:             extractFirstArgVariable,
 		ExtractorWithDefaults: extractFirstArgVariableInfo,
+		ArgTypeHint:           TypeNumber,
+	})
+
+	// lookupIP - Resolve a host name - extracts variables from the host argument
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "lookupIP",
+		Description:           "Resolves a host name to its sorted IP addresses",
+		Handler:               lookupIPMinimalHandler,
+		Extractor:             extractFirstArgVariable,
+		ExtractorWithDefaults: extractFirstArgVariableInfo,
+	})
+
+	// lookupSRV - Resolve SRV records - extracts variables from any argument
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "lookupSRV",
+		Description:           "Resolves the SRV records of a service, sorted by target and port",
+		Handler:               lookupSRVMinimalHandler,
+		Extractor:             extractAllArgVariables,
+		ExtractorWithDefaults: extractAllArgVariablesInfo,
+	})
+
+	// fileExists - Check a file path - extracts variables from the path argument
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "fileExists",
+		Description:           "Checks if a file or directory exists",
+		Handler:               fileExistsMinimalHandler,
+		Extractor:             extractFirstArgVariable,
+		ExtractorWithDefaults: extractFirstArgVariableInfo,
 	})
 
 	// atoi - String to integer - pure utility, no variable extraction
@@ -286,13 +397,17 @@ func base64DecodeMinimalHandler(data string) (string, error)                  {
 func trimSuffixMinimalHandler(s, suffix string) string                        { return "" }
 func parseBoolMinimalHandler(str string) (bool, error)                        { return false, nil }
 func reverseMinimalHandler(values interface{}) interface{}                    { return nil }
-func addMinimalHandler(a, b int) int                                          { return 0 }
-func subMinimalHandler(a, b int) int                                          { return 0 }
-func divMinimalHandler(a, b int) int                                          { return 0 }
-func modMinimalHandler(a, b int) int                                          { return 0 }
-func mulMinimalHandler(a, b int) int                                          { return 0 }
+func addMinimalHandler(a, b interface{}) (interface{}, error)                 { return nil, nil }
+func subMinimalHandler(a, b interface{}) (interface{}, error)                 { return nil, nil }
+func divMinimalHandler(a, b interface{}) (interface{}, error)                 { return nil, nil }
+func modMinimalHandler(a, b interface{}) (interface{}, error)                 { return nil, nil }
+func mulMinimalHandler(a, b interface{}) (interface{}, error)                 { return nil, nil }
 func seqMinimalHandler(first, last int) []int                                 { return []int{} }
 func atoiMinimalHandler(s string) (int, error)                                { return 0, nil }
+func lookupIPMinimalHandler(host string) []string                             { return nil }
+func lookupSRVMinimalHandler(service, proto, name string) []*net.SRV          { return nil }
+func fileExistsMinimalHandler(filepath string) bool                           { return false }
+func filepathJoinMinimalHandler(elem ...string) string                        { return "" }
 
 // Variable extractors (used during template parsing)
 func extractNoVariables(args []parse.Node, cycle int) ([]string, error) {
@@ -325,11 +440,12 @@ func extractFirstArgVariableInfo(args []parse.Node, cycle int) ([]VariableInfo,
 
 // GetConfdRenderFuncMap returns a function map with all Confd-style functions for rendering
 // This is used by both the WASM build (via CreateRenderFuncMap) and tests
-func GetConfdRenderFuncMap(variables map[string]interface{}) template.FuncMap {
+func GetConfdRenderFuncMap(variables map[string]interface{}, env *renderEnv) template.FuncMap {
+	store := env.keyStore(variables)
 	return template.FuncMap{
 		// Custom functions (getv, exists, get)
 		"getv": func(key string, v ...string) string {
-			if val, exists := variables[key]; exists {
+			if val, exists := store.Get(key); exists {
 				if strVal, ok := val.(string); ok && strVal != "" {
 					return strVal
 				}
@@ -339,22 +455,37 @@ func GetConfdRenderFuncMap(variables map[string]interface{}) template.FuncMap {
 			}
 			return ""
 		},
-		"exists": func(key string) bool {
-			_, exists := variables[key]
-			return exists
-		},
+		"exists": store.Exists,
+		"gets":   store.GetAll,
+		"ls":     store.List,
+		"lsdir":  store.ListDir,
+		"getvs":  store.GetAllValues,
 		"get": func(key string) (interface{}, error) {
-			if val, exists := variables[key]; exists {
+			if val, exists := store.Get(key); exists {
 				return val, nil
 			}
 			return nil, fmt.Errorf("key %s not found", key)
 		},
+		"required": requiredRenderHandler,
+		"secret":   secretRenderHandler(store),
+		// Locale-aware formatting
+		"formatNumber":   formatNumberRenderHandler,
+		"formatCurrency": formatCurrencyRenderHandler,
+		"formatDate":     formatDateRenderHandler,
+		// Typed getv variants
+		"getvInt":   getvIntRenderHandler(store),
+		"getvBool":  getvBoolRenderHandler(store),
+		"getvFloat": getvFloatRenderHandler(store),
+		"getvJSON":  getvJSONRenderHandler(store),
 		// Confd functions
 		"base":         func(s string) string { return path.Base(s) },
 		"split":        func(s, sep string) []string { return strings.Split(s, sep) },
 		"dir":          func(s string) string { return path.Dir(s) },
+		"filepathBase": env.filepathBase,
+		"filepathDir":  env.filepathDir,
+		"filepathJoin": env.filepathJoin,
 		"join":         func(elems []string, sep string) string { return strings.Join(elems, sep) },
-		"datetime":     func() time.Time { return time.Now() },
+		"datetime":     env.currentTime,
 		"toUpper":      func(s string) string { return strings.ToUpper(s) },
 		"toLower":      func(s string) string { return strings.ToLower(s) },
 		"replace":      func(s, old, new string, n int) string { return strings.Replace(s, old, new, n) },
@@ -366,11 +497,11 @@ func GetConfdRenderFuncMap(variables map[string]interface{}) template.FuncMap {
 		},
 		"trimSuffix": func(s, suffix string) string { return strings.TrimSuffix(s, suffix) },
 		"parseBool":  func(str string) (bool, error) { return strconv.ParseBool(str) },
-		"add":        func(a, b int) int { return a + b },
-		"sub":        func(a, b int) int { return a - b },
-		"div":        func(a, b int) int { return a / b },
-		"mod":        func(a, b int) int { return a % b },
-		"mul":        func(a, b int) int { return a * b },
+		"add":        addRenderHandler,
+		"sub":        subRenderHandler,
+		"div":        divRenderHandler,
+		"mod":        modRenderHandler,
+		"mul":        mulRenderHandler,
 		"seq": func(first, last int) []int {
 			var result []int
 			for i := first; i <= last; i++ {
@@ -378,7 +509,10 @@ func GetConfdRenderFuncMap(variables map[string]interface{}) template.FuncMap {
 			}
 			return result
 		},
-		"atoi": func(s string) (int, error) { return strconv.Atoi(s) },
+		"atoi":       func(s string) (int, error) { return strconv.Atoi(s) },
+		"lookupIP":   env.lookupIP,
+		"lookupSRV":  env.lookupSRV,
+		"fileExists": env.fs.Exists,
 		"map": func(values ...interface{}) (map[string]interface{}, error) {
 			dict := make(map[string]interface{}, len(values)/2)
 			for i := 0; i < len(values); i += 2 {
@@ -409,7 +543,7 @@ func GetConfdRenderFuncMap(variables map[string]interface{}) template.FuncMap {
 		},
 		// JSON functions that look up variables
 		"json": func(key string) (map[string]interface{}, error) {
-			if val, ok := variables[key]; ok {
+			if val, ok := store.Get(key); ok {
 				if str, ok := val.(string); ok {
 					var result map[string]interface{}
 					err := json.Unmarshal([]byte(str), &result)
@@ -419,7 +553,7 @@ func GetConfdRenderFuncMap(variables map[string]interface{}) template.FuncMap {
 			return nil, nil
 		},
 		"jsonArray": func(key string) ([]interface{}, error) {
-			if val, ok := variables[key]; ok {
+			if val, ok := store.Get(key); ok {
 				if str, ok := val.(string); ok {
 					var result []interface{}
 					err := json.Unmarshal([]byte(str), &result)
@@ -440,6 +574,18 @@ func existsMinimalHandler(key string) bool {
 	return false
 }
 
+func lsMinimalHandler(dir string) []string {
+	return nil
+}
+
+func getsMinimalHandler(pattern string) ([]KVPair, error) {
+	return nil, nil
+}
+
+func getvsMinimalHandler(pattern string) ([]string, error) {
+	return nil, nil
+}
+
 func getMinimalHandler(key string) (interface{}, error) {
 	return nil, nil
 }
@@ -455,6 +601,33 @@ func extractKeyArgVariableInfo(args []parse.Node, cycle int) ([]VariableInfo, er
 	return extractStringArgVariableWithDefaults(args, cycle, 1, -1)
 }
 
+// extractPatternArgVariableInfo extracts the key pattern of gets and getvs as a wildcard variable
+func extractPatternArgVariableInfo(args []parse.Node, cycle int) ([]VariableInfo, error) {
+	variables, err := extractStringArgVariableWithDefaults(args, cycle, 1, -1)
+	for i := range variables {
+		variables[i].Wildcard = true
+	}
+	return variables, err
+}
+
+// extractDirArgVariableInfo returns an extractor recording the directory of ls or lsdir as the
+// wildcard key pattern of what it lists: the directory followed by suffix
+func extractDirArgVariableInfo(suffix string) VariableExtractorWithDefaults {
+	return func(args []parse.Node, cycle int) ([]VariableInfo, error) {
+		variables, err := extractStringArgVariableWithDefaults(args, cycle, 1, -1)
+		if len(args) < 2 {
+			return variables, err
+		}
+		if dir, ok := args[1].(*parse.StringNode); ok {
+			for i := range variables {
+				variables[i].Name = strings.TrimSuffix(joinKey(splitKey(dir.Text)), "/") + suffix
+				variables[i].Wildcard = true
+			}
+		}
+		return variables, err
+	}
+}
+
 // getv is special - it supports default values
 func extractGetvVariables(args []parse.Node, cycle int) ([]string, error) {
 	return extractStringArgVariable(args, cycle, 1)
@@ -4,7 +4,10 @@
 package main
 
 import (
+	"encoding/json"
+	"net"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"text/template"
@@ -20,6 +23,18 @@ func createConfdParser() *Parser {
 	return NewParser(GetGlobalRegistry())
 }
 
+// createConfdRenderer creates a renderer backed by the Confd render function map
+func createConfdRenderer() *Renderer {
+	registerConfdFunctions()
+	return NewRenderer(GetGlobalRegistry(), func(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+		result := make(map[string]interface{})
+		for name, fn := range GetConfdRenderFuncMap(variables, env) {
+			result[name] = fn
+		}
+		return result
+	})
+}
+
 // TestEndToEnd_ConfdFunctions tests the complete workflow with Confd-style functions:
 // 1. Extract variables from template
 // 2. Provide values for those variables
@@ -454,6 +469,11 @@ func TestConfdFunctions_VariableExtraction(t *testing.T) {
 			template:     `{{getv "username" "default"}}`,
 			expectedVars: []VariableInfo{{Name: "username", DefaultValue: "default"}},
 		},
+		{
+			name:         "getv function with number and boolean defaults",
+			template:     `{{getv "port" 8080}} {{getv "debug" false}} {{getv "host" .fallback}}`,
+			expectedVars: []VariableInfo{{Name: "port", DefaultValue: "8080"}, {Name: "debug", DefaultValue: "false"}, {Name: "host"}},
+		},
 		{
 			name:         "exists function extracts variable",
 			template:     `{{exists "config"}}`,
@@ -490,7 +510,7 @@ func TestConfdFunctions_VariableExtraction(t *testing.T) {
 // Uses the actual production implementation from functions_confd_core.go
 func renderTemplateWithConfdFunctions(templateContent string, variables map[string]interface{}) (string, error) {
 	// Use the actual production implementation - this is what we're testing!
-	funcMap := GetConfdRenderFuncMap(variables)
+	funcMap := GetConfdRenderFuncMap(variables, defaultRenderEnv())
 
 	tmpl, err := template.New("test").Funcs(funcMap).Parse(templateContent)
 	if err != nil {
@@ -505,3 +525,864 @@ func renderTemplateWithConfdFunctions(templateContent string, variables map[stri
 
 	return result.String(), nil
 }
+
+// TestConfdExamples_RenderWithSampleValues tests that every embedded confd example parses and renders
+func TestConfdExamples_RenderWithSampleValues(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	examples, err := ListExamples(ProfileConfd)
+	if err != nil {
+		t.Fatalf("ListExamples() error = %v", err)
+	}
+	if len(examples) == 0 {
+		t.Fatal("ListExamples() returned no examples")
+	}
+
+	for _, info := range examples {
+		t.Run(info.Name, func(t *testing.T) {
+			example, err := GetExample(ProfileConfd, info.Name)
+			if err != nil {
+				t.Fatalf("GetExample() error = %v", err)
+			}
+			if _, err := parserConfd.ExtractVariablesWithDefaults(info.Name, example.Template); err != nil {
+				t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+			}
+			if _, err := renderTemplateWithConfdFunctions(example.Template, example.Values); err != nil {
+				t.Fatalf("renderTemplateWithConfdFunctions() error = %v", err)
+			}
+		})
+	}
+}
+
+// TestConfdRenderer_Deterministic tests that deterministic mode freezes datetime
+func TestConfdRenderer_Deterministic(t *testing.T) {
+	renderer := createConfdRenderer()
+	template := `{{datetime.Format "2006-01-02T15:04:05Z07:00"}}`
+
+	tests := []struct {
+		name           string
+		opts           RenderOptions
+		expectedOutput string
+	}{
+		{
+			name:           "default frozen time",
+			opts:           RenderOptions{Deterministic: true},
+			expectedOutput: "2000-01-01T00:00:00Z",
+		},
+		{
+			name:           "explicit frozen time",
+			opts:           RenderOptions{Deterministic: true, FrozenTime: 1700000000000},
+			expectedOutput: "2023-11-14T22:13:20Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				result, err := renderer.Render(template, map[string]interface{}{}, tt.opts)
+				if err != nil {
+					t.Fatalf("Render() error = %v", err)
+				}
+				if result.Output != tt.expectedOutput {
+					t.Errorf("Render() output = %q, want %q", result.Output, tt.expectedOutput)
+				}
+			}
+		})
+	}
+
+	if _, ok := renderer.Environment().clock.(systemClock); !ok {
+		t.Error("Render() changed the clock of the environment in a deterministic render")
+	}
+}
+
+// TestConfdReview_Summary tests the scored review of Confd templates
+func TestConfdReview_Summary(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	tests := []struct {
+		name             string
+		template         string
+		expectedScore    int
+		expectedRequired []string
+		expectedCodes    []string
+	}{
+		{
+			name:             "all defaults",
+			template:         `{{getv "host" "localhost"}}:{{getv "port" "80"}}`,
+			expectedScore:    100,
+			expectedRequired: []string{},
+			expectedCodes:    []string{},
+		},
+		{
+			name:             "required variable",
+			template:         `{{getv "host" "localhost"}}:{{getv "port"}}`,
+			expectedScore:    78,
+			expectedRequired: []string{"port"},
+			expectedCodes:    []string{"getv-no-default", "required-variables"},
+		},
+		{
+			name:             "conflicting defaults",
+			template:         `{{getv "host" "localhost"}} {{getv "host" "127.0.0.1"}}`,
+			expectedScore:    90,
+			expectedRequired: []string{},
+			expectedCodes:    []string{"conflicting-defaults"},
+		},
+		{
+			name:             "deep nesting",
+			template:         `{{if .A}}{{if .B}}{{if .C}}{{if .D}}{{if .E}}x{{end}}{{end}}{{end}}{{end}}{{end}}`,
+			expectedScore:    76,
+			expectedRequired: []string{"A", "B", "C", "D", "E"},
+			expectedCodes:    []string{"nesting-depth", "required-variables"},
+		},
+		{
+			name:             "lint diagnostics",
+			template:         `{{$unused := getv "host" "localhost"}}password: hunter22`,
+			expectedScore:    65,
+			expectedRequired: []string{},
+			expectedCodes:    []string{"unused-variable", "literal-secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := parserConfd.Review("test.tmpl", tt.template)
+			if err != nil {
+				t.Fatalf("Review() error = %v", err)
+			}
+			if summary.Score != tt.expectedScore {
+				t.Errorf("Review() score = %d, want %d (findings %+v)", summary.Score, tt.expectedScore, summary.Findings)
+			}
+			if !reflect.DeepEqual(summary.RequiredVariables, tt.expectedRequired) {
+				t.Errorf("Review() required = %v, want %v", summary.RequiredVariables, tt.expectedRequired)
+			}
+			codes := []string{}
+			for _, finding := range summary.Findings {
+				codes = append(codes, finding.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.expectedCodes) {
+				t.Errorf("Review() finding codes = %v, want %v", codes, tt.expectedCodes)
+			}
+		})
+	}
+}
+
+// TestConfdRenderer_InjectedClock tests that datetime uses the clock set with SetRenderClock
+func TestConfdRenderer_InjectedClock(t *testing.T) {
+	renderer := createConfdRenderer()
+	renderer.Environment().SetRenderClock(1700000000000)
+
+	result, err := renderer.Render(`{{datetime.Year}}-{{datetime.Unix}}`, map[string]interface{}{}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Output != "2023-1700000000" {
+		t.Errorf("Render() output = %q, want %q", result.Output, "2023-1700000000")
+	}
+
+	// Deterministic renders take precedence and leave the injected clock in place
+	if _, err := renderer.Render(`{{datetime}}`, map[string]interface{}{}, RenderOptions{Deterministic: true}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	result, err = renderer.Render(`{{datetime.Unix}}`, map[string]interface{}{}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Output != "1700000000" {
+		t.Errorf("Render() after deterministic render = %q, want %q", result.Output, "1700000000")
+	}
+}
+
+// TestConfdComparer_Profiles tests comparing confd against official rendering
+func TestConfdComparer_Profiles(t *testing.T) {
+	comparer := NewComparer(createConfdRenderer())
+	values := map[string]interface{}{"Name": "web", "port": "8080"}
+
+	result, err := comparer.Compare("name={{.Name}}\nport={{getv \"port\"}}\n", values,
+		CompareSide{Profile: ProfileConfd}, CompareSide{Profile: ProfileOfficial})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Identical {
+		t.Error("Compare() expected outputs to differ")
+	}
+	if !reflect.DeepEqual(result.Left.UnavailableFunctions, []string{}) {
+		t.Errorf("Compare() left unavailable = %v, want none", result.Left.UnavailableFunctions)
+	}
+	if !reflect.DeepEqual(result.Right.UnavailableFunctions, []string{"getv"}) {
+		t.Errorf("Compare() right unavailable = %v, want [getv]", result.Right.UnavailableFunctions)
+	}
+	if result.Right.Error == "" {
+		t.Error("Compare() expected official render to fail on getv")
+	}
+
+	// Same profile, different options
+	result, err = comparer.Compare(`<b>{{.Name}}</b>`, map[string]interface{}{"Name": "<i>"},
+		CompareSide{Options: RenderOptions{OutputFormat: OutputFormatText}},
+		CompareSide{Options: RenderOptions{OutputFormat: OutputFormatHTML}})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	expectedDiff := []DiffLine{
+		{Op: DiffDelete, Text: "<b><i></b>"},
+		{Op: DiffInsert, Text: "<b>&lt;i&gt;</b>"},
+	}
+	if !reflect.DeepEqual(result.Diff, expectedDiff) {
+		t.Errorf("Compare() diff = %v, want %v", result.Diff, expectedDiff)
+	}
+	if result.Stats != (DiffStats{Added: 1, Removed: 1}) {
+		t.Errorf("Compare() stats = %+v", result.Stats)
+	}
+
+	if _, err := comparer.Compare(`x`, nil, CompareSide{Profile: "sprig"}, CompareSide{}); err == nil {
+		t.Error("Compare() expected error for profile not compiled into this build")
+	}
+}
+
+// TestConfdRenderer_Timezone tests that datetime honours the render timezone
+func TestConfdRenderer_Timezone(t *testing.T) {
+	renderer := createConfdRenderer()
+	template := `{{datetime.Format "2006-01-02 15:04 MST"}}`
+
+	tests := []struct {
+		name           string
+		timezone       string
+		expectedOutput string
+		expectError    bool
+	}{
+		{name: "utc by default", expectedOutput: "2023-11-14 22:13 UTC"},
+		{name: "berlin", timezone: "Europe/Berlin", expectedOutput: "2023-11-14 23:13 CET"},
+		{name: "new york", timezone: "America/New_York", expectedOutput: "2023-11-14 17:13 EST"},
+		{name: "unknown zone", timezone: "Mars/Olympus_Mons", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := RenderOptions{Deterministic: true, FrozenTime: 1700000000000, Timezone: tt.timezone}
+			result, err := renderer.Render(template, map[string]interface{}{}, opts)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Render() expected error for unknown timezone")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if result.Output != tt.expectedOutput {
+				t.Errorf("Render() output = %q, want %q", result.Output, tt.expectedOutput)
+			}
+		})
+	}
+}
+
+// TestConfdExtraction_Positions tests that every occurrence records its source position
+func TestConfdExtraction_Positions(t *testing.T) {
+	parserConfd := createConfdParser()
+	template := "server {{getv \"host\" \"localhost\"}}\n  listen {{.Server.Port}};\n  # héllo {{base .path}}"
+
+	variables, err := parserConfd.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	expected := []VariableInfo{
+		{Name: "host", DefaultValue: "localhost", Position: &Position{Offset: 14, Line: 1, Column: 15, Length: 6}},
+		{Name: "Server.Port", Position: &Position{Offset: 46, Line: 2, Column: 12, Length: 12}},
+		{Name: "path", Position: &Position{Offset: 80, Line: 3, Column: 18, Length: 5}},
+	}
+	if !reflect.DeepEqual(variables, expected) {
+		t.Fatalf("ExtractVariablesWithPositions() = %+v, want %+v", variables, expected)
+	}
+	for _, v := range variables {
+		if got := template[v.Position.Offset : v.Position.Offset+v.Position.Length]; !strings.Contains(got, strings.Split(v.Name, ".")[0]) {
+			t.Errorf("position of %s points at %q", v.Name, got)
+		}
+	}
+}
+
+// TestConfdExtraction_Aggregated tests that aggregated extraction counts every occurrence once per name
+func TestConfdExtraction_Aggregated(t *testing.T) {
+	parserConfd := createConfdParser()
+	template := "{{base .filepath}} {{dir .filepath}}\n{{getv \"host\"}} {{getv \"host\" \"localhost\"}} {{toUpper .filepath}}"
+
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+
+	expected := []VariableInfo{
+		{Name: "filepath", Count: 3, Occurrences: []Position{
+			{Offset: 7, Line: 1, Column: 8, Length: 9},
+			{Offset: 25, Line: 1, Column: 26, Length: 9},
+			{Offset: 91, Line: 2, Column: 55, Length: 9},
+		}},
+		{Name: "host", DefaultValue: "localhost", Count: 2, Occurrences: []Position{
+			{Offset: 44, Line: 2, Column: 8, Length: 6},
+			{Offset: 60, Line: 2, Column: 24, Length: 6},
+		}},
+	}
+	if !reflect.DeepEqual(variables, expected) {
+		t.Errorf("ExtractVariablesAggregated() = %+v, want %+v", variables, expected)
+	}
+}
+
+// TestConfdExtraction_Options tests deduplication, sort orders and default stripping
+func TestConfdExtraction_Options(t *testing.T) {
+	parserConfd := createConfdParser()
+	template := "{{getv \"zone\"}} {{if .Enabled}}{{getv \"zone\" \"eu\"}}{{end}} {{base .app}} {{getv \"app\" \"web\"}}"
+
+	tests := []struct {
+		name     string
+		opts     ExtractOptions
+		expected []VariableInfo
+	}{
+		{
+			name: "defaults match plain extraction",
+			opts: DefaultExtractOptions(),
+			expected: []VariableInfo{
+				{Name: "zone"}, {Name: "Enabled", Type: TypeBool}, {Name: "zone", DefaultValue: "eu", DependsOn: []string{"Enabled"}},
+				{Name: "app"}, {Name: "app", DefaultValue: "web"},
+			},
+		},
+		{
+			name: "deduplicate keeps first non-empty default",
+			opts: ExtractOptions{Deduplicate: true, IncludeDefaults: true},
+			expected: []VariableInfo{
+				{Name: "zone", DefaultValue: "eu"}, {Name: "Enabled", Type: TypeBool}, {Name: "app", DefaultValue: "web"},
+			},
+		},
+		{
+			name: "deduplicate alpha without defaults",
+			opts: ExtractOptions{Deduplicate: true, Sort: SortAlpha},
+			expected: []VariableInfo{
+				{Name: "Enabled", Type: TypeBool}, {Name: "app"}, {Name: "zone"},
+			},
+		},
+		{
+			name: "document order",
+			opts: ExtractOptions{Deduplicate: true, Sort: SortDocument, IncludeDefaults: true},
+			expected: []VariableInfo{
+				{Name: "zone", DefaultValue: "eu"}, {Name: "Enabled", Type: TypeBool}, {Name: "app", DefaultValue: "web"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variables, err := parserConfd.ExtractVariablesWithOptions("test.tmpl", template, tt.opts)
+			if err != nil {
+				t.Fatalf("ExtractVariablesWithOptions() error = %v", err)
+			}
+			for i := range variables {
+				variables[i].Position = nil
+			}
+			if !reflect.DeepEqual(variables, tt.expected) {
+				t.Errorf("ExtractVariablesWithOptions() = %+v, want %+v", variables, tt.expected)
+			}
+		})
+	}
+
+	if _, err := parserConfd.ExtractVariablesWithOptions("test.tmpl", template, ExtractOptions{Sort: "random"}); err == nil {
+		t.Error("ExtractVariablesWithOptions() expected error for unknown sort order")
+	}
+}
+
+// TestConfdExtraction_TypeHints tests type hints inferred from usage context
+func TestConfdExtraction_TypeHints(t *testing.T) {
+	parserConfd := createConfdParser()
+	template := `{{range .Servers}}{{.}}{{end}}
+{{if .Enabled}}on{{end}}{{if and .A (not .B)}}x{{end}}
+{{add .Port 1}} {{.Workers | mul 2}} {{range seq .Count 9}}{{.}}{{end}}
+{{if parseBool (getv "/debug" "false")}}debug{{end}}
+{{$cfg := json "/config"}}{{.Name}}`
+
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"Servers": TypeArray,
+		"Enabled": TypeBool,
+		"A":       TypeBool,
+		"B":       TypeBool,
+		"Port":    TypeNumber,
+		"Workers": TypeNumber,
+		"Count":   TypeNumber,
+		"/debug":  TypeBool,
+		"/config": TypeJSONString,
+		"Name":    "",
+	}
+	got := make(map[string]string)
+	for _, v := range variables {
+		got[v.Name] = v.Type
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("type hints = %v, want %v", got, expected)
+	}
+
+	plain, err := parserConfd.ExtractVariablesWithDefaults("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+	}
+	for _, v := range plain {
+		if v.Type != "" {
+			t.Errorf("ExtractVariablesWithDefaults() kept type %q for %s", v.Type, v.Name)
+		}
+	}
+}
+
+// TestConfdExtraction_WithDefaultsShape tests that the legacy extraction keeps its JSON shape of
+// names and default values whatever else extraction finds
+func TestConfdExtraction_WithDefaultsShape(t *testing.T) {
+	parserConfd := createConfdParser()
+	template := `{{/* @var /app/port type=number description="The listen port" */}}{{if .Enabled}}{{getv "/app/port" "8080"}}{{end}}
+{{(json "/cfg").database.host}} {{secret "/db/password"}} {{len (gets "/upstreams/*")}}`
+
+	variables, err := parserConfd.ExtractVariablesWithDefaults("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+	}
+	data, err := json.Marshal(variables)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	expected := `[{"name":"Enabled"},{"name":"/app/port","defaultValue":"8080"},{"name":"/cfg"},{"name":"/db/password"},{"name":"/upstreams/*"}]`
+	if string(data) != expected {
+		t.Errorf("ExtractVariablesWithDefaults() JSON = %s, want %s", data, expected)
+	}
+}
+
+// TestConfdExtraction_DependsOn tests conditional dependencies between variables
+func TestConfdExtraction_DependsOn(t *testing.T) {
+	parserConfd := createConfdParser()
+	template := `{{if exists "/tls/enabled"}}cert={{getv "/tls/cert"}}{{if .Mtls}}ca={{.CA}}{{end}}{{end}}
+{{with .Proxy}}{{.Host}}{{end}}
+{{if .Debug}}{{.Level}}{{end}} {{.Level}} {{if .Debug}}{{.Debug}}{{end}}`
+
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+
+	expected := map[string][]string{
+		"/tls/enabled": nil,
+		"/tls/cert":    {"/tls/enabled"},
+		"Mtls":         {"/tls/enabled"},
+		"CA":           {"/tls/enabled", "Mtls"},
+		"Proxy":        nil,
+		"Host":         {"Proxy"},
+		"Debug":        nil,
+		"Level":        nil,
+	}
+	got := make(map[string][]string)
+	for _, v := range variables {
+		got[v.Name] = v.DependsOn
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("DependsOn = %v, want %v", got, expected)
+	}
+}
+
+// TestConfdExtraction_TypedDefaults tests number and boolean literal defaults of getv
+func TestConfdExtraction_TypedDefaults(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	variables, err := parserConfd.ExtractVariablesWithOptions("test.tmpl",
+		`{{getv "/app/port" 8080}} {{getv "/app/ratio" 0.5}} {{getv "/app/debug" true}} {{getv "/app/name" "web"}}`,
+		ExtractOptions{IncludeDefaults: true})
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithOptions() error = %v", err)
+	}
+
+	got := make(map[string][2]string)
+	for _, v := range variables {
+		got[v.Name] = [2]string{v.DefaultValue, v.DefaultType}
+	}
+	expected := map[string][2]string{
+		"/app/port":  {"8080", TypeNumber},
+		"/app/ratio": {"0.5", TypeNumber},
+		"/app/debug": {"true", TypeBool},
+		"/app/name":  {"web", ""},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExtractVariablesWithOptions() defaults = %v, want %v", got, expected)
+	}
+
+	sample := GenerateSampleValues(variables)
+	if sample["/app/port"] != float64(8080) || sample["/app/debug"] != true || sample["/app/name"] != "web" {
+		t.Errorf("GenerateSampleValues() = %v, want typed defaults", sample)
+	}
+}
+
+// TestConfdExtraction_Required tests that values passed to required are marked with its message
+func TestConfdExtraction_Required(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	template := `{{required "db host is required" (getv "/db/host")}}
+{{getv "/db/user" | required "db user is required"}}
+{{if exists "/tls"}}{{required "cert is required with TLS" (getv "/tls/cert")}}{{end}}
+{{getv "/db/name" "app"}}`
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, v := range variables {
+		if v.Required {
+			got[v.Name] = v.RequiredMessage
+		}
+	}
+	expected := map[string]string{
+		"/db/host":  "db host is required",
+		"/db/user":  "db user is required",
+		"/tls/cert": "cert is required with TLS",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExtractVariablesAggregated() required = %v, want %v", got, expected)
+	}
+
+	// Guarded required values are still reported missing
+	validation := ValidateValues(variables, map[string]interface{}{"/db/host": "db", "/db/user": "app", "/tls": "on"})
+	if expected := []string{"/tls/cert"}; !reflect.DeepEqual(validation.Missing, expected) {
+		t.Errorf("ValidateValues() missing = %v, want %v", validation.Missing, expected)
+	}
+
+	renderer := createConfdRenderer()
+	if _, err := renderer.Render(template, map[string]interface{}{"/db/host": "db"}, RenderOptions{}); err == nil || !strings.Contains(err.Error(), "db user is required") {
+		t.Errorf("Render() error = %v, want the required message", err)
+	}
+	result, err := renderer.Render(template, map[string]interface{}{"/db/host": "db", "/db/user": "app"}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "db\napp\n\napp"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+}
+
+// TestConfdSecret_MaskedPreview tests that secret marks variables sensitive and that masked
+// previews hide their values, including where the same key is read with getv
+func TestConfdSecret_MaskedPreview(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	template := `user={{getv "/db/user"}} password={{secret "/db/password"}} again={{getv "/db/password"}} token={{secret "/api/token" "none"}}`
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+	var sensitive []string
+	for _, v := range variables {
+		if v.Sensitive {
+			sensitive = append(sensitive, v.Name)
+		}
+	}
+	if expected := []string{"/db/password", "/api/token"}; !reflect.DeepEqual(sensitive, expected) {
+		t.Errorf("ExtractVariablesAggregated() sensitive = %v, want %v", sensitive, expected)
+	}
+
+	renderer := createConfdRenderer()
+	values := map[string]interface{}{"/db/user": "app", "/db/password": "hunter2"}
+	result, err := renderer.Render(template, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "user=app password=hunter2 again=hunter2 token=none"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+
+	result, err = renderer.Render(template, values, RenderOptions{MaskedPreview: true})
+	if err != nil {
+		t.Fatalf("Render() masked error = %v", err)
+	}
+	if expected := "user=app password=**** again=**** token=none"; result.Output != expected {
+		t.Errorf("Render() masked output = %q, want %q", result.Output, expected)
+	}
+	if expected := []string{"/db/password"}; !reflect.DeepEqual(result.Masked, expected) {
+		t.Errorf("Render() masked = %v, want %v", result.Masked, expected)
+	}
+	if values["/db/password"] != "hunter2" {
+		t.Errorf("Render() modified the caller's values")
+	}
+}
+
+// TestConfdAuditSecrets tests that every use of a secret is listed with its surrounding function
+func TestConfdAuditSecrets(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	template := `{{getv "/db/user"}}:{{secret "/db/password"}}
+{{base64Encode (getv "/db/password")}}
+{{getv "/db/password" | toUpper}}`
+	references, err := parserConfd.AuditSecrets("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("AuditSecrets() error = %v", err)
+	}
+
+	type use struct {
+		name     string
+		function string
+		line     int
+	}
+	var got []use
+	for _, r := range references {
+		got = append(got, use{r.Name, r.Function, r.Position.Line})
+	}
+	expected := []use{
+		{"/db/password", "secret", 1},
+		{"/db/password", "getv", 2},
+		{"/db/password", "getv", 3},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("AuditSecrets() = %v, want %v", got, expected)
+	}
+
+	references, err = parserConfd.AuditSecrets("test.tmpl", `{{getv "/db/user"}}`)
+	if err != nil || len(references) != 0 {
+		t.Errorf("AuditSecrets() without secrets = %v, %v, want none", references, err)
+	}
+}
+
+// TestConfdHierarchicalKeys tests that getv and exists resolve slash keys in nested values and
+// that extraction reports their path segments
+func TestConfdHierarchicalKeys(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	template := `{{getv "/myapp/database/host"}}:{{getv "/myapp/database/port" "5432"}}{{if exists "/myapp/tls"}} tls{{end}}`
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+	if expected := []string{"myapp", "database", "host"}; !reflect.DeepEqual(variables[0].Path, expected) {
+		t.Errorf("ExtractVariablesAggregated() path = %v, want %v", variables[0].Path, expected)
+	}
+
+	renderer := createConfdRenderer()
+	values := map[string]interface{}{
+		"myapp": map[string]interface{}{
+			"database": map[string]interface{}{"host": "db"},
+			"tls":      "on",
+		},
+	}
+	result, err := renderer.Render(template, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "db:5432 tls"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+}
+
+// TestConfdPrefixFunctions tests gets and getvs rendering and their wildcard extraction
+func TestConfdPrefixFunctions(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	template := `{{range gets "/upstreams/*"}}{{.Key}}={{.Value}};{{end}} {{range getvs "/upstreams/*"}}{{.}},{{end}}`
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+	if variables[0].Name != "/upstreams/*" || !variables[0].Wildcard || variables[0].Count != 2 {
+		t.Errorf("ExtractVariablesAggregated()[0] = %+v, want the wildcard /upstreams/* used twice", variables[0])
+	}
+
+	values := map[string]interface{}{"/upstreams/b": "10.0.0.2", "/upstreams/a": "10.0.0.1"}
+	result, err := createConfdRenderer().Render(template, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "/upstreams/a=10.0.0.1;/upstreams/b=10.0.0.2; 10.0.0.1,10.0.0.2,"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+
+	validation := ValidateValues(variables[:1], values)
+	if len(validation.Missing) != 0 || len(validation.Unused) != 0 {
+		t.Errorf("ValidateValues() missing = %v, unused = %v, want none", validation.Missing, validation.Unused)
+	}
+	validation = ValidateValues(variables[:1], map[string]interface{}{"/other": "x"})
+	if expected := []string{"/upstreams/*"}; !reflect.DeepEqual(validation.Missing, expected) {
+		t.Errorf("ValidateValues() missing = %v, want %v", validation.Missing, expected)
+	}
+}
+
+// TestConfdDirectoryFunctions tests ls and lsdir rendering and their wildcard extraction
+func TestConfdDirectoryFunctions(t *testing.T) {
+	parserConfd := createConfdParser()
+
+	template := `{{range lsdir "/services"}}{{.}}:{{getv (printf "/services/%s/host" .)}} {{end}}{{ls "/services/web"}}`
+	variables, err := parserConfd.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+	var wildcards []string
+	for _, v := range variables {
+		if v.Wildcard {
+			wildcards = append(wildcards, v.Name)
+		}
+	}
+	if expected := []string{"/services/*/*", "/services/web/*"}; !reflect.DeepEqual(wildcards, expected) {
+		t.Errorf("ExtractVariablesAggregated() wildcards = %v, want %v", wildcards, expected)
+	}
+
+	values := map[string]interface{}{
+		"services": map[string]interface{}{
+			"web": map[string]interface{}{"host": "10.0.0.1", "port": "80"},
+			"db":  map[string]interface{}{"host": "10.0.0.2"},
+		},
+	}
+	result, err := createConfdRenderer().Render(template, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "db:10.0.0.2 web:10.0.0.1 [host port]"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+}
+
+// TestConfdKeyPrefix tests rendering against a flat key dump under a confd key prefix
+func TestConfdKeyPrefix(t *testing.T) {
+	template := `{{getv "/nginx/port" "8080"}} {{range gets "/upstreams/*"}}{{.Key}}={{.Value}};{{end}} {{exists "/staging"}}`
+	values := map[string]interface{}{
+		"/production/nginx/port":    "80",
+		"/production/upstreams/web": "10.0.0.1",
+		"/staging/nginx/port":       "8081",
+	}
+
+	result, err := createConfdRenderer().Render(template, values, RenderOptions{KeyPrefix: "/production"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "80 /upstreams/web=10.0.0.1; false"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+
+	evaluated, err := createConfdRenderer().Evaluate(`getv "/nginx/port"`, values, RenderOptions{KeyPrefix: "/staging"})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if evaluated.Output != "8081" {
+		t.Errorf("Evaluate() output = %q, want %q", evaluated.Output, "8081")
+	}
+}
+
+// TestConfdLookupFunctions tests lookupIP and lookupSRV against an injected fixture resolver
+func TestConfdLookupFunctions(t *testing.T) {
+	renderer := createConfdRenderer()
+	renderer.Environment().SetRenderResolver(&FixtureResolver{
+		IP: map[string][]string{"db.internal": {"10.0.0.9", "10.0.0.2"}},
+		SRV: map[string][]*net.SRV{"_etcd._tcp.example.com": {
+			{Target: "etcd2.example.com.", Port: 2379},
+			{Target: "etcd1.example.com.", Port: 2379},
+		}},
+	})
+
+	parserConfd := createConfdParser()
+	template := `{{range lookupIP (getv "/db/host")}}{{.}} {{end}}{{range lookupSRV "etcd" "tcp" "example.com"}}{{.Target}}:{{.Port}} {{end}}{{lookupIP "missing.internal"}}`
+	variables, err := parserConfd.ExtractVariables("test.tmpl", `{{lookupIP (getv "/db/host")}}{{lookupSRV "etcd" "tcp" "example.com"}}`)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"/db/host"}; !reflect.DeepEqual(variables, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", variables, expected)
+	}
+
+	values := map[string]interface{}{"/db/host": "db.internal"}
+	result, err := renderer.Render(template, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "10.0.0.2 10.0.0.9 etcd1.example.com.:2379 etcd2.example.com.:2379 []"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+}
+
+// TestConfdFileExists tests fileExists against an injected virtual filesystem
+func TestConfdFileExists(t *testing.T) {
+	renderer := createConfdRenderer()
+	renderer.Environment().SetRenderFS(NewVirtualFS(map[string]string{"/etc/app/tls.crt": "cert"}))
+
+	template := `{{if fileExists (getv "/app/cert")}}ssl on;{{else}}ssl off;{{end}} {{fileExists "/etc/app"}}`
+	variables, err := createConfdParser().ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"/app/cert"}; !reflect.DeepEqual(variables, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", variables, expected)
+	}
+
+	for cert, expected := range map[string]string{"/etc/app/tls.crt": "ssl on; true", "/etc/app/tls.key": "ssl off; true"} {
+		result, err := renderer.Render(template, map[string]interface{}{"/app/cert": cert}, RenderOptions{})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if result.Output != expected {
+			t.Errorf("Render() with %s output = %q, want %q", cert, result.Output, expected)
+		}
+	}
+}
+
+// TestConfdFilepathFunctions tests the filepath functions under both path styles, with CRLF output
+func TestConfdFilepathFunctions(t *testing.T) {
+	template := "ExecStart={{filepathJoin (getv \"/app/root\") \"bin\" \"app.exe\"}}\nWorkingDirectory={{filepathDir (getv \"/app/config\")}}\nConfig={{filepathBase (getv \"/app/config\")}}\n"
+	variables, err := createConfdParser().ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"/app/root", "/app/config", "/app/config"}; !reflect.DeepEqual(variables, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", variables, expected)
+	}
+
+	renderer := createConfdRenderer()
+	windows := map[string]interface{}{"/app/root": `C:\Program Files\App`, "/app/config": `C:/ProgramData/App/app.ini`}
+	result, err := renderer.Render(template, windows, RenderOptions{PathStyle: PathStyleWindows, LineEnding: LineEndingCRLF})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "ExecStart=C:\\Program Files\\App\\bin\\app.exe\r\nWorkingDirectory=C:\\ProgramData\\App\r\nConfig=app.ini\r\n"; result.Output != expected {
+		t.Errorf("Render() windows output = %q, want %q", result.Output, expected)
+	}
+
+	posix := map[string]interface{}{"/app/root": "/opt/app", "/app/config": "/etc/app/app.ini"}
+	result, err = renderer.Render(template, posix, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "ExecStart=/opt/app/bin/app.exe\nWorkingDirectory=/etc/app\nConfig=app.ini\n"; result.Output != expected {
+		t.Errorf("Render() posix output = %q, want %q", result.Output, expected)
+	}
+
+	if _, err := renderer.Render(template, posix, RenderOptions{PathStyle: "dos"}); err == nil {
+		t.Errorf("Render() accepted an unknown path style")
+	}
+}
+
+// TestConfdProfileTable tests that the generated profile table matches the registered functions
+// Run go generate after adding or removing a function if this fails
+func TestConfdProfileTable(t *testing.T) {
+	registerConfdFunctions()
+	names := GetGlobalRegistry().GetFunctionNames()
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, profileFunctionTables[ProfileConfd]) {
+		t.Errorf("profileFunctionTables[%s] = %v, want %v", ProfileConfd, profileFunctionTables[ProfileConfd], names)
+	}
+}
+
+// BenchmarkConfdStartup measures profile registration followed by the first extraction and render
+func BenchmarkConfdStartup(b *testing.B) {
+	template := `{{getv "/app/host" "localhost"}}:{{add .Port 1}}`
+	variables := map[string]interface{}{"/app/host": "web", "Port": 80}
+	oldRegistry := globalRegistry
+	defer func() { globalRegistry = oldRegistry }()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		globalRegistry = NewFunctionRegistry()
+		parser := createConfdParser()
+		renderer := createConfdRenderer()
+		if _, err := parser.ExtractVariablesWithDefaults("test.tmpl", template); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := renderer.Render(template, variables, RenderOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -17,6 +17,11 @@ import (
 // This is called by both WASM (via init in main_custom.go) and tests
 func registerCustomFunctions() {
 	registry := GetGlobalRegistry()
+	registry.SetProfile(ProfileCustom)
+	registerTypedGetvFunctions(registry)
+	registerRequiredFunction(registry)
+	registerSecretFunction(registry)
+	registerLocaleFunctions(registry)
 
 	// getv - Get variable value with optional default
 	registry.RegisterFunction(&FunctionDefinition{
@@ -25,6 +30,7 @@ func registerCustomFunctions() {
 		Handler:               getvMinimalHandler,
 		Extractor:             extractGetvVariables,
 		ExtractorWithDefaults: extractGetvVariablesWithDefaults,
+		ArgNames:              []string{"key", "default"},
 	})
 
 	// exists - Check if variable exists (no default value support)
@@ -34,6 +40,7 @@ func registerCustomFunctions() {
 		Handler:               existsMinimalHandler,
 		Extractor:             extractKeyArgVariable,
 		ExtractorWithDefaults: extractKeyArgVariableInfo,
+		ArgNames:              []string{"key"},
 	})
 
 	// get - Get variable value (errors if not found, no default value support)
@@ -43,6 +50,7 @@ func registerCustomFunctions() {
 		Handler:               getMinimalHandler,
 		Extractor:             extractKeyArgVariable,
 		ExtractorWithDefaults: extractKeyArgVariableInfo,
+		ArgNames:              []string{"key"},
 	})
 
 	// json - Parse JSON variable (no default value support)
@@ -52,6 +60,8 @@ func registerCustomFunctions() {
 		Handler:               jsonMinimalHandler,
 		Extractor:             extractKeyArgVariable,
 		ExtractorWithDefaults: extractKeyArgVariableInfo,
+		ArgTypeHint:           TypeJSONString,
+		ArgNames:              []string{"key"},
 	})
 
 	// jsonArray - Parse JSON variable and return as array (no default value support)
@@ -61,6 +71,8 @@ func registerCustomFunctions() {
 		Handler:               jsonArrayMinimalHandler,
 		Extractor:             extractKeyArgVariable,
 		ExtractorWithDefaults: extractKeyArgVariableInfo,
+		ArgTypeHint:           TypeJSONString,
+		ArgNames:              []string{"key"},
 	})
 }
 
@@ -86,9 +98,9 @@ func jsonArrayMinimalHandler(key string) ([]interface{}, error) {
 }
 
 // Actual handlers for rendering (use variable values)
-func getvRenderHandler(variables map[string]interface{}) func(key string, v ...string) string {
+func getvRenderHandler(store *KeyStore) func(key string, v ...string) string {
 	return func(key string, v ...string) string {
-		if val, exists := variables[key]; exists {
+		if val, exists := store.Get(key); exists {
 			if strVal, ok := val.(string); ok && strVal != "" {
 				return strVal
 			}
@@ -100,25 +112,22 @@ func getvRenderHandler(variables map[string]interface{}) func(key string, v ...s
 	}
 }
 
-func existsRenderHandler(variables map[string]interface{}) func(key string) bool {
-	return func(key string) bool {
-		_, exists := variables[key]
-		return exists
-	}
+func existsRenderHandler(store *KeyStore) func(key string) bool {
+	return store.Exists
 }
 
-func getRenderHandler(variables map[string]interface{}) func(key string) (interface{}, error) {
+func getRenderHandler(store *KeyStore) func(key string) (interface{}, error) {
 	return func(key string) (interface{}, error) {
-		if val, exists := variables[key]; exists {
+		if val, exists := store.Get(key); exists {
 			return val, nil
 		}
 		return nil, fmt.Errorf("key %s not found", key)
 	}
 }
 
-func jsonRenderHandler(variables map[string]interface{}) func(key string) (map[string]interface{}, error) {
+func jsonRenderHandler(store *KeyStore) func(key string) (map[string]interface{}, error) {
 	return func(key string) (map[string]interface{}, error) {
-		if val, exists := variables[key]; exists {
+		if val, exists := store.Get(key); exists {
 			if strVal, ok := val.(string); ok {
 				var result map[string]interface{}
 				err := json.Unmarshal([]byte(strVal), &result)
@@ -129,9 +138,9 @@ func jsonRenderHandler(variables map[string]interface{}) func(key string) (map[s
 	}
 }
 
-func jsonArrayRenderHandler(variables map[string]interface{}) func(key string) ([]interface{}, error) {
+func jsonArrayRenderHandler(store *KeyStore) func(key string) ([]interface{}, error) {
 	return func(key string) ([]interface{}, error) {
-		if val, exists := variables[key]; exists {
+		if val, exists := store.Get(key); exists {
 			if strVal, ok := val.(string); ok {
 				var result []interface{}
 				err := json.Unmarshal([]byte(strVal), &result)
@@ -168,12 +177,23 @@ func extractGetvVariablesWithDefaults(args []parse.Node, cycle int) ([]VariableI
 
 // GetCustomRenderFuncMap returns a function map with all custom functions for rendering
 // This is used by both the WASM build (via CreateRenderFuncMap) and tests
-func GetCustomRenderFuncMap(variables map[string]interface{}) template.FuncMap {
+func GetCustomRenderFuncMap(variables map[string]interface{}, env *renderEnv) template.FuncMap {
+	store := env.keyStore(variables)
 	return template.FuncMap{
-		"getv":      getvRenderHandler(variables),
-		"exists":    existsRenderHandler(variables),
-		"get":       getRenderHandler(variables),
-		"json":      jsonRenderHandler(variables),
-		"jsonArray": jsonArrayRenderHandler(variables),
+		"getv":      getvRenderHandler(store),
+		"exists":    existsRenderHandler(store),
+		"get":       getRenderHandler(store),
+		"json":      jsonRenderHandler(store),
+		"jsonArray": jsonArrayRenderHandler(store),
+		"getvInt":   getvIntRenderHandler(store),
+		"getvBool":  getvBoolRenderHandler(store),
+		"getvFloat": getvFloatRenderHandler(store),
+		"getvJSON":  getvJSONRenderHandler(store),
+		"required":  requiredRenderHandler,
+		"secret":    secretRenderHandler(store),
+		// Locale-aware formatting
+		"formatNumber":   formatNumberRenderHandler,
+		"formatCurrency": formatCurrencyRenderHandler,
+		"formatDate":     formatDateRenderHandler,
 	}
 }
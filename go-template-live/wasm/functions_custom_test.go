@@ -5,6 +5,7 @@ package main
 
 import (
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"text/template"
@@ -223,7 +224,7 @@ func TestEndToEnd_CustomFunctions(t *testing.T) {
 // Uses the actual production implementation from functions_custom_core.go
 func renderTemplateWithCustomFunctions(templateContent string, variables map[string]interface{}) (string, error) {
 	// Use the actual production implementation - this is what we're testing!
-	funcMap := GetCustomRenderFuncMap(variables)
+	funcMap := GetCustomRenderFuncMap(variables, defaultRenderEnv())
 
 	tmpl, err := template.New("test").Funcs(funcMap).Parse(templateContent)
 	if err != nil {
@@ -238,3 +239,41 @@ func renderTemplateWithCustomFunctions(templateContent string, variables map[str
 
 	return result.String(), nil
 }
+
+// TestCustomExamples_RenderWithSampleValues tests that every embedded custom example parses and renders
+func TestCustomExamples_RenderWithSampleValues(t *testing.T) {
+	parserCustom := createCustomParser()
+
+	examples, err := ListExamples(ProfileCustom)
+	if err != nil {
+		t.Fatalf("ListExamples() error = %v", err)
+	}
+	if len(examples) == 0 {
+		t.Fatal("ListExamples() returned no examples")
+	}
+
+	for _, info := range examples {
+		t.Run(info.Name, func(t *testing.T) {
+			example, err := GetExample(ProfileCustom, info.Name)
+			if err != nil {
+				t.Fatalf("GetExample() error = %v", err)
+			}
+			if _, err := parserCustom.ExtractVariablesWithDefaults(info.Name, example.Template); err != nil {
+				t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+			}
+			if _, err := renderTemplateWithCustomFunctions(example.Template, example.Values); err != nil {
+				t.Fatalf("renderTemplateWithCustomFunctions() error = %v", err)
+			}
+		})
+	}
+}
+
+// TestCustomProfileTable tests that the generated profile table matches the registered functions
+// Run go generate after adding or removing a function if this fails
+func TestCustomProfileTable(t *testing.T) {
+	names := createCustomParser().registry.GetFunctionNames()
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, profileFunctionTables[ProfileCustom]) {
+		t.Errorf("profileFunctionTables[%s] = %v, want %v", ProfileCustom, profileFunctionTables[ProfileCustom], names)
+	}
+}
@@ -1,11 +1,17 @@
-//go:build js && !custom && !confd && !official
-// +build js,!custom,!confd,!official
+//go:build js && !custom && !confd && !official && !sprig && !gomplate && !helm
+// +build js,!custom,!confd,!official,!sprig,!gomplate,!helm
 
 package main
 
-// CreateRenderFuncMap provides a no-op function map for builds that do not
+func init() {
+	// Builds without a function set tag stand in for the custom and Confd functions, so pasted
+	// templates render with visible placeholders and a warning instead of failing to parse
+	registerPlaceholderFunctions(GetGlobalRegistry())
+}
+
+// CreateRenderFuncMap provides the placeholder render functions for builds that do not
 // include any of the custom build tags. This ensures js/wasm builds without
 // additional tags still compile and satisfy references from wasm_handlers.go.
-func CreateRenderFuncMap(variables map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{}
+func CreateRenderFuncMap(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+	return placeholderRenderFuncs(GetGlobalRegistry())
 }
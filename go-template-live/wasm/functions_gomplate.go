@@ -0,0 +1,1179 @@
+//go:build gomplate
+// +build gomplate
+
+// This file contains the core implementations of gomplate's namespaced functions, so templates
+// written for gomplate analyze and render unchanged
+// Tag: gomplate (works for both js && gomplate WASM builds and !js && gomplate tests)
+// {{strings.ToUpper .Name}} calls the strings function, which returns the namespace, and then
+// its ToUpper method; fields passed to a method are extracted like arguments of any command.
+// Data sources, YAML/TOML, file, net, sockaddr, semver and the cloud namespaces are not provided
+
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// registerGomplateFunctions registers the gomplate namespaces and their common top-level aliases
+// This is called by both WASM (via init in main_gomplate.go) and tests
+func registerGomplateFunctions() {
+	registry := GetGlobalRegistry()
+	registry.SetProfile(ProfileGomplate)
+	registerRequiredFunction(registry)
+
+	// Namespaces
+	registry.RegisterFunction(&FunctionDefinition{Name: "strings", Description: "String functions: strings.ToUpper, strings.Split, strings.Indent, ...", Handler: func() gomplateStrings { return gomplateStrings{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "conv", Description: "Conversion functions: conv.ToInt64, conv.ToBool, conv.Default, ...", Handler: func() gomplateConv { return gomplateConv{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "coll", Description: "Collection functions: coll.Dict, coll.Keys, coll.Merge, ...", Handler: func() gomplateColl { return gomplateColl{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "data", Description: "Data functions: data.JSON, data.ToJSON, data.CSV, ...", Handler: func() gomplateData { return gomplateData{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "math", Description: "Math functions: math.Add, math.Div, math.Seq, ...", Handler: func() gomplateMath { return gomplateMath{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexp", Description: "Regular expression functions: regexp.Match, regexp.Replace, ...", Handler: func() gomplateRegexp { return gomplateRegexp{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "path", Description: "Slash path functions: path.Base, path.Join, ...", Handler: func() gomplatePath { return gomplatePath{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "filepath", Description: "File path functions: filepath.Base, filepath.Join, ...", Handler: func() gomplateFilepath { return gomplateFilepath{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "time", Description: "Time functions: time.Now, time.Parse, time.Unix, ...", EnvHandler: func(env *renderEnv) interface{} { return func() gomplateTime { return gomplateTime{env} } }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "crypto", Description: "Hash functions: crypto.SHA1, crypto.SHA256, ...", Handler: func() gomplateCrypto { return gomplateCrypto{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "base64", Description: "Base64 functions: base64.Encode, base64.Decode", Handler: func() gomplateBase64 { return gomplateBase64{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "env", Description: "Environment functions: env.Getenv, env.ExpandEnv", Handler: func() gomplateEnv { return gomplateEnv{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "test", Description: "Assertion functions: test.Assert, test.Fail, test.Ternary, ...", Handler: func() gomplateTest { return gomplateTest{} }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "random", Description: "Random functions: random.AlphaNum, random.Number, random.Item, ...", EnvHandler: func(env *renderEnv) interface{} { return func() gomplateRandom { return gomplateRandom{env} } }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "uuid", Description: "UUID functions: uuid.V4, uuid.IsValid", EnvHandler: func(env *renderEnv) interface{} { return func() gomplateUUID { return gomplateUUID{env} } }})
+
+	// Top-level aliases
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "default",
+		Description:           "Returns the given value, or the default when it is empty (conv.Default)",
+		Handler:               gomplateConv{}.Default,
+		Extractor:             extractDefaultedVariables,
+		ExtractorWithDefaults: extractDefaultedVariablesInfo,
+		ExtractsPipedValue:    true,
+	})
+	registry.RegisterFunction(&FunctionDefinition{Name: "bool", Description: "Converts to a boolean (conv.Bool)", Handler: gomplateConv{}.ToBool})
+	registry.RegisterFunction(&FunctionDefinition{Name: "join", Description: "Joins a list with a separator (conv.Join)", Handler: gomplateConv{}.Join})
+	registry.RegisterFunction(&FunctionDefinition{Name: "has", Description: "Reports whether a map has a key or a list an element (coll.Has)", Handler: gomplateColl{}.Has})
+	registry.RegisterFunction(&FunctionDefinition{Name: "dict", Description: "Builds a dict from key and value pairs (coll.Dict)", Handler: gomplateColl{}.Dict})
+	registry.RegisterFunction(&FunctionDefinition{Name: "json", Description: "Decodes a JSON object (data.JSON)", Handler: gomplateData{}.JSON})
+	registry.RegisterFunction(&FunctionDefinition{Name: "jsonArray", Description: "Decodes a JSON array (data.JSONArray)", Handler: gomplateData{}.JSONArray})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toJSON", Description: "Encodes as JSON (data.ToJSON)", Handler: gomplateData{}.ToJSON})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toJSONPretty", Description: "Encodes as indented JSON (data.ToJSONPretty)", Handler: gomplateData{}.ToJSONPretty})
+	registry.RegisterFunction(&FunctionDefinition{Name: "getenv", Description: "Value of an environment variable, or a default (env.Getenv)", Handler: gomplateEnv{}.Getenv})
+	registry.RegisterFunction(&FunctionDefinition{Name: "seq", Description: "A sequence of integers (math.Seq)", Handler: gomplateMath{}.Seq})
+	registry.RegisterFunction(&FunctionDefinition{Name: "ternary", Description: "The first value when the condition is true, else the second (test.Ternary)", Handler: gomplateTest{}.Ternary})
+	registry.RegisterFunction(&FunctionDefinition{Name: "split", Description: "Splits a string (strings.Split)", Handler: gomplateStrings{}.Split})
+	registry.RegisterFunction(&FunctionDefinition{Name: "splitN", Description: "Splits a string into at most n parts (strings.SplitN)", Handler: gomplateStrings{}.SplitN})
+	registry.RegisterFunction(&FunctionDefinition{Name: "replaceAll", Description: "Replaces every occurrence of a string (strings.ReplaceAll)", Handler: gomplateStrings{}.ReplaceAll})
+	registry.RegisterFunction(&FunctionDefinition{Name: "title", Description: "Converts to title case (strings.Title)", Handler: gomplateStrings{}.Title})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toUpper", Description: "Converts to upper case (strings.ToUpper)", Handler: gomplateStrings{}.ToUpper})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toLower", Description: "Converts to lower case (strings.ToLower)", Handler: gomplateStrings{}.ToLower})
+	registry.RegisterFunction(&FunctionDefinition{Name: "trimSpace", Description: "Removes leading and trailing white space (strings.TrimSpace)", Handler: gomplateStrings{}.TrimSpace})
+	registry.RegisterFunction(&FunctionDefinition{Name: "indent", Description: "Indents every line (strings.Indent)", Handler: gomplateStrings{}.Indent})
+	registry.RegisterFunction(&FunctionDefinition{Name: "quote", Description: "Double quotes a value (strings.Quote)", Handler: gomplateStrings{}.Quote})
+	registry.RegisterFunction(&FunctionDefinition{Name: "squote", Description: "Single quotes a value (strings.Squote)", Handler: gomplateStrings{}.Squote})
+	registry.RegisterFunction(&FunctionDefinition{Name: "contains", Description: "Reports whether a string contains another (strings.Contains)", Handler: gomplateStrings{}.Contains})
+	registry.RegisterFunction(&FunctionDefinition{Name: "hasPrefix", Description: "Reports whether a string has a prefix (strings.HasPrefix)", Handler: gomplateStrings{}.HasPrefix})
+	registry.RegisterFunction(&FunctionDefinition{Name: "hasSuffix", Description: "Reports whether a string has a suffix (strings.HasSuffix)", Handler: gomplateStrings{}.HasSuffix})
+}
+
+// GetGomplateRenderFuncMap returns the render implementations of the gomplate profile that
+// differ from the registered handlers
+func GetGomplateRenderFuncMap(variables map[string]interface{}, env *renderEnv) template.FuncMap {
+	return template.FuncMap{
+		"required": requiredRenderHandler,
+	}
+}
+
+// gomplateStrings is the strings namespace; like gomplate, the input string is the last argument
+type gomplateStrings struct{}
+
+func (gomplateStrings) ToUpper(in interface{}) string { return strings.ToUpper(conv.ToString(in)) }
+func (gomplateStrings) ToLower(in interface{}) string { return strings.ToLower(conv.ToString(in)) }
+func (gomplateStrings) TrimSpace(in interface{}) string {
+	return strings.TrimSpace(conv.ToString(in))
+}
+func (gomplateStrings) Trim(cutset string, in interface{}) string {
+	return strings.Trim(conv.ToString(in), cutset)
+}
+func (gomplateStrings) TrimLeft(cutset string, in interface{}) string {
+	return strings.TrimLeft(conv.ToString(in), cutset)
+}
+func (gomplateStrings) TrimRight(cutset string, in interface{}) string {
+	return strings.TrimRight(conv.ToString(in), cutset)
+}
+func (gomplateStrings) TrimPrefix(prefix string, in interface{}) string {
+	return strings.TrimPrefix(conv.ToString(in), prefix)
+}
+func (gomplateStrings) TrimSuffix(suffix string, in interface{}) string {
+	return strings.TrimSuffix(conv.ToString(in), suffix)
+}
+func (gomplateStrings) Contains(substr string, in interface{}) bool {
+	return strings.Contains(conv.ToString(in), substr)
+}
+func (gomplateStrings) HasPrefix(prefix string, in interface{}) bool {
+	return strings.HasPrefix(conv.ToString(in), prefix)
+}
+func (gomplateStrings) HasSuffix(suffix string, in interface{}) bool {
+	return strings.HasSuffix(conv.ToString(in), suffix)
+}
+func (gomplateStrings) Split(sep string, in interface{}) []string {
+	return strings.Split(conv.ToString(in), sep)
+}
+func (gomplateStrings) SplitN(sep string, n int, in interface{}) []string {
+	return strings.SplitN(conv.ToString(in), sep, n)
+}
+func (gomplateStrings) Replace(old, new string, in interface{}) string {
+	return strings.ReplaceAll(conv.ToString(in), old, new)
+}
+func (gomplateStrings) ReplaceAll(old, new string, in interface{}) string {
+	return strings.ReplaceAll(conv.ToString(in), old, new)
+}
+func (gomplateStrings) Repeat(count int, in interface{}) (string, error) {
+	if count < 0 {
+		return "", errors.New("strings.Repeat: negative count")
+	}
+	return strings.Repeat(conv.ToString(in), count), nil
+}
+func (gomplateStrings) Quote(in interface{}) string { return strconv.Quote(conv.ToString(in)) }
+func (gomplateStrings) Squote(in interface{}) string {
+	return "'" + strings.ReplaceAll(conv.ToString(in), "'", "''") + "'"
+}
+func (gomplateStrings) ShellQuote(in interface{}) string {
+	return "'" + strings.ReplaceAll(conv.ToString(in), "'", `'"'"'`) + "'"
+}
+func (gomplateStrings) RuneCount(in ...interface{}) int {
+	return utf8.RuneCountInString(strings.Join(conv.ToStrings(in...), ""))
+}
+
+// Title upper cases the first letter of every word, leaving the others as they are
+func (gomplateStrings) Title(in interface{}) string {
+	return cases.Title(language.Und, cases.NoLower).String(conv.ToString(in))
+}
+
+// Trunc keeps the first length bytes
+func (gomplateStrings) Trunc(length int, in interface{}) string {
+	s := conv.ToString(in)
+	if length >= 0 && length < len(s) {
+		return s[:length]
+	}
+	return s
+}
+
+// Abbrev truncates to width with an ellipsis
+func (gomplateStrings) Abbrev(width int, in interface{}) string {
+	s := conv.ToString(in)
+	if width < 4 || len(s) <= width {
+		return s
+	}
+	return s[:width-3] + "..."
+}
+
+// Indent indents every line: strings.Indent [width] [indent] input, one space by default
+func (gomplateStrings) Indent(args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 3 {
+		return "", errors.New("strings.Indent: expected [width] [indent] input")
+	}
+	width, indent := 1, " "
+	switch len(args) {
+	case 2:
+		if s, ok := args[0].(string); ok {
+			indent = s
+		} else {
+			width = int(conv.ToInt64(args[0]))
+		}
+	case 3:
+		width, indent = int(conv.ToInt64(args[0])), conv.ToString(args[1])
+	}
+	pad := strings.Repeat(indent, max(width, 0))
+	lines := strings.Split(conv.ToString(args[len(args)-1]), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// WordWrap breaks lines at the last space before the width: strings.WordWrap [width] [lbseq] input
+func (gomplateStrings) WordWrap(args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 3 {
+		return "", errors.New("strings.WordWrap: expected [width] [lbseq] input")
+	}
+	width, lbseq := 80, "\n"
+	if len(args) > 1 {
+		width = int(conv.ToInt64(args[0]))
+	}
+	if len(args) > 2 {
+		lbseq = conv.ToString(args[1])
+	}
+	var b strings.Builder
+	for i, line := range strings.Split(conv.ToString(args[len(args)-1]), "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		column := 0
+		for j, word := range strings.Fields(line) {
+			if j > 0 && column+1+len(word) > width {
+				b.WriteString(lbseq)
+				column = 0
+			} else if j > 0 {
+				b.WriteByte(' ')
+				column++
+			}
+			b.WriteString(word)
+			column += len(word)
+		}
+	}
+	return b.String(), nil
+}
+
+// SnakeCase converts to snake_case
+func (gomplateStrings) SnakeCase(in interface{}) string {
+	return snakeCase(gomplateWords(conv.ToString(in), " "))
+}
+
+// KebabCase converts to kebab-case
+func (gomplateStrings) KebabCase(in interface{}) string {
+	return strings.ReplaceAll(snakeCase(gomplateWords(conv.ToString(in), " ")), "_", "-")
+}
+
+// CamelCase joins words, upper casing the first letter of all but the first: hello world is helloWorld
+func (gomplateStrings) CamelCase(in interface{}) string {
+	words := strings.Fields(gomplateWords(conv.ToString(in), " "))
+	for i := 1; i < len(words); i++ {
+		r, size := utf8.DecodeRuneInString(words[i])
+		words[i] = string(unicode.ToUpper(r)) + words[i][size:]
+	}
+	return strings.Join(words, "")
+}
+
+// gomplateWords replaces punctuation and separators between words with sep
+func gomplateWords(s, sep string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), sep)
+}
+
+// gomplateConv is the conv namespace; its conversions are also used by the other namespaces
+type gomplateConv struct{}
+
+// conv converts arguments for the namespaces
+var conv gomplateConv
+
+// ToString converts a value to a string; nil is the empty string
+func (gomplateConv) ToString(in interface{}) string {
+	switch in := in.(type) {
+	case nil:
+		return ""
+	case string:
+		return in
+	case []byte:
+		return string(in)
+	case error:
+		return in.Error()
+	case fmt.Stringer:
+		return in.String()
+	}
+	return fmt.Sprint(in)
+}
+
+// ToStrings converts every value to a string
+func (gomplateConv) ToStrings(in ...interface{}) []string {
+	result := make([]string, len(in))
+	for i, v := range in {
+		result[i] = conv.ToString(v)
+	}
+	return result
+}
+
+// ToInt64 converts numbers, numeric strings (including hex and octal) and bools; anything else is 0
+func (gomplateConv) ToInt64(in interface{}) int64 {
+	value := reflect.ValueOf(in)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(value.Float())
+	case reflect.Bool:
+		if value.Bool() {
+			return 1
+		}
+	case reflect.String:
+		s := strings.TrimSpace(value.String())
+		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return i
+		}
+		f, _ := strconv.ParseFloat(s, 64)
+		return int64(f)
+	}
+	return 0
+}
+
+// ToInt converts like ToInt64
+func (gomplateConv) ToInt(in interface{}) int { return int(conv.ToInt64(in)) }
+
+// ToFloat64 converts numbers, numeric strings and bools; anything else is 0
+func (gomplateConv) ToFloat64(in interface{}) float64 {
+	value := reflect.ValueOf(in)
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.String:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value.String()), 64); err == nil {
+			return f
+		}
+	}
+	return float64(conv.ToInt64(in))
+}
+
+// ToBool is true for true, non-zero numbers and the strings 1, t, true, y and yes in any case
+func (gomplateConv) ToBool(in interface{}) bool {
+	switch in := in.(type) {
+	case bool:
+		return in
+	case string:
+		switch strings.ToLower(strings.TrimSpace(in)) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true
+		}
+		return false
+	case nil:
+		return false
+	}
+	return conv.ToFloat64(in) != 0
+}
+
+// Bool converts like ToBool
+func (gomplateConv) Bool(in interface{}) bool { return conv.ToBool(in) }
+
+// Default returns in, or def when in is missing or empty
+func (gomplateConv) Default(def interface{}, in ...interface{}) interface{} {
+	if len(in) == 0 || gomplateEmpty(in[0]) {
+		return def
+	}
+	return in[0]
+}
+
+// Join joins the elements of a list with sep
+func (gomplateConv) Join(in interface{}, sep string) (string, error) {
+	list, err := gomplateList(in)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(conv.ToStrings(list...), sep), nil
+}
+
+// ParseInt parses a string in a base, 0 to read the prefix
+func (gomplateConv) ParseInt(in interface{}, base, bitSize int) (int64, error) {
+	return strconv.ParseInt(conv.ToString(in), base, bitSize)
+}
+
+// ParseFloat parses a string
+func (gomplateConv) ParseFloat(in interface{}, bitSize int) (float64, error) {
+	return strconv.ParseFloat(conv.ToString(in), bitSize)
+}
+
+// Atoi parses a decimal string, 0 when it is not a number
+func (gomplateConv) Atoi(in interface{}) int {
+	i, _ := strconv.Atoi(conv.ToString(in))
+	return i
+}
+
+// gomplateEmpty reports whether a value is empty: nil, false, zero, or an empty string, list or map
+func gomplateEmpty(in interface{}) bool {
+	value := reflect.ValueOf(in)
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Interface, reflect.Pointer:
+		return value.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return value.IsZero()
+}
+
+// gomplateList converts a slice or array to a list
+func gomplateList(in interface{}) ([]interface{}, error) {
+	if list, ok := in.([]interface{}); ok {
+		return list, nil
+	}
+	value := reflect.ValueOf(in)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", in)
+	}
+	list := make([]interface{}, value.Len())
+	for i := range list {
+		list[i] = value.Index(i).Interface()
+	}
+	return list, nil
+}
+
+// gomplateColl is the coll namespace
+type gomplateColl struct{}
+
+// Dict builds a map from key and value pairs; a missing last value is ""
+func (gomplateColl) Dict(pairs ...interface{}) map[string]interface{} {
+	dict := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		if i+1 < len(pairs) {
+			dict[conv.ToString(pairs[i])] = pairs[i+1]
+		} else {
+			dict[conv.ToString(pairs[i])] = ""
+		}
+	}
+	return dict
+}
+
+// Slice builds a list
+func (gomplateColl) Slice(args ...interface{}) []interface{} { return args }
+
+// Has reports whether a map has a key or a list holds an element
+func (gomplateColl) Has(in interface{}, key interface{}) bool {
+	value := reflect.ValueOf(in)
+	switch value.Kind() {
+	case reflect.Map:
+		k := reflect.ValueOf(key)
+		if !k.IsValid() || !k.Type().AssignableTo(value.Type().Key()) {
+			return false
+		}
+		return value.MapIndex(k).IsValid()
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if reflect.DeepEqual(value.Index(i).Interface(), key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Keys returns the keys of the maps, each map's keys sorted
+func (gomplateColl) Keys(maps ...map[string]interface{}) []interface{} {
+	keys := []interface{}{}
+	for _, m := range maps {
+		for _, key := range gomplateSortedKeys(m) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Values returns the values of the maps, in key order
+func (gomplateColl) Values(maps ...map[string]interface{}) []interface{} {
+	values := []interface{}{}
+	for _, m := range maps {
+		for _, key := range gomplateSortedKeys(m) {
+			values = append(values, m[key])
+		}
+	}
+	return values
+}
+
+func gomplateSortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Append returns the list with v added at the end
+func (gomplateColl) Append(v interface{}, list interface{}) ([]interface{}, error) {
+	items, err := gomplateList(list)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]interface{}{}, items...), v), nil
+}
+
+// Prepend returns the list with v added at the start
+func (gomplateColl) Prepend(v interface{}, list interface{}) ([]interface{}, error) {
+	items, err := gomplateList(list)
+	if err != nil {
+		return nil, err
+	}
+	return append([]interface{}{v}, items...), nil
+}
+
+// Uniq removes repeated elements, keeping the first
+func (gomplateColl) Uniq(list interface{}) ([]interface{}, error) {
+	items, err := gomplateList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for _, item := range items {
+		if !(gomplateColl{}).Has(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// Reverse returns the list in reverse order
+func (gomplateColl) Reverse(list interface{}) ([]interface{}, error) {
+	items, err := gomplateList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[len(items)-1-i] = item
+	}
+	return result, nil
+}
+
+// Sort sorts a list of strings or numbers, or of maps by a key: coll.Sort [key] list
+func (gomplateColl) Sort(args ...interface{}) ([]interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errors.New("coll.Sort: expected [key] list")
+	}
+	items, err := gomplateList(args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]interface{}{}, items...)
+	sortValue := func(v interface{}) interface{} { return v }
+	if len(args) == 2 {
+		key := conv.ToString(args[0])
+		sortValue = func(v interface{}) interface{} {
+			if m, ok := v.(map[string]interface{}); ok {
+				return m[key]
+			}
+			return nil
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sortValue(sorted[i]), sortValue(sorted[j])
+		if gomplateIsNum(a) && gomplateIsNum(b) {
+			return conv.ToFloat64(a) < conv.ToFloat64(b)
+		}
+		return conv.ToString(a) < conv.ToString(b)
+	})
+	return sorted, nil
+}
+
+// Merge deep merges maps; the leftmost map takes precedence
+func (gomplateColl) Merge(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(dst))
+	for key, value := range dst {
+		result[key] = value
+	}
+	for _, src := range srcs {
+		for key, value := range src {
+			existing, ok := result[key]
+			existingMap, existingIsMap := existing.(map[string]interface{})
+			valueMap, valueIsMap := value.(map[string]interface{})
+			switch {
+			case existingIsMap && valueIsMap:
+				result[key] = gomplateColl{}.Merge(existingMap, valueMap)
+			case !ok:
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
+
+// Pick keeps the given keys: coll.Pick keys... map
+func (gomplateColl) Pick(args ...interface{}) (map[string]interface{}, error) {
+	return gomplatePick("coll.Pick", args, true)
+}
+
+// Omit removes the given keys: coll.Omit keys... map
+func (gomplateColl) Omit(args ...interface{}) (map[string]interface{}, error) {
+	return gomplatePick("coll.Omit", args, false)
+}
+
+func gomplatePick(name string, args []interface{}, keep bool) (map[string]interface{}, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("%s: expected keys and a map", name)
+	}
+	m, ok := args[len(args)-1].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a map, got %T", name, args[len(args)-1])
+	}
+	listed := make(map[string]bool)
+	for _, key := range args[:len(args)-1] {
+		listed[conv.ToString(key)] = true
+	}
+	result := make(map[string]interface{})
+	for key, value := range m {
+		if listed[key] == keep {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+// Flatten flattens nested lists, to the given depth when one is given: coll.Flatten [depth] list
+func (gomplateColl) Flatten(args ...interface{}) ([]interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errors.New("coll.Flatten: expected [depth] list")
+	}
+	depth := -1
+	if len(args) == 2 {
+		depth = int(conv.ToInt64(args[0]))
+	}
+	items, err := gomplateList(args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	return gomplateFlatten(items, depth), nil
+}
+
+func gomplateFlatten(items []interface{}, depth int) []interface{} {
+	result := []interface{}{}
+	for _, item := range items {
+		nested, err := gomplateList(item)
+		if err != nil || depth == 0 {
+			result = append(result, item)
+			continue
+		}
+		result = append(result, gomplateFlatten(nested, depth-1)...)
+	}
+	return result
+}
+
+// gomplateData is the data namespace
+type gomplateData struct{}
+
+// JSON decodes a JSON object
+func (gomplateData) JSON(in interface{}) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(conv.ToString(in)), &m); err != nil {
+		return nil, fmt.Errorf("data.JSON: %v", err)
+	}
+	return m, nil
+}
+
+// JSONArray decodes a JSON array
+func (gomplateData) JSONArray(in interface{}) ([]interface{}, error) {
+	var list []interface{}
+	if err := json.Unmarshal([]byte(conv.ToString(in)), &list); err != nil {
+		return nil, fmt.Errorf("data.JSONArray: %v", err)
+	}
+	return list, nil
+}
+
+// ToJSON encodes as JSON
+func (gomplateData) ToJSON(in interface{}) (string, error) {
+	data, err := json.Marshal(in)
+	return string(data), err
+}
+
+// ToJSONPretty encodes as JSON indented with indent
+func (gomplateData) ToJSONPretty(indent string, in interface{}) (string, error) {
+	data, err := json.MarshalIndent(in, "", indent)
+	return string(data), err
+}
+
+// CSV decodes comma separated rows: data.CSV [delimiter] input
+func (gomplateData) CSV(args ...string) ([][]string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errors.New("data.CSV: expected [delimiter] input")
+	}
+	reader := csv.NewReader(strings.NewReader(args[len(args)-1]))
+	if len(args) == 2 {
+		reader.Comma, _ = utf8.DecodeRuneInString(args[0])
+	}
+	return reader.ReadAll()
+}
+
+// ToCSV encodes rows as CSV with CRLF line endings: data.ToCSV [delimiter] rows
+func (gomplateData) ToCSV(args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", errors.New("data.ToCSV: expected [delimiter] rows")
+	}
+	rows, err := gomplateList(args[len(args)-1])
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writer := csv.NewWriter(&b)
+	writer.UseCRLF = true
+	if len(args) == 2 {
+		writer.Comma, _ = utf8.DecodeRuneInString(conv.ToString(args[0]))
+	}
+	for _, row := range rows {
+		cells, err := gomplateList(row)
+		if err != nil {
+			return "", err
+		}
+		if err := writer.Write(conv.ToStrings(cells...)); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	return b.String(), writer.Error()
+}
+
+// gomplateMath is the math namespace; results are integers unless an operand is a float
+type gomplateMath struct{}
+
+func (gomplateMath) Add(n ...interface{}) interface{} {
+	return gomplateArith(n, func(a, b int64) int64 { return a + b }, func(a, b float64) float64 { return a + b })
+}
+func (gomplateMath) Mul(n ...interface{}) interface{} {
+	return gomplateArith(n, func(a, b int64) int64 { return a * b }, func(a, b float64) float64 { return a * b })
+}
+func (gomplateMath) Sub(a, b interface{}) interface{} {
+	return gomplateArith([]interface{}{a, b}, func(a, b int64) int64 { return a - b }, func(a, b float64) float64 { return a - b })
+}
+
+// Div always divides as floats, like gomplate
+func (gomplateMath) Div(a, b interface{}) (float64, error) {
+	divisor := conv.ToFloat64(b)
+	if divisor == 0 {
+		return 0, errors.New("math.Div: division by zero")
+	}
+	return conv.ToFloat64(a) / divisor, nil
+}
+
+func (gomplateMath) Rem(a, b interface{}) (int64, error) {
+	divisor := conv.ToInt64(b)
+	if divisor == 0 {
+		return 0, errors.New("math.Rem: division by zero")
+	}
+	return conv.ToInt64(a) % divisor, nil
+}
+
+func (gomplateMath) Pow(a, b interface{}) interface{} {
+	result := math.Pow(conv.ToFloat64(a), conv.ToFloat64(b))
+	if gomplateIsInt(a) && gomplateIsInt(b) && result == math.Trunc(result) && math.Abs(result) < 1<<63 {
+		return int64(result)
+	}
+	return result
+}
+
+func (gomplateMath) Max(a interface{}, b ...interface{}) interface{} {
+	return gomplateArith(append([]interface{}{a}, b...), func(a, b int64) int64 { return max(a, b) }, math.Max)
+}
+func (gomplateMath) Min(a interface{}, b ...interface{}) interface{} {
+	return gomplateArith(append([]interface{}{a}, b...), func(a, b int64) int64 { return min(a, b) }, math.Min)
+}
+func (gomplateMath) Abs(n interface{}) interface{} {
+	if gomplateIsInt(n) {
+		if i := conv.ToInt64(n); i < 0 {
+			return -i
+		} else {
+			return i
+		}
+	}
+	return math.Abs(conv.ToFloat64(n))
+}
+func (gomplateMath) Ceil(n interface{}) float64  { return math.Ceil(conv.ToFloat64(n)) }
+func (gomplateMath) Floor(n interface{}) float64 { return math.Floor(conv.ToFloat64(n)) }
+func (gomplateMath) Round(n interface{}) float64 { return math.Round(conv.ToFloat64(n)) }
+func (gomplateMath) IsInt(n interface{}) bool    { return gomplateIsInt(n) }
+func (gomplateMath) IsFloat(n interface{}) bool  { return gomplateIsNum(n) && !gomplateIsInt(n) }
+func (gomplateMath) IsNum(n interface{}) bool    { return gomplateIsNum(n) }
+
+// Seq returns the integers from start to end inclusive: math.Seq [start] end [step]
+// start defaults to 1, and step to 1 or -1 towards end
+func (gomplateMath) Seq(n ...interface{}) ([]int64, error) {
+	start, step := int64(1), int64(0)
+	var end int64
+	switch len(n) {
+	case 1:
+		end = conv.ToInt64(n[0])
+	case 2:
+		start, end = conv.ToInt64(n[0]), conv.ToInt64(n[1])
+	case 3:
+		start, end, step = conv.ToInt64(n[0]), conv.ToInt64(n[1]), conv.ToInt64(n[2])
+	default:
+		return nil, errors.New("math.Seq: expected [start] end [step]")
+	}
+	if step == 0 {
+		step = 1
+		if end < start {
+			step = -1
+		}
+	}
+	result := []int64{}
+	for i := start; (step > 0 && i <= end) || (step < 0 && i >= end); i += step {
+		result = append(result, i)
+	}
+	return result, nil
+}
+
+// gomplateArith folds the operands as integers, or as floats when any operand is a float
+func gomplateArith(n []interface{}, intOp func(a, b int64) int64, floatOp func(a, b float64) float64) interface{} {
+	if len(n) == 0 {
+		return int64(0)
+	}
+	for _, v := range n {
+		if !gomplateIsInt(v) {
+			result := conv.ToFloat64(n[0])
+			for _, v := range n[1:] {
+				result = floatOp(result, conv.ToFloat64(v))
+			}
+			return result
+		}
+	}
+	result := conv.ToInt64(n[0])
+	for _, v := range n[1:] {
+		result = intOp(result, conv.ToInt64(v))
+	}
+	return result
+}
+
+// gomplateIsInt reports whether a value is an integer or a string holding one
+func gomplateIsInt(n interface{}) bool {
+	switch reflect.ValueOf(n).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.String:
+		_, err := strconv.ParseInt(strings.TrimSpace(conv.ToString(n)), 0, 64)
+		return err == nil
+	}
+	return false
+}
+
+// gomplateIsNum reports whether a value is a number or a string holding one
+func gomplateIsNum(n interface{}) bool {
+	switch reflect.ValueOf(n).Kind() {
+	case reflect.Float32, reflect.Float64:
+		return true
+	case reflect.String:
+		_, err := strconv.ParseFloat(strings.TrimSpace(conv.ToString(n)), 64)
+		return err == nil || gomplateIsInt(n)
+	}
+	return gomplateIsInt(n)
+}
+
+// gomplateRegexp is the regexp namespace; the input is the last argument
+type gomplateRegexp struct{}
+
+func (gomplateRegexp) Match(re string, in interface{}) (bool, error) {
+	return regexp.MatchString(re, conv.ToString(in))
+}
+func (gomplateRegexp) Find(re string, in interface{}) (string, error) {
+	compiled, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return compiled.FindString(conv.ToString(in)), nil
+}
+func (gomplateRegexp) Replace(re, replacement string, in interface{}) (string, error) {
+	compiled, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return compiled.ReplaceAllString(conv.ToString(in), replacement), nil
+}
+func (gomplateRegexp) ReplaceLiteral(re, replacement string, in interface{}) (string, error) {
+	compiled, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return compiled.ReplaceAllLiteralString(conv.ToString(in), replacement), nil
+}
+func (gomplateRegexp) QuoteMeta(in interface{}) string { return regexp.QuoteMeta(conv.ToString(in)) }
+
+// FindAll returns the matches, at most n when given: regexp.FindAll re [n] input
+func (gomplateRegexp) FindAll(args ...interface{}) ([]string, error) {
+	compiled, n, in, err := gomplateRegexpArgs("regexp.FindAll", args)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.FindAllString(in, n), nil
+}
+
+// Split splits around matches, into at most n parts when given: regexp.Split re [n] input
+func (gomplateRegexp) Split(args ...interface{}) ([]string, error) {
+	compiled, n, in, err := gomplateRegexpArgs("regexp.Split", args)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.Split(in, n), nil
+}
+
+func gomplateRegexpArgs(name string, args []interface{}) (*regexp.Regexp, int, string, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, 0, "", fmt.Errorf("%s: expected re [n] input", name)
+	}
+	compiled, err := regexp.Compile(conv.ToString(args[0]))
+	if err != nil {
+		return nil, 0, "", err
+	}
+	n := -1
+	if len(args) == 3 {
+		n = int(conv.ToInt64(args[1]))
+	}
+	return compiled, n, conv.ToString(args[len(args)-1]), nil
+}
+
+// gomplatePath is the path namespace for slash-separated paths
+type gomplatePath struct{}
+
+func (gomplatePath) Base(in interface{}) string  { return path.Base(conv.ToString(in)) }
+func (gomplatePath) Clean(in interface{}) string { return path.Clean(conv.ToString(in)) }
+func (gomplatePath) Dir(in interface{}) string   { return path.Dir(conv.ToString(in)) }
+func (gomplatePath) Ext(in interface{}) string   { return path.Ext(conv.ToString(in)) }
+func (gomplatePath) IsAbs(in interface{}) bool   { return path.IsAbs(conv.ToString(in)) }
+func (gomplatePath) Join(elem ...interface{}) string {
+	return path.Join(conv.ToStrings(elem...)...)
+}
+func (gomplatePath) Match(pattern, name interface{}) (bool, error) {
+	return path.Match(conv.ToString(pattern), conv.ToString(name))
+}
+func (gomplatePath) Split(in interface{}) []string {
+	dir, file := path.Split(conv.ToString(in))
+	return []string{dir, file}
+}
+
+// gomplateFilepath is the filepath namespace, using the separator of the platform running the
+// template (always / in the browser)
+type gomplateFilepath struct{}
+
+func (gomplateFilepath) Base(in interface{}) string  { return filepath.Base(conv.ToString(in)) }
+func (gomplateFilepath) Clean(in interface{}) string { return filepath.Clean(conv.ToString(in)) }
+func (gomplateFilepath) Dir(in interface{}) string   { return filepath.Dir(conv.ToString(in)) }
+func (gomplateFilepath) Ext(in interface{}) string   { return filepath.Ext(conv.ToString(in)) }
+func (gomplateFilepath) IsAbs(in interface{}) bool   { return filepath.IsAbs(conv.ToString(in)) }
+func (gomplateFilepath) Join(elem ...interface{}) string {
+	return filepath.Join(conv.ToStrings(elem...)...)
+}
+func (gomplateFilepath) ToSlash(in interface{}) string {
+	return filepath.ToSlash(conv.ToString(in))
+}
+func (gomplateFilepath) FromSlash(in interface{}) string {
+	return filepath.FromSlash(conv.ToString(in))
+}
+
+// gomplateTime is the time namespace; Now follows the render clock
+type gomplateTime struct {
+	env *renderEnv
+}
+
+func (t gomplateTime) Now() time.Time { return t.env.currentTime() }
+func (gomplateTime) Parse(layout string, value interface{}) (time.Time, error) {
+	return time.Parse(layout, conv.ToString(value))
+}
+func (gomplateTime) ParseDuration(in interface{}) (time.Duration, error) {
+	return time.ParseDuration(conv.ToString(in))
+}
+
+// Unix converts seconds since the epoch to a time in the render time zone
+func (t gomplateTime) Unix(in interface{}) time.Time {
+	return time.Unix(conv.ToInt64(in), 0).In(t.env.currentTime().Location())
+}
+
+// gomplateCrypto is the crypto namespace; only the hash functions are provided
+type gomplateCrypto struct{}
+
+func (gomplateCrypto) SHA1(in interface{}) string {
+	sum := sha1.Sum([]byte(conv.ToString(in)))
+	return hex.EncodeToString(sum[:])
+}
+func (gomplateCrypto) SHA224(in interface{}) string {
+	sum := sha256.Sum224([]byte(conv.ToString(in)))
+	return hex.EncodeToString(sum[:])
+}
+func (gomplateCrypto) SHA256(in interface{}) string {
+	sum := sha256.Sum256([]byte(conv.ToString(in)))
+	return hex.EncodeToString(sum[:])
+}
+func (gomplateCrypto) SHA384(in interface{}) string {
+	sum := sha512.Sum384([]byte(conv.ToString(in)))
+	return hex.EncodeToString(sum[:])
+}
+func (gomplateCrypto) SHA512(in interface{}) string {
+	sum := sha512.Sum512([]byte(conv.ToString(in)))
+	return hex.EncodeToString(sum[:])
+}
+
+// gomplateBase64 is the base64 namespace
+type gomplateBase64 struct{}
+
+func (gomplateBase64) Encode(in interface{}) string {
+	return base64.StdEncoding.EncodeToString([]byte(conv.ToString(in)))
+}
+func (gomplateBase64) Decode(in interface{}) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(conv.ToString(in))
+	return string(data), err
+}
+
+// gomplateEnv is the env namespace
+type gomplateEnv struct{}
+
+// Getenv returns an environment variable, or the default when it is unset or empty
+func (gomplateEnv) Getenv(name interface{}, def ...interface{}) string {
+	if value := os.Getenv(conv.ToString(name)); value != "" || len(def) == 0 {
+		return value
+	}
+	return conv.ToString(def[0])
+}
+func (gomplateEnv) ExpandEnv(in interface{}) string { return os.ExpandEnv(conv.ToString(in)) }
+
+// gomplateTest is the test namespace
+type gomplateTest struct{}
+
+// Assert fails rendering when the value is false: test.Assert [message] value
+func (gomplateTest) Assert(args ...interface{}) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", errors.New("test.Assert: expected [message] value")
+	}
+	if conv.ToBool(args[len(args)-1]) {
+		return "", nil
+	}
+	if len(args) == 2 {
+		return "", fmt.Errorf("assertion failed: %s", conv.ToString(args[0]))
+	}
+	return "", errors.New("assertion failed")
+}
+
+// Fail fails rendering: test.Fail [message]
+func (gomplateTest) Fail(args ...interface{}) (string, error) {
+	if len(args) > 0 {
+		return "", fmt.Errorf("template generation failed: %s", conv.ToString(args[0]))
+	}
+	return "", errors.New("template generation failed")
+}
+
+// Required fails rendering when the value is nil or "": test.Required [message] value
+func (gomplateTest) Required(args ...interface{}) (interface{}, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errors.New("test.Required: expected [message] value")
+	}
+	value := args[len(args)-1]
+	if value != nil && value != "" {
+		return value, nil
+	}
+	if len(args) == 2 {
+		return nil, errors.New(conv.ToString(args[0]))
+	}
+	return nil, errors.New("can not render template: a required value was not set")
+}
+
+func (gomplateTest) Ternary(whenTrue, whenFalse, condition interface{}) interface{} {
+	if conv.ToBool(condition) {
+		return whenTrue
+	}
+	return whenFalse
+}
+func (gomplateTest) Kind(in interface{}) string {
+	if in == nil {
+		return "invalid"
+	}
+	return reflect.ValueOf(in).Kind().String()
+}
+func (gomplateTest) IsKind(kind string, in interface{}) bool {
+	actual := gomplateTest{}.Kind(in)
+	if kind == "number" {
+		return strings.HasPrefix(actual, "int") || strings.HasPrefix(actual, "uint") || strings.HasPrefix(actual, "float")
+	}
+	return actual == kind
+}
+
+// gomplateRandom is the random namespace; it draws from the render random source
+type gomplateRandom struct {
+	env *renderEnv
+}
+
+const (
+	gomplateAlphaNum = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	gomplateASCII    = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+)
+
+func (r gomplateRandom) ASCII(count interface{}) string {
+	return gomplateRandomString(r.env, count, gomplateASCII)
+}
+func (r gomplateRandom) Alpha(count interface{}) string {
+	return gomplateRandomString(r.env, count, gomplateAlphaNum[10:])
+}
+func (r gomplateRandom) AlphaNum(count interface{}) string {
+	return gomplateRandomString(r.env, count, gomplateAlphaNum)
+}
+
+// Number returns an integer from min to max inclusive: random.Number [[min] max], 0 to 100 by default
+func (r gomplateRandom) Number(args ...interface{}) (int64, error) {
+	low, high := int64(0), int64(100)
+	switch len(args) {
+	case 0:
+	case 1:
+		high = conv.ToInt64(args[0])
+	case 2:
+		low, high = conv.ToInt64(args[0]), conv.ToInt64(args[1])
+	default:
+		return 0, errors.New("random.Number: expected [[min] max]")
+	}
+	if high < low {
+		return 0, fmt.Errorf("random.Number: max %d is below min %d", high, low)
+	}
+	return low + r.env.randomSource().Int63n(high-low+1), nil
+}
+
+// Item returns a random element of a list
+func (r gomplateRandom) Item(list interface{}) (interface{}, error) {
+	items, err := gomplateList(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errors.New("random.Item: empty list")
+	}
+	return items[r.env.randomSource().Intn(len(items))], nil
+}
+
+func gomplateRandomString(env *renderEnv, count interface{}, alphabet string) string {
+	random := env.randomSource()
+	b := make([]byte, max(conv.ToInt64(count), 0))
+	for i := range b {
+		b[i] = alphabet[random.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// gomplateUUID is the uuid namespace
+type gomplateUUID struct {
+	env *renderEnv
+}
+
+var gomplateUUIDPattern = regexp.MustCompile(`^(urn:uuid:)?\{?[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}\}?$`)
+
+// V4 returns a random UUID drawn from the render random source
+func (u gomplateUUID) V4() string {
+	random := u.env.randomSource()
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(random.Intn(256))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (gomplateUUID) IsValid(in interface{}) bool {
+	return gomplateUUIDPattern.MatchString(conv.ToString(in))
+}
@@ -0,0 +1,122 @@
+//go:build !js && gomplate
+// +build !js,gomplate
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// createGomplateParser creates a parser with the gomplate namespaces registered
+func createGomplateParser() *Parser {
+	registerGomplateFunctions()
+	return NewParser(GetGlobalRegistry())
+}
+
+// createGomplateRenderer creates a renderer backed by the gomplate render function map
+func createGomplateRenderer() *Renderer {
+	registerGomplateFunctions()
+	return NewRenderer(GetGlobalRegistry(), func(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+		result := make(map[string]interface{})
+		for name, fn := range GetGomplateRenderFuncMap(variables, env) {
+			result[name] = fn
+		}
+		return result
+	})
+}
+
+// TestGomplateFunctions_VariableExtraction tests that fields passed to namespaced functions are extracted
+func TestGomplateFunctions_VariableExtraction(t *testing.T) {
+	parser := createGomplateParser()
+
+	tests := []struct {
+		name     string
+		template string
+		expected []VariableInfo
+	}{
+		{
+			name:     "namespaced call",
+			template: `{{strings.ToUpper .Name}}`,
+			expected: []VariableInfo{{Name: "Name"}},
+		},
+		{
+			name:     "piped into namespaced calls",
+			template: `{{.Title | strings.Trunc 10 | strings.Indent 2}}`,
+			expected: []VariableInfo{{Name: "Title"}},
+		},
+		{
+			name:     "nested namespaces",
+			template: `{{range coll.Keys (data.JSON .Config)}}{{.}}{{end}}`,
+			expected: []VariableInfo{{Name: "Config"}},
+		},
+		{
+			name:     "default alias",
+			template: `{{.Port | default 8080}}`,
+			expected: []VariableInfo{{Name: "Port", DefaultValue: "8080"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, err := parser.ExtractVariablesWithDefaults("test.tmpl", tt.template)
+			if err != nil {
+				t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(vars, tt.expected) {
+				t.Errorf("ExtractVariablesWithDefaults() = %+v, want %+v", vars, tt.expected)
+			}
+		})
+	}
+}
+
+// TestGomplateFunctions_Render tests rendering with gomplate namespaces and aliases
+func TestGomplateFunctions_Render(t *testing.T) {
+	renderer := createGomplateRenderer()
+	renderer.Environment().SetRenderClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli())
+
+	tests := []struct {
+		name     string
+		template string
+		values   map[string]interface{}
+		expected string
+	}{
+		{"strings", `{{strings.ToUpper .Name}} {{"a,b" | strings.Split ","}} {{strings.Indent 2 "x\ny"}}`, map[string]interface{}{"Name": "web"}, "WEB [a b]   x\n  y"},
+		{"case", `{{strings.CamelCase "hello big world"}} {{strings.SnakeCase "HelloWorld"}} {{strings.KebabCase "hello world"}}`, nil, "helloBigWorld hello_world hello-world"},
+		{"conv", `{{conv.ToInt64 "0x10"}} {{conv.ToBool "yes"}} {{conv.Join (coll.Slice 1 2) "-"}} {{conv.Default "x" ""}}`, nil, "16 true 1-2 x"},
+		{"coll", `{{$d := coll.Dict "b" 2 "a" 1}}{{coll.Keys $d}} {{coll.Has $d "a"}} {{coll.Sort (coll.Slice 3 1 2)}}`, nil, "[a b] true [1 2 3]"},
+		{"merge", `{{$m := coll.Merge (dict "a" 1) (dict "a" 2 "b" 3)}}{{$m.a}}{{$m.b}}`, nil, "13"},
+		{"data", `{{(data.JSON .Config).port}} {{data.ToJSON (coll.Slice 1 "x")}}`, map[string]interface{}{"Config": `{"port": 80}`}, `80 [1,"x"]`},
+		{"math", `{{math.Add 1 2 3}} {{math.Add 1 2.5}} {{math.Div 7 2}} {{math.Seq 3}} {{math.Max 4 9 2}}`, nil, "6 3.5 3.5 [1 2 3] 9"},
+		{"regexp", `{{regexp.Replace "(\\d+)" "<$1>" "v12"}} {{regexp.Match "^v" "v1"}}`, nil, "v<12> true"},
+		{"paths", `{{path.Base "/a/b.txt"}} {{path.Join "a" "b"}} {{path.Ext "x.tar.gz"}}`, nil, "b.txt a/b .gz"},
+		{"encoding", `{{base64.Encode "hello"}} {{crypto.SHA1 "a"}}`, nil, "aGVsbG8= 86f7e437faa5a7fce15d1ddcb9eaeaea377667b8"},
+		{"time", `{{(time.Now).Format "2006-01-02"}} {{(time.Unix 0).Year}}`, nil, "2024-03-01 1970"},
+		{"test", `{{test.Ternary "on" "off" .Enabled}} {{test.IsKind "number" 3}}`, map[string]interface{}{"Enabled": true}, "on true"},
+		{"aliases", `{{toUpper "a"}} {{.Port | default 8080}} {{has (coll.Slice "x") "x"}}`, nil, "A 8080 true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderer.Render(tt.template, tt.values, RenderOptions{})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if result.Output != tt.expected {
+				t.Errorf("Render() = %q, want %q", result.Output, tt.expected)
+			}
+		})
+	}
+
+	for _, tmpl := range []string{`{{math.Div 1 0}}`, `{{test.Assert "must hold" false}}`, `{{regexp.Match "(" "x"}}`} {
+		if _, err := renderer.Render(tmpl, nil, RenderOptions{}); err == nil {
+			t.Errorf("Render(%q) succeeded, want an error", tmpl)
+		}
+	}
+}
+
+// TestGomplateExamples_RenderWithSampleValues tests that every embedded gomplate example renders
+func TestGomplateExamples_RenderWithSampleValues(t *testing.T) {
+	testProfileExamples(t, ProfileGomplate, createGomplateParser(), createGomplateRenderer())
+}
@@ -0,0 +1,222 @@
+//go:build helm
+// +build helm
+
+// This file contains the Helm chart functions, built on top of the sprig function set
+// Tag: helm (works for both js && helm WASM builds and !js && helm tests)
+// include and tpl execute templates of the set being rendered; named templates are loaded from
+// the registered includes that define them, as Helm loads a chart's _helpers.tpl.
+// Renders start from default .Release, .Chart, .Capabilities and .Template objects, so chart
+// templates render outside a cluster; lookup finds nothing, as with helm template
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxIncludeDepth bounds nested include and tpl calls, as a template including itself would
+// otherwise recurse until the stack overflows
+const maxIncludeDepth = 1000
+
+// registerHelmFunctions registers the sprig functions and the Helm chart functions
+// This is called by both WASM (via init in main_helm.go) and tests
+func registerHelmFunctions() {
+	registerSprigFunctions()
+	registry := GetGlobalRegistry()
+	registry.SetProfile(ProfileHelm)
+	registry.SetBuiltinValues(helmBuiltinValues)
+	registry.SetTemplateSetFuncs(helmTemplateSetFuncs)
+
+	// include - the output of a named template; extraction follows it like {{template}}
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:        "include",
+		Description: "Renders a named template and returns its output, for piping into nindent",
+		Handler:     includeMinimalHandler,
+	})
+
+	// tpl - renders a string as a template; the variables it reads are only known once rendered
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:        "tpl",
+		Description: "Renders a string as a template with the given data",
+		Handler:     tplMinimalHandler,
+	})
+
+	registry.RegisterFunction(&FunctionDefinition{Name: "toYaml", Description: "Encodes as YAML", Handler: helmToYaml})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mustToYaml", Description: "Encodes as YAML, failing on values that cannot be", Handler: helmMustToYaml})
+	registry.RegisterFunction(&FunctionDefinition{Name: "fromYaml", Description: "Decodes a YAML mapping; errors are returned under the Error key", Handler: helmFromYaml})
+	registry.RegisterFunction(&FunctionDefinition{Name: "fromYamlArray", Description: "Decodes a YAML sequence; errors are returned as the only element", Handler: helmFromYamlArray})
+	registry.RegisterFunction(&FunctionDefinition{Name: "fromJsonArray", Description: "Decodes a JSON array; errors are returned as the only element", Handler: helmFromJSONArray})
+	registry.RegisterFunction(&FunctionDefinition{Name: "lookup", Description: "Looks up a cluster resource; finds nothing outside a cluster", Handler: helmLookup})
+}
+
+// GetHelmRenderFuncMap returns the render implementations of the Helm profile that differ
+// from the registered handlers
+// include and tpl only work in text/template renders, where each execution replaces them with
+// functions bound to its template set (see helmTemplateSetFuncs)
+func GetHelmRenderFuncMap(variables map[string]interface{}, env *renderEnv) template.FuncMap {
+	funcMap := GetSprigRenderFuncMap(variables, env)
+	funcMap["include"] = func(name string, data interface{}) (string, error) {
+		return "", errors.New("include: no template is being rendered as text")
+	}
+	funcMap["tpl"] = func(text string, data interface{}) (string, error) {
+		return "", errors.New("tpl: no template is being rendered as text")
+	}
+	return funcMap
+}
+
+func includeMinimalHandler(name string, data interface{}) (string, error) { return "", nil }
+func tplMinimalHandler(text string, data interface{}) (string, error)     { return "", nil }
+
+// helmTemplateSetFuncs returns include and tpl executing the templates of set
+// Nested calls of one execution share a depth count
+func helmTemplateSetFuncs(set *template.Template) template.FuncMap {
+	depth := 0
+	return helmSetFuncs(set, &depth)
+}
+
+// helmSetFuncs returns include and tpl bound to set, counting nested calls in depth
+func helmSetFuncs(set *template.Template, depth *int) template.FuncMap {
+	return template.FuncMap{
+		// include executes the named template of the set and returns its output
+		"include": func(name string, data interface{}) (string, error) {
+			if *depth >= maxIncludeDepth {
+				return "", fmt.Errorf("include: rendering template has a nested reference name: %s", name)
+			}
+			*depth++
+			defer func() { *depth-- }()
+			var output strings.Builder
+			if err := set.ExecuteTemplate(&output, name, data); err != nil {
+				return "", err
+			}
+			return output.String(), nil
+		},
+		// tpl renders text as a template that can include the templates of the set
+		"tpl": func(text string, data interface{}) (string, error) {
+			if *depth >= maxIncludeDepth {
+				return "", errors.New("tpl: too many nested tpl and include calls")
+			}
+			clone, err := set.Clone()
+			if err != nil {
+				return "", err
+			}
+			clone.Funcs(helmSetFuncs(clone, depth))
+			tmpl, err := clone.New("tpl").Parse(text)
+			if err != nil {
+				return "", fmt.Errorf("tpl: %v", err)
+			}
+			*depth++
+			defer func() { *depth-- }()
+			var output strings.Builder
+			if err := tmpl.Execute(&output, data); err != nil {
+				return "", fmt.Errorf("tpl: %v", err)
+			}
+			return output.String(), nil
+		},
+	}
+}
+
+// helmToYaml encodes with two-space indentation and no trailing newline, or returns "" when
+// the value cannot be encoded
+func helmToYaml(v interface{}) string {
+	s, _ := helmMustToYaml(v)
+	return s
+}
+
+func helmMustToYaml(v interface{}) (string, error) {
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func helmFromYaml(s string) map[string]interface{} {
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+		return map[string]interface{}{"Error": err.Error()}
+	}
+	return m
+}
+
+func helmFromYamlArray(s string) []interface{} {
+	list := []interface{}{}
+	if err := yaml.Unmarshal([]byte(s), &list); err != nil {
+		return []interface{}{err.Error()}
+	}
+	return list
+}
+
+func helmFromJSONArray(s string) []interface{} {
+	list, err := sprigFromJSON(s)
+	if err != nil {
+		return []interface{}{err.Error()}
+	}
+	if items, ok := list.([]interface{}); ok {
+		return items
+	}
+	return []interface{}{fmt.Sprintf("expected a JSON array, got %T", list)}
+}
+
+func helmLookup(apiVersion, kind, namespace, name string) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+// helmAPIVersions lists the API versions of .Capabilities.APIVersions
+type helmAPIVersions []string
+
+// Has reports whether an API version, such as apps/v1 or apps/v1/Deployment, is available
+func (v helmAPIVersions) Has(apiVersion string) bool {
+	for _, version := range v {
+		if version == apiVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// helmBuiltinValues builds the objects Helm provides next to .Values, as helm template sets them
+func helmBuiltinValues() map[string]interface{} {
+	return map[string]interface{}{
+		"Values": map[string]interface{}{},
+		"Release": map[string]interface{}{
+			"Name":      "release-name",
+			"Namespace": "default",
+			"Service":   "Helm",
+			"Revision":  1,
+			"IsInstall": true,
+			"IsUpgrade": false,
+		},
+		"Chart": map[string]interface{}{
+			"Name":       "chart",
+			"Version":    "0.1.0",
+			"AppVersion": "1.0.0",
+		},
+		"Capabilities": map[string]interface{}{
+			"KubeVersion": map[string]interface{}{
+				"Version":    "v1.30.0",
+				"GitVersion": "v1.30.0",
+				"Major":      "1",
+				"Minor":      "30",
+			},
+			"APIVersions": helmAPIVersions{
+				"v1", "apps/v1", "batch/v1", "autoscaling/v2", "networking.k8s.io/v1",
+				"policy/v1", "rbac.authorization.k8s.io/v1", "storage.k8s.io/v1",
+			},
+			"HelmVersion": map[string]interface{}{"Version": "v3.15.0"},
+		},
+		"Template": map[string]interface{}{
+			"Name":     "chart/templates/template.yaml",
+			"BasePath": "chart/templates",
+		},
+	}
+}
@@ -0,0 +1,184 @@
+//go:build !js && helm
+// +build !js,helm
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const helmHelpers = `{{- define "chart.fullname" -}}
+{{ .Release.Name }}-{{ .Chart.Name }}
+{{- end -}}
+{{- define "chart.labels" -}}
+app: {{ include "chart.fullname" . }}
+team: {{ .Values.team | default "core" }}
+{{- end -}}`
+
+const helmDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "chart.fullname" . }}
+  labels:
+    {{- include "chart.labels" . | nindent 4 }}
+spec:
+  replicas: {{ .Values.replicaCount }}
+  template:
+    spec:
+      containers:
+        - name: app
+          resources:
+            {{- toYaml .Values.resources | nindent 12 }}
+`
+
+// createHelmRenderer creates a renderer backed by the Helm render function map
+func createHelmRenderer() *Renderer {
+	registerHelmFunctions()
+	return NewRenderer(GetGlobalRegistry(), func(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+		result := make(map[string]interface{})
+		for name, fn := range GetHelmRenderFuncMap(variables, env) {
+			result[name] = fn
+		}
+		return result
+	})
+}
+
+// TestHelmFunctions_Extraction tests that extraction follows include into the registered helpers
+func TestHelmFunctions_Extraction(t *testing.T) {
+	registerHelmFunctions()
+	parser := NewParser(GetGlobalRegistry())
+	parser.env.SetTemplateIncludes(map[string]string{"_helpers.tpl": helmHelpers})
+
+	vars, err := parser.ExtractVariablesWithDefaults("deployment.yaml", helmDeployment)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+	}
+	// chart.fullname is included twice, directly and through chart.labels
+	expected := []VariableInfo{
+		{Name: "Release.Name"},
+		{Name: "Chart.Name"},
+		{Name: "Release.Name"},
+		{Name: "Chart.Name"},
+		{Name: "Values.team", DefaultValue: "core"},
+		{Name: "Values.replicaCount"},
+		{Name: "Values.resources"},
+	}
+	if !reflect.DeepEqual(vars, expected) {
+		t.Errorf("ExtractVariablesWithDefaults() = %+v, want %+v", vars, expected)
+	}
+}
+
+// TestHelmFunctions_Render tests rendering a chart template with the built-in objects
+func TestHelmFunctions_Render(t *testing.T) {
+	renderer := createHelmRenderer()
+	renderer.Environment().SetTemplateIncludes(map[string]string{"_helpers.tpl": helmHelpers})
+
+	values := map[string]interface{}{
+		"Release": map[string]interface{}{"Name": "web"},
+		"Values": map[string]interface{}{
+			"replicaCount": 2,
+			"resources":    map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m"}},
+		},
+	}
+	result, err := renderer.Render(helmDeployment, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{
+		"  name: web-chart\n",
+		"  labels:\n    app: web-chart\n    team: core\n",
+		"  replicas: 2\n",
+		"          resources:\n            limits:\n              cpu: 500m\n",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("Render() output = %q, want it to contain %q", result.Output, want)
+		}
+	}
+	if len(result.MissingKeys) != 0 {
+		t.Errorf("Render() missing keys = %v, want none with built-in objects", result.MissingKeys)
+	}
+
+	tests := []struct {
+		name, template, expected string
+	}{
+		{"tpl", `{{tpl .Values.greeting .}}`, "hello web"},
+		{"capabilities", `{{.Capabilities.APIVersions.Has "apps/v1"}} {{.Capabilities.KubeVersion.Minor}}`, "true 30"},
+		{"fromYaml", `{{(fromYaml "a: 1\nb: [x]").b}}`, "[x]"},
+		{"lookup", `{{len (lookup "v1" "Secret" "default" "db")}}`, "0"},
+	}
+	values["Values"].(map[string]interface{})["greeting"] = "hello {{ .Release.Name }}"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderer.Render(tt.template, values, RenderOptions{})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if result.Output != tt.expected {
+				t.Errorf("Render() = %q, want %q", result.Output, tt.expected)
+			}
+		})
+	}
+
+	if _, err := renderer.Render(`{{define "loop"}}{{include "loop" .}}{{end}}{{include "loop" .}}`, nil, RenderOptions{}); err == nil {
+		t.Errorf("Render() of a recursive include succeeded")
+	}
+}
+
+// TestHelmFunctions_BuiltinValuesPerRender tests that a render modifying the built-in objects
+// leaves them unchanged for later renders
+func TestHelmFunctions_BuiltinValuesPerRender(t *testing.T) {
+	renderer := createHelmRenderer()
+	template := `{{.Release.Name}} {{.Chart.Name}}`
+
+	for i, values := range []map[string]interface{}{nil, {"Chart": map[string]interface{}{"Version": "2.0.0"}}} {
+		result, err := renderer.Render(`{{$_ := set .Release "Name" "hacked"}}{{$_ := set .Chart "Name" "other"}}`+template, values, RenderOptions{})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if result.Output != "hacked other" {
+			t.Errorf("Render() %d modifying the built-in objects = %q, want %q", i, result.Output, "hacked other")
+		}
+
+		result, err = renderer.Render(template, nil, RenderOptions{})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if result.Output != "release-name chart" {
+			t.Errorf("Render() %d after a render modifying the built-in objects = %q, want %q", i, result.Output, "release-name chart")
+		}
+	}
+}
+
+// TestHelmFunctions_ConcurrentInclude tests that include and tpl execute the templates of their
+// own render when renders run concurrently
+func TestHelmFunctions_ConcurrentInclude(t *testing.T) {
+	renderer := createHelmRenderer()
+	var wg sync.WaitGroup
+	errs := make(chan string, 40)
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			template := fmt.Sprintf(`{{define "name"}}%d{{end}}{{include "name" .}} {{tpl "{{include \"name\" .}}" .}}`, i)
+			result, err := renderer.Render(template, nil, RenderOptions{})
+			if want := fmt.Sprintf("%d %d", i, i); err != nil || result.Output != want {
+				errs <- fmt.Sprintf("render %d = %v, %v; want %q", i, result, err, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestHelmExamples_RenderWithSampleValues tests that every embedded Helm example renders
+func TestHelmExamples_RenderWithSampleValues(t *testing.T) {
+	renderer := createHelmRenderer()
+	testProfileExamples(t, ProfileHelm, NewParser(GetGlobalRegistry()), renderer)
+}
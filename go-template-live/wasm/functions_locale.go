@@ -0,0 +1,189 @@
+//go:build confd || custom
+// +build confd custom
+
+// This file contains the locale-aware formatting functions shared by the Confd and custom profiles
+// Tag: confd || custom (registered by registerConfdFunctions and registerCustomFunctions)
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// registerLocaleFunctions registers formatNumber, formatCurrency and formatDate, which format a
+// value for a BCP 47 locale such as "de-DE": {{formatNumber "de-DE" 1234567.89}} is 1.234.567,89
+func registerLocaleFunctions(registry *FunctionRegistry) {
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "formatNumber",
+		Description:           "Format a number with the locale's separators and an optional number of decimals",
+		Handler:               formatNumberMinimalHandler,
+		Extractor:             extractAllArgVariables,
+		ExtractorWithDefaults: extractAllArgVariablesInfo,
+		ArgTypeHint:           TypeNumber,
+	})
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "formatCurrency",
+		Description:           "Format an amount of an ISO 4217 currency for the locale",
+		Handler:               formatCurrencyMinimalHandler,
+		Extractor:             extractAllArgVariables,
+		ExtractorWithDefaults: extractAllArgVariablesInfo,
+	})
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "formatDate",
+		Description:           "Format a date (time, RFC 3339 string or Unix seconds) in the locale's numeric date order",
+		Handler:               formatDateMinimalHandler,
+		Extractor:             extractAllArgVariables,
+		ExtractorWithDefaults: extractAllArgVariablesInfo,
+	})
+}
+
+// Minimal handlers for parsing
+func formatNumberMinimalHandler(locale string, value interface{}, decimals ...int) (string, error) {
+	return "", nil
+}
+func formatCurrencyMinimalHandler(locale string, amount interface{}, code string) (string, error) {
+	return "", nil
+}
+func formatDateMinimalHandler(locale string, value interface{}) (string, error) { return "", nil }
+
+// localePrinter returns a printer for a BCP 47 locale
+func localePrinter(fn, locale string) (*message.Printer, language.Tag, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, language.Und, fmt.Errorf("%s: invalid locale %q: %v", fn, locale, err)
+	}
+	return message.NewPrinter(tag), tag, nil
+}
+
+// localeNumber converts a value to a number x/text can format; numeric strings are accepted
+// as values from key-value stores are text
+func localeNumber(fn string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v, nil
+	case json.Number:
+		return localeNumber(fn, string(v))
+	case string:
+		s := strings.TrimSpace(v)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %v (%T) is not a number", fn, value, value)
+}
+
+// Actual handlers for rendering
+func formatNumberRenderHandler(locale string, value interface{}, decimals ...int) (string, error) {
+	printer, _, err := localePrinter("formatNumber", locale)
+	if err != nil {
+		return "", err
+	}
+	n, err := localeNumber("formatNumber", value)
+	if err != nil {
+		return "", err
+	}
+	var opts []number.Option
+	if len(decimals) > 0 {
+		if decimals[0] < 0 {
+			return "", fmt.Errorf("formatNumber: decimals must not be negative")
+		}
+		opts = append(opts, number.MinFractionDigits(decimals[0]), number.MaxFractionDigits(decimals[0]))
+	}
+	return printer.Sprint(number.Decimal(n, opts...)), nil
+}
+
+func formatCurrencyRenderHandler(locale string, amount interface{}, code string) (string, error) {
+	printer, _, err := localePrinter("formatCurrency", locale)
+	if err != nil {
+		return "", err
+	}
+	unit, err := currency.ParseISO(code)
+	if err != nil {
+		return "", fmt.Errorf("formatCurrency: invalid currency %q: %v", code, err)
+	}
+	n, err := localeNumber("formatCurrency", amount)
+	if err != nil {
+		return "", err
+	}
+	return printer.Sprint(currency.Symbol(unit.Amount(n))), nil
+}
+
+// dateLayouts are the numeric date orders of common locales; x/text has no date formatting
+// The first entry is the fallback for other locales: ISO 8601
+var dateLayouts = []struct {
+	tag    language.Tag
+	layout string
+}{
+	{language.Und, "2006-01-02"},
+	{language.AmericanEnglish, "1/2/2006"},
+	{language.BritishEnglish, "02/01/2006"},
+	{language.German, "02.01.2006"},
+	{language.French, "02/01/2006"},
+	{language.Spanish, "02/01/2006"},
+	{language.Italian, "02/01/2006"},
+	{language.Portuguese, "02/01/2006"},
+	{language.Dutch, "02-01-2006"},
+	{language.Russian, "02.01.2006"},
+	{language.Polish, "02.01.2006"},
+	{language.Japanese, "2006/01/02"},
+	{language.Chinese, "2006/01/02"},
+	{language.Korean, "2006. 1. 2."},
+}
+
+var dateLayoutMatcher = func() language.Matcher {
+	tags := make([]language.Tag, len(dateLayouts))
+	for i, entry := range dateLayouts {
+		tags[i] = entry.tag
+	}
+	return language.NewMatcher(tags)
+}()
+
+func formatDateRenderHandler(locale string, value interface{}) (string, error) {
+	_, tag, err := localePrinter("formatDate", locale)
+	if err != nil {
+		return "", err
+	}
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case string:
+		if t, err = time.Parse(time.RFC3339, strings.TrimSpace(v)); err != nil {
+			if t, err = time.Parse("2006-01-02", strings.TrimSpace(v)); err != nil {
+				return "", fmt.Errorf("formatDate: %q is not an RFC 3339 time or date", v)
+			}
+		}
+	case int:
+		t = time.Unix(int64(v), 0)
+	case int64:
+		t = time.Unix(v, 0)
+	case float64:
+		t = time.Unix(int64(v), 0)
+	case json.Number:
+		seconds, err := v.Int64()
+		if err != nil {
+			return "", fmt.Errorf("formatDate: %v is not a Unix time", v)
+		}
+		t = time.Unix(seconds, 0)
+	default:
+		return "", fmt.Errorf("formatDate: %v (%T) is not a time", value, value)
+	}
+
+	_, index, confidence := dateLayoutMatcher.Match(tag)
+	if confidence < language.High {
+		index = 0
+	}
+	return t.Format(dateLayouts[index].layout), nil
+}
@@ -0,0 +1,66 @@
+//go:build !js && (confd || custom)
+// +build !js
+// +build confd custom
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+// TestLocaleFunctions tests number, currency and date formatting for several locales
+func TestLocaleFunctions(t *testing.T) {
+	funcs := template.FuncMap{
+		"formatNumber":   formatNumberRenderHandler,
+		"formatCurrency": formatCurrencyRenderHandler,
+		"formatDate":     formatDateRenderHandler,
+	}
+	values := map[string]interface{}{
+		"Total": 1234567.89,
+		"Count": "42000",
+		"Day":   time.Date(2024, 3, 7, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{name: "German number", template: `{{formatNumber "de-DE" .Total}}`, want: "1.234.567,89"},
+		{name: "US number", template: `{{formatNumber "en-US" .Total}}`, want: "1,234,567.89"},
+		{name: "decimals", template: `{{formatNumber "en-US" .Total 1}}`, want: "1,234,567.9"},
+		{name: "numeric string", template: `{{formatNumber "fr-FR" .Count}}`, want: "42 000"},
+		{name: "currency", template: `{{formatCurrency "de-DE" 1234.5 "EUR"}}`, want: "€ 1.234,50"},
+		{name: "German date", template: `{{formatDate "de-DE" .Day}}`, want: "07.03.2024"},
+		{name: "US date", template: `{{formatDate "en-US" .Day}}`, want: "3/7/2024"},
+		{name: "Japanese date from string", template: `{{formatDate "ja-JP" "2024-03-07"}}`, want: "2024/03/07"},
+		{name: "regional variant date", template: `{{formatDate "de-AT" .Day}}`, want: "07.03.2024"},
+		{name: "fallback date", template: `{{formatDate "sv-SE" .Day}}`, want: "2024-03-07"},
+		{name: "invalid locale", template: `{{formatNumber "not a locale" 1}}`, wantErr: "invalid locale"},
+		{name: "invalid currency", template: `{{formatCurrency "en-US" 1 "XYZW"}}`, wantErr: "invalid currency"},
+		{name: "not a number", template: `{{formatNumber "en-US" "abc"}}`, wantErr: "is not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("test").Funcs(funcs).Parse(tt.template))
+			var output strings.Builder
+			err := tmpl.Execute(&output, values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if output.String() != tt.want {
+				t.Errorf("Execute() = %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+//go:build confd
+// +build confd
+
+// This file contains the exact integer arithmetic behind the Confd math functions
+// Tag: confd (works for both js && confd WASM builds and !js && confd tests)
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExactFloat is the largest integer magnitude a float64 holds exactly (2^53); float64 values
+// above it may have been rounded already. ParseValues keeps JSON integers exact as ints, or as
+// json.Number beyond the int range
+const maxExactFloat = 1 << 53
+
+// mathOperand is a math function argument: an integer for exact arithmetic, or a float
+type mathOperand struct {
+	integer *big.Int
+	float   float64
+	// text is set for operands given as strings, which select string results
+	text bool
+}
+
+// toMathOperand converts an argument, rejecting float64 integers beyond maxExactFloat
+func toMathOperand(fn string, value interface{}) (mathOperand, error) {
+	switch v := value.(type) {
+	case int:
+		return mathOperand{integer: big.NewInt(int64(v))}, nil
+	case int8:
+		return mathOperand{integer: big.NewInt(int64(v))}, nil
+	case int16:
+		return mathOperand{integer: big.NewInt(int64(v))}, nil
+	case int32:
+		return mathOperand{integer: big.NewInt(int64(v))}, nil
+	case int64:
+		return mathOperand{integer: big.NewInt(v)}, nil
+	case uint:
+		return mathOperand{integer: new(big.Int).SetUint64(uint64(v))}, nil
+	case uint8:
+		return mathOperand{integer: new(big.Int).SetUint64(uint64(v))}, nil
+	case uint16:
+		return mathOperand{integer: new(big.Int).SetUint64(uint64(v))}, nil
+	case uint32:
+		return mathOperand{integer: new(big.Int).SetUint64(uint64(v))}, nil
+	case uint64:
+		return mathOperand{integer: new(big.Int).SetUint64(v)}, nil
+	case float32:
+		return floatOperand(fn, float64(v))
+	case float64:
+		return floatOperand(fn, v)
+	case json.Number:
+		operand, err := textOperand(fn, string(v))
+		operand.text = false
+		return operand, err
+	case string:
+		return textOperand(fn, v)
+	}
+	return mathOperand{}, fmt.Errorf("%s: %v (%T) is not a number", fn, value, value)
+}
+
+func floatOperand(fn string, v float64) (mathOperand, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return mathOperand{}, fmt.Errorf("%s: %v is not a finite number", fn, v)
+	}
+	if v != math.Trunc(v) {
+		return mathOperand{float: v}, nil
+	}
+	if math.Abs(v) > maxExactFloat {
+		return mathOperand{}, fmt.Errorf("%s: %.0f is too large to be exact as a JSON number, pass it as a string", fn, v)
+	}
+	return mathOperand{integer: big.NewInt(int64(v))}, nil
+}
+
+func textOperand(fn string, s string) (mathOperand, error) {
+	s = strings.TrimSpace(s)
+	if n, ok := new(big.Int).SetString(s, 10); ok {
+		return mathOperand{integer: n, text: true}, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return mathOperand{}, fmt.Errorf("%s: %q is not a number", fn, s)
+	}
+	return mathOperand{float: f, text: true}, nil
+}
+
+func (o mathOperand) toFloat() float64 {
+	if o.integer == nil {
+		return o.float
+	}
+	f, _ := new(big.Float).SetInt(o.integer).Float64()
+	return f
+}
+
+// arithmetic applies a math function to two arguments
+// Integers are computed exactly; the result is an int, or a uint64 above the int range, and
+// overflowing both is an error. When either argument is a string the result is a string of
+// arbitrary precision instead, so large IDs can be computed by passing them as strings
+// A float argument switches to floatOp; functions without one accept integers only
+func arithmetic(fn string, a, b interface{}, intOp func(x, y *big.Int) (*big.Int, error), floatOp func(x, y float64) (float64, error)) (interface{}, error) {
+	x, err := toMathOperand(fn, a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toMathOperand(fn, b)
+	if err != nil {
+		return nil, err
+	}
+	text := x.text || y.text
+
+	if x.integer != nil && y.integer != nil {
+		result, err := intOp(x.integer, y.integer)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fn, err)
+		}
+		switch {
+		case text:
+			return result.String(), nil
+		case result.IsInt64() && result.Int64() >= math.MinInt && result.Int64() <= math.MaxInt:
+			return int(result.Int64()), nil
+		case result.IsUint64():
+			return result.Uint64(), nil
+		}
+		return nil, fmt.Errorf("%s: result %s overflows 64-bit integers, pass the arguments as strings for arbitrary precision", fn, result)
+	}
+
+	if floatOp == nil {
+		return nil, fmt.Errorf("%s: arguments must be integers", fn)
+	}
+	result, err := floatOp(x.toFloat(), y.toFloat())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn, err)
+	}
+	if text {
+		return strconv.FormatFloat(result, 'f', -1, 64), nil
+	}
+	return result, nil
+}
+
+var errDivisionByZero = errors.New("division by zero")
+
+// Math render handlers
+func addRenderHandler(a, b interface{}) (interface{}, error) {
+	return arithmetic("add", a, b,
+		func(x, y *big.Int) (*big.Int, error) { return new(big.Int).Add(x, y), nil },
+		func(x, y float64) (float64, error) { return x + y, nil })
+}
+
+func subRenderHandler(a, b interface{}) (interface{}, error) {
+	return arithmetic("sub", a, b,
+		func(x, y *big.Int) (*big.Int, error) { return new(big.Int).Sub(x, y), nil },
+		func(x, y float64) (float64, error) { return x - y, nil })
+}
+
+func mulRenderHandler(a, b interface{}) (interface{}, error) {
+	return arithmetic("mul", a, b,
+		func(x, y *big.Int) (*big.Int, error) { return new(big.Int).Mul(x, y), nil },
+		func(x, y float64) (float64, error) { return x * y, nil })
+}
+
+// divRenderHandler divides integers with truncation, like Go's / operator
+func divRenderHandler(a, b interface{}) (interface{}, error) {
+	return arithmetic("div", a, b,
+		func(x, y *big.Int) (*big.Int, error) {
+			if y.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			return new(big.Int).Quo(x, y), nil
+		},
+		func(x, y float64) (float64, error) {
+			if y == 0 {
+				return 0, errDivisionByZero
+			}
+			return x / y, nil
+		})
+}
+
+// modRenderHandler returns the remainder with the sign of the dividend, like Go's % operator
+func modRenderHandler(a, b interface{}) (interface{}, error) {
+	return arithmetic("mod", a, b,
+		func(x, y *big.Int) (*big.Int, error) {
+			if y.Sign() == 0 {
+				return nil, errDivisionByZero
+			}
+			return new(big.Int).Rem(x, y), nil
+		}, nil)
+}
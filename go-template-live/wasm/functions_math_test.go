@@ -0,0 +1,80 @@
+//go:build !js && confd
+// +build !js,confd
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestConfdMath_Arithmetic tests exact integer arithmetic, overflow detection and string mode
+func TestConfdMath_Arithmetic(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(a, b interface{}) (interface{}, error)
+		a, b    interface{}
+		want    interface{}
+		wantErr string
+	}{
+		{name: "ints", fn: addRenderHandler, a: 5, b: 3, want: 8},
+		{name: "JSON numbers", fn: addRenderHandler, a: 8080.0, b: 1, want: 8081},
+		{name: "int64", fn: mulRenderHandler, a: int64(1) << 40, b: int64(1) << 20, want: 1 << 60},
+		{name: "uint64 range", fn: addRenderHandler, a: uint64(1) << 63, b: 1, want: uint64(1)<<63 + 1},
+		{name: "overflow", fn: mulRenderHandler, a: uint64(1) << 63, b: 4, wantErr: "overflows 64-bit integers"},
+		{name: "inexact JSON number", fn: addRenderHandler, a: 1e17, b: 1, wantErr: "too large to be exact"},
+		{name: "string mode", fn: addRenderHandler, a: "18446744073709551616", b: 1, want: "18446744073709551617"},
+		{name: "string mode multiply", fn: mulRenderHandler, a: "9007199254740993", b: "3", want: "27021597764222979"},
+		{name: "float", fn: mulRenderHandler, a: 1.5, b: 2, want: 3.0},
+		{name: "integer division", fn: divRenderHandler, a: 7, b: 2, want: 3},
+		{name: "division by zero", fn: divRenderHandler, a: 7, b: 0, wantErr: "division by zero"},
+		{name: "mod", fn: modRenderHandler, a: -7, b: 3, want: -1},
+		{name: "mod of floats", fn: modRenderHandler, a: 7.5, b: 2, wantErr: "must be integers"},
+		{name: "not a number", fn: subRenderHandler, a: "abc", b: 1, wantErr: "is not a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn(tt.a, tt.b)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("result = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+// TestConfdMath_Render tests math functions on values decoded from JSON
+func TestConfdMath_Render(t *testing.T) {
+	renderer := createConfdRenderer()
+	values := map[string]interface{}{"Port": 8080.0, "ID": "9223372036854775807"}
+
+	result, err := renderer.Render(`{{add .Port 1}} {{add .ID 1}} {{range seq 1 (add 1 1)}}{{.}}{{end}}`, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "8081 9223372036854775808 12"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+
+	// Integers above 2^53 parsed from JSON values stay exact
+	values, err = ParseValues(`{"id": 9007199254740993, "big": 9223372036854775808}`)
+	if err != nil {
+		t.Fatalf("ParseValues() error = %v", err)
+	}
+	result, err = renderer.Render(`{{add .id 1}} {{add .big 1}}`, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "9007199254740994 9223372036854775809"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+}
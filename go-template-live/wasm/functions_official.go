@@ -11,6 +11,6 @@ package main
 
 // CreateRenderFuncMap creates an empty function map for official mode
 // Only standard Go template functions will be available
-func CreateRenderFuncMap(variables map[string]interface{}) map[string]interface{} {
+func CreateRenderFuncMap(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
 	return map[string]interface{}{}
 }
@@ -145,3 +145,35 @@ func renderTemplateOfficialMode(templateContent string, variables map[string]int
 
 	return result.String(), nil
 }
+
+// TestOfficialExamples_RenderWithSampleValues tests that every embedded official example parses and renders
+func TestOfficialExamples_RenderWithSampleValues(t *testing.T) {
+	helper := NewTestHelper()
+	parserOfficial := helper.NewParserWithOfficialFunctions()
+
+	examples, err := ListExamples(ProfileOfficial)
+	if err != nil {
+		t.Fatalf("ListExamples() error = %v", err)
+	}
+	if len(examples) == 0 {
+		t.Fatal("ListExamples() returned no examples")
+	}
+
+	for _, info := range examples {
+		t.Run(info.Name, func(t *testing.T) {
+			example, err := GetExample(ProfileOfficial, info.Name)
+			if err != nil {
+				t.Fatalf("GetExample() error = %v", err)
+			}
+			if example.Description == "" {
+				t.Errorf("GetExample() description is empty for %s", info.Name)
+			}
+			if _, err := parserOfficial.ExtractVariablesWithDefaults(info.Name, example.Template); err != nil {
+				t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+			}
+			if _, err := renderTemplateOfficialMode(example.Template, example.Values); err != nil {
+				t.Fatalf("renderTemplateOfficialMode() error = %v", err)
+			}
+		})
+	}
+}
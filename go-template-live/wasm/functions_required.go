@@ -0,0 +1,49 @@
+//go:build confd || custom || sprig || gomplate || helm
+// +build confd custom sprig gomplate helm
+
+// This file contains the Helm-style required function shared by the Confd, custom, sprig,
+// gomplate and Helm profiles
+// Tag: confd || custom || sprig || gomplate || helm (registered by registerConfdFunctions,
+// registerCustomFunctions, registerSprigFunctions and registerGomplateFunctions)
+
+package main
+
+import (
+	"errors"
+	"text/template/parse"
+)
+
+// registerRequiredFunction registers required, which fails the render with a message when its
+// value is empty: {{required "db host is required" (getv "/db/host")}} or
+// {{getv "/db/host" | required "db host is required"}}
+// Extraction marks the variables of the value as Required with the message (see applyRequired)
+func registerRequiredFunction(registry *FunctionRegistry) {
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "required",
+		Description:           "Fail rendering with the given message when the value is empty (Helm-style)",
+		Handler:               requiredMinimalHandler,
+		Extractor:             extractRequiredVariables,
+		ExtractorWithDefaults: extractRequiredVariablesInfo,
+	})
+}
+
+func requiredMinimalHandler(message string, value interface{}) (interface{}, error) {
+	return value, nil
+}
+
+// requiredRenderHandler returns value, or an error with message when value is nil or ""
+func requiredRenderHandler(message string, value interface{}) (interface{}, error) {
+	if value == nil || value == "" {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
+
+// The value is the second argument; the message is text, not a variable name
+func extractRequiredVariables(args []parse.Node, cycle int) ([]string, error) {
+	return extractArgVariable(args, cycle, 2, false)
+}
+
+func extractRequiredVariablesInfo(args []parse.Node, cycle int) ([]VariableInfo, error) {
+	return extractArgVariableWithDefaults(args, cycle, 2, -1, false)
+}
@@ -0,0 +1,47 @@
+//go:build confd || custom
+// +build confd custom
+
+// This file contains the secret function shared by the Confd and custom profiles
+// Tag: confd || custom (registered by registerConfdFunctions and registerCustomFunctions)
+
+package main
+
+import (
+	"text/template/parse"
+)
+
+// registerSecretFunction registers secret, which reads a key like getv but marks the variable
+// as Sensitive during extraction: {{secret "/db/password"}}
+// Renders with RenderOptions.MaskedPreview output MaskedValue in place of its value
+func registerSecretFunction(registry *FunctionRegistry) {
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "secret",
+		Description:           "Get sensitive variable value with optional default, masked in previews",
+		Handler:               getvMinimalHandler,
+		Extractor:             extractGetvVariables,
+		ExtractorWithDefaults: extractSecretVariablesWithDefaults,
+	})
+}
+
+// secretRenderHandler returns a non-empty string value of key, or the default
+func secretRenderHandler(store *KeyStore) func(key string, v ...string) string {
+	return func(key string, v ...string) string {
+		if val, exists := store.Get(key); exists {
+			if strVal, ok := val.(string); ok && strVal != "" {
+				return strVal
+			}
+		}
+		if len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+}
+
+func extractSecretVariablesWithDefaults(args []parse.Node, cycle int) ([]VariableInfo, error) {
+	variables, err := extractGetvVariablesWithDefaults(args, cycle)
+	for i := range variables {
+		variables[i].Sensitive = true
+	}
+	return variables, err
+}
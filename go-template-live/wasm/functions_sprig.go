@@ -0,0 +1,1119 @@
+//go:build sprig || helm
+// +build sprig helm
+
+// This file contains the core implementations of the sprig function set used by Helm, chezmoi
+// and many other tools
+// Tag: sprig || helm (works for both js && sprig WASM builds and !js && sprig tests; the Helm
+// profile registers these functions before its own)
+// The functions follow sprig v3; key generation, certificates, semver, DNS and the must*
+// variants of most functions are not provided
+
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// registerSprigFunctions registers the sprig template functions
+// This is called by both WASM (via init in main_sprig.go) and tests
+// Sprig functions compute on their arguments only, so their handlers serve both parsing and
+// rendering, and the fields passed to them are extracted like arguments of builtins
+func registerSprigFunctions() {
+	registry := GetGlobalRegistry()
+	registry.SetProfile(ProfileSprig)
+	registerRequiredFunction(registry)
+
+	// default - the value, or the default when the value is empty
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "default",
+		Description:           "Returns the given value, or the default when it is empty",
+		Handler:               sprigDefault,
+		Extractor:             extractDefaultedVariables,
+		ExtractorWithDefaults: extractDefaultedVariablesInfo,
+		ExtractsPipedValue:    true,
+	})
+
+	// Defaults and flow control
+	registry.RegisterFunction(&FunctionDefinition{Name: "empty", Description: "Reports whether a value is empty", Handler: sprigEmpty})
+	registry.RegisterFunction(&FunctionDefinition{Name: "coalesce", Description: "Returns the first non-empty value", Handler: sprigCoalesce})
+	registry.RegisterFunction(&FunctionDefinition{Name: "all", Description: "Reports whether all values are non-empty", Handler: sprigAll})
+	registry.RegisterFunction(&FunctionDefinition{Name: "any", Description: "Reports whether any value is non-empty", Handler: sprigAny})
+	registry.RegisterFunction(&FunctionDefinition{Name: "ternary", Description: "Returns the first value when the condition is true, else the second", Handler: sprigTernary})
+	registry.RegisterFunction(&FunctionDefinition{Name: "fail", Description: "Fails rendering with a message", Handler: sprigFail})
+
+	// Strings
+	registry.RegisterFunction(&FunctionDefinition{Name: "trim", Description: "Removes leading and trailing white space", Handler: strings.TrimSpace})
+	registry.RegisterFunction(&FunctionDefinition{Name: "trimAll", Description: "Removes the given characters from both ends", Handler: func(cutset, s string) string { return strings.Trim(s, cutset) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "trimPrefix", Description: "Removes a prefix", Handler: func(prefix, s string) string { return strings.TrimPrefix(s, prefix) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "trimSuffix", Description: "Removes a suffix", Handler: func(suffix, s string) string { return strings.TrimSuffix(s, suffix) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "upper", Description: "Converts to upper case", Handler: strings.ToUpper})
+	registry.RegisterFunction(&FunctionDefinition{Name: "lower", Description: "Converts to lower case", Handler: strings.ToLower})
+	registry.RegisterFunction(&FunctionDefinition{Name: "title", Description: "Converts to title case", Handler: sprigTitle})
+	registry.RegisterFunction(&FunctionDefinition{Name: "untitle", Description: "Lower cases the first letter of every word", Handler: sprigUntitle})
+	registry.RegisterFunction(&FunctionDefinition{Name: "repeat", Description: "Repeats a string", Handler: func(count int, s string) string { return strings.Repeat(s, count) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "substr", Description: "Returns the bytes from start to end", Handler: sprigSubstr})
+	registry.RegisterFunction(&FunctionDefinition{Name: "nospace", Description: "Removes all white space", Handler: sprigNospace})
+	registry.RegisterFunction(&FunctionDefinition{Name: "trunc", Description: "Truncates to a length, from the end when negative", Handler: sprigTrunc})
+	registry.RegisterFunction(&FunctionDefinition{Name: "abbrev", Description: "Truncates with an ellipsis", Handler: sprigAbbrev})
+	registry.RegisterFunction(&FunctionDefinition{Name: "initials", Description: "Returns the first letter of every word", Handler: sprigInitials})
+	registry.RegisterFunction(&FunctionDefinition{Name: "wrap", Description: "Wraps text at a column", Handler: func(width int, s string) string { return sprigWrapWith(width, "\n", s) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "wrapWith", Description: "Wraps text at a column with the given separator", Handler: sprigWrapWith})
+	registry.RegisterFunction(&FunctionDefinition{Name: "contains", Description: "Reports whether a string contains another", Handler: func(substr, s string) bool { return strings.Contains(s, substr) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "hasPrefix", Description: "Reports whether a string has a prefix", Handler: func(prefix, s string) bool { return strings.HasPrefix(s, prefix) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "hasSuffix", Description: "Reports whether a string has a suffix", Handler: func(suffix, s string) bool { return strings.HasSuffix(s, suffix) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "quote", Description: "Double quotes each value", Handler: func(values ...interface{}) string { return sprigQuote(values, strconv.Quote) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "squote", Description: "Single quotes each value", Handler: sprigSquote})
+	registry.RegisterFunction(&FunctionDefinition{Name: "cat", Description: "Joins values with spaces", Handler: sprigCat})
+	registry.RegisterFunction(&FunctionDefinition{Name: "indent", Description: "Indents every line", Handler: sprigIndent})
+	registry.RegisterFunction(&FunctionDefinition{Name: "nindent", Description: "Indents every line after a leading newline", Handler: func(spaces int, s string) string { return "\n" + sprigIndent(spaces, s) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "replace", Description: "Replaces every occurrence of a string", Handler: func(old, new, s string) string { return strings.ReplaceAll(s, old, new) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "plural", Description: "Picks the singular or plural form for a count", Handler: sprigPlural})
+	registry.RegisterFunction(&FunctionDefinition{Name: "snakecase", Description: "Converts to snake_case", Handler: snakeCase})
+	registry.RegisterFunction(&FunctionDefinition{Name: "camelcase", Description: "Converts to CamelCase", Handler: sprigCamelcase})
+	registry.RegisterFunction(&FunctionDefinition{Name: "kebabcase", Description: "Converts to kebab-case", Handler: func(s string) string { return strings.ReplaceAll(snakeCase(s), "_", "-") }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "swapcase", Description: "Swaps the case of every letter", Handler: sprigSwapcase})
+	registry.RegisterFunction(&FunctionDefinition{Name: "shuffle", Description: "Shuffles the characters", EnvHandler: func(env *renderEnv) interface{} { return func(s string) string { return sprigShuffle(env, s) } }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toString", Description: "Converts a value to a string", Handler: sprigString})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toStrings", Description: "Converts a list to a list of strings", Handler: sprigStrings})
+	registry.RegisterFunction(&FunctionDefinition{Name: "split", Description: "Splits into a dict with keys _0, _1, ...", Handler: func(sep, s string) map[string]string { return sprigSplitDict(strings.Split(s, sep)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "splitn", Description: "Splits into at most n parts, as a dict", Handler: func(sep string, n int, s string) map[string]string { return sprigSplitDict(strings.SplitN(s, sep, n)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "splitList", Description: "Splits into a list", Handler: func(sep, s string) []string { return strings.Split(s, sep) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "join", Description: "Joins a list with a separator", Handler: func(sep string, list interface{}) string { return strings.Join(sprigStrings(list), sep) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "sortAlpha", Description: "Sorts a list of strings", Handler: sprigSortAlpha})
+	registry.RegisterFunction(&FunctionDefinition{Name: "randAlphaNum", Description: "Random letters and digits", EnvHandler: sprigRandom(sprigAlphaNum)})
+	registry.RegisterFunction(&FunctionDefinition{Name: "randAlpha", Description: "Random letters", EnvHandler: sprigRandom(sprigAlphaNum[10:])})
+	registry.RegisterFunction(&FunctionDefinition{Name: "randNumeric", Description: "Random digits", EnvHandler: sprigRandom(sprigAlphaNum[:10])})
+	registry.RegisterFunction(&FunctionDefinition{Name: "randAscii", Description: "Random printable ASCII characters", EnvHandler: sprigRandom(sprigPrintable)})
+	registry.RegisterFunction(&FunctionDefinition{Name: "uuidv4", Description: "A random UUID", EnvHandler: func(env *renderEnv) interface{} { return func() string { return sprigUUID(env) } }})
+
+	// Regular expressions
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexMatch", Description: "Reports whether a string matches", Handler: func(regex, s string) bool { return sprigRegexp(regex).MatchString(s) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mustRegexMatch", Description: "Reports whether a string matches, failing on an invalid expression", Handler: regexp.MatchString})
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexFind", Description: "Returns the first match", Handler: func(regex, s string) string { return sprigRegexp(regex).FindString(s) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexFindAll", Description: "Returns up to n matches, all when negative", Handler: func(regex, s string, n int) []string { return sprigRegexp(regex).FindAllString(s, n) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexReplaceAll", Description: "Replaces matches, expanding $1 references", Handler: func(regex, s, repl string) string { return sprigRegexp(regex).ReplaceAllString(s, repl) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexReplaceAllLiteral", Description: "Replaces matches literally", Handler: func(regex, s, repl string) string { return sprigRegexp(regex).ReplaceAllLiteralString(s, repl) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexSplit", Description: "Splits around matches", Handler: func(regex, s string, n int) []string { return sprigRegexp(regex).Split(s, n) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "regexQuoteMeta", Description: "Escapes regular expression metacharacters", Handler: regexp.QuoteMeta})
+
+	// Conversion and math
+	registry.RegisterFunction(&FunctionDefinition{Name: "atoi", Description: "Converts a string to an int", Handler: func(s string) int { i, _ := strconv.Atoi(s); return i }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "int", Description: "Converts a value to an int", Handler: func(v interface{}) int { return int(sprigInt64(v)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "int64", Description: "Converts a value to an int64", Handler: sprigInt64})
+	registry.RegisterFunction(&FunctionDefinition{Name: "float64", Description: "Converts a value to a float64", Handler: sprigFloat64})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toDecimal", Description: "Converts an octal string to an int64", Handler: sprigToDecimal})
+	registry.RegisterFunction(&FunctionDefinition{Name: "add", Description: "Adds integers", Handler: sprigAdd})
+	registry.RegisterFunction(&FunctionDefinition{Name: "add1", Description: "Adds one", Handler: func(v interface{}) int64 { return sprigInt64(v) + 1 }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "sub", Description: "Subtracts integers", Handler: func(a, b interface{}) int64 { return sprigInt64(a) - sprigInt64(b) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mul", Description: "Multiplies integers", Handler: sprigMul})
+	registry.RegisterFunction(&FunctionDefinition{Name: "div", Description: "Divides integers", Handler: sprigDiv})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mod", Description: "Integer remainder", Handler: sprigMod})
+	registry.RegisterFunction(&FunctionDefinition{Name: "max", Description: "Largest integer", Handler: sprigMax})
+	registry.RegisterFunction(&FunctionDefinition{Name: "biggest", Description: "Largest integer", Handler: sprigMax})
+	registry.RegisterFunction(&FunctionDefinition{Name: "min", Description: "Smallest integer", Handler: sprigMin})
+	registry.RegisterFunction(&FunctionDefinition{Name: "addf", Description: "Adds numbers", Handler: func(values ...interface{}) float64 { return sprigFold(values, sprigAddf) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "subf", Description: "Subtracts numbers", Handler: func(values ...interface{}) float64 { return sprigFold(values, sprigSubf) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mulf", Description: "Multiplies numbers", Handler: func(values ...interface{}) float64 { return sprigFold(values, sprigMulf) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "divf", Description: "Divides numbers", Handler: func(values ...interface{}) float64 { return sprigFold(values, sprigDivf) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "maxf", Description: "Largest number", Handler: func(values ...interface{}) float64 { return sprigFold(values, math.Max) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "minf", Description: "Smallest number", Handler: func(values ...interface{}) float64 { return sprigFold(values, math.Min) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "floor", Description: "Rounds down", Handler: func(v interface{}) float64 { return math.Floor(sprigFloat64(v)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "ceil", Description: "Rounds up", Handler: func(v interface{}) float64 { return math.Ceil(sprigFloat64(v)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "round", Description: "Rounds to a number of decimals", Handler: sprigRound})
+	registry.RegisterFunction(&FunctionDefinition{Name: "seq", Description: "A sequence of integers as text, like Unix seq", Handler: sprigSeq})
+	registry.RegisterFunction(&FunctionDefinition{Name: "until", Description: "Integers from 0 up to a count", Handler: func(count int) []int { return sprigUntilStep(0, count, 1) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "untilStep", Description: "Integers from start up to stop by step", Handler: sprigUntilStep})
+
+	// Lists
+	registry.RegisterFunction(&FunctionDefinition{Name: "list", Description: "Builds a list", Handler: func(values ...interface{}) []interface{} { return values }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "first", Description: "First element", Handler: sprigFirst})
+	registry.RegisterFunction(&FunctionDefinition{Name: "last", Description: "Last element", Handler: sprigLast})
+	registry.RegisterFunction(&FunctionDefinition{Name: "rest", Description: "All elements but the first", Handler: sprigRest})
+	registry.RegisterFunction(&FunctionDefinition{Name: "initial", Description: "All elements but the last", Handler: sprigInitial})
+	registry.RegisterFunction(&FunctionDefinition{Name: "append", Description: "Appends an element", Handler: sprigAppend})
+	registry.RegisterFunction(&FunctionDefinition{Name: "push", Description: "Appends an element", Handler: sprigAppend})
+	registry.RegisterFunction(&FunctionDefinition{Name: "prepend", Description: "Prepends an element", Handler: sprigPrepend})
+	registry.RegisterFunction(&FunctionDefinition{Name: "concat", Description: "Concatenates lists", Handler: sprigConcat})
+	registry.RegisterFunction(&FunctionDefinition{Name: "reverse", Description: "Reverses a list", Handler: sprigReverse})
+	registry.RegisterFunction(&FunctionDefinition{Name: "uniq", Description: "Removes duplicate elements", Handler: sprigUniq})
+	registry.RegisterFunction(&FunctionDefinition{Name: "without", Description: "Removes the given elements", Handler: sprigWithout})
+	registry.RegisterFunction(&FunctionDefinition{Name: "has", Description: "Reports whether a list holds an element", Handler: sprigHas})
+	registry.RegisterFunction(&FunctionDefinition{Name: "compact", Description: "Removes empty elements", Handler: sprigCompact})
+	registry.RegisterFunction(&FunctionDefinition{Name: "slice", Description: "Elements from start to end", Handler: sprigSlice})
+	registry.RegisterFunction(&FunctionDefinition{Name: "chunk", Description: "Splits a list into chunks of a size", Handler: sprigChunk})
+
+	// Dicts
+	registry.RegisterFunction(&FunctionDefinition{Name: "dict", Description: "Builds a dict from key and value pairs", Handler: sprigDict})
+	registry.RegisterFunction(&FunctionDefinition{Name: "get", Description: "Value of a key, or an empty string", Handler: sprigGet})
+	registry.RegisterFunction(&FunctionDefinition{Name: "set", Description: "Sets a key and returns the dict", Handler: func(d map[string]interface{}, key string, value interface{}) map[string]interface{} {
+		d[key] = value
+		return d
+	}})
+	registry.RegisterFunction(&FunctionDefinition{Name: "unset", Description: "Removes a key and returns the dict", Handler: func(d map[string]interface{}, key string) map[string]interface{} { delete(d, key); return d }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "hasKey", Description: "Reports whether a dict has a key", Handler: func(d map[string]interface{}, key string) bool { _, ok := d[key]; return ok }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "pluck", Description: "Values of a key across dicts", Handler: sprigPluck})
+	registry.RegisterFunction(&FunctionDefinition{Name: "keys", Description: "Sorted keys of dicts", Handler: sprigKeys})
+	registry.RegisterFunction(&FunctionDefinition{Name: "values", Description: "Values of a dict, in key order", Handler: sprigValues})
+	registry.RegisterFunction(&FunctionDefinition{Name: "pick", Description: "Dict with only the given keys", Handler: func(d map[string]interface{}, keys ...string) map[string]interface{} { return sprigPick(d, keys, true) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "omit", Description: "Dict without the given keys", Handler: func(d map[string]interface{}, keys ...string) map[string]interface{} {
+		return sprigPick(d, keys, false)
+	}})
+	registry.RegisterFunction(&FunctionDefinition{Name: "merge", Description: "Deep merges dicts, keeping the values of the first", Handler: func(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+		return sprigMerge(dst, srcs, false)
+	}})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mergeOverwrite", Description: "Deep merges dicts, later values winning", Handler: func(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+		return sprigMerge(dst, srcs, true)
+	}})
+	registry.RegisterFunction(&FunctionDefinition{Name: "deepCopy", Description: "Deep copies dicts and lists", Handler: sprigDeepCopy})
+	registry.RegisterFunction(&FunctionDefinition{Name: "dig", Description: "Value at a key path, or a default", Handler: sprigDig})
+
+	// Encoding and hashing
+	registry.RegisterFunction(&FunctionDefinition{Name: "b64enc", Description: "Base64 encodes", Handler: func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "b64dec", Description: "Base64 decodes", Handler: func(s string) string { return sprigDecoded(base64.StdEncoding.DecodeString(s)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "b32enc", Description: "Base32 encodes", Handler: func(s string) string { return base32.StdEncoding.EncodeToString([]byte(s)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "b32dec", Description: "Base32 decodes", Handler: func(s string) string { return sprigDecoded(base32.StdEncoding.DecodeString(s)) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "sha1sum", Description: "Hex SHA-1 digest", Handler: func(s string) string { sum := sha1.Sum([]byte(s)); return hex.EncodeToString(sum[:]) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "sha256sum", Description: "Hex SHA-256 digest", Handler: func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "adler32sum", Description: "Adler-32 checksum", Handler: func(s string) string { return strconv.FormatUint(uint64(adler32.Checksum([]byte(s))), 10) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toJson", Description: "Encodes as JSON", Handler: func(v interface{}) string { s, _ := sprigJSON(v, "", true); return s }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mustToJson", Description: "Encodes as JSON, failing on values that cannot be", Handler: func(v interface{}) (string, error) { return sprigJSON(v, "", true) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toPrettyJson", Description: "Encodes as indented JSON", Handler: func(v interface{}) string { s, _ := sprigJSON(v, "  ", true); return s }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toRawJson", Description: "Encodes as JSON without HTML escaping", Handler: func(v interface{}) string { s, _ := sprigJSON(v, "", false); return s }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "fromJson", Description: "Decodes JSON", Handler: func(s string) interface{} { v, _ := sprigFromJSON(s); return v }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "mustFromJson", Description: "Decodes JSON, failing on invalid input", Handler: sprigFromJSON})
+
+	// Reflection
+	registry.RegisterFunction(&FunctionDefinition{Name: "typeOf", Description: "Go type of a value", Handler: func(v interface{}) string { return fmt.Sprintf("%T", v) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "typeIs", Description: "Reports whether a value has a Go type", Handler: func(target string, v interface{}) bool { return target == fmt.Sprintf("%T", v) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "typeIsLike", Description: "Reports whether a value or what it points to has a Go type", Handler: sprigTypeIsLike})
+	registry.RegisterFunction(&FunctionDefinition{Name: "kindOf", Description: "Kind of a value", Handler: sprigKindOf})
+	registry.RegisterFunction(&FunctionDefinition{Name: "kindIs", Description: "Reports whether a value is of a kind", Handler: func(target string, v interface{}) bool { return target == sprigKindOf(v) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "deepEqual", Description: "Reports whether two values are deeply equal", Handler: reflect.DeepEqual})
+
+	// Paths
+	registry.RegisterFunction(&FunctionDefinition{Name: "base", Description: "Last element of a path", Handler: path.Base})
+	registry.RegisterFunction(&FunctionDefinition{Name: "dir", Description: "Directory of a path", Handler: path.Dir})
+	registry.RegisterFunction(&FunctionDefinition{Name: "clean", Description: "Cleans a path", Handler: path.Clean})
+	registry.RegisterFunction(&FunctionDefinition{Name: "ext", Description: "Extension of a path", Handler: path.Ext})
+	registry.RegisterFunction(&FunctionDefinition{Name: "isAbs", Description: "Reports whether a path is absolute", Handler: path.IsAbs})
+
+	// Dates
+	registry.RegisterFunction(&FunctionDefinition{Name: "now", Description: "Current time", EnvHandler: func(env *renderEnv) interface{} { return env.currentTime }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "date", Description: "Formats a date in the render time zone", EnvHandler: func(env *renderEnv) interface{} {
+		return func(layout string, date interface{}) string { return sprigDateInZone(env, layout, date, "Local") }
+	}})
+	registry.RegisterFunction(&FunctionDefinition{Name: "dateInZone", Description: "Formats a date in a time zone", EnvHandler: func(env *renderEnv) interface{} {
+		return func(layout string, date interface{}, zone string) string {
+			return sprigDateInZone(env, layout, date, zone)
+		}
+	}})
+	registry.RegisterFunction(&FunctionDefinition{Name: "htmlDate", Description: "Formats a date as yyyy-mm-dd", EnvHandler: func(env *renderEnv) interface{} {
+		return func(date interface{}) string { return sprigDateInZone(env, "2006-01-02", date, "Local") }
+	}})
+	registry.RegisterFunction(&FunctionDefinition{Name: "unixEpoch", Description: "Unix time of a date", Handler: func(date time.Time) string { return strconv.FormatInt(date.Unix(), 10) }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "dateModify", Description: "Adds a duration such as -1.5h to a date", Handler: sprigDateModify})
+	registry.RegisterFunction(&FunctionDefinition{Name: "ago", Description: "Time elapsed since a date", EnvHandler: func(env *renderEnv) interface{} { return func(date interface{}) string { return sprigAgo(env, date) } }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "toDate", Description: "Parses a date with a layout", Handler: func(layout, s string) time.Time { t, _ := time.ParseInLocation(layout, s, time.Local); return t }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "duration", Description: "Formats a number of seconds as a duration", Handler: func(seconds interface{}) string { return (time.Duration(sprigInt64(seconds)) * time.Second).String() }})
+
+	// Environment
+	registry.RegisterFunction(&FunctionDefinition{Name: "env", Description: "Value of an environment variable", Handler: os.Getenv})
+	registry.RegisterFunction(&FunctionDefinition{Name: "expandenv", Description: "Substitutes $VAR references from the environment", Handler: os.ExpandEnv})
+}
+
+// GetSprigRenderFuncMap returns the render implementations of the sprig profile that differ
+// from the registered handlers
+func GetSprigRenderFuncMap(variables map[string]interface{}, env *renderEnv) template.FuncMap {
+	return template.FuncMap{
+		"required": requiredRenderHandler,
+	}
+}
+
+// Characters of the random string functions
+const (
+	sprigAlphaNum  = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	sprigPrintable = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+)
+
+// sprigString converts a value to a string like sprig's toString
+func sprigString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// sprigStrings converts a list to strings, skipping nil elements; other values become a one
+// element list
+func sprigStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case nil:
+		return []string{}
+	case []string:
+		return v
+	}
+	list, err := sprigList(v)
+	if err != nil {
+		return []string{sprigString(v)}
+	}
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if item != nil {
+			result = append(result, sprigString(item))
+		}
+	}
+	return result
+}
+
+// sprigInt64 converts numbers, numeric strings and bools to an int64, and anything else to 0
+// json.Number values, integers beyond the int range, are read from their text like strings
+func sprigInt64(v interface{}) int64 {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return int64(value.Float())
+	case reflect.Bool:
+		if value.Bool() {
+			return 1
+		}
+		return 0
+	case reflect.String:
+		if i, err := strconv.ParseInt(value.String(), 0, 64); err == nil {
+			return i
+		}
+		f, _ := strconv.ParseFloat(value.String(), 64)
+		return int64(f)
+	}
+	return 0
+}
+
+// sprigFloat64 converts numbers (json.Number included), numeric strings and bools to a
+// float64, and anything else to 0
+func sprigFloat64(v interface{}) float64 {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	case reflect.String:
+		f, _ := strconv.ParseFloat(value.String(), 64)
+		return f
+	}
+	return float64(sprigInt64(v))
+}
+
+// sprigList converts a slice or array to a list
+func sprigList(v interface{}) ([]interface{}, error) {
+	if list, ok := v.([]interface{}); ok {
+		return list, nil
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("cannot use type %T as a list", v)
+	}
+	list := make([]interface{}, value.Len())
+	for i := range list {
+		list[i] = value.Index(i).Interface()
+	}
+	return list, nil
+}
+
+// sprigEmpty reports whether a value is empty: nil, false, zero, or an empty string, list or dict
+func sprigEmpty(v interface{}) bool {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Complex64, reflect.Complex128:
+		return value.Complex() == 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return value.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return reflect.DeepEqual(v, reflect.Zero(value.Type()).Interface())
+}
+
+func sprigDefault(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || sprigEmpty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+func sprigCoalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !sprigEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func sprigAll(values ...interface{}) bool {
+	for _, v := range values {
+		if sprigEmpty(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func sprigAny(values ...interface{}) bool {
+	for _, v := range values {
+		if !sprigEmpty(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sprigTernary(whenTrue, whenFalse interface{}, condition bool) interface{} {
+	if condition {
+		return whenTrue
+	}
+	return whenFalse
+}
+
+func sprigFail(message string) (string, error) {
+	return "", errors.New(message)
+}
+
+// sprigTitle upper cases the first letter of every word, leaving the others as they are
+func sprigTitle(s string) string {
+	return cases.Title(language.Und, cases.NoLower).String(s)
+}
+
+func sprigUntitle(s string) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) {
+			runes[i] = unicode.ToLower(r)
+		}
+	}
+	return string(runes)
+}
+
+func sprigSubstr(start, end int, s string) string {
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 || end > len(s) {
+		end = len(s)
+	}
+	if start > end {
+		return ""
+	}
+	return s[start:end]
+}
+
+func sprigNospace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func sprigTrunc(length int, s string) string {
+	if length < 0 && len(s)+length > 0 {
+		return s[len(s)+length:]
+	}
+	if length >= 0 && len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+func sprigAbbrev(width int, s string) string {
+	if width < 4 || len(s) <= width {
+		return s
+	}
+	return s[:width-3] + "..."
+}
+
+func sprigInitials(s string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(s) {
+		r, _ := utf8.DecodeRuneInString(word)
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sprigWrapWith breaks lines at the last space before the width, with sep as the line break
+func sprigWrapWith(width int, sep, s string) string {
+	if width < 1 {
+		width = 1
+	}
+	var b strings.Builder
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		column := 0
+		for j, word := range strings.Fields(line) {
+			if j > 0 && column+1+len(word) > width {
+				b.WriteString(sep)
+				column = 0
+			} else if j > 0 {
+				b.WriteByte(' ')
+				column++
+			}
+			b.WriteString(word)
+			column += len(word)
+		}
+	}
+	return b.String()
+}
+
+func sprigQuote(values []interface{}, quote func(string) string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			quoted = append(quoted, quote(sprigString(v)))
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func sprigSquote(values ...interface{}) string {
+	return sprigQuote(values, func(s string) string { return "'" + s + "'" })
+}
+
+func sprigCat(values ...interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			parts = append(parts, sprigString(v))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func sprigIndent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func sprigPlural(one, many string, count int) string {
+	if count == 1 {
+		return one
+	}
+	return many
+}
+
+// sprigCamelcase converts snake_case, kebab-case and spaced words to CamelCase
+func sprigCamelcase(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' || r == ' ' }) {
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	return b.String()
+}
+
+func sprigSwapcase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+func sprigShuffle(env *renderEnv, s string) string {
+	runes := []rune(s)
+	random := env.randomSource()
+	random.Shuffle(len(runes), func(i, j int) { runes[i], runes[j] = runes[j], runes[i] })
+	return string(runes)
+}
+
+func sprigSplitDict(parts []string) map[string]string {
+	dict := make(map[string]string, len(parts))
+	for i, part := range parts {
+		dict["_"+strconv.Itoa(i)] = part
+	}
+	return dict
+}
+
+func sprigSortAlpha(list interface{}) []string {
+	sorted := append([]string{}, sprigStrings(list)...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sprigRandom builds a function returning n characters of alphabet drawn from the random
+// source of the render
+func sprigRandom(alphabet string) func(env *renderEnv) interface{} {
+	return func(env *renderEnv) interface{} {
+		return func(n int) string {
+			random := env.randomSource()
+			b := make([]byte, max(n, 0))
+			for i := range b {
+				b[i] = alphabet[random.Intn(len(alphabet))]
+			}
+			return string(b)
+		}
+	}
+}
+
+func sprigUUID(env *renderEnv) string {
+	random := env.randomSource()
+	var b [16]byte
+	for i := range b {
+		b[i] = byte(random.Intn(256))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// sprigRegexp compiles an expression, matching nothing when it is invalid
+func sprigRegexp(regex string) *regexp.Regexp {
+	compiled, err := regexp.Compile(regex)
+	if err != nil {
+		return regexp.MustCompile(`[^\s\S]`)
+	}
+	return compiled
+}
+
+func sprigToDecimal(v interface{}) int64 {
+	i, _ := strconv.ParseInt(sprigString(v), 8, 64)
+	return i
+}
+
+func sprigAdd(values ...interface{}) int64 {
+	var sum int64
+	for _, v := range values {
+		sum += sprigInt64(v)
+	}
+	return sum
+}
+
+func sprigMul(a interface{}, values ...interface{}) int64 {
+	product := sprigInt64(a)
+	for _, v := range values {
+		product *= sprigInt64(v)
+	}
+	return product
+}
+
+func sprigDiv(a, b interface{}) (int64, error) {
+	divisor := sprigInt64(b)
+	if divisor == 0 {
+		return 0, errors.New("div: division by zero")
+	}
+	return sprigInt64(a) / divisor, nil
+}
+
+func sprigMod(a, b interface{}) (int64, error) {
+	divisor := sprigInt64(b)
+	if divisor == 0 {
+		return 0, errors.New("mod: division by zero")
+	}
+	return sprigInt64(a) % divisor, nil
+}
+
+func sprigMax(a interface{}, values ...interface{}) int64 {
+	result := sprigInt64(a)
+	for _, v := range values {
+		result = max(result, sprigInt64(v))
+	}
+	return result
+}
+
+func sprigMin(a interface{}, values ...interface{}) int64 {
+	result := sprigInt64(a)
+	for _, v := range values {
+		result = min(result, sprigInt64(v))
+	}
+	return result
+}
+
+// sprigFold combines the values as floats from left to right
+func sprigFold(values []interface{}, op func(a, b float64) float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	result := sprigFloat64(values[0])
+	for _, v := range values[1:] {
+		result = op(result, sprigFloat64(v))
+	}
+	return result
+}
+
+func sprigAddf(a, b float64) float64 { return a + b }
+func sprigSubf(a, b float64) float64 { return a - b }
+func sprigMulf(a, b float64) float64 { return a * b }
+func sprigDivf(a, b float64) float64 { return a / b }
+
+// sprigRound rounds half away from zero, or up from the optional threshold, to precision decimals
+func sprigRound(v interface{}, precision int, threshold ...float64) float64 {
+	roundOn := 0.5
+	if len(threshold) > 0 {
+		roundOn = threshold[0]
+	}
+	pow := math.Pow(10, float64(precision))
+	digit := pow * sprigFloat64(v)
+	_, fraction := math.Modf(digit)
+	if math.Abs(fraction) >= roundOn {
+		return math.Copysign(math.Ceil(math.Abs(digit)), digit) / pow
+	}
+	return math.Copysign(math.Floor(math.Abs(digit)), digit) / pow
+}
+
+// sprigSeq prints integers like Unix seq: seq LAST, seq FIRST LAST or seq FIRST STEP LAST
+func sprigSeq(params ...int) string {
+	first, step, last := 1, 1, 0
+	switch len(params) {
+	case 1:
+		last = params[0]
+	case 2:
+		first, last = params[0], params[1]
+	case 3:
+		first, step, last = params[0], params[1], params[2]
+	default:
+		return ""
+	}
+	if len(params) < 3 && last < first {
+		step = -1
+	}
+	numbers := sprigUntilStep(first, last+sign(step), step)
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, " ")
+}
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	}
+	return 1
+}
+
+func sprigUntilStep(start, stop, step int) []int {
+	result := []int{}
+	if step == 0 {
+		return result
+	}
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		result = append(result, i)
+	}
+	return result
+}
+
+func sprigFirst(list interface{}) (interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+func sprigLast(list interface{}) (interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[len(items)-1], nil
+}
+
+func sprigRest(list interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return append([]interface{}{}, items[1:]...), nil
+}
+
+func sprigInitial(list interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return append([]interface{}{}, items[:len(items)-1]...), nil
+}
+
+func sprigAppend(list interface{}, v interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]interface{}{}, items...), v), nil
+}
+
+func sprigPrepend(list interface{}, v interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	return append([]interface{}{v}, items...), nil
+}
+
+func sprigConcat(lists ...interface{}) ([]interface{}, error) {
+	result := []interface{}{}
+	for _, list := range lists {
+		items, err := sprigList(list)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+	}
+	return result, nil
+}
+
+func sprigReverse(list interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[len(items)-1-i] = item
+	}
+	return result, nil
+}
+
+func sprigUniq(list interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for _, item := range items {
+		if !sprigContains(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func sprigWithout(list interface{}, omit ...interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for _, item := range items {
+		if !sprigContains(omit, item) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func sprigHas(needle interface{}, haystack interface{}) (bool, error) {
+	if haystack == nil {
+		return false, nil
+	}
+	items, err := sprigList(haystack)
+	if err != nil {
+		return false, err
+	}
+	return sprigContains(items, needle), nil
+}
+
+func sprigContains(items []interface{}, v interface{}) bool {
+	for _, item := range items {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sprigCompact(list interface{}) ([]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	result := []interface{}{}
+	for _, item := range items {
+		if !sprigEmpty(item) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// sprigSlice returns list[start:end]; both indices are optional
+func sprigSlice(list interface{}, indices ...interface{}) (interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	start, end := 0, len(items)
+	if len(indices) > 0 {
+		start = int(sprigInt64(indices[0]))
+	}
+	if len(indices) > 1 {
+		end = int(sprigInt64(indices[1]))
+	}
+	if start < 0 || end > len(items) || start > end {
+		return nil, fmt.Errorf("slice: indices [%d:%d] out of range for %d elements", start, end, len(items))
+	}
+	return items[start:end], nil
+}
+
+func sprigChunk(size int, list interface{}) ([][]interface{}, error) {
+	items, err := sprigList(list)
+	if err != nil {
+		return nil, err
+	}
+	if size < 1 {
+		return nil, errors.New("chunk: size must be positive")
+	}
+	chunks := [][]interface{}{}
+	for start := 0; start < len(items); start += size {
+		chunks = append(chunks, items[start:min(start+size, len(items))])
+	}
+	return chunks, nil
+}
+
+// sprigDict builds a dict from key and value pairs; a missing last value is ""
+func sprigDict(pairs ...interface{}) map[string]interface{} {
+	dict := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key := sprigString(pairs[i])
+		if i+1 < len(pairs) {
+			dict[key] = pairs[i+1]
+		} else {
+			dict[key] = ""
+		}
+	}
+	return dict
+}
+
+func sprigGet(d map[string]interface{}, key string) interface{} {
+	if value, ok := d[key]; ok {
+		return value
+	}
+	return ""
+}
+
+func sprigPluck(key string, dicts ...map[string]interface{}) []interface{} {
+	result := []interface{}{}
+	for _, d := range dicts {
+		if value, ok := d[key]; ok {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// sprigKeys returns the keys of the dicts in sorted order, so output is stable across renders
+func sprigKeys(dicts ...map[string]interface{}) []string {
+	keys := []string{}
+	for _, d := range dicts {
+		for key := range d {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sprigValues(d map[string]interface{}) []interface{} {
+	values := make([]interface{}, 0, len(d))
+	for _, key := range sprigKeys(d) {
+		values = append(values, d[key])
+	}
+	return values
+}
+
+// sprigPick keeps the listed keys of d, or all the others when keep is false
+func sprigPick(d map[string]interface{}, keys []string, keep bool) map[string]interface{} {
+	listed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		listed[key] = true
+	}
+	result := make(map[string]interface{})
+	for key, value := range d {
+		if listed[key] == keep {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// sprigMerge deep merges srcs into dst; existing values are kept unless overwrite is set
+func sprigMerge(dst map[string]interface{}, srcs []map[string]interface{}, overwrite bool) map[string]interface{} {
+	for _, src := range srcs {
+		for key, value := range src {
+			existing, ok := dst[key]
+			existingDict, existingIsDict := existing.(map[string]interface{})
+			valueDict, valueIsDict := value.(map[string]interface{})
+			switch {
+			case existingIsDict && valueIsDict:
+				dst[key] = sprigMerge(existingDict, []map[string]interface{}{valueDict}, overwrite)
+			case !ok || overwrite || sprigEmpty(existing):
+				dst[key] = value
+			}
+		}
+	}
+	return dst
+}
+
+func sprigDeepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			copied[key] = sprigDeepCopy(value)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, value := range v {
+			copied[i] = sprigDeepCopy(value)
+		}
+		return copied
+	}
+	return v
+}
+
+// sprigDig follows keys through nested dicts: dig "a" "b" "default" $dict
+func sprigDig(args ...interface{}) (interface{}, error) {
+	if len(args) < 3 {
+		return nil, errors.New("dig: expected at least one key, a default and a dict")
+	}
+	current, ok := args[len(args)-1].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dig: cannot read keys of type %T", args[len(args)-1])
+	}
+	fallback := args[len(args)-2]
+	keys := args[:len(args)-2]
+	for i, key := range keys {
+		value, ok := current[sprigString(key)]
+		if !ok {
+			return fallback, nil
+		}
+		if i == len(keys)-1 {
+			return value, nil
+		}
+		if current, ok = value.(map[string]interface{}); !ok {
+			return fallback, nil
+		}
+	}
+	return fallback, nil
+}
+
+// sprigDecoded returns decoded bytes as a string, or the error text like sprig does
+func sprigDecoded(data []byte, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+func sprigJSON(v interface{}, indent string, escapeHTML bool) (string, error) {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(escapeHTML)
+	encoder.SetIndent("", indent)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func sprigFromJSON(s string) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+func sprigTypeIsLike(target string, v interface{}) bool {
+	t := fmt.Sprintf("%T", v)
+	return target == t || "*"+target == t
+}
+
+func sprigKindOf(v interface{}) string {
+	if v == nil {
+		return "invalid"
+	}
+	return reflect.ValueOf(v).Kind().String()
+}
+
+// sprigDate converts a time.Time, *time.Time or Unix seconds to a time, the current time of the
+// render for other values
+func sprigDate(env *renderEnv, date interface{}) time.Time {
+	switch date := date.(type) {
+	case time.Time:
+		return date
+	case *time.Time:
+		return *date
+	case int, int32, int64, float64, json.Number, string:
+		return time.Unix(sprigInt64(date), 0)
+	}
+	return env.currentTime()
+}
+
+func sprigDateInZone(env *renderEnv, layout string, date interface{}, zone string) string {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		location = time.UTC
+	}
+	return sprigDate(env, date).In(location).Format(layout)
+}
+
+func sprigDateModify(modification string, date time.Time) time.Time {
+	d, err := time.ParseDuration(modification)
+	if err != nil {
+		return date
+	}
+	return date.Add(d)
+}
+
+func sprigAgo(env *renderEnv, date interface{}) string {
+	return env.currentTime().Sub(sprigDate(env, date)).Round(time.Second).String()
+}
@@ -0,0 +1,123 @@
+//go:build !js && sprig
+// +build !js,sprig
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// createSprigParser creates a parser with the sprig functions registered
+func createSprigParser() *Parser {
+	registerSprigFunctions()
+	return NewParser(GetGlobalRegistry())
+}
+
+// createSprigRenderer creates a renderer backed by the sprig render function map
+func createSprigRenderer() *Renderer {
+	registerSprigFunctions()
+	return NewRenderer(GetGlobalRegistry(), func(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+		result := make(map[string]interface{})
+		for name, fn := range GetSprigRenderFuncMap(variables, env) {
+			result[name] = fn
+		}
+		return result
+	})
+}
+
+// TestSprigFunctions_VariableExtraction tests that fields passed to sprig functions are extracted,
+// with literal defaults recorded for default
+func TestSprigFunctions_VariableExtraction(t *testing.T) {
+	parser := createSprigParser()
+
+	tests := []struct {
+		name     string
+		template string
+		expected []VariableInfo
+	}{
+		{
+			name:     "piped default",
+			template: `{{.Port | default 8080}}`,
+			expected: []VariableInfo{{Name: "Port", DefaultValue: "8080"}},
+		},
+		{
+			name:     "default call",
+			template: `{{default "web" .Name}}`,
+			expected: []VariableInfo{{Name: "Name", DefaultValue: "web"}},
+		},
+		{
+			name:     "field default",
+			template: `{{default .Fallback .Name}}`,
+			expected: []VariableInfo{{Name: "Name"}, {Name: "Fallback"}},
+		},
+		{
+			name:     "string functions",
+			template: `{{.Title | trunc 10 | upper}} {{indent 2 .Body}}`,
+			expected: []VariableInfo{{Name: "Title"}, {Name: "Body"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars, err := parser.ExtractVariablesWithDefaults("test.tmpl", tt.template)
+			if err != nil {
+				t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+			}
+			if !reflect.DeepEqual(vars, tt.expected) {
+				t.Errorf("ExtractVariablesWithDefaults() = %+v, want %+v", vars, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSprigFunctions_Render tests rendering with sprig functions
+func TestSprigFunctions_Render(t *testing.T) {
+	renderer := createSprigRenderer()
+	renderer.Environment().SetRenderClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli())
+
+	tests := []struct {
+		name     string
+		template string
+		values   map[string]interface{}
+		expected string
+	}{
+		{"default empty", `{{.Port | default 8080}}`, map[string]interface{}{"Port": ""}, "8080"},
+		{"default set", `{{.Port | default 8080}}`, map[string]interface{}{"Port": 9090}, "9090"},
+		{"strings", `{{"  hi  " | trim | upper | quote}} {{title "hello world"}} {{trimSuffix ".txt" "a.txt"}}`, nil, `"HI" Hello World a`},
+		{"nindent", `a:{{"b: 1\nc: 2" | nindent 2}}`, nil, "a:\n  b: 1\n  c: 2"},
+		{"lists", `{{list 3 1 2 | sortAlpha | join ","}} {{list 1 2 2 | uniq | len}} {{last (list 1 2 3)}}`, nil, "1,2,3 2 3"},
+		{"dicts", `{{$d := dict "b" 2 "a" 1}}{{keys $d | join ","}} {{get $d "b"}} {{hasKey $d "c"}}`, nil, "a,b 2 false"},
+		{"math", `{{add 1 2 3}} {{div 7 2}} {{max 4 9 2}} {{round 2.345 2}}`, nil, "6 3 9 2.35"},
+		{"ternary", `{{ternary "on" "off" .Enabled}}`, map[string]interface{}{"Enabled": true}, "on"},
+		{"encoding", `{{b64enc "hello"}} {{sha256sum "a" | trunc 8}}`, nil, "aGVsbG8= ca978112"},
+		{"json", `{{toJson (dict "a" (list 1 "x"))}}`, nil, `{"a":[1,"x"]}`},
+		{"regex", `{{regexReplaceAll "(\\d+)" "v12" "<$1>"}}`, nil, "v<12>"},
+		{"date", `{{now | date "2006-01-02"}}`, nil, "2024-03-01"},
+		{"seq", `{{seq 3}}|{{until 3}}`, nil, "1 2 3|[0 1 2]"},
+		{"json numbers", `{{int64 .Big}} {{float64 .Big}}`, map[string]interface{}{"Big": json.Number("9223372036854775807")}, "9223372036854775807 9.223372036854776e+18"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderer.Render(tt.template, tt.values, RenderOptions{})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if result.Output != tt.expected {
+				t.Errorf("Render() = %q, want %q", result.Output, tt.expected)
+			}
+		})
+	}
+
+	if _, err := renderer.Render(`{{div 1 0}}`, nil, RenderOptions{}); err == nil {
+		t.Errorf("Render() of a division by zero succeeded")
+	}
+}
+
+// TestSprigExamples_RenderWithSampleValues tests that every embedded sprig example renders
+func TestSprigExamples_RenderWithSampleValues(t *testing.T) {
+	testProfileExamples(t, ProfileSprig, createSprigParser(), createSprigRenderer())
+}
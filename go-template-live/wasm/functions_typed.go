@@ -0,0 +1,170 @@
+//go:build confd || custom
+// +build confd custom
+
+// This file contains the typed getv variants shared by the Confd and custom profiles
+// Tag: confd || custom (registered by registerConfdFunctions and registerCustomFunctions)
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// registerTypedGetvFunctions registers getvInt, getvBool, getvFloat and getvJSON
+// They read a key like getv but convert its value, failing the render on a missing key
+// without a default or on a value that does not convert, instead of emitting an empty string
+func registerTypedGetvFunctions(registry *FunctionRegistry) {
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "getvInt",
+		Description:           "Get variable as an integer with optional default, errors on non-integer values",
+		Handler:               getvIntMinimalHandler,
+		Extractor:             extractGetvVariables,
+		ExtractorWithDefaults: extractGetvVariablesWithDefaults,
+		ArgTypeHint:           TypeNumber,
+	})
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "getvBool",
+		Description:           "Get variable as a boolean with optional default, errors on non-boolean values",
+		Handler:               getvBoolMinimalHandler,
+		Extractor:             extractGetvVariables,
+		ExtractorWithDefaults: extractGetvVariablesWithDefaults,
+		ArgTypeHint:           TypeBool,
+	})
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "getvFloat",
+		Description:           "Get variable as a number with optional default, errors on non-numeric values",
+		Handler:               getvFloatMinimalHandler,
+		Extractor:             extractGetvVariables,
+		ExtractorWithDefaults: extractGetvVariablesWithDefaults,
+		ArgTypeHint:           TypeNumber,
+	})
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:                  "getvJSON",
+		Description:           "Get variable parsed as JSON with optional JSON default, errors on invalid JSON",
+		Handler:               getvJSONMinimalHandler,
+		Extractor:             extractGetvVariables,
+		ExtractorWithDefaults: extractGetvVariablesWithDefaults,
+		ArgTypeHint:           TypeJSONString,
+	})
+}
+
+// Minimal handlers for parsing
+func getvIntMinimalHandler(key string, v ...int) (int, error)             { return 0, nil }
+func getvBoolMinimalHandler(key string, v ...bool) (bool, error)          { return false, nil }
+func getvFloatMinimalHandler(key string, v ...float64) (float64, error)   { return 0, nil }
+func getvJSONMinimalHandler(key string, v ...string) (interface{}, error) { return nil, nil }
+
+// typedGetvValue returns the value of key, or reports that it is missing or empty
+func typedGetvValue(store *KeyStore, key string) (interface{}, bool) {
+	value, ok := store.Get(key)
+	if !ok || value == nil || value == "" {
+		return nil, false
+	}
+	return value, true
+}
+
+// Actual handlers for rendering
+func getvIntRenderHandler(store *KeyStore) func(key string, v ...int) (int, error) {
+	return func(key string, v ...int) (int, error) {
+		value, ok := typedGetvValue(store, key)
+		if !ok {
+			if len(v) > 0 {
+				return v[0], nil
+			}
+			return 0, fmt.Errorf("getvInt: key %s not found", key)
+		}
+		switch value := value.(type) {
+		case string:
+			if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				return n, nil
+			}
+		case float64:
+			// -math.MinInt is the first power of two above math.MaxInt, exact as a float64
+			if value == math.Trunc(value) && value >= math.MinInt && value < -math.MinInt {
+				return int(value), nil
+			}
+		case json.Number:
+			if n, err := value.Int64(); err == nil && n >= math.MinInt && n <= math.MaxInt {
+				return int(n), nil
+			}
+		case int:
+			return value, nil
+		}
+		return 0, fmt.Errorf("getvInt: value of key %s is not an integer: %v", key, value)
+	}
+}
+
+func getvBoolRenderHandler(store *KeyStore) func(key string, v ...bool) (bool, error) {
+	return func(key string, v ...bool) (bool, error) {
+		value, ok := typedGetvValue(store, key)
+		if !ok {
+			if len(v) > 0 {
+				return v[0], nil
+			}
+			return false, fmt.Errorf("getvBool: key %s not found", key)
+		}
+		switch value := value.(type) {
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+				return b, nil
+			}
+		case bool:
+			return value, nil
+		}
+		return false, fmt.Errorf("getvBool: value of key %s is not a boolean: %v", key, value)
+	}
+}
+
+func getvFloatRenderHandler(store *KeyStore) func(key string, v ...float64) (float64, error) {
+	return func(key string, v ...float64) (float64, error) {
+		value, ok := typedGetvValue(store, key)
+		if !ok {
+			if len(v) > 0 {
+				return v[0], nil
+			}
+			return 0, fmt.Errorf("getvFloat: key %s not found", key)
+		}
+		switch value := value.(type) {
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				return f, nil
+			}
+		case float64:
+			return value, nil
+		case int:
+			return float64(value), nil
+		case json.Number:
+			if f, err := value.Float64(); err == nil {
+				return f, nil
+			}
+		}
+		return 0, fmt.Errorf("getvFloat: value of key %s is not a number: %v", key, value)
+	}
+}
+
+// getvJSONRenderHandler parses string values and defaults as JSON; values that are already
+// structured (objects and arrays in the values JSON) are returned as they are
+func getvJSONRenderHandler(store *KeyStore) func(key string, v ...string) (interface{}, error) {
+	return func(key string, v ...string) (interface{}, error) {
+		value, ok := typedGetvValue(store, key)
+		if !ok {
+			if len(v) == 0 {
+				return nil, fmt.Errorf("getvJSON: key %s not found", key)
+			}
+			value = v[0]
+		}
+		text, isString := value.(string)
+		if !isString {
+			return value, nil
+		}
+		var result interface{}
+		if err := json.Unmarshal([]byte(text), &result); err != nil {
+			return nil, fmt.Errorf("getvJSON: value of key %s is not valid JSON: %v", key, err)
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,103 @@
+//go:build !js && (confd || custom)
+// +build !js
+// +build confd custom
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// renderTypedGetv renders a template with only the typed getv variants available
+func renderTypedGetv(templateContent string, variables map[string]interface{}) (string, error) {
+	store := NewKeyStore(variables)
+	funcs := template.FuncMap{
+		"getvInt":   getvIntRenderHandler(store),
+		"getvBool":  getvBoolRenderHandler(store),
+		"getvFloat": getvFloatRenderHandler(store),
+		"getvJSON":  getvJSONRenderHandler(store),
+	}
+	tmpl, err := template.New("test").Funcs(funcs).Parse(templateContent)
+	if err != nil {
+		return "", err
+	}
+	var output strings.Builder
+	err = tmpl.Execute(&output, variables)
+	return output.String(), err
+}
+
+func TestTypedGetv_Render(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		variables map[string]interface{}
+		expected  string
+		errMsg    string
+	}{
+		{"int", `{{getvInt "/port"}}`, map[string]interface{}{"/port": " 8080 "}, "8080", ""},
+		{"int from JSON number", `{{getvInt "/port"}}`, map[string]interface{}{"/port": float64(80)}, "80", ""},
+		{"int beyond int32", `{{getvInt "/size"}}`, map[string]interface{}{"/size": float64(1 << 40)}, "1099511627776", ""},
+		{"int from json.Number", `{{getvInt "/id"}}`, map[string]interface{}{"/id": json.Number("9223372036854775807")}, "9223372036854775807", ""},
+		{"int float beyond int", `{{getvInt "/id"}}`, map[string]interface{}{"/id": float64(1 << 63)}, "", "getvInt: value of key /id is not an integer"},
+		{"int default", `{{getvInt "/port" 80}}`, map[string]interface{}{"/port": ""}, "80", ""},
+		{"int invalid", `{{getvInt "/port" 80}}`, map[string]interface{}{"/port": "eighty"}, "", "getvInt: value of key /port is not an integer: eighty"},
+		{"int missing", `{{getvInt "/port"}}`, map[string]interface{}{}, "", "getvInt: key /port not found"},
+		{"bool", `{{if getvBool "/debug"}}on{{end}}`, map[string]interface{}{"/debug": "true"}, "on", ""},
+		{"bool default", `{{getvBool "/debug" false}}`, map[string]interface{}{}, "false", ""},
+		{"bool invalid", `{{getvBool "/debug"}}`, map[string]interface{}{"/debug": "yes please"}, "", "getvBool: value of key /debug is not a boolean"},
+		{"float", `{{getvFloat "/ratio"}}`, map[string]interface{}{"/ratio": "0.25"}, "0.25", ""},
+		{"float integer default", `{{getvFloat "/ratio" 1}}`, map[string]interface{}{}, "1", ""},
+		{"json object", `{{(getvJSON "/cfg").name}}`, map[string]interface{}{"/cfg": `{"name":"web"}`}, "web", ""},
+		{"json default", `{{range getvJSON "/hosts" "[\"a\",\"b\"]"}}{{.}}{{end}}`, map[string]interface{}{}, "ab", ""},
+		{"json structured value", `{{index (getvJSON "/hosts") 0}}`, map[string]interface{}{"/hosts": []interface{}{"x"}}, "x", ""},
+		{"json invalid", `{{getvJSON "/cfg"}}`, map[string]interface{}{"/cfg": "{"}, "", "getvJSON: value of key /cfg is not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTypedGetv(tt.template, tt.variables)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("render error = %v, want %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("render error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("render = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTypedGetv_Extraction(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registerTypedGetvFunctions(registry)
+	parser := NewParser(registry)
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl",
+		`{{getvInt "/port" 8080}} {{getvBool "/debug"}} {{getvFloat "/ratio" 0.5}} {{getvJSON "/cfg" "{}"}}`)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	var got [][4]string
+	for _, v := range variables {
+		got = append(got, [4]string{v.Name, v.Type, v.DefaultValue, v.DefaultType})
+	}
+	expected := [][4]string{
+		{"/port", TypeNumber, "8080", TypeNumber},
+		{"/debug", TypeBool, "", ""},
+		{"/ratio", TypeNumber, "0.5", TypeNumber},
+		{"/cfg", TypeJSONString, "{}", ""},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExtractVariablesWithPositions() = %v, want %v", got, expected)
+	}
+}
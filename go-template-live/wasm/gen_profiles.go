@@ -0,0 +1,102 @@
+//go:build ignore
+// +build ignore
+
+// gen_profiles generates profiles_gen.go, the static table of function names per profile
+// Run with: go generate (see the directive in types.go)
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// profileSources maps each profile to the files registering its functions
+var profileSources = []struct {
+	profile string
+	files   []string
+}{
+	{"ProfileCustom", []string{"functions_custom.go", "functions_typed.go", "functions_required.go", "functions_secret.go", "functions_locale.go"}},
+	{"ProfileConfd", []string{"functions_confd.go", "functions_typed.go", "functions_required.go", "functions_secret.go", "functions_locale.go"}},
+	{"ProfileSprig", []string{"functions_sprig.go", "functions_required.go"}},
+	{"ProfileGomplate", []string{"functions_gomplate.go", "functions_required.go"}},
+	{"ProfileHelm", []string{"functions_sprig.go", "functions_helm.go", "functions_required.go"}},
+}
+
+func main() {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_profiles.go; DO NOT EDIT.\n\n")
+	b.WriteString("package main\n\n")
+	b.WriteString("// profileFunctionTables lists the functions each profile registers, sorted by name\n")
+	b.WriteString("var profileFunctionTables = map[string][]string{\n")
+	b.WriteString("\tProfileOfficial: {},\n")
+	for _, source := range profileSources {
+		var names []string
+		for _, file := range source.files {
+			fileNames, err := registeredNames(file)
+			if err != nil {
+				log.Fatal(err)
+			}
+			names = append(names, fileNames...)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "\t%s: {\n", source.profile)
+		for _, name := range names {
+			fmt.Fprintf(&b, "\t\t%s,\n", strconv.Quote(name))
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n")
+
+	formatted, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("profiles_gen.go", formatted, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// registeredNames returns the Name of every FunctionDefinition literal in file
+func registeredNames(file string) ([]string, error) {
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	ast.Inspect(parsed, func(node ast.Node) bool {
+		literal, ok := node.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		if ident, ok := literal.Type.(*ast.Ident); !ok || ident.Name != "FunctionDefinition" {
+			return true
+		}
+		for _, element := range literal.Elts {
+			kv, ok := element.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Name" {
+				if value, ok := kv.Value.(*ast.BasicLit); ok && value.Kind == token.STRING {
+					name, err := strconv.Unquote(value.Value)
+					if err == nil {
+						names = append(names, name)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return names, nil
+}
@@ -1,3 +1,11 @@
 module github.com/plify-trove/go-template-live
 
-go 1.21
\ No newline at end of file
+go 1.21
+
+require (
+	github.com/BurntSushi/toml v1.4.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+	golang.org/x/text v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Editor grammar formats
+const (
+	GrammarMonarch   = "monarch"
+	GrammarTextMate  = "textmate"
+	grammarScopeName = "source.gotemplate"
+)
+
+// grammarKeywords are the words with a meaning of their own inside actions
+var grammarKeywords = []string{"if", "else", "end", "range", "with", "define", "block", "template", "break", "continue"}
+
+// grammarConstants are the literal words of the template language
+var grammarConstants = []string{"true", "false", "nil"}
+
+// GrammarWords are the keyword lists of an editor grammar, derived from a function registry
+type GrammarWords struct {
+	Profile   string   `json:"profile"`
+	Keywords  []string `json:"keywords"`
+	Constants []string `json:"constants"`
+	// Builtins are the functions predefined by text/template the registry does not override
+	Builtins []string `json:"builtins"`
+	// Functions are the functions of the registry, placeholders included as they parse
+	Functions []string `json:"functions"`
+}
+
+// NewGrammarWords returns the sorted keyword lists for the functions of a registry
+func NewGrammarWords(registry *FunctionRegistry) GrammarWords {
+	words := GrammarWords{
+		Profile:   registry.Profile(),
+		Keywords:  append([]string(nil), grammarKeywords...),
+		Constants: append([]string(nil), grammarConstants...),
+		Builtins:  []string{},
+		Functions: registry.GetFunctionNames(),
+	}
+	for name := range builtinSignatures {
+		if !registry.HasFunction(name) {
+			words.Builtins = append(words.Builtins, name)
+		}
+	}
+	sort.Strings(words.Builtins)
+	sort.Strings(words.Functions)
+	return words
+}
+
+// GenerateGrammar returns a highlighting grammar for the functions of a registry, either a
+// Monaco Monarch language definition or a TextMate grammar, ready to be marshaled to JSON
+func GenerateGrammar(registry *FunctionRegistry, format string) (map[string]interface{}, error) {
+	words := NewGrammarWords(registry)
+	switch strings.ToLower(format) {
+	case "", GrammarMonarch:
+		return monarchGrammar(words), nil
+	case GrammarTextMate:
+		return textMateGrammar(words), nil
+	}
+	return nil, fmt.Errorf("unknown grammar format %q (want %s or %s)", format, GrammarMonarch, GrammarTextMate)
+}
+
+// monarchGrammar writes a Monarch language definition: text outside actions is left plain,
+// and words inside actions are looked up in the keyword lists
+func monarchGrammar(words GrammarWords) map[string]interface{} {
+	rule := func(pattern string, action interface{}) []interface{} {
+		return []interface{}{pattern, action}
+	}
+	next := func(token, state string) map[string]interface{} {
+		return map[string]interface{}{"token": token, "next": state}
+	}
+	return map[string]interface{}{
+		"name":         "gotemplate-" + words.Profile,
+		"defaultToken": "",
+		"keywords":     words.Keywords,
+		"constants":    words.Constants,
+		"builtins":     words.Builtins,
+		"functions":    words.Functions,
+		"tokenizer": map[string]interface{}{
+			"root": []interface{}{
+				rule(`\{\{-?\s*\/\*`, next("comment", "@comment")),
+				rule(`\{\{-?`, next("delimiter.bracket", "@action")),
+				rule(`[^{]+`, ""),
+				rule(`\{`, ""),
+			},
+			"comment": []interface{}{
+				rule(`\*\/\s*-?\}\}`, next("comment", "@pop")),
+				rule(`[^*]+`, "comment"),
+				rule(`\*`, "comment"),
+			},
+			"action": []interface{}{
+				rule(`-?\}\}`, next("delimiter.bracket", "@pop")),
+				rule(`\$[A-Za-z_]\w*`, "variable"),
+				rule(`\$`, "variable"),
+				rule(`\.[A-Za-z_]\w*`, "variable.field"),
+				rule(`[A-Za-z_]\w*`, map[string]interface{}{"cases": map[string]interface{}{
+					"@keywords":  "keyword",
+					"@constants": "constant",
+					"@builtins":  "predefined",
+					"@functions": "function",
+					"@default":   "identifier",
+				}}),
+				rule(`"(?:[^"\\]|\\.)*"`, "string"),
+				rule("`[^`]*`", "string"),
+				rule(`'(?:[^'\\]|\\.)+'`, "string"),
+				rule(`-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?`, "number"),
+				rule(`:=|=|\|`, "operator"),
+				rule(`[()]`, "delimiter.parenthesis"),
+				rule(`\s+`, ""),
+			},
+		},
+	}
+}
+
+// textMateGrammar writes a TextMate grammar whose keyword and function patterns alternate the
+// words of each list
+func textMateGrammar(words GrammarWords) map[string]interface{} {
+	match := func(pattern, scope string) map[string]interface{} {
+		return map[string]interface{}{"match": pattern, "name": scope + ".gotemplate"}
+	}
+	patterns := []interface{}{
+		map[string]interface{}{"include": "#string"},
+		match(`\$[A-Za-z_]\w*|\$`, "variable.other"),
+		match(`\.[A-Za-z_]\w*`, "variable.other.member"),
+		match(wordsPattern(words.Keywords), "keyword.control"),
+		match(wordsPattern(words.Constants), "constant.language"),
+		match(`-?\b\d+(?:\.\d+)?(?:[eE][+-]?\d+)?\b`, "constant.numeric"),
+		match(`:=|=|\|`, "keyword.operator"),
+	}
+	if len(words.Builtins) > 0 {
+		patterns = append(patterns, match(wordsPattern(words.Builtins), "support.function.builtin"))
+	}
+	if len(words.Functions) > 0 {
+		patterns = append(patterns, match(wordsPattern(words.Functions), "support.function"))
+	}
+	return map[string]interface{}{
+		"name":      "Go Template (" + words.Profile + ")",
+		"scopeName": grammarScopeName,
+		"patterns": []interface{}{
+			map[string]interface{}{"include": "#comment"},
+			map[string]interface{}{"include": "#action"},
+		},
+		"repository": map[string]interface{}{
+			"comment": map[string]interface{}{
+				"begin": `\{\{-?\s*/\*`,
+				"end":   `\*/\s*-?\}\}`,
+				"name":  "comment.block.gotemplate",
+			},
+			"action": map[string]interface{}{
+				"begin":         `\{\{-?`,
+				"end":           `-?\}\}`,
+				"name":          "meta.embedded.action.gotemplate",
+				"beginCaptures": map[string]interface{}{"0": map[string]interface{}{"name": "punctuation.section.embedded.begin.gotemplate"}},
+				"endCaptures":   map[string]interface{}{"0": map[string]interface{}{"name": "punctuation.section.embedded.end.gotemplate"}},
+				"patterns":      patterns,
+			},
+			"string": map[string]interface{}{
+				"patterns": []interface{}{
+					match(`"(?:[^"\\]|\\.)*"`, "string.quoted.double"),
+					match("`[^`]*`", "string.quoted.other"),
+					match(`'(?:[^'\\]|\\.)+'`, "string.quoted.single"),
+				},
+			},
+		},
+	}
+}
+
+// wordsPattern matches any of the words as a whole word, longest first
+func wordsPattern(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = regexp.QuoteMeta(word)
+	}
+	sort.SliceStable(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return `\b(?:` + strings.Join(quoted, "|") + `)\b`
+}
@@ -0,0 +1,61 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateGrammar(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.SetProfile(ProfileConfd)
+	registry.RegisterFunction(&FunctionDefinition{Name: "getv", Handler: func(string, ...string) string { return "" }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "getenv", Handler: func(string) string { return "" }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "len", Handler: func(interface{}) int { return 0 }})
+
+	words := NewGrammarWords(registry)
+	if !reflect.DeepEqual(words.Functions, []string{"getenv", "getv", "len"}) {
+		t.Errorf("functions = %v", words.Functions)
+	}
+	for _, name := range words.Builtins {
+		if name == "len" {
+			t.Error("builtins list len, which the registry overrides")
+		}
+	}
+
+	monarch, err := GenerateGrammar(registry, "")
+	if err != nil {
+		t.Fatalf("GenerateGrammar(monarch) error = %v", err)
+	}
+	if monarch["name"] != "gotemplate-confd" || !reflect.DeepEqual(monarch["functions"], words.Functions) {
+		t.Errorf("monarch = %v %v", monarch["name"], monarch["functions"])
+	}
+	if _, err := json.Marshal(monarch); err != nil {
+		t.Errorf("monarch grammar does not marshal: %v", err)
+	}
+
+	textMate, err := GenerateGrammar(registry, "TextMate")
+	if err != nil {
+		t.Fatalf("GenerateGrammar(textmate) error = %v", err)
+	}
+	data, err := json.Marshal(textMate)
+	if err != nil {
+		t.Fatalf("textmate grammar does not marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"scopeName":"source.gotemplate"`) {
+		t.Errorf("textmate grammar = %s", data)
+	}
+	functions := regexp.MustCompile(wordsPattern(words.Functions))
+	if got := functions.FindAllString(`getv "/a" | getenv | length | len`, -1); !reflect.DeepEqual(got, []string{"getv", "getenv", "len"}) {
+		t.Errorf("function pattern matched %v", got)
+	}
+
+	if _, err := GenerateGrammar(registry, "vim"); err == nil {
+		t.Error("GenerateGrammar() accepted an unknown format")
+	}
+}
@@ -0,0 +1,80 @@
+//go:build !js
+// +build !js
+
+// This file contains liveness and readiness HTTP handlers for server mode
+// Tag: !js (the browser build serves no HTTP endpoints)
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// Self-test fixture rendered by the readiness check
+const (
+	selfTestTemplate = "{{.Probe}}"
+	selfTestValue    = "ready"
+)
+
+// HealthStatus is the JSON body returned by the health endpoints
+type HealthStatus struct {
+	Status  string `json:"status"`
+	Profile string `json:"profile,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SelfTest extracts and renders a tiny template with the active profile
+// It fails when extraction or rendering is broken, or when a non-official profile has no functions registered
+func SelfTest(parser *Parser, renderer *Renderer) error {
+	profile := renderer.registry.Profile()
+	if profile != ProfileOfficial && len(renderer.registry.GetFunctionNames()) == 0 {
+		return fmt.Errorf("profile %s has no functions registered", profile)
+	}
+
+	variables, err := parser.ExtractVariables("selftest.tmpl", selfTestTemplate)
+	if err != nil {
+		return fmt.Errorf("self-test extraction failed: %v", err)
+	}
+	if !reflect.DeepEqual(variables, []string{"Probe"}) {
+		return fmt.Errorf("self-test extraction returned %v, want [Probe]", variables)
+	}
+
+	result, err := renderer.Render(selfTestTemplate, map[string]interface{}{"Probe": selfTestValue}, RenderOptions{})
+	if err != nil {
+		return fmt.Errorf("self-test render failed: %v", err)
+	}
+	if result.Output != selfTestValue {
+		return fmt.Errorf("self-test render returned %q, want %q", result.Output, selfTestValue)
+	}
+	return nil
+}
+
+// NewHealthMux returns a mux serving /healthz (process is up) and /readyz (self-test passes)
+func NewHealthMux(parser *Parser, renderer *Renderer) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthStatus(w, http.StatusOK, HealthStatus{Status: "ok"})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status := HealthStatus{Status: "ok", Profile: renderer.registry.Profile()}
+		if err := SelfTest(parser, renderer); err != nil {
+			status.Status = "unavailable"
+			status.Error = err.Error()
+			writeHealthStatus(w, http.StatusServiceUnavailable, status)
+			return
+		}
+		writeHealthStatus(w, http.StatusOK, status)
+	})
+	return mux
+}
+
+// writeHealthStatus writes a health status as JSON
+func writeHealthStatus(w http.ResponseWriter, code int, status HealthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
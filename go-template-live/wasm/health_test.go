@@ -0,0 +1,51 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHealthMux tests liveness and readiness responses
+func TestHealthMux(t *testing.T) {
+	brokenRegistry := NewFunctionRegistry()
+	brokenRegistry.SetProfile(ProfileConfd)
+
+	tests := []struct {
+		name     string
+		registry *FunctionRegistry
+		path     string
+		wantCode int
+		wantBody HealthStatus
+	}{
+		{"healthz", NewFunctionRegistry(), "/healthz", http.StatusOK, HealthStatus{Status: "ok"}},
+		{"readyz", NewFunctionRegistry(), "/readyz", http.StatusOK, HealthStatus{Status: "ok", Profile: ProfileOfficial}},
+		{"readyz missing functions", brokenRegistry, "/readyz", http.StatusServiceUnavailable, HealthStatus{
+			Status: "unavailable", Profile: ProfileConfd, Error: "profile confd has no functions registered",
+		}},
+		{"healthz missing functions", brokenRegistry, "/healthz", http.StatusOK, HealthStatus{Status: "ok"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := NewHealthMux(NewParser(tt.registry), NewRenderer(tt.registry, nil))
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != tt.wantCode {
+				t.Errorf("GET %s code = %d, want %d", tt.path, recorder.Code, tt.wantCode)
+			}
+			var body HealthStatus
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("GET %s body %q: %v", tt.path, recorder.Body.String(), err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("GET %s body = %+v, want %+v", tt.path, body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"text/template"
+	"text/template/parse"
+)
+
+// includeFunction is Helm's include, which executes a named template like {{template}} but
+// returns its output, so {{include "mychart.labels" . | nindent 4}} loads and extracts the same way
+const includeFunction = "include"
+
+// TemplateSetFuncs builds functions bound to the template set of one execution, such as
+// include and tpl, which execute its named templates
+type TemplateSetFuncs func(set *template.Template) template.FuncMap
+
+// SetTemplateIncludes registers files that {{template "partials/header.tmpl" .}} can include,
+// keyed by template name; nil clears them, leaving includes to the render filesystem
+func (e *Environment) SetTemplateIncludes(files map[string]string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.includes = files
+}
+
+// includeSource is where {{template}} includes not defined in the set are looked up: the
+// registered files, then the filesystem
+type includeSource struct {
+	files map[string]string
+	fs    FileSystem
+}
+
+// loadIncludes parses into tmpl the files its {{template}} actions include, directly or through
+// other includes, and returns their names in load order
+// A template name not defined in the set is looked up in local, then in the registered
+// includes of source, then read from its filesystem; a name that is no file is looked up among the {{define}}s
+// of the local and registered files, as Helm charts keep named templates in _helpers.tpl.
+// Names found in none are left for execution to report.
+// Definitions already in the set win over those of included files, so a {{define}} next to
+// {{template "layout.tmpl" .}} fills a {{block}} of layout.tmpl
+func loadIncludes(tmpl *template.Template, local map[string]string, source includeSource) ([]string, error) {
+	var loaded []string
+	tried := make(map[string]bool)
+	for {
+		missing := undefinedTemplates(tmpl, tried)
+		if len(missing) == 0 {
+			return loaded, nil
+		}
+		for _, name := range missing {
+			tried[name] = true
+			content, ok, err := includeContent(name, local, source)
+			if err != nil {
+				return nil, fmt.Errorf("error reading included template %s: %v", name, err)
+			}
+			if !ok {
+				if name, content, ok = definingInclude(name, local, source); !ok {
+					continue
+				}
+				tried[name] = true
+			}
+			if err := parseInclude(tmpl, name, content); err != nil {
+				return nil, err
+			}
+			loaded = append(loaded, name)
+		}
+	}
+}
+
+// parseInclude parses an included file into tmpl, restoring the definitions it replaced
+func parseInclude(tmpl *template.Template, name, content string) error {
+	defined := make(map[string]*parse.Tree)
+	for _, t := range tmpl.Templates() {
+		defined[t.Name()] = t.Tree
+	}
+	if _, err := tmpl.New(name).Parse(content); err != nil {
+		return fmt.Errorf("error parsing included template %s: %v", name, err)
+	}
+	for definedName, tree := range defined {
+		if tree != nil && tmpl.Lookup(definedName).Tree != tree {
+			if _, err := tmpl.AddParseTree(definedName, tree); err != nil {
+				return fmt.Errorf("error parsing included template %s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// includeContent returns the content of an included template
+func includeContent(name string, local map[string]string, source includeSource) (string, bool, error) {
+	if content, ok := local[name]; ok {
+		return content, true, nil
+	}
+	if content, ok := source.files[name]; ok {
+		return content, true, nil
+	}
+	content, err := source.fs.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(content), true, nil
+}
+
+// definingInclude returns the local or registered file that defines the template name
+// Files are checked in name order, local files first, and ones that do not parse are skipped
+func definingInclude(name string, local map[string]string, source includeSource) (string, string, bool) {
+	for _, files := range []map[string]string{local, source.files} {
+		fileNames := make([]string, 0, len(files))
+		for fileName := range files {
+			fileNames = append(fileNames, fileName)
+		}
+		sort.Strings(fileNames)
+		for _, fileName := range fileNames {
+			tree := parse.New(fileName)
+			tree.Mode = parse.SkipFuncCheck
+			defined := make(map[string]*parse.Tree)
+			if _, err := tree.Parse(files[fileName], "", "", defined); err != nil {
+				continue
+			}
+			if _, ok := defined[name]; ok && name != fileName {
+				return fileName, files[fileName], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// undefinedTemplates returns the sorted names invoked by {{template}} actions of the set that
+// neither are defined nor were tried before
+func undefinedTemplates(tmpl *template.Template, tried map[string]bool) []string {
+	seen := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			collectTemplateNames(t.Tree.Root, seen, 0)
+		}
+	}
+	var missing []string
+	for name := range seen {
+		if !tried[name] && tmpl.Lookup(name) == nil {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// collectTemplateNames records the names of the templates invoked under node, by {{template}}
+// actions or include calls with a literal name
+func collectTemplateNames(node parse.Node, names map[string]bool, depth int) {
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		switch node := node.(type) {
+		case *parse.TemplateNode:
+			names[node.Name] = true
+		case *parse.CommandNode:
+			if name, ok := includedName(node.Args); ok {
+				names[name] = true
+			}
+		}
+		return true
+	})
+}
+
+// includedName returns the template name of an include call with a literal name
+func includedName(args []parse.Node) (string, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	if ident, ok := args[0].(*parse.IdentifierNode); !ok || ident.Ident != includeFunction {
+		return "", false
+	}
+	name, ok := args[1].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	return name.Text, true
+}
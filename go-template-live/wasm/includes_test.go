@@ -0,0 +1,113 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestTemplateIncludes tests that included template files are parsed into the template set
+// for extraction and rendering, from the registered includes and the render filesystem
+func TestTemplateIncludes(t *testing.T) {
+	env := NewEnvironment()
+	env.SetTemplateIncludes(map[string]string{
+		"partials/header.tmpl": `# {{.AppName}}{{template "partials/version.tmpl" .}}`,
+		"partials/loop.tmpl":   `{{if .Next}}{{template "partials/loop.tmpl" .Next}}{{end}}`,
+	})
+	env.SetRenderFS(NewVirtualFS(map[string]string{"partials/version.tmpl": ` v{{.Version}}`}))
+	parser, renderer := newIncludesParserRenderer(env)
+
+	content := `{{template "partials/header.tmpl" .}}
+{{define "port"}}port={{.Port}}{{end}}{{template "port" .}}{{template "partials/loop.tmpl" .}}`
+
+	names, err := parser.ExtractVariables("main.tmpl", content)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"AppName", "Version", "Port", "Next", "Next"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+
+	variables, err := parser.ExtractVariablesWithPositions("main.tmpl", content)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+	for _, v := range variables {
+		included := v.Name != "Port"
+		if included != (v.Position == nil) {
+			t.Errorf("ExtractVariablesWithPositions() %s position = %+v, want one only for variables of main.tmpl", v.Name, v.Position)
+		}
+	}
+	if port := variables[2]; port.Position == nil || port.Position.Line != 2 {
+		t.Errorf("ExtractVariablesWithPositions() Port = %+v, want it on line 2", port)
+	}
+
+	values := map[string]interface{}{"AppName": "shop", "Version": "1.2", "Port": 80}
+	for _, format := range []string{OutputFormatText, OutputFormatHTML} {
+		result, err := renderer.Render(content, values, RenderOptions{OutputFormat: format})
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", format, err)
+		}
+		if expected := "# shop v1.2\nport=80"; result.Output != expected {
+			t.Errorf("Render(%s) output = %q, want %q", format, result.Output, expected)
+		}
+	}
+}
+
+// TestTemplateIncludes_DefinedNames tests that a name that is no file is found among the
+// {{define}}s of the registered includes
+func TestTemplateIncludes_DefinedNames(t *testing.T) {
+	env := NewEnvironment()
+	env.SetTemplateIncludes(map[string]string{
+		"_helpers.tpl": `{{define "app.name"}}{{.Name}}-app{{end}}`,
+		"broken.tpl":   `{{define "app.name"}}`,
+	})
+	parser, renderer := newIncludesParserRenderer(env)
+
+	content := `name: {{template "app.name" .}}`
+	names, err := parser.ExtractVariables("main.tmpl", content)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"Name"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+	result, err := renderer.Render(content, map[string]interface{}{"Name": "web"}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "name: web-app"; result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+}
+
+// TestTemplateIncludes_Errors tests that broken includes fail, and unknown names fail at execution
+func TestTemplateIncludes_Errors(t *testing.T) {
+	env := NewEnvironment()
+	env.SetTemplateIncludes(map[string]string{"broken.tmpl": `{{if .A}}`})
+	env.SetRenderFS(NewVirtualFS(nil))
+	parser, renderer := newIncludesParserRenderer(env)
+	if _, err := parser.ExtractVariables("main.tmpl", `{{template "broken.tmpl" .}}`); err == nil || !strings.Contains(err.Error(), "included template broken.tmpl") {
+		t.Errorf("ExtractVariables() error = %v, want an included template error", err)
+	}
+
+	names, err := parser.ExtractVariables("main.tmpl", `{{.A}}{{template "missing.tmpl" .}}`)
+	if err != nil || !reflect.DeepEqual(names, []string{"A"}) {
+		t.Errorf("ExtractVariables() = %v, %v, want [A]", names, err)
+	}
+	if _, err := renderer.Render(`{{template "missing.tmpl" .}}`, nil, RenderOptions{}); err == nil {
+		t.Errorf("Render() of a missing include succeeded, want an error")
+	}
+}
+
+// newIncludesParserRenderer creates a parser and a renderer without functions sharing env
+func newIncludesParserRenderer(env *Environment) (*Parser, *Renderer) {
+	parser := NewParser(NewFunctionRegistry())
+	parser.SetEnvironment(env)
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	renderer.SetEnvironment(env)
+	return parser, renderer
+}
@@ -0,0 +1,132 @@
+//go:build !js
+// +build !js
+
+// This file contains incremental directory rendering driven by variable dependencies
+// Tag: !js (used by the watch mode of native builds)
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IncrementalRenderer re-renders only the templates affected by changed values
+// It is not safe for concurrent use; each Render call renders its batch in parallel
+type IncrementalRenderer struct {
+	renderer     *Renderer
+	parser       *Parser
+	resources    []TemplateResource
+	dependencies map[string][]string
+	dirty        map[string]bool
+	values       map[string]interface{}
+	rendered     bool
+}
+
+// NewIncrementalRenderer extracts the variables consumed by every resource
+func NewIncrementalRenderer(renderer *Renderer, parser *Parser, resources []TemplateResource) (*IncrementalRenderer, error) {
+	ir := &IncrementalRenderer{
+		renderer:     renderer,
+		parser:       parser,
+		dependencies: make(map[string][]string),
+		dirty:        make(map[string]bool),
+	}
+	for _, resource := range resources {
+		if err := ir.UpdateResource(resource); err != nil {
+			return nil, err
+		}
+	}
+	return ir, nil
+}
+
+// UpdateResource adds or replaces a template, marking it for re-rendering
+func (ir *IncrementalRenderer) UpdateResource(resource TemplateResource) error {
+	names, err := ir.parser.ExtractVariables(resource.Name, resource.Content)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range ir.resources {
+		if ir.resources[i].Name == resource.Name {
+			ir.resources[i] = resource
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		ir.resources = append(ir.resources, resource)
+	}
+	ir.dependencies[resource.Name] = uniqueSorted(names)
+	ir.dirty[resource.Name] = true
+	return nil
+}
+
+// Dependencies returns the sorted variable names a template consumes
+func (ir *IncrementalRenderer) Dependencies(name string) ([]string, error) {
+	deps, ok := ir.dependencies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown template %s", name)
+	}
+	return deps, nil
+}
+
+// Render renders every template on the first call; later calls render only templates that were
+// updated or whose dependencies intersect the keys that changed since the previous call
+func (ir *IncrementalRenderer) Render(values map[string]interface{}, opts RenderOptions, workers int) ([]ResourceResult, error) {
+	var changed []string
+	if ir.rendered {
+		changed = ChangedKeys(ir.values, values)
+	}
+
+	var batch []TemplateResource
+	for _, resource := range ir.resources {
+		if !ir.rendered || ir.dirty[resource.Name] || dependsOnAny(ir.dependencies[resource.Name], changed) {
+			batch = append(batch, resource)
+		}
+	}
+
+	results, err := ir.renderer.RenderBatch(batch, values, opts, workers)
+	if results == nil && err != nil {
+		return nil, err
+	}
+
+	// Keep a copy of the top level so callers may reuse their map; nested values must be replaced, not mutated
+	ir.values = copyValues(values)
+	ir.rendered = true
+	ir.dirty = make(map[string]bool)
+	// Failed templates are retried on the next call even if nothing they use changes
+	for _, result := range results {
+		if result.Error != "" {
+			ir.dirty[result.Name] = true
+		}
+	}
+	return results, err
+}
+
+// dependsOnAny reports whether a variable such as Server.Port is affected by a changed top-level key
+func dependsOnAny(dependencies, changedKeys []string) bool {
+	for _, key := range changedKeys {
+		for _, dep := range dependencies {
+			if dep == key || strings.HasPrefix(dep, key+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// uniqueSorted returns the distinct strings of names in sorted order
+func uniqueSorted(names []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
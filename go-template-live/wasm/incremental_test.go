@@ -0,0 +1,72 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestIncrementalRenderer_RendersAffectedTemplates tests that only templates using changed keys re-render
+func TestIncrementalRenderer_RendersAffectedTemplates(t *testing.T) {
+	registry := NewFunctionRegistry()
+	resources := []TemplateResource{
+		{Name: "app.conf", Content: "port={{.Server.Port}}"},
+		{Name: "db.conf", Content: "db={{.Database}}"},
+		{Name: "static.conf", Content: "static"},
+	}
+	ir, err := NewIncrementalRenderer(NewRenderer(registry, nil), NewParser(registry), resources)
+	if err != nil {
+		t.Fatalf("NewIncrementalRenderer() error = %v", err)
+	}
+
+	renderedNames := func(values map[string]interface{}) []string {
+		results, err := ir.Render(values, RenderOptions{}, 2)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		names := []string{}
+		for _, result := range results {
+			names = append(names, result.Name)
+		}
+		return names
+	}
+
+	values := map[string]interface{}{"Server": map[string]interface{}{"Port": 80}, "Database": "pg"}
+	if got := renderedNames(values); !reflect.DeepEqual(got, []string{"app.conf", "db.conf", "static.conf"}) {
+		t.Errorf("first Render() rendered %v, want all templates", got)
+	}
+
+	values = map[string]interface{}{"Server": map[string]interface{}{"Port": 8080}, "Database": "pg"}
+	if got := renderedNames(values); !reflect.DeepEqual(got, []string{"app.conf"}) {
+		t.Errorf("Render() after Server change rendered %v, want [app.conf]", got)
+	}
+
+	if got := renderedNames(values); len(got) != 0 {
+		t.Errorf("Render() without changes rendered %v, want none", got)
+	}
+
+	if err := ir.UpdateResource(TemplateResource{Name: "static.conf", Content: "static v2"}); err != nil {
+		t.Fatalf("UpdateResource() error = %v", err)
+	}
+	if got := renderedNames(values); !reflect.DeepEqual(got, []string{"static.conf"}) {
+		t.Errorf("Render() after template update rendered %v, want [static.conf]", got)
+	}
+
+	delete(values, "Database")
+	if got := renderedNames(values); !reflect.DeepEqual(got, []string{"db.conf"}) {
+		t.Errorf("Render() after removing Database rendered %v, want [db.conf]", got)
+	}
+}
+
+// TestChangedKeys tests detection of added, removed and modified keys
+func TestChangedKeys(t *testing.T) {
+	oldValues := map[string]interface{}{"a": 1, "b": []interface{}{"x"}, "c": "same"}
+	newValues := map[string]interface{}{"b": []interface{}{"y"}, "c": "same", "d": true}
+
+	expected := []string{"a", "b", "d"}
+	if got := ChangedKeys(oldValues, newValues); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ChangedKeys() = %v, want %v", got, expected)
+	}
+}
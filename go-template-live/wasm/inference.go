@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// Confidence levels of an inferred value
+const (
+	// ConfidenceHigh values sit between literal text on both sides, and every occurrence agrees
+	ConfidenceHigh = "high"
+	// ConfidenceMedium values contain the start of the literal text that follows them, so the
+	// sample could be split at another place
+	ConfidenceMedium = "medium"
+	// ConfidenceLow values border another variable or a block that could not be solved, or
+	// their occurrences disagree
+	ConfidenceLow = "low"
+)
+
+// InferredValue is the best guess for one variable of a template
+type InferredValue struct {
+	// Name is a field path (Config.Port) or a confd key (/app/port)
+	Name       string      `json:"name"`
+	Value      interface{} `json:"value"`
+	Confidence string      `json:"confidence"`
+	// Occurrences is the number of places the variable was read from the sample
+	Occurrences int `json:"occurrences"`
+	// Position is the first action reading the variable in the template
+	Position Position `json:"position"`
+	// Note explains a confidence below high
+	Note string `json:"note,omitempty"`
+}
+
+// InferenceResult is the outcome of solving a template for the values of a rendered sample
+type InferenceResult struct {
+	// Matched is false when the literal text of the template could not be lined up with the
+	// sample; Mismatch and MismatchPosition then tell where the template and sample diverge
+	Matched          bool      `json:"matched"`
+	Mismatch         string    `json:"mismatch,omitempty"`
+	MismatchPosition *Position `json:"mismatchPosition,omitempty"`
+	// Values holds the inferred values, ready to render the template with
+	Values   map[string]interface{} `json:"values"`
+	Inferred []InferredValue        `json:"inferred"`
+	// Verified is set when rendering the template with Values reproduces the sample exactly
+	Verified bool `json:"verified"`
+}
+
+// inferenceElement is one piece of a template's top-level output: literal text, a variable
+// read by a plain action, or output that cannot be solved (other actions and blocks)
+type inferenceElement struct {
+	text     string
+	variable string
+	wildcard bool
+	pos      parse.Pos
+	length   int
+}
+
+// InferValues solves a template for the variable values that render a sample of its output
+// Literal text between actions anchors the solution: plain {{.Field}} and {{getv "key"}}
+// actions are read from the sample, while conditionals, loops and computed actions match any
+// text and leave the variables they read unsolved. Values are strings, except field values
+// that render back identically as integers or booleans
+func (r *Renderer) InferValues(templateContent, sample string) (*InferenceResult, error) {
+	tree := parse.New("template")
+	tree.Mode = parse.SkipFuncCheck | parse.ParseComments
+	if _, err := tree.Parse(templateContent, "", "", make(map[string]*parse.Tree)); err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+	elements := inferenceElements(tree.Root)
+	index := newLineIndex(templateContent)
+
+	result := &InferenceResult{Values: make(map[string]interface{}), Inferred: []InferredValue{}}
+	match := inferencePattern(elements, true).FindStringSubmatch(sample)
+	if match == nil {
+		result.Mismatch, result.MismatchPosition = inferenceMismatch(elements, sample, templateContent, index)
+		return result, nil
+	}
+	result.Matched = true
+
+	byName := make(map[string]int)
+	group := 1
+	for i, e := range elements {
+		if e.variable == "" {
+			continue
+		}
+		value := match[group]
+		group++
+
+		confidence, note := ConfidenceHigh, ""
+		switch {
+		case !anchored(elements, i-1) || !anchored(elements, i+1):
+			confidence, note = ConfidenceLow, "not separated by literal text from another action"
+		case i+1 < len(elements) && strings.Contains(value, firstLine(elements[i+1].text)):
+			confidence, note = ConfidenceMedium, fmt.Sprintf("value contains %q, which starts the text that follows it", firstLine(elements[i+1].text))
+		}
+
+		k, seen := byName[e.variable]
+		if !seen {
+			position := Position{Offset: int(e.pos), Length: e.length}
+			index.resolve(templateContent, &position)
+			byName[e.variable] = len(result.Inferred)
+			result.Inferred = append(result.Inferred, InferredValue{
+				Name:        e.variable,
+				Value:       value,
+				Confidence:  confidence,
+				Occurrences: 1,
+				Position:    position,
+				Note:        note,
+			})
+			continue
+		}
+		inferred := &result.Inferred[k]
+		inferred.Occurrences++
+		switch {
+		case inferred.Value != value:
+			inferred.Confidence = ConfidenceLow
+			inferred.Note = fmt.Sprintf("occurrences disagree: %q and %q", inferred.Value, value)
+		case confidenceRank(confidence) < confidenceRank(inferred.Confidence):
+			inferred.Confidence, inferred.Note = confidence, note
+		}
+	}
+
+	for i := range result.Inferred {
+		inferred := &result.Inferred[i]
+		if !isKeyPath(inferred.Name) {
+			inferred.Value = inferScalar(inferred.Value.(string))
+		}
+		setValuePath(result.Values, inferred.Name, inferred.Value)
+	}
+
+	if rendered, err := r.Render(templateContent, result.Values, RenderOptions{}); err == nil {
+		result.Verified = rendered.Output == sample
+	}
+	return result, nil
+}
+
+// inferenceElements flattens the top level of a template into literal text, plain variable
+// reads and wildcards; adjacent wildcards are merged
+func inferenceElements(root *parse.ListNode) []inferenceElement {
+	var elements []inferenceElement
+	for _, node := range root.Nodes {
+		switch node := node.(type) {
+		case *parse.TextNode:
+			if len(node.Text) > 0 {
+				elements = append(elements, inferenceElement{text: string(node.Text), pos: node.Pos})
+			}
+			continue
+		case *parse.CommentNode:
+			continue
+		case *parse.ActionNode:
+			if name, ok := plainVariable(node.Pipe); ok {
+				elements = append(elements, inferenceElement{variable: name, pos: node.Pos, length: len(node.String())})
+				continue
+			}
+			if len(node.Pipe.Decl) > 0 {
+				// {{$x := ...}} renders nothing
+				continue
+			}
+		}
+		if len(elements) > 0 && elements[len(elements)-1].wildcard {
+			continue
+		}
+		elements = append(elements, inferenceElement{wildcard: true, pos: node.Position()})
+	}
+	return elements
+}
+
+// plainVariable returns the variable an action prints unchanged: a field path or a getv key,
+// with or without a default
+func plainVariable(pipe *parse.PipeNode) (string, bool) {
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 {
+		return "", false
+	}
+	args := pipe.Cmds[0].Args
+	switch first := args[0].(type) {
+	case *parse.FieldNode:
+		if len(args) == 1 {
+			return strings.Join(first.Ident, "."), true
+		}
+	case *parse.IdentifierNode:
+		if first.Ident == "getv" && (len(args) == 2 || len(args) == 3) {
+			if key, ok := args[1].(*parse.StringNode); ok {
+				return key.Text, true
+			}
+		}
+	}
+	return "", false
+}
+
+// inferencePattern builds the regular expression matching the output of elements, with a group
+// for every variable; whole matches the complete sample rather than a prefix of it
+func inferencePattern(elements []inferenceElement, whole bool) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`(?s)\A`)
+	for _, e := range elements {
+		switch {
+		case e.variable != "":
+			b.WriteString(`(.*?)`)
+		case e.wildcard:
+			b.WriteString(`.*?`)
+		default:
+			b.WriteString(regexp.QuoteMeta(e.text))
+		}
+	}
+	if whole {
+		b.WriteString(`\z`)
+	}
+	return regexp.MustCompile(b.String())
+}
+
+// inferenceMismatch describes the first literal text of the template the sample does not line
+// up with, or the extra output at the end of the sample
+func inferenceMismatch(elements []inferenceElement, sample, templateContent string, index lineIndex) (string, *Position) {
+	matched := 0
+	for matched < len(elements) && inferencePattern(elements[:matched+1], false).MatchString(sample) {
+		matched++
+	}
+	if matched == len(elements) {
+		return "the sample continues after the end of the template's output", nil
+	}
+	e := elements[matched]
+	position := &Position{Offset: int(e.pos), Length: len(e.text)}
+	index.resolve(templateContent, position)
+	return fmt.Sprintf("template text %q not found in the sample", e.text), position
+}
+
+// anchored reports whether the element at i is literal text or lies outside the template, so
+// a variable next to it has a fixed boundary
+func anchored(elements []inferenceElement, i int) bool {
+	return i < 0 || i >= len(elements) || (elements[i].variable == "" && !elements[i].wildcard)
+}
+
+// firstLine returns text up to its first newline, or the newline itself when text starts a line
+func firstLine(text string) string {
+	switch i := strings.IndexByte(text, '\n'); {
+	case i == 0:
+		return "\n"
+	case i > 0:
+		return text[:i]
+	}
+	return text
+}
+
+// confidenceRank orders confidence levels from low to high
+func confidenceRank(confidence string) int {
+	switch confidence {
+	case ConfidenceHigh:
+		return 2
+	case ConfidenceMedium:
+		return 1
+	}
+	return 0
+}
+
+// inferScalar converts a value to an integer or boolean when it prints back unchanged
+func inferScalar(value string) interface{} {
+	if n, err := strconv.Atoi(value); err == nil && strconv.Itoa(n) == value {
+		return n
+	}
+	if b, err := strconv.ParseBool(value); err == nil && strconv.FormatBool(b) == value {
+		return b
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInferValues(t *testing.T) {
+	template := `server {{.Server.Host}}:{{.Server.Port}}
+{{/* workers */}}workers={{.Workers}} debug={{.Debug}}
+name={{.Name}}{{.Suffix}}
+{{if .TLS}}tls on{{end}}
+log={{.Log}};
+again={{.Server.Host}}
+`
+	sample := `server db.example.com:5432
+workers=4 debug=false
+name=app-prod
+tls on
+log=/var/log/a;b.log;
+again=db.example.com
+`
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	result, err := renderer.InferValues(template, sample)
+	if err != nil {
+		t.Fatalf("InferValues() error = %v", err)
+	}
+	if !result.Matched {
+		t.Fatalf("Matched = false, mismatch %q", result.Mismatch)
+	}
+
+	confidences := make(map[string]string)
+	for _, v := range result.Inferred {
+		confidences[v.Name] = v.Confidence
+	}
+	wantConfidences := map[string]string{
+		"Server.Host": ConfidenceHigh,
+		"Server.Port": ConfidenceHigh,
+		"Workers":     ConfidenceHigh,
+		"Debug":       ConfidenceHigh,
+		"Name":        ConfidenceLow,
+		"Suffix":      ConfidenceLow,
+		"Log":         ConfidenceMedium,
+	}
+	if !reflect.DeepEqual(confidences, wantConfidences) {
+		t.Errorf("confidences = %v, want %v", confidences, wantConfidences)
+	}
+	if result.Inferred[0].Occurrences != 2 || result.Inferred[0].Position.Line != 1 {
+		t.Errorf("Server.Host = %+v, want 2 occurrences first read on line 1", result.Inferred[0])
+	}
+
+	server := result.Values["Server"].(map[string]interface{})
+	if server["Host"] != "db.example.com" || server["Port"] != 5432 || result.Values["Workers"] != 4 || result.Values["Debug"] != false {
+		t.Errorf("Values = %v, want typed server, workers and debug values", result.Values)
+	}
+	// TLS is only read by the skipped conditional, so the values do not render the sample
+	if result.Verified {
+		t.Error("Verified = true, want false without a value for .TLS")
+	}
+}
+
+func TestInferValues_Verified(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	result, err := renderer.InferValues("host: {{.Host}}\nport: {{.Port}}\n", "host: localhost\nport: 8080\n")
+	if err != nil {
+		t.Fatalf("InferValues() error = %v", err)
+	}
+	if !result.Verified || !reflect.DeepEqual(result.Values, map[string]interface{}{"Host": "localhost", "Port": 8080}) {
+		t.Errorf("result = %+v, want verified host and port", result)
+	}
+}
+
+func TestInferValues_Conflict(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	result, err := renderer.InferValues("a={{.X}}\nb={{.X}}\n", "a=1\nb=2\n")
+	if err != nil {
+		t.Fatalf("InferValues() error = %v", err)
+	}
+	if len(result.Inferred) != 1 || result.Inferred[0].Confidence != ConfidenceLow || result.Inferred[0].Note == "" {
+		t.Errorf("Inferred = %+v, want one low-confidence value with a note", result.Inferred)
+	}
+}
+
+func TestInferValues_Mismatch(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	result, err := renderer.InferValues("host: {{.Host}}\nport: {{.Port}}\n", "host: localhost\nlisten: 8080\n")
+	if err != nil {
+		t.Fatalf("InferValues() error = %v", err)
+	}
+	if result.Matched || result.MismatchPosition == nil || result.MismatchPosition.Line != 1 {
+		t.Errorf("result = %+v, want a mismatch at the text after .Host on line 1", result)
+	}
+	if result.Mismatch != `template text "\nport: " not found in the sample` {
+		t.Errorf("Mismatch = %q", result.Mismatch)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+)
+
+// jsExternalFunc calls a template function defined by the page
+// Arguments are passed as JSON values (numbers, strings, booleans, arrays, objects, null) and the
+// result is converted back the same way, undefined becoming nil; a thrown error fails the action
+func jsExternalFunc(fn js.Value) ExternalFunc {
+	return func(args []interface{}) (result interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("%v", recovered)
+			}
+		}()
+
+		jsArgs := make([]interface{}, len(args))
+		for i, arg := range args {
+			data, err := json.Marshal(arg)
+			if err != nil {
+				return nil, fmt.Errorf("argument %d: %v", i+1, err)
+			}
+			jsArgs[i] = js.Global().Get("JSON").Call("parse", string(data))
+		}
+		return goValue(fn.Invoke(jsArgs...))
+	}
+}
+
+// goValue converts a JavaScript value to the Go values JSON decoding produces
+func goValue(value js.Value) (interface{}, error) {
+	switch value.Type() {
+	case js.TypeUndefined, js.TypeNull:
+		return nil, nil
+	case js.TypeString:
+		return value.String(), nil
+	case js.TypeBoolean:
+		return value.Bool(), nil
+	case js.TypeNumber:
+		return value.Float(), nil
+	case js.TypeObject:
+		var result interface{}
+		err := json.Unmarshal([]byte(js.Global().Get("JSON").Call("stringify", value).String()), &result)
+		return result, err
+	}
+	return nil, fmt.Errorf("cannot use a %s as a template value", value.Type())
+}
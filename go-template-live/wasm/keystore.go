@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// KeyStore resolves confd-style keys such as /myapp/database/host against a values map
+// Values can be flat, keyed by the full path as confd's backends store them, or hierarchical,
+// with an object per path segment: {"myapp": {"database": {"host": "db"}}}
+type KeyStore struct {
+	values map[string]interface{}
+	// lookups resolves missing keys while rendering; nil outside renders
+	lookups *valueLookups
+}
+
+// NewKeyStore creates a key store over values; the map is read, never modified
+func NewKeyStore(values map[string]interface{}) *KeyStore {
+	return &KeyStore{values: values}
+}
+
+// Get returns the value of key, trying the key as written, then the cleaned path
+// ("myapp//database/" is "/myapp/database"), then the nested objects along its segments,
+// and while rendering finally the value resolver (see Environment.SetValueResolver)
+func (s *KeyStore) Get(key string) (interface{}, bool) {
+	if value, ok := s.values[key]; ok {
+		return value, true
+	}
+	segments := splitKey(key)
+	if len(segments) == 0 {
+		return s.lookups.resolve(key)
+	}
+	if cleaned := joinKey(segments); cleaned != key {
+		if value, ok := s.values[cleaned]; ok {
+			return value, true
+		}
+	}
+
+	var current interface{} = s.values
+	for _, segment := range segments {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return s.lookups.resolve(key)
+		}
+		if current, ok = object[segment]; !ok {
+			return s.lookups.resolve(key)
+		}
+	}
+	return current, true
+}
+
+// Exists reports whether key has a value
+func (s *KeyStore) Exists(key string) bool {
+	_, ok := s.Get(key)
+	return ok
+}
+
+// KVPair is a key and its value, as returned by confd's gets
+type KVPair struct {
+	Key   string
+	Value string
+}
+
+// GetAll returns the keys matching a path.Match pattern such as /myapp/upstreams/*, sorted by key
+// Values that are not strings are formatted with fmt.Sprint
+func (s *KeyStore) GetAll(pattern string) ([]KVPair, error) {
+	var pairs []KVPair
+	var err error
+	s.walk(func(key string, value interface{}) {
+		if err != nil {
+			return
+		}
+		var matched bool
+		if matched, err = path.Match(pattern, key); matched {
+			text, ok := value.(string)
+			if !ok {
+				text = fmt.Sprint(value)
+			}
+			pairs = append(pairs, KVPair{Key: key, Value: text})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	return pairs, nil
+}
+
+// GetAllValues returns the sorted values of the keys matching pattern, like confd's getvs
+func (s *KeyStore) GetAllValues(pattern string) ([]string, error) {
+	pairs, err := s.GetAll(pattern)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(pairs))
+	for i, pair := range pairs {
+		values[i] = pair.Value
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// List returns the sorted names of the keys and directories directly under dir, like confd's ls
+// A key equal to dir itself contributes its own name, as in confd
+func (s *KeyStore) List(dir string) []string {
+	return s.children(dir, 0)
+}
+
+// ListDir returns the sorted names of the directories directly under dir, like confd's lsdir:
+// the children that have keys below them
+func (s *KeyStore) ListDir(dir string) []string {
+	return s.children(dir, 1)
+}
+
+// children collects the segments following dir in keys at least depth segments deeper
+func (s *KeyStore) children(dir string, depth int) []string {
+	prefix := splitKey(dir)
+	seen := make(map[string]bool)
+	s.walk(func(key string, value interface{}) {
+		segments := splitKey(key)
+		if depth == 0 && len(segments) > 0 && len(segments) == len(prefix) && hasPrefix(segments, prefix) {
+			seen[segments[len(segments)-1]] = true
+			return
+		}
+		if len(segments) > len(prefix)+depth && hasPrefix(segments, prefix) {
+			seen[segments[len(prefix)]] = true
+		}
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithKeyPrefix returns the values a confd template resource with the given key prefix sees:
+// only the keys below prefix, with the prefix removed, so getv "/nginx/port" reads
+// /production/nginx/port under prefix /production. This lets a flat dump of a whole keyspace
+// (an etcd or Consul export keyed by full path) be used as values. Flat keys and the nested
+// objects along the prefix are both re-rooted; everything outside the prefix is left out
+// An empty or root prefix returns values unchanged; values is not modified
+func WithKeyPrefix(values map[string]interface{}, prefix string) map[string]interface{} {
+	segments := splitKey(prefix)
+	if len(segments) == 0 {
+		return values
+	}
+
+	view := make(map[string]interface{})
+	var current interface{} = values
+	for _, segment := range segments {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			current = nil
+			break
+		}
+		current = object[segment]
+	}
+	if object, ok := current.(map[string]interface{}); ok {
+		for key, value := range object {
+			view[key] = value
+		}
+	}
+
+	for key, value := range values {
+		if !isKeyPath(key) {
+			continue
+		}
+		if keySegments := splitKey(key); len(keySegments) > len(segments) && hasPrefix(keySegments, segments) {
+			view[joinKey(keySegments[len(segments):])] = value
+		}
+	}
+	return view
+}
+
+// hasPrefix reports whether segments starts with prefix
+func hasPrefix(segments, prefix []string) bool {
+	if len(segments) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if segments[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// walk calls fn with every key of the store: flat keys as written, and the slash path of every
+// value that is not an object below the other top-level entries
+func (s *KeyStore) walk(fn func(key string, value interface{})) {
+	for key, value := range s.values {
+		if isKeyPath(key) {
+			fn(key, value)
+			continue
+		}
+		walkNested([]string{key}, value, fn)
+	}
+}
+
+func walkNested(segments []string, value interface{}, fn func(key string, value interface{})) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		fn(joinKey(segments), value)
+		return
+	}
+	for key, child := range object {
+		walkNested(append(segments[:len(segments):len(segments)], key), child, fn)
+	}
+}
+
+// splitKey returns the segments of a cleaned key path, none for the root
+func splitKey(key string) []string {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" {
+		return nil
+	}
+	return strings.Split(cleaned[1:], "/")
+}
+
+// joinKey is the inverse of splitKey
+func joinKey(segments []string) string {
+	return "/" + strings.Join(segments, "/")
+}
+
+// isKeyPath reports whether a variable name is a confd key rather than a field path
+func isKeyPath(name string) bool {
+	return strings.HasPrefix(name, "/")
+}
+
+// KeyNode is one segment of the key hierarchy read by a template
+type KeyNode struct {
+	Name string `json:"name"`
+	// Key is the full path of the node, e.g. /myapp/database
+	Key string `json:"key"`
+	// Variable is set when the template reads this key itself, not only keys below it
+	Variable     bool       `json:"variable,omitempty"`
+	DefaultValue string     `json:"defaultValue,omitempty"`
+	Children     []*KeyNode `json:"children,omitempty"`
+}
+
+// BuildKeyTree nests the confd keys among variables by path segment, in order of first use
+// Field variables such as .Name are not keys and are left out
+func BuildKeyTree(variables []VariableInfo) []*KeyNode {
+	root := &KeyNode{}
+	index := map[string]*KeyNode{"": root}
+	for _, v := range AggregateVariables(variables) {
+		if !isKeyPath(v.Name) {
+			continue
+		}
+		segments := splitKey(v.Name)
+		if len(segments) == 0 {
+			continue
+		}
+		node := root
+		for i, segment := range segments {
+			key := joinKey(segments[:i+1])
+			child, ok := index[key]
+			if !ok {
+				child = &KeyNode{Name: segment, Key: key}
+				index[key] = child
+				node.Children = append(node.Children, child)
+			}
+			node = child
+		}
+		node.Variable = true
+		if node.DefaultValue == "" {
+			node.DefaultValue = v.DefaultValue
+		}
+	}
+	if root.Children == nil {
+		return []*KeyNode{}
+	}
+	return root.Children
+}
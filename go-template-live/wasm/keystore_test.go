@@ -0,0 +1,188 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestKeyStore_Get tests flat, cleaned and hierarchical key lookups
+func TestKeyStore_Get(t *testing.T) {
+	store := NewKeyStore(map[string]interface{}{
+		"/flat/key": "flat",
+		"myapp": map[string]interface{}{
+			"database": map[string]interface{}{"host": "db", "port": "5432"},
+		},
+		"name": "plain",
+	})
+
+	tests := []struct {
+		key    string
+		want   interface{}
+		wantOk bool
+	}{
+		{key: "/flat/key", want: "flat", wantOk: true},
+		{key: "flat//key/", want: "flat", wantOk: true},
+		{key: "/myapp/database/host", want: "db", wantOk: true},
+		{key: "/myapp/database", want: map[string]interface{}{"host": "db", "port": "5432"}, wantOk: true},
+		{key: "name", want: "plain", wantOk: true},
+		{key: "/myapp/database/user", wantOk: false},
+		{key: "/myapp/database/host/extra", wantOk: false},
+		{key: "/", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := store.Get(tt.key)
+			if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+// TestKeyStore_GetAll tests pattern matching over flat and hierarchical keys
+func TestKeyStore_GetAll(t *testing.T) {
+	store := NewKeyStore(map[string]interface{}{
+		"/upstreams/b": "10.0.0.2",
+		"upstreams": map[string]interface{}{
+			"a": "10.0.0.1",
+			"c": map[string]interface{}{"port": 8080.0},
+		},
+		"/other/x": "y",
+	})
+
+	pairs, err := store.GetAll("/upstreams/*")
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	expected := []KVPair{{Key: "/upstreams/a", Value: "10.0.0.1"}, {Key: "/upstreams/b", Value: "10.0.0.2"}}
+	if !reflect.DeepEqual(pairs, expected) {
+		t.Errorf("GetAll() = %v, want %v", pairs, expected)
+	}
+
+	values, err := store.GetAllValues("/upstreams/*/port")
+	if err != nil {
+		t.Fatalf("GetAllValues() error = %v", err)
+	}
+	if expected := []string{"8080"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("GetAllValues() = %v, want %v", values, expected)
+	}
+
+	// getvs sorts the values themselves, not by key
+	store = NewKeyStore(map[string]interface{}{"/hosts/a": "web3", "/hosts/b": "web1", "/hosts/c": "web2"})
+	if values, err = store.GetAllValues("/hosts/*"); err != nil || !reflect.DeepEqual(values, []string{"web1", "web2", "web3"}) {
+		t.Errorf("GetAllValues() = %v, %v, want values in value order", values, err)
+	}
+
+	if _, err := store.GetAll("/upstreams/["); err == nil {
+		t.Errorf("GetAll() with a bad pattern error = nil, want error")
+	}
+}
+
+// TestKeyStore_List tests confd's ls and lsdir semantics over flat and hierarchical keys
+func TestKeyStore_List(t *testing.T) {
+	store := NewKeyStore(map[string]interface{}{
+		"/services/web/host": "10.0.0.1",
+		"/services/web/port": "80",
+		"/services/db/host":  "10.0.0.2",
+		"/services/version":  "2",
+		"services":           map[string]interface{}{"cache": map[string]interface{}{"host": "10.0.0.3"}},
+	})
+
+	if got, expected := store.List("/services"), []string{"cache", "db", "version", "web"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("List() = %v, want %v", got, expected)
+	}
+	if got, expected := store.ListDir("/services/"), []string{"cache", "db", "web"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("ListDir() = %v, want %v", got, expected)
+	}
+	if got, expected := store.List("/services/version"), []string{"version"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("List() of a key = %v, want %v", got, expected)
+	}
+	if got := store.ListDir("/missing"); len(got) != 0 {
+		t.Errorf("ListDir() of a missing directory = %v, want none", got)
+	}
+}
+
+// TestWithKeyPrefix tests that flat and nested keys below the prefix are re-rooted and
+// everything else is left out
+func TestWithKeyPrefix(t *testing.T) {
+	values := map[string]interface{}{
+		"/production/nginx/port":   "80",
+		"/production/nginx/worker": "4",
+		"/production":              "the prefix itself",
+		"/productionx/nginx/port":  "81",
+		"/staging/nginx/port":      "8080",
+		"production":               map[string]interface{}{"db": map[string]interface{}{"host": "db"}},
+		"Name":                     "app",
+	}
+
+	view := WithKeyPrefix(values, "production/")
+	expected := map[string]interface{}{
+		"/nginx/port":   "80",
+		"/nginx/worker": "4",
+		"db":            map[string]interface{}{"host": "db"},
+	}
+	if !reflect.DeepEqual(view, expected) {
+		t.Errorf("WithKeyPrefix() = %v, want %v", view, expected)
+	}
+	if _, ok := values["/nginx/port"]; ok {
+		t.Error("WithKeyPrefix() modified values")
+	}
+
+	store := NewKeyStore(view)
+	if got, expected := store.List("/nginx"), []string{"port", "worker"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("List() = %v, want %v", got, expected)
+	}
+	if value, _ := store.Get("/db/host"); value != "db" {
+		t.Errorf("Get(/db/host) = %v, want db", value)
+	}
+
+	if view := WithKeyPrefix(values, "/"); !reflect.DeepEqual(view, values) {
+		t.Errorf("WithKeyPrefix() with the root prefix = %v, want values unchanged", view)
+	}
+}
+
+// TestBuildKeyTree tests that keys nest by segment in order of first use and fields are left out
+func TestBuildKeyTree(t *testing.T) {
+	variables := []VariableInfo{
+		{Name: "/myapp/database/host", DefaultValue: "localhost"},
+		{Name: "Name"},
+		{Name: "/myapp/port"},
+		{Name: "/myapp/database/host"},
+		{Name: "/other"},
+	}
+	expected := []*KeyNode{
+		{Name: "myapp", Key: "/myapp", Children: []*KeyNode{
+			{Name: "database", Key: "/myapp/database", Children: []*KeyNode{
+				{Name: "host", Key: "/myapp/database/host", Variable: true, DefaultValue: "localhost"},
+			}},
+			{Name: "port", Key: "/myapp/port", Variable: true},
+		}},
+		{Name: "other", Key: "/other", Variable: true},
+	}
+	if got := BuildKeyTree(variables); !reflect.DeepEqual(got, expected) {
+		t.Errorf("BuildKeyTree() = %v, want %v", got, expected)
+	}
+	if got := BuildKeyTree([]VariableInfo{{Name: "Name"}}); len(got) != 0 {
+		t.Errorf("BuildKeyTree() without keys = %v, want empty", got)
+	}
+}
+
+// TestValidateValues_HierarchicalKeys tests that nested values satisfy confd keys
+func TestValidateValues_HierarchicalKeys(t *testing.T) {
+	variables := []VariableInfo{{Name: "/myapp/database/host", Path: []string{"myapp", "database", "host"}}}
+	values := map[string]interface{}{
+		"myapp": map[string]interface{}{
+			"database": map[string]interface{}{"host": "db", "user": "app"},
+		},
+	}
+	result := ValidateValues(variables, values)
+	if len(result.Missing) != 0 {
+		t.Errorf("ValidateValues() missing = %v, want none", result.Missing)
+	}
+	if expected := []string{"myapp.database.user"}; !reflect.DeepEqual(result.Unused, expected) {
+		t.Errorf("ValidateValues() unused = %v, want %v", result.Unused, expected)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build !js
+// +build !js
+
+// This file contains linting of template directories on disk for native builds
+// Tag: !js (the browser playground lints the open template with lintTemplate instead)
+// The module builds no native command; LoadLintConfig and LintDir are the library side of a
+// `lint` mode for the embedding tool to expose
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LintFileReport is the lint result of one template file
+type LintFileReport struct {
+	File        string           `json:"file"`
+	Diagnostics []LintDiagnostic `json:"diagnostics"`
+	// Error is set when the template does not parse
+	Error string `json:"error,omitempty"`
+}
+
+// LoadLintConfig reads a lint configuration file written as JSON or YAML
+func LoadLintConfig(name string) (LintConfig, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return LintConfig{}, err
+	}
+	return ParseLintConfig(string(data))
+}
+
+// LintDir lints every template below dir (files ending in .tmpl, .tpl, .gotmpl or .template),
+// returning a report per file in path order; templates that do not parse get a report with an
+// error instead of failing the run
+func (l *Linter) LintDir(dir string) ([]LintFileReport, error) {
+	reports := []LintFileReport{}
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !templateExtensions[filepath.Ext(name)] {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		report := LintFileReport{File: filepath.ToSlash(rel), Diagnostics: []LintDiagnostic{}}
+		if diagnostics, err := l.Lint(report.File, string(content)); err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Diagnostics = diagnostics
+		}
+		reports = append(reports, report)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading template directory: %v", err)
+	}
+	return reports, nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// Lint rules run by Linter
+const (
+	LintUnusedVariable     = "unused-variable"
+	LintGetvNoDefault      = "getv-no-default"
+	LintDeprecatedFunction = "deprecated-function"
+	LintNestingDepth       = "nesting-depth"
+	LintDuplicateKey       = "duplicate-key"
+	LintLiteralSecret      = "literal-secret"
+)
+
+// LintSeverityOff disables a rule in LintConfig.Rules
+const LintSeverityOff = "off"
+
+// lintRuleSeverities are the rules with their default severities
+var lintRuleSeverities = map[string]string{
+	LintUnusedVariable:     SeverityWarning,
+	LintGetvNoDefault:      SeverityWarning,
+	LintDeprecatedFunction: SeverityWarning,
+	LintNestingDepth:       SeverityInfo,
+	LintDuplicateKey:       SeverityError,
+	LintLiteralSecret:      SeverityError,
+}
+
+// deprecatedFunctions maps functions kept only for compatibility to their replacement
+var deprecatedFunctions = map[string]string{
+	"trimall": "trimAll",
+}
+
+// LintConfig selects the lint rules and their severities
+type LintConfig struct {
+	// Rules maps rule names to "off", "error", "warning" or "info"; unlisted rules run with their
+	// default severity
+	Rules map[string]string `json:"rules,omitempty"`
+	// MaxNesting is the deepest nesting of if, range and with allowed by nesting-depth (0 for 4)
+	MaxNesting int `json:"maxNesting,omitempty"`
+	// Deprecated adds functions reported by deprecated-function, mapped to their replacement
+	// ("" when there is none)
+	Deprecated map[string]string `json:"deprecated,omitempty"`
+}
+
+// ParseLintConfig decodes a lint configuration written as JSON or YAML
+func ParseLintConfig(data string) (LintConfig, error) {
+	var config LintConfig
+	values, err := ParseValues(data)
+	if err != nil {
+		return config, fmt.Errorf("invalid lint config: %v", err)
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return config, fmt.Errorf("invalid lint config: %v", err)
+	}
+	if err := json.Unmarshal(encoded, &config); err != nil {
+		return config, fmt.Errorf("invalid lint config: %v", err)
+	}
+	return config, nil
+}
+
+// LintDiagnostic is one problem found by a lint rule
+type LintDiagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity string   `json:"severity"`
+	Message  string   `json:"message"`
+	Position Position `json:"position"`
+}
+
+// Linter runs configurable rules over the parse tree of templates
+// Templates are parsed without function checks, so those written for any profile can be linted
+type Linter struct {
+	severities map[string]string
+	maxNesting int
+	deprecated map[string]string
+}
+
+// NewLinter creates a linter, checking the rule names and severities of config
+func NewLinter(config LintConfig) (*Linter, error) {
+	l := &Linter{
+		severities: make(map[string]string, len(lintRuleSeverities)),
+		maxNesting: config.MaxNesting,
+		deprecated: make(map[string]string, len(deprecatedFunctions)+len(config.Deprecated)),
+	}
+	for rule, severity := range lintRuleSeverities {
+		l.severities[rule] = severity
+	}
+	for rule, severity := range config.Rules {
+		if _, ok := lintRuleSeverities[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q", rule)
+		}
+		switch severity {
+		case LintSeverityOff, SeverityError, SeverityWarning, SeverityInfo:
+		default:
+			return nil, fmt.Errorf("unknown severity %q for lint rule %s, expected one of off, error, warning, info", severity, rule)
+		}
+		l.severities[rule] = severity
+	}
+	if l.maxNesting <= 0 {
+		l.maxNesting = reviewMaxNesting
+	}
+	for name, replacement := range deprecatedFunctions {
+		l.deprecated[name] = replacement
+	}
+	for name, replacement := range config.Deprecated {
+		l.deprecated[name] = replacement
+	}
+	return l, nil
+}
+
+// Lint parses a template and returns the diagnostics of the enabled rules in document order
+func (l *Linter) Lint(fileName, fileContent string) ([]LintDiagnostic, error) {
+	tree := parse.New(fileName)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(fileContent, "", "", treeSet); err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
+	}
+
+	run := &lintRun{linter: l, diagnostics: []LintDiagnostic{}}
+	var roots []*parse.ListNode
+	for _, t := range treeSet {
+		if t.Root != nil {
+			roots = append(roots, t.Root)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Pos < roots[j].Pos })
+
+	for _, root := range roots {
+		scope := &lintScope{declared: make(map[string]parse.Pos), used: make(map[string]bool)}
+		run.walk(root, 0, nil, scope)
+		run.reportUnused(scope)
+	}
+
+	index := newLineIndex(fileContent)
+	for i := range run.diagnostics {
+		index.resolve(fileContent, &run.diagnostics[i].Position)
+	}
+	sort.SliceStable(run.diagnostics, func(i, j int) bool {
+		return run.diagnostics[i].Position.Offset < run.diagnostics[j].Position.Offset
+	})
+	return run.diagnostics, nil
+}
+
+// lintRun collects the diagnostics of one Lint call
+type lintRun struct {
+	linter      *Linter
+	diagnostics []LintDiagnostic
+}
+
+// lintScope tracks the $variables declared and used in one template of the file
+type lintScope struct {
+	declared map[string]parse.Pos
+	order    []string
+	used     map[string]bool
+}
+
+// report records a diagnostic of an enabled rule
+func (r *lintRun) report(rule string, pos parse.Pos, length int, format string, args ...interface{}) {
+	severity := r.linter.severities[rule]
+	if severity == LintSeverityOff {
+		return
+	}
+	r.diagnostics = append(r.diagnostics, LintDiagnostic{
+		Rule:     rule,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Position: Position{Offset: int(pos), Length: length},
+	})
+}
+
+// walk visits node with the nesting depth of if, range and with around it and the keys the
+// enclosing {{if exists ...}} conditions guard
+func (r *lintRun) walk(node parse.Node, nesting int, guarded []string, scope *lintScope) {
+	switch node := node.(type) {
+	case *parse.TextNode:
+		r.checkSecretText(node)
+	case *parse.IfNode:
+		bodyGuarded := append(append([]string(nil), guarded...), existsKeys(node.Pipe)...)
+		r.walkBranch(node.Pos, &node.BranchNode, "if", nesting, bodyGuarded, guarded, scope)
+	case *parse.WithNode:
+		r.walkBranch(node.Pos, &node.BranchNode, "with", nesting, guarded, guarded, scope)
+	case *parse.RangeNode:
+		r.walkBranch(node.Pos, &node.BranchNode, "range", nesting, guarded, guarded, scope)
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, decl := range node.Decl {
+			name := decl.Ident[0]
+			if _, ok := scope.declared[name]; !ok && !node.IsAssign {
+				scope.declared[name] = decl.Pos
+				scope.order = append(scope.order, name)
+			}
+		}
+		for _, cmd := range node.Cmds {
+			r.walk(cmd, nesting, guarded, scope)
+		}
+	case *parse.CommandNode:
+		r.checkCall(node, guarded)
+		for _, arg := range node.Args {
+			r.walk(arg, nesting, guarded, scope)
+		}
+	case *parse.VariableNode:
+		scope.used[node.Ident[0]] = true
+	case *parse.StringNode:
+		r.checkSecretLiteral(node)
+	default:
+		for _, child := range childNodes(node) {
+			r.walk(child, nesting, guarded, scope)
+		}
+	}
+}
+
+// walkBranch visits the condition and bodies of an if, with or range; the body of an if is
+// walked with the keys its condition checks with exists
+func (r *lintRun) walkBranch(pos parse.Pos, branch *parse.BranchNode, keyword string, nesting int, bodyGuarded, guarded []string, scope *lintScope) {
+	nesting++
+	if nesting == r.linter.maxNesting+1 {
+		r.report(LintNestingDepth, pos, 0, "%s nested %d levels deep (limit %d); extract the block into a {{define}} template", keyword, nesting, r.linter.maxNesting)
+	}
+	r.walk(branch.Pipe, nesting-1, guarded, scope)
+	r.walk(branch.List, nesting, bodyGuarded, scope)
+	r.walk(branch.ElseList, nesting, guarded, scope)
+}
+
+// reportUnused reports the $variables of a template that are declared but never read
+func (r *lintRun) reportUnused(scope *lintScope) {
+	for _, name := range scope.order {
+		if !scope.used[name] {
+			r.report(LintUnusedVariable, scope.declared[name], len(name), "%s is declared but never used", name)
+		}
+	}
+}
+
+// existsKeys returns the literal keys an if condition checks with exists
+func existsKeys(pipe *parse.PipeNode) []string {
+	var keys []string
+	if pipe == nil {
+		return keys
+	}
+	for _, cmd := range pipe.Cmds {
+		for i, arg := range cmd.Args {
+			switch arg := arg.(type) {
+			case *parse.IdentifierNode:
+				if arg.Ident == "exists" && i+1 < len(cmd.Args) {
+					if key, ok := cmd.Args[i+1].(*parse.StringNode); ok {
+						keys = append(keys, key.Text)
+					}
+				}
+			case *parse.PipeNode:
+				keys = append(keys, existsKeys(arg)...)
+			}
+		}
+	}
+	return keys
+}
+
+// checkCall applies the rules about function calls: getv without a default, deprecated
+// functions and duplicate dict keys
+func (r *lintRun) checkCall(cmd *parse.CommandNode, guarded []string) {
+	if len(cmd.Args) == 0 {
+		return
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return
+	}
+
+	if replacement, ok := r.linter.deprecated[ident.Ident]; ok {
+		if replacement != "" {
+			r.report(LintDeprecatedFunction, ident.Pos, len(ident.Ident), "%s is deprecated, use %s", ident.Ident, replacement)
+		} else {
+			r.report(LintDeprecatedFunction, ident.Pos, len(ident.Ident), "%s is deprecated", ident.Ident)
+		}
+	}
+
+	switch ident.Ident {
+	case "getv":
+		if len(cmd.Args) != 2 {
+			return
+		}
+		key, ok := cmd.Args[1].(*parse.StringNode)
+		if !ok || containsString(guarded, key.Text) {
+			return
+		}
+		r.report(LintGetvNoDefault, ident.Pos, len(ident.Ident), "getv %s has no default, so the render fails when the key is missing; add a default or guard it with {{if exists %s}}", key.Quoted, key.Quoted)
+	case "dict":
+		seen := make(map[string]bool)
+		for i := 1; i < len(cmd.Args); i += 2 {
+			key, ok := cmd.Args[i].(*parse.StringNode)
+			if !ok {
+				continue
+			}
+			if seen[key.Text] {
+				r.report(LintDuplicateKey, key.Pos, len(key.Quoted), "dict key %s is given twice; the last value wins", key.Quoted)
+			}
+			seen[key.Text] = true
+		}
+	}
+}
+
+// secretPatterns match credentials with a recognizable shape
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"AWS access key", regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+}
+
+// secretAssignment matches a literal value given to a credential-like setting in config text,
+// such as password: hunter22 or api_key = "abc123"
+var secretAssignment = regexp.MustCompile(`(?i)\b(password|passwd|secret|token|api[_-]?key|access[_-]?key)\b["']?\s*[:=]\s*["']?([^\s"'#;]{4,})`)
+
+// checkSecretLiteral reports string literals that look like credentials
+func (r *lintRun) checkSecretLiteral(node *parse.StringNode) {
+	for _, p := range secretPatterns {
+		if p.pattern.MatchString(node.Text) {
+			r.report(LintLiteralSecret, node.Pos, len(node.Quoted), "string literal looks like a %s; read it from a value with secret instead", p.kind)
+			return
+		}
+	}
+}
+
+// checkSecretText reports credentials written into the static text of a template
+func (r *lintRun) checkSecretText(node *parse.TextNode) {
+	text := string(node.Text)
+	for _, p := range secretPatterns {
+		if loc := p.pattern.FindStringIndex(text); loc != nil {
+			r.report(LintLiteralSecret, node.Pos+parse.Pos(loc[0]), loc[1]-loc[0], "text contains what looks like a %s; read it from a value with secret instead", p.kind)
+			return
+		}
+	}
+	for _, match := range secretAssignment.FindAllStringSubmatchIndex(text, -1) {
+		value := text[match[4]:match[5]]
+		if isPlaceholderValue(value) {
+			continue
+		}
+		name := text[match[2]:match[3]]
+		r.report(LintLiteralSecret, node.Pos+parse.Pos(match[4]), len(value), "%s is set to a literal value; read it from a value with secret instead", strconv.Quote(name))
+	}
+}
+
+// isPlaceholderValue reports values that stand for a credential rather than being one, such as
+// changeme, <password>, ${DB_PASSWORD} or xxxx
+func isPlaceholderValue(value string) bool {
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "$"), strings.HasPrefix(lower, "<"), strings.HasPrefix(lower, "%"):
+		return true
+	case lower == "changeme" || lower == "none" || lower == "null" || lower == "true" || lower == "false":
+		return true
+	case strings.Trim(lower, "x*.") == "":
+		return true
+	}
+	return false
+}
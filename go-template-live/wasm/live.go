@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LiveUpdate is what the subscriber of a live template receives after an update re-renders it
+type LiveUpdate struct {
+	ID string `json:"id"`
+	SessionUpdate
+	// Output is the complete new output; Regions tell which byte runs of the previous one changed
+	Output string `json:"output"`
+	// Diff is the line diff from the previous output to Output
+	Diff []DiffLine `json:"diff"`
+	// Error is set when the update failed to render; the template keeps its previous values and output
+	Error string `json:"error,omitempty"`
+}
+
+// LiveTemplates is the set of live templates of an editor: each registered template is kept
+// rendered, and variable patches re-render it incrementally (see RenderSession) and notify its
+// subscriber with the new output and a diff
+// It is not safe for concurrent use
+type LiveTemplates struct {
+	parser   *Parser
+	renderer *Renderer
+	nextID   int
+	live     map[string]*liveTemplate
+}
+
+// liveTemplate is one registered template with the function notified of its updates
+type liveTemplate struct {
+	session *RenderSession
+	notify  func(*LiveUpdate)
+}
+
+// NewLiveTemplates creates an empty set of live templates rendering with renderer
+func NewLiveTemplates(parser *Parser, renderer *Renderer) *LiveTemplates {
+	return &LiveTemplates{
+		parser:   parser,
+		renderer: renderer,
+		live:     make(map[string]*liveTemplate),
+	}
+}
+
+// Register renders a template with the initial values and subscribes notify to its updates
+// It returns the subscription id, passed to UpdateVariables and Unregister, and the first render
+func (l *LiveTemplates) Register(content string, values map[string]interface{}, opts RenderOptions, notify func(*LiveUpdate)) (string, *RenderResult, error) {
+	l.nextID++
+	id := "live-" + strconv.Itoa(l.nextID)
+	session, result, err := NewRenderSession(l.parser, l.renderer, id, content, values, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	l.live[id] = &liveTemplate{session: session, notify: notify}
+	return id, result, nil
+}
+
+// UpdateVariables applies a patch of variable changes to a live template, in the form taken by
+// RenderSession.Update (dotted paths reach nested objects, a nil value removes a key)
+// The subscriber is notified when the output was re-rendered or rendering failed; patches of
+// variables the template does not read only advance the revision. The update is also returned
+func (l *LiveTemplates) UpdateVariables(id string, patch map[string]interface{}) (*LiveUpdate, error) {
+	live, ok := l.live[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown live template %q", id)
+	}
+
+	previous := live.session.Output()
+	update := &LiveUpdate{ID: id}
+	sessionUpdate, err := live.session.Update(patch)
+	if err != nil {
+		update.Revision = live.session.Revision()
+		update.Regions = []OutputRegion{}
+		update.Output = previous
+		update.Diff = []DiffLine{}
+		update.Error = err.Error()
+	} else {
+		update.SessionUpdate = *sessionUpdate
+		update.Output = live.session.Output()
+		update.Diff = []DiffLine{}
+		if update.Rendered {
+			update.Diff = DiffLines(previous, update.Output)
+		}
+	}
+
+	if live.notify != nil && (update.Rendered || update.Error != "") {
+		live.notify(update)
+	}
+	return update, nil
+}
+
+// Unregister drops a live template, reporting whether it was registered
+func (l *LiveTemplates) Unregister(id string) bool {
+	_, ok := l.live[id]
+	delete(l.live, id)
+	return ok
+}
@@ -0,0 +1,63 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestLiveTemplates tests that patches notify the subscriber with the output and a diff, and
+// that patches the template does not read do not
+func TestLiveTemplates(t *testing.T) {
+	registry := NewFunctionRegistry()
+	live := NewLiveTemplates(NewParser(registry), NewRenderer(registry, nil))
+
+	var updates []*LiveUpdate
+	id, result, err := live.Register("name: {{.Name}}\nport: {{.Port}}\n", map[string]interface{}{"Name": "app", "Port": 80},
+		RenderOptions{MissingKey: MissingKeyError}, func(update *LiveUpdate) { updates = append(updates, update) })
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if id != "live-1" || result.Output != "name: app\nport: 80\n" {
+		t.Fatalf("Register() = %q, %q", id, result.Output)
+	}
+
+	update, err := live.UpdateVariables(id, map[string]interface{}{"Port": 81})
+	if err != nil {
+		t.Fatalf("UpdateVariables() error = %v", err)
+	}
+	expectedDiff := []DiffLine{
+		{Op: DiffEqual, Text: "name: app"},
+		{Op: DiffDelete, Text: "port: 80"},
+		{Op: DiffInsert, Text: "port: 81"},
+	}
+	if update.Output != "name: app\nport: 81\n" || update.Revision != 1 || !reflect.DeepEqual(update.Diff, expectedDiff) {
+		t.Errorf("UpdateVariables() = %+v, want the new output with diff %+v", update, expectedDiff)
+	}
+	if len(updates) != 1 || updates[0] != update {
+		t.Errorf("subscriber got %d updates, want the returned one", len(updates))
+	}
+
+	if update, err = live.UpdateVariables(id, map[string]interface{}{"Unused": true}); err != nil || update.Rendered {
+		t.Errorf("UpdateVariables() of an unused value = %+v, %v, want no render", update, err)
+	}
+	if len(updates) != 1 {
+		t.Errorf("subscriber notified of an update that did not render")
+	}
+
+	if update, err = live.UpdateVariables(id, map[string]interface{}{"Name": nil}); err != nil || update.Error == "" {
+		t.Errorf("UpdateVariables() removing a required key = %+v, %v, want a render error", update, err)
+	}
+	if len(updates) != 2 || updates[1].Output != "name: app\nport: 81\n" {
+		t.Errorf("subscriber not notified of the failed render with the previous output")
+	}
+
+	if !live.Unregister(id) || live.Unregister(id) {
+		t.Errorf("Unregister() should succeed once")
+	}
+	if _, err := live.UpdateVariables(id, map[string]interface{}{"Port": 82}); err == nil {
+		t.Errorf("UpdateVariables() of an unregistered template succeeded")
+	}
+}
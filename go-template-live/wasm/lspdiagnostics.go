@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// LSP DiagnosticSeverity values
+const (
+	LSPSeverityError       = 1
+	LSPSeverityWarning     = 2
+	LSPSeverityInformation = 3
+	LSPSeverityHint        = 4
+)
+
+// Sources of the diagnostics returned by Analyze, one per stage
+const (
+	DiagnosticSourceParse   = "parse"
+	DiagnosticSourceExtract = "extract"
+	DiagnosticSourceLint    = "lint"
+	DiagnosticSourceValues  = "values"
+	DiagnosticSourceRender  = "render"
+)
+
+// LSPPosition is a zero-based line and UTF-16 character offset, as in the Language Server
+// Protocol
+type LSPPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// LSPRange is a range of a document, its end exclusive
+type LSPRange struct {
+	Start LSPPosition `json:"start"`
+	End   LSPPosition `json:"end"`
+}
+
+// LSPDiagnostic is a problem in the shape of the Language Server Protocol Diagnostic, as
+// consumed by Monaco and CodeMirror integrations
+type LSPDiagnostic struct {
+	Range    LSPRange `json:"range"`
+	Severity int      `json:"severity"`
+	// Code is the lint rule, or the kind of problem of the other stages
+	Code    string `json:"code,omitempty"`
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+// AnalyzeOptions selects the checks of Analyze
+type AnalyzeOptions struct {
+	// Lint configures the lint rules; nil runs them with their default severities
+	Lint *LintConfig `json:"lint,omitempty"`
+	// Values, when set, are checked against the variables of the template and rendered with
+	// RenderOptions, reporting missing values, type mismatches and render errors
+	Values        map[string]interface{} `json:"values,omitempty"`
+	RenderOptions RenderOptions          `json:"renderOptions,omitempty"`
+}
+
+// renderErrorLocation matches the file:line:column a text/template error starts with
+var renderErrorLocation = regexp.MustCompile(`template: [^\s:]+:(\d+)(?::(\d+))?: (.*)$`)
+
+// Analyze runs the parser, variable extraction, linter and, with values, values validation and
+// rendering over a template, returning every problem found as LSP diagnostics in document
+// order. Lint and render checks are skipped for a template with syntax errors
+func Analyze(parser *Parser, renderer *Renderer, fileName, fileContent string, opts AnalyzeOptions) ([]LSPDiagnostic, error) {
+	config := LintConfig{}
+	if opts.Lint != nil {
+		config = *opts.Lint
+	}
+	linter, err := NewLinter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &analysis{content: fileContent, lines: newLineIndex(fileContent), diagnostics: []LSPDiagnostic{}}
+
+	// Syntax errors, reported once by the parser rather than again by extraction
+	syntaxMessage := ""
+	if _, err := template.New(fileName).Funcs(parser.registry.GetMinimalFuncMap()).Parse(fileContent); err != nil {
+		line, message := syntaxErrorLine(fileName, err)
+		syntaxMessage = message
+		position := linePosition(fileContent, line)
+		if position == nil {
+			position = &Position{}
+		}
+		a.add(*position, LSPSeverityError, "syntax", DiagnosticSourceParse, message)
+	}
+
+	extractOpts := DefaultExtractOptions()
+	extractOpts.ErrorPolicy = ErrorPolicyCollectAll
+	variables, err := parser.ExtractVariablesWithOptions(fileName, fileContent, extractOpts)
+	var extractionErrors *ExtractionErrors
+	if errors.As(err, &extractionErrors) {
+		for _, e := range extractionErrors.Errors {
+			if syntaxMessage != "" && (e.Message == syntaxMessage || e.Position == nil) {
+				continue
+			}
+			position := Position{}
+			if e.Position != nil {
+				position = *e.Position
+			}
+			a.add(position, LSPSeverityError, "extract-error", DiagnosticSourceExtract, e.Message)
+		}
+	}
+
+	if syntaxMessage == "" {
+		if diagnostics, err := linter.Lint(fileName, fileContent); err == nil {
+			for _, d := range diagnostics {
+				a.add(d.Position, lspSeverity(d.Severity), d.Rule, DiagnosticSourceLint, d.Message)
+			}
+		}
+		if opts.Values != nil {
+			a.checkValues(renderer, variables, opts)
+		}
+	}
+
+	sort.SliceStable(a.diagnostics, func(i, j int) bool {
+		x, y := a.diagnostics[i].Range.Start, a.diagnostics[j].Range.Start
+		if x.Line != y.Line {
+			return x.Line < y.Line
+		}
+		return x.Character < y.Character
+	})
+	return a.diagnostics, nil
+}
+
+// analysis collects the diagnostics of one Analyze call
+type analysis struct {
+	content     string
+	lines       lineIndex
+	diagnostics []LSPDiagnostic
+}
+
+// add records a diagnostic spanning a template position
+func (a *analysis) add(position Position, severity int, code, source, message string) {
+	a.diagnostics = append(a.diagnostics, LSPDiagnostic{
+		Range: LSPRange{
+			Start: a.lspPosition(position.Offset),
+			End:   a.lspPosition(position.Offset + position.Length),
+		},
+		Severity: severity,
+		Code:     code,
+		Source:   source,
+		Message:  message,
+	})
+}
+
+// lspPosition converts a byte offset into a zero-based line and UTF-16 character
+func (a *analysis) lspPosition(offset int) LSPPosition {
+	if offset > len(a.content) {
+		offset = len(a.content)
+	}
+	line := sort.Search(len(a.lines), func(j int) bool { return a.lines[j] > offset }) - 1
+	character := 0
+	for _, r := range a.content[a.lines[line]:offset] {
+		if r >= 0x10000 {
+			character += 2
+		} else {
+			character++
+		}
+	}
+	return LSPPosition{Line: line, Character: character}
+}
+
+// checkValues reports values missing or of the wrong type at the first use of their variable,
+// and the error of rendering with them at the action that failed
+func (a *analysis) checkValues(renderer *Renderer, variables []VariableInfo, opts AnalyzeOptions) {
+	firstUse := func(name string) Position {
+		for _, v := range variables {
+			if v.Name == name && v.Position != nil {
+				return *v.Position
+			}
+		}
+		return Position{}
+	}
+
+	validation := ValidateValues(variables, opts.Values)
+	for _, name := range validation.Missing {
+		a.add(firstUse(name), LSPSeverityError, "missing-value", DiagnosticSourceValues, "no value for "+name)
+	}
+	for _, m := range validation.Mismatches {
+		a.add(firstUse(m.Name), LSPSeverityWarning, "type-mismatch", DiagnosticSourceValues,
+			m.Name+" is used as "+m.Expected+" but the value is "+m.Actual)
+	}
+
+	if _, err := renderer.Render(a.content, opts.Values, opts.RenderOptions); err != nil {
+		a.add(a.renderErrorPosition(err.Error()), LSPSeverityError, "render-error", DiagnosticSourceRender, err.Error())
+	}
+}
+
+// renderErrorPosition locates a text/template error by its line and byte column, spanning to
+// the end of that line; errors without a location are put at the start of the template
+func (a *analysis) renderErrorPosition(message string) Position {
+	match := renderErrorLocation.FindStringSubmatch(message)
+	if match == nil {
+		return Position{}
+	}
+	line, _ := strconv.Atoi(match[1])
+	if line < 1 || line > len(a.lines) {
+		return Position{}
+	}
+	start := a.lines[line-1]
+	end := len(a.content)
+	if line < len(a.lines) {
+		end = a.lines[line] - 1
+	}
+	if match[2] != "" {
+		column, _ := strconv.Atoi(match[2])
+		if start+column <= end {
+			start += column
+		}
+	}
+	// Keep the range on whole characters
+	for start < end && !utf8.RuneStart(a.content[start]) {
+		start++
+	}
+	return Position{Offset: start, Length: len(strings.TrimRight(a.content[start:end], "\r"))}
+}
+
+// lspSeverity maps lint severities to LSP severities
+func lspSeverity(severity string) int {
+	switch severity {
+	case SeverityError:
+		return LSPSeverityError
+	case SeverityWarning:
+		return LSPSeverityWarning
+	}
+	return LSPSeverityInformation
+}
@@ -0,0 +1,66 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// diagnosticSummaries returns source/code@line:character of each diagnostic
+func diagnosticSummaries(diagnostics []LSPDiagnostic) []string {
+	summaries := make([]string, len(diagnostics))
+	for i, d := range diagnostics {
+		summaries[i] = fmt.Sprintf("%s/%s@%d:%d", d.Source, d.Code, d.Range.Start.Line, d.Range.Start.Character)
+	}
+	return summaries
+}
+
+func TestAnalyze(t *testing.T) {
+	registry := NewFunctionRegistry()
+	parser, renderer := NewParser(registry), NewRenderer(registry, nil)
+	template := `name: {{.Name}}
+{{$unused := 1}}port: {{.Port | printf "%d"}}
+héllo {{index .Items 5}}
+`
+	diagnostics, err := Analyze(parser, renderer, "app.tmpl", template, AnalyzeOptions{
+		Values: map[string]interface{}{"Port": 8080, "Items": []interface{}{"a"}},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	want := []string{
+		"values/missing-value@0:8",
+		"lint/unused-variable@1:2",
+		// Columns count UTF-16 units: é is one, though two bytes
+		"render/render-error@2:8",
+	}
+	if got := diagnosticSummaries(diagnostics); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %v, want %v", got, want)
+	}
+	if diagnostics[1].Severity != LSPSeverityWarning || diagnostics[1].Range.End.Character != 9 {
+		t.Errorf("lint diagnostic = %+v, want a warning on $unused", diagnostics[1])
+	}
+}
+
+func TestAnalyze_SyntaxError(t *testing.T) {
+	registry := NewFunctionRegistry()
+	parser, renderer := NewParser(registry), NewRenderer(registry, nil)
+	template := "ok {{.A}}\n{{if .B}}{{end}}\n{{.C )}}\n"
+	diagnostics, err := Analyze(parser, renderer, "app.tmpl", template, AnalyzeOptions{
+		Lint:   &LintConfig{Rules: map[string]string{LintUnusedVariable: LintSeverityOff}},
+		Values: map[string]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Source != DiagnosticSourceParse || diagnostics[0].Range.Start.Line != 2 || diagnostics[0].Severity != LSPSeverityError {
+		t.Errorf("diagnostics = %+v, want only the syntax error on line 3", diagnostics)
+	}
+
+	if _, err := Analyze(parser, renderer, "app.tmpl", template, AnalyzeOptions{Lint: &LintConfig{Rules: map[string]string{"nope": "error"}}}); err == nil {
+		t.Error("Analyze() accepted an unknown lint rule")
+	}
+}
@@ -3,7 +3,10 @@
 
 package main
 
-import ()
+import (
+	// Embed the IANA timezone database; browsers provide no zoneinfo files for time.LoadLocation
+	_ "time/tzdata"
+)
 
 // registerCallbacks registers the Go functions to be called from JavaScript
 func registerCallbacks() {
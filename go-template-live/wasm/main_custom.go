@@ -15,8 +15,8 @@ func init() {
 
 // CreateRenderFuncMap creates function map with actual variable values for rendering custom functions
 // This delegates to GetCustomRenderFuncMap from functions_custom_core.go
-func CreateRenderFuncMap(variables map[string]interface{}) map[string]interface{} {
-	funcMap := GetCustomRenderFuncMap(variables)
+func CreateRenderFuncMap(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+	funcMap := GetCustomRenderFuncMap(variables, env)
 	// Convert template.FuncMap to map[string]interface{}
 	result := make(map[string]interface{}, len(funcMap))
 	for k, v := range funcMap {
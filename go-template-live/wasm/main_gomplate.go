@@ -0,0 +1,25 @@
+//go:build js && gomplate
+// +build js,gomplate
+
+// This file contains WASM-specific wiring for gomplate functions
+// The actual implementations are in functions_gomplate.go
+
+package main
+
+func init() {
+	// Register gomplate functions on initialization
+	// This only happens when building WASM with the "js && gomplate" tags
+	registerGomplateFunctions()
+}
+
+// CreateRenderFuncMap creates function map with actual variable values for rendering gomplate functions
+// This delegates to GetGomplateRenderFuncMap from functions_gomplate.go
+func CreateRenderFuncMap(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+	funcMap := GetGomplateRenderFuncMap(variables, env)
+	// Convert template.FuncMap to map[string]interface{}
+	result := make(map[string]interface{}, len(funcMap))
+	for k, v := range funcMap {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,25 @@
+//go:build js && helm
+// +build js,helm
+
+// This file contains WASM-specific wiring for helm functions
+// The actual implementations are in functions_helm.go
+
+package main
+
+func init() {
+	// Register helm functions on initialization
+	// This only happens when building WASM with the "js && helm" tags
+	registerHelmFunctions()
+}
+
+// CreateRenderFuncMap creates function map with actual variable values for rendering helm functions
+// This delegates to GetHelmRenderFuncMap from functions_helm.go
+func CreateRenderFuncMap(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+	funcMap := GetHelmRenderFuncMap(variables, env)
+	// Convert template.FuncMap to map[string]interface{}
+	result := make(map[string]interface{}, len(funcMap))
+	for k, v := range funcMap {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,25 @@
+//go:build js && sprig
+// +build js,sprig
+
+// This file contains WASM-specific wiring for sprig functions
+// The actual implementations are in functions_sprig.go
+
+package main
+
+func init() {
+	// Register sprig functions on initialization
+	// This only happens when building WASM with the "js && sprig" tags
+	registerSprigFunctions()
+}
+
+// CreateRenderFuncMap creates function map with actual variable values for rendering sprig functions
+// This delegates to GetSprigRenderFuncMap from functions_sprig.go
+func CreateRenderFuncMap(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+	funcMap := GetSprigRenderFuncMap(variables, env)
+	// Convert template.FuncMap to map[string]interface{}
+	result := make(map[string]interface{}, len(funcMap))
+	for k, v := range funcMap {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package main
+
+// MaskedValue replaces sensitive values in masked previews
+const MaskedValue = "****"
+
+// maskSensitive returns variables with the value of every sensitive variable replaced by
+// MaskedValue, along with the names masked; the caller's map is not modified
+// Variables without a value are left alone, so defaults written in the template still show
+func (r *Renderer) maskSensitive(templateContent string, variables map[string]interface{}) (map[string]interface{}, []string, error) {
+	extracted, err := r.parser().ExtractVariablesWithPositions("template", templateContent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	masked := variables
+	var names []string
+	for _, v := range AggregateVariables(extracted) {
+		if !v.Sensitive {
+			continue
+		}
+		if _, ok := lookupValue(masked, v.Name); !ok {
+			continue
+		}
+		if len(names) == 0 {
+			masked = copyValues(variables)
+		}
+		if setValue(masked, v.Name, MaskedValue) {
+			names = append(names, v.Name)
+		}
+	}
+	return masked, names, nil
+}
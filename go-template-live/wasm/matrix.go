@@ -0,0 +1,86 @@
+package main
+
+import "fmt"
+
+// maxMatrixCells bounds the combinations of a matrix render, which grow as the product of the
+// dimension sizes
+const maxMatrixCells = 10000
+
+// MatrixDimension is one variable varied by a matrix render
+type MatrixDimension struct {
+	// Name is a top-level key, a dotted path into nested objects or a confd key
+	Name   string        `json:"name"`
+	Values []interface{} `json:"values"`
+}
+
+// MatrixCell is the render outcome of one combination of dimension values
+type MatrixCell struct {
+	// Values maps each dimension name to the value substituted for it
+	Values      map[string]interface{} `json:"values"`
+	Output      string                 `json:"output"`
+	MissingKeys []string               `json:"missingKeys,omitempty"`
+	Warnings    []string               `json:"warnings,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+// RenderMatrix renders a template for every combination of dimension values, each set over the
+// base values, e.g. regions × instance types for per-node configs
+// Cells come in row-major order, the last dimension varying fastest; every cell is rendered
+// even when others fail, failures being recorded in the cell. It fails when a dimension has no
+// values, a name repeats or there are more than maxMatrixCells combinations
+func (r *Renderer) RenderMatrix(templateContent string, base map[string]interface{}, dimensions []MatrixDimension, opts RenderOptions) ([]MatrixCell, error) {
+	total := 1
+	seen := make(map[string]bool, len(dimensions))
+	for _, dimension := range dimensions {
+		if dimension.Name == "" {
+			return nil, fmt.Errorf("matrix dimension without a name")
+		}
+		if seen[dimension.Name] {
+			return nil, fmt.Errorf("matrix dimension %s is given twice", dimension.Name)
+		}
+		seen[dimension.Name] = true
+		if len(dimension.Values) == 0 {
+			return nil, fmt.Errorf("matrix dimension %s has no values", dimension.Name)
+		}
+		total *= len(dimension.Values)
+		if total > maxMatrixCells {
+			return nil, fmt.Errorf("matrix has more than %d combinations", maxMatrixCells)
+		}
+	}
+
+	cells := make([]MatrixCell, 0, total)
+	indexes := make([]int, len(dimensions))
+	for {
+		values := copyValues(base)
+		cell := MatrixCell{Values: make(map[string]interface{}, len(dimensions))}
+		for i, dimension := range dimensions {
+			value := dimension.Values[indexes[i]]
+			cell.Values[dimension.Name] = value
+			setValuePath(values, dimension.Name, value)
+		}
+
+		rendered, err := r.Render(templateContent, values, opts)
+		if rendered != nil {
+			cell.Output = rendered.Output
+			cell.MissingKeys = rendered.MissingKeys
+			cell.Warnings = rendered.Warnings
+		}
+		if err != nil {
+			cell.Error = err.Error()
+		}
+		cells = append(cells, cell)
+
+		// Advance the indexes like an odometer, the last dimension first
+		i := len(indexes) - 1
+		for ; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(dimensions[i].Values) {
+				break
+			}
+			indexes[i] = 0
+		}
+		if i < 0 {
+			return cells, nil
+		}
+	}
+}
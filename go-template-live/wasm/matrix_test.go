@@ -0,0 +1,75 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRenderMatrix tests that every combination is rendered in row-major order with the
+// dimension values set over the base values
+func TestRenderMatrix(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	base := map[string]interface{}{
+		"app":  "web",
+		"node": map[string]interface{}{"type": "t3.micro", "disk": 20},
+	}
+	dimensions := []MatrixDimension{
+		{Name: "region", Values: []interface{}{"eu", "us"}},
+		{Name: "node.type", Values: []interface{}{"m5", "c5", "r5"}},
+	}
+
+	cells, err := renderer.RenderMatrix("{{.app}}-{{.region}}-{{.node.type}}-{{.node.disk}}", base, dimensions, RenderOptions{})
+	if err != nil {
+		t.Fatalf("RenderMatrix() error = %v", err)
+	}
+
+	var outputs []string
+	for _, cell := range cells {
+		outputs = append(outputs, cell.Output)
+	}
+	expected := []string{
+		"web-eu-m5-20", "web-eu-c5-20", "web-eu-r5-20",
+		"web-us-m5-20", "web-us-c5-20", "web-us-r5-20",
+	}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Errorf("RenderMatrix() outputs = %v, want %v", outputs, expected)
+	}
+	if values := map[string]interface{}{"region": "us", "node.type": "c5"}; !reflect.DeepEqual(cells[4].Values, values) {
+		t.Errorf("RenderMatrix() cell values = %v, want %v", cells[4].Values, values)
+	}
+	if nodeType := base["node"].(map[string]interface{})["type"]; nodeType != "t3.micro" {
+		t.Errorf("RenderMatrix() modified the base values, node.type = %v", nodeType)
+	}
+
+	cells, err = renderer.RenderMatrix("{{.app}}", base, nil, RenderOptions{})
+	if err != nil || len(cells) != 1 || cells[0].Output != "web" {
+		t.Errorf("RenderMatrix() without dimensions = %+v, %v, want one cell", cells, err)
+	}
+}
+
+func TestRenderMatrix_Errors(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	many := make([]interface{}, 101)
+	tests := []struct {
+		name       string
+		dimensions []MatrixDimension
+		expected   string
+	}{
+		{name: "empty", dimensions: []MatrixDimension{{Name: "a"}}, expected: "a has no values"},
+		{name: "duplicate", dimensions: []MatrixDimension{{Name: "a", Values: many[:1]}, {Name: "a", Values: many[:1]}}, expected: "a is given twice"},
+		{name: "too large", dimensions: []MatrixDimension{{Name: "a", Values: many}, {Name: "b", Values: many}}, expected: "more than 10000 combinations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderer.RenderMatrix("", nil, tt.dimensions, RenderOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("RenderMatrix() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
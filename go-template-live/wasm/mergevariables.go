@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Merge strategies accepted by MergeOptions.Strategy
+const (
+	// MergeStrategyDeep merges nested objects key by key (the default)
+	MergeStrategyDeep = "deep"
+	// MergeStrategyOverride replaces each top-level value of an earlier layer as a whole
+	MergeStrategyOverride = "override"
+	// MergeStrategyError merges like deep, but fails when two layers give a key different values
+	MergeStrategyError = "error"
+)
+
+// List merge modes accepted by MergeOptions.Lists
+const (
+	ListMergeReplace = "replace"
+	ListMergeAppend  = "append"
+)
+
+// MergeOptions controls how MergeVariables combines layers
+type MergeOptions struct {
+	// Strategy is "deep" (default), "override" or "error"
+	Strategy string `json:"strategy,omitempty"`
+	// Lists is "replace" (default), a later list replacing the earlier one, or "append",
+	// a later list being added to the end of the earlier one
+	Lists string `json:"lists,omitempty"`
+}
+
+// validate checks the strategy and list mode
+func (o MergeOptions) validate() error {
+	switch o.Strategy {
+	case "", MergeStrategyDeep, MergeStrategyOverride, MergeStrategyError:
+	default:
+		return fmt.Errorf("unknown merge strategy %q, expected one of deep, override, error", o.Strategy)
+	}
+	switch o.Lists {
+	case "", ListMergeReplace, ListMergeAppend:
+	default:
+		return fmt.Errorf("unknown list merge mode %q, expected one of replace, append", o.Lists)
+	}
+	return nil
+}
+
+// VariableLayer is one named set of values of a layered merge, such as defaults, a profile or an
+// environment overlay
+type VariableLayer struct {
+	Name   string                 `json:"name"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// MergedVariables is the outcome of MergeVariables
+type MergedVariables struct {
+	Values map[string]interface{} `json:"values"`
+	// Sources maps the dotted path of every leaf value (objects are descended, lists are leaves)
+	// to the name of the layer it came from; an appended list is attributed to the last layer
+	// that added to it
+	Sources map[string]string `json:"sources"`
+}
+
+// MergeVariables merges layers in order, later layers winning, and reports which layer each
+// value came from. A null value removes the key in every strategy (with "error" only when no
+// earlier layer set it). Layers without a name are called "layer N". The layers are not modified
+func MergeVariables(layers []VariableLayer, opts MergeOptions) (*MergedVariables, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	m := &variableMerger{opts: opts, sources: map[string]string{}}
+	merged := map[string]interface{}{}
+	for i, layer := range layers {
+		m.layer = layer.Name
+		if m.layer == "" {
+			m.layer = fmt.Sprintf("layer %d", i+1)
+		}
+		var err error
+		if merged, err = m.merge(merged, layer.Values, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &MergedVariables{Values: merged, Sources: m.sources}, nil
+}
+
+// variableMerger carries the options and the source report through a layered merge
+type variableMerger struct {
+	opts    MergeOptions
+	layer   string
+	sources map[string]string
+}
+
+// merge returns dst with src merged over it, copying the objects it changes
+func (m *variableMerger) merge(dst, src map[string]interface{}, prefix string) (map[string]interface{}, error) {
+	merged := copyValues(dst)
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := src[key]
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		existing, exists := merged[key]
+
+		if value == nil {
+			if exists && m.opts.Strategy == MergeStrategyError {
+				return nil, m.conflict(path)
+			}
+			delete(merged, key)
+			m.forget(path)
+			continue
+		}
+
+		object, isObject := value.(map[string]interface{})
+		existingObject, existingIsObject := existing.(map[string]interface{})
+		list, isList := value.([]interface{})
+		existingList, existingIsList := existing.([]interface{})
+		switch {
+		case isObject && existingIsObject && !(m.opts.Strategy == MergeStrategyOverride && prefix == ""):
+			descended, err := m.merge(existingObject, object, path)
+			if err != nil {
+				return nil, err
+			}
+			merged[key] = descended
+		case isList && existingIsList && m.opts.Lists == ListMergeAppend:
+			merged[key] = append(append(make([]interface{}, 0, len(existingList)+len(list)), existingList...), list...)
+			m.sources[path] = m.layer
+		default:
+			if exists && m.opts.Strategy == MergeStrategyError && !reflect.DeepEqual(existing, value) {
+				return nil, m.conflict(path)
+			}
+			m.forget(path)
+			if !isObject {
+				merged[key] = value
+				m.sources[path] = m.layer
+				continue
+			}
+			copied, err := m.merge(map[string]interface{}{}, object, path)
+			if err != nil {
+				return nil, err
+			}
+			merged[key] = copied
+			if len(copied) == 0 {
+				m.sources[path] = m.layer
+			}
+		}
+	}
+	return merged, nil
+}
+
+// forget drops the sources of a path and every path below it
+func (m *variableMerger) forget(path string) {
+	for source := range m.sources {
+		if source == path || strings.HasPrefix(source, path+".") {
+			delete(m.sources, source)
+		}
+	}
+}
+
+// conflict describes a key the current layer gives a value different from an earlier layer's
+func (m *variableMerger) conflict(path string) error {
+	var earlier []string
+	for source, layer := range m.sources {
+		if (source == path || strings.HasPrefix(source, path+".")) && layer != m.layer {
+			earlier = append(earlier, layer)
+		}
+	}
+	sort.Strings(earlier)
+	if len(earlier) == 0 {
+		return fmt.Errorf("conflicting values for %s in layer %q", path, m.layer)
+	}
+	return fmt.Errorf("conflicting values for %s in layers %q and %q", path, earlier[0], m.layer)
+}
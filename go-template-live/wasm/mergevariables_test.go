@@ -0,0 +1,135 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeVariables_Strategies(t *testing.T) {
+	layers := []VariableLayer{
+		{Name: "defaults", Values: map[string]interface{}{
+			"image": map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+			"hosts": []interface{}{"a"},
+			"debug": true,
+		}},
+		{Name: "prod", Values: map[string]interface{}{
+			"image": map[string]interface{}{"tag": "1.27"},
+			"hosts": []interface{}{"b"},
+			"debug": nil,
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		opts    MergeOptions
+		values  map[string]interface{}
+		sources map[string]string
+	}{
+		{
+			name: "deep",
+			values: map[string]interface{}{
+				"image": map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+				"hosts": []interface{}{"b"},
+			},
+			sources: map[string]string{"image.repository": "defaults", "image.tag": "prod", "hosts": "prod"},
+		},
+		{
+			name: "override",
+			opts: MergeOptions{Strategy: MergeStrategyOverride},
+			values: map[string]interface{}{
+				"image": map[string]interface{}{"tag": "1.27"},
+				"hosts": []interface{}{"b"},
+			},
+			sources: map[string]string{"image.tag": "prod", "hosts": "prod"},
+		},
+		{
+			name: "append lists",
+			opts: MergeOptions{Lists: ListMergeAppend},
+			values: map[string]interface{}{
+				"image": map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+				"hosts": []interface{}{"a", "b"},
+			},
+			sources: map[string]string{"image.repository": "defaults", "image.tag": "prod", "hosts": "prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeVariables(layers, tt.opts)
+			if err != nil {
+				t.Fatalf("MergeVariables() error = %v", err)
+			}
+			if !reflect.DeepEqual(merged.Values, tt.values) {
+				t.Errorf("Values = %#v, want %#v", merged.Values, tt.values)
+			}
+			if !reflect.DeepEqual(merged.Sources, tt.sources) {
+				t.Errorf("Sources = %v, want %v", merged.Sources, tt.sources)
+			}
+		})
+	}
+
+	if layers[0].Values["image"].(map[string]interface{})["tag"] != "1.25" {
+		t.Error("MergeVariables() modified a layer")
+	}
+}
+
+func TestMergeVariables_ErrorOnConflict(t *testing.T) {
+	opts := MergeOptions{Strategy: MergeStrategyError}
+	base := VariableLayer{Name: "base", Values: map[string]interface{}{
+		"server": map[string]interface{}{"host": "localhost", "port": 80},
+	}}
+
+	merged, err := MergeVariables([]VariableLayer{base, {Name: "tls", Values: map[string]interface{}{
+		"server": map[string]interface{}{"port": 80, "tls": true},
+	}}}, opts)
+	if err != nil {
+		t.Fatalf("MergeVariables() error = %v", err)
+	}
+	if merged.Sources["server.host"] != "base" || merged.Sources["server.tls"] != "tls" {
+		t.Errorf("Sources = %v", merged.Sources)
+	}
+
+	_, err = MergeVariables([]VariableLayer{base, {Values: map[string]interface{}{
+		"server": map[string]interface{}{"port": 443},
+	}}}, opts)
+	if err == nil || !strings.Contains(err.Error(), `server.port in layers "base" and "layer 2"`) {
+		t.Errorf("MergeVariables() error = %v, want a conflict on server.port", err)
+	}
+
+	if _, err := MergeVariables(nil, MergeOptions{Strategy: "shallow"}); err == nil {
+		t.Error("MergeVariables() accepted an unknown strategy")
+	}
+}
+
+func TestMergeVariables_DefaultsAndProfiles(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	defaults, err := renderer.DefaultValues("{{/* @var Port type=number default=8080 */}}{{/* @var Region default=eu */}}{{.Name}}:{{.Port}} {{.Region}}")
+	if err != nil {
+		t.Fatalf("DefaultValues() error = %v", err)
+	}
+
+	profiles := NewValueProfiles()
+	profiles.Save("base", map[string]interface{}{"Name": "web"})
+	profiles.Save("prod", map[string]interface{}{"Port": 443})
+	fromProfiles, err := profiles.Merge([]string{"base", "prod"}, MergeOptions{})
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	merged, err := MergeVariables([]VariableLayer{{Name: "defaults", Values: defaults}, {Name: "profiles", Values: fromProfiles.Values}}, MergeOptions{})
+	if err != nil {
+		t.Fatalf("MergeVariables() error = %v", err)
+	}
+	want := map[string]string{"Name": "profiles", "Port": "profiles", "Region": "defaults"}
+	if !reflect.DeepEqual(merged.Sources, want) {
+		t.Errorf("Sources = %v, want %v", merged.Sources, want)
+	}
+
+	if _, err := profiles.Merge([]string{"base", "staging"}, MergeOptions{}); err == nil {
+		t.Error("Merge() accepted an unknown profile")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Metadata annotations, written in template comments:
+//
+//	{{/* @owner alice
+//	     @team team-payments
+//	     @tags billing, critical */}}
+const (
+	annotationOwner = "@owner"
+	annotationTeam  = "@team"
+	annotationTags  = "@tags"
+)
+
+// TemplateMetadata is the ownership information a template declares about itself
+type TemplateMetadata struct {
+	Owner string   `json:"owner,omitempty"`
+	Team  string   `json:"team,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// hasMetadataAnnotations reports whether content may declare metadata, to skip the comment scan
+func hasMetadataAnnotations(content string) bool {
+	return strings.Contains(content, annotationOwner) ||
+		strings.Contains(content, annotationTeam) ||
+		strings.Contains(content, annotationTags)
+}
+
+// ExtractMetadata returns the @owner, @team and @tags annotations of a template
+// A later @owner or @team replaces an earlier one; @tags values accumulate, separated by commas
+// or spaces, keeping the first occurrence of each tag
+func (p *Parser) ExtractMetadata(fileName, fileContent string) (*TemplateMetadata, error) {
+	metadata := &TemplateMetadata{}
+	if !hasMetadataAnnotations(fileContent) {
+		return metadata, nil
+	}
+
+	lines, err := commentLines(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		for _, a := range splitAnnotations(line.text) {
+			if a.value == "" {
+				return nil, fmt.Errorf("invalid %s annotation in %s at line %d: missing value", a.name, fileName, line.line)
+			}
+			switch a.name {
+			case annotationOwner:
+				metadata.Owner = a.value
+			case annotationTeam:
+				metadata.Team = a.value
+			case annotationTags:
+				for _, tag := range strings.FieldsFunc(a.value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+					if !containsString(metadata.Tags, tag) {
+						metadata.Tags = append(metadata.Tags, tag)
+					}
+				}
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// annotation is one metadata annotation and its value
+type annotation struct {
+	name  string
+	value string
+}
+
+// splitAnnotations returns the metadata annotations of a comment line
+// Several may share a line ("@team payments @owner alice"); lines not starting with one are
+// ignored, so an annotation name inside other text such as a @var description is not picked up
+func splitAnnotations(line string) []annotation {
+	var annotations []annotation
+	for _, field := range strings.Fields(line) {
+		switch field {
+		case annotationOwner, annotationTeam, annotationTags:
+			annotations = append(annotations, annotation{name: field})
+			continue
+		}
+		if len(annotations) == 0 {
+			return nil
+		}
+		last := &annotations[len(annotations)-1]
+		if last.value != "" {
+			last.value += " "
+		}
+		last.value += field
+	}
+	return annotations
+}
+
+// HasTag reports whether the template is tagged with tag
+func (m *TemplateMetadata) HasTag(tag string) bool {
+	return containsString(m.Tags, tag)
+}
@@ -0,0 +1,68 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	tests := []struct {
+		name     string
+		template string
+		expected *TemplateMetadata
+		errMsg   string
+	}{
+		{
+			name:     "no annotations",
+			template: "{{/* plain comment */}}{{.Name}}",
+			expected: &TemplateMetadata{},
+		},
+		{
+			name: "owner, team and tags",
+			template: `{{/*
+  @owner alice
+  @team team-payments
+  @tags billing, critical
+*/}}{{if .A}}{{/* @tags critical pci */}}{{end}}`,
+			expected: &TemplateMetadata{Owner: "alice", Team: "team-payments", Tags: []string{"billing", "critical", "pci"}},
+		},
+		{
+			name:     "later owner wins",
+			template: "{{/* @owner alice */}}{{/* @owner bob */}}",
+			expected: &TemplateMetadata{Owner: "bob"},
+		},
+		{
+			name:     "annotation names inside other text",
+			template: `{{/* @var Email description="mail of the @owner" */}}{{/* ask @team first */}}{{.Email}}`,
+			expected: &TemplateMetadata{},
+		},
+		{
+			name:     "missing value",
+			template: "{{.A}}\n{{/* @team */}}",
+			errMsg:   "invalid @team annotation in test.tmpl at line 2: missing value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.ExtractMetadata("test.tmpl", tt.template)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("ExtractMetadata() error = %v, want %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ExtractMetadata() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
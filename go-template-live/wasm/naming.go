@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// NamingRules are conventions for variable names, checked by LintNames
+type NamingRules struct {
+	// SnakeCase requires every segment of a name to be snake_case, e.g. /myapp/db_host or db.max_conns
+	SnakeCase bool `json:"snakeCase,omitempty"`
+	// MaxDepth limits the number of segments of slash-path keys such as /myapp/db/host (0 for no limit)
+	MaxDepth int `json:"maxDepth,omitempty"`
+	// ReservedPrefixes are name prefixes templates must not use, such as /_internal/
+	ReservedPrefixes []string `json:"reservedPrefixes,omitempty"`
+}
+
+// Naming rules reported by NamingViolation.Rule
+const (
+	NamingRuleSnakeCase      = "snake-case"
+	NamingRuleMaxDepth       = "max-depth"
+	NamingRuleReservedPrefix = "reserved-prefix"
+)
+
+// NamingViolation is a variable occurrence breaking a naming rule
+type NamingViolation struct {
+	Name     string    `json:"name"`
+	Rule     string    `json:"rule"`
+	Message  string    `json:"message"`
+	Position *Position `json:"position,omitempty"`
+	// Fix is the canonical name FixNames rewrites the occurrence to; empty when the rule
+	// cannot be fixed automatically
+	Fix string `json:"fix,omitempty"`
+}
+
+// NamingFix is a template with its variable references renamed to their canonical names
+type NamingFix struct {
+	Content string `json:"content"`
+	// Mapping maps each renamed variable to its new name, for migrating values files and stores
+	Mapping map[string]string `json:"mapping"`
+}
+
+// snakeCaseSegment matches a name segment in snake_case, allowing a leading _ for private keys
+var snakeCaseSegment = regexp.MustCompile(`^_*[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// validate checks the rules themselves
+func (r NamingRules) validate() error {
+	if r.MaxDepth < 0 {
+		return fmt.Errorf("maxDepth must not be negative, got %d", r.MaxDepth)
+	}
+	for _, prefix := range r.ReservedPrefixes {
+		if prefix == "" {
+			return fmt.Errorf("reserved prefixes must not be empty")
+		}
+	}
+	return nil
+}
+
+// LintNames checks every variable occurrence against rules, in document order
+func (p *Parser) LintNames(fileName, fileContent string, rules NamingRules) ([]NamingViolation, error) {
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	variables, err := p.ExtractVariablesWithPositions(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(variables, func(i, j int) bool {
+		return variableOffset(variables[i]) < variableOffset(variables[j])
+	})
+
+	violations := []NamingViolation{}
+	for _, v := range variables {
+		violations = append(violations, rules.check(v)...)
+	}
+	return violations, nil
+}
+
+// check returns the violations of one occurrence
+func (r NamingRules) check(v VariableInfo) []NamingViolation {
+	var violations []NamingViolation
+	for _, prefix := range r.ReservedPrefixes {
+		if strings.HasPrefix(v.Name, prefix) {
+			violations = append(violations, NamingViolation{
+				Name: v.Name, Rule: NamingRuleReservedPrefix, Position: v.Position,
+				Message: fmt.Sprintf("%s uses the reserved prefix %s", v.Name, prefix),
+			})
+			break
+		}
+	}
+	if r.MaxDepth > 0 && isKeyPath(v.Name) {
+		if depth := len(splitKey(v.Name)); depth > r.MaxDepth {
+			violations = append(violations, NamingViolation{
+				Name: v.Name, Rule: NamingRuleMaxDepth, Position: v.Position,
+				Message: fmt.Sprintf("%s is %d levels deep, more than the limit of %d", v.Name, depth, r.MaxDepth),
+			})
+		}
+	}
+	if r.SnakeCase {
+		if canonical := snakeCaseName(v.Name); canonical != v.Name {
+			violations = append(violations, NamingViolation{
+				Name: v.Name, Rule: NamingRuleSnakeCase, Position: v.Position, Fix: canonical,
+				Message: fmt.Sprintf("%s is not snake_case, expected %s", v.Name, canonical),
+			})
+		}
+	}
+	return violations
+}
+
+// FixNames rewrites the references of the variables breaking fixable rules (currently
+// snake-case) to their canonical names
+// Only occurrences found in the template source are rewritten: string keys such as
+// getv "/myApp/dbHost" and fields such as .DbHost; names in included files are left alone
+func (p *Parser) FixNames(fileName, fileContent string, rules NamingRules) (*NamingFix, error) {
+	violations, err := p.LintNames(fileName, fileContent, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	fix := &NamingFix{Mapping: make(map[string]string)}
+	var b strings.Builder
+	last := 0
+	for _, violation := range violations {
+		if violation.Fix == "" || violation.Position == nil || violation.Position.Offset < last {
+			continue
+		}
+		start, end := violation.Position.Offset, violation.Position.Offset+violation.Position.Length
+		if end > len(fileContent) {
+			continue
+		}
+		replacement, ok := renameReference(fileContent[start:end], violation.Name)
+		if !ok {
+			continue
+		}
+		b.WriteString(fileContent[last:start])
+		b.WriteString(replacement)
+		last = end
+		fix.Mapping[violation.Name] = violation.Fix
+	}
+	b.WriteString(fileContent[last:])
+	fix.Content = b.String()
+
+	// The rewrite must still parse, e.g. a field renamed to start with a digit would not
+	if _, err := p.parseTemplate(context.Background(), fileName, fix.Content); err != nil {
+		return nil, fmt.Errorf("renamed template does not parse: %v", err)
+	}
+	return fix, nil
+}
+
+// renameReference rewrites the source text of one reference to name: a quoted key or a field chain
+func renameReference(text, name string) (string, bool) {
+	if strings.HasPrefix(text, ".") {
+		// A field chain; name may carry the prefix of an enclosing {{template}} invocation
+		if !strings.HasSuffix(name, text[1:]) {
+			return "", false
+		}
+		return snakeCaseName(text), true
+	}
+	return requoteKey(text, name, snakeCaseName(name))
+}
+
+// requoteKey replaces the string literal text, which must hold name, with a literal of newName
+// in the same quoting style
+func requoteKey(text, name, newName string) (string, bool) {
+	unquoted, err := strconv.Unquote(text)
+	if err != nil || unquoted != name {
+		return "", false
+	}
+	if strings.HasPrefix(text, "`") && !strings.Contains(newName, "`") {
+		return "`" + newName + "`", true
+	}
+	return strconv.Quote(newName), true
+}
+
+// snakeCaseName converts every segment of a slash-path key or dotted field name to snake_case,
+// keeping separators and * wildcards: /myApp/DBHost becomes /my_app/db_host
+func snakeCaseName(name string) string {
+	separator := "."
+	if strings.Contains(name, "/") {
+		separator = "/"
+	}
+	segments := strings.Split(name, separator)
+	for i, segment := range segments {
+		if segment == "" || segment == "*" || snakeCaseSegment.MatchString(segment) {
+			continue
+		}
+		segments[i] = snakeCase(segment)
+	}
+	return strings.Join(segments, separator)
+}
+
+// snakeCase converts one segment: camelCase, PascalCase, acronyms and - or space separators
+func snakeCase(segment string) string {
+	runes := []rune(segment)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case unicode.IsUpper(r):
+			if i > 0 && b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
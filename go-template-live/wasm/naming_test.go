@@ -0,0 +1,91 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"text/template/parse"
+)
+
+func newNamingTestParser() *Parser {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:    "getv",
+		Handler: func(key string, v ...string) string { return "" },
+		ExtractorWithDefaults: func(args []parse.Node, cycle int) ([]VariableInfo, error) {
+			return extractStringArgVariableWithDefaults(args, cycle, 1, 2)
+		},
+	})
+	return NewParser(registry)
+}
+
+// TestSnakeCaseName tests the canonical form of slash keys and field names
+func TestSnakeCaseName(t *testing.T) {
+	tests := map[string]string{
+		"/myApp/dbHost":     "/my_app/db_host",
+		"/myapp/DBHost":     "/myapp/db_host",
+		"/my-app/db host":   "/my_app/db_host",
+		"/apps/*/HTTPPort2": "/apps/*/http_port2",
+		"Database.MaxConns": "database.max_conns",
+		"db_host":           "db_host",
+	}
+	for name, expected := range tests {
+		if got := snakeCaseName(name); got != expected {
+			t.Errorf("snakeCaseName(%q) = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+// TestParser_LintNames tests the naming rules on each variable occurrence
+func TestParser_LintNames(t *testing.T) {
+	parser := newNamingTestParser()
+	template := "{{getv \"/myApp/dbHost\"}}\n{{getv \"/_internal/token\"}}\n{{getv \"/a/b/c/d/e\"}}\n{{.Port}}"
+	rules := NamingRules{SnakeCase: true, MaxDepth: 4, ReservedPrefixes: []string{"/_internal/"}}
+
+	violations, err := parser.LintNames("test.tmpl", template, rules)
+	if err != nil {
+		t.Fatalf("LintNames() error = %v", err)
+	}
+	type found struct{ name, rule, fix string }
+	var got []found
+	for _, v := range violations {
+		got = append(got, found{v.Name, v.Rule, v.Fix})
+	}
+	expected := []found{
+		{"/myApp/dbHost", NamingRuleSnakeCase, "/my_app/db_host"},
+		{"/_internal/token", NamingRuleReservedPrefix, ""},
+		{"/a/b/c/d/e", NamingRuleMaxDepth, ""},
+		{"Port", NamingRuleSnakeCase, "port"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("LintNames() = %+v, want %+v", got, expected)
+	}
+	if violations[0].Position == nil || violations[0].Position.Line != 1 {
+		t.Errorf("LintNames() position = %+v, want line 1", violations[0].Position)
+	}
+
+	if _, err := parser.LintNames("test.tmpl", template, NamingRules{MaxDepth: -1}); err == nil {
+		t.Errorf("LintNames() with a negative depth succeeded")
+	}
+}
+
+// TestParser_FixNames tests rewriting references and the emitted mapping
+func TestParser_FixNames(t *testing.T) {
+	parser := newNamingTestParser()
+	template := "host={{getv \"/myApp/dbHost\" \"localhost\"}}\nagain={{getv `/myApp/dbHost`}}\n{{.Server.maxConns}} {{.port}}"
+
+	fix, err := parser.FixNames("test.tmpl", template, NamingRules{SnakeCase: true})
+	if err != nil {
+		t.Fatalf("FixNames() error = %v", err)
+	}
+	expected := "host={{getv \"/my_app/db_host\" \"localhost\"}}\nagain={{getv `/my_app/db_host`}}\n{{.server.max_conns}} {{.port}}"
+	if fix.Content != expected {
+		t.Errorf("FixNames() content = %q, want %q", fix.Content, expected)
+	}
+	expectedMapping := map[string]string{"/myApp/dbHost": "/my_app/db_host", "Server.maxConns": "server.max_conns"}
+	if !reflect.DeepEqual(fix.Mapping, expectedMapping) {
+		t.Errorf("FixNames() mapping = %v, want %v", fix.Mapping, expectedMapping)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats accepted by RenderOptions.OutputValidation
+const (
+	// OutputValidationAuto validates the detected syntax of the output when it is one of the
+	// validated formats, and nothing otherwise
+	OutputValidationAuto = "auto"
+	OutputValidationJSON = "json"
+	OutputValidationYAML = "yaml"
+	OutputValidationTOML = "toml"
+	OutputValidationINI  = "ini"
+	OutputValidationXML  = "xml"
+)
+
+// OutputError is a syntax error of rendered output in the format it was validated as
+type OutputError struct {
+	// Line is the 1-based output line of the error; Column is 1-based and 0 when the parser
+	// of the format does not report one
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// validateOutputValidation checks a RenderOptions.OutputValidation value
+func validateOutputValidation(format string) error {
+	switch format {
+	case "", OutputValidationAuto, OutputValidationJSON, OutputValidationYAML, OutputValidationTOML, OutputValidationINI, OutputValidationXML:
+		return nil
+	}
+	return fmt.Errorf("unknown output validation %q, expected one of auto, json, yaml, toml, ini, xml", format)
+}
+
+// outputValidationFormat resolves auto to the format of the detected syntax, or "" when the
+// detected syntax is not one that can be validated
+func outputValidationFormat(format string, syntax SyntaxDetection) string {
+	if format != OutputValidationAuto {
+		return format
+	}
+	switch syntax.Format {
+	case SyntaxJSON:
+		return OutputValidationJSON
+	case SyntaxYAML:
+		return OutputValidationYAML
+	case SyntaxINI:
+		return OutputValidationINI
+	case SyntaxTOML:
+		return OutputValidationTOML
+	case SyntaxXML:
+		return OutputValidationXML
+	}
+	return ""
+}
+
+// ValidateOutput parses output as format (json, yaml, toml, ini or xml) and returns its syntax
+// errors, none when it is valid. Parsers stop at the first error, so at most one is returned
+func ValidateOutput(format, output string) []OutputError {
+	var err *OutputError
+	switch format {
+	case OutputValidationJSON:
+		err = validateJSONOutput(output)
+	case OutputValidationYAML:
+		err = validateYAMLOutput(output)
+	case OutputValidationTOML:
+		err = validateTOMLOutput(output)
+	case OutputValidationINI:
+		err = validateINIOutput(output)
+	case OutputValidationXML:
+		err = validateXMLOutput(output)
+	}
+	if err == nil {
+		return nil
+	}
+	return []OutputError{*err}
+}
+
+// validateJSONOutput requires output to be a single JSON value
+func validateJSONOutput(output string) *OutputError {
+	var value interface{}
+	err := json.Unmarshal([]byte(output), &value)
+	if err == nil {
+		return nil
+	}
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return &OutputError{Line: 1, Message: err.Error()}
+	}
+	line, column := offsetPosition(output, int(syntaxErr.Offset))
+	return &OutputError{Line: line, Column: column, Message: syntaxErr.Error()}
+}
+
+// offsetPosition returns the 1-based line and column of the byte before offset, where JSON
+// syntax errors point
+func offsetPosition(text string, offset int) (int, int) {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	if offset > 0 {
+		offset--
+	}
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := offset - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+// lineErrorPattern matches the "line N: message" errors of the YAML parser
+var lineErrorPattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// lineError converts a parser error carrying its line in the message; errors without one are
+// put on line 1
+func lineError(err error) *OutputError {
+	if match := lineErrorPattern.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return &OutputError{Line: line, Message: match[2]}
+	}
+	return &OutputError{Line: 1, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+}
+
+// validateYAMLOutput requires every document of output to be well-formed YAML
+func validateYAMLOutput(output string) *OutputError {
+	decoder := yaml.NewDecoder(strings.NewReader(output))
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return lineError(err)
+		}
+	}
+}
+
+// tomlErrorPrefix is the "toml: line N (last key ...): " prefix of TOML parse errors
+var tomlErrorPrefix = regexp.MustCompile(`^toml: line \d+(?: \(last key "[^"]*"\))?: `)
+
+// validateTOMLOutput requires output to be a TOML v1.0 document
+func validateTOMLOutput(output string) *OutputError {
+	var document map[string]interface{}
+	_, err := toml.Decode(output, &document)
+	if err == nil {
+		return nil
+	}
+	var parseErr toml.ParseError
+	if !errors.As(err, &parseErr) {
+		return &OutputError{Line: 1, Message: err.Error()}
+	}
+	line, column := offsetPosition(output, parseErr.Position.Start+1)
+	if line != parseErr.Position.Line {
+		column = 0
+	}
+	return &OutputError{Line: parseErr.Position.Line, Column: column, Message: tomlErrorPrefix.ReplaceAllString(parseErr.Error(), "")}
+}
+
+// validateINIOutput requires every line of output to be blank, a ; or # comment, a [section]
+// header, a key = value or key: value pair, or an indented continuation of the previous value
+func validateINIOutput(output string) *OutputError {
+	inValue := false
+	for i, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			inValue = false
+		case trimmed[0] == ';' || trimmed[0] == '#':
+		case trimmed[0] == '[':
+			if !strings.HasSuffix(trimmed, "]") {
+				return &OutputError{Line: i + 1, Column: strings.Index(line, "[") + 1, Message: "unterminated section header"}
+			}
+			if strings.TrimSpace(trimmed[1:len(trimmed)-1]) == "" {
+				return &OutputError{Line: i + 1, Column: strings.Index(line, "[") + 1, Message: "empty section name"}
+			}
+			inValue = false
+		case inValue && (line[0] == ' ' || line[0] == '\t'):
+		default:
+			separator := strings.IndexAny(line, "=:")
+			if separator < 0 {
+				return &OutputError{Line: i + 1, Column: 1, Message: fmt.Sprintf("expected key = value or [section], found %q", trimmed)}
+			}
+			if strings.TrimSpace(line[:separator]) == "" {
+				return &OutputError{Line: i + 1, Column: separator + 1, Message: "missing key before " + string(line[separator])}
+			}
+			inValue = true
+		}
+	}
+	return nil
+}
+
+// validateXMLOutput requires output to be a well-formed XML document with a single root element
+func validateXMLOutput(output string) *OutputError {
+	decoder := xml.NewDecoder(strings.NewReader(output))
+	depth, roots := 0, 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				return &OutputError{Line: syntaxErr.Line, Message: syntaxErr.Msg}
+			}
+			line, column := decoder.InputPos()
+			return &OutputError{Line: line, Column: column, Message: err.Error()}
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+				if roots > 1 {
+					line, _ := decoder.InputPos()
+					return &OutputError{Line: line, Message: fmt.Sprintf("second root element <%s>", token.Name.Local)}
+				}
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(strings.TrimSpace(string(token))) > 0 {
+				line, _ := decoder.InputPos()
+				return &OutputError{Line: line, Message: "text outside the root element"}
+			}
+		}
+	}
+	if roots == 0 {
+		return &OutputError{Line: strings.Count(output, "\n") + 1, Message: "no root element"}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		output string
+		want   []OutputError
+	}{
+		{"valid json", OutputValidationJSON, "{\n  \"port\": 80\n}\n", nil},
+		{"json trailing comma", OutputValidationJSON, "{\n  \"port\": 80,\n}\n", []OutputError{{Line: 3, Column: 1, Message: "invalid character '}' looking for beginning of object key string"}}},
+		{"valid yaml", OutputValidationYAML, "server:\n  port: 80\n---\nother: true\n", nil},
+		{"yaml bad indent", OutputValidationYAML, "server:\n  port: 80\n host: x\n", []OutputError{{Line: 2, Message: "did not find expected key"}}},
+		{"valid toml", OutputValidationTOML, "[server]\nport = 80\n", nil},
+		{"toml duplicate key", OutputValidationTOML, "[server]\nport = 80\nport = 81\n", []OutputError{{Line: 3, Column: 1, Message: "Key 'server.port' has already been defined."}}},
+		{"toml array tables", OutputValidationTOML, "[[servers]]\nname = \"a\"\n\n[[servers]]\nname = \"b\"\n", nil},
+		{"toml inline table", OutputValidationTOML, "point = { x = 1, y = 2 }\n", nil},
+		{"toml dotted keys", OutputValidationTOML, "server.port = 80\nserver.host = \"web\"\n", nil},
+		{"toml datetimes", OutputValidationTOML, "created = 1979-05-27T07:32:00Z\nday = 1979-05-27\nat = 07:32:00\n", nil},
+		{"toml open array", OutputValidationTOML, "ports = [80,\n81\n", []OutputError{{Line: 2, Column: 3, Message: "expected a comma (',') or array terminator (']'), but got end of file"}}},
+		{"valid ini", OutputValidationINI, "; comment\n[server]\nport = 80\nhosts =\n  a\n  b\n", nil},
+		{"ini stray line", OutputValidationINI, "[server]\nport 80\n", []OutputError{{Line: 2, Column: 1, Message: `expected key = value or [section], found "port 80"`}}},
+		{"ini open section", OutputValidationINI, "[server\n", []OutputError{{Line: 1, Column: 1, Message: "unterminated section header"}}},
+		{"valid xml", OutputValidationXML, "<?xml version=\"1.0\"?>\n<server>\n  <port>80</port>\n</server>\n", nil},
+		{"xml mismatched tag", OutputValidationXML, "<server>\n  <port>80</prt>\n</server>\n", []OutputError{{Line: 2, Message: "element <port> closed by </prt>"}}},
+		{"xml two roots", OutputValidationXML, "<a/>\n<b/>\n", []OutputError{{Line: 2, Message: "second root element <b>"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateOutput(tt.format, tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_OutputValidation(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	template := "{\n  \"name\": {{.Name}}\n}\n"
+
+	result, err := renderer.Render(template, map[string]interface{}{"Name": "web"}, RenderOptions{FileName: "app.json.tmpl", OutputValidation: OutputValidationAuto})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(result.OutputErrors) != 1 || result.OutputErrors[0].Line != 2 {
+		t.Errorf("OutputErrors = %+v, want one error on line 2", result.OutputErrors)
+	}
+
+	result, err = renderer.Render(template, map[string]interface{}{"Name": `"web"`}, RenderOptions{OutputValidation: OutputValidationJSON})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.OutputErrors != nil {
+		t.Errorf("OutputErrors = %+v, want none", result.OutputErrors)
+	}
+
+	for _, tt := range []struct {
+		fileName, output string
+		line             int
+	}{
+		{"config.toml.tmpl", "[[servers]]\nname = {{.Name}}\n", 2},
+		{"config.xml.tmpl", "<server>{{.Name}}</srv>\n", 1},
+	} {
+		result, err = renderer.Render(tt.output, map[string]interface{}{"Name": "web"}, RenderOptions{FileName: tt.fileName, OutputValidation: OutputValidationAuto})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		if len(result.OutputErrors) != 1 || result.OutputErrors[0].Line != tt.line {
+			t.Errorf("%s OutputErrors = %+v, want one error on line %d", tt.fileName, result.OutputErrors, tt.line)
+		}
+	}
+
+	if _, err := renderer.Render(template, nil, RenderOptions{OutputValidation: "hcl"}); err == nil {
+		t.Error("Render() accepted an unknown output validation")
+	}
+}
@@ -1,58 +1,533 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 	"text/template"
 	"text/template/parse"
+	"unicode/utf8"
 )
 
 // Parser handles template parsing and variable extraction
 // This follows the Confd pattern of parsing templates to extract dependencies
 type Parser struct {
 	registry *FunctionRegistry
+	// collected receives the errors of failing nodes under ErrorPolicyCollectAll; when nil the
+	// walk stops at the first error. It is only set on the per-call copy doing the main walk
+	collected *[]ExtractError
+	// templates is the parsed template set, letting the main walk descend into the templates
+	// invoked by {{template}} actions; invoking holds those being walked, against recursion, and
+	// ownPositions the positions found in trees of the walked file itself, as opposed to
+	// included files; walked holds every template reached so far. They are only set on the
+	// per-call copy doing the main walk
+	templates    *template.Template
+	invoking     map[string]bool
+	walked       map[string]bool
+	ownPositions map[*Position]bool
+	// fieldPrefix is the field path dot stands for inside an invoked template, e.g. Data within
+	// {{template "name" .Data}}; fields found there are reported with it
+	fieldPrefix []string
+	// bindings holds the pipeline last assigned to each $variable in scope, nil when its value
+	// is not known, and dynamic receives the lookups of keys held in such variables; both are
+	// only set on the per-call copy doing the main walk
+	bindings map[string]*parse.PipeNode
+	dynamic  *[]DynamicLookup
+	// includes are template files available to {{template}} ahead of the registered includes,
+	// such as the other templates of a Project
+	includes map[string]string
+	// env holds the registered includes and the filesystem {{template}} includes are read from
+	env *Environment
 }
 
 // NewParser creates a new template parser using the global registry
 func NewParser(registry *FunctionRegistry) *Parser {
 	return &Parser{
 		registry: registry,
+		env:      NewEnvironment(),
 	}
 }
 
+// SetEnvironment sets the environment {{template}} includes are read from, to share the one
+// of a Renderer
+func (p *Parser) SetEnvironment(env *Environment) {
+	p.env = env
+}
+
 // ExtractVariables extracts variable names from template content
-func (p *Parser) ExtractVariables(fileName, fileContent string) ([]string, error) {
-	funcs := p.registry.GetMinimalFuncMap()
-	tmpl, err := template.New(fileName).Option("missingkey=error").Funcs(funcs).Parse(fileContent)
+func (p *Parser) ExtractVariables(fileName, fileContent string) (result []string, err error) {
+	ctx, span := startTemplateSpan(context.Background(), SpanExtract, fileName, fileContent)
+	defer func() {
+		span.SetAttribute(AttrVariableCount, len(result))
+		endSpan(span, err)
+	}()
+
+	tmpl, err := p.parseTemplate(ctx, fileName, fileContent)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
+		return nil, err
+	}
+	if _, err := loadIncludes(tmpl, p.includes, p.env.includeSource()); err != nil {
+		return nil, err
 	}
 
-	result, err := p.getFieldFromNode(tmpl.Tree.Root, 0)
+	walker := p.newWalker(tmpl, nil)
+	_, walkSpan := startSpan(ctx, SpanWalk)
+	result, err = walker.getFieldFromNode(tmpl.Tree.Root, 0)
+	if err == nil {
+		err = walker.walkUninvoked(func(root *parse.ListNode) error {
+			names, err := walker.getFieldFromNode(root, 0)
+			result = append(result, names...)
+			return err
+		})
+	}
+	endSpan(walkSpan, err)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
 	}
 
+	in := make(stringInterner)
+	for i, name := range result {
+		result[i] = in.intern(name)
+	}
 	return result, nil
 }
 
-// ExtractVariablesWithDefaults extracts variables with default values from template content
-func (p *Parser) ExtractVariablesWithDefaults(fileName, fileContent string) ([]VariableInfo, error) {
+// parseTemplate parses template content with the minimal function map for extraction
+func (p *Parser) parseTemplate(ctx context.Context, fileName, fileContent string) (*template.Template, error) {
+	_, span := startSpan(ctx, SpanParse)
 	funcs := p.registry.GetMinimalFuncMap()
 	tmpl, err := template.New(fileName).Option("missingkey=error").Funcs(funcs).Parse(fileContent)
+	endSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
 	}
+	return tmpl, nil
+}
+
+// ExtractVariablesWithDefaults extracts variables with default values from template content
+// Only names and default values are kept, the original output shape; see
+// ExtractVariablesWithPositions for everything else extraction finds
+func (p *Parser) ExtractVariablesWithDefaults(fileName, fileContent string) ([]VariableInfo, error) {
+	infos, err := p.extractVariableInfos(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []VariableInfo
+	for _, v := range infos {
+		result = append(result, VariableInfo{Name: v.Name, DefaultValue: v.DefaultValue})
+	}
+	return result, nil
+}
+
+// ExtractVariablesWithPositions extracts variables with default values and the source
+// position of every occurrence, so editors can jump to and highlight each reference
+func (p *Parser) ExtractVariablesWithPositions(fileName, fileContent string) ([]VariableInfo, error) {
+	result, err := p.extractVariableInfos(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvePositions(fileContent, result)
+	return result, nil
+}
+
+// ExtractVariablesAggregated returns each variable once, in order of first use, with a usage
+// count and the positions of all its occurrences
+func (p *Parser) ExtractVariablesAggregated(fileName, fileContent string) ([]VariableInfo, error) {
+	variables, err := p.ExtractVariablesWithPositions(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	return AggregateVariables(variables), nil
+}
+
+// AggregateVariables merges occurrences of the same variable
+// The first non-empty default and type win, a variable is required if any occurrence is,
+// and DependsOn keeps the guards shared by all occurrences;
+// the per-occurrence Position is replaced by Occurrences
+func AggregateVariables(variables []VariableInfo) []VariableInfo {
+	index := make(map[string]int)
+	result := []VariableInfo{}
+	for _, v := range variables {
+		i, seen := index[v.Name]
+		if !seen {
+			i = len(result)
+			index[v.Name] = i
+			result = append(result, VariableInfo{Name: v.Name, Occurrences: []Position{}, DependsOn: v.DependsOn, Description: v.Description, Path: v.Path, Wildcard: v.Wildcard})
+		}
+		aggregated := &result[i]
+		if seen {
+			aggregated.DependsOn = intersectDependsOn(aggregated.DependsOn, v.DependsOn)
+		}
+		aggregated.Count++
+		if aggregated.DefaultValue == "" {
+			aggregated.DefaultValue, aggregated.DefaultType = v.DefaultValue, v.DefaultType
+		}
+		if aggregated.Type == "" {
+			aggregated.Type = v.Type
+		}
+		if !aggregated.Required && v.Required {
+			aggregated.Required, aggregated.RequiredMessage = true, v.RequiredMessage
+		}
+		aggregated.Sensitive = aggregated.Sensitive || v.Sensitive
+		aggregated.Fields = mergeFields(aggregated.Fields, v.Fields)
+		if v.Position != nil {
+			aggregated.Occurrences = append(aggregated.Occurrences, *v.Position)
+		}
+	}
+	return result
+}
+
+// ExtractVariablesWithOptions extracts variables with positions, then deduplicates, sorts,
+// filters, pages and strips defaults as requested by opts
+// Under ErrorPolicyCollectAll a template with failing nodes yields the variables of the other
+// nodes together with an *ExtractionErrors; a template that does not parse yields only the error
+func (p *Parser) ExtractVariablesWithOptions(fileName, fileContent string, opts ExtractOptions) ([]VariableInfo, error) {
+	page, err := p.ExtractVariablesPage(fileName, fileContent, opts)
+	if page == nil {
+		return nil, err
+	}
+	return page.Variables, err
+}
+
+// ExtractVariablesPage is ExtractVariablesWithOptions, also reporting the number of variables
+// passing the filters so UIs can page through huge templates
+func (p *Parser) ExtractVariablesPage(fileName, fileContent string, opts ExtractOptions) (*VariablePage, error) {
+	if err := validateSortOrder(opts.Sort); err != nil {
+		return nil, err
+	}
+	if err := validateErrorPolicy(opts.ErrorPolicy); err != nil {
+		return nil, err
+	}
+	if opts.Offset < 0 || opts.Limit < 0 {
+		return nil, fmt.Errorf("offset and limit must not be negative, got %d and %d", opts.Offset, opts.Limit)
+	}
 
-	result, err := p.getFieldFromNodeWithDefaults(tmpl.Tree.Root, 0)
+	var collected *[]ExtractError
+	if opts.ErrorPolicy == ErrorPolicyCollectAll {
+		collected = &[]ExtractError{}
+	}
+	// source is the content extracted from: under collectAll, a template with a syntax error is
+	// repaired so the variables outside the broken part are still returned
+	source := fileContent
+	variables, err := p.extractVariableInfosCollecting(fileName, source, collected)
+	if err != nil && collected != nil {
+		if repaired, syntaxError, ok := p.repairSyntax(fileName, fileContent); ok {
+			*collected = append(*collected, syntaxError)
+			source = repaired
+			variables, err = p.extractVariableInfosCollecting(fileName, source, collected)
+		}
+	}
+	if err != nil {
+		if collected == nil {
+			return nil, err
+		}
+		return nil, &ExtractionErrors{Errors: append(*collected, ExtractError{Message: err.Error()})}
+	}
+	resolvePositions(fileContent, variables)
+	// Filters look at every occurrence, so they apply before deduplication
+	if opts.Prefix != "" || opts.Function != "" || opts.RequiredOnly {
+		if variables, err = p.filterVariables(fileName, source, variables, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Document order is applied first so deduplication keeps the earliest occurrence
+	if opts.Sort == SortDocument {
+		sort.SliceStable(variables, func(i, j int) bool {
+			return variableOffset(variables[i]) < variableOffset(variables[j])
+		})
+	}
+	if opts.Deduplicate {
+		variables = deduplicateVariables(variables)
+	}
+	if opts.Sort == SortAlpha {
+		sort.SliceStable(variables, func(i, j int) bool {
+			return variables[i].Name < variables[j].Name
+		})
+	}
+	page := &VariablePage{Total: len(variables)}
+	variables = variables[min(opts.Offset, len(variables)):]
+	if opts.Limit > 0 && opts.Limit < len(variables) {
+		variables = variables[:opts.Limit]
+	}
+	page.Variables = variables
+
+	if !opts.IncludeDefaults {
+		for i := range variables {
+			variables[i].DefaultValue = ""
+			variables[i].DefaultType = ""
+		}
+	}
+	if collected != nil && len(*collected) > 0 {
+		errs := *collected
+		index := newLineIndex(fileContent)
+		for i := range errs {
+			index.resolve(fileContent, errs[i].Position)
+		}
+		return page, &ExtractionErrors{Errors: errs}
+	}
+	return page, nil
+}
+
+// filterVariables keeps the variables matching the prefix, function and required filters of
+// opts; a variable matches the function and required filters when any occurrence does
+func (p *Parser) filterVariables(fileName, fileContent string, variables []VariableInfo, opts ExtractOptions) ([]VariableInfo, error) {
+	readBy := make(map[string]bool)
+	if opts.Function != "" {
+		tmpl, err := p.parseTemplate(context.Background(), fileName, fileContent)
+		if err != nil {
+			return nil, err
+		}
+		functions := make(map[int]string)
+		for _, tree := range append([]*parse.Tree{tmpl.Tree}, definedTrees(tmpl)...) {
+			p.collectFunctions(tree.Root, functions, 0)
+		}
+		for _, v := range variables {
+			if v.Position != nil && functions[v.Position.Offset] == opts.Function {
+				readBy[v.Name] = true
+			}
+		}
+	}
+	required := make(map[string]bool)
+	for _, v := range variables {
+		if v.Required {
+			required[v.Name] = true
+		}
+	}
+
+	filtered := []VariableInfo{}
+	for _, v := range variables {
+		switch {
+		case !strings.HasPrefix(v.Name, opts.Prefix):
+		case opts.Function != "" && !readBy[v.Name]:
+		case opts.RequiredOnly && !required[v.Name]:
+		default:
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered, nil
+}
+
+// validateErrorPolicy checks an ExtractOptions error policy; empty selects ErrorPolicyBreakOnFirstError
+func validateErrorPolicy(policy string) error {
+	switch policy {
+	case "", ErrorPolicyBreakOnFirstError, ErrorPolicyCollectAll:
+		return nil
+	}
+	return fmt.Errorf("invalid error policy %q, expected %q or %q", policy, ErrorPolicyBreakOnFirstError, ErrorPolicyCollectAll)
+}
+
+// validateSortOrder checks an ExtractOptions sort order
+func validateSortOrder(order string) error {
+	switch order {
+	case SortTraversal, SortDocument, SortAlpha:
+		return nil
+	}
+	return fmt.Errorf("invalid sort order %q, expected %q or %q", order, SortDocument, SortAlpha)
+}
+
+// variableOffset returns the byte offset of a variable, placing variables without one last
+func variableOffset(v VariableInfo) int {
+	if v.Position == nil {
+		return int(^uint(0) >> 1)
+	}
+	return v.Position.Offset
+}
+
+// deduplicateVariables keeps the first occurrence of each name, taking the first non-empty default and type
+func deduplicateVariables(variables []VariableInfo) []VariableInfo {
+	index := make(map[string]int)
+	result := []VariableInfo{}
+	for _, v := range variables {
+		i, seen := index[v.Name]
+		if !seen {
+			index[v.Name] = len(result)
+			result = append(result, v)
+			continue
+		}
+		result[i].DependsOn = intersectDependsOn(result[i].DependsOn, v.DependsOn)
+		if result[i].DefaultValue == "" {
+			result[i].DefaultValue, result[i].DefaultType = v.DefaultValue, v.DefaultType
+		}
+		if result[i].Type == "" {
+			result[i].Type = v.Type
+		}
+		if !result[i].Required && v.Required {
+			result[i].Required, result[i].RequiredMessage = true, v.RequiredMessage
+		}
+		result[i].Sensitive = result[i].Sensitive || v.Sensitive
+		result[i].Fields = mergeFields(result[i].Fields, v.Fields)
+	}
+	return result
+}
+
+// mergeFields adds the field paths of another occurrence, keeping the order of first access
+func mergeFields(fields, more []string) []string {
+	for _, field := range more {
+		if !containsString(fields, field) {
+			fields = append(fields[:len(fields):len(fields)], field)
+		}
+	}
+	return fields
+}
+
+// extractVariableInfos parses the template and walks it, recording byte offsets only
+func (p *Parser) extractVariableInfos(fileName, fileContent string) ([]VariableInfo, error) {
+	return p.extractVariableInfosCollecting(fileName, fileContent, nil)
+}
+
+// extractVariableInfosCollecting is extractVariableInfos, recording node and pragma errors in
+// collected instead of failing when collected is not nil
+func (p *Parser) extractVariableInfosCollecting(fileName, fileContent string, collected *[]ExtractError) (result []VariableInfo, err error) {
+	ctx, span := startTemplateSpan(context.Background(), SpanExtract, fileName, fileContent)
+	defer func() {
+		span.SetAttribute(AttrVariableCount, len(result))
+		endSpan(span, err)
+	}()
+
+	tmpl, err := p.parseTemplate(ctx, fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := loadIncludes(tmpl, p.includes, p.env.includeSource()); err != nil {
+		return nil, err
+	}
+
+	walker := p.newWalker(tmpl, collected)
+	_, walkSpan := startSpan(ctx, SpanWalk)
+	// A counting pass sizes the result once instead of growing it occurrence by occurrence
+	result, err = walker.appendFieldsWithDefaults(make([]VariableInfo, 0, countVariableNodes(tmpl.Tree.Root)), tmpl.Tree.Root, 0)
+	if err == nil {
+		err = walker.walkUninvoked(func(root *parse.ListNode) (err error) {
+			result, err = walker.appendFieldsWithDefaults(result, root, 0)
+			return err
+		})
+	}
+	endSpan(walkSpan, err)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
 	}
+	if len(result) == 0 {
+		// Keep returning nil for templates without variables
+		result = nil
+	}
+
+	// Occurrences are matched by offset, so each tree of the file can be applied in turn
+	for _, tree := range append([]*parse.Tree{tmpl.Tree}, definedTrees(tmpl)...) {
+		p.applyTypeHints(tree.Root, result)
+		p.applyConditionalDependencies(tree.Root, result)
+		p.applyRequired(tree.Root, result)
+	}
 
+	// Comments are dropped by the regular parse, so pragmas need a second, comment-preserving one
+	if strings.Contains(fileContent, pragmaPrefix) {
+		pragmas, err := parsePragmas(fileName, fileContent)
+		if err != nil && collected == nil {
+			return nil, err
+		}
+		if err != nil {
+			*collected = append(*collected, ExtractError{Message: err.Error()})
+		} else {
+			applyPragmas(pragmas, result)
+		}
+	}
+	internNames(result)
+	applyKeyPaths(result)
 	return result, nil
 }
 
+// stringInterner returns one shared copy of each distinct string
+type stringInterner map[string]string
+
+func (in stringInterner) intern(s string) string {
+	if interned, ok := in[s]; ok {
+		return interned
+	}
+	in[s] = s
+	return s
+}
+
+// internNames makes repeated names share one string, so large results retain one copy per
+// distinct name instead of one per occurrence (dotted names are built anew at every occurrence)
+func internNames(variables []VariableInfo) {
+	in := make(stringInterner)
+	for i := range variables {
+		variables[i].Name = in.intern(variables[i].Name)
+		for j, guard := range variables[i].DependsOn {
+			variables[i].DependsOn[j] = in.intern(guard)
+		}
+	}
+}
+
+// applyKeyPaths sets the Path of every confd key, splitting each distinct key once
+func applyKeyPaths(variables []VariableInfo) {
+	var paths map[string][]string
+	for i := range variables {
+		name := variables[i].Name
+		if !isKeyPath(name) {
+			continue
+		}
+		if paths == nil {
+			paths = make(map[string][]string)
+		}
+		segments, ok := paths[name]
+		if !ok {
+			segments = splitKey(name)
+			paths[name] = segments
+		}
+		variables[i].Path = segments
+	}
+}
+
+// resolvePositions fills in line and column for every recorded offset
+// Line starts are indexed once so large templates resolve in linear time
+func resolvePositions(fileContent string, variables []VariableInfo) {
+	index := newLineIndex(fileContent)
+	for i := range variables {
+		index.resolve(fileContent, variables[i].Position)
+	}
+}
+
+// lineIndex holds the byte offset at which each line of a text starts
+type lineIndex []int
+
+func newLineIndex(text string) lineIndex {
+	lineStarts := lineIndex{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return lineStarts
+}
+
+// resolve sets the 1-based line and column (in runes) of pos from its offset into text
+func (lineStarts lineIndex) resolve(text string, pos *Position) {
+	if pos == nil || pos.Offset > len(text) {
+		return
+	}
+	line := sort.Search(len(lineStarts), func(j int) bool { return lineStarts[j] > pos.Offset }) - 1
+	pos.Line = line + 1
+	pos.Column = utf8.RuneCountInString(text[lineStarts[line]:pos.Offset]) + 1
+}
+
+// fieldPosition returns the position of a field chain such as .User.Name
+// The parser reports the offset of the last segment, so the start is recovered from the source text
+func fieldPosition(node *parse.FieldNode) *Position {
+	text := "." + strings.Join(node.Ident, ".")
+	last := "." + node.Ident[len(node.Ident)-1]
+	start := int(node.Position()) - (len(text) - len(last))
+	if start < 0 {
+		start = int(node.Position())
+	}
+	return nodePosition(parse.Pos(start), len(text))
+}
+
 // getFieldFromNode extracts variables from template nodes
 func (p *Parser) getFieldFromNode(node parse.Node, depth int) ([]string, error) {
 	depth = depth + 1
@@ -62,9 +537,7 @@ func (p *Parser) getFieldFromNode(node parse.Node, depth int) ([]string, error)
 	var result []string
 	switch node := node.(type) {
 	case *parse.FieldNode:
-		ident := node.Ident
-		join := strings.Join(ident, ".")
-		result = append(result, join)
+		result = append(result, p.fieldName(node.Ident))
 	case *parse.CommandNode:
 		args := node.Args
 		firstWord := args[0]
@@ -90,10 +563,16 @@ func (p *Parser) getFieldFromNode(node parse.Node, depth int) ([]string, error)
 			return nil, err
 		}
 		result = append(result, sonResult...)
+		p.bind(pipe, false)
 
 	case *parse.PipeNode:
 		cmds := node.Cmds
-		for _, cmd := range cmds {
+		for i := 0; i < len(cmds); i++ {
+			var cmd parse.Node = cmds[i]
+			if piped := p.pipedCall(cmds, i); piped != nil {
+				cmd = piped
+				i++
+			}
 			sonResult, err := p.getFieldFromNode(cmd, depth)
 			if err != nil {
 				return nil, err
@@ -110,20 +589,31 @@ func (p *Parser) getFieldFromNode(node parse.Node, depth int) ([]string, error)
 			result = append(result, sonResult...)
 		}
 	case *parse.IfNode:
-		sonResult, err := p.processIfAndWithAndRange(node.Pipe, node.List, node.ElseList, depth)
+		sonResult, err := p.processIfAndWithAndRange(node.Pipe, node.List, node.ElseList, false, depth)
 		if err != nil {
 			return nil, err
 		}
 		result = append(result, sonResult...)
 	case *parse.RangeNode:
-		sonResult, err := p.processIfAndWithAndRange(node.Pipe, node.List, node.ElseList, depth)
+		sonResult, err := p.processIfAndWithAndRange(node.Pipe, node.List, node.ElseList, true, depth)
 		if err != nil {
 			return nil, err
 		}
 		result = append(result, sonResult...)
 
 	case *parse.WithNode:
-		sonResult, err := p.processIfAndWithAndRange(node.Pipe, node.List, node.ElseList, depth)
+		sonResult, err := p.processIfAndWithAndRange(node.Pipe, node.List, node.ElseList, false, depth)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sonResult...)
+	case *parse.TemplateNode:
+		sonResult, err := p.getFieldFromNode(node.Pipe, depth)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sonResult...)
+		sonResult, err = p.getInvokedFields(node.Name, node.Pipe, depth)
 		if err != nil {
 			return nil, err
 		}
@@ -136,22 +626,48 @@ func (p *Parser) getFieldFromNode(node parse.Node, depth int) ([]string, error)
 	case *parse.NilNode:
 	case *parse.NumberNode:
 	case *parse.VariableNode:
+		if ident, ok := p.variableField(node); ok {
+			result = append(result, p.fieldName(ident))
+		}
+	case *parse.ChainNode:
+		sonResult, err := p.getFieldFromNode(node.Node, depth)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sonResult...)
+		if base, ok := p.chainBase(node.Node); ok {
+			result = append(result, p.fieldName(append(base, node.Field...)))
+		}
+	case *parse.BreakNode, *parse.ContinueNode, *parse.CommentNode:
+	default:
+		// Node types of newer text/template releases are searched through their child nodes
+		for _, child := range childNodes(node) {
+			sonResult, err := p.getFieldFromNode(child, depth)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, sonResult...)
+		}
 	}
 	return result, nil
 }
 
 // getFieldFromNodeWithDefaults extracts variables with default values from template nodes
 func (p *Parser) getFieldFromNodeWithDefaults(node parse.Node, depth int) ([]VariableInfo, error) {
+	return p.appendFieldsWithDefaults(nil, node, depth)
+}
+
+// appendFieldsWithDefaults appends the variables found under node to dst
+// Appending into one slice avoids allocating and copying a result slice at every tree level
+func (p *Parser) appendFieldsWithDefaults(dst []VariableInfo, node parse.Node, depth int) ([]VariableInfo, error) {
 	depth = depth + 1
 	if depth > maxDepth {
 		return nil, errors.New("template nesting depth exceeded maximum limit, please verify template structure")
 	}
-	var result []VariableInfo
+	var err error
 	switch node := node.(type) {
 	case *parse.FieldNode:
-		ident := node.Ident
-		join := strings.Join(ident, ".")
-		result = append(result, VariableInfo{Name: join})
+		dst = append(dst, VariableInfo{Name: p.fieldName(node.Ident), Position: fieldPosition(node)})
 	case *parse.CommandNode:
 		args := node.Args
 		firstWord := args[0]
@@ -160,61 +676,53 @@ func (p *Parser) getFieldFromNodeWithDefaults(node parse.Node, depth int) ([]Var
 			if err != nil {
 				return nil, err
 			}
-			result = append(result, sonResult...)
+			dst = append(dst, sonResult...)
 		} else {
 			for _, arg := range args {
-				sonResult, err := p.getFieldFromNodeWithDefaults(arg, depth)
-				if err != nil {
+				if dst, err = p.appendFieldsWithDefaults(dst, arg, depth); err != nil {
 					return nil, err
 				}
-				result = append(result, sonResult...)
 			}
 		}
 	case *parse.ActionNode:
-		pipe := node.Pipe
-		sonResult, err := p.getFieldFromNodeWithDefaults(pipe, depth)
-		if err != nil {
+		if dst, err = p.appendFieldsWithDefaults(dst, node.Pipe, depth); err != nil {
 			return nil, err
 		}
-		result = append(result, sonResult...)
-
+		p.bind(node.Pipe, false)
 	case *parse.PipeNode:
-		cmds := node.Cmds
-		for _, cmd := range cmds {
-			sonResult, err := p.getFieldFromNodeWithDefaults(cmd, depth)
-			if err != nil {
+		for i := 0; i < len(node.Cmds); i++ {
+			var cmd parse.Node = node.Cmds[i]
+			if piped := p.pipedCall(node.Cmds, i); piped != nil {
+				cmd = piped
+				i++
+			}
+			if dst, err = p.appendFieldsWithDefaults(dst, cmd, depth); err != nil {
 				return nil, err
 			}
-			result = append(result, sonResult...)
 		}
 	case *parse.ListNode:
-		nodes := node.Nodes
-		for _, item := range nodes {
-			sonResult, err := p.getFieldFromNodeWithDefaults(item, depth)
+		for _, item := range node.Nodes {
+			next, err := p.appendFieldsWithDefaults(dst, item, depth)
 			if err != nil {
-				return nil, err
+				if !p.collectError(item, err) {
+					return nil, err
+				}
+				// Drop the failing item's variables and carry on with its siblings
+				continue
 			}
-			result = append(result, sonResult...)
+			dst = next
 		}
 	case *parse.IfNode:
-		sonResult, err := p.processIfAndWithAndRangeWithDefaults(node.Pipe, node.List, node.ElseList, depth)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, sonResult...)
+		return p.appendBranchFieldsWithDefaults(dst, node.Pipe, node.List, node.ElseList, false, depth)
 	case *parse.RangeNode:
-		sonResult, err := p.processIfAndWithAndRangeWithDefaults(node.Pipe, node.List, node.ElseList, depth)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, sonResult...)
-
+		return p.appendBranchFieldsWithDefaults(dst, node.Pipe, node.List, node.ElseList, true, depth)
 	case *parse.WithNode:
-		sonResult, err := p.processIfAndWithAndRangeWithDefaults(node.Pipe, node.List, node.ElseList, depth)
-		if err != nil {
+		return p.appendBranchFieldsWithDefaults(dst, node.Pipe, node.List, node.ElseList, false, depth)
+	case *parse.TemplateNode:
+		if dst, err = p.appendFieldsWithDefaults(dst, node.Pipe, depth); err != nil {
 			return nil, err
 		}
-		result = append(result, sonResult...)
+		return p.appendInvokedFieldsWithDefaults(dst, node.Name, node.Pipe, depth)
 	case *parse.IdentifierNode:
 	case *parse.TextNode:
 	case *parse.DotNode:
@@ -223,18 +731,400 @@ func (p *Parser) getFieldFromNodeWithDefaults(node parse.Node, depth int) ([]Var
 	case *parse.NilNode:
 	case *parse.NumberNode:
 	case *parse.VariableNode:
+		if ident, ok := p.variableField(node); ok {
+			dst = append(dst, VariableInfo{Name: p.fieldName(ident), Position: variablePosition(node)})
+		}
+	case *parse.ChainNode:
+		start := len(dst)
+		if dst, err = p.appendFieldsWithDefaults(dst, node.Node, depth); err != nil {
+			return nil, err
+		}
+		if base, ok := p.chainBase(node.Node); ok {
+			dst = append(dst, VariableInfo{Name: p.fieldName(append(base, node.Field...)), Position: chainPosition(node)})
+		} else if p.isLookupCall(node.Node) {
+			// The variables read by the call hold a value whose fields are accessed
+			path := strings.Join(node.Field, ".")
+			for i := start; i < len(dst); i++ {
+				dst[i].Fields = append(dst[i].Fields, path)
+			}
+		}
+	case *parse.BreakNode, *parse.ContinueNode, *parse.CommentNode:
+	default:
+		// Node types of newer text/template releases are searched through their child nodes
+		for _, child := range childNodes(node) {
+			if dst, err = p.appendFieldsWithDefaults(dst, child, depth); err != nil {
+				return nil, err
+			}
+		}
 	}
-	return result, nil
+	return dst, nil
+}
+
+// getInvokedFields walks the template invoked by name with dot standing for pipe
+func (p *Parser) getInvokedFields(name string, pipe *parse.PipeNode, depth int) ([]string, error) {
+	tree := p.invokedTree(name)
+	if tree == nil {
+		return nil, nil
+	}
+	defer delete(p.invoking, name)
+	defer p.useInvocationPrefix(pipe)()
+	defer p.templateScope(tree)()
+	return p.getFieldFromNode(tree.Root, depth)
+}
+
+// appendInvokedFieldsWithDefaults appends the variables of the template invoked by name with
+// dot standing for pipe
+func (p *Parser) appendInvokedFieldsWithDefaults(dst []VariableInfo, name string, pipe *parse.PipeNode, depth int) ([]VariableInfo, error) {
+	tree := p.invokedTree(name)
+	if tree == nil {
+		return dst, nil
+	}
+	defer delete(p.invoking, name)
+	defer p.useInvocationPrefix(pipe)()
+	defer p.templateScope(tree)()
+	start := len(dst)
+	dst, err := p.appendFieldsWithDefaults(dst, tree.Root, depth)
+	if err != nil {
+		return nil, err
+	}
+	own := tree.ParseName == p.templates.Name()
+	for i := start; i < len(dst); i++ {
+		switch {
+		case dst[i].Position == nil:
+		case own:
+			p.ownPositions[dst[i].Position] = true
+		case !p.ownPositions[dst[i].Position]:
+			// Offsets inside an included file do not point into this template's source
+			dst[i].Position = nil
+		}
+	}
+	return dst, nil
+}
+
+// includeDot returns the pipeline an include call passes as dot, for useInvocationPrefix
+func includeDot(args []parse.Node) *parse.PipeNode {
+	if len(args) < 3 {
+		return nil
+	}
+	return &parse.PipeNode{
+		NodeType: parse.NodePipe,
+		Pos:      args[2].Position(),
+		Cmds:     []*parse.CommandNode{{NodeType: parse.NodeCommand, Pos: args[2].Position(), Args: args[2:3]}},
+	}
+}
+
+// walkNodes calls visit for node and, while visit returns true, for the nodes below it in
+// template order; depth counts from the depth of node and the walk stops at maxDepth
+func walkNodes(node parse.Node, depth int, visit func(node parse.Node, depth int) bool) {
+	if isNilNode(node) || depth > maxDepth || !visit(node, depth) {
+		return
+	}
+	for _, child := range childNodes(node) {
+		walkNodes(child, depth+1, visit)
+	}
+}
+
+// isNilNode reports whether node is nil or a nil pointer, such as the ElseList of an if without else
+func isNilNode(node parse.Node) bool {
+	if node == nil {
+		return true
+	}
+	value := reflect.ValueOf(node)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+// childNodes returns the child nodes of node in template order, so walkers only need cases for
+// the nodes they act on; node types of newer text/template releases are searched through the
+// nodes held in their exported fields, including those of embedded structs such as BranchNode
+func childNodes(node parse.Node) []parse.Node {
+	if isNilNode(node) {
+		return nil
+	}
+	switch node := node.(type) {
+	case *parse.ListNode:
+		return node.Nodes
+	case *parse.ActionNode:
+		return []parse.Node{node.Pipe}
+	case *parse.TemplateNode:
+		return appendNode(nil, node.Pipe)
+	case *parse.IfNode:
+		return branchNodes(&node.BranchNode)
+	case *parse.RangeNode:
+		return branchNodes(&node.BranchNode)
+	case *parse.WithNode:
+		return branchNodes(&node.BranchNode)
+	case *parse.PipeNode:
+		children := make([]parse.Node, 0, len(node.Decl)+len(node.Cmds))
+		for _, decl := range node.Decl {
+			children = append(children, decl)
+		}
+		for _, cmd := range node.Cmds {
+			children = append(children, cmd)
+		}
+		return children
+	case *parse.CommandNode:
+		return node.Args
+	case *parse.ChainNode:
+		return []parse.Node{node.Node}
+	case *parse.FieldNode, *parse.VariableNode, *parse.IdentifierNode, *parse.DotNode, *parse.NilNode,
+		*parse.BoolNode, *parse.NumberNode, *parse.StringNode, *parse.TextNode, *parse.CommentNode,
+		*parse.BreakNode, *parse.ContinueNode:
+		return nil
+	}
+	value := reflect.ValueOf(node)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return appendChildNodes(nil, value.Elem())
+}
+
+// branchNodes returns the pipeline and bodies of an if, range or with
+func branchNodes(node *parse.BranchNode) []parse.Node {
+	children := appendNode(make([]parse.Node, 0, 3), node.Pipe)
+	children = appendNode(children, node.List)
+	return appendNode(children, node.ElseList)
+}
+
+// appendNode appends node to dst unless it is nil
+func appendNode(dst []parse.Node, node parse.Node) []parse.Node {
+	if isNilNode(node) {
+		return dst
+	}
+	return append(dst, node)
+}
+
+// appendChildNodes appends the non-nil nodes found in the exported fields of a node struct
+func appendChildNodes(dst []parse.Node, value reflect.Value) []parse.Node {
+	nodeType := reflect.TypeOf((*parse.Node)(nil)).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field, info := value.Field(i), value.Type().Field(i)
+		switch {
+		case !info.IsExported():
+		case info.Anonymous && field.Kind() == reflect.Struct:
+			dst = appendChildNodes(dst, field)
+		case field.Type().Implements(nodeType) && (field.Kind() == reflect.Pointer || field.Kind() == reflect.Interface):
+			if !field.IsNil() {
+				dst = append(dst, field.Interface().(parse.Node))
+			}
+		case field.Kind() == reflect.Slice && field.Type().Elem().Implements(nodeType):
+			for j := 0; j < field.Len(); j++ {
+				if item := field.Index(j); !item.IsNil() {
+					dst = append(dst, item.Interface().(parse.Node))
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// pipedCall returns the call of cmds[i+1] with the operand cmds[i] piped into it as its last
+// argument, as text/template passes it, so {{"username" | getv}} is extracted like
+// {{getv "username"}}; nil unless cmds[i] is a lone string literal piped into a registered
+// function with an extractor. Piped fields and variables are extracted where they stand,
+// since extractors only look at the arguments they know about, unless the function is marked
+// ExtractsPipedValue
+func (p *Parser) pipedCall(cmds []*parse.CommandNode, i int) *parse.CommandNode {
+	if i+1 >= len(cmds) || len(cmds[i].Args) != 1 {
+		return nil
+	}
+	next := cmds[i+1]
+	ident, ok := next.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return nil
+	}
+	funcDef, exists := p.registry.GetFunction(ident.Ident)
+	if !exists || (funcDef.Extractor == nil && funcDef.ExtractorWithDefaults == nil) {
+		return nil
+	}
+	switch cmds[i].Args[0].(type) {
+	case *parse.StringNode:
+	case *parse.FieldNode, *parse.VariableNode, *parse.ChainNode:
+		if !funcDef.ExtractsPipedValue {
+			return nil
+		}
+	default:
+		return nil
+	}
+	args := make([]parse.Node, 0, len(next.Args)+1)
+	args = append(append(args, next.Args...), cmds[i].Args[0])
+	return &parse.CommandNode{NodeType: parse.NodeCommand, Pos: next.Pos, Args: args}
+}
+
+// chainBase returns the field chain a chained expression is applied to, such as Service in
+// (.Service).Port; a copy is returned, so callers may append to it
+func (p *Parser) chainBase(node parse.Node) ([]string, bool) {
+	switch node := node.(type) {
+	case *parse.PipeNode:
+		if len(node.Decl) != 0 || len(node.Cmds) != 1 {
+			return nil, false
+		}
+		args := node.Cmds[0].Args
+		if len(args) == 1 {
+			return p.chainBase(args[0])
+		}
+		if ident, ok := args[0].(*parse.IdentifierNode); ok && ident.Ident == indexFunction && !p.registry.HasFunction(indexFunction) {
+			path, _, ok := p.indexPath(args)
+			return path, ok
+		}
+	case *parse.FieldNode:
+		return append([]string{}, node.Ident...), true
+	case *parse.ChainNode:
+		if base, ok := p.chainBase(node.Node); ok {
+			return append(base, node.Field...), true
+		}
+	case *parse.VariableNode:
+		if len(node.Ident) == 1 && node.Ident[0] == "$" {
+			return []string{}, true
+		}
+		if len(node.Ident) == 1 {
+			if field, ok := p.boundArg(node.Ident[0]).(*parse.FieldNode); ok {
+				return append([]string{}, field.Ident...), true
+			}
+			return nil, false
+		}
+		return p.variableField(node)
+	}
+	return nil, false
+}
+
+// isLookupCall reports whether a chained expression is applied to the result of a single call
+// of a registered function, such as (json "/cfg") in (json "/cfg").database.host
+func (p *Parser) isLookupCall(node parse.Node) bool {
+	pipe, ok := node.(*parse.PipeNode)
+	if !ok || len(pipe.Decl) != 0 || len(pipe.Cmds) != 1 {
+		return false
+	}
+	ident, ok := pipe.Cmds[0].Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return false
+	}
+	funcDef, exists := p.registry.GetFunction(ident.Ident)
+	return exists && funcDef.ExtractorWithDefaults != nil
+}
+
+// chainPosition returns the position of the fields of a chained expression, .Port in
+// (.Service).Port
+func chainPosition(node *parse.ChainNode) *Position {
+	return nodePosition(node.Position(), len("."+strings.Join(node.Field, ".")))
+}
+
+// newWalker returns the per-call copy of the parser doing the main walk of tmpl
+func (p *Parser) newWalker(tmpl *template.Template, collected *[]ExtractError) *Parser {
+	return &Parser{
+		registry:     p.registry,
+		collected:    collected,
+		templates:    tmpl,
+		invoking:     make(map[string]bool),
+		walked:       make(map[string]bool),
+		ownPositions: make(map[*Position]bool),
+		bindings:     make(map[string]*parse.PipeNode),
+	}
+}
+
+// walkUninvoked calls walk on the templates defined in the walked file that no {{template}}
+// action has reached, such as a library of {{define}} blocks, with dot standing for the data
+func (p *Parser) walkUninvoked(walk func(root *parse.ListNode) error) error {
+	for _, tree := range definedTrees(p.templates) {
+		name := tree.Name
+		if p.walked[name] {
+			continue
+		}
+		p.walked[name] = true
+		p.invoking[name] = true
+		restore := p.templateScope(tree)
+		err := walk(tree.Root)
+		restore()
+		delete(p.invoking, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// processIfAndWithAndRange processes if, range, and with nodes
-func (p *Parser) processIfAndWithAndRange(pipe *parse.PipeNode, list, elseList *parse.ListNode, cycle int) ([]string, error) {
+// definedTrees returns the trees of the templates a file defines besides its own, in document
+// order; those of included files are left out
+func definedTrees(tmpl *template.Template) []*parse.Tree {
+	var trees []*parse.Tree
+	for _, t := range tmpl.Templates() {
+		if t == tmpl || t.Tree == nil || t.Tree.Root == nil || t.Tree.ParseName != tmpl.Name() {
+			continue
+		}
+		trees = append(trees, t.Tree)
+	}
+	sort.Slice(trees, func(i, j int) bool { return trees[i].Root.Pos < trees[j].Root.Pos })
+	return trees
+}
+
+// invokedTree returns the tree of a template invoked by {{template}} and marks it as being
+// walked, or nil when it is not defined, empty, or already being walked (a recursive template)
+func (p *Parser) invokedTree(name string) *parse.Tree {
+	if p.templates == nil || p.invoking[name] {
+		return nil
+	}
+	invoked := p.templates.Lookup(name)
+	if invoked == nil || invoked.Tree == nil || invoked.Tree.Root == nil {
+		return nil
+	}
+	p.invoking[name] = true
+	p.walked[name] = true
+	return invoked.Tree
+}
+
+// fieldName joins a field chain such as .User.Name into a variable name, under fieldPrefix
+func (p *Parser) fieldName(ident []string) string {
+	if len(p.fieldPrefix) == 0 {
+		return strings.Join(ident, ".")
+	}
+	return strings.Join(p.fieldPrefix, ".") + "." + strings.Join(ident, ".")
+}
+
+// useInvocationPrefix sets fieldPrefix to what dot stands for in a template invoked with pipe,
+// returning a function that restores the previous prefix
+// . keeps the prefix, $ resets it and a field chain (.Data, $.Data) extends it; other
+// pipelines, such as function calls, leave it unchanged as their result has no field path
+func (p *Parser) useInvocationPrefix(pipe *parse.PipeNode) (restore func()) {
+	prev := p.fieldPrefix
+	restore = func() { p.fieldPrefix = prev }
+	if pipe == nil || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return restore
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode:
+		p.fieldPrefix = append(append([]string(nil), prev...), arg.Ident...)
+	case *parse.VariableNode:
+		if arg.Ident[0] == "$" {
+			p.fieldPrefix = append([]string(nil), arg.Ident[1:]...)
+		}
+	}
+	return restore
+}
+
+// countVariableNodes counts the field and string nodes under node as the capacity hint of the
+// extraction result. It is not a bound: occurrences reached through $variables or included
+// templates are not counted, and append grows the result past the hint when they are recorded
+func countVariableNodes(node parse.Node) int {
+	count := 0
+	walkNodes(node, 0, func(node parse.Node, depth int) bool {
+		switch node.(type) {
+		case *parse.FieldNode, *parse.StringNode:
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// processIfAndWithAndRange processes if, range, and with nodes; iterates is set for range
+func (p *Parser) processIfAndWithAndRange(pipe *parse.PipeNode, list, elseList *parse.ListNode, iterates bool, cycle int) ([]string, error) {
+	defer p.scope()()
 	var result []string
 	sonResult, err := p.getFieldFromNode(pipe, cycle)
 	if err != nil {
 		return nil, err
 	}
 	result = append(result, sonResult...)
+	p.bind(pipe, iterates)
 	sonResult, err = p.getFieldFromNode(list, cycle)
 	if err != nil {
 		return nil, err
@@ -251,26 +1141,30 @@ func (p *Parser) processIfAndWithAndRange(pipe *parse.PipeNode, list, elseList *
 }
 
 // processIfAndWithAndRangeWithDefaults processes if, range, and with nodes with default values
-func (p *Parser) processIfAndWithAndRangeWithDefaults(pipe *parse.PipeNode, list, elseList *parse.ListNode, cycle int) ([]VariableInfo, error) {
-	var result []VariableInfo
-	sonResult, err := p.getFieldFromNodeWithDefaults(pipe, cycle)
+func (p *Parser) processIfAndWithAndRangeWithDefaults(pipe *parse.PipeNode, list, elseList *parse.ListNode, iterates bool, cycle int) ([]VariableInfo, error) {
+	return p.appendBranchFieldsWithDefaults(nil, pipe, list, elseList, iterates, cycle)
+}
+
+// appendBranchFieldsWithDefaults appends the variables of an if, range or with node to dst;
+// iterates is set for range
+func (p *Parser) appendBranchFieldsWithDefaults(dst []VariableInfo, pipe *parse.PipeNode, list, elseList *parse.ListNode, iterates bool, cycle int) ([]VariableInfo, error) {
+	defer p.scope()()
+	dst, err := p.appendFieldsWithDefaults(dst, pipe, cycle)
 	if err != nil {
 		return nil, err
 	}
-	result = append(result, sonResult...)
-	sonResult, err = p.getFieldFromNodeWithDefaults(list, cycle)
+	p.bind(pipe, iterates)
+	dst, err = p.appendFieldsWithDefaults(dst, list, cycle)
 	if err != nil {
 		return nil, err
 	}
-	result = append(result, sonResult...)
 	if elseList != nil {
-		sonResult, err = p.getFieldFromNodeWithDefaults(elseList, cycle)
+		dst, err = p.appendFieldsWithDefaults(dst, elseList, cycle)
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, sonResult...)
 	}
-	return result, nil
+	return dst, nil
 }
 
 // parseCustomFunc parses custom functions for variable extraction
@@ -278,13 +1172,18 @@ func (p *Parser) parseCustomFunc(args []parse.Node, cycle int) ([]string, error)
 	var result []string
 	node := args[0].(*parse.IdentifierNode)
 	funcName := node.Ident
-	
+
 	// Check if this is a registered custom function
 	if funcDef, exists := p.registry.GetFunction(funcName); exists && funcDef.Extractor != nil {
-		// Use the function's custom extractor
-		return funcDef.Extractor(args, cycle)
+		// Use the function's custom extractor, with the $variables it is passed resolved
+		args, probes := p.resolveArgs(args)
+		result, err := funcDef.Extractor(args, cycle)
+		if err != nil || probes == nil {
+			return result, err
+		}
+		return p.dropProbeNames(result, funcName, probes), nil
 	}
-	
+
 	// Not a custom function, process all arguments normally
 	for _, arg := range args {
 		sonResult, err := p.getFieldFromNode(arg, cycle)
@@ -293,6 +1192,18 @@ func (p *Parser) parseCustomFunc(args []parse.Node, cycle int) ([]string, error)
 		}
 		result = append(result, sonResult...)
 	}
+	if funcName == indexFunction {
+		if path, _, ok := p.indexPath(args); ok {
+			result = append(result, p.fieldName(path))
+		}
+	}
+	if name, ok := includedName(args); ok && p.registry.HasFunction(includeFunction) {
+		invoked, err := p.getInvokedFields(name, includeDot(args), cycle)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, invoked...)
+	}
 	return result, nil
 }
 
@@ -301,23 +1212,86 @@ func (p *Parser) parseCustomFuncWithDefaults(args []parse.Node, cycle int) ([]Va
 	var result []VariableInfo
 	node := args[0].(*parse.IdentifierNode)
 	funcName := node.Ident
-	
+
 	// Check if this is a registered custom function
 	if funcDef, exists := p.registry.GetFunction(funcName); exists && funcDef.ExtractorWithDefaults != nil {
-		// Use the function's custom extractor with defaults
-		return funcDef.ExtractorWithDefaults(args, cycle)
+		// Use the function's custom extractor with defaults, with the $variables it is passed resolved
+		args, probes := p.resolveArgs(args)
+		result, err := funcDef.ExtractorWithDefaults(args, cycle)
+		if err != nil || probes == nil {
+			return result, err
+		}
+		return p.dropProbes(result, funcName, probes), nil
 	}
-	
-	// Not a custom function, process all arguments normally
-	for _, arg := range args {
-		sonResult, err := p.getFieldFromNode(arg, cycle)
+
+	// Not a custom function, process all arguments normally (defaults are not propagated)
+	for i, arg := range args {
+		start := len(result)
+		sonResult, err := p.getFieldFromNodeWithDefaults(arg, cycle)
 		if err != nil {
 			return nil, err
 		}
-		for _, name := range sonResult {
-			result = append(result, VariableInfo{Name: name})
+		result = append(result, withoutDefaults(sonResult)...)
+		if i == 1 && funcName == indexFunction {
+			result = p.appendIndexAccess(result, start, args)
 		}
 	}
+	if name, ok := includedName(args); ok && p.registry.HasFunction(includeFunction) {
+		return p.appendInvokedFieldsWithDefaults(result, name, includeDot(args), cycle)
+	}
 	return result, nil
 }
 
+// indexFunction is the text/template builtin reading map keys and slice elements
+const indexFunction = "index"
+
+// indexKeys returns the keys an index call reads when all are strings known before rendering,
+// such as timeout in {{index .Settings "timeout"}}, with the node of the last one
+func (p *Parser) indexKeys(args []parse.Node) ([]string, *parse.StringNode, bool) {
+	if len(args) < 3 {
+		return nil, nil, false
+	}
+	var keys []string
+	var last *parse.StringNode
+	for _, arg := range args[2:] {
+		if variable, ok := arg.(*parse.VariableNode); ok && len(variable.Ident) == 1 {
+			arg = p.boundArg(variable.Ident[0])
+		}
+		key, ok := arg.(*parse.StringNode)
+		if !ok {
+			return nil, nil, false
+		}
+		keys = append(keys, key.Text)
+		last = key
+	}
+	return keys, last, true
+}
+
+// indexPath returns the field path an index call on a field reads, Settings.timeout for
+// {{index .Settings "timeout"}}, with the node of its last key
+func (p *Parser) indexPath(args []parse.Node) ([]string, *parse.StringNode, bool) {
+	keys, last, ok := p.indexKeys(args)
+	if !ok {
+		return nil, nil, false
+	}
+	base, ok := p.chainBase(args[1])
+	if !ok {
+		return nil, nil, false
+	}
+	return append(base, keys...), last, true
+}
+
+// appendIndexAccess reports the keys read by an index call whose indexed value's variables
+// start at dst[start]: as a field path for a field, or as fields of a looked-up value for
+// {{index (json "/cfg") "timeout"}}
+func (p *Parser) appendIndexAccess(dst []VariableInfo, start int, args []parse.Node) []VariableInfo {
+	if path, last, ok := p.indexPath(args); ok {
+		return append(dst, VariableInfo{Name: p.fieldName(path), Position: nodePosition(last.Position(), len(last.Quoted))})
+	}
+	if keys, _, ok := p.indexKeys(args); ok && p.isLookupCall(args[1]) {
+		for i := start; i < len(dst); i++ {
+			dst[i].Fields = append(dst[i].Fields, strings.Join(keys, "."))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,475 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template/parse"
+	"unsafe"
+)
+
+// TestExtraction_InternedNames tests that repeated dotted names share one backing string
+func TestExtraction_InternedNames(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := strings.Repeat("{{if .Db.Enabled}}{{.Db.Host}}{{end}}\n", 50)
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	first := make(map[string]*byte)
+	for _, v := range variables {
+		names := append([]string{v.Name}, v.DependsOn...)
+		for _, name := range names {
+			data := unsafe.StringData(name)
+			if shared, ok := first[name]; !ok {
+				first[name] = data
+			} else if shared != data {
+				t.Fatalf("name %q is not interned", name)
+			}
+		}
+	}
+	if len(first) != 2 {
+		t.Errorf("distinct names = %d, want 2", len(first))
+	}
+
+	names, err := parser.ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if unsafe.StringData(names[1]) != unsafe.StringData(names[len(names)-1]) {
+		t.Errorf("ExtractVariables() names are not interned")
+	}
+}
+
+// TestExtraction_ErrorPolicy tests that collectAll skips failing actions and reports them
+func TestExtraction_ErrorPolicy(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:    "broken",
+		Handler: func(args ...interface{}) string { return "" },
+		ExtractorWithDefaults: func(args []parse.Node, cycle int) ([]VariableInfo, error) {
+			return nil, errors.New("broken extractor")
+		},
+	})
+	parser := NewParser(registry)
+	template := "{{.Host}}\n{{broken .Port}}\n{{.User}}\n{{broken}}"
+
+	if _, err := parser.ExtractVariablesWithOptions("test.tmpl", template, DefaultExtractOptions()); err == nil {
+		t.Fatalf("ExtractVariablesWithOptions() with default policy error = nil, want error")
+	}
+
+	opts := DefaultExtractOptions()
+	opts.ErrorPolicy = ErrorPolicyCollectAll
+	variables, err := parser.ExtractVariablesWithOptions("test.tmpl", template, opts)
+	var extractionErrors *ExtractionErrors
+	if !errors.As(err, &extractionErrors) {
+		t.Fatalf("ExtractVariablesWithOptions() error = %v, want *ExtractionErrors", err)
+	}
+	var names []string
+	for _, v := range variables {
+		names = append(names, v.Name)
+	}
+	if want := []string{"Host", "User"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ExtractVariablesWithOptions() names = %v, want %v", names, want)
+	}
+	var lines []int
+	for _, e := range extractionErrors.Errors {
+		if e.Position == nil {
+			t.Fatalf("error %q has no position", e.Message)
+		}
+		lines = append(lines, e.Position.Line)
+	}
+	if want := []int{2, 4}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("error lines = %v, want %v", lines, want)
+	}
+
+	if _, err := parser.ExtractVariablesWithOptions("test.tmpl", "{{.Host", opts); !errors.As(err, &extractionErrors) {
+		t.Errorf("ExtractVariablesWithOptions() parse error = %v, want *ExtractionErrors", err)
+	}
+
+	opts.ErrorPolicy = "ignore"
+	if _, err := parser.ExtractVariablesWithOptions("test.tmpl", template, opts); err == nil {
+		t.Errorf("ExtractVariablesWithOptions() with invalid policy error = nil, want error")
+	}
+}
+
+// TestExtraction_SyntaxErrorRecovery tests that collectAll returns the variables of a template
+// with a syntax error, together with the error and its line
+func TestExtraction_SyntaxErrorRecovery(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	opts := DefaultExtractOptions()
+	opts.ErrorPolicy = ErrorPolicyCollectAll
+
+	tests := []struct {
+		name, template string
+		names          []string
+		line           int
+	}{
+		{"unclosed action", "{{.Host}}\n{{.Port\n{{.User}}", []string{"Host"}, 3},
+		{"bad operand", "{{.Host}}\n{{.Port)}}\n{{.User}}", []string{"Host", "User"}, 2},
+		{"open block", "{{if .Debug}}\n{{.Level}}\n{{range .Items}}{{.Name}}", []string{"Debug", "Level", "Items", "Name"}, 3},
+		{"stray end", "{{.Host}}\n{{end}}\n{{.User}}", []string{"Host", "User"}, 2},
+	}
+	for _, tt := range tests {
+		variables, err := parser.ExtractVariablesWithOptions("test.tmpl", tt.template, opts)
+		var extractionErrors *ExtractionErrors
+		if !errors.As(err, &extractionErrors) || len(extractionErrors.Errors) != 1 {
+			t.Errorf("%s: ExtractVariablesWithOptions() error = %v, want one syntax error", tt.name, err)
+			continue
+		}
+		if position := extractionErrors.Errors[0].Position; position == nil || position.Line != tt.line {
+			t.Errorf("%s: syntax error position = %+v, want line %d", tt.name, position, tt.line)
+		}
+		var names []string
+		for _, v := range variables {
+			names = append(names, v.Name)
+		}
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("%s: ExtractVariablesWithOptions() = %v, want %v", tt.name, names, tt.names)
+		}
+	}
+
+	variables, _ := parser.ExtractVariablesWithOptions("test.tmpl", "{{.Port)}}\n  {{.User}}", opts)
+	if len(variables) != 1 || variables[0].Position.Line != 2 || variables[0].Position.Column != 5 {
+		t.Errorf("ExtractVariablesWithOptions() = %+v, want User at line 2, column 5", variables)
+	}
+}
+
+// TestExtraction_TemplateInvocations tests extraction of the data piped into {{template}} and
+// of the invoked template bodies, with fields relative to the data they receive
+func TestExtraction_TemplateInvocations(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{define "server"}}{{.Host}}:{{.Port}}{{template "tls" .TLS}}{{end}}` +
+		`{{define "tls"}}{{.Cert}}{{template "root" $}}{{end}}` +
+		`{{define "root"}}{{.Env}}{{end}}` +
+		`{{template "server" .Data}}{{template "root" .}}{{template "server" (index .Servers 0)}}`
+
+	names, err := parser.ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	expected := []string{
+		"Data", "Data.Host", "Data.Port", "Data.TLS", "Data.TLS.Cert", "Env",
+		"Env",
+		"Servers", "Host", "Port", "TLS", "TLS.Cert", "Env",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+	if len(variables) != len(expected) || variables[1].Name != "Data.Host" || variables[1].Position == nil || variables[1].Position.Column != 22 {
+		t.Errorf("ExtractVariablesWithPositions() = %+v, want Data.Host at column 22", variables)
+	}
+}
+
+// TestExtraction_VariableAssignments tests following $variables assigned a key or a field
+func TestExtraction_VariableAssignments(t *testing.T) {
+	parser := newNamingTestParser()
+	template := `{{$key := "/app/host"}}{{getv $key "localhost"}}` +
+		`{{$db := .Database}}{{$db.Port}}{{$.Name}}` +
+		`{{range $i, $e := .Items}}{{getv $e}}{{end}}` +
+		`{{$dyn := .ConfigKey}}{{getv $dyn}}` +
+		`{{if .On}}{{$key = .Other}}{{end}}{{getv $key}}`
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+	var names []string
+	for _, v := range variables {
+		names = append(names, v.Name)
+	}
+	expected := []string{"/app/host", "Database", "Database.Port", "Name", "Items", "ConfigKey", "On", "Other"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("ExtractVariablesWithPositions() = %v, want %v", names, expected)
+	}
+	if host := variables[0]; host.DefaultValue != "localhost" || host.Position.Column != 11 {
+		t.Errorf("ExtractVariablesWithPositions() /app/host = %+v, want its default and the assigned literal's position", host)
+	}
+	if port := variables[2]; port.Position.Column != 71 || port.Position.Length != 8 {
+		t.Errorf("ExtractVariablesWithPositions() Database.Port position = %+v, want $db.Port", port.Position)
+	}
+
+	lookups, err := parser.DynamicLookups("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("DynamicLookups() error = %v", err)
+	}
+	type found struct{ variable, source string }
+	var got []found
+	for _, lookup := range lookups {
+		got = append(got, found{lookup.Variable, lookup.Source})
+	}
+	if expected := []found{{"$e", ""}, {"$dyn", ".ConfigKey"}, {"$key", ""}}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("DynamicLookups() = %+v, want %+v", got, expected)
+	}
+}
+
+// TestExtraction_ChainedFields tests field access on parenthesized expressions
+func TestExtraction_ChainedFields(t *testing.T) {
+	parser := newNamingTestParser()
+	parser.registry.RegisterFunction(&FunctionDefinition{
+		Name:    "json",
+		Handler: func(key string) (map[string]interface{}, error) { return nil, nil },
+		Extractor: func(args []parse.Node, cycle int) ([]string, error) {
+			return extractStringArgVariable(args, cycle, 1)
+		},
+		ExtractorWithDefaults: func(args []parse.Node, cycle int) ([]VariableInfo, error) {
+			return extractStringArgVariableWithDefaults(args, cycle, 1, -1)
+		},
+	})
+	template := `{{(json "/cfg").database.host}} {{(json "/cfg").port}} {{ ( .Service ).Port }} {{(index .Servers 0).Name}}`
+
+	variables, err := parser.ExtractVariablesWithOptions("test.tmpl", template, ExtractOptions{Deduplicate: true})
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithOptions() error = %v", err)
+	}
+	type found struct {
+		name   string
+		fields []string
+	}
+	var got []found
+	for _, v := range variables {
+		got = append(got, found{v.Name, v.Fields})
+	}
+	expected := []found{{"/cfg", []string{"database.host", "port"}}, {"Service", nil}, {"Service.Port", nil}, {"Servers", nil}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("ExtractVariablesWithOptions() = %+v, want %+v", got, expected)
+	}
+	if port := variables[2].Position; port.Column != 71 || port.Length != 5 {
+		t.Errorf("ExtractVariablesWithOptions() Service.Port position = %+v, want .Port", port)
+	}
+
+	names, err := parser.ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"/cfg", "/cfg", "Service", "Service.Port", "Servers"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+}
+
+// TestExtraction_FilterAndPage tests filtering by prefix, function and required, and paging
+func TestExtraction_FilterAndPage(t *testing.T) {
+	parser := newNamingTestParser()
+	parser.registry.RegisterFunction(&FunctionDefinition{
+		Name:    "required",
+		Handler: func(message string, value interface{}) (interface{}, error) { return value, nil },
+	})
+	template := `{{getv "/app/a"}}{{getv "/app/b"}}{{.App}}{{getv "/db/host"}}{{required "x" .Port}}{{.Port}}{{getv "/app/c"}}{{printf "%s" .Name}}`
+
+	tests := []struct {
+		name      string
+		opts      ExtractOptions
+		variables []string
+		total     int
+	}{
+		{"prefix", ExtractOptions{Prefix: "/app/"}, []string{"/app/a", "/app/b", "/app/c"}, 3},
+		{"function", ExtractOptions{Function: "getv", Sort: SortAlpha}, []string{"/app/a", "/app/b", "/app/c", "/db/host"}, 4},
+		{"bare fields count as no function", ExtractOptions{Function: "printf"}, []string{"Name"}, 1},
+		{"required by any occurrence", ExtractOptions{RequiredOnly: true}, []string{"Port", "Port"}, 2},
+		{"page", ExtractOptions{Deduplicate: true, Offset: 2, Limit: 3}, []string{"App", "/db/host", "Port"}, 7},
+		{"page past the end", ExtractOptions{Offset: 20, Limit: 3}, nil, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := parser.ExtractVariablesPage("test.tmpl", template, tt.opts)
+			if err != nil {
+				t.Fatalf("ExtractVariablesPage() error = %v", err)
+			}
+			var names []string
+			for _, v := range page.Variables {
+				names = append(names, v.Name)
+			}
+			if !reflect.DeepEqual(names, tt.variables) || page.Total != tt.total {
+				t.Errorf("ExtractVariablesPage() = %v of %d, want %v of %d", names, page.Total, tt.variables, tt.total)
+			}
+		})
+	}
+
+	if _, err := parser.ExtractVariablesPage("test.tmpl", template, ExtractOptions{Limit: -1}); err == nil {
+		t.Errorf("ExtractVariablesPage() with a negative limit succeeded")
+	}
+}
+
+// TestExtraction_IndexKeys tests map keys read with the index builtin
+func TestExtraction_IndexKeys(t *testing.T) {
+	parser := newNamingTestParser()
+	parser.registry.RegisterFunction(&FunctionDefinition{
+		Name:    "json",
+		Handler: func(key string) (map[string]interface{}, error) { return nil, nil },
+		ExtractorWithDefaults: func(args []parse.Node, cycle int) ([]VariableInfo, error) {
+			return extractStringArgVariableWithDefaults(args, cycle, 1, -1)
+		},
+	})
+	template := `{{index .Settings "timeout"}}{{$k := "db"}}{{(index .Settings $k "pool").size}}` +
+		`{{index .Servers 0}}{{index (json "/cfg") "retries"}}`
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+	type found struct {
+		name   string
+		fields []string
+	}
+	var got []found
+	for _, v := range variables {
+		got = append(got, found{v.Name, v.Fields})
+	}
+	expected := []found{
+		{"Settings", nil}, {"Settings.timeout", nil},
+		{"Settings", nil}, {"Settings.db.pool", nil}, {"Settings.db.pool.size", nil},
+		{"Servers", nil},
+		{"/cfg", []string{"retries"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("ExtractVariablesWithPositions() = %+v, want %+v", got, expected)
+	}
+	if timeout := variables[1].Position; timeout.Column != 19 || timeout.Length != 9 {
+		t.Errorf("ExtractVariablesWithPositions() Settings.timeout position = %+v, want the \"timeout\" key", timeout)
+	}
+
+	names, err := parser.ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"Settings", "Settings.timeout", "Settings", "Settings.db.pool", "Settings.db.pool.size", "Servers"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+}
+
+// TestExtraction_UninvokedDefines tests extraction from {{define}} bodies no {{template}} action
+// reaches, such as a file holding only a library of templates
+func TestExtraction_UninvokedDefines(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{block "header" .}}{{.Title}}{{end}}` +
+		`{{define "rows"}}{{range .Items}}{{.}}{{end}}{{template "footer" .}}{{end}}` +
+		`{{define "footer"}}{{.Company}}{{end}}` +
+		`{{define "unused"}}{{.Debug}}{{end}}`
+
+	names, err := parser.ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"Title", "Items", "Company", "Debug"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+	if len(variables) != 4 || variables[1].Name != "Items" || variables[1].Type != TypeArray {
+		t.Fatalf("ExtractVariablesWithPositions() = %+v, want Items typed as an array", variables)
+	}
+	if debug := variables[3]; debug.Position == nil || debug.Position.Column != 172 {
+		t.Errorf("ExtractVariablesWithPositions() Debug position = %+v, want column 172", debug.Position)
+	}
+}
+
+// TestExtraction_PipedArguments tests values piped into a function as its last argument
+func TestExtraction_PipedArguments(t *testing.T) {
+	parser := newNamingTestParser()
+	parser.registry.RegisterFunction(&FunctionDefinition{
+		Name:      "json",
+		Handler:   func(key string) (map[string]interface{}, error) { return nil, nil },
+		Extractor: func(args []parse.Node, cycle int) ([]string, error) { return extractArgVariable(args, cycle, 1, true) },
+		ExtractorWithDefaults: func(args []parse.Node, cycle int) ([]VariableInfo, error) {
+			return extractStringArgVariableWithDefaults(args, cycle, 1, -1)
+		},
+	})
+	template := `{{"username" | getv}}{{"8080" | getv "/port"}}{{.Path | printf "%s"}}{{"/cfg" | json | print}}`
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+	type found struct {
+		name, defaultValue string
+		column             int
+	}
+	var got []found
+	for _, v := range variables {
+		got = append(got, found{v.Name, v.DefaultValue, v.Position.Column})
+	}
+	expected := []found{{"username", "", 3}, {"/port", "8080", 38}, {"Path", "", 49}, {"/cfg", "", 72}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExtractVariablesWithPositions() = %+v, want %+v", got, expected)
+	}
+
+	names, err := parser.ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"Path", "/cfg"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+}
+
+// TestExtraction_BreakContinue tests that {{break}} and {{continue}} inside range are traversed
+func TestExtraction_BreakContinue(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{range .Items}}{{if .Skip}}{{continue}}{{end}}{{if $.Limit}}{{break}}{{end}}{{end}}{{/* done */}}{{.Footer}}`
+
+	names, err := parser.ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"Items", "Skip", "Limit", "Footer"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+}
+
+// TestChildNodes tests the generic descent used for node types the walkers have no case for
+func TestChildNodes(t *testing.T) {
+	tree, err := parse.Parse("test", `{{if .A}}{{.B}}{{else}}{{.C}}{{end}}`, "", "", nil)
+	if err != nil {
+		t.Fatalf("parse error = %v", err)
+	}
+	ifNode := tree["test"].Root.Nodes[0].(*parse.IfNode)
+	children := childNodes(ifNode)
+	if len(children) != 3 || children[0] != parse.Node(ifNode.Pipe) || children[1] != parse.Node(ifNode.List) || children[2] != parse.Node(ifNode.ElseList) {
+		t.Errorf("childNodes() = %v, want the pipe, list and else list", children)
+	}
+	if children := childNodes(&parse.CommandNode{NodeType: parse.NodeCommand}); len(children) != 0 {
+		t.Errorf("childNodes() of an empty command = %v, want none", children)
+	}
+	// Node types without a case are searched through their exported fields
+	if fields := appendChildNodes(nil, reflect.ValueOf(*ifNode)); !reflect.DeepEqual(fields, children) {
+		t.Errorf("appendChildNodes() = %v, want %v", fields, children)
+	}
+}
+
+// TestWalkNodes tests that the shared walker visits nodes in template order and skips the
+// children of nodes the visitor declines
+func TestWalkNodes(t *testing.T) {
+	tree, err := parse.Parse("test", `{{$x := .A}}{{if .B}}{{(.C).F}}{{end}}{{with .D}}{{.E}}{{end}}`, "", "", nil)
+	if err != nil {
+		t.Fatalf("parse error = %v", err)
+	}
+	var visited []string
+	walkNodes(tree["test"].Root, 0, func(node parse.Node, depth int) bool {
+		switch node := node.(type) {
+		case *parse.FieldNode:
+			visited = append(visited, node.String())
+		case *parse.VariableNode:
+			visited = append(visited, node.String())
+		case *parse.WithNode:
+			return false
+		}
+		return true
+	})
+	if expected := []string{"$x", ".A", ".B", ".C"}; !reflect.DeepEqual(visited, expected) {
+		t.Errorf("walkNodes() visited %v, want %v", visited, expected)
+	}
+}
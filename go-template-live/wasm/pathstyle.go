@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Path styles accepted by RenderOptions.PathStyle for the filepath functions
+const (
+	PathStylePOSIX   = "posix"
+	PathStyleWindows = "windows"
+)
+
+// Line endings accepted by RenderOptions.LineEnding
+const (
+	LineEndingLF   = "lf"
+	LineEndingCRLF = "crlf"
+)
+
+// validatePathStyle checks a RenderOptions.PathStyle value
+func validatePathStyle(style string) error {
+	switch style {
+	case PathStylePOSIX, PathStyleWindows:
+		return nil
+	}
+	return fmt.Errorf("unknown path style %q, expected posix or windows", style)
+}
+
+// validateLineEnding checks a RenderOptions.LineEnding value
+func validateLineEnding(ending string) error {
+	switch ending {
+	case "", LineEndingLF, LineEndingCRLF:
+		return nil
+	}
+	return fmt.Errorf("unknown line ending %q, expected lf or crlf", ending)
+}
+
+// applyLineEnding converts the line endings of output; lf leaves output as rendered
+func applyLineEnding(output, ending string) string {
+	if ending != LineEndingCRLF {
+		return output
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(output, "\r\n", "\n"), "\n", "\r\n")
+}
+
+// filepathBase returns the last element of p in the render path style, like filepath.Base
+func (env *renderEnv) filepathBase(p string) string {
+	if env.pathStyle != PathStyleWindows {
+		return path.Base(p)
+	}
+	if p == "" {
+		return "."
+	}
+	p = p[len(windowsVolume(p)):]
+	for len(p) > 0 && isWindowsSeparator(p[len(p)-1]) {
+		p = p[:len(p)-1]
+	}
+	if p == "" {
+		return `\`
+	}
+	if i := strings.LastIndexAny(p, `\/`); i >= 0 {
+		p = p[i+1:]
+	}
+	return p
+}
+
+// filepathDir returns all but the last element of p in the render path style, like filepath.Dir
+func (env *renderEnv) filepathDir(p string) string {
+	if env.pathStyle != PathStyleWindows {
+		return path.Dir(p)
+	}
+	volume := windowsVolume(p)
+	i := len(p) - 1
+	for i >= len(volume) && !isWindowsSeparator(p[i]) {
+		i--
+	}
+	dir := windowsClean(p[len(volume) : i+1])
+	if dir == "." && len(volume) > 2 {
+		// A UNC volume such as \\host\share is its own directory
+		return volume
+	}
+	return volume + dir
+}
+
+// filepathJoin joins path elements in the render path style, like filepath.Join
+func (env *renderEnv) filepathJoin(elem ...string) string {
+	if env.pathStyle != PathStyleWindows {
+		return path.Join(elem...)
+	}
+	var parts []string
+	for _, e := range elem {
+		if e != "" {
+			parts = append(parts, e)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return windowsClean(strings.Join(parts, `\`))
+}
+
+// windowsClean is filepath.Clean for Windows paths: forward slashes become backslashes and
+// . and .. elements are resolved, keeping the volume name
+func windowsClean(p string) string {
+	volume := windowsVolume(p)
+	rest := strings.ReplaceAll(p[len(volume):], `\`, "/")
+	if rest == "" {
+		if len(volume) > 2 {
+			return volume
+		}
+		return volume + "."
+	}
+	return volume + strings.ReplaceAll(path.Clean(rest), "/", `\`)
+}
+
+// windowsVolume returns the leading volume name of p: a drive such as C: or a UNC share
+// such as \\host\share
+func windowsVolume(p string) string {
+	if len(p) >= 2 && p[1] == ':' && isDriveLetter(p[0]) {
+		return p[:2]
+	}
+	if len(p) >= 5 && isWindowsSeparator(p[0]) && isWindowsSeparator(p[1]) && !isWindowsSeparator(p[2]) && p[2] != '.' {
+		// \\host\share: skip the host, then the share
+		n := 3
+		for n < len(p)-1 && !isWindowsSeparator(p[n]) {
+			n++
+		}
+		n++
+		if n < len(p) && !isWindowsSeparator(p[n]) {
+			for n < len(p) && !isWindowsSeparator(p[n]) {
+				n++
+			}
+			return p[:n]
+		}
+	}
+	return ""
+}
+
+func isWindowsSeparator(c byte) bool {
+	return c == '\\' || c == '/'
+}
+
+func isDriveLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
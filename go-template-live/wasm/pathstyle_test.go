@@ -0,0 +1,67 @@
+//go:build !js
+// +build !js
+
+package main
+
+import "testing"
+
+// TestWindowsPathFunctions tests the filepath functions under the windows path style
+// against the results of path/filepath on Windows
+func TestWindowsPathFunctions(t *testing.T) {
+	env := &renderEnv{pathStyle: PathStyleWindows}
+
+	tests := []struct {
+		path, base, dir string
+	}{
+		{`C:\Program Files\App\app.exe`, `app.exe`, `C:\Program Files\App`},
+		{`C:/ProgramData/app/conf/`, `conf`, `C:\ProgramData\app\conf`},
+		{`C:\app.exe`, `app.exe`, `C:\`},
+		{`C:app.exe`, `app.exe`, `C:.`},
+		{`\\server\share\logs\app.log`, `app.log`, `\\server\share\logs`},
+		{`\\server\share`, `\`, `\\server\share`},
+		{`logs\..\app.log`, `app.log`, `.`},
+		{`app.log`, `app.log`, `.`},
+		{`C:\`, `\`, `C:\`},
+		{``, `.`, `.`},
+	}
+	for _, tt := range tests {
+		if got := env.filepathBase(tt.path); got != tt.base {
+			t.Errorf("filepathBase(%q) = %q, want %q", tt.path, got, tt.base)
+		}
+		if got := env.filepathDir(tt.path); got != tt.dir {
+			t.Errorf("filepathDir(%q) = %q, want %q", tt.path, got, tt.dir)
+		}
+	}
+
+	joins := []struct {
+		elem     []string
+		expected string
+	}{
+		{[]string{`C:\ProgramData`, "app", "conf/app.ini"}, `C:\ProgramData\app\conf\app.ini`},
+		{[]string{`C:\app`, `..\logs`, ""}, `C:\logs`},
+		{[]string{`\\server\share`, "logs"}, `\\server\share\logs`},
+		{[]string{"", ""}, ""},
+	}
+	for _, tt := range joins {
+		if got := env.filepathJoin(tt.elem...); got != tt.expected {
+			t.Errorf("filepathJoin(%q) = %q, want %q", tt.elem, got, tt.expected)
+		}
+	}
+}
+
+// TestPathStyleOptions tests option validation, the posix default and line ending conversion
+func TestPathStyleOptions(t *testing.T) {
+	env := defaultRenderEnv()
+	if env.filepathJoin("/etc", "app", "../app.conf") != "/etc/app.conf" || env.filepathBase(`C:\app.exe`) != `C:\app.exe` {
+		t.Errorf("filepath functions do not default to posix paths")
+	}
+	if _, err := NewEnvironment().newRenderEnv(RenderOptions{PathStyle: "dos"}); err == nil {
+		t.Errorf("newRenderEnv() accepted an unknown path style")
+	}
+	if err := validateLineEnding("cr"); err == nil {
+		t.Errorf("validateLineEnding() accepted an unknown line ending")
+	}
+	if got := applyLineEnding("a\nb\r\nc", LineEndingCRLF); got != "a\r\nb\r\nc" {
+		t.Errorf("applyLineEnding() = %q, want CRLF line endings", got)
+	}
+}
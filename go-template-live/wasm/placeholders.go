@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// placeholderOutput is rendered in place of a call to a function whose function set is not loaded
+func placeholderOutput(name string) string {
+	return "[" + name + ": function set not loaded]"
+}
+
+// registerPlaceholderFunctions registers a stub for every function of the custom and Confd
+// profiles that the registry does not provide, so templates written for those profiles parse
+// and render with visible placeholders instead of failing with "function not defined"
+func registerPlaceholderFunctions(registry *FunctionRegistry) {
+	for _, profile := range []string{ProfileCustom, ProfileConfd} {
+		for _, name := range profileFunctionTables[profile] {
+			if registry.HasFunction(name) {
+				continue
+			}
+			registry.RegisterFunction(&FunctionDefinition{
+				Name:                  name,
+				Description:           "Placeholder: the function set providing " + name + " is not loaded in this build",
+				Handler:               placeholderMinimalHandler,
+				Extractor:             extractAllArgVariables,
+				ExtractorWithDefaults: extractAllArgVariablesInfo,
+				Placeholder:           true,
+			})
+		}
+	}
+}
+
+func placeholderMinimalHandler(args ...interface{}) string { return "" }
+
+// placeholderRenderFuncs returns render implementations of the placeholder functions of registry
+func placeholderRenderFuncs(registry *FunctionRegistry) map[string]interface{} {
+	funcs := make(map[string]interface{})
+	for name, def := range registry.functions {
+		if !def.Placeholder {
+			continue
+		}
+		output := placeholderOutput(name)
+		funcs[name] = func(args ...interface{}) string { return output }
+	}
+	return funcs
+}
+
+// placeholderWarning names the placeholder functions called by a template, or returns ""
+func (r *FunctionRegistry) placeholderWarning(root *parse.ListNode) string {
+	if !r.hasPlaceholders {
+		return ""
+	}
+	seen := make(map[string]bool)
+	collectIdentifiers(root, seen)
+
+	var names []string
+	for name := range seen {
+		if def, ok := r.functions[name]; ok && def.Placeholder {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return "function set not loaded: " + strings.Join(names, ", ") + " rendered as placeholders; use a custom or confd build to render them"
+}
+
+// Placeholders do not know their arguments' meaning, so only field references are extracted
+func extractAllArgVariables(args []parse.Node, cycle int) ([]string, error) {
+	var result []string
+	for i := 1; i < len(args); i++ {
+		variables, err := extractArgVariable(args, cycle, i, false)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, variables...)
+	}
+	return result, nil
+}
+
+func extractAllArgVariablesInfo(args []parse.Node, cycle int) ([]VariableInfo, error) {
+	var result []VariableInfo
+	for i := 1; i < len(args); i++ {
+		variables, err := extractArgVariableWithDefaults(args, cycle, i, -1, false)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, variables...)
+	}
+	return result, nil
+}
@@ -0,0 +1,46 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestPlaceholderFunctions tests the stand-ins registered by builds without a function set
+func TestPlaceholderFunctions(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registerPlaceholderFunctions(registry)
+	renderer := NewRenderer(registry, func(variables map[string]interface{}, env *renderEnv) map[string]interface{} {
+		return placeholderRenderFuncs(registry)
+	})
+
+	template := `host={{getv "/app/host" "localhost"}} name={{toUpper .Name}} {{if exists "/app/debug"}}debug{{end}}`
+	result, err := renderer.Render(template, map[string]interface{}{"Name": "web"}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	expected := "host=[getv: function set not loaded] name=[toUpper: function set not loaded] debug"
+	if result.Output != expected {
+		t.Errorf("Render() output = %q, want %q", result.Output, expected)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "function set not loaded: exists, getv, toUpper") {
+		t.Errorf("Render() warnings = %v, want the placeholder functions listed", result.Warnings)
+	}
+
+	variables, err := NewParser(registry).ExtractVariables("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	if expected := []string{"Name"}; !reflect.DeepEqual(variables, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", variables, expected)
+	}
+
+	// Templates using only builtins render without warnings
+	result, err = renderer.Render(`{{.Name}}`, map[string]interface{}{"Name": "web"}, RenderOptions{})
+	if err != nil || len(result.Warnings) != 0 {
+		t.Errorf("Render() = %+v, %v, want no warnings", result, err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Post-processors accepted by RenderOptions.PostProcess
+const (
+	// PostProcessJSONPretty indents JSON output by two spaces, keeping the key order
+	PostProcessJSONPretty = "json-pretty"
+	// PostProcessYAMLNormalize re-emits YAML output with two-space indentation and consistent
+	// quoting, keeping key order and comments
+	PostProcessYAMLNormalize = "yaml-normalize"
+	// PostProcessSortKeys sorts the object keys of JSON or YAML output at every level; JSON
+	// output is re-emitted indented by two spaces
+	PostProcessSortKeys = "sort-keys"
+)
+
+// validatePostProcess checks the names of RenderOptions.PostProcess
+func validatePostProcess(names []string) error {
+	for _, name := range names {
+		switch name {
+		case PostProcessJSONPretty, PostProcessYAMLNormalize, PostProcessSortKeys:
+		default:
+			return fmt.Errorf("unknown post-processor %q, expected one of json-pretty, yaml-normalize, sort-keys", name)
+		}
+	}
+	return nil
+}
+
+// postProcess applies post-processors to output in order; a post-processor that cannot handle
+// the output leaves it unchanged and is reported as a warning
+func postProcess(output string, names []string) (string, []string) {
+	var warnings []string
+	for _, name := range names {
+		var processed string
+		var err error
+		switch name {
+		case PostProcessJSONPretty:
+			processed, err = prettyJSON(output)
+		case PostProcessYAMLNormalize:
+			processed, err = normalizeYAML(output, false)
+		case PostProcessSortKeys:
+			if json.Valid([]byte(output)) {
+				processed, err = sortJSONKeys(output)
+			} else {
+				processed, err = normalizeYAML(output, true)
+			}
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("post-processor %s skipped: %v", name, err))
+			continue
+		}
+		output = processed
+	}
+	return output, warnings
+}
+
+// prettyJSON indents a JSON document by two spaces
+func prettyJSON(output string) (string, error) {
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(strings.TrimSpace(output)), "", "  "); err != nil {
+		return "", fmt.Errorf("invalid JSON: %v", err)
+	}
+	b.WriteByte('\n')
+	return b.String(), nil
+}
+
+// sortJSONKeys re-emits a JSON document with sorted keys, indented by two spaces
+// Numbers are kept as written
+func sortJSONKeys(output string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// normalizeYAML re-emits every document of a YAML stream with two-space indentation, sorting
+// mapping keys when sortKeys is set
+func normalizeYAML(output string, sortKeys bool) (string, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(output))
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid YAML: %v", err)
+		}
+		if sortKeys {
+			sortYAMLKeys(&document)
+		}
+		if err := encoder.Encode(&document); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// sortYAMLKeys sorts the key/value pairs of every mapping below node by key
+func sortYAMLKeys(node *yaml.Node) {
+	for _, child := range node.Content {
+		sortYAMLKeys(child)
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i][0].Value < pairs[j][0].Value
+	})
+	for i, pair := range pairs {
+		node.Content[2*i], node.Content[2*i+1] = pair[0], pair[1]
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostProcess(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		steps  []string
+		want   string
+	}{
+		{"json pretty", `{"b": 1, "a": [1,2], "url": "a&b"}`, []string{PostProcessJSONPretty},
+			"{\n  \"b\": 1,\n  \"a\": [\n    1,\n    2\n  ],\n  \"url\": \"a&b\"\n}\n"},
+		{"json sort keys", `{"b": {"d": 1.50, "c": 2}, "a": "<x>"}`, []string{PostProcessSortKeys},
+			"{\n  \"a\": \"<x>\",\n  \"b\": {\n    \"c\": 2,\n    \"d\": 1.50\n  }\n}\n"},
+		{"yaml normalize", "server:\n    port: 80 # http\n    hosts:\n    - a\n", []string{PostProcessYAMLNormalize},
+			"server:\n  port: 80 # http\n  hosts:\n    - a\n"},
+		{"yaml sort keys", "b: 1\na:\n  z: true\n  y: false\n---\nd: 1\nc: 2\n", []string{PostProcessSortKeys},
+			"a:\n  y: false\n  z: true\nb: 1\n---\nc: 2\nd: 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := postProcess(tt.output, tt.steps)
+			if got != tt.want || len(warnings) != 0 {
+				t.Errorf("postProcess() = %q, %v, want %q", got, warnings, tt.want)
+			}
+		})
+	}
+
+	got, warnings := postProcess("not: [json", []string{PostProcessJSONPretty})
+	if got != "not: [json" || len(warnings) != 1 || !strings.Contains(warnings[0], "json-pretty skipped") {
+		t.Errorf("postProcess() = %q, %v, want the output unchanged with a warning", got, warnings)
+	}
+}
+
+func TestRender_PostProcess(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	template := `{"name": "{{.Name}}", "enabled": true}`
+
+	result, err := renderer.Render(template, map[string]interface{}{"Name": "web"}, RenderOptions{
+		PostProcess:      []string{PostProcessSortKeys},
+		OutputValidation: OutputValidationJSON,
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "{\n  \"enabled\": true,\n  \"name\": \"web\"\n}\n"
+	if result.Output != want || result.OutputErrors != nil {
+		t.Errorf("Render() = %q, %v, want %q", result.Output, result.OutputErrors, want)
+	}
+
+	if _, err := renderer.Render(template, nil, RenderOptions{PostProcess: []string{"xml-pretty"}}); err == nil {
+		t.Error("Render() accepted an unknown post-processor")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"unicode"
+)
+
+// pragmaPrefix starts a variable declaration inside a template comment:
+//
+//	{{/* @var db_host type=string default="localhost" description="Database host" */}}
+const pragmaPrefix = "@var"
+
+// knownPragmaTypes are the types a @var pragma may declare
+var knownPragmaTypes = map[string]bool{
+	TypeString: true, TypeNumber: true, TypeBool: true, TypeArray: true, TypeJSONString: true,
+}
+
+// VariablePragma is a variable declaration written by the template author
+type VariablePragma struct {
+	Name        string
+	Type        string
+	Default     string
+	Description string
+}
+
+// parsePragmas collects the @var declarations found in the comments of a template
+func parsePragmas(fileName, fileContent string) (map[string]VariablePragma, error) {
+	lines, err := commentLines(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	pragmas := make(map[string]VariablePragma)
+	for _, line := range lines {
+		if !strings.HasPrefix(line.text, pragmaPrefix+" ") {
+			continue
+		}
+		pragma, err := parsePragma(strings.TrimPrefix(line.text, pragmaPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pragma in %s at line %d: %v", pragmaPrefix, fileName, line.line, err)
+		}
+		pragmas[pragma.Name] = pragma
+	}
+	return pragmas, nil
+}
+
+// commentLine is one line of a template comment, trimmed of comment markers and indentation
+type commentLine struct {
+	text string
+	// line is the 1-based line of the comment in the template
+	line int
+}
+
+// commentLines returns the lines of every comment in a template, in document order
+func commentLines(fileName, fileContent string) ([]commentLine, error) {
+	tree := parse.New(fileName)
+	tree.Mode = parse.ParseComments | parse.SkipFuncCheck
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(fileContent, "", "", treeSet); err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
+	}
+
+	var comments []*parse.CommentNode
+	for _, t := range treeSet {
+		collectComments(t.Root, &comments, 0)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].Position() < comments[j].Position() })
+
+	var lines []commentLine
+	for _, comment := range comments {
+		lineNumber := strings.Count(fileContent[:comment.Position()], "\n") + 1
+		text := strings.TrimSuffix(strings.TrimPrefix(comment.Text, "/*"), "*/")
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, commentLine{text: strings.TrimLeft(strings.TrimSpace(line), "* "), line: lineNumber})
+		}
+	}
+	return lines, nil
+}
+
+// parsePragma parses "name key=value key=\"quoted value\" ..."
+func parsePragma(text string) (VariablePragma, error) {
+	tokens, err := splitPragmaTokens(text)
+	if err != nil {
+		return VariablePragma{}, err
+	}
+	if len(tokens) == 0 || strings.Contains(tokens[0], "=") {
+		return VariablePragma{}, fmt.Errorf("missing variable name")
+	}
+
+	pragma := VariablePragma{Name: strings.TrimPrefix(tokens[0], ".")}
+	for _, token := range tokens[1:] {
+		key, value, ok := strings.Cut(token, "=")
+		if !ok {
+			return VariablePragma{}, fmt.Errorf("expected key=value, got %q", token)
+		}
+		if strings.HasPrefix(value, "\"") {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return VariablePragma{}, fmt.Errorf("invalid quoted value for %s: %v", key, err)
+			}
+			value = unquoted
+		}
+		switch key {
+		case "type":
+			if !knownPragmaTypes[value] {
+				return VariablePragma{}, fmt.Errorf("unknown type %q", value)
+			}
+			pragma.Type = value
+		case "default":
+			pragma.Default = value
+		case "description":
+			pragma.Description = value
+		default:
+			return VariablePragma{}, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return pragma, nil
+}
+
+// splitPragmaTokens splits on whitespace, keeping double-quoted values (with escapes) together
+func splitPragmaTokens(text string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inQuotes, escaped := false, false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && unicode.IsSpace(r):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quoted value")
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// collectComments appends every comment node under node
+func collectComments(node parse.Node, comments *[]*parse.CommentNode, depth int) {
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		if comment, ok := node.(*parse.CommentNode); ok {
+			*comments = append(*comments, comment)
+		}
+		return true
+	})
+}
+
+// applyPragmas merges declarations into the extracted occurrences
+// A declared type overrides the inferred hint; a declared default only fills in a missing one
+func applyPragmas(pragmas map[string]VariablePragma, variables []VariableInfo) {
+	for i := range variables {
+		pragma, ok := pragmas[variables[i].Name]
+		if !ok {
+			continue
+		}
+		if pragma.Type != "" {
+			variables[i].Type = pragma.Type
+		}
+		if variables[i].DefaultValue == "" {
+			variables[i].DefaultValue = pragma.Default
+		}
+		variables[i].Description = pragma.Description
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestExtraction_Pragmas tests that @var comment pragmas are merged into extracted variables
+func TestExtraction_Pragmas(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{/* @var db_host type=string default="localhost" description="Database host" */}}
+{{- /*
+  * @var Replicas type=number default=3 description="Number of \"hot\" replicas"
+  * @var Tags description=unused
+*/ -}}
+host={{.db_host}}
+{{range .Replicas}}{{end}}{{if .Debug}}debug{{end}}`
+
+	variables, err := parser.ExtractVariablesAggregated("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesAggregated() error = %v", err)
+	}
+	for i := range variables {
+		variables[i].Occurrences = nil
+	}
+
+	expected := []VariableInfo{
+		{Name: "db_host", DefaultValue: "localhost", Type: TypeString, Description: "Database host", Count: 1},
+		{Name: "Replicas", DefaultValue: "3", Type: TypeNumber, Description: `Number of "hot" replicas`, Count: 1},
+		{Name: "Debug", Type: TypeBool, Count: 1},
+	}
+	if !reflect.DeepEqual(variables, expected) {
+		t.Errorf("ExtractVariablesAggregated() = %+v, want %+v", variables, expected)
+	}
+
+	plain, err := parser.ExtractVariablesWithDefaults("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+	}
+	if plain[0].DefaultValue != "localhost" || plain[0].Description != "" {
+		t.Errorf("ExtractVariablesWithDefaults() = %+v, want pragma default without description", plain[0])
+	}
+}
+
+// TestExtraction_InvalidPragmas tests that malformed pragmas are reported with their line
+func TestExtraction_InvalidPragmas(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{"unknown key", "{{.A}}\n{{/* @var A color=red */}}", "at line 2: unknown key \"color\""},
+		{"unknown type", "{{/* @var A type=date */}}", "unknown type \"date\""},
+		{"unterminated quote", "{{/* @var A description=\"open */}}", "unterminated quoted value"},
+		{"missing name", "{{/* @var type=string */}}", "missing variable name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser.ExtractVariablesWithDefaults("test.tmpl", tt.template)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExtractVariablesWithDefaults() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"text/template/parse"
+)
+
+// ProfileDivergence is a function call whose behavior differs between function profiles
+type ProfileDivergence struct {
+	Function string   `json:"function"`
+	Position Position `json:"position"`
+	// Behaviors maps each compared profile to what the call does under it
+	Behaviors map[string]string `json:"behaviors"`
+}
+
+// Behaviors of functions that every compared profile treats alike, unless listed in profileBehaviors
+const (
+	behaviorAvailable   = "available"
+	behaviorUnavailable = "not defined: the template fails to parse"
+)
+
+// profileBehaviors describes the functions whose behavior differs between the profiles defining them
+var profileBehaviors = map[string]map[string]string{
+	"json": {
+		ProfileCustom: "parses the JSON object stored under the key; a missing key fails the render",
+		ProfileConfd:  "parses the JSON object stored under the key; a missing key renders no value",
+	},
+	"jsonArray": {
+		ProfileCustom: "parses the JSON array stored under the key; a missing key fails the render",
+		ProfileConfd:  "parses the JSON array stored under the key; a missing key renders no value",
+	},
+}
+
+// AnalyzeProfileDivergence reports the function calls of a template that behave differently
+// under the given profiles (every profile when none are given), in document order, so
+// switching profiles does not silently change what a template renders
+// Each build only compiles one profile, so the template is parsed without checking functions and
+// calls are compared against the generated profile tables and profileBehaviors
+func AnalyzeProfileDivergence(fileName, fileContent string, profiles []string) ([]ProfileDivergence, error) {
+	if len(profiles) == 0 {
+		profiles = []string{ProfileOfficial, ProfileCustom, ProfileConfd, ProfileSprig, ProfileGomplate, ProfileHelm}
+	}
+	defined := make(map[string]map[string]bool, len(profiles))
+	for _, profile := range profiles {
+		names, ok := profileFunctionTables[profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", profile)
+		}
+		defined[profile] = make(map[string]bool, len(names))
+		for _, name := range names {
+			defined[profile][name] = true
+		}
+	}
+
+	tree := parse.New(fileName)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(fileContent, "", "", treeSet); err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
+	}
+
+	var calls []*parse.IdentifierNode
+	for _, t := range treeSet {
+		calls = collectCalls(t.Root, calls, 0)
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Pos < calls[j].Pos })
+
+	index := newLineIndex(fileContent)
+	divergences := []ProfileDivergence{}
+	for _, call := range calls {
+		if builtinFunctions[call.Ident] {
+			continue
+		}
+		behaviors := make(map[string]string, len(profiles))
+		distinct := make(map[string]bool)
+		for _, profile := range profiles {
+			behavior := behaviorUnavailable
+			if defined[profile][call.Ident] {
+				behavior = behaviorAvailable
+				if described, ok := profileBehaviors[call.Ident][profile]; ok {
+					behavior = described
+				}
+			}
+			behaviors[profile] = behavior
+			distinct[behavior] = true
+		}
+		if len(distinct) < 2 {
+			continue
+		}
+		position := nodePosition(call.Pos, len(call.Ident))
+		index.resolve(fileContent, position)
+		divergences = append(divergences, ProfileDivergence{Function: call.Ident, Position: *position, Behaviors: behaviors})
+	}
+	return divergences, nil
+}
+
+// collectCalls appends the identifiers of the functions called under node to calls
+func collectCalls(node parse.Node, calls []*parse.IdentifierNode, depth int) []*parse.IdentifierNode {
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		if ident, ok := node.(*parse.IdentifierNode); ok {
+			calls = append(calls, ident)
+		}
+		return true
+	})
+	return calls
+}
@@ -0,0 +1,48 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAnalyzeProfileDivergence tests the detection of calls behaving differently between profiles
+func TestAnalyzeProfileDivergence(t *testing.T) {
+	template := "{{getv \"/db/host\"}}\n{{range (json \"/cfg\").hosts}}{{toUpper .}}{{end}}{{printf \"%d\" 1}}{{.Port}}"
+
+	divergences, err := AnalyzeProfileDivergence("test.tmpl", template, []string{ProfileCustom, ProfileConfd})
+	if err != nil {
+		t.Fatalf("AnalyzeProfileDivergence() error = %v", err)
+	}
+	var functions []string
+	for _, d := range divergences {
+		functions = append(functions, d.Function)
+	}
+	if expected := []string{"json", "toUpper"}; !reflect.DeepEqual(functions, expected) {
+		t.Fatalf("AnalyzeProfileDivergence() functions = %v, want %v", functions, expected)
+	}
+	if json := divergences[0]; json.Position.Line != 2 || json.Position.Column != 10 || json.Behaviors[ProfileCustom] == json.Behaviors[ProfileConfd] {
+		t.Errorf("AnalyzeProfileDivergence() json = %+v", json)
+	}
+	if toUpper := divergences[1]; toUpper.Behaviors[ProfileCustom] != behaviorUnavailable || toUpper.Behaviors[ProfileConfd] != behaviorAvailable {
+		t.Errorf("AnalyzeProfileDivergence() toUpper = %+v", toUpper)
+	}
+
+	// Every profile by default: getv is missing from official
+	divergences, err = AnalyzeProfileDivergence("test.tmpl", `{{define "x"}}{{getv "a"}}{{end}}{{secret "b"}}`, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeProfileDivergence() error = %v", err)
+	}
+	if len(divergences) != 2 || divergences[0].Function != "getv" || divergences[0].Behaviors[ProfileOfficial] != behaviorUnavailable {
+		t.Errorf("AnalyzeProfileDivergence() = %+v, want getv and secret unavailable in official", divergences)
+	}
+
+	if _, err := AnalyzeProfileDivergence("test.tmpl", "{{.A}}", []string{"legacy"}); err == nil {
+		t.Errorf("AnalyzeProfileDivergence() accepted an unknown profile")
+	}
+	if _, err := AnalyzeProfileDivergence("test.tmpl", "{{if}}", nil); err == nil {
+		t.Errorf("AnalyzeProfileDivergence() accepted an invalid template")
+	}
+}
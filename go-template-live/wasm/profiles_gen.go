@@ -0,0 +1,427 @@
+// Code generated by gen_profiles.go; DO NOT EDIT.
+
+package main
+
+// profileFunctionTables lists the functions each profile registers, sorted by name
+var profileFunctionTables = map[string][]string{
+	ProfileOfficial: {},
+	ProfileCustom: {
+		"exists",
+		"formatCurrency",
+		"formatDate",
+		"formatNumber",
+		"get",
+		"getv",
+		"getvBool",
+		"getvFloat",
+		"getvInt",
+		"getvJSON",
+		"json",
+		"jsonArray",
+		"required",
+		"secret",
+	},
+	ProfileConfd: {
+		"add",
+		"atoi",
+		"base",
+		"base64Decode",
+		"base64Encode",
+		"contains",
+		"datetime",
+		"dir",
+		"div",
+		"exists",
+		"fileExists",
+		"filepathBase",
+		"filepathDir",
+		"filepathJoin",
+		"formatCurrency",
+		"formatDate",
+		"formatNumber",
+		"get",
+		"gets",
+		"getv",
+		"getvBool",
+		"getvFloat",
+		"getvInt",
+		"getvJSON",
+		"getvs",
+		"join",
+		"json",
+		"jsonArray",
+		"lookupIP",
+		"lookupSRV",
+		"ls",
+		"lsdir",
+		"map",
+		"mod",
+		"mul",
+		"parseBool",
+		"replace",
+		"required",
+		"reverse",
+		"secret",
+		"seq",
+		"split",
+		"sub",
+		"toLower",
+		"toUpper",
+		"trimSuffix",
+	},
+	ProfileSprig: {
+		"abbrev",
+		"add",
+		"add1",
+		"addf",
+		"adler32sum",
+		"ago",
+		"all",
+		"any",
+		"append",
+		"atoi",
+		"b32dec",
+		"b32enc",
+		"b64dec",
+		"b64enc",
+		"base",
+		"biggest",
+		"camelcase",
+		"cat",
+		"ceil",
+		"chunk",
+		"clean",
+		"coalesce",
+		"compact",
+		"concat",
+		"contains",
+		"date",
+		"dateInZone",
+		"dateModify",
+		"deepCopy",
+		"deepEqual",
+		"default",
+		"dict",
+		"dig",
+		"dir",
+		"div",
+		"divf",
+		"duration",
+		"empty",
+		"env",
+		"expandenv",
+		"ext",
+		"fail",
+		"first",
+		"float64",
+		"floor",
+		"fromJson",
+		"get",
+		"has",
+		"hasKey",
+		"hasPrefix",
+		"hasSuffix",
+		"htmlDate",
+		"indent",
+		"initial",
+		"initials",
+		"int",
+		"int64",
+		"isAbs",
+		"join",
+		"kebabcase",
+		"keys",
+		"kindIs",
+		"kindOf",
+		"last",
+		"list",
+		"lower",
+		"max",
+		"maxf",
+		"merge",
+		"mergeOverwrite",
+		"min",
+		"minf",
+		"mod",
+		"mul",
+		"mulf",
+		"mustFromJson",
+		"mustRegexMatch",
+		"mustToJson",
+		"nindent",
+		"nospace",
+		"now",
+		"omit",
+		"pick",
+		"pluck",
+		"plural",
+		"prepend",
+		"push",
+		"quote",
+		"randAlpha",
+		"randAlphaNum",
+		"randAscii",
+		"randNumeric",
+		"regexFind",
+		"regexFindAll",
+		"regexMatch",
+		"regexQuoteMeta",
+		"regexReplaceAll",
+		"regexReplaceAllLiteral",
+		"regexSplit",
+		"repeat",
+		"replace",
+		"required",
+		"rest",
+		"reverse",
+		"round",
+		"seq",
+		"set",
+		"sha1sum",
+		"sha256sum",
+		"shuffle",
+		"slice",
+		"snakecase",
+		"sortAlpha",
+		"split",
+		"splitList",
+		"splitn",
+		"squote",
+		"sub",
+		"subf",
+		"substr",
+		"swapcase",
+		"ternary",
+		"title",
+		"toDate",
+		"toDecimal",
+		"toJson",
+		"toPrettyJson",
+		"toRawJson",
+		"toString",
+		"toStrings",
+		"trim",
+		"trimAll",
+		"trimPrefix",
+		"trimSuffix",
+		"trunc",
+		"typeIs",
+		"typeIsLike",
+		"typeOf",
+		"uniq",
+		"unixEpoch",
+		"unset",
+		"until",
+		"untilStep",
+		"untitle",
+		"upper",
+		"uuidv4",
+		"values",
+		"without",
+		"wrap",
+		"wrapWith",
+	},
+	ProfileGomplate: {
+		"base64",
+		"bool",
+		"coll",
+		"contains",
+		"conv",
+		"crypto",
+		"data",
+		"default",
+		"dict",
+		"env",
+		"filepath",
+		"getenv",
+		"has",
+		"hasPrefix",
+		"hasSuffix",
+		"indent",
+		"join",
+		"json",
+		"jsonArray",
+		"math",
+		"path",
+		"quote",
+		"random",
+		"regexp",
+		"replaceAll",
+		"required",
+		"seq",
+		"split",
+		"splitN",
+		"squote",
+		"strings",
+		"ternary",
+		"test",
+		"time",
+		"title",
+		"toJSON",
+		"toJSONPretty",
+		"toLower",
+		"toUpper",
+		"trimSpace",
+		"uuid",
+	},
+	ProfileHelm: {
+		"abbrev",
+		"add",
+		"add1",
+		"addf",
+		"adler32sum",
+		"ago",
+		"all",
+		"any",
+		"append",
+		"atoi",
+		"b32dec",
+		"b32enc",
+		"b64dec",
+		"b64enc",
+		"base",
+		"biggest",
+		"camelcase",
+		"cat",
+		"ceil",
+		"chunk",
+		"clean",
+		"coalesce",
+		"compact",
+		"concat",
+		"contains",
+		"date",
+		"dateInZone",
+		"dateModify",
+		"deepCopy",
+		"deepEqual",
+		"default",
+		"dict",
+		"dig",
+		"dir",
+		"div",
+		"divf",
+		"duration",
+		"empty",
+		"env",
+		"expandenv",
+		"ext",
+		"fail",
+		"first",
+		"float64",
+		"floor",
+		"fromJson",
+		"fromJsonArray",
+		"fromYaml",
+		"fromYamlArray",
+		"get",
+		"has",
+		"hasKey",
+		"hasPrefix",
+		"hasSuffix",
+		"htmlDate",
+		"include",
+		"indent",
+		"initial",
+		"initials",
+		"int",
+		"int64",
+		"isAbs",
+		"join",
+		"kebabcase",
+		"keys",
+		"kindIs",
+		"kindOf",
+		"last",
+		"list",
+		"lookup",
+		"lower",
+		"max",
+		"maxf",
+		"merge",
+		"mergeOverwrite",
+		"min",
+		"minf",
+		"mod",
+		"mul",
+		"mulf",
+		"mustFromJson",
+		"mustRegexMatch",
+		"mustToJson",
+		"mustToYaml",
+		"nindent",
+		"nospace",
+		"now",
+		"omit",
+		"pick",
+		"pluck",
+		"plural",
+		"prepend",
+		"push",
+		"quote",
+		"randAlpha",
+		"randAlphaNum",
+		"randAscii",
+		"randNumeric",
+		"regexFind",
+		"regexFindAll",
+		"regexMatch",
+		"regexQuoteMeta",
+		"regexReplaceAll",
+		"regexReplaceAllLiteral",
+		"regexSplit",
+		"repeat",
+		"replace",
+		"required",
+		"rest",
+		"reverse",
+		"round",
+		"seq",
+		"set",
+		"sha1sum",
+		"sha256sum",
+		"shuffle",
+		"slice",
+		"snakecase",
+		"sortAlpha",
+		"split",
+		"splitList",
+		"splitn",
+		"squote",
+		"sub",
+		"subf",
+		"substr",
+		"swapcase",
+		"ternary",
+		"title",
+		"toDate",
+		"toDecimal",
+		"toJson",
+		"toPrettyJson",
+		"toRawJson",
+		"toString",
+		"toStrings",
+		"toYaml",
+		"tpl",
+		"trim",
+		"trimAll",
+		"trimPrefix",
+		"trimSuffix",
+		"trunc",
+		"typeIs",
+		"typeIsLike",
+		"typeOf",
+		"uniq",
+		"unixEpoch",
+		"unset",
+		"until",
+		"untilStep",
+		"untitle",
+		"upper",
+		"uuidv4",
+		"values",
+		"without",
+		"wrap",
+		"wrapWith",
+	},
+}
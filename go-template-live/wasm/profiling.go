@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// ProfileOptions selects what a profiling session records
+type ProfileOptions struct {
+	// CPU records a CPU profile. The js/wasm runtime has no profiling timer, so in browsers the
+	// profile carries no samples and the allocation profiles are the ones to look at
+	CPU bool `json:"cpu,omitempty"`
+	// MemProfileRate is the average number of bytes allocated between recorded allocation
+	// samples during the session: 1 records every allocation, 0 keeps the current rate
+	MemProfileRate int `json:"memProfileRate,omitempty"`
+}
+
+// ProfileData holds the pprof-encoded (gzipped protobuf) profiles of a session
+// The allocation profile is cumulative since the program started, so AllocsBase, taken when
+// the session started, is included for go tool pprof -diff_base=allocs-base.pb.gz allocs.pb.gz
+type ProfileData struct {
+	CPU        []byte `json:"cpu,omitempty"`
+	Allocs     []byte `json:"allocs"`
+	AllocsBase []byte `json:"allocsBase"`
+}
+
+// profiling is the state of the current session; pprof and the sampling rate are process-wide,
+// so there is at most one
+var profiling struct {
+	sync.Mutex
+	active      bool
+	cpu         *bytes.Buffer
+	allocsBase  []byte
+	restoreRate int
+}
+
+// StartProfiling starts recording a profiling session, which StopProfiling ends
+func StartProfiling(opts ProfileOptions) error {
+	if opts.MemProfileRate < 0 {
+		return errors.New("memProfileRate must not be negative")
+	}
+
+	profiling.Lock()
+	defer profiling.Unlock()
+	if profiling.active {
+		return errors.New("a profiling session is already running")
+	}
+
+	base, err := allocsProfile()
+	if err != nil {
+		return err
+	}
+	var cpu *bytes.Buffer
+	if opts.CPU {
+		cpu = &bytes.Buffer{}
+		if err := pprof.StartCPUProfile(cpu); err != nil {
+			return err
+		}
+	}
+
+	profiling.active = true
+	profiling.cpu = cpu
+	profiling.allocsBase = base
+	profiling.restoreRate = runtime.MemProfileRate
+	if opts.MemProfileRate > 0 {
+		runtime.MemProfileRate = opts.MemProfileRate
+	}
+	return nil
+}
+
+// StopProfiling ends the profiling session and returns its profiles
+func StopProfiling() (*ProfileData, error) {
+	profiling.Lock()
+	defer profiling.Unlock()
+	if !profiling.active {
+		return nil, errors.New("no profiling session is running")
+	}
+
+	data := &ProfileData{AllocsBase: profiling.allocsBase}
+	if profiling.cpu != nil {
+		pprof.StopCPUProfile()
+		data.CPU = profiling.cpu.Bytes()
+	}
+	allocs, err := allocsProfile()
+	runtime.MemProfileRate = profiling.restoreRate
+	profiling.active = false
+	profiling.cpu = nil
+	profiling.allocsBase = nil
+	if err != nil {
+		return nil, err
+	}
+	data.Allocs = allocs
+	return data, nil
+}
+
+// allocsProfile writes the allocation profile, after a collection so it is up to date
+func allocsProfile() ([]byte, error) {
+	runtime.GC()
+	var buf bytes.Buffer
+	if err := pprof.Lookup("allocs").WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,46 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+)
+
+// TestProfiling tests that a session returns gzipped profiles and restores the sampling rate
+func TestProfiling(t *testing.T) {
+	rate := runtime.MemProfileRate
+	if err := StartProfiling(ProfileOptions{CPU: true, MemProfileRate: 1}); err != nil {
+		t.Fatalf("StartProfiling() error = %v", err)
+	}
+	if err := StartProfiling(ProfileOptions{}); err == nil {
+		t.Errorf("StartProfiling() while running error = nil, want error")
+	}
+	if runtime.MemProfileRate != 1 {
+		t.Errorf("MemProfileRate = %d during the session, want 1", runtime.MemProfileRate)
+	}
+
+	parser := NewParser(NewFunctionRegistry())
+	if _, err := parser.ExtractVariablesWithPositions("test.tmpl", "{{.Host}}:{{.Port}}"); err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	data, err := StopProfiling()
+	if err != nil {
+		t.Fatalf("StopProfiling() error = %v", err)
+	}
+	gzipMagic := []byte{0x1f, 0x8b}
+	for name, profile := range map[string][]byte{"cpu": data.CPU, "allocs": data.Allocs, "allocsBase": data.AllocsBase} {
+		if !bytes.HasPrefix(profile, gzipMagic) {
+			t.Errorf("%s profile is not gzipped pprof data", name)
+		}
+	}
+	if runtime.MemProfileRate != rate {
+		t.Errorf("MemProfileRate = %d after the session, want %d", runtime.MemProfileRate, rate)
+	}
+	if _, err := StopProfiling(); err == nil {
+		t.Errorf("StopProfiling() without a session error = nil, want error")
+	}
+}
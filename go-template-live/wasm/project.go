@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"text/template"
+)
+
+// Project is a set of named template files that include each other with {{template}} and
+// {{block}}, any of which can be extracted or rendered as an entry point
+// A file includes another by invoking its name, e.g. {{template "partials/header.tmpl" .}};
+// definitions in the entry point override {{block}} defaults of the files it includes
+type Project struct {
+	parser   *Parser
+	renderer *Renderer
+
+	templates map[string]string
+}
+
+// ProjectReferences lists the templates an entry point includes
+type ProjectReferences struct {
+	// Includes are the project files included, directly or through other files, in load order
+	Includes []string `json:"includes"`
+	// Missing are the invoked names neither defined in the loaded files nor in the project
+	Missing []string `json:"missing"`
+}
+
+// NewProject creates an empty project extracting with the registry of parser and rendering
+// with renderer
+func NewProject(parser *Parser, renderer *Renderer) *Project {
+	project := &Project{templates: make(map[string]string)}
+	project.parser = &Parser{registry: parser.registry, includes: project.templates, env: parser.env}
+	project.renderer = &Renderer{registry: renderer.registry, renderFuncs: renderer.renderFuncs, includes: project.templates, env: renderer.env}
+	return project
+}
+
+// AddTemplate adds or replaces a named template file
+func (p *Project) AddTemplate(name, content string) {
+	p.templates[name] = content
+}
+
+// RemoveTemplate removes a named template file
+func (p *Project) RemoveTemplate(name string) {
+	delete(p.templates, name)
+}
+
+// Templates returns the names of the project files, sorted
+func (p *Project) Templates() []string {
+	names := make([]string, 0, len(p.templates))
+	for name := range p.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// entry returns the content of an entry point
+func (p *Project) entry(name string) (string, error) {
+	content, ok := p.templates[name]
+	if !ok {
+		return "", fmt.Errorf("template %s is not part of the project", name)
+	}
+	return content, nil
+}
+
+// References resolves the {{template}} and {{block}} references of an entry point
+func (p *Project) References(entry string) (*ProjectReferences, error) {
+	content, err := p.entry(entry)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(entry).Funcs(p.parser.registry.GetMinimalFuncMap()).Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", entry, err)
+	}
+	includes, err := loadIncludes(tmpl, p.templates, p.parser.env.includeSource())
+	if err != nil {
+		return nil, err
+	}
+	references := &ProjectReferences{Includes: []string{}, Missing: undefinedTemplates(tmpl, nil)}
+	for _, name := range includes {
+		// loadIncludes also reads the registered includes and the filesystem; only project files count
+		if _, ok := p.templates[name]; ok {
+			references.Includes = append(references.Includes, name)
+		}
+	}
+	if references.Missing == nil {
+		references.Missing = []string{}
+	}
+	return references, nil
+}
+
+// ExtractVariables returns the variables an entry point uses, including those of the files it
+// includes, aggregated as by ExtractVariablesAggregated; only occurrences in the entry point
+// itself have positions
+func (p *Project) ExtractVariables(entry string) ([]VariableInfo, error) {
+	content, err := p.entry(entry)
+	if err != nil {
+		return nil, err
+	}
+	return p.parser.ExtractVariablesAggregated(entry, content)
+}
+
+// Render renders an entry point together with the files it includes
+func (p *Project) Render(entry string, variables map[string]interface{}, opts RenderOptions) (*RenderResult, error) {
+	content, err := p.entry(entry)
+	if err != nil {
+		return nil, err
+	}
+	if opts.FileName == "" {
+		opts.FileName = entry
+	}
+	return p.renderer.Render(content, variables, opts)
+}
@@ -0,0 +1,81 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestProject() *Project {
+	registry := NewFunctionRegistry()
+	project := NewProject(NewParser(registry), NewRenderer(registry, nil))
+	project.AddTemplate("layout.tmpl", `<h1>{{.Title}}</h1>{{block "content" .}}no content{{end}}{{template "partials/footer.tmpl" .}}`)
+	project.AddTemplate("partials/footer.tmpl", `<footer>{{.Company}}</footer>`)
+	project.AddTemplate("home.tmpl", `{{define "content"}}<p>{{.Intro}}</p>{{end}}{{template "layout.tmpl" .}}`)
+	project.AddTemplate("about.tmpl", `{{template "layout.tmpl" .}}{{template "sidebar" .}}`)
+	return project
+}
+
+// TestProject_References tests resolving {{template}} references among project files
+func TestProject_References(t *testing.T) {
+	project := newTestProject()
+	if expected := []string{"about.tmpl", "home.tmpl", "layout.tmpl", "partials/footer.tmpl"}; !reflect.DeepEqual(project.Templates(), expected) {
+		t.Errorf("Templates() = %v, want %v", project.Templates(), expected)
+	}
+
+	references, err := project.References("about.tmpl")
+	if err != nil {
+		t.Fatalf("References() error = %v", err)
+	}
+	expected := &ProjectReferences{Includes: []string{"layout.tmpl", "partials/footer.tmpl"}, Missing: []string{"sidebar"}}
+	if !reflect.DeepEqual(references, expected) {
+		t.Errorf("References() = %+v, want %+v", references, expected)
+	}
+
+	if _, err := project.References("missing.tmpl"); err == nil {
+		t.Errorf("References() of an unknown entry point succeeded")
+	}
+}
+
+// TestProject_ExtractAndRender tests per entry point extraction and rendering, with block overrides
+func TestProject_ExtractAndRender(t *testing.T) {
+	project := newTestProject()
+
+	variables, err := project.ExtractVariables("home.tmpl")
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	var names []string
+	for _, v := range variables {
+		names = append(names, v.Name)
+	}
+	if expected := []string{"Title", "Intro", "Company"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("ExtractVariables() = %v, want %v", names, expected)
+	}
+	if intro := variables[1]; len(intro.Occurrences) != 1 || intro.Occurrences[0].Column != 26 {
+		t.Errorf("ExtractVariables() Intro occurrences = %+v, want its position in home.tmpl", intro.Occurrences)
+	}
+
+	values := map[string]interface{}{"Title": "Home", "Intro": "Hi", "Company": "Acme"}
+	for entry, expected := range map[string]string{
+		"home.tmpl":   "<h1>Home</h1><p>Hi</p><footer>Acme</footer>",
+		"layout.tmpl": "<h1>Home</h1>no content<footer>Acme</footer>",
+	} {
+		for _, format := range []string{OutputFormatText, OutputFormatHTML} {
+			result, err := project.Render(entry, values, RenderOptions{OutputFormat: format})
+			if err != nil {
+				t.Fatalf("Render(%s, %s) error = %v", entry, format, err)
+			}
+			if result.Output != expected {
+				t.Errorf("Render(%s, %s) = %q, want %q", entry, format, result.Output, expected)
+			}
+		}
+	}
+
+	project.RemoveTemplate("partials/footer.tmpl")
+	if _, err := project.Render("home.tmpl", values, RenderOptions{}); err == nil {
+		t.Errorf("Render() succeeded with a removed include")
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeyRename is a template with its references to a key renamed
+type KeyRename struct {
+	Content string `json:"content"`
+	// References is the number of rewritten references
+	References int `json:"references"`
+}
+
+// validateKeyRename checks the keys of a rename and returns them cleaned
+func validateKeyRename(from, to string) (string, string, error) {
+	for _, key := range []string{from, to} {
+		if !isKeyPath(key) || len(splitKey(key)) == 0 {
+			return "", "", fmt.Errorf("%q is not a key path such as /myapp/database", key)
+		}
+		if strings.ContainsAny(key, "*?[") {
+			return "", "", fmt.Errorf("%q is a pattern, renames need a plain key", key)
+		}
+	}
+	from, to = joinKey(splitKey(from)), joinKey(splitKey(to))
+	if from == to {
+		return "", "", fmt.Errorf("%s is renamed to itself", from)
+	}
+	if strings.HasPrefix(to, from+"/") {
+		return "", "", fmt.Errorf("cannot rename %s into its own subtree %s", from, to)
+	}
+	return from, to, nil
+}
+
+// renamedKey returns the name of key after renaming from to to: the key itself and every key
+// or pattern below it are renamed, so /old/db/host becomes /new/db/host when /old is renamed
+func renamedKey(key, from, to string) (string, bool) {
+	if key == from {
+		return to, true
+	}
+	if strings.HasPrefix(key, from+"/") {
+		return to + key[len(from):], true
+	}
+	return "", false
+}
+
+// RenameKey rewrites the references of a template to the key from, and to the keys below it,
+// into to
+// Only keys written as string literals are found: getv "/old/host" and gets "/old/*" are
+// rewritten, a key assembled with printf is not
+func (p *Parser) RenameKey(fileName, fileContent, from, to string) (*KeyRename, error) {
+	from, to, err := validateKeyRename(from, to)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := p.ExtractVariablesWithPositions(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(variables, func(i, j int) bool {
+		return variableOffset(variables[i]) < variableOffset(variables[j])
+	})
+
+	rename := &KeyRename{}
+	var b strings.Builder
+	last := 0
+	for _, v := range variables {
+		newName, ok := renamedKey(v.Name, from, to)
+		if !ok || v.Position == nil || v.Position.Offset < last {
+			continue
+		}
+		start, end := v.Position.Offset, v.Position.Offset+v.Position.Length
+		if end > len(fileContent) {
+			continue
+		}
+		replacement, ok := requoteKey(fileContent[start:end], v.Name, newName)
+		if !ok {
+			continue
+		}
+		b.WriteString(fileContent[last:start])
+		b.WriteString(replacement)
+		last = end
+		rename.References++
+	}
+	b.WriteString(fileContent[last:])
+	rename.Content = b.String()
+
+	if _, err := p.parseTemplate(context.Background(), fileName, rename.Content); err != nil {
+		return nil, fmt.Errorf("renamed template does not parse: %v", err)
+	}
+	return rename, nil
+}
+
+// RenameValueKeys moves the value of the key from, and the keys below it, to to in values,
+// which may be flat or hierarchical as read by KeyStore; values is modified in place
+// It returns the number of moved values and fails, without changes, when to already has one
+func RenameValueKeys(values map[string]interface{}, from, to string) (int, error) {
+	from, to, err := validateKeyRename(from, to)
+	if err != nil {
+		return 0, err
+	}
+	if NewKeyStore(values).Exists(to) {
+		return 0, fmt.Errorf("%s already has a value", to)
+	}
+	target := splitKey(to)
+	for object, i := values, 0; i < len(target)-1; i++ {
+		child, exists := object[target[i]]
+		if !exists {
+			break
+		}
+		var ok bool
+		if object, ok = child.(map[string]interface{}); !ok {
+			return 0, fmt.Errorf("%s already has a value", joinKey(target[:i+1]))
+		}
+	}
+
+	// Flat keys, as confd's backends store them
+	flat := make(map[string]string)
+	for key := range values {
+		if !isKeyPath(key) {
+			continue
+		}
+		if newKey, ok := renamedKey(joinKey(splitKey(key)), from, to); ok {
+			flat[key] = newKey
+		}
+	}
+	for _, newKey := range flat {
+		if _, ok := values[newKey]; ok {
+			return 0, fmt.Errorf("%s already has a value", newKey)
+		}
+	}
+	for key, newKey := range flat {
+		values[newKey] = values[key]
+		delete(values, key)
+	}
+	moved := len(flat)
+
+	// Hierarchical values, with an object per segment
+	segments := splitKey(from)
+	parent := values
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := parent[segment].(map[string]interface{})
+		if !ok {
+			return moved, nil
+		}
+		parent = child
+	}
+	value, ok := parent[segments[len(segments)-1]]
+	if !ok {
+		return moved, nil
+	}
+	delete(parent, segments[len(segments)-1])
+
+	parent = values
+	for _, segment := range target[:len(target)-1] {
+		child, ok := parent[segment].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			parent[segment] = child
+		}
+		parent = child
+	}
+	parent[target[len(target)-1]] = value
+	walkNested(target, value, func(string, interface{}) { moved++ })
+	return moved, nil
+}
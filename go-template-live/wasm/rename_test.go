@@ -0,0 +1,128 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParser_RenameKey tests rewriting the references to a key and the keys below it
+func TestParser_RenameKey(t *testing.T) {
+	parser := newNamingTestParser()
+	template := "{{getv \"/old/db\"}} {{getv `/old/db/host` \"localhost\"}} {{getv \"/old/dbname\"}} {{.Host}}"
+
+	rename, err := parser.RenameKey("test.tmpl", template, "/old/db/", "/new/db")
+	if err != nil {
+		t.Fatalf("RenameKey() error = %v", err)
+	}
+	expected := "{{getv \"/new/db\"}} {{getv `/new/db/host` \"localhost\"}} {{getv \"/old/dbname\"}} {{.Host}}"
+	if rename.Content != expected || rename.References != 2 {
+		t.Errorf("RenameKey() = %+v, want %q with 2 references", rename, expected)
+	}
+
+	for _, keys := range [][2]string{{"/old", "/old"}, {"/old", "/old/sub"}, {"old", "/new"}, {"/old/*", "/new"}} {
+		if _, err := parser.RenameKey("test.tmpl", template, keys[0], keys[1]); err == nil {
+			t.Errorf("RenameKey(%q, %q) succeeded", keys[0], keys[1])
+		}
+	}
+}
+
+// TestRenameValueKeys tests moving flat and hierarchical values
+func TestRenameValueKeys(t *testing.T) {
+	values := map[string]interface{}{
+		"/old/db/host": "db",
+		"old":          map[string]interface{}{"db": map[string]interface{}{"port": "5432", "user": "app"}},
+		"new":          map[string]interface{}{"cache": "redis"},
+	}
+	moved, err := RenameValueKeys(values, "/old/db", "/new/db")
+	if err != nil {
+		t.Fatalf("RenameValueKeys() error = %v", err)
+	}
+	expected := map[string]interface{}{
+		"/new/db/host": "db",
+		"old":          map[string]interface{}{},
+		"new":          map[string]interface{}{"cache": "redis", "db": map[string]interface{}{"port": "5432", "user": "app"}},
+	}
+	if moved != 3 || !reflect.DeepEqual(values, expected) {
+		t.Errorf("RenameValueKeys() = %d, %v, want 3, %v", moved, values, expected)
+	}
+
+	if _, err := RenameValueKeys(values, "/new/db", "/new/cache"); err == nil {
+		t.Errorf("RenameValueKeys() onto an existing value succeeded")
+	}
+	if _, err := RenameValueKeys(values, "/new/db", "/new/cache/db"); err == nil {
+		t.Errorf("RenameValueKeys() below an existing value succeeded")
+	}
+}
+
+// TestParser_RenameKeyInDir tests a rename across templates and JSON and YAML values files
+func TestParser_RenameKeyInDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"templates/app.conf.tmpl":   "host={{getv \"/old/db/host\"}}\n",
+		"templates/nested/web.tmpl": "{{getv \"/old/db/port\"}} {{getv \"/other\"}}\n",
+		"templates/plain.tmpl":      "{{getv \"/other\"}}\n",
+		"templates/README.md":       "{{getv \"/old/db/host\"}}\n",
+		"values.json":               "{\"/old/db/host\": \"db\", \"/other\": 1}\n",
+		"values.yaml":               "# database\nold:\n  db:\n    port: 5432 # default\nother: x\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := RenameKeyOptions{
+		Dir:         filepath.Join(dir, "templates"),
+		ValuesFiles: []string{filepath.Join(dir, "values.json"), filepath.Join(dir, "values.yaml")},
+	}
+	parser := newNamingTestParser()
+
+	dryRun := opts
+	dryRun.DryRun = true
+	summary, err := parser.RenameKeyInDir("/old/db", "/new/db", dryRun)
+	if err != nil {
+		t.Fatalf("RenameKeyInDir() error = %v", err)
+	}
+	expected := []RenamedFile{{File: "app.conf.tmpl", Count: 1}, {File: "nested/web.tmpl", Count: 1}}
+	if !reflect.DeepEqual(summary.Templates, expected) || len(summary.ValuesFiles) != 2 {
+		t.Errorf("RenameKeyInDir() = %+v, want templates %+v and both values files", summary, expected)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "templates/app.conf.tmpl")); string(content) != files["templates/app.conf.tmpl"] {
+		t.Errorf("RenameKeyInDir() dry run wrote %q", content)
+	}
+	if !strings.Contains(summary.String(), "2 reference(s) in 2 template(s), 2 value(s) in 2 values file(s)") {
+		t.Errorf("RenameSummary.String() = %q", summary.String())
+	}
+
+	if _, err := parser.RenameKeyInDir("/old/db", "/new/db", opts); err != nil {
+		t.Fatalf("RenameKeyInDir() error = %v", err)
+	}
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+	if got := read("templates/nested/web.tmpl"); got != "{{getv \"/new/db/port\"}} {{getv \"/other\"}}\n" {
+		t.Errorf("RenameKeyInDir() template = %q", got)
+	}
+	if got := read("templates/README.md"); got != files["templates/README.md"] {
+		t.Errorf("RenameKeyInDir() rewrote a file that is not a template: %q", got)
+	}
+	if got := read("values.json"); got != "{\n  \"/new/db/host\": \"db\",\n  \"/other\": 1\n}\n" {
+		t.Errorf("RenameKeyInDir() JSON values = %q", got)
+	}
+	if got := read("values.yaml"); got != "# database\nold: {}\nother: x\nnew:\n  db:\n    port: 5432 # default\n" {
+		t.Errorf("RenameKeyInDir() YAML values = %q", got)
+	}
+}
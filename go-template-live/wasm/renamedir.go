@@ -0,0 +1,266 @@
+//go:build !js
+// +build !js
+
+// This file contains key renames across a template directory and its values files
+// Tag: !js (the browser playground has no project directory)
+// The module builds no native command; RenameKeyInDir and RenameSummary.String are the library
+// side of a `rename-key --from --to --dir` command for the embedding tool to expose
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RenameKeyOptions select the files a directory-wide key rename rewrites
+type RenameKeyOptions struct {
+	// Dir is searched recursively for templates (.tmpl, .tpl, .gotmpl and .template files)
+	Dir string
+	// ValuesFiles are JSON or YAML values files whose keys are renamed as well
+	ValuesFiles []string
+	// DryRun reports the changes without writing any file
+	DryRun bool
+}
+
+// RenamedFile is a file changed by a key rename and the number of changes in it
+type RenamedFile struct {
+	File  string `json:"file"`
+	Count int    `json:"count"`
+}
+
+// RenameSummary reports a directory-wide key rename
+type RenameSummary struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Templates are the templates with rewritten references, relative to the directory
+	Templates []RenamedFile `json:"templates"`
+	// ValuesFiles are the values files with moved values
+	ValuesFiles []RenamedFile `json:"valuesFiles"`
+}
+
+// String formats the summary for terminal output
+func (s *RenameSummary) String() string {
+	var b strings.Builder
+	references, values := 0, 0
+	for _, file := range s.Templates {
+		fmt.Fprintf(&b, "%s: %d reference(s)\n", file.File, file.Count)
+		references += file.Count
+	}
+	for _, file := range s.ValuesFiles {
+		fmt.Fprintf(&b, "%s: %d value(s)\n", file.File, file.Count)
+		values += file.Count
+	}
+	fmt.Fprintf(&b, "renamed %s to %s: %d reference(s) in %d template(s), %d value(s) in %d values file(s)\n",
+		s.From, s.To, references, len(s.Templates), values, len(s.ValuesFiles))
+	return b.String()
+}
+
+// RenameKeyInDir renames the key from, and the keys below it, to to in every template of a
+// directory and in values files
+// Every file is rewritten in memory first, so a template that fails to parse or a values file
+// that already has the new key leaves all files untouched
+func (p *Parser) RenameKeyInDir(from, to string, opts RenameKeyOptions) (*RenameSummary, error) {
+	from, to, err := validateKeyRename(from, to)
+	if err != nil {
+		return nil, err
+	}
+	summary := &RenameSummary{From: from, To: to, Templates: []RenamedFile{}, ValuesFiles: []RenamedFile{}}
+	rewritten := make(map[string][]byte)
+
+	err = filepath.WalkDir(opts.Dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !templateExtensions[path.Ext(d.Name())] {
+			return err
+		}
+		rel, err := filepath.Rel(opts.Dir, file)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rename, err := p.RenameKey(filepath.ToSlash(rel), string(content), from, to)
+		if err != nil {
+			return err
+		}
+		if rename.References > 0 {
+			rewritten[file] = []byte(rename.Content)
+			summary.Templates = append(summary.Templates, RenamedFile{File: filepath.ToSlash(rel), Count: rename.References})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error renaming templates: %v", err)
+	}
+
+	for _, file := range opts.ValuesFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading values file %s: %v", file, err)
+		}
+		content, moved, err := renameValuesFile(file, data, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("error renaming keys of values file %s: %v", file, err)
+		}
+		if moved > 0 {
+			rewritten[file] = content
+			summary.ValuesFiles = append(summary.ValuesFiles, RenamedFile{File: file, Count: moved})
+		}
+	}
+	sort.Slice(summary.Templates, func(i, j int) bool { return summary.Templates[i].File < summary.Templates[j].File })
+
+	if opts.DryRun {
+		return summary, nil
+	}
+	for file, content := range rewritten {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(file, content, info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("error writing %s: %v", file, err)
+		}
+	}
+	return summary, nil
+}
+
+// renameValuesFile renames keys in the content of a values file: JSON is re-encoded with
+// sorted keys, YAML is edited in place so comments and key order survive
+func renameValuesFile(file string, data []byte, from, to string) ([]byte, int, error) {
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var values map[string]interface{}
+		if err := decoder.Decode(&values); err != nil {
+			return nil, 0, err
+		}
+		moved, err := RenameValueKeys(values, from, to)
+		if err != nil || moved == 0 {
+			return nil, moved, err
+		}
+		content, err := json.MarshalIndent(values, "", "  ")
+		return append(content, '\n'), moved, err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, 0, err
+	}
+	if len(document.Content) == 0 {
+		return nil, 0, nil
+	}
+	moved, err := renameYAMLKeys(document.Content[0], from, to)
+	if err != nil || moved == 0 {
+		return nil, moved, err
+	}
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, 0, err
+	}
+	return b.Bytes(), moved, encoder.Close()
+}
+
+// renameYAMLKeys is RenameValueKeys on the root mapping of a YAML document
+func renameYAMLKeys(root *yaml.Node, from, to string) (int, error) {
+	if root.Kind != yaml.MappingNode {
+		return 0, fmt.Errorf("values must be a mapping")
+	}
+	target := splitKey(to)
+	mapping := root
+	for i, segment := range target {
+		child := yamlValue(mapping, segment)
+		if child == nil {
+			break
+		}
+		if i == len(target)-1 || child.Kind != yaml.MappingNode {
+			return 0, fmt.Errorf("%s already has a value", joinKey(target[:i+1]))
+		}
+		mapping = child
+	}
+
+	// Flat keys, as confd's backends store them
+	moved := 0
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		if !isKeyPath(key.Value) {
+			continue
+		}
+		if newKey, ok := renamedKey(joinKey(splitKey(key.Value)), from, to); ok {
+			if yamlValue(root, newKey) != nil {
+				return 0, fmt.Errorf("%s already has a value", newKey)
+			}
+			key.Value = newKey
+			moved++
+		}
+	}
+
+	// Hierarchical values, with a mapping per segment
+	segments := splitKey(from)
+	parent := root
+	for _, segment := range segments[:len(segments)-1] {
+		if parent = yamlValue(parent, segment); parent == nil || parent.Kind != yaml.MappingNode {
+			return moved, nil
+		}
+	}
+	index := yamlKeyIndex(parent, segments[len(segments)-1])
+	if index < 0 {
+		return moved, nil
+	}
+	key, value := parent.Content[index], parent.Content[index+1]
+	parent.Content = append(parent.Content[:index], parent.Content[index+2:]...)
+
+	parent = root
+	for _, segment := range target[:len(target)-1] {
+		child := yamlValue(parent, segment)
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment}, child)
+		}
+		parent = child
+	}
+	key.Value = target[len(target)-1]
+	parent.Content = append(parent.Content, key, value)
+	return moved + countYAMLLeaves(value), nil
+}
+
+// yamlKeyIndex returns the index of key in the content of a mapping node, or -1
+func yamlKeyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlValue returns the value of key in a mapping node, or nil
+func yamlValue(mapping *yaml.Node, key string) *yaml.Node {
+	if index := yamlKeyIndex(mapping, key); index >= 0 {
+		return mapping.Content[index+1]
+	}
+	return nil
+}
+
+// countYAMLLeaves counts the values of a node that are not mappings, as walkNested visits them
+func countYAMLLeaves(node *yaml.Node) int {
+	if node.Kind != yaml.MappingNode {
+		return 1
+	}
+	count := 0
+	for i := 1; i < len(node.Content); i += 2 {
+		count += countYAMLLeaves(node.Content[i])
+	}
+	return count
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// Missing key policies, mirroring text/template's "missingkey" option
+const (
+	MissingKeyDefault = "default"
+	MissingKeyInvalid = "invalid"
+	MissingKeyZero    = "zero"
+	MissingKeyError   = "error"
+)
+
+// Output formats accepted by RenderOptions.OutputFormat
+const (
+	OutputFormatText = "text"
+	OutputFormatHTML = "html"
+)
+
+// RenderOptions controls how a template is executed
+type RenderOptions struct {
+	// MissingKey selects the missingkey policy: "default" (or "invalid"), "zero" or "error"
+	MissingKey string `json:"missingKey,omitempty"`
+	// OutputFormat selects text/template ("text", the default) or html/template ("html")
+	// html applies contextual auto-escaping to every action
+	OutputFormat string `json:"outputFormat,omitempty"`
+	// Deterministic pins time-dependent functions to FrozenTime and seeds randomness with Seed
+	// so repeated renders of the same input produce byte-identical output
+	Deterministic bool `json:"deterministic,omitempty"`
+	// FrozenTime is the Unix time in milliseconds used in deterministic mode (0 selects 2000-01-01T00:00:00Z)
+	FrozenTime int64 `json:"frozenTime,omitempty"`
+	// Seed seeds the random source in deterministic mode
+	Seed int64 `json:"seed,omitempty"`
+	// Timezone is an IANA zone name (e.g. "Europe/Berlin") applied to datetime and other date functions
+	Timezone string `json:"timezone,omitempty"`
+	// ApplyDefaults fills variables missing from the values with the defaults found during
+	// extraction (getv defaults, @var pragma defaults) before rendering
+	ApplyDefaults bool `json:"applyDefaults,omitempty"`
+	// MaskedPreview replaces the values of sensitive variables (those read with secret) with
+	// MaskedValue, so previews can be shared or captured without leaking credentials
+	MaskedPreview bool `json:"maskedPreview,omitempty"`
+	// FileName is the template file name; its extension (e.g. nginx.conf.tmpl, app.yaml.tmpl)
+	// takes precedence over the content when detecting the output syntax
+	FileName string `json:"fileName,omitempty"`
+	// PathStyle is the path syntax of filepathBase, filepathDir and filepathJoin: "posix" (the
+	// default) or "windows", for templates generating configs of Windows services
+	PathStyle string `json:"pathStyle,omitempty"`
+	// LineEnding is "lf" (the default, output as rendered) or "crlf", converting every line
+	// ending of the output to \r\n
+	LineEnding string `json:"lineEnding,omitempty"`
+	// KeyPrefix is the confd key prefix (confd's -prefix joined with the resource's prefix):
+	// getv, gets, ls and the other key functions read the keys below it, with the prefix
+	// removed, and nothing else (see WithKeyPrefix)
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// OutputValidation parses the output as "json", "yaml", "toml", "ini" or "xml", or as its
+	// detected syntax with "auto", reporting syntax errors in OutputErrors (see ValidateOutput)
+	OutputValidation string `json:"outputValidation,omitempty"`
+	// PostProcess lists post-processors applied to the output in order after execution:
+	// "json-pretty", "yaml-normalize" and "sort-keys"; OutputValidation sees the processed output
+	PostProcess []string `json:"postProcess,omitempty"`
+	// CleanWhitespace strips trailing whitespace from the output lines and collapses runs of
+	// blank lines into one, before post-processing
+	CleanWhitespace bool `json:"cleanWhitespace,omitempty"`
+	// WhitespaceFences are line prefixes (e.g. "```") opening and closing regions CleanWhitespace
+	// leaves as rendered
+	WhitespaceFences []string `json:"whitespaceFences,omitempty"`
+}
+
+// RenderResult holds the rendered output together with render diagnostics
+type RenderResult struct {
+	Output string `json:"output"`
+	// MissingKeys lists the field paths that were not present in the provided values
+	// and therefore triggered the missingkey policy
+	MissingKeys []string `json:"missingKeys,omitempty"`
+	// AppliedDefaults lists the variables filled from extracted defaults, in order of first use
+	AppliedDefaults []string `json:"appliedDefaults,omitempty"`
+	// Masked lists the sensitive variables whose values were masked, in order of first use
+	Masked []string `json:"masked,omitempty"`
+	// Warnings reports conditions that did not stop rendering, such as placeholder functions
+	Warnings []string `json:"warnings,omitempty"`
+	// Syntax is the detected format of Output, for picking highlighting and validators
+	Syntax *SyntaxDetection `json:"syntax,omitempty"`
+	// OutputErrors are the syntax errors of Output in the OutputValidation format, with output
+	// line numbers; the output is returned even when it is invalid
+	OutputErrors []OutputError `json:"outputErrors,omitempty"`
+}
+
+// Render stages reported by RenderError
+const (
+	RenderStageParse   = "parsing"
+	RenderStageExecute = "executing"
+)
+
+// RenderError is a parse or execution failure of Render, wrapping the text/template error
+type RenderError struct {
+	Stage string
+	Err   error
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("error %s template: %v", e.Stage, e.Err)
+}
+
+func (e *RenderError) Unwrap() error { return e.Err }
+
+// RenderFuncMapProvider builds the render-time function map for a set of variables, bound to
+// the environment of the render
+// In WASM builds this is CreateRenderFuncMap; tests pass the profile's render map directly
+type RenderFuncMapProvider func(variables map[string]interface{}, env *renderEnv) map[string]interface{}
+
+// Renderer executes templates using the functions of a registry
+type Renderer struct {
+	registry    *FunctionRegistry
+	renderFuncs RenderFuncMapProvider
+	// includes are template files available to {{template}} ahead of the registered includes,
+	// such as the other templates of a Project
+	includes map[string]string
+	// env is the environment every render takes its own snapshot of
+	env *Environment
+}
+
+// NewRenderer creates a renderer for the given registry and render function provider
+func NewRenderer(registry *FunctionRegistry, renderFuncs RenderFuncMapProvider) *Renderer {
+	return &Renderer{
+		registry:    registry,
+		renderFuncs: renderFuncs,
+		env:         NewEnvironment(),
+	}
+}
+
+// SetEnvironment sets the environment renders start from
+func (r *Renderer) SetEnvironment(env *Environment) {
+	r.env = env
+}
+
+// Environment returns the environment renders start from
+func (r *Renderer) Environment() *Environment {
+	return r.env
+}
+
+// parser returns a parser extracting with the registry, includes and environment of the renderer
+func (r *Renderer) parser() *Parser {
+	return &Parser{registry: r.registry, includes: r.includes, env: r.env}
+}
+
+// validateMissingKey checks that the policy is one text/template understands
+func validateMissingKey(policy string) error {
+	switch policy {
+	case "", MissingKeyDefault, MissingKeyInvalid, MissingKeyZero, MissingKeyError:
+		return nil
+	}
+	return fmt.Errorf("unknown missingkey mode %q, expected one of default, invalid, zero, error", policy)
+}
+
+// executor is the part of text/template and html/template used for rendering
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// parse parses the template with the engine selected by opts.OutputFormat, together with the
+// template files it includes (see loadIncludes)
+func (r *Renderer) parse(templateContent string, funcs template.FuncMap, env *renderEnv, opts RenderOptions) (executor, *parse.Tree, error) {
+	switch opts.OutputFormat {
+	case "", OutputFormatText:
+		tmpl := template.New("template").Funcs(funcs)
+		if opts.MissingKey != "" {
+			tmpl = tmpl.Option("missingkey=" + opts.MissingKey)
+		}
+		tmpl, err := tmpl.Parse(templateContent)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := loadIncludes(tmpl, r.includes, env.includeSource()); err != nil {
+			return nil, nil, err
+		}
+		return tmpl, tmpl.Tree, nil
+	case OutputFormatHTML:
+		tmpl := htmltemplate.New("template").Funcs(htmltemplate.FuncMap(funcs))
+		if opts.MissingKey != "" {
+			tmpl = tmpl.Option("missingkey=" + opts.MissingKey)
+		}
+		tmpl, err := tmpl.Parse(templateContent)
+		if err != nil {
+			return nil, nil, err
+		}
+		// loadIncludes works on text/template sets, so includes are resolved on a text/template
+		// parse whose trees are then added to the html set
+		discovery, err := template.New("template").Funcs(funcs).Parse(templateContent)
+		if err != nil {
+			return nil, nil, err
+		}
+		loaded, err := loadIncludes(discovery, r.includes, env.includeSource())
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(loaded) > 0 {
+			for _, t := range discovery.Templates() {
+				if t.Name() == discovery.Name() || t.Tree == nil {
+					continue
+				}
+				if _, err := tmpl.AddParseTree(t.Name(), t.Tree); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		return tmpl, tmpl.Tree, nil
+	}
+	return nil, nil, fmt.Errorf("unknown output format %q, expected text or html", opts.OutputFormat)
+}
+
+// funcMap merges the registered handlers, bound to env, with the render implementations
+func (r *Renderer) funcMap(variables map[string]interface{}, env *renderEnv) template.FuncMap {
+	funcs := r.registry.GetRenderFuncMap(env)
+	if r.renderFuncs == nil {
+		return funcs
+	}
+	for name, fn := range r.renderFuncs(variables, env) {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// Render renders the template with the given variables and options
+// On execution failure the partially populated result is returned along with the error
+func (r *Renderer) Render(templateContent string, variables map[string]interface{}, opts RenderOptions) (*RenderResult, error) {
+	env, err := r.prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.execute(templateContent, variables, env, opts)
+}
+
+// prepare validates options and creates the render environment (timezone, frozen clock,
+// random source, lookup budget) from the renderer's environment
+func (r *Renderer) prepare(opts RenderOptions) (*renderEnv, error) {
+	if err := validateMissingKey(opts.MissingKey); err != nil {
+		return nil, err
+	}
+	if err := validateLineEnding(opts.LineEnding); err != nil {
+		return nil, err
+	}
+	if err := validateOutputValidation(opts.OutputValidation); err != nil {
+		return nil, err
+	}
+	if err := validatePostProcess(opts.PostProcess); err != nil {
+		return nil, err
+	}
+	return r.env.newRenderEnv(opts)
+}
+
+// deterministicTime returns the frozen time of a deterministic render
+func deterministicTime(opts RenderOptions) time.Time {
+	if opts.FrozenTime != 0 {
+		return time.UnixMilli(opts.FrozenTime).UTC()
+	}
+	return deterministicEpoch
+}
+
+// execute parses and executes a template in a prepared environment
+func (r *Renderer) execute(templateContent string, variables map[string]interface{}, env *renderEnv, opts RenderOptions) (result *RenderResult, err error) {
+	ctx, span := startTemplateSpan(context.Background(), SpanRender, "", templateContent)
+	span.SetAttribute(AttrProfile, r.registry.Profile())
+	span.SetAttribute(AttrVariableCount, len(variables))
+	defer func() { endSpan(span, err) }()
+
+	variables = withBuiltinValues(r.registry.BuiltinValues(), WithKeyPrefix(variables, opts.KeyPrefix))
+	var applied []string
+	if opts.ApplyDefaults {
+		variables, applied, err = r.applyDefaults(templateContent, variables)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var masked []string
+	if opts.MaskedPreview {
+		variables, masked, err = r.maskSensitive(templateContent, variables)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, parseSpan := startSpan(ctx, SpanParse)
+	tmpl, tree, err := r.parse(templateContent, r.funcMap(variables, env), env, opts)
+	endSpan(parseSpan, err)
+	if err != nil {
+		return nil, &RenderError{Stage: RenderStageParse, Err: err}
+	}
+
+	result = &RenderResult{
+		MissingKeys:     findMissingKeys(tree.Root, variables),
+		AppliedDefaults: applied,
+		Masked:          masked,
+	}
+	if warning := r.registry.placeholderWarning(tree.Root); warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
+
+	if set, ok := tmpl.(*template.Template); ok && r.registry.setFuncs != nil {
+		set.Funcs(r.registry.setFuncs(set))
+	}
+	var output strings.Builder
+	err = tmpl.Execute(&output, variables)
+	if warning := env.values.warning(); warning != "" {
+		result.Warnings = append(result.Warnings, warning)
+	}
+	if err != nil {
+		return result, &RenderError{Stage: RenderStageExecute, Err: err}
+	}
+	rendered := output.String()
+	if opts.CleanWhitespace {
+		rendered = cleanWhitespace(rendered, opts.WhitespaceFences)
+	}
+	processed, warnings := postProcess(rendered, opts.PostProcess)
+	result.Warnings = append(result.Warnings, warnings...)
+	result.Output = applyLineEnding(processed, opts.LineEnding)
+	syntax := DetectSyntax(opts.FileName, result.Output)
+	result.Syntax = &syntax
+	if opts.OutputValidation != "" {
+		result.OutputErrors = ValidateOutput(outputValidationFormat(opts.OutputValidation, syntax), result.Output)
+	}
+	span.SetAttribute(AttrOutputSize, len(result.Output))
+
+	return result, nil
+}
+
+// findMissingKeys reports root-scope field paths that cannot be resolved in variables
+// Fields inside range/with bodies are relative to a rebound dot and are not checked,
+// except when anchored to the root with $ (e.g. $.Name)
+func findMissingKeys(root *parse.ListNode, variables map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	collectMissingKeys(root, variables, true, seen)
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// collectMissingKeys walks the tree and records unresolved paths in seen
+// rootScope is false once dot has been rebound by range or with
+func collectMissingKeys(node parse.Node, variables map[string]interface{}, rootScope bool, seen map[string]bool) {
+	walkNodes(node, 0, func(node parse.Node, depth int) bool {
+		switch node := node.(type) {
+		case *parse.FieldNode:
+			if rootScope {
+				if missing := missingPath(node.Ident, variables); missing != "" {
+					seen[missing] = true
+				}
+			}
+		case *parse.VariableNode:
+			if len(node.Ident) > 1 && node.Ident[0] == "$" {
+				if missing := missingPath(node.Ident[1:], variables); missing != "" {
+					seen[missing] = true
+				}
+			}
+		case *parse.RangeNode:
+			collectRebindingMissingKeys(&node.BranchNode, variables, rootScope, seen)
+			return false
+		case *parse.WithNode:
+			collectRebindingMissingKeys(&node.BranchNode, variables, rootScope, seen)
+			return false
+		}
+		return true
+	})
+}
+
+// collectRebindingMissingKeys walks a range or with, whose body runs with dot rebound
+func collectRebindingMissingKeys(node *parse.BranchNode, variables map[string]interface{}, rootScope bool, seen map[string]bool) {
+	collectMissingKeys(node.Pipe, variables, rootScope, seen)
+	collectMissingKeys(node.List, variables, false, seen)
+	collectMissingKeys(node.ElseList, variables, rootScope, seen)
+}
+
+// missingPath resolves ident against variables and returns the dotted path up to
+// the first absent map key, or "" when the path resolves (or leaves map territory)
+func missingPath(ident []string, variables map[string]interface{}) string {
+	var current interface{} = variables
+	for i, name := range ident {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value, exists := m[name]
+		if !exists {
+			return strings.Join(ident[:i+1], ".")
+		}
+		current = value
+	}
+	return ""
+}
@@ -0,0 +1,235 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRenderer_MissingKeyModes tests the missingkey policies exposed through RenderOptions
+func TestRenderer_MissingKeyModes(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+
+	tests := []struct {
+		name            string
+		template        string
+		values          map[string]interface{}
+		missingKey      string
+		expectedOutput  string
+		expectedMissing []string
+		expectError     bool
+	}{
+		{
+			name:            "default mode renders no value",
+			template:        `Hello {{.Name}}`,
+			values:          map[string]interface{}{},
+			expectedOutput:  "Hello <no value>",
+			expectedMissing: []string{"Name"},
+		},
+		{
+			name:            "zero mode renders zero value",
+			template:        `Hello {{.Name}}`,
+			values:          map[string]interface{}{},
+			missingKey:      MissingKeyZero,
+			expectedOutput:  "Hello <no value>",
+			expectedMissing: []string{"Name"},
+		},
+		{
+			name:            "error mode fails fast",
+			template:        `Hello {{.Name}}`,
+			values:          map[string]interface{}{},
+			missingKey:      MissingKeyError,
+			expectedMissing: []string{"Name"},
+			expectError:     true,
+		},
+		{
+			name:            "nested path reports first missing segment",
+			template:        `{{.User.Name}} {{.User.Role}} {{.Env}}`,
+			values:          map[string]interface{}{"User": map[string]interface{}{"Name": "Ann"}, "Env": "prod"},
+			expectedOutput:  "Ann <no value> prod",
+			expectedMissing: []string{"User.Role"},
+		},
+		{
+			name:            "fields inside range are relative to dot",
+			template:        `{{range .Items}}{{.Name}}{{end}}{{$.Title}}`,
+			values:          map[string]interface{}{"Items": []interface{}{map[string]interface{}{"Name": "a"}}},
+			expectedOutput:  "a<no value>",
+			expectedMissing: []string{"Title"},
+		},
+		{
+			name:            "all keys provided",
+			template:        `{{.A}}-{{.B}}`,
+			values:          map[string]interface{}{"A": 1, "B": 2},
+			missingKey:      MissingKeyError,
+			expectedOutput:  "1-2",
+			expectedMissing: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderer.Render(tt.template, tt.values, RenderOptions{MissingKey: tt.missingKey})
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "map has no entry for key") {
+					t.Fatalf("Render() error = %v, want missing key error", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			} else if result.Output != tt.expectedOutput {
+				t.Errorf("Render() output = %q, want %q", result.Output, tt.expectedOutput)
+			}
+			if !reflect.DeepEqual(result.MissingKeys, tt.expectedMissing) {
+				t.Errorf("Render() missingKeys = %v, want %v", result.MissingKeys, tt.expectedMissing)
+			}
+		})
+	}
+}
+
+// TestRenderer_RenderErrorStages tests that render failures report the stage that failed with the
+// text/template error, keeping the error text of earlier releases
+func TestRenderer_RenderErrorStages(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+
+	tests := []struct {
+		name     string
+		template string
+		stage    string
+		prefix   string
+	}{
+		{name: "parse", template: "{{.Name", stage: RenderStageParse, prefix: "error parsing template: template: template:1: "},
+		{name: "execute", template: "{{.Name.First}}", stage: RenderStageExecute, prefix: "error executing template: template: template:1:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderer.Render(tt.template, map[string]interface{}{"Name": "x"}, RenderOptions{})
+			var renderErr *RenderError
+			if !errors.As(err, &renderErr) {
+				t.Fatalf("Render() error = %v, want a RenderError", err)
+			}
+			if renderErr.Stage != tt.stage || !strings.HasPrefix(err.Error(), tt.prefix) {
+				t.Errorf("Render() error = %q at stage %q, want prefix %q at stage %q", err, renderErr.Stage, tt.prefix, tt.stage)
+			}
+			if err.Error() != "error "+tt.stage+" template: "+renderErr.Err.Error() {
+				t.Errorf("Render() error = %q does not wrap %q", err, renderErr.Err)
+			}
+		})
+	}
+}
+
+// TestRenderer_InvalidMissingKeyMode tests that unknown policies are rejected
+func TestRenderer_InvalidMissingKeyMode(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	if _, err := renderer.Render(`{{.A}}`, nil, RenderOptions{MissingKey: "strict"}); err == nil {
+		t.Error("Render() expected error for unknown missingkey mode")
+	}
+}
+
+// TestRenderer_OutputFormat tests text versus html/template rendering
+func TestRenderer_OutputFormat(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	values := map[string]interface{}{"Name": `<b>"Tom" & Jerry</b>`, "URL": "javascript:alert(1)"}
+	template := `<p title="{{.Name}}">{{.Name}}</p><a href="{{.URL}}">x</a>`
+
+	tests := []struct {
+		name           string
+		outputFormat   string
+		expectedOutput string
+	}{
+		{
+			name:           "text format leaves values untouched",
+			outputFormat:   OutputFormatText,
+			expectedOutput: `<p title="<b>"Tom" & Jerry</b>"><b>"Tom" & Jerry</b></p><a href="javascript:alert(1)">x</a>`,
+		},
+		{
+			name:           "html format escapes by context",
+			outputFormat:   OutputFormatHTML,
+			expectedOutput: `<p title="&lt;b&gt;&#34;Tom&#34; &amp; Jerry&lt;/b&gt;">&lt;b&gt;&#34;Tom&#34; &amp; Jerry&lt;/b&gt;</p><a href="#ZgotmplZ">x</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderer.Render(template, values, RenderOptions{OutputFormat: tt.outputFormat})
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if result.Output != tt.expectedOutput {
+				t.Errorf("Render() output = %q, want %q", result.Output, tt.expectedOutput)
+			}
+		})
+	}
+
+	if _, err := renderer.Render(template, values, RenderOptions{OutputFormat: "markdown"}); err == nil {
+		t.Error("Render() expected error for unknown output format")
+	}
+}
+
+// TestRenderer_ConcurrentEnvironments tests that concurrent renders each see their own clock,
+// timezone and random source; run with -race
+func TestRenderer_ConcurrentEnvironments(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{Name: "clock", EnvHandler: func(env *renderEnv) interface{} { return env.currentTime }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "roll", EnvHandler: func(env *renderEnv) interface{} {
+		return func() int { return env.randomSource().Intn(1000000) }
+	}})
+	renderer := NewRenderer(registry, nil)
+	renderer.Environment().SetRenderClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli())
+	template := `{{clock.Format "2006-01-02 15:04 MST"}} {{roll}} {{roll}}`
+
+	options := func(i int) RenderOptions {
+		switch i % 3 {
+		case 0:
+			return RenderOptions{Deterministic: true, Seed: int64(i), Timezone: "UTC"}
+		case 1:
+			return RenderOptions{Deterministic: true, Seed: int64(i), Timezone: "America/New_York"}
+		}
+		return RenderOptions{Timezone: "Europe/Berlin"}
+	}
+	expected := make([]string, 12)
+	for i := range expected {
+		result, err := renderer.Render(template, nil, options(i))
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		expected[i] = result.Output
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*len(expected))
+	for round := 0; round < 4; round++ {
+		for i := range expected {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				result, err := renderer.Render(template, nil, options(i))
+				if err != nil {
+					errs <- err
+					return
+				}
+				// Renders without a seed only share the clock with their sequential render
+				if i%3 == 2 {
+					if !strings.HasPrefix(result.Output, "2024-03-01 13:00 CET ") {
+						errs <- fmt.Errorf("render %d = %q, want the injected clock in CET", i, result.Output)
+					}
+					return
+				}
+				if result.Output != expected[i] {
+					errs <- fmt.Errorf("render %d = %q, want %q", i, result.Output, expected[i])
+				}
+			}(i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"text/template/parse"
+)
+
+// requiredFunction is the name of the Helm-style required function of the custom and Confd profiles
+// (functions_required.go registers it under the literal name, which gen_profiles.go reads)
+const requiredFunction = "required"
+
+// applyRequired marks the variables whose value is passed to required, attaching its message,
+// so editors can flag them before rendering
+// Both the call form, required "message" (getv "x"), and the piped form, getv "x" | required "message",
+// are recognized
+func (p *Parser) applyRequired(root *parse.ListNode, variables []VariableInfo) {
+	if !p.registry.HasFunction(requiredFunction) {
+		return
+	}
+	messages := make(map[int]string)
+	p.collectRequired(root, messages, 0)
+	if len(messages) == 0 {
+		return
+	}
+	for i := range variables {
+		if variables[i].Position == nil {
+			continue
+		}
+		if message, ok := messages[variables[i].Position.Offset]; ok {
+			variables[i].Required = true
+			variables[i].RequiredMessage = message
+		}
+	}
+}
+
+// collectRequired records, per occurrence offset, the message of the required call it is passed to
+func (p *Parser) collectRequired(node parse.Node, messages map[int]string, depth int) {
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		pipe, ok := node.(*parse.PipeNode)
+		if !ok {
+			return true
+		}
+		for i, cmd := range pipe.Cmds {
+			// Arguments are walked first so the outer required call's message wins
+			for _, arg := range cmd.Args {
+				p.collectRequired(arg, messages, depth+1)
+			}
+			message, ok := requiredMessage(cmd)
+			if !ok {
+				continue
+			}
+			if len(cmd.Args) > 2 {
+				p.recordRequired(cmd.Args[2], message, messages, depth+1)
+			} else if i > 0 {
+				p.recordRequired(pipe.Cmds[i-1], message, messages, depth+1)
+			}
+		}
+		return false
+	})
+}
+
+// requiredMessage returns the message of a required call
+func requiredMessage(cmd *parse.CommandNode) (string, bool) {
+	if len(cmd.Args) < 2 {
+		return "", false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok || ident.Ident != requiredFunction {
+		return "", false
+	}
+	message, ok := cmd.Args[1].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	return message.Text, true
+}
+
+// recordRequired records message for every occurrence under the required value
+func (p *Parser) recordRequired(value parse.Node, message string, messages map[int]string, depth int) {
+	buf := getScratchVariables()
+	defer putScratchVariables(buf)
+
+	required, err := p.appendFieldsWithDefaults((*buf)[:0], value, depth)
+	if err != nil {
+		return
+	}
+	*buf = required
+	for _, v := range required {
+		if v.Position != nil {
+			messages[v.Position.Offset] = message
+		}
+	}
+}
+
+// isRequired reports whether a value must be provided: the variable has no default and is
+// either passed to required or used outside any guarding condition
+func (v *VariableInfo) isRequired() bool {
+	return v.DefaultValue == "" && (v.Required || len(v.DependsOn) == 0)
+}
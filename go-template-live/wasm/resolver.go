@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// Resolver answers the DNS lookups of lookupIP and lookupSRV
+// Native builds query the system resolver; WASM builds, which have no network stack, ask a
+// resolver registered by the page or answer from fixtures it provides
+type Resolver interface {
+	// LookupIP returns the addresses of host as strings
+	LookupIP(host string) ([]string, error)
+	// LookupSRV returns the SRV records of _service._proto.name, or of name when service and
+	// proto are both empty
+	LookupSRV(service, proto, name string) ([]*net.SRV, error)
+}
+
+// SetRenderResolver sets the resolver used by lookupIP and lookupSRV
+func (e *Environment) SetRenderResolver(r Resolver) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resolver = r
+}
+
+// ResetRenderResolver restores the default resolver of the build
+func (e *Environment) ResetRenderResolver() {
+	e.SetRenderResolver(defaultResolver())
+}
+
+// FixtureResolver answers lookups from fixed records, so previews do not depend on live DNS
+type FixtureResolver struct {
+	// IP maps host names to addresses
+	IP map[string][]string `json:"ip"`
+	// SRV maps SRV names such as _etcd._tcp.example.com to records; keys of the records are
+	// target, port, priority and weight
+	SRV map[string][]*net.SRV `json:"srv"`
+}
+
+// LookupIP returns the fixture addresses of host
+func (f *FixtureResolver) LookupIP(host string) ([]string, error) {
+	ips, ok := f.IP[host]
+	if !ok {
+		return nil, fmt.Errorf("lookup %s: no fixture", host)
+	}
+	return ips, nil
+}
+
+// LookupSRV returns the fixture records of the SRV name
+func (f *FixtureResolver) LookupSRV(service, proto, name string) ([]*net.SRV, error) {
+	target := srvName(service, proto, name)
+	records, ok := f.SRV[target]
+	if !ok {
+		return nil, fmt.Errorf("lookup %s: no fixture", target)
+	}
+	return records, nil
+}
+
+// srvName builds the queried name the way net.LookupSRV does
+func srvName(service, proto, name string) string {
+	if service == "" && proto == "" {
+		return name
+	}
+	return "_" + service + "._" + proto + "." + name
+}
+
+// lookupIP resolves host like confd's lookupIP: sorted address strings, none on failure
+func (env *renderEnv) lookupIP(host string) []string {
+	ips, err := env.resolver.LookupIP(host)
+	if err != nil {
+		return nil
+	}
+	sorted := append([]string(nil), ips...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// lookupSRV resolves SRV records like confd's lookupSRV: sorted by target and port, none on failure
+func (env *renderEnv) lookupSRV(service, proto, name string) []*net.SRV {
+	records, err := env.resolver.LookupSRV(service, proto, name)
+	if err != nil {
+		return []*net.SRV{}
+	}
+	sorted := append([]*net.SRV(nil), records...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return fmt.Sprintf("%s%d", sorted[i].Target, sorted[i].Port) < fmt.Sprintf("%s%d", sorted[j].Target, sorted[j].Port)
+	})
+	return sorted
+}
@@ -0,0 +1,87 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"syscall/js"
+)
+
+// defaultResolver answers from an empty fixture set until the page provides a resolver
+func defaultResolver() Resolver {
+	return &FixtureResolver{}
+}
+
+// jsResolver calls a resolver function registered by the page
+// The function is called as fn("ip", host) or fn("srv", service, proto, name) and must return
+// synchronously: an array of address strings, or of {target, port, priority, weight} objects;
+// null, undefined or a thrown error counts as a failed lookup
+type jsResolver struct {
+	fn js.Value
+}
+
+func (r jsResolver) LookupIP(host string) (ips []string, err error) {
+	result, err := r.invoke("ip", host)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < result.Length(); i++ {
+		ips = append(ips, result.Index(i).String())
+	}
+	return ips, nil
+}
+
+func (r jsResolver) LookupSRV(service, proto, name string) (records []*net.SRV, err error) {
+	result, err := r.invoke("srv", service, proto, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < result.Length(); i++ {
+		record := result.Index(i)
+		if record.Type() != js.TypeObject {
+			return nil, fmt.Errorf("resolver: expected an SRV record object, got %s", record.Type())
+		}
+		records = append(records, &net.SRV{
+			Target:   jsStringField(record, "target"),
+			Port:     uint16(jsIntField(record, "port")),
+			Priority: uint16(jsIntField(record, "priority")),
+			Weight:   uint16(jsIntField(record, "weight")),
+		})
+	}
+	return records, nil
+}
+
+// invoke calls the resolver function, turning a thrown error or a non-array result into an error
+func (r jsResolver) invoke(args ...interface{}) (result js.Value, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("resolver: %v", recovered)
+		}
+	}()
+	result = r.fn.Invoke(args...)
+	if result.IsNull() || result.IsUndefined() {
+		return js.Undefined(), fmt.Errorf("resolver: no records")
+	}
+	if !js.Global().Get("Array").Call("isArray", result).Bool() {
+		return js.Undefined(), fmt.Errorf("resolver: expected an array, got %s", result.Type())
+	}
+	return result, nil
+}
+
+func jsStringField(v js.Value, name string) string {
+	field := v.Get(name)
+	if field.Type() != js.TypeString {
+		return ""
+	}
+	return field.String()
+}
+
+func jsIntField(v js.Value, name string) int {
+	field := v.Get(name)
+	if field.Type() != js.TypeNumber {
+		return 0
+	}
+	return field.Int()
+}
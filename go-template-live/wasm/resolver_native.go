@@ -0,0 +1,43 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// lookupTimeout bounds each lookup so a slow DNS server cannot stall a render
+const lookupTimeout = 5 * time.Second
+
+// netResolver queries DNS through a net.Resolver
+type netResolver struct {
+	resolver *net.Resolver
+}
+
+func defaultResolver() Resolver {
+	return netResolver{resolver: net.DefaultResolver}
+}
+
+func (r netResolver) LookupIP(host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := r.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]string, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP.String()
+	}
+	return ips, nil
+}
+
+func (r netResolver) LookupSRV(service, proto, name string) ([]*net.SRV, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	_, records, err := r.resolver.LookupSRV(ctx, service, proto, name)
+	return records, err
+}
@@ -0,0 +1,42 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestFixtureResolver(t *testing.T) {
+	resolver := &FixtureResolver{
+		IP: map[string][]string{"web.internal": {"10.0.0.2", "10.0.0.1"}},
+		SRV: map[string][]*net.SRV{
+			"_http._tcp.example.com": {{Target: "b.example.com.", Port: 80}, {Target: "a.example.com.", Port: 8080}, {Target: "a.example.com.", Port: 80}},
+			"srv.example.com":        {{Target: "c.example.com.", Port: 53}},
+		},
+	}
+	env := &renderEnv{resolver: resolver}
+
+	if ips, expected := env.lookupIP("web.internal"), []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(ips, expected) {
+		t.Errorf("lookupIP() = %v, want %v", ips, expected)
+	}
+	if !reflect.DeepEqual(resolver.IP["web.internal"], []string{"10.0.0.2", "10.0.0.1"}) {
+		t.Errorf("lookupIP() reordered the fixture: %v", resolver.IP["web.internal"])
+	}
+	if ips := env.lookupIP("unknown.internal"); ips != nil {
+		t.Errorf("lookupIP() of an unknown host = %v, want nil", ips)
+	}
+
+	records := env.lookupSRV("http", "tcp", "example.com")
+	if len(records) != 3 || records[0].Target != "a.example.com." || records[0].Port != 80 || records[1].Port != 8080 || records[2].Target != "b.example.com." {
+		t.Errorf("lookupSRV() = %v, want records sorted by target and port", records)
+	}
+	if records := env.lookupSRV("", "", "srv.example.com"); len(records) != 1 {
+		t.Errorf("lookupSRV() of a bare name = %v, want one record", records)
+	}
+	if records := env.lookupSRV("ldap", "tcp", "example.com"); records == nil || len(records) != 0 {
+		t.Errorf("lookupSRV() of an unknown name = %#v, want an empty list", records)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Severities used by review findings
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Review thresholds
+const (
+	reviewMaxNesting    = 4
+	reviewMaxBranches   = 20
+	reviewCoverageScale = 20
+)
+
+// severityPenalty is the score deduction per finding severity
+var severityPenalty = map[string]int{
+	SeverityError:   25,
+	SeverityWarning: 10,
+	SeverityInfo:    2,
+}
+
+// TemplateMetrics holds structural and variable metrics for a template
+type TemplateMetrics struct {
+	Actions               int     `json:"actions"`
+	Branches              int     `json:"branches"`
+	Loops                 int     `json:"loops"`
+	MaxNesting            int     `json:"maxNesting"`
+	Variables             int     `json:"variables"`
+	VariablesWithDefaults int     `json:"variablesWithDefaults"`
+	RequiredVariables     int     `json:"requiredVariables"`
+	DefaultCoverage       float64 `json:"defaultCoverage"`
+}
+
+// ReviewFinding is an actionable observation about a template
+type ReviewFinding struct {
+	Severity   string `json:"severity"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	// Position is set for the findings of lint rules, which point at a place in the template
+	Position *Position `json:"position,omitempty"`
+}
+
+// ReviewSummary is a scored review of a template
+type ReviewSummary struct {
+	Score             int             `json:"score"`
+	Grade             string          `json:"grade"`
+	Metrics           TemplateMetrics `json:"metrics"`
+	RequiredVariables []string        `json:"requiredVariables"`
+	Findings          []ReviewFinding `json:"findings"`
+}
+
+// Review parses the template and returns a scored summary with actionable findings
+// The findings start with the diagnostics of the linter with its default rules, so a review and
+// a lint of the same template report the same problems
+func (p *Parser) Review(fileName, fileContent string) (*ReviewSummary, error) {
+	funcs := p.registry.GetMinimalFuncMap()
+	tmpl, err := template.New(fileName).Funcs(funcs).Parse(fileContent)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %v", fileName, err)
+	}
+
+	variables, err := p.ExtractVariablesWithDefaults(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	linter, err := NewLinter(LintConfig{})
+	if err != nil {
+		return nil, err
+	}
+	diagnostics, err := linter.Lint(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ReviewSummary{
+		RequiredVariables: []string{},
+		Findings:          []ReviewFinding{},
+	}
+	collectStructureMetrics(tmpl.Tree.Root, 0, &summary.Metrics)
+	summary.reviewLint(diagnostics)
+	summary.reviewVariables(variables)
+	summary.reviewStructure()
+	lookups, err := p.DynamicLookups(fileName, fileContent)
+	if err != nil {
+		return nil, err
+	}
+	summary.reviewDynamicLookups(lookups)
+	summary.score()
+
+	return summary, nil
+}
+
+// collectStructureMetrics counts actions and control structures and tracks nesting depth
+func collectStructureMetrics(node parse.Node, nesting int, metrics *TemplateMetrics) {
+	if nesting > metrics.MaxNesting {
+		metrics.MaxNesting = nesting
+	}
+	walkNodes(node, 0, func(node parse.Node, depth int) bool {
+		switch node := node.(type) {
+		case *parse.ListNode:
+			return true
+		case *parse.ActionNode:
+			metrics.Actions++
+		case *parse.IfNode:
+			metrics.Branches++
+			collectBranchMetrics(&node.BranchNode, nesting, metrics)
+		case *parse.WithNode:
+			metrics.Branches++
+			collectBranchMetrics(&node.BranchNode, nesting, metrics)
+		case *parse.RangeNode:
+			metrics.Loops++
+			collectBranchMetrics(&node.BranchNode, nesting, metrics)
+		}
+		return false
+	})
+}
+
+// collectBranchMetrics counts the bodies of an if, with or range one level deeper
+func collectBranchMetrics(node *parse.BranchNode, nesting int, metrics *TemplateMetrics) {
+	collectStructureMetrics(node.List, nesting+1, metrics)
+	collectStructureMetrics(node.ElseList, nesting+1, metrics)
+}
+
+// reviewLint reports the diagnostics of the linter as findings
+func (s *ReviewSummary) reviewLint(diagnostics []LintDiagnostic) {
+	for i := range diagnostics {
+		s.Findings = append(s.Findings, ReviewFinding{
+			Severity: diagnostics[i].Severity,
+			Code:     diagnostics[i].Rule,
+			Message:  diagnostics[i].Message,
+			Position: &diagnostics[i].Position,
+		})
+	}
+}
+
+// reviewVariables computes default coverage and reports required and conflicting variables
+func (s *ReviewSummary) reviewVariables(variables []VariableInfo) {
+	defaults := make(map[string]map[string]bool)
+	var order []string
+	for _, v := range variables {
+		if _, seen := defaults[v.Name]; !seen {
+			defaults[v.Name] = make(map[string]bool)
+			order = append(order, v.Name)
+		}
+		if v.DefaultValue != "" {
+			defaults[v.Name][v.DefaultValue] = true
+		}
+	}
+
+	for _, name := range order {
+		values := defaults[name]
+		if len(values) == 0 {
+			s.RequiredVariables = append(s.RequiredVariables, name)
+			continue
+		}
+		s.Metrics.VariablesWithDefaults++
+		if len(values) > 1 {
+			conflicting := make([]string, 0, len(values))
+			for value := range values {
+				conflicting = append(conflicting, fmt.Sprintf("%q", value))
+			}
+			sort.Strings(conflicting)
+			s.Findings = append(s.Findings, ReviewFinding{
+				Severity:   SeverityWarning,
+				Code:       "conflicting-defaults",
+				Message:    fmt.Sprintf("variable %s has different defaults: %s", name, strings.Join(conflicting, ", ")),
+				Suggestion: "Use a single default value, e.g. assign the lookup to a $variable once and reuse it",
+			})
+		}
+	}
+
+	s.Metrics.Variables = len(order)
+	s.Metrics.RequiredVariables = len(s.RequiredVariables)
+	if s.Metrics.Variables > 0 {
+		s.Metrics.DefaultCoverage = float64(s.Metrics.VariablesWithDefaults) / float64(s.Metrics.Variables)
+	}
+
+	if len(s.RequiredVariables) > 0 {
+		s.Findings = append(s.Findings, ReviewFinding{
+			Severity:   SeverityInfo,
+			Code:       "required-variables",
+			Message:    fmt.Sprintf("%d variable(s) must be provided: %s", len(s.RequiredVariables), strings.Join(s.RequiredVariables, ", ")),
+			Suggestion: "Provide defaults where a sensible fallback exists, e.g. {{getv \"key\" \"default\"}}",
+		})
+	}
+}
+
+// reviewStructure reports branching that makes a template hard to follow; deep nesting is
+// reported by the nesting-depth lint rule
+func (s *ReviewSummary) reviewStructure() {
+	if s.Metrics.Actions == 0 && s.Metrics.Branches == 0 && s.Metrics.Loops == 0 {
+		s.Findings = append(s.Findings, ReviewFinding{
+			Severity: SeverityInfo,
+			Code:     "static-template",
+			Message:  "template contains no actions and renders as static text",
+		})
+	}
+	if s.Metrics.Branches+s.Metrics.Loops > reviewMaxBranches {
+		s.Findings = append(s.Findings, ReviewFinding{
+			Severity:   SeverityWarning,
+			Code:       "high-complexity",
+			Message:    fmt.Sprintf("template has %d branches and loops (limit %d)", s.Metrics.Branches+s.Metrics.Loops, reviewMaxBranches),
+			Suggestion: "Split the template or move logic into the values",
+		})
+	}
+}
+
+// reviewDynamicLookups reports keys read through $variables whose value extraction cannot know
+func (s *ReviewSummary) reviewDynamicLookups(lookups []DynamicLookup) {
+	for _, lookup := range lookups {
+		source := "a value only known when rendering"
+		if lookup.Source != "" {
+			source = lookup.Source
+		}
+		s.Findings = append(s.Findings, ReviewFinding{
+			Severity:   SeverityWarning,
+			Code:       "dynamic-key",
+			Message:    fmt.Sprintf("line %d: %s reads the key held by %s, assigned %s, so it is missing from the extracted variables", lookup.Position.Line, lookup.Function, lookup.Variable, source),
+			Suggestion: "Assign the key as a string literal, e.g. {{$key := \"/myapp/host\"}}, so extraction can follow it",
+		})
+	}
+}
+
+// score derives the 0-100 score and letter grade from findings and default coverage
+func (s *ReviewSummary) score() {
+	score := 100
+	for _, finding := range s.Findings {
+		score -= severityPenalty[finding.Severity]
+	}
+	if s.Metrics.Variables > 0 {
+		score -= int((1 - s.Metrics.DefaultCoverage) * reviewCoverageScale)
+	}
+	if score < 0 {
+		score = 0
+	}
+	s.Score = score
+
+	switch {
+	case score >= 90:
+		s.Grade = "A"
+	case score >= 80:
+		s.Grade = "B"
+	case score >= 70:
+		s.Grade = "C"
+	case score >= 60:
+		s.Grade = "D"
+	default:
+		s.Grade = "F"
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// GenerateSampleValues builds a values object that renders the template straight away
+// Known defaults are used as typed by the type hint; other variables get a placeholder of their
+// type: the field name for strings, 0 for numbers, true for booleans (so guarded branches
+// render), "{}" for JSON strings and a one-element array for ranged values
+func GenerateSampleValues(variables []VariableInfo) map[string]interface{} {
+	return sampleObject(buildVariableTree(variables))
+}
+
+// sampleObject returns the sample values of an object node
+func sampleObject(node *variableTree) map[string]interface{} {
+	values := make(map[string]interface{}, len(node.children))
+	for _, name := range node.order {
+		child := node.children[name]
+		if len(child.children) > 0 {
+			values[name] = sampleObject(child)
+			continue
+		}
+		values[name] = sampleValue(name, child.variable)
+	}
+	return values
+}
+
+// sampleValue returns the default of v when it converts to the hinted type, else a placeholder
+func sampleValue(name string, v *VariableInfo) interface{} {
+	if v.DefaultValue != "" {
+		if value, ok := v.typedDefault(); ok {
+			return value
+		}
+	}
+
+	switch v.Type {
+	case TypeNumber:
+		return 0
+	case TypeBool:
+		return true
+	case TypeArray:
+		return []interface{}{samplePlaceholder(name)}
+	case TypeJSONString:
+		return "{}"
+	}
+	return samplePlaceholder(name)
+}
+
+// samplePlaceholder derives a readable string placeholder from a name such as "/app/host"
+func samplePlaceholder(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 && i < len(name)-1 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,53 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestGenerateSampleValues tests typed defaults, placeholders per type hint and nesting
+func TestGenerateSampleValues(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{/* @var Server.Port type=number default=8080 */}}
+{{/* @var Retries type=number default=many */}}
+{{.Server.Port}} {{.Server.Host}} {{.Retries}}
+{{if .Debug}}{{.Level}}{{end}}
+{{range .Upstreams}}{{.}}{{end}}`
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	got, err := json.Marshal(GenerateSampleValues(variables))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	expected := `{"Debug":true,"Level":"Level","Retries":0,"Server":{"Host":"Host","Port":8080},"Upstreams":["Upstreams"]}`
+	if string(got) != expected {
+		t.Errorf("GenerateSampleValues() = %s, want %s", got, expected)
+	}
+
+	if _, err := NewRenderer(NewFunctionRegistry(), nil).Render(template, GenerateSampleValues(variables), RenderOptions{MissingKey: "error"}); err != nil {
+		t.Errorf("Render() with sample values error = %v", err)
+	}
+}
+
+func TestSamplePlaceholder(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Host", "Host"},
+		{"/app/host", "host"},
+		{"/app/", "/app/"},
+	}
+	for _, tt := range tests {
+		if got := samplePlaceholder(tt.name); got != tt.expected {
+			t.Errorf("samplePlaceholder(%q) = %q, want %q", tt.name, got, tt.expected)
+		}
+	}
+}
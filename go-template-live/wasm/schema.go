@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Schema versions of the JSON returned by the extraction APIs
+const (
+	// SchemaVersionV1 is the original {name, defaultValue} shape
+	SchemaVersionV1 = 1
+	// SchemaVersionV2 includes every VariableInfo field
+	SchemaVersionV2 = 2
+
+	// DefaultSchemaVersion is used when callers do not ask for a version,
+	// so existing frontends keep receiving the shape they were built against
+	DefaultSchemaVersion = SchemaVersionV1
+	// LatestSchemaVersion is the newest supported schema
+	LatestSchemaVersion = SchemaVersionV2
+)
+
+// variableInfoV1 is the frozen v1 shape of VariableInfo
+type variableInfoV1 struct {
+	Name         string `json:"name"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
+// MarshalVariables encodes extracted variables using the requested schema version
+func MarshalVariables(variables []VariableInfo, version int) ([]byte, error) {
+	switch version {
+	case SchemaVersionV1:
+		var legacy []variableInfoV1
+		if variables != nil {
+			legacy = make([]variableInfoV1, len(variables))
+		}
+		for i, v := range variables {
+			legacy[i] = variableInfoV1{Name: v.Name, DefaultValue: v.DefaultValue}
+		}
+		return json.Marshal(legacy)
+	case SchemaVersionV2:
+		return json.Marshal(variables)
+	}
+	return nil, fmt.Errorf("unsupported schema version %d, expected %d to %d", version, SchemaVersionV1, LatestSchemaVersion)
+}
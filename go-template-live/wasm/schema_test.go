@@ -0,0 +1,58 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"testing"
+)
+
+// TestMarshalVariables tests that each schema version keeps its JSON shape
+func TestMarshalVariables(t *testing.T) {
+	variables := []VariableInfo{
+		{Name: "host", DefaultValue: "localhost", Position: &Position{Offset: 7, Line: 1, Column: 8, Length: 6}},
+		{Name: "port"},
+	}
+
+	tests := []struct {
+		name      string
+		variables []VariableInfo
+		version   int
+		expected  string
+	}{
+		{
+			name:      "v1 shape",
+			variables: variables,
+			version:   SchemaVersionV1,
+			expected:  `[{"name":"host","defaultValue":"localhost"},{"name":"port"}]`,
+		},
+		{
+			name:      "v2 includes positions",
+			variables: variables,
+			version:   SchemaVersionV2,
+			expected:  `[{"name":"host","defaultValue":"localhost","position":{"offset":7,"line":1,"column":8,"length":6}},{"name":"port"}]`,
+		},
+		{
+			name:      "v1 keeps null for no variables",
+			variables: nil,
+			version:   SchemaVersionV1,
+			expected:  `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalVariables(tt.variables, tt.version)
+			if err != nil {
+				t.Fatalf("MarshalVariables() error = %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("MarshalVariables() = %s, want %s", data, tt.expected)
+			}
+		})
+	}
+
+	if _, err := MarshalVariables(variables, LatestSchemaVersion+1); err == nil {
+		t.Error("MarshalVariables() expected error for unsupported version")
+	}
+}
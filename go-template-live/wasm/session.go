@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"text/template/parse"
+)
+
+// RenderSession is a named live preview of one template whose values are edited one at a time,
+// as with slider and toggle controls
+// Updates to variables the template does not read are answered without rendering, and rendered
+// updates return only the output regions that changed. It is not safe for concurrent use
+type RenderSession struct {
+	name     string
+	content  string
+	renderer *Renderer
+	opts     RenderOptions
+
+	// dependencies are the variables the template reads; nil when the template reads values
+	// extraction cannot name (dynamic keys, the whole dot), so every update renders
+	dependencies []string
+	values       map[string]interface{}
+	output       string
+	revision     int
+}
+
+// OutputRegion is a run of output replaced by an update
+// Start and End are byte offsets into the previous output; regions are sorted and do not
+// overlap, so applying them from last to first turns the previous output into the new one
+type OutputRegion struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Line is the 1-based line of the previous output the region starts on
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// SessionUpdate is the result of pushing value changes into a session
+type SessionUpdate struct {
+	Revision int `json:"revision"`
+	// Rendered is false when no changed value is read by the template and the output was kept
+	Rendered bool           `json:"rendered"`
+	Regions  []OutputRegion `json:"regions"`
+	// MissingKeys and Warnings are those of the latest render
+	MissingKeys []string `json:"missingKeys,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// NewRenderSession renders the template with the initial values and returns the session
+// together with the full result of that first render
+func NewRenderSession(parser *Parser, renderer *Renderer, name, content string, values map[string]interface{}, opts RenderOptions) (*RenderSession, *RenderResult, error) {
+	fileName := opts.FileName
+	if fileName == "" {
+		fileName = name
+	}
+	names, err := parser.ExtractVariables(fileName, content)
+	if err != nil {
+		return nil, nil, err
+	}
+	lookups, err := parser.DynamicLookups(fileName, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := &RenderSession{
+		name:     name,
+		content:  content,
+		renderer: renderer,
+		opts:     opts,
+		values:   copyValues(values),
+	}
+	tmpl, err := parser.parseTemplate(context.Background(), fileName, content)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := loadIncludes(tmpl, parser.includes, parser.env.includeSource()); err != nil {
+		return nil, nil, err
+	}
+	opaque := len(lookups) > 0
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && readsRootDot(t.Tree.Root, 0) {
+			opaque = true
+		}
+	}
+	if !opaque {
+		session.dependencies = append([]string{}, names...)
+	}
+
+	result, err := renderer.Render(content, session.values, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	session.output = result.Output
+	return session, result, nil
+}
+
+// Name returns the session name
+func (s *RenderSession) Name() string {
+	return s.name
+}
+
+// Output returns the output of the latest render
+func (s *RenderSession) Output() string {
+	return s.output
+}
+
+// Revision counts the updates applied since the session was created
+func (s *RenderSession) Revision() int {
+	return s.revision
+}
+
+// Update applies value changes and re-renders when the template reads any of them
+// Keys are top-level names or dotted paths into nested objects (Server.Port); a nil value
+// removes the key. When rendering fails the session keeps its previous values and output
+func (s *RenderSession) Update(changes map[string]interface{}) (*SessionUpdate, error) {
+	values := copyValues(s.values)
+	for key, value := range changes {
+		setValuePath(values, key, value)
+	}
+
+	changed := ChangedKeys(s.values, values)
+	if !s.affectedBy(changed) {
+		s.values = values
+		s.revision++
+		return &SessionUpdate{Revision: s.revision, Regions: []OutputRegion{}}, nil
+	}
+
+	result, err := s.renderer.Render(s.content, values, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	update := &SessionUpdate{
+		Revision:    s.revision + 1,
+		Rendered:    true,
+		Regions:     outputRegions(s.output, result.Output),
+		MissingKeys: result.MissingKeys,
+		Warnings:    result.Warnings,
+	}
+	s.values = values
+	s.output = result.Output
+	s.revision++
+	return update, nil
+}
+
+// affectedBy reports whether a change of any of the top-level keys can alter the output
+func (s *RenderSession) affectedBy(changedKeys []string) bool {
+	if len(changedKeys) == 0 {
+		return false
+	}
+	if s.dependencies == nil {
+		return true
+	}
+	for _, key := range changedKeys {
+		for _, dep := range s.dependencies {
+			if keyAffects(key, dep) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyAffects reports whether the top-level values key can change the variable dep
+// Slash keys are read flat or through nested objects, so /myapp/port depends on the keys
+// myapp, /myapp and /myapp/port, and the pattern /services/* on any key under /services
+func keyAffects(key, dep string) bool {
+	if dep == key || strings.HasPrefix(dep, key+".") {
+		return true
+	}
+	if !isKeyPath(dep) {
+		return false
+	}
+	keySegments := strings.Split(strings.Trim(key, "/"), "/")
+	depSegments := strings.Split(strings.Trim(dep, "/"), "/")
+	for i, segment := range keySegments {
+		if i >= len(depSegments) {
+			return false
+		}
+		if depSegments[i] != "*" && depSegments[i] != segment {
+			return false
+		}
+	}
+	return true
+}
+
+// setValuePath sets (or, for a nil value, removes) a top-level key or a dotted path
+// Objects along the path are copied, so maps shared with earlier values are not mutated
+func setValuePath(values map[string]interface{}, path string, value interface{}) {
+	segments := strings.Split(path, ".")
+	if _, flat := values[path]; flat || len(segments) == 1 || isKeyPath(path) {
+		if value == nil {
+			delete(values, path)
+		} else {
+			values[path] = value
+		}
+		return
+	}
+
+	current := values
+	for _, segment := range segments[:len(segments)-1] {
+		nested, ok := current[segment].(map[string]interface{})
+		if !ok {
+			if value == nil {
+				return
+			}
+			nested = make(map[string]interface{})
+		}
+		nested = copyValues(nested)
+		current[segment] = nested
+		current = nested
+	}
+	last := segments[len(segments)-1]
+	if value == nil {
+		delete(current, last)
+	} else {
+		current[last] = value
+	}
+}
+
+// readsRootDot reports whether the template reads the root dot as a whole, as in {{toJson .}}
+// or {{range $k, $v := .}}; passing it to {{template}} is not counted, as extraction follows it
+func readsRootDot(node parse.Node, depth int) bool {
+	found := false
+	walkNodes(node, depth, func(node parse.Node, depth int) bool {
+		if found {
+			return false
+		}
+		switch node := node.(type) {
+		case *parse.DotNode:
+			found = true
+			return false
+		case *parse.RangeNode:
+			// Dot is rebound inside the body
+			found = readsRootDot(node.Pipe, depth+1) || readsRootDot(node.ElseList, depth+1)
+			return false
+		case *parse.WithNode:
+			found = readsRootDot(node.Pipe, depth+1) || readsRootDot(node.ElseList, depth+1)
+			return false
+		case *parse.TemplateNode:
+			if node.Pipe != nil && len(node.Pipe.Cmds) == 1 && len(node.Pipe.Cmds[0].Args) == 1 {
+				if _, ok := node.Pipe.Cmds[0].Args[0].(*parse.DotNode); ok {
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// outputRegions returns the changed runs of lines between two outputs as byte regions of old
+func outputRegions(oldOutput, newOutput string) []OutputRegion {
+	regions := []OutputRegion{}
+	offset, line := 0, 1
+	var current *OutputRegion
+	for _, diff := range diffLineSlices(splitLinesAfter(oldOutput), splitLinesAfter(newOutput)) {
+		if diff.Op == DiffEqual {
+			current = nil
+			offset += len(diff.Text)
+			line++
+			continue
+		}
+		if current == nil {
+			regions = append(regions, OutputRegion{Start: offset, End: offset, Line: line})
+			current = &regions[len(regions)-1]
+		}
+		if diff.Op == DiffDelete {
+			offset += len(diff.Text)
+			current.End = offset
+			line++
+		} else {
+			current.Text += diff.Text
+		}
+	}
+	return regions
+}
+
+// splitLinesAfter splits text into lines that keep their line endings, so a changed final
+// newline shows up as a changed line
+func splitLinesAfter(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
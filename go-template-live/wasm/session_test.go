@@ -0,0 +1,88 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRenderSession_Update tests that updates return the changed output regions and skip
+// rendering when the template reads none of the changed values
+func TestRenderSession_Update(t *testing.T) {
+	registry := NewFunctionRegistry()
+	values := map[string]interface{}{
+		"Name":   "app",
+		"Server": map[string]interface{}{"Port": 80, "Host": "web"},
+	}
+	session, result, err := NewRenderSession(NewParser(registry), NewRenderer(registry, nil), "preview",
+		"name: {{.Name}}\nport: {{.Server.Port}}\nhost: {{.Server.Host}}\n", values, RenderOptions{MissingKey: MissingKeyError})
+	if err != nil {
+		t.Fatalf("NewRenderSession() error = %v", err)
+	}
+	if expected := "name: app\nport: 80\nhost: web\n"; result.Output != expected {
+		t.Fatalf("NewRenderSession() output = %q, want %q", result.Output, expected)
+	}
+
+	update, err := session.Update(map[string]interface{}{"Server.Port": 81})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	expected := []OutputRegion{{Start: 10, End: 19, Line: 2, Text: "port: 81\n"}}
+	if update.Revision != 1 || !update.Rendered || !reflect.DeepEqual(update.Regions, expected) {
+		t.Errorf("Update() = %+v, want revision 1 with regions %+v", update, expected)
+	}
+	if port := values["Server"].(map[string]interface{})["Port"]; port != 80 {
+		t.Errorf("Update() changed the caller's values, Server.Port = %v", port)
+	}
+
+	update, err = session.Update(map[string]interface{}{"Replicas": 3})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if update.Rendered || len(update.Regions) != 0 || update.Revision != 2 {
+		t.Errorf("Update() of an unused value = %+v, want no render", update)
+	}
+
+	if _, err := session.Update(map[string]interface{}{"Name": nil}); err == nil {
+		t.Errorf("Update() removing a required key succeeded with missingkey=error")
+	}
+	if expected := "name: app\nport: 81\nhost: web\n"; session.Output() != expected || session.Revision() != 2 {
+		t.Errorf("failed Update() left output %q at revision %d, want %q at 2", session.Output(), session.Revision(), expected)
+	}
+
+	whole, _, err := NewRenderSession(NewParser(registry), NewRenderer(registry, nil), "keys",
+		"{{range $k, $v := .}}{{$k}} {{end}}", map[string]interface{}{"a": 1}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("NewRenderSession() error = %v", err)
+	}
+	update, err = whole.Update(map[string]interface{}{"b": 2})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if expected = []OutputRegion{{Start: 0, End: 2, Line: 1, Text: "a b "}}; !update.Rendered || !reflect.DeepEqual(update.Regions, expected) {
+		t.Errorf("Update() of a template ranging over dot = %+v, want regions %+v", update, expected)
+	}
+}
+
+// TestKeyAffects tests which top-level value keys a variable depends on
+func TestKeyAffects(t *testing.T) {
+	tests := []struct {
+		key, dep string
+		want     bool
+	}{
+		{"Server", "Server.Port", true},
+		{"Server", "ServerName", false},
+		{"myapp", "/myapp/port", true},
+		{"/myapp/port", "/myapp/port", true},
+		{"/myapp/host", "/myapp/port", false},
+		{"/services/web", "/services/*", true},
+		{"/services/web/port", "/services/*", false},
+	}
+	for _, tt := range tests {
+		if got := keyAffects(tt.key, tt.dep); got != tt.want {
+			t.Errorf("keyAffects(%q, %q) = %v, want %v", tt.key, tt.dep, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+// SnapshotResult is the outcome of checking a template's output against a recorded snapshot
+type SnapshotResult struct {
+	// Name identifies the case when snapshots are run from a directory
+	Name   string `json:"name,omitempty"`
+	Passed bool   `json:"passed"`
+	// Output is the current output; Diff turns the expected output into it
+	Output string     `json:"output"`
+	Diff   []DiffLine `json:"diff"`
+	Stats  DiffStats  `json:"stats"`
+	// Error is set when the template failed to render or the case could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// RecordSnapshot renders a template and returns the output to keep as its snapshot
+// Snapshots of templates reading the clock or randomness should be recorded and checked with
+// the Deterministic option
+func (r *Renderer) RecordSnapshot(templateContent string, values map[string]interface{}, opts RenderOptions) (string, error) {
+	result, err := r.Render(templateContent, values, opts)
+	if err != nil {
+		return "", err
+	}
+	return result.Output, nil
+}
+
+// CheckSnapshot renders a template and compares the output with a recorded snapshot
+// A render error fails the check instead of being returned
+func (r *Renderer) CheckSnapshot(templateContent string, values map[string]interface{}, expected string, opts RenderOptions) *SnapshotResult {
+	output, err := r.RecordSnapshot(templateContent, values, opts)
+	if err != nil {
+		return &SnapshotResult{Diff: []DiffLine{}, Error: err.Error()}
+	}
+	diff := DiffLines(expected, output)
+	return &SnapshotResult{
+		Passed: output == expected,
+		Output: output,
+		Diff:   diff,
+		Stats:  ComputeDiffStats(diff),
+	}
+}
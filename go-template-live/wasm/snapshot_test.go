@@ -0,0 +1,83 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckSnapshot(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	template := "host {{.Host}}\nport {{.Port}}\n"
+	values := map[string]interface{}{"Host": "localhost", "Port": 80}
+
+	snapshot, err := renderer.RecordSnapshot(template, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("RecordSnapshot() error = %v", err)
+	}
+	if result := renderer.CheckSnapshot(template, values, snapshot, RenderOptions{}); !result.Passed {
+		t.Errorf("CheckSnapshot() = %+v, want passed", result)
+	}
+
+	values["Port"] = 8080
+	result := renderer.CheckSnapshot(template, values, snapshot, RenderOptions{})
+	if result.Passed || result.Stats.Added != 1 || result.Stats.Removed != 1 {
+		t.Errorf("CheckSnapshot() = %+v, want one changed line", result)
+	}
+
+	result = renderer.CheckSnapshot("{{.Host", values, snapshot, RenderOptions{})
+	if result.Passed || result.Error == "" {
+		t.Errorf("CheckSnapshot() = %+v, want a render error", result)
+	}
+}
+
+func TestRunSnapshotDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"nginx/site.tmpl":        "server_name {{.server.name}};\n",
+		"nginx/site.values.yaml": "server:\n  name: example.com\n",
+		"motd.tmpl":              "Welcome\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+
+	results, err := renderer.RunSnapshotDir(dir, RenderOptions{}, false)
+	if err != nil {
+		t.Fatalf("RunSnapshotDir() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Passed || !strings.Contains(results[0].Error, "no snapshot recorded") {
+		t.Fatalf("RunSnapshotDir() = %+v, want cases without snapshots to fail", results)
+	}
+
+	if _, err := renderer.RunSnapshotDir(dir, RenderOptions{}, true); err != nil {
+		t.Fatalf("RunSnapshotDir(update) error = %v", err)
+	}
+	recorded, err := os.ReadFile(filepath.Join(dir, "nginx", "site.snap"))
+	if err != nil || string(recorded) != "server_name example.com;\n" {
+		t.Fatalf("recorded snapshot = %q, %v", recorded, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "nginx", "site.values.yaml"), []byte("server:\n  name: example.org\n"), 0o644)
+	results, err = renderer.RunSnapshotDir(dir, RenderOptions{}, false)
+	if err != nil {
+		t.Fatalf("RunSnapshotDir() error = %v", err)
+	}
+	if results[0].Name != "motd" || !results[0].Passed {
+		t.Errorf("results[0] = %+v, want motd to pass", results[0])
+	}
+	if results[1].Name != "nginx/site" || results[1].Passed || results[1].Stats.Removed != 1 {
+		t.Errorf("results[1] = %+v, want nginx/site to fail with a diff", results[1])
+	}
+}
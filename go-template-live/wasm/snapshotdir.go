@@ -0,0 +1,104 @@
+//go:build !js
+// +build !js
+
+// This file contains running directories of snapshot cases for native builds
+// Tag: !js (the browser playground checks single snapshots with checkSnapshot instead)
+// The module builds no native command; RunSnapshotDir is the library side of a `test`
+// subcommand for the embedding tool to expose
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Snapshot case files: <name>.tmpl is the template, <name>.snap its recorded output, and an
+// optional <name>.values.yaml, .values.yml or .values.json the values it is rendered with
+const (
+	snapshotTemplateExt = ".tmpl"
+	snapshotOutputExt   = ".snap"
+)
+
+var snapshotValuesExts = []string{".values.yaml", ".values.yml", ".values.json"}
+
+// RunSnapshotDir checks every snapshot case below dir, returning the results sorted by case name
+// (the template path relative to dir, without .tmpl). With update, the current outputs are
+// recorded as the snapshots instead, and every case that renders passes
+// A case without a snapshot fails; problems with one case do not stop the others
+func (r *Renderer) RunSnapshotDir(dir string, opts RenderOptions, update bool) ([]SnapshotResult, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(name, snapshotTemplateExt) {
+			return err
+		}
+		names = append(names, strings.TrimSuffix(name, snapshotTemplateExt))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot directory: %v", err)
+	}
+	sort.Strings(names)
+
+	results := make([]SnapshotResult, 0, len(names))
+	for _, name := range names {
+		result := r.runSnapshotCase(name, opts, update)
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			rel = name
+		}
+		result.Name = filepath.ToSlash(rel)
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// runSnapshotCase checks or records the snapshot case whose files start with base
+func (r *Renderer) runSnapshotCase(base string, opts RenderOptions, update bool) *SnapshotResult {
+	failed := func(err error) *SnapshotResult {
+		return &SnapshotResult{Diff: []DiffLine{}, Error: err.Error()}
+	}
+
+	content, err := os.ReadFile(base + snapshotTemplateExt)
+	if err != nil {
+		return failed(err)
+	}
+	values := map[string]interface{}{}
+	for _, ext := range snapshotValuesExts {
+		data, err := os.ReadFile(base + ext)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return failed(err)
+		}
+		if values, err = ParseValues(string(data)); err != nil {
+			return failed(fmt.Errorf("%s: %v", base+ext, err))
+		}
+		break
+	}
+
+	if update {
+		output, err := r.RecordSnapshot(string(content), values, opts)
+		if err != nil {
+			return failed(err)
+		}
+		if err := os.WriteFile(base+snapshotOutputExt, []byte(output), 0o644); err != nil {
+			return failed(err)
+		}
+		return &SnapshotResult{Passed: true, Output: output, Diff: []DiffLine{}}
+	}
+
+	expected, err := os.ReadFile(base + snapshotOutputExt)
+	if os.IsNotExist(err) {
+		return failed(fmt.Errorf("no snapshot recorded in %s", base+snapshotOutputExt))
+	}
+	if err != nil {
+		return failed(err)
+	}
+	return r.CheckSnapshot(string(content), values, string(expected), opts)
+}
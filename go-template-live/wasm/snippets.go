@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FunctionSnippet is the text the editor inserts for a function, with tab stops for its
+// arguments in the LSP snippet syntax, e.g. getv "${1:key}" "${2:default}"
+type FunctionSnippet struct {
+	Name    string `json:"name"`
+	Snippet string `json:"snippet"`
+	// Detail is the signature of the function, e.g. getv(string, ...string) string
+	Detail      string `json:"detail,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// FunctionSnippets returns a snippet for each function of the registry, sorted by name
+func FunctionSnippets(registry *FunctionRegistry) []FunctionSnippet {
+	names := registry.GetFunctionNames()
+	sort.Strings(names)
+	snippets := make([]FunctionSnippet, 0, len(names))
+	for _, name := range names {
+		def, _ := registry.GetFunction(name)
+		snippets = append(snippets, FunctionSnippet{
+			Name:        name,
+			Snippet:     functionSnippet(def),
+			Detail:      functionSignature(name, def.Handler),
+			Description: def.Description,
+		})
+	}
+	return snippets
+}
+
+// functionSnippet writes the name of a function followed by a tab stop per parameter of its
+// handler: string parameters are quoted, and a variadic parameter gets a single tab stop
+func functionSnippet(def *FunctionDefinition) string {
+	t := reflect.TypeOf(def.Handler)
+	if t == nil || t.Kind() != reflect.Func {
+		return def.Name
+	}
+	parts := []string{def.Name}
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			in = in.Elem()
+		}
+		name := snippetArgName(in)
+		if i < len(def.ArgNames) {
+			name = def.ArgNames[i]
+		}
+		stop := fmt.Sprintf("${%d:%s}", i+1, escapeSnippet(name))
+		if in.Kind() == reflect.String {
+			stop = `"` + stop + `"`
+		}
+		parts = append(parts, stop)
+	}
+	return strings.Join(parts, " ")
+}
+
+// snippetArgName names a parameter after its type
+func snippetArgName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "text"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map:
+		return "map"
+	case reflect.Func:
+		return "func"
+	}
+	return "value"
+}
+
+// escapeSnippet escapes the characters with a meaning in snippet placeholders
+func escapeSnippet(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`).Replace(text)
+}
@@ -0,0 +1,34 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionSnippets(t *testing.T) {
+	registry := NewFunctionRegistry()
+	registry.RegisterFunction(&FunctionDefinition{
+		Name:        "getv",
+		Description: "Returns the value of a key",
+		Handler:     func(key string, defaultValue ...string) string { return "" },
+		ArgNames:    []string{"key", "default"},
+	})
+	registry.RegisterFunction(&FunctionDefinition{Name: "add", Handler: func(a, b int) int { return 0 }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "join", Handler: func(items []string, sep string) string { return "" }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "now", Handler: func() (string, error) { return "", nil }})
+	registry.RegisterFunction(&FunctionDefinition{Name: "odd", Handler: func(v interface{}) bool { return false }, ArgNames: []string{"${x}"}})
+
+	want := []FunctionSnippet{
+		{Name: "add", Snippet: "add ${1:int} ${2:int}", Detail: "add(int, int) int"},
+		{Name: "getv", Snippet: `getv "${1:key}" "${2:default}"`, Detail: "getv(string, ...string) string", Description: "Returns the value of a key"},
+		{Name: "join", Snippet: `join ${1:list} "${2:text}"`, Detail: "join([]string, string) string"},
+		{Name: "now", Snippet: "now", Detail: "now() string"},
+		{Name: "odd", Snippet: `odd ${1:\${x\}}`, Detail: "odd(any) bool"},
+	}
+	if got := FunctionSnippets(registry); !reflect.DeepEqual(got, want) {
+		t.Errorf("FunctionSnippets() = %+v, want %+v", got, want)
+	}
+}
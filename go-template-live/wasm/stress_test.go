@@ -0,0 +1,166 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// maxNestedBlocks is the deepest {{if}} nesting accepted by the walkers: every block spends two
+// levels of maxDepth (the block and its list) and the innermost action three more
+const maxNestedBlocks = (maxDepth - 5) / 2
+
+// generateNestedTemplate nests depth blocks, cycling through if, with and range
+func generateNestedTemplate(depth int) string {
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		switch i % 3 {
+		case 0:
+			fmt.Fprintf(&b, "{{if .Flag%d}}", i)
+		case 1:
+			b.WriteString("{{with $}}")
+		case 2:
+			b.WriteString("{{range $.Items}}")
+		}
+	}
+	b.WriteString("{{$.Leaf}}")
+	b.WriteString(strings.Repeat("{{end}}", depth))
+	return b.String()
+}
+
+// generateWideTemplate produces n sibling sections, each with a plain and a guarded action
+func generateWideTemplate(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "{{.Field%d}}\n{{if .Flag%d}}{{.Name}}{{end}}\n", i, i%10)
+	}
+	return b.String()
+}
+
+// stressExtractors are the extraction entry points covered by the stress tests
+func stressExtractors(parser *Parser) map[string]func(string) error {
+	return map[string]func(string) error{
+		"ExtractVariables": func(tmpl string) error {
+			_, err := parser.ExtractVariables("stress.tmpl", tmpl)
+			return err
+		},
+		"ExtractVariablesWithDefaults": func(tmpl string) error {
+			_, err := parser.ExtractVariablesWithDefaults("stress.tmpl", tmpl)
+			return err
+		},
+		"ExtractVariablesAggregated": func(tmpl string) error {
+			_, err := parser.ExtractVariablesAggregated("stress.tmpl", tmpl)
+			return err
+		},
+	}
+}
+
+// TestStress_DepthBoundary tests that nesting up to the limit succeeds and one more level fails cleanly
+func TestStress_DepthBoundary(t *testing.T) {
+	for name, extract := range stressExtractors(NewParser(NewFunctionRegistry())) {
+		t.Run(name, func(t *testing.T) {
+			if err := extract(generateNestedTemplate(maxNestedBlocks)); err != nil {
+				t.Errorf("depth %d: unexpected error %v", maxNestedBlocks, err)
+			}
+			err := extract(generateNestedTemplate(maxNestedBlocks + 1))
+			if err == nil || !strings.Contains(err.Error(), "nesting depth exceeded") {
+				t.Errorf("depth %d: error = %v, want nesting depth error", maxNestedBlocks+1, err)
+			}
+		})
+	}
+}
+
+// TestStress_WideFanOutLinear tests that extraction finds every sibling action and that its
+// allocations grow linearly with their number
+func TestStress_WideFanOutLinear(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test skipped in short mode")
+	}
+	const small, factor = 500, 8
+	smallTemplate := generateWideTemplate(small)
+	largeTemplate := generateWideTemplate(small * factor)
+
+	parser := NewParser(NewFunctionRegistry())
+	names, err := parser.ExtractVariables("stress.tmpl", largeTemplate)
+	if err != nil {
+		t.Fatalf("ExtractVariables() error = %v", err)
+	}
+	// Each section reads its field, a flag and Name
+	if expected := small * factor * 3; len(names) != expected {
+		t.Errorf("ExtractVariables() found %d variables, want %d", len(names), expected)
+	}
+
+	for name, extract := range stressExtractors(parser) {
+		t.Run(name, func(t *testing.T) {
+			if err := extract(largeTemplate); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			smallAllocs := testing.AllocsPerRun(3, func() { _ = extract(smallTemplate) })
+			largeAllocs := testing.AllocsPerRun(3, func() { _ = extract(largeTemplate) })
+			if ratio := largeAllocs / smallAllocs; ratio > factor*1.25 {
+				t.Errorf("allocations grew %.1fx for %dx input, want at most %.1fx", ratio, factor, factor*1.25)
+			}
+		})
+	}
+}
+
+// TestStress_ResolvePositions tests line and column resolution of many variables in one template;
+// BenchmarkResolvePositions measures its cost
+func TestStress_ResolvePositions(t *testing.T) {
+	const n = 2000
+	line := "{{.Name}} héllo\n"
+	content := strings.Repeat(line, n)
+	variables := make([]VariableInfo, 2*n)
+	for i := 0; i < n; i++ {
+		variables[2*i].Position = &Position{Offset: i * len(line)}
+		// The l after é is the 13th rune of the line but its 14th byte
+		variables[2*i+1].Position = &Position{Offset: i*len(line) + len("{{.Name}} hé")}
+	}
+	resolvePositions(content, variables)
+
+	for i := 0; i < n; i++ {
+		start, after := variables[2*i].Position, variables[2*i+1].Position
+		if start.Line != i+1 || start.Column != 1 || after.Line != i+1 || after.Column != 13 {
+			t.Fatalf("line %d resolved to %d:%d and %d:%d, want %d:1 and %d:13", i+1, start.Line, start.Column, after.Line, after.Column, i+1, i+1)
+		}
+	}
+}
+
+func BenchmarkExtract_Wide(b *testing.B) {
+	parser := NewParser(NewFunctionRegistry())
+	tmpl := generateWideTemplate(2000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ExtractVariablesAggregated("stress.tmpl", tmpl); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExtract_Deep(b *testing.B) {
+	parser := NewParser(NewFunctionRegistry())
+	tmpl := generateNestedTemplate(maxNestedBlocks)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ExtractVariablesAggregated("stress.tmpl", tmpl); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResolvePositions(b *testing.B) {
+	const n = 16000
+	line := "{{.Name}} héllo\n"
+	content := strings.Repeat(line, n)
+	variables := make([]VariableInfo, n)
+	for i := range variables {
+		variables[i].Position = &Position{Offset: i * len(line)}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resolvePositions(content, variables)
+	}
+}
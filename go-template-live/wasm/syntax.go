@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Output syntaxes detected for rendered content, so UIs can pick highlighting and validators
+const (
+	SyntaxText    = "text"
+	SyntaxYAML    = "yaml"
+	SyntaxJSON    = "json"
+	SyntaxINI     = "ini"
+	SyntaxTOML    = "toml"
+	SyntaxXML     = "xml"
+	SyntaxNginx   = "nginx"
+	SyntaxShell   = "shell"
+	SyntaxSystemd = "systemd"
+)
+
+// Sources of a syntax detection
+const (
+	// SyntaxSourceExtension means the template file name decided the syntax
+	SyntaxSourceExtension = "extension"
+	// SyntaxSourceContent means the syntax was inferred from the rendered output
+	SyntaxSourceContent = "content"
+)
+
+// SyntaxDetection is the likely format of rendered output
+type SyntaxDetection struct {
+	// Format is one of the Syntax constants; SyntaxText when nothing matched
+	Format string `json:"format"`
+	// Source is SyntaxSourceExtension or SyntaxSourceContent, empty for SyntaxText
+	Source string `json:"source,omitempty"`
+}
+
+// templateExtensions are stripped from file names before looking at the output extension,
+// so config.yaml.tmpl is detected as YAML
+var templateExtensions = map[string]bool{
+	".tmpl": true, ".tpl": true, ".gotmpl": true, ".template": true,
+}
+
+// extensionSyntaxes maps output file extensions to their syntax
+var extensionSyntaxes = map[string]string{
+	".yaml": SyntaxYAML, ".yml": SyntaxYAML,
+	".json": SyntaxJSON,
+	".ini":  SyntaxINI,
+	".toml": SyntaxTOML,
+	".xml":  SyntaxXML,
+	".sh":   SyntaxShell, ".bash": SyntaxShell, ".zsh": SyntaxShell, ".env": SyntaxShell,
+	".service": SyntaxSystemd, ".socket": SyntaxSystemd, ".timer": SyntaxSystemd,
+	".mount": SyntaxSystemd, ".automount": SyntaxSystemd, ".target": SyntaxSystemd,
+	".slice": SyntaxSystemd, ".swap": SyntaxSystemd,
+}
+
+// systemdSections are the section names of systemd unit files
+var systemdSections = map[string]bool{
+	"Unit": true, "Install": true, "Service": true, "Socket": true, "Timer": true, "Mount": true,
+	"Automount": true, "Path": true, "Slice": true, "Scope": true, "Swap": true,
+}
+
+// syntaxPrecedence breaks ties between content scores; plain KEY=value lines count for shell,
+// INI and systemd alike, so only section headers make a file INI or systemd
+var syntaxPrecedence = []string{SyntaxNginx, SyntaxShell, SyntaxINI, SyntaxSystemd, SyntaxYAML}
+
+var (
+	sectionHeader    = regexp.MustCompile(`^\[([^\[\]]+)\]$`)
+	shellAssignment  = regexp.MustCompile(`^(export\s+)?[A-Za-z_][A-Za-z0-9_]*=\S*`)
+	iniAssignment    = regexp.MustCompile(`^[A-Za-z0-9_.\-]+\s*=`)
+	yamlMappingEntry = regexp.MustCompile(`^(- )?["']?[A-Za-z0-9_.\-/]+["']?:(\s|$)`)
+	shellKeyword     = regexp.MustCompile(`^(if|then|else|elif|fi|for|while|do|done|case|esac|echo|exec|set|source)(\s|;|$)`)
+)
+
+// DetectSyntax returns the likely format of rendered output
+// A known extension of fileName, after template extensions such as .tmpl are removed, decides
+// the format; otherwise (including the ambiguous .conf, unless the name mentions nginx) it is
+// inferred from the output
+func DetectSyntax(fileName, output string) SyntaxDetection {
+	if format := extensionSyntax(fileName); format != "" {
+		return SyntaxDetection{Format: format, Source: SyntaxSourceExtension}
+	}
+	if format := contentSyntax(output); format != SyntaxText {
+		return SyntaxDetection{Format: format, Source: SyntaxSourceContent}
+	}
+	return SyntaxDetection{Format: SyntaxText}
+}
+
+// extensionSyntax maps a file name to a syntax, empty when the name does not tell
+func extensionSyntax(fileName string) string {
+	name := strings.ToLower(path.Base(fileName))
+	for templateExtensions[path.Ext(name)] {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	ext := path.Ext(name)
+	if ext == ".conf" && strings.Contains(name, "nginx") {
+		return SyntaxNginx
+	}
+	return extensionSyntaxes[ext]
+}
+
+// contentSyntax scores each line of output against every syntax and returns the best match
+func contentSyntax(output string) string {
+	trimmed := strings.TrimSpace(output)
+	if trimmed == "" {
+		return SyntaxText
+	}
+	if strings.HasPrefix(trimmed, "#!") && isShellShebang(trimmed) {
+		return SyntaxShell
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return SyntaxJSON
+	}
+
+	scores := make(map[string]int)
+	for _, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if m := sectionHeader.FindStringSubmatch(line); m != nil {
+			if systemdSections[m[1]] {
+				scores[SyntaxSystemd] += 3
+			} else {
+				scores[SyntaxINI] += 2
+			}
+			continue
+		}
+		switch {
+		case strings.HasSuffix(line, "{") || line == "}" || strings.HasSuffix(line, ";"):
+			scores[SyntaxNginx]++
+		case shellKeyword.MatchString(line):
+			scores[SyntaxShell] += 2
+		case strings.HasPrefix(line, "export "):
+			scores[SyntaxShell] += 2
+		case shellAssignment.MatchString(line):
+			scores[SyntaxShell]++
+			scores[SyntaxINI]++
+			scores[SyntaxSystemd]++
+		case iniAssignment.MatchString(line):
+			scores[SyntaxINI]++
+			scores[SyntaxSystemd]++
+		case line == "---" || yamlMappingEntry.MatchString(line) || strings.HasPrefix(line, "- "):
+			scores[SyntaxYAML]++
+		}
+	}
+
+	best, bestScore := SyntaxText, 0
+	for _, format := range syntaxPrecedence {
+		if scores[format] > bestScore {
+			best, bestScore = format, scores[format]
+		}
+	}
+	return best
+}
+
+// isShellShebang reports whether the interpreter line of content runs a shell,
+// as in #!/bin/sh or #!/usr/bin/env bash
+func isShellShebang(content string) bool {
+	shebang, _, _ := strings.Cut(strings.TrimPrefix(content, "#!"), "\n")
+	for _, field := range strings.Fields(shebang) {
+		switch path.Base(field) {
+		case "sh", "bash", "dash", "ksh", "zsh", "ash":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+//go:build !js
+// +build !js
+
+package main
+
+import "testing"
+
+// TestDetectSyntax tests output syntax detection from file names and rendered content
+func TestDetectSyntax(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		output   string
+		expected SyntaxDetection
+	}{
+		{"yaml extension", "app.yaml.tmpl", "anything", SyntaxDetection{SyntaxYAML, SyntaxSourceExtension}},
+		{"systemd unit extension", "web.service.tpl", "", SyntaxDetection{SyntaxSystemd, SyntaxSourceExtension}},
+		{"nginx conf name", "conf.d/nginx.conf.tmpl", "", SyntaxDetection{SyntaxNginx, SyntaxSourceExtension}},
+		{"json content", "template.tmpl", "{\"port\": 8080, \"hosts\": [\"a\"]}\n", SyntaxDetection{SyntaxJSON, SyntaxSourceContent}},
+		{"yaml content", "template.tmpl", "---\nserver:\n  port: 8080\n  hosts:\n    - a\n", SyntaxDetection{SyntaxYAML, SyntaxSourceContent}},
+		{"nginx content under ambiguous .conf", "site.conf", "upstream app {\n  server 10.0.0.1:80;\n}\nserver {\n  listen 80;\n}\n", SyntaxDetection{SyntaxNginx, SyntaxSourceContent}},
+		{"ini content", "template.tmpl", "[database]\nhost = db\nport = 5432\n", SyntaxDetection{SyntaxINI, SyntaxSourceContent}},
+		{"systemd content", "template.tmpl", "[Unit]\nDescription=Web\n\n[Service]\nExecStart=/usr/bin/web --port 80\n", SyntaxDetection{SyntaxSystemd, SyntaxSourceContent}},
+		{"shell shebang", "template.tmpl", "#!/usr/bin/env bash\nrun\n", SyntaxDetection{SyntaxShell, SyntaxSourceContent}},
+		{"shell assignments", "template.tmpl", "export DB_HOST=db\nDB_PORT=5432\n", SyntaxDetection{SyntaxShell, SyntaxSourceContent}},
+		{"plain text", "template.tmpl", "Hello, World!", SyntaxDetection{Format: SyntaxText}},
+		{"empty output", "", "", SyntaxDetection{Format: SyntaxText}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectSyntax(tt.fileName, tt.output); got != tt.expected {
+				t.Errorf("DetectSyntax(%q) = %+v, want %+v", tt.fileName, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRenderer_Syntax tests that render results carry the detected output syntax
+func TestRenderer_Syntax(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+
+	result, err := renderer.Render("port: {{.Port}}\n", map[string]interface{}{"Port": 8080}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Syntax == nil || result.Syntax.Format != SyntaxYAML {
+		t.Errorf("Render() syntax = %+v, want yaml", result.Syntax)
+	}
+
+	result, err = renderer.Render("port: {{.Port}}\n", map[string]interface{}{"Port": 8080}, RenderOptions{FileName: "app.ini"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := (SyntaxDetection{SyntaxINI, SyntaxSourceExtension}); result.Syntax == nil || *result.Syntax != expected {
+		t.Errorf("Render() syntax = %+v, want %+v", result.Syntax, expected)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import "strings"
+
+// Quote styles accepted by FormatOptions.QuoteStyle
+const (
+	// QuoteStyleDouble rewrites `raw` strings as "interpreted" ones where no escaping is needed
+	QuoteStyleDouble = "double"
+	// QuoteStyleRaw rewrites "interpreted" strings without escapes as `raw` ones
+	QuoteStyleRaw = "raw"
+)
+
+// formatIndent is the indentation of one nesting level written by FormatOptions.IndentBlocks
+const formatIndent = "  "
+
+// blockKeywords open a block closed by {{end}}
+var blockKeywords = map[string]bool{
+	"if": true, "range": true, "with": true, "define": true, "block": true,
+}
+
+// formatActions rewrites the actions of content and the indentation of the lines they start,
+// as selected by opts. Comments and actions spanning lines are kept as written
+func formatActions(content string, opts FormatOptions) string {
+	var b strings.Builder
+	last := 0
+	depth := 0
+	previousRightTrim := false
+	for _, a := range scanActions(content) {
+		text := content[last:a.start]
+		keyword := actionKeyword(content, a)
+
+		level := depth
+		switch keyword {
+		case "end", "else":
+			level = depth - 1
+		}
+		if opts.IndentBlocks && level >= 0 {
+			text = reindentAction(text, a.leftTrim, previousRightTrim && last > 0, level)
+		}
+		b.WriteString(text)
+
+		action := content[a.start:a.end]
+		if opts.NormalizeSpacing || opts.QuoteStyle != "" {
+			action = formatAction(action, a, opts)
+		}
+		b.WriteString(action)
+
+		switch {
+		case blockKeywords[keyword]:
+			depth++
+		case keyword == "end" && depth > 0:
+			depth--
+		}
+		previousRightTrim = a.rightTrim
+		last = a.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// reindentAction sets the indentation before an action that starts its line, when that
+// indentation is removed by a trim marker anyway: the action's own {{- or the -}} of the action
+// before it when only whitespace separates them. text is the source between the two actions
+func reindentAction(text string, leftTrim, trimmedBefore bool, level int) string {
+	lineStart := strings.LastIndexByte(text, '\n') + 1
+	if lineStart == 0 || strings.Trim(text[lineStart:], " \t") != "" {
+		return text
+	}
+	if !leftTrim && !(trimmedBefore && strings.TrimSpace(text) == "") {
+		return text
+	}
+	return text[:lineStart] + strings.Repeat(formatIndent, level)
+}
+
+// actionKeyword returns the control keyword an action starts with, or ""
+func actionKeyword(content string, a templateAction) string {
+	fields := strings.Fields(actionBody(content, a))
+	if len(fields) == 0 {
+		return ""
+	}
+	switch fields[0] {
+	case "if", "range", "with", "define", "block", "else", "end":
+		return fields[0]
+	}
+	return ""
+}
+
+// actionBody returns the source of an action between its delimiters and trim markers
+func actionBody(content string, a templateAction) string {
+	start, end := a.start+2, a.end-2
+	if a.leftTrim {
+		start++
+	}
+	if a.rightTrim {
+		end--
+	}
+	if start > end {
+		return ""
+	}
+	return content[start:end]
+}
+
+// formatAction rewrites one action: a single space inside the delimiters and trim markers,
+// single spaces between words and around pipes, and string literals in the selected quote style
+func formatAction(action string, a templateAction, opts FormatOptions) string {
+	body := actionBody(action, templateAction{start: 0, end: len(action), leftTrim: a.leftTrim, rightTrim: a.rightTrim})
+	trimmed := strings.TrimSpace(body)
+	if trimmed == "" || strings.HasPrefix(trimmed, "/*") || strings.Contains(body, "\n") {
+		return action
+	}
+
+	var out strings.Builder
+	space := false
+	for i := 0; i < len(trimmed); {
+		c := trimmed[i]
+		switch {
+		case isTemplateSpace(c):
+			if !opts.NormalizeSpacing {
+				out.WriteByte(c)
+			}
+			space = true
+			i++
+			continue
+		case c == '"' || c == '`' || c == '\'':
+			end := stringLiteralEnd(trimmed, i)
+			if opts.NormalizeSpacing && space {
+				out.WriteByte(' ')
+			}
+			out.WriteString(requoteLiteral(trimmed[i:end], opts.QuoteStyle))
+			i = end
+		case c == '|' && opts.NormalizeSpacing:
+			out.WriteString(" | ")
+			i++
+			for i < len(trimmed) && isTemplateSpace(trimmed[i]) {
+				i++
+			}
+			space = false
+			continue
+		default:
+			if opts.NormalizeSpacing && space && !strings.HasSuffix(out.String(), " ") {
+				out.WriteByte(' ')
+			}
+			out.WriteByte(c)
+			i++
+		}
+		space = false
+	}
+
+	if !opts.NormalizeSpacing {
+		lead := body[:len(body)-len(strings.TrimLeft(body, " \t\r\n"))]
+		trail := body[len(strings.TrimRight(body, " \t\r\n")):]
+		return openDelimiter(a, lead) + out.String() + closeDelimiter(a, trail)
+	}
+	return openDelimiter(a, " ") + out.String() + closeDelimiter(a, " ")
+}
+
+// openDelimiter writes {{ and its trim marker followed by space
+func openDelimiter(a templateAction, space string) string {
+	if a.leftTrim {
+		return "{{-" + space
+	}
+	return "{{" + space
+}
+
+// closeDelimiter writes space followed by the trim marker and }}
+func closeDelimiter(a templateAction, space string) string {
+	if a.rightTrim {
+		return space + "-}}"
+	}
+	return space + "}}"
+}
+
+// stringLiteralEnd returns the offset after the string or character literal starting at i
+func stringLiteralEnd(s string, i int) int {
+	quote := s[i]
+	j := i + 1
+	for j < len(s) && s[j] != quote {
+		if s[j] == '\\' && quote != '`' {
+			j++
+		}
+		j++
+	}
+	if j < len(s) {
+		j++
+	}
+	return j
+}
+
+// requoteLiteral converts a string literal to the quote style when its value stays the same
+// without escaping; character literals and literals that need escapes are kept
+func requoteLiteral(literal, style string) string {
+	if len(literal) < 2 {
+		return literal
+	}
+	value := literal[1 : len(literal)-1]
+	switch {
+	case style == QuoteStyleDouble && literal[0] == '`' && !strings.ContainsAny(value, "\"\\\n\r"):
+		return `"` + value + `"`
+	case style == QuoteStyleRaw && literal[0] == '"' && !strings.ContainsAny(value, "`\\"):
+		return "`" + value + "`"
+	}
+	return literal
+}
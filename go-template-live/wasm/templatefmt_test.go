@@ -0,0 +1,102 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFormatTemplate_Actions(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	tests := []struct {
+		name     string
+		template string
+		opts     FormatOptions
+		want     string
+	}{
+		{"spacing", "{{.Name|printf   \"%s-x\"}} {{-  .Port  -}} {{/*  note  */}}", FormatOptions{NormalizeSpacing: true},
+			"{{ .Name | printf \"%s-x\" }} {{- .Port -}} {{/*  note  */}}"},
+		{"negative number", "{{-3}}", FormatOptions{NormalizeSpacing: true}, "{{ -3 }}"},
+		{"double quotes", "{{printf `%s` `a\"b`}}", FormatOptions{QuoteStyle: QuoteStyleDouble}, "{{printf \"%s\" `a\"b`}}"},
+		{"raw quotes", "{{ printf \"%s\" \"a\\tb\" }}", FormatOptions{QuoteStyle: QuoteStyleRaw}, "{{ printf `%s` \"a\\tb\" }}"},
+		{"indent", "{{- range .Hosts}}\n{{- if .}}\nhost\n{{- else}}\n      {{- $x := 1}}\n{{- end}}\n{{- end}}\n", FormatOptions{IndentBlocks: true},
+			"{{- range .Hosts}}\n  {{- if .}}\nhost\n  {{- else}}\n    {{- $x := 1}}\n  {{- end}}\n{{- end}}\n"},
+		{"untrimmed lines kept", "{{if .A}}\n{{.B}}\n{{end}}\n", FormatOptions{IndentBlocks: true}, "{{if .A}}\n{{.B}}\n{{end}}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.FormatTemplate("test.tmpl", tt.template, tt.opts)
+			if err != nil {
+				t.Fatalf("FormatTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FormatTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parser.FormatTemplate("test.tmpl", "x", FormatOptions{QuoteStyle: "single"}); err == nil {
+		t.Error("FormatTemplate() accepted an unknown quote style")
+	}
+}
+
+// TestFormatTemplate_SameOutput tests that formatting with every option keeps the output
+func TestFormatTemplate_SameOutput(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	template := "server:\n  {{if .TLS}}\n  tls: {{printf `%v`   .TLS}}\n  {{end}}\n  {{range .Hosts}}\n    {{if .}}\n  - {{.|printf \"%s\"}}\n    {{end}}\n  {{end}}\n  port: 80\n"
+	values := map[string]interface{}{"TLS": true, "Hosts": []interface{}{"a", "", "b"}}
+
+	formatted, err := parser.FormatTemplate("test.tmpl", template, FormatOptions{FixTrimMarkers: true, NormalizeSpacing: true, QuoteStyle: QuoteStyleDouble, IndentBlocks: true})
+	if err != nil {
+		t.Fatalf("FormatTemplate() error = %v", err)
+	}
+	want := "server:\n{{- if .TLS }}\n  tls: {{ printf \"%v\" .TLS }}\n{{- end }}\n{{- range .Hosts }}\n  {{- if . }}\n  - {{ . | printf \"%s\" }}\n  {{- end }}\n{{- end }}\n  port: 80\n"
+	if formatted != want {
+		t.Errorf("FormatTemplate() = %q, want %q", formatted, want)
+	}
+
+	fixed, _ := parser.FormatTemplate("test.tmpl", template, FormatOptions{FixTrimMarkers: true})
+	before, err := renderer.Render(fixed, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	after, err := renderer.Render(formatted, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if before.Output != after.Output {
+		t.Errorf("formatted output = %q, want %q", after.Output, before.Output)
+	}
+}
+
+func TestFormatTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "nginx"), 0o755)
+	os.WriteFile(filepath.Join(dir, "nginx", "site.conf.tmpl"), []byte("{{.Name}}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "motd.tmpl"), []byte("{{ .Motd }}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("{{.Name}}\n"), 0o644)
+	parser := NewParser(NewFunctionRegistry())
+	opts := FormatOptions{NormalizeSpacing: true}
+
+	changed, err := parser.FormatTemplateDir(dir, opts, false)
+	if err != nil || !reflect.DeepEqual(changed, []string{"nginx/site.conf.tmpl"}) {
+		t.Fatalf("FormatTemplateDir() = %v, %v, want [nginx/site.conf.tmpl]", changed, err)
+	}
+
+	if _, err := parser.FormatTemplateDir(dir, opts, true); err != nil {
+		t.Fatalf("FormatTemplateDir(write) error = %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "nginx", "site.conf.tmpl"))
+	if string(content) != "{{ .Name }}\n" {
+		t.Errorf("written template = %q", content)
+	}
+	if changed, _ := parser.FormatTemplateDir(dir, opts, false); len(changed) != 0 {
+		t.Errorf("FormatTemplateDir() after writing = %v, want none", changed)
+	}
+}
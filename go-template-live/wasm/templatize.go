@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of values Templatize replaces
+const (
+	TemplatizeHint     = "hint"
+	TemplatizeIP       = "ip"
+	TemplatizeHostname = "hostname"
+	TemplatizePort     = "port"
+)
+
+// TemplatizeOptions selects the values Templatize turns into variables
+type TemplatizeOptions struct {
+	// Hints maps literal values of the config to the keys replacing them, e.g.
+	// {"10.0.0.5": "/db/host"}; every occurrence of a hinted value is replaced
+	Hints map[string]string `json:"hints,omitempty"`
+	// Heuristics also replaces IP addresses, hostnames and ports, keyed by the setting and the
+	// sections they appear under
+	Heuristics bool `json:"heuristics,omitempty"`
+	// Prefix is put before the keys derived by the heuristics, e.g. "/myapp"
+	Prefix string `json:"prefix,omitempty"`
+	// KeepDefaults writes the original values as getv defaults, so the template renders the
+	// original config without any values
+	KeepDefaults bool `json:"keepDefaults,omitempty"`
+}
+
+// TemplatizedVariable is a value Templatize replaced with a variable
+type TemplatizedVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Kind  string `json:"kind"`
+	// Line is the first line of the config the value was replaced on
+	Line int `json:"line"`
+}
+
+// TemplatizeResult is the scaffolded template and the variables it reads
+type TemplatizeResult struct {
+	Template  string                `json:"template"`
+	Variables []TemplatizedVariable `json:"variables"`
+}
+
+// templatizeSpan is one value of a line to replace
+type templatizeSpan struct {
+	start, end int
+	key, kind  string
+}
+
+var (
+	ipv4Pattern      = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	hostnamePattern  = regexp.MustCompile(`^(?:[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?\.)+[A-Za-z]{2,63}$`)
+	valueTokenRegexp = regexp.MustCompile(`[A-Za-z0-9._:-]+`)
+	settingPattern   = regexp.MustCompile(`^(\s*)(-\s+)?([A-Za-z_][A-Za-z0-9_.-]*)\s*(:\s|:$|=|\s)\s*(.*)$`)
+)
+
+// fileExtensions end tokens that look like hostnames but name files, such as nginx.conf
+var fileExtensions = map[string]bool{
+	".conf": true, ".cfg": true, ".ini": true, ".log": true, ".pid": true, ".sock": true,
+	".pem": true, ".crt": true, ".key": true, ".json": true, ".yaml": true, ".yml": true,
+	".xml": true, ".html": true, ".htm": true, ".txt": true, ".so": true, ".js": true,
+	".css": true, ".sh": true, ".py": true, ".tmpl": true, ".toml": true, ".d": true,
+}
+
+// Templatize converts a plain config file into a template scaffold: hinted values and, with
+// heuristics, IP addresses, hostnames and ports are replaced with getv calls. Text that would
+// start an action is escaped, so the template renders the config as written apart from the
+// replaced values. Variables are listed in order of first replacement
+func Templatize(content string, opts TemplatizeOptions) (*TemplatizeResult, error) {
+	for value, key := range opts.Hints {
+		if value == "" || !isKeyPath(key) {
+			return nil, fmt.Errorf("invalid hint %q: %q, expected a value and a key path starting with /", value, key)
+		}
+	}
+	if opts.Prefix != "" && !isKeyPath(opts.Prefix) {
+		return nil, fmt.Errorf("invalid key prefix %q, expected a key path starting with /", opts.Prefix)
+	}
+
+	t := &templatizer{
+		opts:   opts,
+		keys:   make(map[string]string),
+		values: make(map[string]string),
+		result: &TemplatizeResult{Variables: []TemplatizedVariable{}},
+	}
+	lines := strings.SplitAfter(content, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		spans := t.hintSpans(body, i+1)
+		if opts.Heuristics {
+			spans = append(spans, t.heuristicSpans(body, i+1, spans)...)
+		}
+		t.updateSections(body)
+		b.WriteString(t.rewrite(body, spans))
+		b.WriteString(line[len(body):])
+	}
+	t.result.Template = b.String()
+	return t.result, nil
+}
+
+// templatizer carries the state of one Templatize call
+type templatizer struct {
+	opts TemplatizeOptions
+	// sections are the enclosing sections of the current line (INI headers, YAML mappings,
+	// brace blocks), with the indentation of YAML mappings
+	sections []templatizeSection
+	// keys maps each replaced value to its key; values maps each key to its value
+	keys   map[string]string
+	values map[string]string
+	result *TemplatizeResult
+}
+
+// templatizeSection is one enclosing section of a config line
+type templatizeSection struct {
+	name   string
+	indent int
+	// brace is set for blocks closed by }, ini for [section] headers
+	brace, ini bool
+}
+
+// hintSpans finds the occurrences of hinted values on a line, longest values first
+func (t *templatizer) hintSpans(line string, lineNumber int) []templatizeSpan {
+	values := make([]string, 0, len(t.opts.Hints))
+	for value := range t.opts.Hints {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+
+	var spans []templatizeSpan
+	for _, value := range values {
+		for offset := 0; ; {
+			k := strings.Index(line[offset:], value)
+			if k < 0 {
+				break
+			}
+			start, end := offset+k, offset+k+len(value)
+			offset = end
+			if !isValueBoundary(line, start-1) || !isValueBoundary(line, end) || overlaps(spans, start, end) {
+				continue
+			}
+			key := t.opts.Hints[value]
+			t.record(key, value, TemplatizeHint, lineNumber)
+			spans = append(spans, templatizeSpan{start: start, end: end, key: key, kind: TemplatizeHint})
+		}
+	}
+	return spans
+}
+
+// isValueBoundary reports whether offset i of line is outside a value token
+func isValueBoundary(line string, i int) bool {
+	if i < 0 || i >= len(line) {
+		return true
+	}
+	c := line[i]
+	return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-')
+}
+
+// overlaps reports whether start:end overlaps one of spans
+func overlaps(spans []templatizeSpan, start, end int) bool {
+	for _, s := range spans {
+		if start < s.end && s.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// heuristicSpans finds the IP addresses, hostnames and ports in the value of a setting line
+func (t *templatizer) heuristicSpans(line string, lineNumber int, taken []templatizeSpan) []templatizeSpan {
+	match := settingPattern.FindStringSubmatchIndex(line)
+	if match == nil || strings.HasPrefix(strings.TrimSpace(line), "#") || strings.HasPrefix(strings.TrimSpace(line), ";") {
+		return nil
+	}
+	setting := line[match[6]:match[7]]
+	valueStart := match[10]
+	portSetting := strings.Contains(strings.ToLower(setting), "port") || setting == "listen"
+
+	var spans []templatizeSpan
+	add := func(start, end int, suffix, kind string) {
+		start, end = valueStart+start, valueStart+end
+		if overlaps(taken, start, end) || overlaps(spans, start, end) {
+			return
+		}
+		value := line[start:end]
+		key, ok := t.keys[value]
+		if !ok {
+			key = t.heuristicKey(setting+suffix, value)
+		}
+		t.record(key, value, kind, lineNumber)
+		spans = append(spans, templatizeSpan{start: start, end: end, key: key, kind: kind})
+	}
+
+	value := line[valueStart:]
+	for _, loc := range valueTokenRegexp.FindAllStringIndex(value, -1) {
+		token := value[loc[0]:loc[1]]
+		host, port := token, ""
+		if i := strings.LastIndexByte(token, ':'); i > 0 && isPort(token[i+1:]) {
+			host, port = token[:i], token[i+1:]
+		}
+		switch {
+		case isIPv4(host):
+			add(loc[0], loc[0]+len(host), "", TemplatizeIP)
+		case isHostname(host, setting):
+			add(loc[0], loc[0]+len(host), "", TemplatizeHostname)
+		case port == "" && portSetting && isPort(host):
+			add(loc[0], loc[1], "", TemplatizePort)
+			continue
+		default:
+			continue
+		}
+		if port != "" {
+			add(loc[1]-len(port), loc[1], "_port", TemplatizePort)
+		}
+	}
+	return spans
+}
+
+// isIPv4 reports whether s is a dotted IPv4 address
+func isIPv4(s string) bool {
+	return ipv4Pattern.MatchString(s) && ipv4Pattern.FindString(s) == s && net.ParseIP(s) != nil
+}
+
+// isHostname reports whether s looks like a host name rather than a file name or version
+func isHostname(s, setting string) bool {
+	if s == "localhost" {
+		return true
+	}
+	if !hostnamePattern.MatchString(s) || fileExtensions[strings.ToLower(path.Ext(s))] {
+		return false
+	}
+	lower := strings.ToLower(setting)
+	return !strings.Contains(lower, "file") && !strings.Contains(lower, "path") && !strings.Contains(lower, "dir")
+}
+
+// isPort reports whether s is a port number
+func isPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535 && strconv.Itoa(n) == s
+}
+
+// heuristicKey derives the key of a detected value from its setting and enclosing sections,
+// numbering keys already holding another value
+func (t *templatizer) heuristicKey(setting, value string) string {
+	segments := make([]string, 0, len(t.sections)+1)
+	for _, section := range t.sections {
+		segments = append(segments, section.name)
+	}
+	segments = append(segments, keySegment(setting))
+	base := strings.TrimSuffix(t.opts.Prefix, "/") + "/" + strings.Join(segments, "/")
+	key := base
+	for n := 2; ; n++ {
+		if existing, ok := t.values[key]; !ok || existing == value {
+			return key
+		}
+		key = base + "_" + strconv.Itoa(n)
+	}
+}
+
+// keySegment turns a setting or section name into a snake_case key segment
+func keySegment(name string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+			b.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// record adds a replaced value to the variable list the first time its key is used
+func (t *templatizer) record(key, value, kind string, line int) {
+	t.keys[value] = key
+	if _, ok := t.values[key]; ok {
+		return
+	}
+	t.values[key] = value
+	t.result.Variables = append(t.result.Variables, TemplatizedVariable{Key: key, Value: value, Kind: kind, Line: line})
+}
+
+// updateSections tracks the sections enclosing the following lines: INI [section] headers,
+// YAML keys without a value and blocks opened with {
+func (t *templatizer) updateSections(line string) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+		return
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+	switch {
+	case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+		t.sections = []templatizeSection{{name: keySegment(trimmed[1 : len(trimmed)-1]), ini: true}}
+		return
+	case trimmed == "}" || strings.HasPrefix(trimmed, "}"):
+		for i := len(t.sections) - 1; i >= 0; i-- {
+			if t.sections[i].brace {
+				t.sections = t.sections[:i]
+				break
+			}
+		}
+		return
+	case strings.HasSuffix(trimmed, "{"):
+		t.sections = append(t.sections, templatizeSection{name: keySegment(strings.TrimSuffix(trimmed, "{")), brace: true})
+		return
+	}
+
+	// YAML: leaving the mappings indented at least as deep as this line
+	for len(t.sections) > 0 {
+		last := t.sections[len(t.sections)-1]
+		if last.brace || last.ini || last.indent < indent {
+			break
+		}
+		t.sections = t.sections[:len(t.sections)-1]
+	}
+	if strings.HasSuffix(trimmed, ":") && !strings.HasPrefix(trimmed, "-") {
+		t.sections = append(t.sections, templatizeSection{name: keySegment(strings.TrimSuffix(trimmed, ":")), indent: indent})
+	}
+}
+
+// rewrite replaces the spans of a line with getv calls and escapes {{ in the rest
+func (t *templatizer) rewrite(line string, spans []templatizeSpan) string {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		b.WriteString(escapeActions(line[last:s.start]))
+		if t.opts.KeepDefaults {
+			fmt.Fprintf(&b, "{{getv %q %q}}", s.key, line[s.start:s.end])
+		} else {
+			fmt.Fprintf(&b, "{{getv %q}}", s.key)
+		}
+		last = s.end
+	}
+	b.WriteString(escapeActions(line[last:]))
+	return b.String()
+}
+
+// escapeActions writes text so a template renders it as written
+func escapeActions(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
@@ -0,0 +1,102 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplatize_Heuristics(t *testing.T) {
+	config := `[database]
+host = 10.0.0.5
+port = 5432
+name = nginx.conf
+`
+	result, err := Templatize(config, TemplatizeOptions{Heuristics: true, Prefix: "/myapp"})
+	if err != nil {
+		t.Fatalf("Templatize() error = %v", err)
+	}
+	want := `[database]
+host = {{getv "/myapp/database/host"}}
+port = {{getv "/myapp/database/port"}}
+name = nginx.conf
+`
+	if result.Template != want {
+		t.Errorf("Template =\n%s\nwant\n%s", result.Template, want)
+	}
+
+	config = `server:
+  listen: 8080
+  upstream: api.example.com:9000
+  replica: 10.0.0.6
+log_file: app.example.log
+version: 1.2.3
+`
+	result, err = Templatize(config, TemplatizeOptions{Heuristics: true, Prefix: "/myapp"})
+	if err != nil {
+		t.Fatalf("Templatize() error = %v", err)
+	}
+	want = `server:
+  listen: {{getv "/myapp/server/listen"}}
+  upstream: {{getv "/myapp/server/upstream"}}:{{getv "/myapp/server/upstream_port"}}
+  replica: {{getv "/myapp/server/replica"}}
+log_file: app.example.log
+version: 1.2.3
+`
+	if result.Template != want {
+		t.Errorf("Template =\n%s\nwant\n%s", result.Template, want)
+	}
+
+	var got []string
+	for _, v := range result.Variables {
+		got = append(got, v.Key+"="+v.Value+"/"+v.Kind)
+	}
+	wantVariables := []string{
+		"/myapp/server/listen=8080/port",
+		"/myapp/server/upstream=api.example.com/hostname",
+		"/myapp/server/upstream_port=9000/port",
+		"/myapp/server/replica=10.0.0.6/ip",
+	}
+	if !reflect.DeepEqual(got, wantVariables) {
+		t.Errorf("Variables = %v, want %v", got, wantVariables)
+	}
+}
+
+func TestTemplatize_HintsAndEscaping(t *testing.T) {
+	config := `upstream backend {
+    server 10.0.0.1:80;
+    server 10.0.0.2:80;
+}
+# password s3cr3t, not s3cr3t2
+auth {{ s3cr3t }};
+`
+	result, err := Templatize(config, TemplatizeOptions{
+		Hints:        map[string]string{"s3cr3t": "/auth/password"},
+		Heuristics:   true,
+		KeepDefaults: true,
+	})
+	if err != nil {
+		t.Fatalf("Templatize() error = %v", err)
+	}
+	want := `upstream backend {
+    server {{getv "/upstream_backend/server" "10.0.0.1"}}:{{getv "/upstream_backend/server_port" "80"}};
+    server {{getv "/upstream_backend/server_2" "10.0.0.2"}}:{{getv "/upstream_backend/server_port" "80"}};
+}
+# password {{getv "/auth/password" "s3cr3t"}}, not s3cr3t2
+auth {{"{{"}} {{getv "/auth/password" "s3cr3t"}} }};
+`
+	if result.Template != want {
+		t.Errorf("Template =\n%s\nwant\n%s", result.Template, want)
+	}
+	if len(result.Variables) != 4 || result.Variables[3].Key != "/auth/password" || result.Variables[3].Line != 5 {
+		t.Errorf("Variables = %+v, want the hinted password last, found on line 5", result.Variables)
+	}
+}
+
+func TestTemplatize_InvalidHint(t *testing.T) {
+	if _, err := Templatize("a = b", TemplatizeOptions{Hints: map[string]string{"b": "key"}}); err == nil {
+		t.Error("Templatize() accepted a hint key without a leading /")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Span names emitted by the engine
+const (
+	SpanExtract = "template.extract"
+	SpanRender  = "template.render"
+	SpanParse   = "template.parse"
+	SpanWalk    = "template.walk"
+)
+
+// Span attribute keys
+const (
+	AttrTemplateName  = "template.name"
+	AttrTemplateHash  = "template.hash"
+	AttrTemplateSize  = "template.size"
+	AttrVariableCount = "template.variable_count"
+	AttrOutputSize    = "template.output_size"
+	AttrProfile       = "template.profile"
+)
+
+// Tracer starts spans for engine operations; it mirrors the OpenTelemetry tracer API so an
+// adapter (see tracing_otel.go) can forward spans to any OTel exporter
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// noopTracer discards all spans
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) RecordError(err error)                      {}
+func (noopSpan) End()                                       {}
+
+// engineTracer receives the engine spans; it is set once at startup, before any extraction or render
+var engineTracer Tracer = noopTracer{}
+
+// SetTracer installs the tracer used by the engine; nil disables tracing
+func SetTracer(tracer Tracer) {
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	engineTracer = tracer
+}
+
+// tracingEnabled reports whether a tracer is installed, so costly attributes can be skipped
+func tracingEnabled() bool {
+	_, noop := engineTracer.(noopTracer)
+	return !noop
+}
+
+// startTemplateSpan starts a span carrying the attributes that identify a template
+func startTemplateSpan(ctx context.Context, name, fileName, content string) (context.Context, Span) {
+	ctx, span := startSpan(ctx, name)
+	if tracingEnabled() {
+		if fileName != "" {
+			span.SetAttribute(AttrTemplateName, fileName)
+		}
+		span.SetAttribute(AttrTemplateHash, templateHash(content))
+		span.SetAttribute(AttrTemplateSize, len(content))
+	}
+	return ctx, span
+}
+
+// startSpan starts an engine span
+func startSpan(ctx context.Context, name string) (context.Context, Span) {
+	return engineTracer.Start(ctx, name)
+}
+
+// endSpan records err, if any, and ends the span
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// templateHash identifies template content in traces without recording the content itself
+func templateHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,62 @@
+//go:build otel
+// +build otel
+
+// This file adapts the engine Tracer to OpenTelemetry
+// Tag: otel (keeps the OpenTelemetry dependency out of default builds)
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelTracer forwards engine spans to an OpenTelemetry tracer
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+// NewOTelTracer wraps an OpenTelemetry tracer, e.g. otel.Tracer("go-template-live"), for SetTracer
+func NewOTelTracer(tracer trace.Tracer) Tracer {
+	return otelTracer{tracer: tracer}
+}
+
+func (t otelTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, otelSpan{span: span}
+}
+
+// otelSpan forwards attributes and errors to an OpenTelemetry span
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	case float64:
+		s.span.SetAttributes(attribute.Float64(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+func (s otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s otelSpan) End() {
+	s.span.End()
+}
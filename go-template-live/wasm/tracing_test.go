@@ -0,0 +1,87 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// recordedSpan is a span captured by recordingTracer
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+// recordingTracer keeps every started span in order
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+type spanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordedSpan{name: name, attrs: make(map[string]interface{})}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordedSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *recordedSpan) RecordError(err error)                      { s.err = err }
+func (s *recordedSpan) End()                                       { s.ended = true }
+
+// TestTracing_Spans tests the spans and attributes emitted by extraction and rendering
+func TestTracing_Spans(t *testing.T) {
+	tracer := &recordingTracer{}
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	registry := NewFunctionRegistry()
+	template := "{{.Name}} {{.Port}}"
+	if _, err := NewParser(registry).ExtractVariablesWithDefaults("app.tmpl", template); err != nil {
+		t.Fatalf("ExtractVariablesWithDefaults() error = %v", err)
+	}
+	if _, err := NewRenderer(registry, nil).Render("{{.Name", nil, RenderOptions{}); err == nil {
+		t.Fatal("Render() expected parse error")
+	}
+
+	var names [][2]string
+	for _, span := range tracer.spans {
+		names = append(names, [2]string{span.name, span.parent})
+		if !span.ended {
+			t.Errorf("span %s was not ended", span.name)
+		}
+	}
+	expected := [][2]string{
+		{SpanExtract, ""}, {SpanParse, SpanExtract}, {SpanWalk, SpanExtract},
+		{SpanRender, ""}, {SpanParse, SpanRender},
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("spans = %v, want %v", names, expected)
+	}
+
+	extract := tracer.spans[0]
+	wantAttrs := map[string]interface{}{
+		AttrTemplateName:  "app.tmpl",
+		AttrTemplateHash:  templateHash(template),
+		AttrTemplateSize:  len(template),
+		AttrVariableCount: 2,
+	}
+	if !reflect.DeepEqual(extract.attrs, wantAttrs) {
+		t.Errorf("extract attributes = %v, want %v", extract.attrs, wantAttrs)
+	}
+	if render := tracer.spans[3]; render.err == nil || tracer.spans[4].err == nil {
+		t.Errorf("render spans did not record the parse error")
+	}
+	if extract.err != nil {
+		t.Errorf("extract span recorded error %v", extract.err)
+	}
+}
@@ -0,0 +1,128 @@
+//go:build !js
+// +build !js
+
+// This file contains change tracking across repeated batch renders for daemon mode
+// Tag: !js (the browser playground renders on demand and writes no destinations)
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Resource statuses set by RenderTracker.Track
+const (
+	// ResourceUpdated means the output differs from the previous cycle, or was rendered for the first time
+	ResourceUpdated = "updated"
+	// ResourceUnchanged means the output is identical to the previous cycle, so writing it can be skipped
+	ResourceUnchanged = "unchanged"
+	// ResourceFailed means the resource did not render; the previous output is kept
+	ResourceFailed = "failed"
+)
+
+// CycleSummary reports what one render cycle changed, by resource name
+type CycleSummary struct {
+	Cycle     int      `json:"cycle"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+	Failed    []string `json:"failed"`
+}
+
+// String formats the summary for daemon logs, e.g.
+// "cycle 3: 1 updated, 2 unchanged (skipped), 0 failed; updated: nginx.conf.tmpl"
+func (s CycleSummary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cycle %d: %d updated, %d unchanged (skipped), %d failed", s.Cycle, len(s.Updated), len(s.Unchanged), len(s.Failed))
+	if len(s.Updated) > 0 {
+		b.WriteString("; updated: " + strings.Join(s.Updated, ", "))
+	}
+	if len(s.Failed) > 0 {
+		b.WriteString("; failed: " + strings.Join(s.Failed, ", "))
+	}
+	return b.String()
+}
+
+// TrackerMetrics are counters accumulated over all cycles of a RenderTracker
+type TrackerMetrics struct {
+	Cycles    int `json:"cycles"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+}
+
+// RenderTracker remembers the hash of the last output rendered for each destination, so
+// repeated renders of the same resources (daemon mode) can tell changed configs from
+// unchanged ones and skip rewriting the latter
+// Resources are keyed by Dest, or by Name when they have no destination
+type RenderTracker struct {
+	mu      sync.Mutex
+	hashes  map[string]string
+	metrics TrackerMetrics
+}
+
+// NewRenderTracker creates a tracker with no previous outputs
+func NewRenderTracker() *RenderTracker {
+	return &RenderTracker{hashes: make(map[string]string)}
+}
+
+// Track classifies the results of one cycle against the previous ones, setting their Status
+// and Hash, and returns the cycle summary; results keep their order
+func (t *RenderTracker) Track(results []ResourceResult) CycleSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.metrics.Cycles++
+	summary := CycleSummary{Cycle: t.metrics.Cycles, Updated: []string{}, Unchanged: []string{}, Failed: []string{}}
+	for i := range results {
+		result := &results[i]
+		if result.Error != "" {
+			result.Status = ResourceFailed
+			summary.Failed = append(summary.Failed, result.Name)
+			continue
+		}
+
+		key := result.Dest
+		if key == "" {
+			key = result.Name
+		}
+		result.Hash = outputHash(result.Output)
+		if previous, ok := t.hashes[key]; ok && previous == result.Hash {
+			result.Status = ResourceUnchanged
+			summary.Unchanged = append(summary.Unchanged, result.Name)
+			continue
+		}
+		t.hashes[key] = result.Hash
+		result.Status = ResourceUpdated
+		summary.Updated = append(summary.Updated, result.Name)
+	}
+
+	t.metrics.Updated += len(summary.Updated)
+	t.metrics.Unchanged += len(summary.Unchanged)
+	t.metrics.Failed += len(summary.Failed)
+	return summary
+}
+
+// Forget drops the remembered output of a destination, so its next render counts as updated,
+// e.g. after the file was removed or edited outside the daemon
+func (t *RenderTracker) Forget(dest string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.hashes, dest)
+}
+
+// Metrics returns the counters accumulated so far
+func (t *RenderTracker) Metrics() TrackerMetrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.metrics
+}
+
+// outputHash returns the hex SHA-256 of rendered output
+func outputHash(output string) string {
+	sum := sha256.Sum256([]byte(output))
+	return hex.EncodeToString(sum[:])
+}
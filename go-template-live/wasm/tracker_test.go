@@ -0,0 +1,60 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRenderTracker tests updated/unchanged/failed classification across daemon cycles
+func TestRenderTracker(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	tracker := NewRenderTracker()
+	resources := []TemplateResource{
+		{Name: "app.conf.tmpl", Content: "port={{.Port}}", Dest: "/etc/app.conf"},
+		{Name: "motd.tmpl", Content: "welcome"},
+		{Name: "broken.tmpl", Content: "{{.Port.Missing}}", Dest: "/etc/broken"},
+	}
+	cycle := func(port int) ([]ResourceResult, CycleSummary) {
+		results, _ := renderer.RenderBatch(resources, map[string]interface{}{"Port": port}, RenderOptions{}, 2)
+		return results, tracker.Track(results)
+	}
+
+	results, summary := cycle(80)
+	expected := CycleSummary{Cycle: 1, Updated: []string{"app.conf.tmpl", "motd.tmpl"}, Unchanged: []string{}, Failed: []string{"broken.tmpl"}}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("Track() cycle 1 = %+v, want %+v", summary, expected)
+	}
+	if results[0].Status != ResourceUpdated || results[2].Status != ResourceFailed || len(results[0].Hash) != 64 || results[2].Hash != "" {
+		t.Errorf("Track() cycle 1 statuses = %+v", results)
+	}
+
+	results, summary = cycle(80)
+	expected = CycleSummary{Cycle: 2, Updated: []string{}, Unchanged: []string{"app.conf.tmpl", "motd.tmpl"}, Failed: []string{"broken.tmpl"}}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("Track() cycle 2 = %+v, want %+v", summary, expected)
+	}
+	if results[0].Status != ResourceUnchanged {
+		t.Errorf("Track() cycle 2 status = %q, want %q", results[0].Status, ResourceUnchanged)
+	}
+
+	_, summary = cycle(8080)
+	if expected := []string{"app.conf.tmpl"}; !reflect.DeepEqual(summary.Updated, expected) {
+		t.Errorf("Track() cycle 3 updated = %v, want %v", summary.Updated, expected)
+	}
+	if expected := "cycle 3: 1 updated, 1 unchanged (skipped), 1 failed; updated: app.conf.tmpl; failed: broken.tmpl"; summary.String() != expected {
+		t.Errorf("CycleSummary.String() = %q, want %q", summary.String(), expected)
+	}
+
+	tracker.Forget("motd.tmpl")
+	_, summary = cycle(8080)
+	if expected := []string{"motd.tmpl"}; !reflect.DeepEqual(summary.Updated, expected) {
+		t.Errorf("Track() after Forget updated = %v, want %v", summary.Updated, expected)
+	}
+
+	if metrics, expected := tracker.Metrics(), (TrackerMetrics{Cycles: 4, Updated: 4, Unchanged: 4, Failed: 4}); metrics != expected {
+		t.Errorf("Metrics() = %+v, want %+v", metrics, expected)
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TrimMarkerIssue is a control action alone on its line without a trim marker, which leaves
+// a stray blank line (and the line's indentation) in the output
+type TrimMarkerIssue struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	// Action is the source of the first action on the line
+	Action string `json:"action"`
+	// Fix is the marker to add: "{{-" on this action, or "-}}" on the last action of the line
+	// when it is the first line of the template
+	Fix string `json:"fix"`
+}
+
+// FormatOptions selects the rewrites FormatTemplate applies
+type FormatOptions struct {
+	// FixTrimMarkers adds the trim markers reported by AnalyzeTrimMarkers
+	FixTrimMarkers bool `json:"fixTrimMarkers,omitempty"`
+	// NormalizeSpacing writes actions as {{ .X | f "a" }}: one space inside the delimiters and
+	// trim markers, between words and around pipes
+	NormalizeSpacing bool `json:"normalizeSpacing,omitempty"`
+	// QuoteStyle is "double" or "raw", rewriting string literals that need no escaping in it
+	QuoteStyle string `json:"quoteStyle,omitempty"`
+	// IndentBlocks indents if, range, with, define and block bodies by two spaces per level, on
+	// the lines whose indentation a trim marker removes, so the output does not change
+	IndentBlocks bool `json:"indentBlocks,omitempty"`
+}
+
+// templateAction is the source span of one {{ }} action
+type templateAction struct {
+	start, end int
+	leftTrim   bool
+	rightTrim  bool
+	// silent is set for actions that write nothing: control keywords, comments and
+	// variable declarations
+	silent bool
+}
+
+// silentKeywords start actions that write nothing themselves
+var silentKeywords = map[string]bool{
+	"if": true, "else": true, "end": true, "range": true, "with": true,
+	"define": true, "break": true, "continue": true,
+}
+
+// AnalyzeTrimMarkers reports the lines holding only silent actions ({{if}}, {{end}},
+// {{$x := ...}}, comments and the like) that have no trim marker, so each leaves a blank line
+// in the output; these are the usual source of stray blank lines in YAML templates
+// A line following a blank line is not reported, as the blank line may be intended and
+// {{- would remove it
+func (p *Parser) AnalyzeTrimMarkers(fileName, fileContent string) ([]TrimMarkerIssue, error) {
+	if _, err := p.parseTemplate(context.Background(), fileName, fileContent); err != nil {
+		return nil, err
+	}
+
+	issues := []TrimMarkerIssue{}
+	index := newLineIndex(fileContent)
+	for _, fix := range trimMarkerFixes(fileContent) {
+		pos := &Position{Offset: fix.action.start}
+		index.resolve(fileContent, pos)
+		issues = append(issues, TrimMarkerIssue{
+			Line:   pos.Line,
+			Column: pos.Column,
+			Action: fileContent[fix.action.start:fix.action.end],
+			Fix:    fix.marker,
+		})
+	}
+	return issues, nil
+}
+
+// FormatTemplate rewrites a template according to opts; the result must still parse
+func (p *Parser) FormatTemplate(fileName, fileContent string, opts FormatOptions) (string, error) {
+	if _, err := p.parseTemplate(context.Background(), fileName, fileContent); err != nil {
+		return "", err
+	}
+	switch opts.QuoteStyle {
+	case "", QuoteStyleDouble, QuoteStyleRaw:
+	default:
+		return "", fmt.Errorf("unknown quote style %q, expected double or raw", opts.QuoteStyle)
+	}
+	formatted := fileContent
+	if opts.FixTrimMarkers {
+		formatted = applyTrimMarkerFixes(fileContent, trimMarkerFixes(fileContent))
+	}
+	if opts.NormalizeSpacing || opts.QuoteStyle != "" || opts.IndentBlocks {
+		formatted = formatActions(formatted, opts)
+	}
+	if _, err := p.parseTemplate(context.Background(), fileName, formatted); err != nil {
+		return "", fmt.Errorf("formatting produced an invalid template: %v", err)
+	}
+	return formatted, nil
+}
+
+// trimMarkerFix is a trim marker to add to an action
+type trimMarkerFix struct {
+	// action is the first action of the line, which the issue is reported at
+	action templateAction
+	// target is the action the marker goes on
+	target templateAction
+	marker string
+}
+
+// trimMarkerFixes finds the lines of content made only of silent actions without trim markers
+func trimMarkerFixes(content string) []trimMarkerFix {
+	var fixes []trimMarkerFix
+	actions := scanActions(content)
+	for i := 0; i < len(actions); {
+		// Group the actions sharing a line, separated only by spaces and tabs
+		j := i + 1
+		for j < len(actions) && strings.Trim(content[actions[j-1].end:actions[j].start], " \t") == "" {
+			j++
+		}
+		line := actions[i:j]
+		i = j
+
+		first, last := line[0], line[len(line)-1]
+		lineStart := strings.LastIndexByte(content[:first.start], '\n') + 1
+		lineEnd := len(content)
+		if k := strings.IndexByte(content[last.end:], '\n'); k >= 0 {
+			lineEnd = last.end + k
+		}
+		if strings.Trim(content[lineStart:first.start], " \t") != "" || strings.Trim(content[last.end:lineEnd], " \t\r") != "" {
+			continue
+		}
+		if lineEnd == len(content) || first.leftTrim || last.rightTrim || !allSilent(line) {
+			continue
+		}
+
+		if lineStart == 0 {
+			// -}} also trims the indentation of the next line, which must have none
+			if next := lineEnd + 1; next < len(content) && (content[next] == ' ' || content[next] == '\t') {
+				continue
+			}
+			fixes = append(fixes, trimMarkerFix{action: first, target: last, marker: "-}}"})
+			continue
+		}
+		previousStart := strings.LastIndexByte(content[:lineStart-1], '\n') + 1
+		if strings.TrimSpace(content[previousStart:lineStart-1]) == "" {
+			continue
+		}
+		fixes = append(fixes, trimMarkerFix{action: first, target: first, marker: "{{-"})
+	}
+	return fixes
+}
+
+func allSilent(actions []templateAction) bool {
+	for _, a := range actions {
+		if !a.silent {
+			return false
+		}
+	}
+	return true
+}
+
+// applyTrimMarkerFixes inserts the markers of fixes into content
+func applyTrimMarkerFixes(content string, fixes []trimMarkerFix) string {
+	var b strings.Builder
+	last := 0
+	for _, fix := range fixes {
+		if fix.marker == "{{-" {
+			insert := fix.target.start + 2
+			b.WriteString(content[last:insert])
+			if isTemplateSpace(content[insert]) {
+				b.WriteString("-")
+			} else {
+				b.WriteString("- ")
+			}
+			last = insert
+			continue
+		}
+		insert := fix.target.end - 2
+		b.WriteString(content[last:insert])
+		if isTemplateSpace(content[insert-1]) {
+			b.WriteString("-")
+		} else {
+			b.WriteString(" -")
+		}
+		last = insert
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// isTemplateSpace reports whether c is whitespace to text/template's lexer
+func isTemplateSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// scanActions returns the {{ }} actions of a template that parses, skipping the contents of
+// comments and quoted strings, which may contain }}
+func scanActions(content string) []templateAction {
+	var actions []templateAction
+	for i := 0; ; {
+		k := strings.Index(content[i:], "{{")
+		if k < 0 {
+			return actions
+		}
+		a := templateAction{start: i + k}
+		j := a.start + 2
+		if strings.HasPrefix(content[j:], "-") && j+1 < len(content) && isTemplateSpace(content[j+1]) {
+			a.leftTrim = true
+			j++
+		}
+		for j < len(content) && isTemplateSpace(content[j]) {
+			j++
+		}
+		body := j
+		if strings.HasPrefix(content[j:], "/*") {
+			a.silent = true
+			if end := strings.Index(content[j+2:], "*/"); end >= 0 {
+				j += 2 + end + 2
+			}
+		}
+		j = scanActionEnd(content, j)
+		if j < 0 {
+			return actions
+		}
+		a.end = j + 2
+		a.rightTrim = j >= 2 && content[j-1] == '-' && isTemplateSpace(content[j-2])
+		if !a.silent {
+			a.silent = isSilentAction(content[body:j])
+		}
+		actions = append(actions, a)
+		i = a.end
+	}
+}
+
+// scanActionEnd returns the offset of the }} closing the action whose body starts at i
+func scanActionEnd(content string, i int) int {
+	for i < len(content) {
+		switch c := content[i]; c {
+		case '"', '\'':
+			i++
+			for i < len(content) && content[i] != c {
+				if content[i] == '\\' {
+					i++
+				}
+				i++
+			}
+		case '`':
+			if end := strings.IndexByte(content[i+1:], '`'); end >= 0 {
+				i += 1 + end
+			}
+		case '}':
+			if strings.HasPrefix(content[i:], "}}") {
+				return i
+			}
+		}
+		i++
+	}
+	return -1
+}
+
+// isSilentAction reports whether an action body writes nothing: a control keyword or a
+// variable declaration or assignment
+func isSilentAction(body string) bool {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(body), "-"))
+	if len(fields) == 0 {
+		return false
+	}
+	if silentKeywords[fields[0]] {
+		return true
+	}
+	if !strings.HasPrefix(fields[0], "$") {
+		return false
+	}
+	if strings.Contains(fields[0], ":=") || strings.HasSuffix(fields[0], "=") {
+		return true
+	}
+	return len(fields) > 1 && (fields[1] == ":=" || fields[1] == "=" || strings.HasPrefix(fields[1], ":=") || strings.HasPrefix(fields[1], "=") || strings.HasPrefix(fields[1], ","))
+}
@@ -0,0 +1,79 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestAnalyzeTrimMarkers tests which lines of silent actions are reported and how they are fixed
+func TestAnalyzeTrimMarkers(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{/* config */}}
+server:
+  {{- if .TLS}}
+  tls: true
+  {{end}}
+  {{$port := .Port}}
+  port: {{$port}}
+  {{range .Hosts}}{{if .}}
+  - {{.}}
+  {{end}}{{end}}
+
+  {{if .Debug}}
+  debug: {{"}}"}}
+  {{end -}}
+`
+	issues, err := parser.AnalyzeTrimMarkers("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("AnalyzeTrimMarkers() error = %v", err)
+	}
+	var got []TrimMarkerIssue
+	for _, issue := range issues {
+		got = append(got, TrimMarkerIssue{Line: issue.Line, Action: issue.Action, Fix: issue.Fix})
+	}
+	expected := []TrimMarkerIssue{
+		{Line: 1, Action: "{{/* config */}}", Fix: "-}}"},
+		{Line: 5, Action: "{{end}}", Fix: "{{-"},
+		{Line: 6, Action: "{{$port := .Port}}", Fix: "{{-"},
+		{Line: 8, Action: "{{range .Hosts}}", Fix: "{{-"},
+		{Line: 10, Action: "{{end}}", Fix: "{{-"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("AnalyzeTrimMarkers() = %+v, want %+v", got, expected)
+	}
+}
+
+// TestFormatTemplate_FixTrimMarkers tests that fixed templates render without stray blank lines
+func TestFormatTemplate_FixTrimMarkers(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	template := "server:\n  {{if .TLS}}\n  tls: true\n  {{end}}\n  {{range .Hosts}}\n  - {{.}}\n  {{end}}\n  port: 80\n"
+
+	formatted, err := parser.FormatTemplate("test.tmpl", template, FormatOptions{FixTrimMarkers: true})
+	if err != nil {
+		t.Fatalf("FormatTemplate() error = %v", err)
+	}
+	if expected := "server:\n  {{- if .TLS}}\n  tls: true\n  {{- end}}\n  {{- range .Hosts}}\n  - {{.}}\n  {{- end}}\n  port: 80\n"; formatted != expected {
+		t.Errorf("FormatTemplate() = %q, want %q", formatted, expected)
+	}
+
+	values := map[string]interface{}{"TLS": true, "Hosts": []interface{}{"a", "b"}}
+	result, err := renderer.Render(formatted, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "server:\n  tls: true\n  - a\n  - b\n  port: 80\n"; result.Output != expected {
+		t.Errorf("Render() = %q, want %q", result.Output, expected)
+	}
+
+	unchanged, err := parser.FormatTemplate("test.tmpl", template, FormatOptions{})
+	if err != nil || unchanged != template {
+		t.Errorf("FormatTemplate() without options = %q, %v, want the template unchanged", unchanged, err)
+	}
+	if _, err := parser.FormatTemplate("test.tmpl", "{{if}}", FormatOptions{FixTrimMarkers: true}); err == nil {
+		t.Errorf("FormatTemplate() of an invalid template error = nil, want error")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Tutorial lessons are embedded JSON files, ordered by file name
+//
+//go:embed lessons
+var lessonsFS embed.FS
+
+const lessonsRoot = "lessons"
+
+// Lesson is a single tutorial exercise
+type Lesson struct {
+	ID                string                 `json:"id"`
+	Title             string                 `json:"title"`
+	Instructions      string                 `json:"instructions"`
+	Profiles          []string               `json:"profiles"`
+	StarterTemplate   string                 `json:"starterTemplate"`
+	Values            map[string]interface{} `json:"values"`
+	ExpectedVariables []string               `json:"expectedVariables"`
+	ExpectedOutput    string                 `json:"expectedOutput"`
+	Hints             []string               `json:"hints,omitempty"`
+}
+
+// LessonInfo is the summary of a lesson used for listings
+type LessonInfo struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// ExerciseCheck is the outcome of one validation step of an exercise
+type ExerciseCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// ExerciseResult is the outcome of checking a user's template against a lesson
+type ExerciseResult struct {
+	LessonID string          `json:"lessonId"`
+	Passed   bool            `json:"passed"`
+	Checks   []ExerciseCheck `json:"checks"`
+	Output   string          `json:"output,omitempty"`
+	Hints    []string        `json:"hints,omitempty"`
+}
+
+// TutorialEngine validates exercises using the active parser and renderer
+type TutorialEngine struct {
+	parser   *Parser
+	renderer *Renderer
+	lessons  []*Lesson
+}
+
+// NewTutorialEngine creates a tutorial engine with the embedded lessons
+func NewTutorialEngine(parser *Parser, renderer *Renderer) (*TutorialEngine, error) {
+	lessons, err := loadLessons()
+	if err != nil {
+		return nil, err
+	}
+	return &TutorialEngine{
+		parser:   parser,
+		renderer: renderer,
+		lessons:  lessons,
+	}, nil
+}
+
+// loadLessons reads all embedded lessons in file name order
+func loadLessons() ([]*Lesson, error) {
+	entries, err := fs.ReadDir(lessonsFS, lessonsRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error reading lessons: %v", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var lessons []*Lesson
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := fs.ReadFile(lessonsFS, path.Join(lessonsRoot, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading lesson %s: %v", entry.Name(), err)
+		}
+		lesson := &Lesson{}
+		if err := json.Unmarshal(data, lesson); err != nil {
+			return nil, fmt.Errorf("error parsing lesson %s: %v", entry.Name(), err)
+		}
+		lessons = append(lessons, lesson)
+	}
+	return lessons, nil
+}
+
+// supportsProfile reports whether the lesson can be solved with the given function profile
+func (l *Lesson) supportsProfile(profile string) bool {
+	for _, p := range l.Profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// ListLessons returns the lessons available for the engine's function profile
+func (e *TutorialEngine) ListLessons() []LessonInfo {
+	profile := e.parser.registry.Profile()
+	result := []LessonInfo{}
+	for _, lesson := range e.lessons {
+		if lesson.supportsProfile(profile) {
+			result = append(result, LessonInfo{ID: lesson.ID, Title: lesson.Title})
+		}
+	}
+	return result
+}
+
+// GetLesson returns a lesson by id if it is available for the active profile
+func (e *TutorialEngine) GetLesson(id string) (*Lesson, error) {
+	for _, lesson := range e.lessons {
+		if lesson.ID == id {
+			if !lesson.supportsProfile(e.parser.registry.Profile()) {
+				return nil, fmt.Errorf("lesson %s is not available in the %s profile", id, e.parser.registry.Profile())
+			}
+			return lesson, nil
+		}
+	}
+	return nil, fmt.Errorf("lesson %s not found", id)
+}
+
+// Check validates a template against a lesson's expected variables and output
+// Checks run in order (syntax, variables, output) and later checks are skipped on syntax errors
+func (e *TutorialEngine) Check(id, templateContent string) (*ExerciseResult, error) {
+	lesson, err := e.GetLesson(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExerciseResult{LessonID: id}
+
+	variables, err := e.parser.ExtractVariables(lesson.ID, templateContent)
+	if err != nil {
+		result.Checks = append(result.Checks, ExerciseCheck{Name: "syntax", Message: err.Error()})
+		result.Hints = lesson.Hints
+		return result, nil
+	}
+	result.Checks = append(result.Checks, ExerciseCheck{Name: "syntax", Passed: true})
+	result.Checks = append(result.Checks, checkExpectedVariables(lesson.ExpectedVariables, variables))
+
+	rendered, err := e.renderer.Render(templateContent, lesson.Values, RenderOptions{})
+	switch {
+	case err != nil:
+		result.Checks = append(result.Checks, ExerciseCheck{Name: "output", Message: err.Error()})
+	case rendered.Output != lesson.ExpectedOutput:
+		result.Output = rendered.Output
+		result.Checks = append(result.Checks, ExerciseCheck{
+			Name:    "output",
+			Message: fmt.Sprintf("expected output %q, got %q", lesson.ExpectedOutput, rendered.Output),
+		})
+	default:
+		result.Output = rendered.Output
+		result.Checks = append(result.Checks, ExerciseCheck{Name: "output", Passed: true})
+	}
+
+	result.Passed = true
+	for _, check := range result.Checks {
+		if !check.Passed {
+			result.Passed = false
+		}
+	}
+	if !result.Passed {
+		result.Hints = lesson.Hints
+	}
+	return result, nil
+}
+
+// checkExpectedVariables compares the extracted variable set with the expected one
+func checkExpectedVariables(expected, extracted []string) ExerciseCheck {
+	found := make(map[string]bool, len(extracted))
+	for _, name := range extracted {
+		found[name] = true
+	}
+	wanted := make(map[string]bool, len(expected))
+	var missing, unexpected []string
+	for _, name := range expected {
+		wanted[name] = true
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	for name := range found {
+		if !wanted[name] {
+			unexpected = append(unexpected, name)
+		}
+	}
+	sort.Strings(unexpected)
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing variables: "+strings.Join(missing, ", "))
+	}
+	if len(unexpected) > 0 {
+		problems = append(problems, "unexpected variables: "+strings.Join(unexpected, ", "))
+	}
+	if len(problems) > 0 {
+		return ExerciseCheck{Name: "variables", Message: strings.Join(problems, "; ")}
+	}
+	return ExerciseCheck{Name: "variables", Passed: true}
+}
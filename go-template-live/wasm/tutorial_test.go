@@ -0,0 +1,98 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"testing"
+)
+
+// TestTutorialEngine_Check tests pass/fail results for the profile-independent lessons
+func TestTutorialEngine_Check(t *testing.T) {
+	registry := NewFunctionRegistry()
+	engine, err := NewTutorialEngine(NewParser(registry), NewRenderer(registry, nil))
+	if err != nil {
+		t.Fatalf("NewTutorialEngine() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		lessonID     string
+		template     string
+		expectPassed bool
+		failedCheck  string
+	}{
+		{
+			name:         "correct solution passes",
+			lessonID:     "hello-field",
+			template:     `Hello, {{.Name}}!`,
+			expectPassed: true,
+		},
+		{
+			name:        "syntax error fails",
+			lessonID:    "hello-field",
+			template:    `Hello, {{.Name}!`,
+			failedCheck: "syntax",
+		},
+		{
+			name:        "wrong variable fails",
+			lessonID:    "hello-field",
+			template:    `Hello, {{.User}}!`,
+			failedCheck: "variables",
+		},
+		{
+			name:        "wrong output fails",
+			lessonID:    "conditionals",
+			template:    `Feature is {{if .Enabled}}off{{else}}on{{end}}`,
+			failedCheck: "output",
+		},
+		{
+			name:         "range solution passes",
+			lessonID:     "range",
+			template:     "{{range .Hosts}}- {{.}}\n{{end}}",
+			expectPassed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := engine.Check(tt.lessonID, tt.template)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if result.Passed != tt.expectPassed {
+				t.Fatalf("Check() passed = %v, want %v (checks %+v)", result.Passed, tt.expectPassed, result.Checks)
+			}
+			if tt.failedCheck == "" {
+				return
+			}
+			for _, check := range result.Checks {
+				if check.Name == tt.failedCheck && !check.Passed {
+					if len(result.Hints) == 0 {
+						t.Error("Check() expected hints on failure")
+					}
+					return
+				}
+			}
+			t.Errorf("Check() expected %s check to fail, got %+v", tt.failedCheck, result.Checks)
+		})
+	}
+}
+
+// TestTutorialEngine_ProfileFiltering tests that lessons needing custom functions are hidden in official mode
+func TestTutorialEngine_ProfileFiltering(t *testing.T) {
+	registry := NewFunctionRegistry()
+	engine, err := NewTutorialEngine(NewParser(registry), NewRenderer(registry, nil))
+	if err != nil {
+		t.Fatalf("NewTutorialEngine() error = %v", err)
+	}
+
+	for _, lesson := range engine.ListLessons() {
+		if lesson.ID == "getv-defaults" {
+			t.Error("ListLessons() included getv-defaults in official profile")
+		}
+	}
+	if _, err := engine.GetLesson("getv-defaults"); err == nil {
+		t.Error("GetLesson() expected error for lesson outside the active profile")
+	}
+}
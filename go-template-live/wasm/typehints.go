@@ -0,0 +1,145 @@
+package main
+
+import (
+	"text/template/parse"
+)
+
+// boolFunctions are builtins whose arguments are used as truth values
+var boolFunctions = map[string]bool{"and": true, "or": true, "not": true}
+
+// typeHinter infers type hints from usage context, keyed by the byte offset of each occurrence
+// Offsets match the Position recorded by extraction, so hints can be attached afterwards
+type typeHinter struct {
+	registry *FunctionRegistry
+	hints    map[int]string
+}
+
+// applyTypeHints sets VariableInfo.Type for every occurrence used in a typed context
+func (p *Parser) applyTypeHints(root *parse.ListNode, variables []VariableInfo) {
+	h := &typeHinter{registry: p.registry, hints: make(map[int]string)}
+	h.walk(root, 0)
+	for i := range variables {
+		if variables[i].Position == nil {
+			continue
+		}
+		if hint, ok := h.hints[variables[i].Position.Offset]; ok {
+			variables[i].Type = hint
+		}
+	}
+}
+
+// walk visits control structures: range pipelines yield arrays, if conditions yield booleans
+func (h *typeHinter) walk(node parse.Node, depth int) {
+	if depth > maxDepth {
+		return
+	}
+	depth++
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, item := range node.Nodes {
+			h.walk(item, depth)
+		}
+	case *parse.ActionNode:
+		h.pipe(node.Pipe, "", depth)
+	case *parse.TemplateNode:
+		h.pipe(node.Pipe, "", depth)
+	case *parse.IfNode:
+		h.pipe(node.Pipe, TypeBool, depth)
+		h.walk(node.List, depth)
+		h.walk(node.ElseList, depth)
+	case *parse.RangeNode:
+		h.pipe(node.Pipe, TypeArray, depth)
+		h.walk(node.List, depth)
+		h.walk(node.ElseList, depth)
+	case *parse.WithNode:
+		h.pipe(node.Pipe, "", depth)
+		h.walk(node.List, depth)
+		h.walk(node.ElseList, depth)
+	case *parse.BreakNode, *parse.ContinueNode, *parse.CommentNode, *parse.TextNode:
+	default:
+		for _, child := range childNodes(node) {
+			h.walk(child, depth)
+		}
+	}
+}
+
+// pipe hints function arguments in every command, and the pipeline value itself with resultType
+func (h *typeHinter) pipe(pipe *parse.PipeNode, resultType string, depth int) {
+	if pipe == nil || len(pipe.Cmds) == 0 || depth > maxDepth {
+		return
+	}
+	for i, cmd := range pipe.Cmds {
+		var previous *parse.CommandNode
+		if i > 0 {
+			previous = pipe.Cmds[i-1]
+		}
+		h.command(cmd, previous, depth+1)
+	}
+	if last := pipe.Cmds[len(pipe.Cmds)-1]; resultType != "" && len(last.Args) == 1 {
+		h.hint(last.Args[0], resultType, depth+1)
+	}
+}
+
+// command hints the arguments of a function call, including a value piped in from previous
+func (h *typeHinter) command(cmd *parse.CommandNode, previous *parse.CommandNode, depth int) {
+	argType := ""
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		argType = h.argTypeHint(ident.Ident)
+	}
+	for _, arg := range cmd.Args {
+		if argType != "" && arg != cmd.Args[0] {
+			h.hint(arg, argType, depth)
+		}
+		if nested, ok := arg.(*parse.PipeNode); ok {
+			h.pipe(nested, "", depth+1)
+		}
+	}
+	if argType != "" && previous != nil && len(previous.Args) == 1 {
+		h.hint(previous.Args[0], argType, depth)
+	}
+}
+
+// argTypeHint returns the type a function expects of its arguments
+func (h *typeHinter) argTypeHint(name string) string {
+	if boolFunctions[name] {
+		return TypeBool
+	}
+	if def, ok := h.registry.GetFunction(name); ok {
+		return def.ArgTypeHint
+	}
+	return ""
+}
+
+// hint records hint for the variable occurrence that node stands for
+// Key lookups such as getv "key" are followed to the key, which is where extraction records them
+func (h *typeHinter) hint(node parse.Node, hint string, depth int) {
+	if depth > maxDepth {
+		return
+	}
+	switch node := node.(type) {
+	case *parse.FieldNode:
+		h.record(fieldPosition(node).Offset, hint)
+	case *parse.StringNode:
+		h.record(int(node.Position()), hint)
+	case *parse.PipeNode:
+		if len(node.Cmds) != 1 {
+			return
+		}
+		args := node.Cmds[0].Args
+		if len(args) == 1 {
+			h.hint(args[0], hint, depth+1)
+		} else if _, ok := args[0].(*parse.IdentifierNode); ok && len(args) >= 2 && args[1].Type() == parse.NodeString {
+			h.hint(args[1], hint, depth+1)
+		}
+	}
+}
+
+// record keeps the first hint found for an offset
+func (h *typeHinter) record(offset int, hint string) {
+	if _, ok := h.hints[offset]; !ok {
+		h.hints[offset] = hint
+	}
+}
@@ -1,16 +1,134 @@
 package main
 
 import (
+	"sync"
 	"text/template"
 	"text/template/parse"
 )
 
 const maxDepth = 40
 
+// Function profile names, one per WASM build variant
+const (
+	ProfileOfficial = "official"
+	ProfileCustom   = "custom"
+	ProfileConfd    = "confd"
+	ProfileSprig    = "sprig"
+	ProfileGomplate = "gomplate"
+	ProfileHelm     = "helm"
+)
+
+// builtinFunctions are the functions predefined by text/template
+// They are always available regardless of the function profile
+var builtinFunctions = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// Position locates a variable occurrence in the template source
+// Offset is a byte offset; Line and Column are 1-based, with Column counted in characters
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Length int `json:"length"`
+}
+
 // VariableInfo stores variable information including name and default value
 type VariableInfo struct {
-	Name         string `json:"name"`
-	DefaultValue string `json:"defaultValue,omitempty"`
+	Name         string    `json:"name"`
+	DefaultValue string    `json:"defaultValue,omitempty"`
+	Position     *Position `json:"position,omitempty"`
+	// DefaultType is the literal type of DefaultValue: TypeNumber or TypeBool for unquoted
+	// defaults such as {{getv "port" 8080}}, empty for string defaults
+	DefaultType string `json:"defaultType,omitempty"`
+	// Type is a type hint inferred from how the variable is used, or declared by an @var pragma
+	Type string `json:"type,omitempty"`
+	// Description is declared by an @var comment pragma
+	Description string `json:"description,omitempty"`
+	// DependsOn lists the variables whose if/with conditions guard every use of this variable
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Required is set when the value is passed to required, whose message is RequiredMessage
+	Required        bool   `json:"required,omitempty"`
+	RequiredMessage string `json:"requiredMessage,omitempty"`
+	// Sensitive is set when the value is read with secret, so UIs can hide it
+	Sensitive bool `json:"sensitive,omitempty"`
+	// Path holds the segments of a confd key such as /myapp/database/host; occurrences of the
+	// same key share one slice, which must not be modified
+	Path []string `json:"path,omitempty"`
+	// Fields lists the field paths read from the value, such as database.host for
+	// {{(json "/cfg").database.host}}
+	Fields []string `json:"fields,omitempty"`
+	// Wildcard is set when Name is a key pattern such as /myapp/upstreams/*, read by gets or
+	// getvs, standing for every key it matches
+	Wildcard bool `json:"wildcard,omitempty"`
+	// Count and Occurrences are only set by aggregated extraction
+	Count       int        `json:"count,omitempty"`
+	Occurrences []Position `json:"occurrences,omitempty"`
+}
+
+// Type hints inferred for extracted variables, so UIs can pick a matching input widget
+const (
+	TypeString     = "string"
+	TypeArray      = "array"
+	TypeNumber     = "number"
+	TypeBool       = "bool"
+	TypeJSONString = "json-string"
+)
+
+// Extraction sort orders
+const (
+	// SortTraversal keeps the raw order in which the parser walks the template
+	SortTraversal = ""
+	// SortDocument orders variables by their position in the source
+	SortDocument = "document"
+	// SortAlpha orders variables by name
+	SortAlpha = "alpha"
+)
+
+// ExtractOptions controls the shape of the extracted variable list
+type ExtractOptions struct {
+	// Deduplicate keeps one entry per name; the first non-empty default wins
+	Deduplicate bool `json:"deduplicate"`
+	// Sort is one of SortTraversal, SortDocument or SortAlpha
+	Sort string `json:"sort"`
+	// IncludeDefaults keeps default values; when false they are cleared
+	IncludeDefaults bool `json:"includeDefaults"`
+	// ErrorPolicy is ErrorPolicyBreakOnFirstError (the default) or ErrorPolicyCollectAll
+	ErrorPolicy string `json:"errorPolicy,omitempty"`
+	// Prefix keeps the variables whose name starts with it, e.g. /myapp/database
+	Prefix string `json:"prefix,omitempty"`
+	// Function keeps the variables passed or piped to the named function, e.g. getv, by any
+	// of their occurrences
+	Function string `json:"function,omitempty"`
+	// RequiredOnly keeps the variables marked required by any of their occurrences
+	RequiredOnly bool `json:"requiredOnly,omitempty"`
+	// Offset and Limit select a page of the filtered variables; a zero Limit returns them all
+	Offset int `json:"offset,omitempty"`
+	Limit  int `json:"limit,omitempty"`
+}
+
+// VariablePage is one page of extracted variables
+type VariablePage struct {
+	Variables []VariableInfo `json:"variables"`
+	// Total is the number of variables passing the filters, across all pages
+	Total int `json:"total"`
+}
+
+// Extraction error policies
+const (
+	// ErrorPolicyBreakOnFirstError stops at the first error and returns no variables
+	ErrorPolicyBreakOnFirstError = "breakOnFirstError"
+	// ErrorPolicyCollectAll skips the template nodes that fail, returning the variables of the
+	// rest together with an *ExtractionErrors listing every failure
+	ErrorPolicyCollectAll = "collectAll"
+)
+
+// DefaultExtractOptions returns options reproducing the plain extraction output
+func DefaultExtractOptions() ExtractOptions {
+	return ExtractOptions{IncludeDefaults: true}
 }
 
 // VariableExtractor extracts variable names from function arguments
@@ -30,12 +148,42 @@ type FunctionDefinition struct {
 	Handler               interface{}
 	Extractor             VariableExtractor
 	ExtractorWithDefaults VariableExtractorWithDefaults
+	// ArgTypeHint is the type hint given to variables passed as arguments (empty for none)
+	ArgTypeHint string
+	// ArgNames name the handler's parameters in editor snippets, which reflection cannot recover;
+	// unnamed parameters are named after their type
+	ArgNames []string
+	// Placeholder marks a stub standing in for a function of a profile not built in
+	Placeholder bool
+	// ExtractsPipedValue marks extractors that read a field or $variable piped into the function
+	// as its last argument, as default does with {{.Port | default 8080}}
+	ExtractsPipedValue bool
+	// External marks a function implemented outside Go (see RegisterExternalFunction)
+	External bool
+	// EnvHandler builds the handler of a function reading the render environment, such as now
+	// or randAlpha; every render binds it to its own environment. Handler, used for parsing,
+	// defaults to one bound to a default environment
+	EnvHandler func(env *renderEnv) interface{}
 }
 
+//go:generate go run gen_profiles.go
+
 // FunctionRegistry manages all custom template functions
 // This is the single source of truth for available functions
 type FunctionRegistry struct {
 	functions map[string]*FunctionDefinition
+	profile   string
+	// hasPlaceholders is set once a placeholder function is registered
+	hasPlaceholders bool
+	// builtinValues builds the values every render starts from, such as Helm's .Release and .Chart
+	builtinValues func() map[string]interface{}
+	// setFuncs builds the functions that execute templates of the set being rendered, such as
+	// Helm's include and tpl
+	setFuncs TemplateSetFuncs
+
+	// minimalFuncs is materialized on first use and reset when functions change
+	mu           sync.Mutex
+	minimalFuncs template.FuncMap
 }
 
 // NewFunctionRegistry creates a new function registry
@@ -47,7 +195,53 @@ func NewFunctionRegistry() *FunctionRegistry {
 
 // RegisterFunction registers a new custom function
 func (r *FunctionRegistry) RegisterFunction(def *FunctionDefinition) {
+	if def.Handler == nil && def.EnvHandler != nil {
+		def.Handler = def.EnvHandler(defaultRenderEnv())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.functions[def.Name] = def
+	r.hasPlaceholders = r.hasPlaceholders || def.Placeholder
+	r.minimalFuncs = nil
+}
+
+// SetProfile records which function profile populated this registry
+// The generated profile table sizes the function map before registration starts
+func (r *FunctionRegistry) SetProfile(profile string) {
+	r.profile = profile
+	if len(r.functions) == 0 {
+		r.functions = make(map[string]*FunctionDefinition, len(profileFunctionTables[profile]))
+	}
+}
+
+// Profile returns the function profile name, defaulting to official when unset
+func (r *FunctionRegistry) Profile() string {
+	if r.profile == "" {
+		return ProfileOfficial
+	}
+	return r.profile
+}
+
+// SetBuiltinValues sets the builder of the values every render starts from; provided values
+// override them key by key, through nested objects
+// Every render builds its own, so templates modifying them, as set does, cannot leak changes
+// into later renders
+func (r *FunctionRegistry) SetBuiltinValues(builder func() map[string]interface{}) {
+	r.builtinValues = builder
+}
+
+// BuiltinValues returns a fresh copy of the values every render starts from, nil for none
+func (r *FunctionRegistry) BuiltinValues() map[string]interface{} {
+	if r.builtinValues == nil {
+		return nil
+	}
+	return r.builtinValues()
+}
+
+// SetTemplateSetFuncs sets the builder of the functions that execute templates of the set
+// being rendered; they are added to each text/template set before it is executed
+func (r *FunctionRegistry) SetTemplateSetFuncs(funcs TemplateSetFuncs) {
+	r.setFuncs = funcs
 }
 
 // GetFunction returns a function definition by name
@@ -71,23 +265,34 @@ func (r *FunctionRegistry) GetFunctionNames() []string {
 	return names
 }
 
-// GetMinimalFuncMap creates a minimal function map for parsing
+// GetMinimalFuncMap returns a minimal function map for parsing
 // Uses minimal implementations that don't require actual variables
+// The map is built once and shared, so callers must not modify it
 func (r *FunctionRegistry) GetMinimalFuncMap() template.FuncMap {
-	funcMap := template.FuncMap{}
-	for name, def := range r.functions {
-		funcMap[name] = def.Handler
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.minimalFuncs == nil {
+		r.minimalFuncs = make(template.FuncMap, len(r.functions))
+		for name, def := range r.functions {
+			r.minimalFuncs[name] = def.Handler
+		}
 	}
-	return funcMap
+	return r.minimalFuncs
 }
 
-// GetRenderFuncMap creates a function map for rendering with actual variable values
-func (r *FunctionRegistry) GetRenderFuncMap(variables map[string]interface{}) template.FuncMap {
-	funcMap := template.FuncMap{}
+// GetRenderFuncMap creates the function map of one render, binding the functions that read
+// the render environment to env
+// The map is the caller's to extend with the render implementations of the profile
+func (r *FunctionRegistry) GetRenderFuncMap(env *renderEnv) template.FuncMap {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	funcMap := make(template.FuncMap, len(r.functions))
 	for name, def := range r.functions {
-		// For rendering, we need to create closures with the variables
-		// This is handled by the function implementations themselves
-		funcMap[name] = def.Handler
+		if def.EnvHandler != nil {
+			funcMap[name] = def.EnvHandler(env)
+		} else {
+			funcMap[name] = def.Handler
+		}
 	}
 	return funcMap
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultTypeScriptName is the interface name used when none is given
+const DefaultTypeScriptName = "TemplateValues"
+
+// tsIdentifier matches names usable as TypeScript identifiers and unquoted property names
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// typeScriptTypes maps type hints to TypeScript types; variables without a hint are strings,
+// matching the values entered in the playground
+var typeScriptTypes = map[string]string{
+	TypeString:     "string",
+	TypeNumber:     "number",
+	TypeBool:       "boolean",
+	TypeArray:      "unknown[]",
+	TypeJSONString: "string",
+}
+
+// GenerateTypeScript emits an exported interface describing the values a template needs
+// Dotted names become nested types; variables with a default or a guarding condition are optional
+// unless passed to required
+func GenerateTypeScript(name string, variables []VariableInfo) (string, error) {
+	if name == "" {
+		name = DefaultTypeScriptName
+	}
+	if !tsIdentifier.MatchString(name) {
+		return "", fmt.Errorf("invalid interface name %q", name)
+	}
+
+	root := buildVariableTree(variables)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "export interface %s ", name)
+	writeTypeScriptObject(&b, root, 0)
+	b.WriteString("\n")
+	return b.String(), nil
+}
+
+// writeTypeScriptObject writes the fields of node as an object type, sorted by name
+func writeTypeScriptObject(b *strings.Builder, node *variableTree, indent int) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b.WriteString("{\n")
+	padding := strings.Repeat("  ", indent+1)
+	for _, name := range names {
+		field := node.children[name]
+		if v := field.variable; v != nil && v.Description != "" {
+			fmt.Fprintf(b, "%s/** %s */\n", padding, strings.ReplaceAll(v.Description, "*/", "*\\/"))
+		}
+
+		key := name
+		if !tsIdentifier.MatchString(name) {
+			key = strconv.Quote(name)
+		}
+		optional := ""
+		if v := field.variable; v != nil && len(field.children) == 0 && !v.isRequired() {
+			optional = "?"
+		}
+		fmt.Fprintf(b, "%s%s%s: ", padding, key, optional)
+
+		if len(field.children) > 0 {
+			writeTypeScriptObject(b, field, indent+1)
+		} else {
+			tsType, ok := typeScriptTypes[field.variable.Type]
+			if !ok {
+				tsType = "string"
+			}
+			b.WriteString(tsType)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString(strings.Repeat("  ", indent) + "}")
+}
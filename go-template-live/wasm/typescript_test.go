@@ -0,0 +1,68 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"testing"
+)
+
+// TestGenerateTypeScript tests interface generation from extracted variables
+func TestGenerateTypeScript(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{/* @var Server.Port type=number description="Listen port" */}}
+listen {{.Server.Port}} {{.Server.Host}}
+{{range .Upstreams}}{{end}}
+{{if .Tls.Enabled}}{{.Tls.Cert}}{{end}}`
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	got, err := GenerateTypeScript("", variables)
+	if err != nil {
+		t.Fatalf("GenerateTypeScript() error = %v", err)
+	}
+	expected := `export interface TemplateValues {
+  Server: {
+    Host: string;
+    /** Listen port */
+    Port: number;
+  };
+  Tls: {
+    Cert?: string;
+    Enabled: boolean;
+  };
+  Upstreams: unknown[];
+}
+`
+	if got != expected {
+		t.Errorf("GenerateTypeScript() =\n%s\nwant\n%s", got, expected)
+	}
+}
+
+// TestGenerateTypeScript_QuotedKeys tests keys that are not TypeScript identifiers
+func TestGenerateTypeScript_QuotedKeys(t *testing.T) {
+	variables := []VariableInfo{
+		{Name: "/app/host", DefaultValue: "localhost"},
+		{Name: "max-conns", Type: TypeNumber},
+	}
+
+	got, err := GenerateTypeScript("AppValues", variables)
+	if err != nil {
+		t.Fatalf("GenerateTypeScript() error = %v", err)
+	}
+	expected := `export interface AppValues {
+  "/app/host"?: string;
+  "max-conns": number;
+}
+`
+	if got != expected {
+		t.Errorf("GenerateTypeScript() =\n%s\nwant\n%s", got, expected)
+	}
+
+	if _, err := GenerateTypeScript("bad name", variables); err == nil {
+		t.Error("GenerateTypeScript() expected error for invalid interface name")
+	}
+}
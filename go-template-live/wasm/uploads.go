@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// maxUploadSizeHint caps the capacity reserved up front from a caller-supplied size hint
+const maxUploadSizeHint = 64 << 20
+
+// templateUploads assembles large templates sent from JavaScript in chunks, so no single
+// call has to copy the whole template across the JS boundary
+type templateUploads struct {
+	nextID  int
+	buffers map[int][]byte
+}
+
+func newTemplateUploads() *templateUploads {
+	return &templateUploads{buffers: make(map[int][]byte)}
+}
+
+// Begin starts an upload, reserving sizeHint bytes when known, and returns its handle
+func (u *templateUploads) Begin(sizeHint int) int {
+	if sizeHint < 0 || sizeHint > maxUploadSizeHint {
+		sizeHint = 0
+	}
+	u.nextID++
+	u.buffers[u.nextID] = make([]byte, 0, sizeHint)
+	return u.nextID
+}
+
+// Append adds a chunk to an upload
+func (u *templateUploads) Append(id int, chunk []byte) error {
+	buf, ok := u.buffers[id]
+	if !ok {
+		return fmt.Errorf("unknown template upload %d", id)
+	}
+	u.buffers[id] = append(buf, chunk...)
+	return nil
+}
+
+// Take returns the assembled template and releases the upload
+// The buffer is handed over to the string without another copy, as nothing else references it
+func (u *templateUploads) Take(id int) (string, error) {
+	buf, ok := u.buffers[id]
+	if !ok {
+		return "", fmt.Errorf("unknown template upload %d", id)
+	}
+	delete(u.buffers, id)
+	if len(buf) == 0 {
+		return "", nil
+	}
+	return unsafe.String(&buf[0], len(buf)), nil
+}
+
+// Abort discards an upload
+func (u *templateUploads) Abort(id int) {
+	delete(u.buffers, id)
+}
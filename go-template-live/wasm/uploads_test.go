@@ -0,0 +1,48 @@
+//go:build !js
+// +build !js
+
+package main
+
+import "testing"
+
+func TestTemplateUploads(t *testing.T) {
+	uploads := newTemplateUploads()
+	id := uploads.Begin(16)
+	for _, chunk := range []string{"Hello ", "{{.Na", "me}}"} {
+		if err := uploads.Append(id, []byte(chunk)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	got, err := uploads.Take(id)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if want := "Hello {{.Name}}"; got != want {
+		t.Errorf("Take() = %q, want %q", got, want)
+	}
+
+	if _, err := uploads.Take(id); err == nil {
+		t.Error("Take() on a consumed upload should fail")
+	}
+	if err := uploads.Append(id, []byte("x")); err == nil {
+		t.Error("Append() on a consumed upload should fail")
+	}
+}
+
+func TestTemplateUploads_EmptyAndAbort(t *testing.T) {
+	uploads := newTemplateUploads()
+	empty := uploads.Begin(-1)
+	if got, err := uploads.Take(empty); err != nil || got != "" {
+		t.Errorf("Take() = %q, %v, want empty string", got, err)
+	}
+
+	aborted := uploads.Begin(maxUploadSizeHint + 1)
+	uploads.Abort(aborted)
+	if _, err := uploads.Take(aborted); err == nil {
+		t.Error("Take() on an aborted upload should fail")
+	}
+	if next := uploads.Begin(0); next == aborted {
+		t.Errorf("Begin() reused handle %d", next)
+	}
+}
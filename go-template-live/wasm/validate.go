@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TypeMismatch is a provided value whose type does not match how the template uses it
+type TypeMismatch struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ValuesValidation compares the values provided for a template with the variables it uses
+type ValuesValidation struct {
+	// Valid is false when a required value is missing or a value has the wrong type;
+	// unused values alone do not make the values invalid
+	Valid bool `json:"valid"`
+	// Missing lists variables without a default that have no value and are used outside any
+	// guarding condition or passed to required, in order of first use; a key pattern read by
+	// gets or getvs is missing when no key matches it
+	Missing []string `json:"missing"`
+	// Unused lists provided keys the template never reads, sorted; nested keys are dotted
+	Unused     []string       `json:"unused"`
+	Mismatches []TypeMismatch `json:"mismatches"`
+}
+
+// ValidateValues checks values against the variables extracted from a template
+// A variable name is looked up as a flat key first (confd keys such as "/app/port") and then
+// as a path through nested objects (fields such as .Server.Port)
+func ValidateValues(variables []VariableInfo, values map[string]interface{}) *ValuesValidation {
+	result := &ValuesValidation{
+		Missing:    []string{},
+		Unused:     []string{},
+		Mismatches: []TypeMismatch{},
+	}
+
+	aggregated := AggregateVariables(variables)
+	used := make(map[string]bool, len(aggregated))
+	for _, v := range aggregated {
+		used[v.Name] = true
+		if len(v.Path) > 0 {
+			// Hierarchical values hold keys as nested objects, which unusedKeys walks by dotted name
+			used[strings.Join(v.Path, ".")] = true
+		}
+		if v.Wildcard {
+			// A key pattern is provided when any key matches it
+			matches, _ := NewKeyStore(values).GetAll(v.Name)
+			if len(matches) == 0 && v.isRequired() {
+				result.Missing = append(result.Missing, v.Name)
+			}
+			for _, match := range matches {
+				used[match.Key] = true
+				used[strings.Join(splitKey(match.Key), ".")] = true
+			}
+			continue
+		}
+		value, ok := lookupValue(values, v.Name)
+		if !ok {
+			if v.isRequired() {
+				result.Missing = append(result.Missing, v.Name)
+			}
+			continue
+		}
+		if expected, ok := valueMatchesType(v.Type, value); !ok {
+			result.Mismatches = append(result.Mismatches, TypeMismatch{Name: v.Name, Expected: expected, Actual: jsonTypeName(value)})
+		}
+	}
+
+	result.Unused = unusedKeys(values, "", used, result.Unused)
+	sort.Strings(result.Unused)
+	result.Valid = len(result.Missing) == 0 && len(result.Mismatches) == 0
+	return result
+}
+
+// lookupValue finds the value of a variable name, as a flat key or a dotted path; confd keys
+// are resolved like getv does, through nested objects when not stored flat
+func lookupValue(values map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := values[name]; ok {
+		return value, true
+	}
+	if isKeyPath(name) {
+		return NewKeyStore(values).Get(name)
+	}
+	var current interface{} = values
+	for _, segment := range strings.Split(name, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[segment]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// unusedKeys appends the keys of values that no variable reads
+// Objects are descended into when the template reads some of their fields but not the object itself
+func unusedKeys(values map[string]interface{}, prefix string, used map[string]bool, dst []string) []string {
+	for key, value := range values {
+		name := prefix + key
+		if used[name] {
+			continue
+		}
+		nested, isObject := value.(map[string]interface{})
+		if isObject && usesPrefix(used, name+".") {
+			dst = unusedKeys(nested, name+".", used, dst)
+			continue
+		}
+		dst = append(dst, name)
+	}
+	return dst
+}
+
+// usesPrefix reports whether any used variable name starts with prefix
+func usesPrefix(used map[string]bool, prefix string) bool {
+	for name := range used {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueMatchesType reports whether value suits a type hint, returning the expected JSON type
+// Strings holding a number or boolean are accepted, as key-value backends store every value as text
+func valueMatchesType(typeHint string, value interface{}) (string, bool) {
+	switch typeHint {
+	case TypeString:
+		_, ok := value.(string)
+		return "string", ok
+	case TypeNumber:
+		if s, ok := value.(string); ok {
+			_, err := strconv.ParseFloat(s, 64)
+			return "number", err == nil
+		}
+		return "number", jsonTypeName(value) == "number"
+	case TypeBool:
+		if s, ok := value.(string); ok {
+			_, err := strconv.ParseBool(s)
+			return "boolean", err == nil
+		}
+		_, ok := value.(bool)
+		return "boolean", ok
+	case TypeArray:
+		name := jsonTypeName(value)
+		return "array", name == "array" || name == "object"
+	case TypeJSONString:
+		s, ok := value.(string)
+		return "JSON string", ok && json.Valid([]byte(s))
+	}
+	return "", true
+}
+
+// jsonTypeName names the JSON type of a decoded value
+func jsonTypeName(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	if _, ok := value.(json.Number); ok {
+		return "number"
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "unknown"
+}
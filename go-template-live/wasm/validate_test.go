@@ -0,0 +1,93 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateValues(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	template := `{{/* @var Server.Port type=number */}}
+{{/* @var Settings type=json-string */}}
+{{/* @var Region default=eu */}}
+{{.Server.Host}}:{{.Server.Port}} {{.Settings}} {{.Region}}
+{{if .Debug}}{{.Level}}{{end}}
+{{range .Upstreams}}{{.}}{{end}}`
+
+	variables, err := parser.ExtractVariablesWithPositions("test.tmpl", template)
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		values   map[string]interface{}
+		expected *ValuesValidation
+	}{
+		{
+			name: "complete values",
+			values: map[string]interface{}{
+				"Server":    map[string]interface{}{"Host": "localhost", "Port": float64(80)},
+				"Settings":  `{"a":1}`,
+				"Debug":     "true",
+				"Upstreams": []interface{}{"a"},
+			},
+			expected: &ValuesValidation{Valid: true, Missing: []string{}, Unused: []string{}, Mismatches: []TypeMismatch{}},
+		},
+		{
+			name: "missing, unused and mismatched",
+			values: map[string]interface{}{
+				"Server":   map[string]interface{}{"Port": "eighty", "Name": "web"},
+				"Settings": map[string]interface{}{"a": 1},
+				"Debug":    float64(1),
+				"Extra":    "x",
+			},
+			expected: &ValuesValidation{
+				Missing: []string{"Server.Host", "Upstreams"},
+				Unused:  []string{"Extra", "Server.Name"},
+				Mismatches: []TypeMismatch{
+					{Name: "Server.Port", Expected: "number", Actual: "string"},
+					{Name: "Settings", Expected: "JSON string", Actual: "object"},
+					{Name: "Debug", Expected: "boolean", Actual: "number"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateValues(variables, tt.values)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ValidateValues() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLookupValue(t *testing.T) {
+	values := map[string]interface{}{
+		"/app/port": "80",
+		"a.b":       "flat",
+		"Server":    map[string]interface{}{"Host": "localhost"},
+	}
+	tests := []struct {
+		name     string
+		expected interface{}
+		found    bool
+	}{
+		{"/app/port", "80", true},
+		{"a.b", "flat", true},
+		{"Server.Host", "localhost", true},
+		{"Server.Port", nil, false},
+		{"Server.Host.Name", nil, false},
+	}
+	for _, tt := range tests {
+		got, found := lookupValue(values, tt.name)
+		if got != tt.expected || found != tt.found {
+			t.Errorf("lookupValue(%q) = %v, %v, want %v, %v", tt.name, got, found, tt.expected, tt.found)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// valueProfilesVersion is the format version of saved value profiles
+const valueProfilesVersion = 1
+
+// ValueProfileCoverage tells which variables of a template a value profile provides
+type ValueProfileCoverage struct {
+	Profile string `json:"profile"`
+	// Covered and Uncovered split the template's variables, in order of first use; a key
+	// pattern read by gets or getvs is covered when any key matches it
+	Covered   []string `json:"covered"`
+	Uncovered []string `json:"uncovered"`
+	// Valid is the validity of the profile for the template, as ValidateValues reports it
+	Valid bool `json:"valid"`
+}
+
+// valueProfilesSnapshot is the saved form of ValueProfiles
+type valueProfilesSnapshot struct {
+	Version  int                               `json:"version"`
+	Profiles map[string]map[string]interface{} `json:"profiles"`
+}
+
+// ValueProfiles keeps named sets of values, such as prod-eu or local-dev, to render templates
+// with. Profiles are validated against a template when loaded, and can be saved and restored
+// It is not safe for concurrent use
+type ValueProfiles struct {
+	profiles map[string]map[string]interface{}
+}
+
+// NewValueProfiles creates an empty profile store
+func NewValueProfiles() *ValueProfiles {
+	return &ValueProfiles{profiles: make(map[string]map[string]interface{})}
+}
+
+// ReadValueProfiles restores profiles written by Write
+func ReadValueProfiles(r io.Reader) (*ValueProfiles, error) {
+	var snapshot valueProfilesSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("invalid value profiles: %v", err)
+	}
+	if snapshot.Version != valueProfilesVersion {
+		return nil, fmt.Errorf("unsupported value profiles version %d", snapshot.Version)
+	}
+	profiles := NewValueProfiles()
+	for name, values := range snapshot.Profiles {
+		if err := profiles.Save(name, values); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// Write saves every profile as JSON
+func (s *ValueProfiles) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(valueProfilesSnapshot{Version: valueProfilesVersion, Profiles: s.profiles})
+}
+
+// Save stores values under a profile name, replacing any profile of that name
+func (s *ValueProfiles) Save(name string, values map[string]interface{}) error {
+	if name == "" {
+		return fmt.Errorf("value profile without a name")
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	s.profiles[name] = values
+	return nil
+}
+
+// Get returns the values of a profile
+func (s *ValueProfiles) Get(name string) (map[string]interface{}, bool) {
+	values, ok := s.profiles[name]
+	return values, ok
+}
+
+// Delete removes a profile, reporting whether it existed
+func (s *ValueProfiles) Delete(name string) bool {
+	_, ok := s.profiles[name]
+	delete(s.profiles, name)
+	return ok
+}
+
+// Names returns the profile names, sorted
+func (s *ValueProfiles) Names() []string {
+	names := make([]string, 0, len(s.profiles))
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the values of a profile validated against the variables of a template
+func (s *ValueProfiles) Load(name string, variables []VariableInfo) (map[string]interface{}, *ValuesValidation, error) {
+	values, ok := s.profiles[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown value profile %q", name)
+	}
+	return values, ValidateValues(variables, values), nil
+}
+
+// Merge layers profiles in order, later profiles winning, as MergeVariables does with opts;
+// each layer is named after its profile
+func (s *ValueProfiles) Merge(names []string, opts MergeOptions) (*MergedVariables, error) {
+	layers := make([]VariableLayer, 0, len(names))
+	for _, name := range names {
+		values, ok := s.profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown value profile %q", name)
+		}
+		layers = append(layers, VariableLayer{Name: name, Values: values})
+	}
+	return MergeVariables(layers, opts)
+}
+
+// Coverage reports, for every profile in name order, which of a template's variables it provides
+func (s *ValueProfiles) Coverage(variables []VariableInfo) []ValueProfileCoverage {
+	aggregated := AggregateVariables(variables)
+	coverage := make([]ValueProfileCoverage, 0, len(s.profiles))
+	for _, name := range s.Names() {
+		values := s.profiles[name]
+		profile := ValueProfileCoverage{
+			Profile:   name,
+			Covered:   []string{},
+			Uncovered: []string{},
+			Valid:     ValidateValues(variables, values).Valid,
+		}
+		for _, v := range aggregated {
+			var covered bool
+			if v.Wildcard {
+				matches, _ := NewKeyStore(values).GetAll(v.Name)
+				covered = len(matches) > 0
+			} else {
+				_, covered = lookupValue(values, v.Name)
+			}
+			if covered {
+				profile.Covered = append(profile.Covered, v.Name)
+			} else {
+				profile.Uncovered = append(profile.Uncovered, v.Name)
+			}
+		}
+		coverage = append(coverage, profile)
+	}
+	return coverage
+}
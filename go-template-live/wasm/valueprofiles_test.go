@@ -0,0 +1,67 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestValueProfiles tests that profiles are validated on load, report their coverage of a
+// template and survive a write and read
+func TestValueProfiles(t *testing.T) {
+	variables, err := NewParser(NewFunctionRegistry()).ExtractVariablesWithPositions("app.tmpl",
+		"{{.Name}}:{{.Server.Port}} {{if .Debug}}debug{{end}}")
+	if err != nil {
+		t.Fatalf("ExtractVariablesWithPositions() error = %v", err)
+	}
+
+	profiles := NewValueProfiles()
+	if err := profiles.Save("prod-eu", map[string]interface{}{"Name": "web", "Server": map[string]interface{}{"Port": 443.0}, "Debug": false}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := profiles.Save("local-dev", map[string]interface{}{"Debug": true, "Extra": 1}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := profiles.Save("", nil); err == nil {
+		t.Error("Save() without a name succeeded")
+	}
+
+	values, validation, err := profiles.Load("local-dev", variables)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if values["Debug"] != true || validation.Valid || !reflect.DeepEqual(validation.Missing, []string{"Name", "Server.Port"}) ||
+		!reflect.DeepEqual(validation.Unused, []string{"Extra"}) {
+		t.Errorf("Load() = %v, %+v, want local-dev missing Name and Server.Port with Extra unused", values, validation)
+	}
+	if _, _, err := profiles.Load("missing", variables); err == nil {
+		t.Error("Load() of an unknown profile succeeded")
+	}
+
+	expected := []ValueProfileCoverage{
+		{Profile: "local-dev", Covered: []string{"Debug"}, Uncovered: []string{"Name", "Server.Port"}},
+		{Profile: "prod-eu", Covered: []string{"Name", "Server.Port", "Debug"}, Uncovered: []string{}, Valid: true},
+	}
+	if coverage := profiles.Coverage(variables); !reflect.DeepEqual(coverage, expected) {
+		t.Errorf("Coverage() = %+v, want %+v", coverage, expected)
+	}
+
+	var saved bytes.Buffer
+	if err := profiles.Write(&saved); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	restored, err := ReadValueProfiles(&saved)
+	if err != nil {
+		t.Fatalf("ReadValueProfiles() error = %v", err)
+	}
+	if got, _ := restored.Get("prod-eu"); !reflect.DeepEqual(got, map[string]interface{}{"Name": "web", "Server": map[string]interface{}{"Port": 443.0}, "Debug": false}) {
+		t.Errorf("ReadValueProfiles() prod-eu = %v", got)
+	}
+
+	if !restored.Delete("prod-eu") || restored.Delete("prod-eu") || !reflect.DeepEqual(restored.Names(), []string{"local-dev"}) {
+		t.Errorf("Delete() left %v", restored.Names())
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValueResolver supplies values on demand: when getv, get, exists or the typed getv functions
+// read a key missing from the provided values, the resolver is asked for it, so large value
+// sets (an API, browser storage) need not be passed up front
+type ValueResolver interface {
+	// ResolveValue returns the value of key as the template wrote it; ok is false when the key
+	// has no value
+	ResolveValue(key string) (value interface{}, ok bool, err error)
+}
+
+// resolvedValue is a cached answer of the value resolver
+type resolvedValue struct {
+	value interface{}
+	ok    bool
+}
+
+// valueResolution is the value resolver registered with an Environment, with its cache
+// Answers, misses included, are cached until the resolver is replaced or the cache cleared;
+// failed lookups are not cached
+type valueResolution struct {
+	resolver ValueResolver
+	budget   int
+	cache    map[string]resolvedValue
+}
+
+// valueLookups resolves the keys one render reads: at most budget lookups reach the resolver
+// per Render, Evaluate or batch resource (0 for no limit); keys beyond it read as missing
+// Renders share the cache of the environment, and the environment lock serializes their
+// resolver calls
+type valueLookups struct {
+	mu       *sync.Mutex
+	resolver ValueResolver
+	budget   int
+	cache    map[string]resolvedValue
+	// lookups counts resolver calls of the render; skipped and failed collect the keys it could
+	// not resolve for the render warning
+	lookups int
+	skipped map[string]bool
+	failed  map[string]string
+}
+
+// SetValueResolver registers the resolver asked for missing keys, with a per-render budget of
+// lookups (0 for no limit), and clears the cache; a nil resolver disables resolution
+func (e *Environment) SetValueResolver(resolver ValueResolver, budget int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.values = valueResolution{resolver: resolver, budget: budget, cache: make(map[string]resolvedValue)}
+}
+
+// ClearValueResolverCache drops the cached answers of the value resolver, for when the data
+// behind it changed
+func (e *Environment) ClearValueResolverCache() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.values.cache = make(map[string]resolvedValue)
+}
+
+// lookups starts the lookups of a render; mu guards the cache
+// Replacing the resolver or clearing the cache gives the environment a new cache, so answers
+// of renders still running go to the one they started with
+func (v valueResolution) lookups(mu *sync.Mutex) *valueLookups {
+	return &valueLookups{mu: mu, resolver: v.resolver, budget: v.budget, cache: v.cache}
+}
+
+// resolve returns the value of a key missing from the provided values, asking the resolver
+// within the budget when the cache has no answer
+func (v *valueLookups) resolve(key string) (interface{}, bool) {
+	if v == nil || v.resolver == nil {
+		return nil, false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if cached, ok := v.cache[key]; ok {
+		return cached.value, cached.ok
+	}
+	if v.budget > 0 && v.lookups >= v.budget {
+		if v.skipped == nil {
+			v.skipped = make(map[string]bool)
+		}
+		v.skipped[key] = true
+		return nil, false
+	}
+
+	v.lookups++
+	value, ok, err := v.resolver.ResolveValue(key)
+	if err != nil {
+		if v.failed == nil {
+			v.failed = make(map[string]string)
+		}
+		v.failed[key] = err.Error()
+		return nil, false
+	}
+	v.cache[key] = resolvedValue{value: value, ok: ok}
+	return value, ok
+}
+
+// warning describes the keys the render could not resolve, or is empty
+func (v *valueLookups) warning() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var parts []string
+	if len(v.skipped) > 0 {
+		keys := make([]string, 0, len(v.skipped))
+		for key := range v.skipped {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts = append(parts, fmt.Sprintf("lookup budget of %d exhausted, not resolved: %s", v.budget, strings.Join(keys, ", ")))
+	}
+	if len(v.failed) > 0 {
+		keys := make([]string, 0, len(v.failed))
+		for key := range v.failed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			parts = append(parts, fmt.Sprintf("%s: %s", key, v.failed[key]))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "value resolver: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,31 @@
+//go:build js
+// +build js
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// jsValueResolver asks a resolver function registered by the page for missing keys
+// The function is called as fn(key) and must return synchronously: the value (converted like the
+// result of a JavaScript template function), or undefined when the key has no value; a thrown
+// error fails the lookup, which the render reports as a warning
+type jsValueResolver struct {
+	fn js.Value
+}
+
+func (r jsValueResolver) ResolveValue(key string) (value interface{}, ok bool, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			value, ok, err = nil, false, fmt.Errorf("%v", recovered)
+		}
+	}()
+	result := r.fn.Invoke(key)
+	if result.IsUndefined() {
+		return nil, false, nil
+	}
+	value, err = goValue(result)
+	return value, err == nil, err
+}
@@ -0,0 +1,82 @@
+//go:build !js && confd
+// +build !js,confd
+
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mapValueResolver answers from a map and records the keys it was asked for
+type mapValueResolver struct {
+	values map[string]interface{}
+	asked  []string
+}
+
+func (r *mapValueResolver) ResolveValue(key string) (interface{}, bool, error) {
+	r.asked = append(r.asked, key)
+	if key == "/broken" {
+		return nil, false, errors.New("backend unavailable")
+	}
+	value, ok := r.values[key]
+	return value, ok, nil
+}
+
+// TestValueResolver tests that missing keys are resolved on demand, cached across renders and
+// limited by the lookup budget
+func TestValueResolver(t *testing.T) {
+	resolver := &mapValueResolver{values: map[string]interface{}{"/db/host": "db.internal", "/db/port": "5432"}}
+	renderer := createConfdRenderer()
+	env := renderer.Environment()
+	env.SetValueResolver(resolver, 0)
+	template := `{{getv "/app/name"}}@{{getv "/db/host"}}:{{getv "/db/port"}} {{exists "/missing"}}`
+	values := map[string]interface{}{"/app/name": "web"}
+
+	result, err := renderer.Render(template, values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if expected := "web@db.internal:5432 false"; result.Output != expected {
+		t.Errorf("Render() = %q, want %q", result.Output, expected)
+	}
+	if expected := []string{"/db/host", "/db/port", "/missing"}; !reflect.DeepEqual(resolver.asked, expected) {
+		t.Errorf("resolver asked for %v, want %v", resolver.asked, expected)
+	}
+
+	if _, err := renderer.Render(template, values, RenderOptions{}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(resolver.asked) != 3 {
+		t.Errorf("second Render() asked the resolver again: %v", resolver.asked[3:])
+	}
+
+	env.ClearValueResolverCache()
+	env.SetValueResolver(resolver, 1)
+	resolver.asked = nil
+	result, err = renderer.Render(`{{getv "/db/host"}}:{{getv "/db/port" "80"}}{{getv "/broken"}}`, nil, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Output != "db.internal:80" {
+		t.Errorf("Render() with a budget of 1 = %q, want %q", result.Output, "db.internal:80")
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "lookup budget of 1 exhausted, not resolved: /broken, /db/port") {
+		t.Errorf("Render() warnings = %v, want the keys over budget", result.Warnings)
+	}
+
+	env.SetValueResolver(resolver, 0)
+	result, err = renderer.Render(`{{getv "/broken" "fallback"}}`, nil, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Output != "fallback" || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "/broken: backend unavailable") {
+		t.Errorf("Render() of a failing lookup = %q with warnings %v", result.Output, result.Warnings)
+	}
+
+	if value, ok := NewKeyStore(nil).Get("/db/host"); ok {
+		t.Errorf("KeyStore.Get() outside a render resolved %v", value)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseValues decodes a values payload written as a JSON object or a YAML mapping, as kept in
+// confd and Helm values files
+// A YAML stream of several documents (separated by ---) is merged in order like MergeValues.
+// An empty payload is an empty set of values
+func ParseValues(data string) (map[string]interface{}, error) {
+	if strings.TrimSpace(data) == "" {
+		return map[string]interface{}{}, nil
+	}
+	if values, ok := parseJSONValues(data); ok {
+		return values, nil
+	}
+
+	var documents []map[string]interface{}
+	decoder := yaml.NewDecoder(strings.NewReader(data))
+	for i := 1; ; i++ {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid values: %v", err)
+		}
+		if document == nil {
+			continue
+		}
+		values, ok := valuesFromYAML(document).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid values: document %d is a %s, expected a mapping of names to values", i, valueKind(document))
+		}
+		documents = append(documents, values)
+	}
+	return MergeValues(documents...), nil
+}
+
+// valuesErrorMessage describes why ParseValues rejected data: a payload written as a JSON object
+// reports the JSON syntax error, as the playground did before it accepted YAML, and any other
+// payload the YAML error
+func valuesErrorMessage(data string, err error) string {
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		var values map[string]interface{}
+		if jsonErr := json.Unmarshal([]byte(data), &values); jsonErr != nil {
+			return "Failed to parse variables JSON: " + jsonErr.Error()
+		}
+	}
+	return "Failed to parse variables YAML: " + err.Error()
+}
+
+// parseJSONValues decodes a JSON object of values, with numbers decoded as by valuesFromJSON
+func parseJSONValues(data string) (map[string]interface{}, bool) {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil || values == nil {
+		return nil, false
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, false
+	}
+	return valuesFromJSON(values).(map[string]interface{}), true
+}
+
+// valuesFromJSON converts the numbers of JSON decoded with UseNumber to the shapes YAML
+// decoding produces: integers become ints and other numbers float64. Integers beyond the int
+// range stay json.Number, keeping every digit for the math functions, which would be rounded
+// as float64 (above 2^53)
+func valuesFromJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = valuesFromJSON(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = valuesFromJSON(item)
+		}
+		return value
+	case json.Number:
+		return jsonNumberValue(value)
+	}
+	return value
+}
+
+// jsonNumberValue converts a JSON number to an int, a float64, or itself when it is an
+// integer beyond the int range
+func jsonNumberValue(n json.Number) interface{} {
+	if i, err := strconv.ParseInt(string(n), 10, 0); err == nil {
+		return int(i)
+	}
+	if !strings.ContainsAny(string(n), ".eE") {
+		return n
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return n
+}
+
+// ParseValuesDocuments decodes values payloads with ParseValues and merges them in order
+func ParseValuesDocuments(documents []string) (map[string]interface{}, error) {
+	parsed := make([]map[string]interface{}, 0, len(documents))
+	for i, document := range documents {
+		values, err := ParseValues(document)
+		if err != nil {
+			return nil, fmt.Errorf("values document %d: %v", i+1, err)
+		}
+		parsed = append(parsed, values)
+	}
+	return MergeValues(parsed...), nil
+}
+
+// MergeValues merges values documents in order, as helm -f base.yaml -f prod.yaml does: nested
+// objects are merged key by key, any other value (lists included) replaces the earlier one,
+// and a null value removes the key. The documents are not modified; see MergeVariables for
+// other strategies
+func MergeValues(documents ...map[string]interface{}) map[string]interface{} {
+	layers := make([]VariableLayer, len(documents))
+	for i, document := range documents {
+		layers[i] = VariableLayer{Values: document}
+	}
+	// Deep merges with replaced lists cannot fail
+	merged, _ := MergeVariables(layers, MergeOptions{})
+	return merged.Values
+}
+
+// valuesFromYAML converts decoded YAML to the shapes JSON decoding produces: mappings with
+// non-string keys get their keys printed, and timestamps become the strings they were written as
+func valuesFromYAML(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = valuesFromYAML(item)
+		}
+		return value
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = valuesFromYAML(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range value {
+			value[i] = valuesFromYAML(item)
+		}
+		return value
+	case time.Time:
+		if value.Equal(value.Truncate(24*time.Hour)) && value.Location() == time.UTC {
+			return value.Format("2006-01-02")
+		}
+		return value.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+// valueKind names the YAML kind of a decoded document for error messages
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case []interface{}:
+		return "list"
+	case string:
+		return "string"
+	}
+	return "scalar"
+}
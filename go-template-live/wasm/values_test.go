@@ -0,0 +1,167 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValues_YAML(t *testing.T) {
+	values, err := ParseValues(`
+app:
+  name: web
+  replicas: 3
+  ports: [80, 443]
+  release: 2024-01-02
+debug: true
+1: one
+`)
+	if err != nil {
+		t.Fatalf("ParseValues() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"app": map[string]interface{}{
+			"name":     "web",
+			"replicas": 3,
+			"ports":    []interface{}{80, 443},
+			"release":  "2024-01-02",
+		},
+		"debug": true,
+		"1":     "one",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("ParseValues() = %#v, want %#v", values, expected)
+	}
+}
+
+func TestParseValues_JSON(t *testing.T) {
+	values, err := ParseValues(`{"port": 8080, "tags": ["a"], "ratio": 0.5, "id": 9007199254740993, "big": 18446744073709551616}`)
+	if err != nil {
+		t.Fatalf("ParseValues() error = %v", err)
+	}
+
+	// Integers decode as ints like YAML ones; integers beyond the int range keep every digit
+	expected := map[string]interface{}{
+		"port":  8080,
+		"tags":  []interface{}{"a"},
+		"ratio": 0.5,
+		"id":    9007199254740993,
+		"big":   json.Number("18446744073709551616"),
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("ParseValues() = %#v, want %#v", values, expected)
+	}
+}
+
+func TestParseValues_Documents(t *testing.T) {
+	values, err := ParseValues("a: 1\nb: {c: 2}\n---\nb: {d: 3}\n")
+	if err != nil {
+		t.Fatalf("ParseValues() error = %v", err)
+	}
+
+	expected := map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 2, "d": 3}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("ParseValues() = %#v, want %#v", values, expected)
+	}
+}
+
+func TestParseValues_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "list", data: "- a\n- b\n", expected: "document 1 is a list"},
+		{name: "scalar", data: "a: 1\n---\nplain\n", expected: "document 2 is a string"},
+		{name: "syntax", data: "a: [1\n", expected: "invalid values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseValues(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("ParseValues() error = %v, want %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValuesErrorMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "json", data: `{"a": 1 "b": 2}`, expected: "Failed to parse variables JSON: invalid character '\"'"},
+		{name: "yaml", data: "a: [1\n", expected: "Failed to parse variables YAML: invalid values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseValues(tt.data)
+			if err == nil {
+				t.Fatal("ParseValues() error = nil")
+			}
+			if message := valuesErrorMessage(tt.data, err); !strings.HasPrefix(message, tt.expected) {
+				t.Errorf("valuesErrorMessage() = %q, want prefix %q", message, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+		"hosts":    []interface{}{"a", "b"},
+		"debug":    true,
+		"replicas": 1,
+	}
+	prod := map[string]interface{}{
+		"image":    map[string]interface{}{"tag": "1.27"},
+		"hosts":    []interface{}{"c"},
+		"debug":    nil,
+		"replicas": 3,
+	}
+
+	merged := MergeValues(base, prod)
+
+	expected := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+		"hosts":    []interface{}{"c"},
+		"replicas": 3,
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("MergeValues() = %#v, want %#v", merged, expected)
+	}
+	if base["image"].(map[string]interface{})["tag"] != "1.25" {
+		t.Error("MergeValues() modified its first document")
+	}
+}
+
+func TestParseValuesDocuments_Render(t *testing.T) {
+	values, err := ParseValuesDocuments([]string{
+		"server:\n  host: localhost\n  port: 80\n",
+		`{"server": {"port": 8443}}`,
+	})
+	if err != nil {
+		t.Fatalf("ParseValuesDocuments() error = %v", err)
+	}
+
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	result, err := renderer.Render("{{.server.host}}:{{.server.port}}", values, RenderOptions{})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if result.Output != "localhost:8443" {
+		t.Errorf("Render() = %q, want %q", result.Output, "localhost:8443")
+	}
+
+	if _, err := ParseValuesDocuments([]string{"a: 1", "- b"}); err == nil || !strings.Contains(err.Error(), "values document 2") {
+		t.Errorf("ParseValuesDocuments() error = %v, want values document 2", err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+)
+
+// variableTree nests aggregated variables by the segments of their dotted names
+// Nodes with children are objects; a node's variable is set when the name itself is used
+type variableTree struct {
+	variable *VariableInfo
+	children map[string]*variableTree
+	// order lists child names in order of first use
+	order []string
+}
+
+// buildVariableTree aggregates variables and nests them by dotted name
+func buildVariableTree(variables []VariableInfo) *variableTree {
+	root := &variableTree{children: make(map[string]*variableTree)}
+	aggregated := AggregateVariables(variables)
+	for i := range aggregated {
+		v := &aggregated[i]
+		node := root
+		for _, segment := range strings.Split(v.Name, ".") {
+			child, ok := node.children[segment]
+			if !ok {
+				child = &variableTree{children: make(map[string]*variableTree)}
+				node.children[segment] = child
+				node.order = append(node.order, segment)
+			}
+			node = child
+		}
+		node.variable = v
+	}
+	return root
+}
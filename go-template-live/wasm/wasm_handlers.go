@@ -5,66 +5,129 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"syscall/js"
-	"text/template"
+	"unsafe"
 )
 
 // WASMHandler handles WASM/JavaScript interface operations
 type WASMHandler struct {
-	parser *Parser
+	// env is shared by the parser and the renderer and configured by the Set handlers
+	env      *Environment
+	parser   *Parser
+	renderer *Renderer
+	tutorial *TutorialEngine
+	uploads  *templateUploads
+	sessions map[string]*RenderSession
+	live     *LiveTemplates
+	profiles *ValueProfiles
 }
 
 // NewWASMHandler creates a new WASM handler using the global registry
 func NewWASMHandler() *WASMHandler {
-	return &WASMHandler{
-		parser: NewParser(GetGlobalRegistry()),
+	h := &WASMHandler{
+		env:      NewEnvironment(),
+		parser:   NewParser(GetGlobalRegistry()),
+		renderer: NewRenderer(GetGlobalRegistry(), CreateRenderFuncMap),
+		uploads:  newTemplateUploads(),
+		sessions: make(map[string]*RenderSession),
 	}
+	h.parser.SetEnvironment(h.env)
+	h.renderer.SetEnvironment(h.env)
+	h.live = NewLiveTemplates(h.parser, h.renderer)
+	h.profiles = NewValueProfiles()
+	return h
 }
 
 // ExtractVariables extracts variables with default values - main function exposed to JavaScript
+// Arguments: template content, file name (optional), schema version (optional, defaults to v1),
+// extract options JSON (optional)
 func (h *WASMHandler) ExtractVariables(this js.Value, args []js.Value) interface{} {
 	if len(args) < 1 {
 		return jsError("Missing template content parameter")
 	}
 
-	templateContent := args[0].String()
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
 	fileName := "template.tmpl"
 	if len(args) > 1 {
 		fileName = args[1].String()
 	}
-
-	variables, err := h.parser.ExtractVariablesWithDefaults(fileName, templateContent)
+	schemaVersion, err := schemaVersionArg(args, 2)
 	if err != nil {
+		return jsError("Invalid schema version: " + err.Error())
+	}
+
+	opts := DefaultExtractOptions()
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse extract options JSON: " + err.Error())
+		}
+	}
+
+	// Positions are dropped by the v1 schema when marshalling
+	page, err := h.parser.ExtractVariablesPage(fileName, templateContent, opts)
+	var extractionErrors *ExtractionErrors
+	if err != nil && !errors.As(err, &extractionErrors) {
 		return jsError("Failed to extract variables: " + err.Error())
 	}
+	var variables []VariableInfo
+	if page != nil {
+		variables = page.Variables
+	}
 
-	jsonData, err := json.Marshal(variables)
+	jsonData, err := MarshalVariables(variables, schemaVersion)
 	if err != nil {
 		return jsError("Failed to marshal variables to JSON: " + err.Error())
 	}
 
+	// Under the collectAll policy partial results come with the list of failures, and a page
+	// comes with the number of variables across all pages
+	paged := opts.Offset > 0 || opts.Limit > 0
+	if opts.ErrorPolicy == ErrorPolicyCollectAll || paged {
+		result := map[string]interface{}{"variables": json.RawMessage(jsonData)}
+		if opts.ErrorPolicy == ErrorPolicyCollectAll {
+			result["errors"] = []ExtractError{}
+			if extractionErrors != nil {
+				result["errors"] = extractionErrors.Errors
+			}
+		}
+		if paged && page != nil {
+			result["total"] = page.Total
+		}
+		if jsonData, err = json.Marshal(result); err != nil {
+			return jsError("Failed to marshal variables to JSON: " + err.Error())
+		}
+	}
+
 	return js.ValueOf(string(jsonData))
 }
 
-// ExtractVariablesSimple returns only variable names (without defaults)
-func (h *WASMHandler) ExtractVariablesSimple(this js.Value, args []js.Value) interface{} {
+// ExtractVariablesAggregated returns each variable once with a usage count and all occurrence positions
+func (h *WASMHandler) ExtractVariablesAggregated(this js.Value, args []js.Value) interface{} {
 	if len(args) < 1 {
 		return jsError("Missing template content parameter")
 	}
 
-	templateContent := args[0].String()
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
 	fileName := "template.tmpl"
 	if len(args) > 1 {
 		fileName = args[1].String()
 	}
 
-	variables, err := h.parser.ExtractVariables(fileName, templateContent)
+	variables, err := h.parser.ExtractVariablesAggregated(fileName, templateContent)
 	if err != nil {
 		return jsError("Failed to extract variables: " + err.Error())
 	}
 
-	jsonData, err := json.Marshal(variables)
+	jsonData, err := MarshalVariables(variables, LatestSchemaVersion)
 	if err != nil {
 		return jsError("Failed to marshal variables to JSON: " + err.Error())
 	}
@@ -72,50 +135,2077 @@ func (h *WASMHandler) ExtractVariablesSimple(this js.Value, args []js.Value) int
 	return js.ValueOf(string(jsonData))
 }
 
-// RenderTemplate renders a template with provided variable values
-func (h *WASMHandler) RenderTemplate(this js.Value, args []js.Value) interface{} {
+// GenerateTypes returns a TypeScript interface for the values a template needs
+// Arguments: template content, file name (optional), interface name (optional)
+func (h *WASMHandler) GenerateTypes(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+	interfaceName := ""
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		interfaceName = args[2].String()
+	}
+
+	variables, err := h.parser.ExtractVariablesWithPositions(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	types, err := GenerateTypeScript(interfaceName, variables)
+	if err != nil {
+		return jsError("Failed to generate types: " + err.Error())
+	}
+
+	return js.ValueOf(types)
+}
+
+// GenerateFormSchema returns a JSON {schema, uiSchema} form definition for a template's values
+// Arguments: template content, file name (optional)
+func (h *WASMHandler) GenerateFormSchema(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	variables, err := h.parser.ExtractVariablesWithPositions(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(GenerateFormSchema(variables))
+	if err != nil {
+		return jsError("Failed to marshal form schema to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// GenerateSampleValues returns a JSON values object that renders the template as is
+// Arguments: template content, file name (optional)
+func (h *WASMHandler) GenerateSampleValues(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	variables, err := h.parser.ExtractVariablesWithPositions(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	jsonData, err := json.MarshalIndent(GenerateSampleValues(variables), "", "  ")
+	if err != nil {
+		return jsError("Failed to marshal sample values to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// ValidateValues checks a JSON values object against the variables a template uses
+// Arguments: template content, variables JSON, file name (optional)
+// Returns JSON {valid, missing, unused, mismatches}
+func (h *WASMHandler) ValidateValues(this js.Value, args []js.Value) interface{} {
 	if len(args) < 2 {
 		return jsError("Missing template content or variables parameter")
 	}
 
-	templateContent := args[0].String()
-	variablesJSON := args[1].String()
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	values, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+	fileName := "template.tmpl"
+	if len(args) > 2 {
+		fileName = args[2].String()
+	}
+
+	variables, err := h.parser.ExtractVariablesWithPositions(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(ValidateValues(variables, values))
+	if err != nil {
+		return jsError("Failed to marshal validation result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// SaveValueProfile stores values under a profile name such as prod-eu, replacing any profile of that name
+// Arguments: profile name, variables (JSON or YAML)
+func (h *WASMHandler) SaveValueProfile(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing profile name or variables parameter")
+	}
 
-	var variables map[string]interface{}
-	err := json.Unmarshal([]byte(variablesJSON), &variables)
+	values, err := ParseValues(args[1].String())
 	if err != nil {
-		return jsError("Failed to parse variables JSON: " + err.Error())
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+	if err := h.profiles.Save(args[0].String(), values); err != nil {
+		return jsError("Failed to save value profile: " + err.Error())
 	}
+	return js.ValueOf(true)
+}
 
-	// Start with minimal function map for parsing
-	funcs := h.parser.registry.GetMinimalFuncMap()
+// LoadValueProfile returns the values of a profile validated against a template
+// Arguments: profile name, template content, file name (optional)
+// Returns JSON {values, validation: {valid, missing, unused, mismatches}}
+func (h *WASMHandler) LoadValueProfile(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing profile name or template content parameter")
+	}
 
-	// Add render-specific function implementations
-	// This is provided by either functions_custom.go or functions_official.go
-	renderFuncs := CreateRenderFuncMap(variables)
-	for name, fn := range renderFuncs {
-		funcs[name] = fn
+	templateContent, err := h.templateArg(args[1])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 2 {
+		fileName = args[2].String()
+	}
+	variables, err := h.parser.ExtractVariablesWithPositions(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+	values, validation, err := h.profiles.Load(args[0].String(), variables)
+	if err != nil {
+		return jsError("Failed to load value profile: " + err.Error())
 	}
 
-	tmpl, err := template.New("template").Funcs(funcs).Parse(templateContent)
+	jsonData, err := json.Marshal(map[string]interface{}{"values": values, "validation": validation})
 	if err != nil {
-		return jsError("Failed to parse template: " + err.Error())
+		return jsError("Failed to marshal value profile to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// DeleteValueProfile removes a profile, returning whether it existed
+func (h *WASMHandler) DeleteValueProfile(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return js.ValueOf(false)
 	}
+	return js.ValueOf(h.profiles.Delete(args[0].String()))
+}
 
-	var result strings.Builder
-	err = tmpl.Execute(&result, variables)
+// ListValueProfiles returns the JSON array of profile names, sorted
+func (h *WASMHandler) ListValueProfiles(this js.Value, args []js.Value) interface{} {
+	jsonData, err := json.Marshal(h.profiles.Names())
 	if err != nil {
-		return jsError("Failed to execute template: " + err.Error())
+		return jsError("Failed to marshal value profiles to JSON: " + err.Error())
 	}
 
-	return js.ValueOf(result.String())
+	return js.ValueOf(string(jsonData))
 }
 
-// RegisterCallbacks registers the Go functions to be called from JavaScript
-func (h *WASMHandler) RegisterCallbacks() {
-	js.Global().Set("extractTemplateVariables", js.FuncOf(h.ExtractVariables))
-	js.Global().Set("extractTemplateVariablesSimple", js.FuncOf(h.ExtractVariablesSimple))
-	js.Global().Set("renderTemplateWithValues", js.FuncOf(h.RenderTemplate))
+// ValueProfileCoverage reports which variables of a template each profile provides
+// Arguments: template content, file name (optional)
+// Returns JSON [{profile, covered, uncovered, valid}] in profile name order
+func (h *WASMHandler) ValueProfileCoverage(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+	variables, err := h.parser.ExtractVariablesWithPositions(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(h.profiles.Coverage(variables))
+	if err != nil {
+		return jsError("Failed to marshal value profile coverage to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// ExportValueProfiles returns every profile as JSON, for keeping them in browser storage
+func (h *WASMHandler) ExportValueProfiles(this js.Value, args []js.Value) interface{} {
+	var b strings.Builder
+	if err := h.profiles.Write(&b); err != nil {
+		return jsError("Failed to export value profiles: " + err.Error())
+	}
+	return js.ValueOf(b.String())
+}
+
+// ImportValueProfiles replaces the profiles with those of exportValueProfiles JSON
+func (h *WASMHandler) ImportValueProfiles(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing value profiles parameter")
+	}
+
+	profiles, err := ReadValueProfiles(strings.NewReader(args[0].String()))
+	if err != nil {
+		return jsError("Failed to import value profiles: " + err.Error())
+	}
+	h.profiles = profiles
+	return js.ValueOf(true)
+}
+
+// ExtractMetadata returns the JSON {owner, team, tags} annotations of a template
+// Arguments: template content, file name (optional)
+func (h *WASMHandler) ExtractMetadata(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	metadata, err := h.parser.ExtractMetadata(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract metadata: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(metadata)
+	if err != nil {
+		return jsError("Failed to marshal metadata to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// ExtractKeyTree returns the confd keys a template reads, nested by path segment
+// Arguments: template content, file name (optional)
+// Returns JSON array of {name, key, variable, defaultValue, children}
+func (h *WASMHandler) ExtractKeyTree(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	variables, err := h.parser.ExtractVariablesWithPositions(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(BuildKeyTree(variables))
+	if err != nil {
+		return jsError("Failed to marshal key tree to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// AnalyzeTrimMarkers reports control actions alone on their line that leave blank lines
+// Arguments: template content, file name (optional)
+// Returns JSON array of {line, column, action, fix}
+func (h *WASMHandler) AnalyzeTrimMarkers(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	issues, err := h.parser.AnalyzeTrimMarkers(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to analyze trim markers: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(issues)
+	if err != nil {
+		return jsError("Failed to marshal trim marker issues to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// GetCompletions returns the autocomplete candidates at the cursor
+// Arguments: template content, cursor byte offset
+// Returns JSON {from, prefix, items: [{label, kind, detail, documentation}]}
+func (h *WASMHandler) GetCompletions(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing template content or offset parameter")
+	}
+	if args[1].Type() != js.TypeNumber {
+		return jsError("Offset must be a number")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(h.parser.Completions(templateContent, args[1].Int()))
+	if err != nil {
+		return jsError("Failed to marshal completions to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// AnalyzeTemplate reports the parse, extraction, lint and, with values, values and render
+// problems of a template as Language Server Protocol diagnostics
+// Arguments: template content, options JSON (optional) {lint: lint config, values,
+// renderOptions}, file name (optional)
+// Returns JSON array of {range: {start: {line, character}, end}, severity, code, source, message}
+func (h *WASMHandler) AnalyzeTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	var opts AnalyzeOptions
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+			return jsError("Failed to parse analyze options JSON: " + err.Error())
+		}
+	}
+	fileName := "template.tmpl"
+	if len(args) > 2 && args[2].Type() == js.TypeString && args[2].String() != "" {
+		fileName = args[2].String()
+	}
+
+	diagnostics, err := Analyze(h.parser, h.renderer, fileName, templateContent, opts)
+	if err != nil {
+		return jsError("Failed to analyze template: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(diagnostics)
+	if err != nil {
+		return jsError("Failed to marshal diagnostics to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// GetFoldingRanges returns the blocks and comment sections of a template the editor can collapse
+// Arguments: template content, file name (optional)
+// Returns JSON array of {startLine, endLine, startOffset, endOffset, kind: "region"|"comment", keyword}
+func (h *WASMHandler) GetFoldingRanges(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		fileName = args[1].String()
+	}
+
+	ranges, err := FoldingRanges(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to compute folding ranges: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(ranges)
+	if err != nil {
+		return jsError("Failed to marshal folding ranges to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// GenerateEditorGrammar returns a highlighting grammar whose function lists are the registered
+// functions of the active profile
+// Arguments: format "monarch" or "textmate" (optional, defaults to monarch)
+// Returns JSON Monarch language definition or TextMate grammar
+func (h *WASMHandler) GenerateEditorGrammar(this js.Value, args []js.Value) interface{} {
+	format := GrammarMonarch
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		format = args[0].String()
+	}
+
+	grammar, err := GenerateGrammar(h.parser.registry, format)
+	if err != nil {
+		return jsError("Failed to generate grammar: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(grammar)
+	if err != nil {
+		return jsError("Failed to marshal grammar to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// GetFunctionSnippets returns an editor snippet for each registered function
+// Returns JSON array of {name, snippet, detail, description}
+func (h *WASMHandler) GetFunctionSnippets(this js.Value, args []js.Value) interface{} {
+	jsonData, err := json.Marshal(FunctionSnippets(h.parser.registry))
+	if err != nil {
+		return jsError("Failed to marshal function snippets to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// FindDefinition returns where the template or $variable under the cursor is defined
+// Arguments: template content, cursor byte offset, file name (optional)
+// Returns JSON {kind: "template"|"variable", name, file, position} (position omitted when no
+// definition is found), or null when the cursor is on neither
+func (h *WASMHandler) FindDefinition(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing template content or offset parameter")
+	}
+	if args[1].Type() != js.TypeNumber {
+		return jsError("Offset must be a number")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 2 && args[2].Type() == js.TypeString && args[2].String() != "" {
+		fileName = args[2].String()
+	}
+
+	definition, err := h.parser.FindDefinition(fileName, templateContent, args[1].Int())
+	if err != nil {
+		return jsError("Failed to find definition: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(definition)
+	if err != nil {
+		return jsError("Failed to marshal definition to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// FormatTemplate rewrites a template, e.g. adding the trim markers AnalyzeTrimMarkers reports
+// Arguments: template content, options JSON (optional) {"fixTrimMarkers": bool,
+// "normalizeSpacing": bool, "quoteStyle": "double"|"raw", "indentBlocks": bool}
+// Returns the formatted template
+func (h *WASMHandler) FormatTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	var opts FormatOptions
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+			return jsError("Failed to parse format options JSON: " + err.Error())
+		}
+	}
+
+	formatted, err := h.parser.FormatTemplate("template.tmpl", templateContent, opts)
+	if err != nil {
+		return jsError("Failed to format template: " + err.Error())
+	}
+
+	return js.ValueOf(formatted)
+}
+
+// AnalyzeProfileDivergence reports function calls that behave differently between profiles
+// Arguments: template content, profiles JSON array (optional, defaults to every profile)
+// Returns JSON array of {function, position, behaviors: {profile: behavior}}
+func (h *WASMHandler) AnalyzeProfileDivergence(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	var profiles []string
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		if err := json.Unmarshal([]byte(args[1].String()), &profiles); err != nil {
+			return jsError("Failed to parse profiles JSON: " + err.Error())
+		}
+	}
+
+	divergences, err := AnalyzeProfileDivergence("template.tmpl", templateContent, profiles)
+	if err != nil {
+		return jsError("Failed to analyze profiles: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(divergences)
+	if err != nil {
+		return jsError("Failed to marshal profile divergences to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// LintTemplate runs the lint rules over a template
+// Arguments: template content, lint config JSON or YAML (optional) {rules: {rule: "off"|"error"|
+// "warning"|"info"}, maxNesting, deprecated: {function: replacement}}, file name (optional)
+// Returns JSON array of {rule, severity, message, position}
+func (h *WASMHandler) LintTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	var config LintConfig
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		if config, err = ParseLintConfig(args[1].String()); err != nil {
+			return jsError("Failed to parse lint config: " + err.Error())
+		}
+	}
+	fileName := "template.tmpl"
+	if len(args) > 2 && args[2].Type() == js.TypeString && args[2].String() != "" {
+		fileName = args[2].String()
+	}
+
+	linter, err := NewLinter(config)
+	if err != nil {
+		return jsError("Invalid lint config: " + err.Error())
+	}
+	diagnostics, err := linter.Lint(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to lint template: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(diagnostics)
+	if err != nil {
+		return jsError("Failed to marshal lint diagnostics to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// Templatize converts a plain config file into a template scaffold
+// Arguments: config file content, options JSON (optional) {hints: {value: key}, heuristics,
+// prefix, keepDefaults}
+// Returns JSON {template, variables: [{key, value, kind, line}]}
+func (h *WASMHandler) Templatize(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return jsError("Missing config content parameter")
+	}
+
+	var opts TemplatizeOptions
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+			return jsError("Failed to parse templatize options: " + err.Error())
+		}
+	}
+
+	result, err := Templatize(args[0].String(), opts)
+	if err != nil {
+		return jsError("Failed to templatize config: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal templatize result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// LintVariableNames checks variable names against naming rules
+// Arguments: template content, rules JSON {snakeCase, maxDepth, reservedPrefixes}
+// Returns JSON array of {name, rule, message, position, fix}
+func (h *WASMHandler) LintVariableNames(this js.Value, args []js.Value) interface{} {
+	templateContent, rules, errValue := h.namingArgs(args)
+	if errValue != nil {
+		return errValue
+	}
+
+	violations, err := h.parser.LintNames("template.tmpl", templateContent, rules)
+	if err != nil {
+		return jsError("Failed to lint variable names: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(violations)
+	if err != nil {
+		return jsError("Failed to marshal naming violations to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// FixVariableNames renames variable references to their canonical names
+// Arguments: template content, rules JSON {snakeCase, maxDepth, reservedPrefixes}
+// Returns JSON {content, mapping: {old: new}}
+func (h *WASMHandler) FixVariableNames(this js.Value, args []js.Value) interface{} {
+	templateContent, rules, errValue := h.namingArgs(args)
+	if errValue != nil {
+		return errValue
+	}
+
+	fix, err := h.parser.FixNames("template.tmpl", templateContent, rules)
+	if err != nil {
+		return jsError("Failed to fix variable names: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(fix)
+	if err != nil {
+		return jsError("Failed to marshal naming fix to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RenameKey renames a key, and the keys below it, in the references of a template
+// Arguments: template content, old key, new key
+// Returns JSON {content, references}
+func (h *WASMHandler) RenameKey(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("Missing template content, old key or new key parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+
+	rename, err := h.parser.RenameKey("template.tmpl", templateContent, args[1].String(), args[2].String())
+	if err != nil {
+		return jsError("Failed to rename key: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(rename)
+	if err != nil {
+		return jsError("Failed to marshal key rename to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// namingArgs reads the template content and naming rules arguments
+func (h *WASMHandler) namingArgs(args []js.Value) (string, NamingRules, interface{}) {
+	var rules NamingRules
+	if len(args) < 1 {
+		return "", rules, jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return "", rules, jsError("Invalid template content: " + err.Error())
+	}
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		if err := json.Unmarshal([]byte(args[1].String()), &rules); err != nil {
+			return "", rules, jsError("Failed to parse naming rules JSON: " + err.Error())
+		}
+	}
+	return templateContent, rules, nil
+}
+
+// AuditSecrets lists every use of the variables a template reads with secret
+// Arguments: template content, file name (optional)
+// Returns JSON array of {name, function, position}
+func (h *WASMHandler) AuditSecrets(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	references, err := h.parser.AuditSecrets(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to audit secrets: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(references)
+	if err != nil {
+		return jsError("Failed to marshal secret references to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// AnonymizeTemplate rewrites a template for sharing in bug reports, hashing names and text
+// Arguments: template content, salt (optional)
+// Returns the anonymized template
+func (h *WASMHandler) AnonymizeTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	salt := ""
+	if len(args) > 1 && args[1].Type() == js.TypeString {
+		salt = args[1].String()
+	}
+
+	anonymized, err := h.parser.AnonymizeTemplate("template.tmpl", templateContent, salt)
+	if err != nil {
+		return jsError("Failed to anonymize template: " + err.Error())
+	}
+
+	return js.ValueOf(anonymized)
+}
+
+// GenerateChangelog summarizes a template edit for a pull request description
+// Arguments: old template, new template, old variables JSON (optional), new variables JSON (optional)
+// Returns JSON Changelog with a markdown field
+func (h *WASMHandler) GenerateChangelog(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing old or new template parameter")
+	}
+
+	var revisions [2]TemplateRevision
+	for i := range revisions {
+		content, err := h.templateArg(args[i])
+		if err != nil {
+			return jsError("Invalid template content: " + err.Error())
+		}
+		revisions[i].Content = content
+		if len(args) > i+2 && args[i+2].Type() == js.TypeString {
+			if revisions[i].Values, err = ParseValues(args[i+2].String()); err != nil {
+				return jsError(valuesErrorMessage(args[i+2].String(), err))
+			}
+		}
+	}
+
+	changelog, err := GenerateChangelog(h.parser, h.renderer, revisions[0], revisions[1])
+	if err != nil {
+		return jsError("Failed to generate changelog: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(changelog)
+	if err != nil {
+		return jsError("Failed to marshal changelog to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// ExtractVariablesSimple returns only variable names (without defaults)
+func (h *WASMHandler) ExtractVariablesSimple(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	variables, err := h.parser.ExtractVariables(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(variables)
+	if err != nil {
+		return jsError("Failed to marshal variables to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RenderTemplate renders a template with provided variable values
+func (h *WASMHandler) RenderTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing template content or variables parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	variables, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+
+	result, err := h.renderer.Render(templateContent, variables, RenderOptions{})
+	if err != nil {
+		// Keep the messages renderTemplateWithValues has always returned, which callers match on
+		var renderErr *RenderError
+		switch {
+		case errors.As(err, &renderErr) && renderErr.Stage == RenderStageParse:
+			return jsError("Failed to parse template: " + renderErr.Err.Error())
+		case errors.As(err, &renderErr) && renderErr.Stage == RenderStageExecute:
+			return jsError("Failed to execute template: " + renderErr.Err.Error())
+		}
+		return jsError("Failed to render template: " + err.Error())
+	}
+
+	return js.ValueOf(result.Output)
+}
+
+// RenderTemplateWithOptions renders a template with render options and returns a JSON RenderResult
+// Arguments: template content, variables JSON, options JSON (optional)
+func (h *WASMHandler) RenderTemplateWithOptions(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing template content or variables parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	variables, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+
+	var opts RenderOptions
+	if len(args) > 2 && args[2].Type() == js.TypeString && args[2].String() != "" {
+		if err := json.Unmarshal([]byte(args[2].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	result, err := h.renderer.Render(templateContent, variables, opts)
+	if err != nil {
+		errResult := jsError("Failed to render template: " + err.Error())
+		if result != nil && len(result.MissingKeys) > 0 {
+			missingKeys := make([]interface{}, len(result.MissingKeys))
+			for i, key := range result.MissingKeys {
+				missingKeys[i] = key
+			}
+			errResult["missingKeys"] = missingKeys
+		}
+		return errResult
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal render result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RecordSnapshot renders a template and returns its output, to be stored as the snapshot
+// that checkSnapshot compares later renders with
+// Arguments: template content, variables JSON or YAML, render options JSON (optional)
+func (h *WASMHandler) RecordSnapshot(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing template content or variables parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	variables, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+	var opts RenderOptions
+	if len(args) > 2 && args[2].Type() == js.TypeString && args[2].String() != "" {
+		if err := json.Unmarshal([]byte(args[2].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	output, err := h.renderer.RecordSnapshot(templateContent, variables, opts)
+	if err != nil {
+		return jsError("Failed to render template: " + err.Error())
+	}
+
+	return js.ValueOf(output)
+}
+
+// CheckSnapshot renders a template and compares the output with a recorded snapshot
+// Arguments: template content, variables JSON or YAML, expected output, render options JSON (optional)
+// Returns JSON {passed, output, diff, stats, error}
+func (h *WASMHandler) CheckSnapshot(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("Missing template content, variables or expected output parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	variables, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+	var opts RenderOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	jsonData, err := json.Marshal(h.renderer.CheckSnapshot(templateContent, variables, args[2].String(), opts))
+	if err != nil {
+		return jsError("Failed to marshal snapshot result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// InferValues solves a template for the values that render a sample of its output
+// Arguments: template content, rendered sample
+// Returns JSON {matched, mismatch, mismatchPosition, values, inferred: [{name, value, confidence,
+// occurrences, position, note}], verified}
+func (h *WASMHandler) InferValues(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing template content or rendered sample parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	result, err := h.renderer.InferValues(templateContent, args[1].String())
+	if err != nil {
+		return jsError("Failed to infer values: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal inferred values to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// MergeValues merges values files in order, later files winning (as helm -f base.yaml -f prod.yaml)
+// Argument: JSON array of values documents, each a JSON object or YAML string
+// Returns the merged values JSON, ready for renderTemplateWithValues
+func (h *WASMHandler) MergeValues(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing values documents parameter")
+	}
+
+	var documents []string
+	if err := json.Unmarshal([]byte(args[0].String()), &documents); err != nil {
+		return jsError("Failed to parse values documents JSON: " + err.Error())
+	}
+	values, err := ParseValuesDocuments(documents)
+	if err != nil {
+		return jsError("Failed to parse variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(values)
+	if err != nil {
+		return jsError("Failed to marshal values to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// MergeVariables merges named layers of values, reporting which layer each value came from
+// Arguments: layers JSON [{name, values}, ...] where values is an object or a JSON/YAML string,
+// merge options JSON {strategy: "deep"|"override"|"error", lists: "replace"|"append"} (optional),
+// template content (optional) whose extracted defaults are merged first as the "defaults" layer
+// Returns JSON {values, sources: {path: layer}}
+func (h *WASMHandler) MergeVariables(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing layers parameter")
+	}
+
+	var rawLayers []struct {
+		Name   string          `json:"name"`
+		Values json.RawMessage `json:"values"`
+	}
+	if err := json.Unmarshal([]byte(args[0].String()), &rawLayers); err != nil {
+		return jsError("Failed to parse layers JSON: " + err.Error())
+	}
+	var opts MergeOptions
+	if len(args) > 1 && args[1].Type() == js.TypeString && args[1].String() != "" {
+		if err := json.Unmarshal([]byte(args[1].String()), &opts); err != nil {
+			return jsError("Failed to parse merge options JSON: " + err.Error())
+		}
+	}
+
+	layers := make([]VariableLayer, 0, len(rawLayers)+1)
+	if len(args) > 2 && args[2].Type() == js.TypeString && args[2].String() != "" {
+		templateContent, err := h.templateArg(args[2])
+		if err != nil {
+			return jsError("Invalid template content: " + err.Error())
+		}
+		defaults, err := h.renderer.DefaultValues(templateContent)
+		if err != nil {
+			return jsError("Failed to extract defaults: " + err.Error())
+		}
+		layers = append(layers, VariableLayer{Name: "defaults", Values: defaults})
+	}
+	for i, raw := range rawLayers {
+		document := string(raw.Values)
+		var text string
+		if json.Unmarshal(raw.Values, &text) == nil {
+			document = text
+		}
+		values, err := ParseValues(document)
+		if err != nil {
+			return jsError(fmt.Sprintf("Failed to parse layer %d: %v", i+1, err))
+		}
+		layers = append(layers, VariableLayer{Name: raw.Name, Values: values})
+	}
+
+	merged, err := MergeVariables(layers, opts)
+	if err != nil {
+		return jsError("Failed to merge variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(merged)
+	if err != nil {
+		return jsError("Failed to marshal merged variables to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RenderEnvironments renders a template for each environment, its overlay merged over the base values
+// Arguments: template content, base variables (JSON or YAML), overlays JSON {env: values, ...}
+// where each value is an object or a JSON/YAML string, render options JSON (optional)
+// Returns JSON {env: {output, missingKeys, warnings, error}, ...}
+func (h *WASMHandler) RenderEnvironments(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("Missing template content, base variables or overlays parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	base, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+	var rawOverlays map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(args[2].String()), &rawOverlays); err != nil {
+		return jsError("Failed to parse overlays JSON: " + err.Error())
+	}
+	overlays := make(map[string]map[string]interface{}, len(rawOverlays))
+	for env, raw := range rawOverlays {
+		document := string(raw)
+		var text string
+		if json.Unmarshal(raw, &text) == nil {
+			document = text
+		}
+		if overlays[env], err = ParseValues(document); err != nil {
+			return jsError("Failed to parse overlay " + env + ": " + err.Error())
+		}
+	}
+	var opts RenderOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	jsonData, err := json.Marshal(h.renderer.RenderEnvironments(templateContent, base, overlays, opts))
+	if err != nil {
+		return jsError("Failed to marshal environment results to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RenderMatrix renders a template for every combination of dimension values
+// Arguments: template content, base variables (JSON or YAML), dimensions JSON
+// [{name, values: [...]}, ...] (names may be dotted paths), render options JSON (optional)
+// Returns JSON [{values: {name: value}, output, missingKeys, warnings, error}] with the last
+// dimension varying fastest
+func (h *WASMHandler) RenderMatrix(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("Missing template content, base variables or dimensions parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	base, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+	var dimensions []MatrixDimension
+	if err := json.Unmarshal([]byte(args[2].String()), &dimensions); err != nil {
+		return jsError("Failed to parse dimensions JSON: " + err.Error())
+	}
+	var opts RenderOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	cells, err := h.renderer.RenderMatrix(templateContent, base, dimensions, opts)
+	if err != nil {
+		return jsError("Failed to render matrix: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(cells)
+	if err != nil {
+		return jsError("Failed to marshal matrix to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// BuildArchive packages rendered files as a zip archive for a "Download all" action
+// Argument: files JSON [{path, content}, ...], e.g. project outputs with their destinations
+// Returns the archive as a Uint8Array
+func (h *WASMHandler) BuildArchive(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing files parameter")
+	}
+
+	var files []ArchiveFile
+	if err := json.Unmarshal([]byte(args[0].String()), &files); err != nil {
+		return jsError("Failed to parse files JSON: " + err.Error())
+	}
+	data, err := BuildArchive(files)
+	if err != nil {
+		return jsError("Failed to build archive: " + err.Error())
+	}
+	return bytesToJS(data)
+}
+
+// EvalExpression evaluates a single pipeline, for the editor's "evaluate selection"
+// Arguments: pipeline (e.g. add (atoi .port) 1), variables JSON, render options JSON (optional)
+// Returns JSON {value, type, goType, output}
+func (h *WASMHandler) EvalExpression(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing pipeline or variables parameter")
+	}
+
+	variables, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+	var opts RenderOptions
+	if len(args) > 2 && args[2].Type() == js.TypeString && args[2].String() != "" {
+		if err := json.Unmarshal([]byte(args[2].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	result, err := h.renderer.Evaluate(args[0].String(), variables, opts)
+	if err != nil {
+		return jsError("Failed to evaluate expression: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal evaluation result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// CreateRenderSession starts a named live preview, replacing any session of the same name
+// Arguments: session name, template content, variables JSON, render options JSON (optional)
+// Returns the JSON RenderResult of the first render
+func (h *WASMHandler) CreateRenderSession(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("Missing session name, template content or variables parameter")
+	}
+
+	templateContent, err := h.templateArg(args[1])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	variables, err := ParseValues(args[2].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[2].String(), err))
+	}
+	var opts RenderOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	name := args[0].String()
+	session, result, err := NewRenderSession(h.parser, h.renderer, name, templateContent, variables, opts)
+	if err != nil {
+		return jsError("Failed to create render session: " + err.Error())
+	}
+	h.sessions[name] = session
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal render result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// UpdateRenderSession applies value changes to a session and returns the changed output regions
+// Arguments: session name, changes JSON {key or dotted path: value, ...} (null removes a key)
+// Returns JSON {revision, rendered, regions: [{start, end, line, text}], missingKeys, warnings}
+func (h *WASMHandler) UpdateRenderSession(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing session name or changes parameter")
+	}
+
+	session, ok := h.sessions[args[0].String()]
+	if !ok {
+		return jsError("Unknown render session: " + args[0].String())
+	}
+	var changes map[string]interface{}
+	if err := json.Unmarshal([]byte(args[1].String()), &changes); err != nil {
+		return jsError("Failed to parse changes JSON: " + err.Error())
+	}
+
+	update, err := session.Update(changes)
+	if err != nil {
+		return jsError("Failed to render template: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(update)
+	if err != nil {
+		return jsError("Failed to marshal session update to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// CloseRenderSession discards a session
+func (h *WASMHandler) CloseRenderSession(this js.Value, args []js.Value) interface{} {
+	if len(args) > 0 {
+		delete(h.sessions, args[0].String())
+	}
+	return js.Null()
+}
+
+// RegisterLiveTemplate renders a template and keeps it live: updateVariables(id, patch)
+// re-renders it and calls callback(output, updateJSON), where updateJSON is {id, revision,
+// rendered, regions, missingKeys, warnings, output, diff: [{op, text}], error}
+// Arguments: template content, callback, variables JSON (optional), render options JSON (optional)
+// Returns JSON {id, result} with the subscription id and the RenderResult of the first render
+func (h *WASMHandler) RegisterLiveTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing template content or callback parameter")
+	}
+	if args[1].Type() != js.TypeFunction {
+		return jsError("Callback must be a function")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	variables := map[string]interface{}{}
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		if variables, err = ParseValues(args[2].String()); err != nil {
+			return jsError(valuesErrorMessage(args[2].String(), err))
+		}
+	}
+	var opts RenderOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	callback := args[1]
+	id, result, err := h.live.Register(templateContent, variables, opts, func(update *LiveUpdate) {
+		jsonData, err := json.Marshal(update)
+		if err != nil {
+			return
+		}
+		callback.Invoke(update.Output, string(jsonData))
+	})
+	if err != nil {
+		return jsError("Failed to register live template: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{"id": id, "result": result})
+	if err != nil {
+		return jsError("Failed to marshal render result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// UpdateVariables applies a variables patch to a live template, calling its callback when the
+// output is re-rendered or rendering fails
+// Arguments: subscription id, patch JSON {key or dotted path: value, ...} (null removes a key)
+// Returns the update JSON passed to the callback
+func (h *WASMHandler) UpdateVariables(this js.Value, args []js.Value) (result interface{}) {
+	if len(args) < 2 {
+		return jsError("Missing subscription id or patch parameter")
+	}
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal([]byte(args[1].String()), &patch); err != nil {
+		return jsError("Failed to parse patch JSON: " + err.Error())
+	}
+
+	defer func() {
+		// A callback that throws must not take the WASM instance down
+		if recovered := recover(); recovered != nil {
+			result = jsError(fmt.Sprintf("Live template callback failed: %v", recovered))
+		}
+	}()
+	update, err := h.live.UpdateVariables(args[0].String(), patch)
+	if err != nil {
+		return jsError(err.Error())
+	}
+
+	jsonData, err := json.Marshal(update)
+	if err != nil {
+		return jsError("Failed to marshal live update to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// UnregisterLiveTemplate stops a live template; its callback is no longer called
+func (h *WASMHandler) UnregisterLiveTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return js.ValueOf(false)
+	}
+	return js.ValueOf(h.live.Unregister(args[0].String()))
+}
+
+// ExtractProjectVariables extracts the aggregated variables of a project entry point
+// Arguments: project JSON {"templates": {name: content, ...}}, entry point name
+// Returns JSON {variables, references: {includes, missing}}
+func (h *WASMHandler) ExtractProjectVariables(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing project or entry point parameter")
+	}
+
+	project, err := h.projectArg(args[0])
+	if err != nil {
+		return jsError("Failed to parse project: " + err.Error())
+	}
+	entry := args[1].String()
+
+	references, err := project.References(entry)
+	if err != nil {
+		return jsError("Failed to resolve template references: " + err.Error())
+	}
+	variables, err := project.ExtractVariables(entry)
+	if err != nil {
+		return jsError("Failed to extract variables: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"variables":  variables,
+		"references": references,
+	})
+	if err != nil {
+		return jsError("Failed to marshal project variables to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RenderProject renders a project entry point and returns a JSON RenderResult
+// Arguments: project JSON {"templates": {name: content, ...}}, entry point name, variables JSON,
+// options JSON (optional)
+func (h *WASMHandler) RenderProject(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("Missing project, entry point or variables parameter")
+	}
+
+	project, err := h.projectArg(args[0])
+	if err != nil {
+		return jsError("Failed to parse project: " + err.Error())
+	}
+
+	variables, err := ParseValues(args[2].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[2].String(), err))
+	}
+	var opts RenderOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	result, err := project.Render(args[1].String(), variables, opts)
+	if err != nil {
+		return jsError("Failed to render template: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal render result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// ExportBundle bundles a project with the entry point, values and options to render, for sharing
+// a reproducible session
+// Arguments: project JSON {"templates": {name: content, ...}}, entry point name, variables
+// (JSON or YAML, optional), render options JSON (optional)
+// Returns the bundle JSON {format, version, profile, entry, templates, variables, values, options}
+func (h *WASMHandler) ExportBundle(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing project or entry point parameter")
+	}
+
+	project, err := h.projectArg(args[0])
+	if err != nil {
+		return jsError("Failed to parse project: " + err.Error())
+	}
+	variables := map[string]interface{}{}
+	if len(args) > 2 && args[2].Type() == js.TypeString {
+		if variables, err = ParseValues(args[2].String()); err != nil {
+			return jsError(valuesErrorMessage(args[2].String(), err))
+		}
+	}
+	var opts RenderOptions
+	if len(args) > 3 && args[3].Type() == js.TypeString && args[3].String() != "" {
+		if err := json.Unmarshal([]byte(args[3].String()), &opts); err != nil {
+			return jsError("Failed to parse render options JSON: " + err.Error())
+		}
+	}
+
+	bundle, err := project.ExportBundle(args[1].String(), variables, opts)
+	if err != nil {
+		return jsError("Failed to export bundle: " + err.Error())
+	}
+
+	jsonData, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return jsError("Failed to marshal bundle to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// ImportBundle reads a bundle written by exportBundle and renders its entry point
+// Argument: bundle JSON
+// Returns JSON {bundle, result, renderError}: the bundle to restore the session from and the
+// RenderResult of its entry point, or the render error
+func (h *WASMHandler) ImportBundle(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing bundle parameter")
+	}
+
+	bundle, err := ParseBundle([]byte(args[0].String()))
+	if err != nil {
+		return jsError("Failed to import bundle: " + err.Error())
+	}
+	project, err := ImportBundle(h.parser, h.renderer, bundle)
+	if err != nil {
+		return jsError("Failed to import bundle: " + err.Error())
+	}
+
+	imported := map[string]interface{}{"bundle": bundle}
+	result, err := bundle.Render(project)
+	if err != nil {
+		imported["renderError"] = err.Error()
+	} else {
+		imported["result"] = result
+	}
+
+	jsonData, err := json.Marshal(imported)
+	if err != nil {
+		return jsError("Failed to marshal bundle to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// projectArg builds a Project from a project JSON argument
+func (h *WASMHandler) projectArg(arg js.Value) (*Project, error) {
+	var files struct {
+		Templates map[string]string `json:"templates"`
+	}
+	if err := json.Unmarshal([]byte(arg.String()), &files); err != nil {
+		return nil, fmt.Errorf("invalid project JSON: %v", err)
+	}
+
+	project := NewProject(h.parser, h.renderer)
+	for name, content := range files.Templates {
+		project.AddTemplate(name, content)
+	}
+	return project, nil
+}
+
+// CompareRender renders a template under two engine configurations and returns a diff
+// Arguments: template content, variables JSON, left side JSON, right side JSON
+// Each side is {"profile": "official"|"<active profile>", "options": {...RenderOptions}}
+func (h *WASMHandler) CompareRender(this js.Value, args []js.Value) interface{} {
+	if len(args) < 4 {
+		return jsError("Missing template content, variables or comparison sides parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+
+	variables, err := ParseValues(args[1].String())
+	if err != nil {
+		return jsError(valuesErrorMessage(args[1].String(), err))
+	}
+
+	var left, right CompareSide
+	if err := json.Unmarshal([]byte(args[2].String()), &left); err != nil {
+		return jsError("Failed to parse left side JSON: " + err.Error())
+	}
+	if err := json.Unmarshal([]byte(args[3].String()), &right); err != nil {
+		return jsError("Failed to parse right side JSON: " + err.Error())
+	}
+
+	result, err := NewComparer(h.renderer).Compare(templateContent, variables, left, right)
+	if err != nil {
+		return jsError("Failed to compare renders: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal comparison to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// SetRenderClock sets the reference time (Unix milliseconds) used by datetime
+// Calling it without arguments, or with null/undefined, restores the host clock
+func (h *WASMHandler) SetRenderClock(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsNull() || args[0].IsUndefined() {
+		h.env.ResetRenderClock()
+		return js.ValueOf(true)
+	}
+	if args[0].Type() != js.TypeNumber {
+		return jsError("Render clock must be a Unix timestamp in milliseconds")
+	}
+
+	h.env.SetRenderClock(int64(args[0].Float()))
+	return js.ValueOf(true)
+}
+
+// SetResolver sets how lookupIP and lookupSRV resolve names, as WASM has no DNS access
+// Argument: a resolver function (see jsResolver), a fixtures JSON string
+// {"ip": {host: [addresses]}, "srv": {"_service._proto.name": [{target, port, priority, weight}]}},
+// or nothing/null/undefined to restore the empty default, under which every lookup fails
+func (h *WASMHandler) SetResolver(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsNull() || args[0].IsUndefined() {
+		h.env.ResetRenderResolver()
+		return js.ValueOf(true)
+	}
+
+	switch args[0].Type() {
+	case js.TypeFunction:
+		h.env.SetRenderResolver(jsResolver{fn: args[0]})
+	case js.TypeString:
+		var fixtures FixtureResolver
+		if err := json.Unmarshal([]byte(args[0].String()), &fixtures); err != nil {
+			return jsError("Failed to parse resolver fixtures JSON: " + err.Error())
+		}
+		h.env.SetRenderResolver(&fixtures)
+	default:
+		return jsError("Resolver must be a function or a fixtures JSON string")
+	}
+	return js.ValueOf(true)
+}
+
+// SetVirtualFS sets the files seen by fileExists, as WASM has no filesystem access
+// Argument: files JSON {"/etc/app/tls.crt": "contents", ...}, or nothing/null/undefined to
+// restore the empty default, in which no file exists
+func (h *WASMHandler) SetVirtualFS(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsNull() || args[0].IsUndefined() {
+		h.env.ResetRenderFS()
+		return js.ValueOf(true)
+	}
+
+	var files map[string]string
+	if err := json.Unmarshal([]byte(args[0].String()), &files); err != nil {
+		return jsError("Failed to parse virtual filesystem JSON: " + err.Error())
+	}
+	h.env.SetRenderFS(NewVirtualFS(files))
+	return js.ValueOf(true)
+}
+
+// SetTemplateIncludes registers template files for {{template "partials/header.tmpl" .}}
+// Argument: files JSON {"partials/header.tmpl": "contents", ...}, or nothing/null/undefined
+// to clear them; names not registered are then read from the virtual filesystem
+func (h *WASMHandler) SetTemplateIncludes(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsNull() || args[0].IsUndefined() {
+		h.env.SetTemplateIncludes(nil)
+		return js.ValueOf(true)
+	}
+
+	var files map[string]string
+	if err := json.Unmarshal([]byte(args[0].String()), &files); err != nil {
+		return jsError("Failed to parse template includes JSON: " + err.Error())
+	}
+	h.env.SetTemplateIncludes(files)
+	return js.ValueOf(true)
+}
+
+// SetValueResolver registers a function asked for keys getv, get and exists miss in the values
+// (see jsValueResolver), so large value sets can be fetched on demand; answers are cached
+// Arguments: resolver function, or nothing/null/undefined to remove it; lookup budget per render
+// (optional, 0 or absent for no limit)
+func (h *WASMHandler) SetValueResolver(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].IsNull() || args[0].IsUndefined() {
+		h.env.SetValueResolver(nil, 0)
+		return js.ValueOf(true)
+	}
+	if args[0].Type() != js.TypeFunction {
+		return jsError("Value resolver must be a function")
+	}
+
+	budget := 0
+	if len(args) > 1 && args[1].Type() == js.TypeNumber {
+		budget = args[1].Int()
+	}
+	h.env.SetValueResolver(jsValueResolver{fn: args[0]}, budget)
+	return js.ValueOf(true)
+}
+
+// ClearValueResolverCache forgets the cached answers of the value resolver
+func (h *WASMHandler) ClearValueResolverCache(this js.Value, args []js.Value) interface{} {
+	h.env.ClearValueResolverCache()
+	return js.Null()
+}
+
+// LintConfdResources cross-checks confd template resources against their templates
+// Argument: files JSON {"conf.d/nginx.toml": "...", "templates/nginx.conf.tmpl": "...", ...}
+// Returns JSON [{resource: {file, src, dest, keys, prefix, checkCmd, reloadCmd, ...}, template,
+// undeclaredKeys, unusedKeys, errors}] sorted by resource file
+func (h *WASMHandler) LintConfdResources(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing files parameter")
+	}
+
+	var files map[string]string
+	if err := json.Unmarshal([]byte(args[0].String()), &files); err != nil {
+		return jsError("Failed to parse files JSON: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(h.parser.LintConfdFiles(files))
+	if err != nil {
+		return jsError("Failed to marshal confd reports to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RegisterJSFunction makes a JavaScript function available to templates, for parsing, extraction
+// and rendering; see jsExternalFunc for how values cross over
+// Arguments: function name, arity (number of arguments, -1 for any number), function
+func (h *WASMHandler) RegisterJSFunction(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return jsError("Missing function name, arity or function parameter")
+	}
+	if args[1].Type() != js.TypeNumber {
+		return jsError("Arity must be a number")
+	}
+	if args[2].Type() != js.TypeFunction {
+		return jsError("Function must be a function")
+	}
+
+	if err := h.parser.registry.RegisterExternalFunction(args[0].String(), args[1].Int(), jsExternalFunc(args[2])); err != nil {
+		return jsError("Failed to register function: " + err.Error())
+	}
+	return js.ValueOf(true)
+}
+
+// ListExamples returns the embedded example templates for the active function profile
+func (h *WASMHandler) ListExamples(this js.Value, args []js.Value) interface{} {
+	examples, err := ListExamples(h.parser.registry.Profile())
+	if err != nil {
+		return jsError("Failed to list examples: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(examples)
+	if err != nil {
+		return jsError("Failed to marshal examples to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// GetExample returns a single embedded example template with its sample values
+func (h *WASMHandler) GetExample(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing example name parameter")
+	}
+
+	example, err := GetExample(h.parser.registry.Profile(), args[0].String())
+	if err != nil {
+		return jsError("Failed to get example: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(example)
+	if err != nil {
+		return jsError("Failed to marshal example to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// ReviewTemplate returns a scored review summary with metrics and actionable findings
+func (h *WASMHandler) ReviewTemplate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing template content parameter")
+	}
+
+	templateContent, err := h.templateArg(args[0])
+	if err != nil {
+		return jsError("Invalid template content: " + err.Error())
+	}
+	fileName := "template.tmpl"
+	if len(args) > 1 {
+		fileName = args[1].String()
+	}
+
+	summary, err := h.parser.Review(fileName, templateContent)
+	if err != nil {
+		return jsError("Failed to review template: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(summary)
+	if err != nil {
+		return jsError("Failed to marshal review to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// tutorialEngine lazily loads the embedded tutorial lessons
+func (h *WASMHandler) tutorialEngine() (*TutorialEngine, error) {
+	if h.tutorial == nil {
+		engine, err := NewTutorialEngine(h.parser, h.renderer)
+		if err != nil {
+			return nil, err
+		}
+		h.tutorial = engine
+	}
+	return h.tutorial, nil
+}
+
+// ListLessons returns the tutorial lessons available for the active function profile
+func (h *WASMHandler) ListLessons(this js.Value, args []js.Value) interface{} {
+	engine, err := h.tutorialEngine()
+	if err != nil {
+		return jsError("Failed to load lessons: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(engine.ListLessons())
+	if err != nil {
+		return jsError("Failed to marshal lessons to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// GetLesson returns a tutorial lesson by id
+func (h *WASMHandler) GetLesson(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return jsError("Missing lesson id parameter")
+	}
+
+	engine, err := h.tutorialEngine()
+	if err != nil {
+		return jsError("Failed to load lessons: " + err.Error())
+	}
+
+	lesson, err := engine.GetLesson(args[0].String())
+	if err != nil {
+		return jsError("Failed to get lesson: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(lesson)
+	if err != nil {
+		return jsError("Failed to marshal lesson to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// CheckLesson validates the user's template against a lesson and returns pass/fail with hints
+// Arguments: lesson id, template content
+func (h *WASMHandler) CheckLesson(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return jsError("Missing lesson id or template content parameter")
+	}
+
+	engine, err := h.tutorialEngine()
+	if err != nil {
+		return jsError("Failed to load lessons: " + err.Error())
+	}
+
+	result, err := engine.Check(args[0].String(), args[1].String())
+	if err != nil {
+		return jsError("Failed to check lesson: " + err.Error())
+	}
+
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return jsError("Failed to marshal lesson result to JSON: " + err.Error())
+	}
+
+	return js.ValueOf(string(jsonData))
+}
+
+// RegisterCallbacks registers the Go functions to be called from JavaScript
+func (h *WASMHandler) RegisterCallbacks() {
+	js.Global().Set("extractTemplateVariables", js.FuncOf(h.ExtractVariables))
+	js.Global().Set("extractTemplateVariablesSimple", js.FuncOf(h.ExtractVariablesSimple))
+	js.Global().Set("extractTemplateVariablesAggregated", js.FuncOf(h.ExtractVariablesAggregated))
+	js.Global().Set("extractTemplateMetadata", js.FuncOf(h.ExtractMetadata))
+	js.Global().Set("auditTemplateSecrets", js.FuncOf(h.AuditSecrets))
+	js.Global().Set("analyzeTrimMarkers", js.FuncOf(h.AnalyzeTrimMarkers))
+	js.Global().Set("formatTemplate", js.FuncOf(h.FormatTemplate))
+	js.Global().Set("getCompletions", js.FuncOf(h.GetCompletions))
+	js.Global().Set("findDefinition", js.FuncOf(h.FindDefinition))
+	js.Global().Set("getFoldingRanges", js.FuncOf(h.GetFoldingRanges))
+	js.Global().Set("generateEditorGrammar", js.FuncOf(h.GenerateEditorGrammar))
+	js.Global().Set("getFunctionSnippets", js.FuncOf(h.GetFunctionSnippets))
+	js.Global().Set("analyzeProfileDivergence", js.FuncOf(h.AnalyzeProfileDivergence))
+	js.Global().Set("lintTemplate", js.FuncOf(h.LintTemplate))
+	js.Global().Set("analyzeTemplate", js.FuncOf(h.AnalyzeTemplate))
+	js.Global().Set("templatizeConfig", js.FuncOf(h.Templatize))
+	js.Global().Set("lintVariableNames", js.FuncOf(h.LintVariableNames))
+	js.Global().Set("fixVariableNames", js.FuncOf(h.FixVariableNames))
+	js.Global().Set("renameKey", js.FuncOf(h.RenameKey))
+	js.Global().Set("extractTemplateKeyTree", js.FuncOf(h.ExtractKeyTree))
+	js.Global().Set("startProfiling", js.FuncOf(h.StartProfiling))
+	js.Global().Set("stopProfiling", js.FuncOf(h.StopProfiling))
+	js.Global().Set("anonymizeTemplate", js.FuncOf(h.AnonymizeTemplate))
+	js.Global().Set("generateTypes", js.FuncOf(h.GenerateTypes))
+	js.Global().Set("generateFormSchema", js.FuncOf(h.GenerateFormSchema))
+	js.Global().Set("generateSampleValues", js.FuncOf(h.GenerateSampleValues))
+	js.Global().Set("validateValues", js.FuncOf(h.ValidateValues))
+	js.Global().Set("renderTemplateWithValues", js.FuncOf(h.RenderTemplate))
+	js.Global().Set("renderTemplateWithOptions", js.FuncOf(h.RenderTemplateWithOptions))
+	js.Global().Set("mergeValues", js.FuncOf(h.MergeValues))
+	js.Global().Set("saveValueProfile", js.FuncOf(h.SaveValueProfile))
+	js.Global().Set("loadValueProfile", js.FuncOf(h.LoadValueProfile))
+	js.Global().Set("deleteValueProfile", js.FuncOf(h.DeleteValueProfile))
+	js.Global().Set("listValueProfiles", js.FuncOf(h.ListValueProfiles))
+	js.Global().Set("valueProfileCoverage", js.FuncOf(h.ValueProfileCoverage))
+	js.Global().Set("exportValueProfiles", js.FuncOf(h.ExportValueProfiles))
+	js.Global().Set("importValueProfiles", js.FuncOf(h.ImportValueProfiles))
+	js.Global().Set("recordSnapshot", js.FuncOf(h.RecordSnapshot))
+	js.Global().Set("checkSnapshot", js.FuncOf(h.CheckSnapshot))
+	js.Global().Set("inferValues", js.FuncOf(h.InferValues))
+	js.Global().Set("mergeVariables", js.FuncOf(h.MergeVariables))
+	js.Global().Set("renderEnvironments", js.FuncOf(h.RenderEnvironments))
+	js.Global().Set("renderMatrix", js.FuncOf(h.RenderMatrix))
+	js.Global().Set("buildArchive", js.FuncOf(h.BuildArchive))
+	js.Global().Set("exportBundle", js.FuncOf(h.ExportBundle))
+	js.Global().Set("importBundle", js.FuncOf(h.ImportBundle))
+	js.Global().Set("evalExpression", js.FuncOf(h.EvalExpression))
+	js.Global().Set("createRenderSession", js.FuncOf(h.CreateRenderSession))
+	js.Global().Set("updateRenderSession", js.FuncOf(h.UpdateRenderSession))
+	js.Global().Set("closeRenderSession", js.FuncOf(h.CloseRenderSession))
+	js.Global().Set("registerLiveTemplate", js.FuncOf(h.RegisterLiveTemplate))
+	js.Global().Set("updateVariables", js.FuncOf(h.UpdateVariables))
+	js.Global().Set("unregisterLiveTemplate", js.FuncOf(h.UnregisterLiveTemplate))
+	js.Global().Set("compareRender", js.FuncOf(h.CompareRender))
+	js.Global().Set("extractProjectVariables", js.FuncOf(h.ExtractProjectVariables))
+	js.Global().Set("renderProject", js.FuncOf(h.RenderProject))
+	js.Global().Set("generateChangelog", js.FuncOf(h.GenerateChangelog))
+	js.Global().Set("setRenderClock", js.FuncOf(h.SetRenderClock))
+	js.Global().Set("setResolver", js.FuncOf(h.SetResolver))
+	js.Global().Set("setValueResolver", js.FuncOf(h.SetValueResolver))
+	js.Global().Set("clearValueResolverCache", js.FuncOf(h.ClearValueResolverCache))
+	js.Global().Set("setVirtualFS", js.FuncOf(h.SetVirtualFS))
+	js.Global().Set("setTemplateIncludes", js.FuncOf(h.SetTemplateIncludes))
+	js.Global().Set("lintConfdResources", js.FuncOf(h.LintConfdResources))
+	js.Global().Set("registerJSFunction", js.FuncOf(h.RegisterJSFunction))
+	js.Global().Set("listExamples", js.FuncOf(h.ListExamples))
+	js.Global().Set("getExample", js.FuncOf(h.GetExample))
+	js.Global().Set("reviewTemplate", js.FuncOf(h.ReviewTemplate))
+	js.Global().Set("listLessons", js.FuncOf(h.ListLessons))
+	js.Global().Set("getLesson", js.FuncOf(h.GetLesson))
+	js.Global().Set("checkLesson", js.FuncOf(h.CheckLesson))
+	js.Global().Set("beginTemplateUpload", js.FuncOf(h.BeginTemplateUpload))
+	js.Global().Set("appendTemplateChunk", js.FuncOf(h.AppendTemplateChunk))
+	js.Global().Set("abortTemplateUpload", js.FuncOf(h.AbortTemplateUpload))
+}
+
+// BeginTemplateUpload starts a chunked template upload and returns its handle
+// Arguments: expected size in bytes (optional)
+// Large templates are sent with appendTemplateChunk and then passed as the handle in place of
+// the template string to any function taking template content; the handle is consumed by that call
+func (h *WASMHandler) BeginTemplateUpload(this js.Value, args []js.Value) interface{} {
+	sizeHint := 0
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		sizeHint = args[0].Int()
+	}
+	return js.ValueOf(h.uploads.Begin(sizeHint))
+}
+
+// AppendTemplateChunk appends a chunk (string or Uint8Array) to a template upload
+// Arguments: upload handle, chunk
+func (h *WASMHandler) AppendTemplateChunk(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[0].Type() != js.TypeNumber {
+		return jsError("Missing upload handle or chunk parameter")
+	}
+
+	chunk, err := bytesArg(args[1])
+	if err != nil {
+		return jsError("Invalid template chunk: " + err.Error())
+	}
+	if err := h.uploads.Append(args[0].Int(), chunk); err != nil {
+		return jsError("Failed to append template chunk: " + err.Error())
+	}
+	return js.Null()
+}
+
+// AbortTemplateUpload discards a template upload that will not be used
+func (h *WASMHandler) AbortTemplateUpload(this js.Value, args []js.Value) interface{} {
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		h.uploads.Abort(args[0].Int())
+	}
+	return js.Null()
+}
+
+// StartProfiling starts recording pprof profiles of the engine
+// Arguments: options JSON (optional) {"cpu": bool, "memProfileRate": n}
+func (h *WASMHandler) StartProfiling(this js.Value, args []js.Value) interface{} {
+	var opts ProfileOptions
+	if len(args) > 0 && args[0].Type() == js.TypeString && args[0].String() != "" {
+		if err := json.Unmarshal([]byte(args[0].String()), &opts); err != nil {
+			return jsError("Failed to parse profile options JSON: " + err.Error())
+		}
+	}
+	if err := StartProfiling(opts); err != nil {
+		return jsError("Failed to start profiling: " + err.Error())
+	}
+	return js.Null()
+}
+
+// StopProfiling ends the profiling session
+// Returns {cpu, allocs, allocsBase}, each a Uint8Array of pprof data (cpu is null unless requested)
+func (h *WASMHandler) StopProfiling(this js.Value, args []js.Value) interface{} {
+	data, err := StopProfiling()
+	if err != nil {
+		return jsError("Failed to stop profiling: " + err.Error())
+	}
+	cpu := js.Null()
+	if data.CPU != nil {
+		cpu = bytesToJS(data.CPU)
+	}
+	return map[string]interface{}{
+		"cpu":        cpu,
+		"allocs":     bytesToJS(data.Allocs),
+		"allocsBase": bytesToJS(data.AllocsBase),
+	}
+}
+
+// templateArg reads template content given as a string, a Uint8Array of UTF-8 bytes,
+// or the handle of a completed chunked upload
+func (h *WASMHandler) templateArg(arg js.Value) (string, error) {
+	switch arg.Type() {
+	case js.TypeString:
+		return arg.String(), nil
+	case js.TypeNumber:
+		return h.uploads.Take(arg.Int())
+	}
+
+	content, err := bytesArg(arg)
+	if err != nil {
+		return "", err
+	}
+	if len(content) == 0 {
+		return "", nil
+	}
+	// The bytes were copied out of JavaScript and are not referenced elsewhere
+	return unsafe.String(&content[0], len(content)), nil
+}
+
+// bytesArg copies a string or Uint8Array argument into Go memory
+func bytesArg(arg js.Value) ([]byte, error) {
+	if arg.Type() == js.TypeString {
+		return []byte(arg.String()), nil
+	}
+	if arg.Type() != js.TypeObject || !arg.InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, errors.New("expected a string, Uint8Array or upload handle")
+	}
+	buf := make([]byte, arg.Get("length").Int())
+	js.CopyBytesToGo(buf, arg)
+	return buf, nil
+}
+
+// schemaVersionArg reads an optional schema version argument, defaulting to DefaultSchemaVersion
+func schemaVersionArg(args []js.Value, index int) (int, error) {
+	if len(args) <= index || args[index].IsUndefined() || args[index].IsNull() {
+		return DefaultSchemaVersion, nil
+	}
+	if args[index].Type() != js.TypeNumber {
+		return 0, errors.New("schema version must be a number")
+	}
+	version := args[index].Int()
+	if version < SchemaVersionV1 || version > LatestSchemaVersion {
+		return 0, fmt.Errorf("unsupported schema version %d", version)
+	}
+	return version, nil
+}
+
+// bytesToJS copies data into a new Uint8Array
+func bytesToJS(data []byte) js.Value {
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+	return array
 }
 
 // jsError creates a JavaScript error object
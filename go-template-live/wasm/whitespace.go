@@ -0,0 +1,54 @@
+package main
+
+import "strings"
+
+// cleanWhitespace strips trailing spaces and tabs from every line of output and collapses runs
+// of blank lines into one, the artifacts left by {{if}} and {{range}} blocks without trim markers
+// Lines from a line starting (after indentation) with one of fences up to the next line starting
+// with the same fence are kept as rendered, such as ``` code blocks whose whitespace matters
+// Line endings, \r\n included, are kept
+func cleanWhitespace(output string, fences []string) string {
+	if output == "" {
+		return output
+	}
+	finalNewline := strings.HasSuffix(output, "\n")
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+
+	cleaned := make([]string, 0, len(lines))
+	fence := ""
+	previousBlank := false
+	for _, line := range lines {
+		if fence != "" {
+			cleaned = append(cleaned, line)
+			if strings.HasPrefix(strings.TrimLeft(line, " \t"), fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		body := strings.TrimSuffix(line, "\r")
+		cr := line[len(body):]
+		body = strings.TrimRight(body, " \t")
+		blank := body == ""
+		if blank && previousBlank {
+			continue
+		}
+		previousBlank = blank
+		cleaned = append(cleaned, body+cr)
+
+		indented := strings.TrimLeft(body, " \t")
+		for _, marker := range fences {
+			if marker != "" && strings.HasPrefix(indented, marker) {
+				fence = marker
+				previousBlank = false
+				break
+			}
+		}
+	}
+
+	result := strings.Join(cleaned, "\n")
+	if finalNewline {
+		result += "\n"
+	}
+	return result
+}
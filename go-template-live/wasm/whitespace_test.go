@@ -0,0 +1,43 @@
+//go:build !js
+// +build !js
+
+package main
+
+import "testing"
+
+func TestCleanWhitespace(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		fences []string
+		want   string
+	}{
+		{"trailing whitespace", "a  \nb\t\n", nil, "a\nb\n"},
+		{"blank runs", "a\n\n  \n\nb\n\n\n", nil, "a\n\nb\n\n"},
+		{"crlf kept", "a \r\n\r\n\r\nb\r\n", nil, "a\r\n\r\nb\r\n"},
+		{"fenced region", "text  \n```\ncode  \n\n\n```\n\n\nend", []string{"```"}, "text\n```\ncode  \n\n\n```\n\nend"},
+		{"unclosed fence", "a\n  ~~~\n  x  \n\n\n", []string{"~~~"}, "a\n  ~~~\n  x  \n\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanWhitespace(tt.output, tt.fences); got != tt.want {
+				t.Errorf("cleanWhitespace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_CleanWhitespace(t *testing.T) {
+	renderer := NewRenderer(NewFunctionRegistry(), nil)
+	template := "servers:\n{{range .Servers}}\n  - {{.}}   \n{{end}}\ndone\n"
+
+	result, err := renderer.Render(template, map[string]interface{}{"Servers": []interface{}{"a", "b"}}, RenderOptions{CleanWhitespace: true})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := "servers:\n\n  - a\n\n  - b\n\ndone\n"
+	if result.Output != want {
+		t.Errorf("Render() = %q, want %q", result.Output, want)
+	}
+}
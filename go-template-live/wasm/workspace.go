@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// workspaceIndexVersion is the format version of saved workspace indexes
+// Version 2 added template metadata, version 3 the sensitive flag and key paths of variables,
+// version 4 wildcard key patterns, version 5 the fields read from chained expressions
+const workspaceIndexVersion = 5
+
+// VariableUsage is one occurrence of a variable in a workspace file
+type VariableUsage struct {
+	File     string    `json:"file"`
+	Name     string    `json:"name"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// DefaultConflict is a variable given different default values in different templates
+type DefaultConflict struct {
+	Name     string              `json:"name"`
+	Defaults []DefaultDefinition `json:"defaults"`
+}
+
+// DefaultDefinition is one default value of a variable and every place it is declared
+type DefaultDefinition struct {
+	Value     string          `json:"value"`
+	Locations []VariableUsage `json:"locations"`
+}
+
+// indexedFile is the extraction result of one file, keyed by a hash of the content it came from
+type indexedFile struct {
+	Hash      string            `json:"hash"`
+	Variables []VariableInfo    `json:"variables"`
+	Metadata  *TemplateMetadata `json:"metadata"`
+}
+
+// WorkspaceQuery selects workspace files; empty fields match every file
+type WorkspaceQuery struct {
+	Owner string `json:"owner,omitempty"`
+	Team  string `json:"team,omitempty"`
+	Tag   string `json:"tag,omitempty"`
+	// Variable selects files using the variable
+	Variable string `json:"variable,omitempty"`
+}
+
+// workspaceSnapshot is the saved form of a WorkspaceIndex
+type workspaceSnapshot struct {
+	Version int                     `json:"version"`
+	Profile string                  `json:"profile"`
+	Files   map[string]*indexedFile `json:"files"`
+}
+
+// WorkspaceIndex keeps the variables of every template in a workspace, for editor features
+// such as finding all usages of a variable across files
+// Files are re-extracted only when their content changes; the index can be saved and loaded so
+// a restarted editor does not have to re-parse an unchanged workspace. It is safe for concurrent use
+type WorkspaceIndex struct {
+	parser *Parser
+
+	mu    sync.RWMutex
+	files map[string]*indexedFile
+	// usages maps a variable name to the files using it
+	usages map[string]map[string]struct{}
+}
+
+// NewWorkspaceIndex creates an empty index extracting with parser
+func NewWorkspaceIndex(parser *Parser) *WorkspaceIndex {
+	return &WorkspaceIndex{
+		parser: parser,
+		files:  make(map[string]*indexedFile),
+		usages: make(map[string]map[string]struct{}),
+	}
+}
+
+// LoadWorkspaceIndex restores an index written by Save
+// Indexes saved by another format version or function profile are discarded, returning an empty index
+func LoadWorkspaceIndex(parser *Parser, r io.Reader) (*WorkspaceIndex, error) {
+	var snapshot workspaceSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to read workspace index: %w", err)
+	}
+
+	index := NewWorkspaceIndex(parser)
+	if snapshot.Version != workspaceIndexVersion || snapshot.Profile != parser.registry.Profile() {
+		return index, nil
+	}
+	for file, entry := range snapshot.Files {
+		if entry == nil {
+			continue
+		}
+		index.files[file] = entry
+		index.addUsages(file, entry.Variables)
+	}
+	return index, nil
+}
+
+// Save writes the index so it can be restored with LoadWorkspaceIndex
+func (w *WorkspaceIndex) Save(out io.Writer) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return json.NewEncoder(out).Encode(workspaceSnapshot{
+		Version: workspaceIndexVersion,
+		Profile: w.parser.registry.Profile(),
+		Files:   w.files,
+	})
+}
+
+// Update indexes the content of a file, reporting whether it had to be re-extracted
+// On an extraction error the previous entry of the file is kept
+func (w *WorkspaceIndex) Update(file, content string) (bool, error) {
+	hash := templateHash(content)
+	w.mu.RLock()
+	entry, ok := w.files[file]
+	w.mu.RUnlock()
+	if ok && entry.Hash == hash {
+		return false, nil
+	}
+
+	variables, err := w.parser.ExtractVariablesWithPositions(file, content)
+	if err != nil {
+		return false, err
+	}
+	metadata, err := w.parser.ExtractMetadata(file, content)
+	if err != nil {
+		return false, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeLocked(file)
+	w.files[file] = &indexedFile{Hash: hash, Variables: variables, Metadata: metadata}
+	w.addUsages(file, variables)
+	return true, nil
+}
+
+// Remove drops a file from the index
+func (w *WorkspaceIndex) Remove(file string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeLocked(file)
+}
+
+// Files returns the indexed file names, sorted
+func (w *WorkspaceIndex) Files() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	files := make([]string, 0, len(w.files))
+	for file := range w.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Variables returns the occurrences of every variable in a file, in document order
+func (w *WorkspaceIndex) Variables(file string) ([]VariableInfo, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entry, ok := w.files[file]
+	if !ok {
+		return nil, false
+	}
+	return entry.Variables, true
+}
+
+// Metadata returns the ownership annotations of a file
+func (w *WorkspaceIndex) Metadata(file string) (*TemplateMetadata, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	entry, ok := w.files[file]
+	if !ok {
+		return nil, false
+	}
+	return entry.Metadata, true
+}
+
+// Find returns the files matching every field of query, sorted, e.g. all templates owned by
+// team-payments that use /db/primary
+func (w *WorkspaceIndex) Find(query WorkspaceQuery) []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	files := []string{}
+	for file, entry := range w.files {
+		if query.Variable != "" {
+			if _, ok := w.usages[query.Variable][file]; !ok {
+				continue
+			}
+		}
+		metadata := entry.Metadata
+		if metadata == nil {
+			metadata = &TemplateMetadata{}
+		}
+		if (query.Owner != "" && metadata.Owner != query.Owner) ||
+			(query.Team != "" && metadata.Team != query.Team) ||
+			(query.Tag != "" && !metadata.HasTag(query.Tag)) {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// VariableNames returns every variable used anywhere in the workspace, sorted
+func (w *WorkspaceIndex) VariableNames() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	names := make([]string, 0, len(w.usages))
+	for name := range w.usages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Usages returns every occurrence of a variable across the workspace, sorted by file and offset
+func (w *WorkspaceIndex) Usages(name string) []VariableUsage {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	files := make([]string, 0, len(w.usages[name]))
+	for file := range w.usages[name] {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var usages []VariableUsage
+	for _, file := range files {
+		for _, v := range w.files[file].Variables {
+			if v.Name == name {
+				usages = append(usages, VariableUsage{File: file, Name: name, Position: v.Position})
+			}
+		}
+	}
+	return usages
+}
+
+// DefaultConflicts reports variables whose defaults differ between templates, sorted by name
+// Templates that silently fall back to different values for the same key diverge once the key
+// is unset; differing defaults within a single file are left to Review
+func (w *WorkspaceIndex) DefaultConflicts() []DefaultConflict {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	files := make([]string, 0, len(w.files))
+	for file := range w.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	// definitions maps a variable name to its default values and their locations, in file order
+	definitions := make(map[string]map[string][]VariableUsage)
+	for _, file := range files {
+		for _, v := range w.files[file].Variables {
+			if v.DefaultValue == "" {
+				continue
+			}
+			values, ok := definitions[v.Name]
+			if !ok {
+				values = make(map[string][]VariableUsage)
+				definitions[v.Name] = values
+			}
+			values[v.DefaultValue] = append(values[v.DefaultValue], VariableUsage{File: file, Name: v.Name, Position: v.Position})
+		}
+	}
+
+	var conflicts []DefaultConflict
+	for name, values := range definitions {
+		if len(values) < 2 || !spansFiles(values) {
+			continue
+		}
+		conflict := DefaultConflict{Name: name, Defaults: make([]DefaultDefinition, 0, len(values))}
+		for value, locations := range values {
+			conflict.Defaults = append(conflict.Defaults, DefaultDefinition{Value: value, Locations: locations})
+		}
+		sort.Slice(conflict.Defaults, func(i, j int) bool { return conflict.Defaults[i].Value < conflict.Defaults[j].Value })
+		conflicts = append(conflicts, conflict)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Name < conflicts[j].Name })
+	return conflicts
+}
+
+// spansFiles reports whether the default values are declared in more than one file
+func spansFiles(values map[string][]VariableUsage) bool {
+	first := ""
+	for _, locations := range values {
+		for _, location := range locations {
+			if first == "" {
+				first = location.File
+			} else if location.File != first {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addUsages records the variables of file in the usage map; the caller holds the write lock
+func (w *WorkspaceIndex) addUsages(file string, variables []VariableInfo) {
+	for _, v := range variables {
+		files, ok := w.usages[v.Name]
+		if !ok {
+			files = make(map[string]struct{})
+			w.usages[v.Name] = files
+		}
+		files[file] = struct{}{}
+	}
+}
+
+// removeLocked drops file and its usages; the caller holds the write lock
+func (w *WorkspaceIndex) removeLocked(file string) {
+	entry, ok := w.files[file]
+	if !ok {
+		return
+	}
+	for _, v := range entry.Variables {
+		if files, ok := w.usages[v.Name]; ok {
+			delete(files, file)
+			if len(files) == 0 {
+				delete(w.usages, v.Name)
+			}
+		}
+	}
+	delete(w.files, file)
+}
@@ -0,0 +1,172 @@
+//go:build !js
+// +build !js
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWorkspaceIndex_Usages(t *testing.T) {
+	index := NewWorkspaceIndex(NewParser(NewFunctionRegistry()))
+	for file, content := range map[string]string{
+		"b.tmpl": "{{.Port}}",
+		"a.tmpl": "{{.Host}}:{{.Port}}\n{{.Port}}",
+	} {
+		if _, err := index.Update(file, content); err != nil {
+			t.Fatalf("Update(%s) error = %v", file, err)
+		}
+	}
+
+	var got []string
+	for _, usage := range index.Usages("Port") {
+		got = append(got, fmt.Sprintf("%s:%d", usage.File, usage.Position.Line))
+	}
+	expected := []string{"a.tmpl:1", "a.tmpl:2", "b.tmpl:1"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Usages() = %v, want %v", got, expected)
+	}
+	if got, expected := index.VariableNames(), []string{"Host", "Port"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("VariableNames() = %v, want %v", got, expected)
+	}
+
+	// Replacing a file drops its old usages
+	if _, err := index.Update("a.tmpl", "{{.Name}}"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, expected := index.VariableNames(), []string{"Name", "Port"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("VariableNames() after update = %v, want %v", got, expected)
+	}
+
+	index.Remove("b.tmpl")
+	if usages := index.Usages("Port"); len(usages) != 0 {
+		t.Errorf("Usages() after Remove = %v, want none", usages)
+	}
+	if got, expected := index.Files(), []string{"a.tmpl"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Files() = %v, want %v", got, expected)
+	}
+}
+
+func TestWorkspaceIndex_UpdateUnchanged(t *testing.T) {
+	index := NewWorkspaceIndex(NewParser(NewFunctionRegistry()))
+	if changed, err := index.Update("a.tmpl", "{{.Host}}"); err != nil || !changed {
+		t.Fatalf("Update() = %v, %v, want true", changed, err)
+	}
+	if changed, err := index.Update("a.tmpl", "{{.Host}}"); err != nil || changed {
+		t.Errorf("Update() with same content = %v, %v, want false", changed, err)
+	}
+
+	// A failed extraction keeps the previous entry
+	if _, err := index.Update("a.tmpl", "{{.Host"); err == nil {
+		t.Error("Update() with invalid template should fail")
+	}
+	if usages := index.Usages("Host"); len(usages) != 1 {
+		t.Errorf("Usages() after failed update = %v, want one usage", usages)
+	}
+}
+
+func TestWorkspaceIndex_SaveLoad(t *testing.T) {
+	parser := NewParser(NewFunctionRegistry())
+	index := NewWorkspaceIndex(parser)
+	if _, err := index.Update("a.tmpl", "{{.Host}} {{.Port}}"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	var saved bytes.Buffer
+	if err := index.Save(&saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadWorkspaceIndex(parser, bytes.NewReader(saved.Bytes()))
+	if err != nil {
+		t.Fatalf("LoadWorkspaceIndex() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Usages("Port"), index.Usages("Port")) {
+		t.Errorf("Usages() after load = %v, want %v", loaded.Usages("Port"), index.Usages("Port"))
+	}
+	if changed, _ := loaded.Update("a.tmpl", "{{.Host}} {{.Port}}"); changed {
+		t.Error("Update() after load re-extracted an unchanged file")
+	}
+
+	// An index saved under another profile is discarded
+	other := NewFunctionRegistry()
+	other.SetProfile(ProfileConfd)
+	stale, err := LoadWorkspaceIndex(NewParser(other), bytes.NewReader(saved.Bytes()))
+	if err != nil {
+		t.Fatalf("LoadWorkspaceIndex() error = %v", err)
+	}
+	if files := stale.Files(); len(files) != 0 {
+		t.Errorf("Files() of index from another profile = %v, want none", files)
+	}
+
+	if _, err := LoadWorkspaceIndex(parser, bytes.NewReader([]byte("{"))); err == nil {
+		t.Error("LoadWorkspaceIndex() with invalid data should fail")
+	}
+}
+
+func TestWorkspaceIndex_DefaultConflicts(t *testing.T) {
+	index := NewWorkspaceIndex(NewParser(NewFunctionRegistry()))
+	for file, content := range map[string]string{
+		"a.tmpl": "{{/* @var Port default=80 */}}{{/* @var Host default=localhost */}}{{.Port}} {{.Host}}",
+		"b.tmpl": "{{/* @var Port default=8080 */}}{{/* @var Host default=localhost */}}{{.Port}} {{.Host}}",
+		"c.tmpl": "{{/* @var Port default=80 */}}{{.Port}}",
+	} {
+		if _, err := index.Update(file, content); err != nil {
+			t.Fatalf("Update(%s) error = %v", file, err)
+		}
+	}
+
+	conflicts := index.DefaultConflicts()
+	if len(conflicts) != 1 || conflicts[0].Name != "Port" {
+		t.Fatalf("DefaultConflicts() = %+v, want a single Port conflict", conflicts)
+	}
+	got := make(map[string][]string)
+	for _, definition := range conflicts[0].Defaults {
+		for _, location := range definition.Locations {
+			got[definition.Value] = append(got[definition.Value], location.File)
+		}
+	}
+	expected := map[string][]string{"80": {"a.tmpl", "c.tmpl"}, "8080": {"b.tmpl"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("DefaultConflicts() locations = %v, want %v", got, expected)
+	}
+
+	index.Remove("b.tmpl")
+	if conflicts := index.DefaultConflicts(); len(conflicts) != 0 {
+		t.Errorf("DefaultConflicts() after Remove = %+v, want none", conflicts)
+	}
+}
+
+func TestWorkspaceIndex_Find(t *testing.T) {
+	index := NewWorkspaceIndex(NewParser(NewFunctionRegistry()))
+	for file, content := range map[string]string{
+		"billing.tmpl":  "{{/* @team team-payments\n@tags billing, critical */}}{{.DB}}",
+		"refunds.tmpl":  "{{/* @team team-payments @owner alice */}}{{.Queue}}",
+		"frontend.tmpl": "{{/* @team team-web @tags critical */}}{{.DB}}",
+	} {
+		if _, err := index.Update(file, content); err != nil {
+			t.Fatalf("Update(%s) error = %v", file, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    WorkspaceQuery
+		expected []string
+	}{
+		{"everything", WorkspaceQuery{}, []string{"billing.tmpl", "frontend.tmpl", "refunds.tmpl"}},
+		{"team", WorkspaceQuery{Team: "team-payments"}, []string{"billing.tmpl", "refunds.tmpl"}},
+		{"team and variable", WorkspaceQuery{Team: "team-payments", Variable: "DB"}, []string{"billing.tmpl"}},
+		{"tag", WorkspaceQuery{Tag: "critical"}, []string{"billing.tmpl", "frontend.tmpl"}},
+		{"no match", WorkspaceQuery{Owner: "bob"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := index.Find(tt.query); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Find() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}